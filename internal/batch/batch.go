@@ -0,0 +1,195 @@
+// Package batch reads batch task files and tracks which of their items have completed.
+package batch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// File is a batch task file
+type File struct {
+	Mode        string `yaml:"mode"`          // Default mode for tasks that don't set one
+	Delay       string `yaml:"delay"`         // Pause between items, e.g. 2s, to go easy on the Ollama server
+	StopOnError bool   `yaml:"stop_on_error"` // Stop at the first failed item instead of continuing
+	Tasks       []Task `yaml:"tasks"`
+}
+
+// Task is one entry in a batch file. A task with files runs once per file, with {file}
+// in the prompt replaced by the file's path.
+type Task struct {
+	Name   string   `yaml:"name"`
+	Mode   string   `yaml:"mode"`
+	Prompt string   `yaml:"prompt"`
+	Files  []string `yaml:"files"`
+}
+
+// Item is a single prompt to run
+type Item struct {
+	Task   string `json:"task"`
+	Mode   string `json:"mode"`
+	Prompt string `json:"prompt"`
+}
+
+// Key identifies the item in the progress file
+func (i Item) Key() string {
+	return i.Mode + "\x00" + i.Prompt
+}
+
+// Load reads and validates a batch file
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse batch file %s: %w", path, err)
+	}
+	if len(f.Tasks) == 0 {
+		return nil, fmt.Errorf("batch file %s has no tasks", path)
+	}
+	if f.Mode == "" {
+		f.Mode = "ask"
+	}
+	for i, t := range f.Tasks {
+		if strings.TrimSpace(t.Prompt) == "" {
+			return nil, fmt.Errorf("task %d in %s has an empty prompt", i+1, path)
+		}
+	}
+	if _, err := f.DelayDuration(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// DelayDuration parses the delay between items
+func (f *File) DelayDuration() (time.Duration, error) {
+	if f.Delay == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(f.Delay)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid delay %q: use a duration such as 2s or 500ms", f.Delay)
+	}
+	return d, nil
+}
+
+// Items expands the tasks into the prompts to run, in order
+func (f *File) Items() []Item {
+	var items []Item
+	for i, t := range f.Tasks {
+		mode := t.Mode
+		if mode == "" {
+			mode = f.Mode
+		}
+		name := t.Name
+		if name == "" {
+			name = fmt.Sprintf("task %d", i+1)
+		}
+		if len(t.Files) == 0 {
+			items = append(items, Item{Task: name, Mode: mode, Prompt: t.Prompt})
+			continue
+		}
+		for _, file := range t.Files {
+			prompt := t.Prompt
+			if strings.Contains(prompt, "{file}") {
+				prompt = strings.ReplaceAll(prompt, "{file}", file)
+			} else {
+				prompt += " " + file
+			}
+			items = append(items, Item{Task: name, Mode: mode, Prompt: prompt})
+		}
+	}
+	return items
+}
+
+// Progress records the items of a batch file that completed, so an interrupted batch can
+// pick up where it stopped
+type Progress struct {
+	Done map[string]time.Time `json:"done"`
+
+	path string
+}
+
+// LoadProgress returns the saved progress for the batch file at batchPath
+func LoadProgress(batchPath string) (*Progress, error) {
+	path, err := progressPath(batchPath)
+	if err != nil {
+		return nil, err
+	}
+	p := &Progress{Done: map[string]time.Time{}, path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch progress: %w", err)
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse batch progress: %w", err)
+	}
+	if p.Done == nil {
+		p.Done = map[string]time.Time{}
+	}
+	return p, nil
+}
+
+// IsDone reports whether item completed in an earlier run
+func (p *Progress) IsDone(item Item) bool {
+	_, ok := p.Done[item.Key()]
+	return ok
+}
+
+// MarkDone records item as completed and saves the progress
+func (p *Progress) MarkDone(item Item) error {
+	p.Done[item.Key()] = time.Now()
+	return p.save()
+}
+
+// Reset forgets all completed items
+func (p *Progress) Reset() error {
+	p.Done = map[string]time.Time{}
+	if err := os.Remove(p.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to reset batch progress: %w", err)
+	}
+	return nil
+}
+
+func (p *Progress) save() error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch progress: %w", err)
+	}
+	if err := os.WriteFile(p.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write batch progress: %w", err)
+	}
+	return nil
+}
+
+// progressPath returns the progress file for a batch file, kept in the config dir so
+// task files in the project stay untouched
+func progressPath(batchPath string) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config dir: %w", err)
+	}
+	dir := filepath.Join(configDir, "batch")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create batch dir: %w", err)
+	}
+	abs := batchPath
+	if a, err := filepath.Abs(batchPath); err == nil {
+		abs = a
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".json"), nil
+}
@@ -0,0 +1,83 @@
+package batch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeBatch(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tasks.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_ExpandsFiles(t *testing.T) {
+	path := writeBatch(t, `
+mode: edit
+delay: 1s
+tasks:
+  - name: docs
+    prompt: "Add doc comments to {file}"
+    files: [a.go, b.go]
+  - mode: ask
+    prompt: "Summarize the changes"
+`)
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := f.Items()
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %d: %+v", len(items), items)
+	}
+	if items[1] != (Item{Task: "docs", Mode: "edit", Prompt: "Add doc comments to b.go"}) {
+		t.Fatalf("unexpected item: %+v", items[1])
+	}
+	if items[2].Mode != "ask" || items[2].Task != "task 2" {
+		t.Fatalf("unexpected item: %+v", items[2])
+	}
+}
+
+func TestLoad_RejectsInvalidFiles(t *testing.T) {
+	for _, content := range []string{
+		"tasks: []",
+		"tasks:\n  - prompt: \"\"",
+		"delay: soon\ntasks:\n  - prompt: hi",
+	} {
+		if _, err := Load(writeBatch(t, content)); err == nil {
+			t.Fatalf("expected error for %q", content)
+		}
+	}
+}
+
+func TestProgress_ResumesAndResets(t *testing.T) {
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", t.TempDir())
+	path := writeBatch(t, "tasks:\n  - prompt: hi")
+	item := Item{Mode: "ask", Prompt: "hi"}
+
+	p, err := LoadProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.MarkDone(item); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.IsDone(item) {
+		t.Fatalf("expected item to be done after reload")
+	}
+	if err := reloaded.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := LoadProgress(path); again.IsDone(item) {
+		t.Fatalf("expected progress to be reset")
+	}
+}
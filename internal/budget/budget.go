@@ -0,0 +1,211 @@
+// Package budget keeps track of how many tokens and how much generation time
+// LlamaSidekick uses, per run and per day, and stops requests once a configured limit
+// is reached until the user overrides it.
+package budget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/filelock"
+)
+
+// ErrExceeded means a token or generation time limit is used up
+var ErrExceeded = errors.New("budget used up")
+
+// Usage is an amount of generation
+type Usage struct {
+	Tokens  int     `json:"tokens"`
+	Seconds float64 `json:"seconds"`
+}
+
+// dailyUsage is what the usage file holds: the usage of one day, across all runs
+type dailyUsage struct {
+	Date string `json:"date"`
+	Usage
+}
+
+// Tracker adds up the usage of every request and checks it against the limits. It is
+// safe for concurrent use, so one tracker can be shared by all clients of a run.
+type Tracker struct {
+	Warn func(message string) // Called when usage passes budget.warn_at of a limit; nil logs it
+
+	mu         sync.Mutex
+	limits     config.BudgetConfig
+	path       string // Daily usage file
+	run        Usage
+	warned     map[string]bool
+	overridden bool
+	now        func() time.Time
+}
+
+// New creates a tracker that keeps the daily usage in path
+func New(limits config.BudgetConfig, path string) *Tracker {
+	return &Tracker{limits: limits, path: path, warned: map[string]bool{}, now: time.Now}
+}
+
+// Open creates a tracker that keeps the daily usage in the data dir
+func Open(limits config.BudgetConfig) (*Tracker, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return New(limits, filepath.Join(dataDir, "usage.json")), nil
+}
+
+// SetLimits replaces the limits, e.g. after the config was edited
+func (t *Tracker) SetLimits(limits config.BudgetConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits = limits
+}
+
+// Override lifts the limits for the rest of the run
+func (t *Tracker) Override() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.overridden = true
+}
+
+// limit is one configured limit and how much of it is used
+type limit struct {
+	what string // e.g. "tokens today"
+	key  string
+	used float64
+	max  float64
+}
+
+func (l limit) String() string {
+	if strings.HasPrefix(l.what, "tokens") {
+		return fmt.Sprintf("%d of %d %s", int(l.used), int(l.max), l.what)
+	}
+	return fmt.Sprintf("%.1f of %g %s", l.used, l.max, l.what)
+}
+
+func (t *Tracker) limitsFor(today Usage) []limit {
+	return []limit{
+		{"tokens this run", "budget.session_tokens", float64(t.run.Tokens), float64(t.limits.SessionTokens)},
+		{"generation minutes this run", "budget.session_minutes", t.run.Seconds / 60, t.limits.SessionMinutes},
+		{"tokens today", "budget.daily_tokens", float64(today.Tokens), float64(t.limits.DailyTokens)},
+		{"generation minutes today", "budget.daily_minutes", today.Seconds / 60, t.limits.DailyMinutes},
+	}
+}
+
+// Check returns an error wrapping ErrExceeded if a limit is used up and hasn't been
+// overridden
+func (t *Tracker) Check() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.overridden {
+		return nil
+	}
+	for _, l := range t.limitsFor(t.readToday()) {
+		if l.max > 0 && l.used >= l.max {
+			return fmt.Errorf("%w: %s; continue with /budget override or raise %s", ErrExceeded, l, l.key)
+		}
+	}
+	return nil
+}
+
+// Record adds the usage of a finished request and warns about limits it brought close
+func (t *Tracker) Record(tokens int, elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.run.Tokens += tokens
+	t.run.Seconds += elapsed.Seconds()
+	today, err := t.addToday(Usage{Tokens: tokens, Seconds: elapsed.Seconds()})
+	if err != nil {
+		slog.Warn("failed to record daily usage", "error", err)
+	}
+
+	if t.overridden {
+		return
+	}
+	warnAt := float64(t.limits.WarnAt) / 100
+	for _, l := range t.limitsFor(today) {
+		if l.max <= 0 || l.used < l.max*warnAt || t.warned[l.key] {
+			continue
+		}
+		t.warned[l.key] = true
+		message := fmt.Sprintf("Budget: %s used (%.0f%%)", l, 100*l.used/l.max)
+		if l.used >= l.max {
+			message += "; further requests need /budget override"
+		}
+		if t.Warn != nil {
+			t.Warn(message)
+		} else {
+			slog.Warn(message)
+		}
+	}
+}
+
+// Summary describes the usage of this run and today, against the limits that are set
+func (t *Tracker) Summary() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	today := t.readToday()
+	lines := []string{
+		fmt.Sprintf("This run: %d tokens, %.1f generation minutes", t.run.Tokens, t.run.Seconds/60),
+		fmt.Sprintf("Today: %d tokens, %.1f generation minutes", today.Tokens, today.Seconds/60),
+	}
+	for _, l := range t.limitsFor(today) {
+		if l.max > 0 {
+			lines = append(lines, fmt.Sprintf("Limit: %s (%.0f%%)", l, 100*l.used/l.max))
+		}
+	}
+	if t.overridden {
+		lines = append(lines, "Limits are overridden for the rest of this run")
+	}
+	return lines
+}
+
+// readToday returns today's usage from the usage file
+func (t *Tracker) readToday() Usage {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return Usage{}
+	}
+	var daily dailyUsage
+	if json.Unmarshal(data, &daily) != nil || daily.Date != t.now().Format("2006-01-02") {
+		return Usage{}
+	}
+	return daily.Usage
+}
+
+// addToday adds usage to today's total in the usage file, which other running instances
+// update too, and returns the new total
+func (t *Tracker) addToday(usage Usage) (Usage, error) {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return usage, fmt.Errorf("failed to create data dir: %w", err)
+	}
+	lock, err := filelock.Acquire(t.path+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return usage, err
+	}
+	defer lock.Release()
+
+	today := t.readToday()
+	today.Tokens += usage.Tokens
+	today.Seconds += usage.Seconds
+	data, err := json.MarshalIndent(dailyUsage{Date: t.now().Format("2006-01-02"), Usage: today}, "", "  ")
+	if err != nil {
+		return today, fmt.Errorf("failed to marshal usage: %w", err)
+	}
+	tmpFile := t.path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return today, fmt.Errorf("failed to write usage file: %w", err)
+	}
+	if err := os.Rename(tmpFile, t.path); err != nil {
+		os.Remove(tmpFile)
+		return today, fmt.Errorf("failed to write usage file: %w", err)
+	}
+	return today, nil
+}
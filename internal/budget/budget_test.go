@@ -0,0 +1,50 @@
+package budget
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+func TestTracker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	var warnings []string
+	tracker := New(config.BudgetConfig{SessionTokens: 1000, DailyTokens: 1500, WarnAt: 80}, path)
+	tracker.Warn = func(message string) { warnings = append(warnings, message) }
+
+	tracker.Record(700, time.Second)
+	if err := tracker.Check(); err != nil || len(warnings) != 0 {
+		t.Fatalf("expected no limit below warn_at, got %v, %v", err, warnings)
+	}
+	tracker.Record(150, time.Second)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "850 of 1000 tokens this run used (85%)") {
+		t.Fatalf("expected a warning for the run limit, got %v", warnings)
+	}
+	tracker.Record(200, time.Second)
+	err := tracker.Check()
+	if !errors.Is(err, ErrExceeded) || !strings.Contains(err.Error(), "budget.session_tokens") {
+		t.Fatalf("expected the run limit to be reached, got %v", err)
+	}
+
+	tracker.Override()
+	if err := tracker.Check(); err != nil {
+		t.Fatalf("expected the override to lift the limits, got %v", err)
+	}
+
+	// A second run the same day shares the daily total but starts a fresh run total
+	next := New(config.BudgetConfig{SessionTokens: 1000, DailyTokens: 1500, WarnAt: 80}, path)
+	next.Record(500, time.Second)
+	if err := next.Check(); !errors.Is(err, ErrExceeded) || !strings.Contains(err.Error(), "1550 of 1500 tokens today") {
+		t.Fatalf("expected the daily limit to be reached, got %v", err)
+	}
+
+	// The next day starts from zero
+	next.now = func() time.Time { return time.Now().AddDate(0, 0, 1) }
+	if err := next.Check(); err != nil {
+		t.Fatalf("expected a new day to have its own budget, got %v", err)
+	}
+}
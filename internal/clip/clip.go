@@ -0,0 +1,147 @@
+// Package clip copies text to the clipboard: the system clipboard where there is one,
+// and otherwise the clipboard of the terminal, through the OSC 52 escape sequence, which
+// also reaches the local machine from an SSH session.
+package clip
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/atotto/clipboard"
+	"github.com/yourusername/llamasidekick/internal/hint"
+	"golang.org/x/term"
+)
+
+// maxOSC52Bytes bounds what is sent with OSC 52; many terminals drop longer sequences
+const maxOSC52Bytes = 100000
+
+// MaxTerminalBytes is the most text that fits in one OSC 52 sequence once it is base64
+// encoded, also when it is wrapped for screen; Split cuts longer text into parts that do
+const MaxTerminalBytes = (maxOSC52Bytes - 12) / 4 * 3
+
+// Method is how text was copied
+type Method int
+
+const (
+	System Method = iota // The system clipboard
+	OSC52                // The terminal, through OSC 52
+)
+
+// ErrUnavailable is returned when there is no way to copy text
+var ErrUnavailable = errors.New("no clipboard is available")
+
+// ErrTooLong is returned when text only the terminal could copy is longer than MaxTerminalBytes
+var ErrTooLong = errors.New("too long to copy through the terminal")
+
+// installHint tells how to get a clipboard, and to copy again once there is one
+const installHint = "install xclip, xsel or wl-clipboard (Wayland), or use a terminal that supports OSC 52; then type /copy retry"
+
+// last is the text of the last copy, which Retry copies again
+var last struct {
+	sync.Mutex
+	text string
+	set  bool
+}
+
+// Copy copies text to the clipboard and remembers it for Retry. Over SSH without a
+// forwarded display it goes straight to the terminal, as the remote machine's clipboard
+// isn't the user's.
+func Copy(text string) (Method, error) {
+	last.Lock()
+	last.text, last.set = text, true
+	last.Unlock()
+	return copyText(text, os.Getenv, os.Stdout)
+}
+
+// Retry copies the text of the last copy again, e.g. after installing a clipboard tool
+func Retry() (Method, error) {
+	last.Lock()
+	text, set := last.text, last.set
+	last.Unlock()
+	if !set {
+		return System, errors.New("nothing has been copied yet")
+	}
+	return copyText(text, os.Getenv, os.Stdout)
+}
+
+// Describe returns a note on how text was copied for messages, "" for the system clipboard
+func (m Method) Describe() string {
+	if m == OSC52 {
+		return " (through the terminal with OSC 52; if nothing arrived, the terminal doesn't allow it)"
+	}
+	return ""
+}
+
+// copyText tries the system clipboard, unless it would be a remote machine's, and then
+// out if it is a terminal
+func copyText(text string, getenv func(string) string, out *os.File) (Method, error) {
+	remote := getenv("SSH_CONNECTION") != "" || getenv("SSH_TTY") != ""
+	display := getenv("DISPLAY") != "" || getenv("WAYLAND_DISPLAY") != ""
+	var systemErr error
+	if (!remote || display) && !clipboard.Unsupported {
+		if systemErr = clipboard.WriteAll(text); systemErr == nil {
+			return System, nil
+		}
+	}
+	if !term.IsTerminal(int(out.Fd())) {
+		if systemErr != nil {
+			return System, hint.Wrap(fmt.Errorf("%w (%v)", ErrUnavailable, systemErr), installHint)
+		}
+		return System, hint.Wrap(ErrUnavailable, installHint)
+	}
+	return OSC52, writeOSC52(out, text, getenv)
+}
+
+// writeOSC52 sends text to the terminal's clipboard. tmux handles the sequence itself
+// (with set-clipboard on); inside screen it is wrapped so it is passed on to the terminal.
+func writeOSC52(w io.Writer, text string, getenv func(string) string) error {
+	seq := osc52Sequence(text, getenv)
+	if len(seq) > maxOSC52Bytes {
+		return hint.Wrap(fmt.Errorf("%d bytes are %w", len(text), ErrTooLong), installHint)
+	}
+	if _, err := io.WriteString(w, seq); err != nil {
+		return fmt.Errorf("failed to copy through the terminal: %w", err)
+	}
+	return nil
+}
+
+func osc52Sequence(text string, getenv func(string) string) string {
+	seq := "\033]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if getenv("STY") != "" && getenv("TMUX") == "" {
+		return "\033P" + seq + "\033\\"
+	}
+	return seq
+}
+
+// Split cuts text into parts of at most max bytes, between lines where it can, so text
+// that is too long for the clipboard can be copied a part at a time
+func Split(text string, max int) []string {
+	var parts []string
+	var part strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if part.Len() > 0 && part.Len()+len(line) > max {
+			parts = append(parts, part.String())
+			part.Reset()
+		}
+		for len(line) > max {
+			// A line that doesn't fit on its own is cut, but not inside a character
+			cut := max
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			parts = append(parts, line[:cut])
+			line = line[cut:]
+		}
+		part.WriteString(line)
+	}
+	if part.Len() > 0 {
+		parts = append(parts, part.String())
+	}
+	return parts
+}
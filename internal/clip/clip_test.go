@@ -0,0 +1,77 @@
+package clip
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestOSC52Sequence(t *testing.T) {
+	if got := osc52Sequence("hi", env(nil)); got != "\033]52;c;aGk=\a" {
+		t.Errorf("unexpected sequence %q", got)
+	}
+	if got := osc52Sequence("hi", env(map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"})); got != "\033]52;c;aGk=\a" {
+		t.Errorf("unexpected tmux sequence %q", got)
+	}
+	if got := osc52Sequence("hi", env(map[string]string{"STY": "1.pts-0"})); got != "\033P\033]52;c;aGk=\a\033\\" {
+		t.Errorf("unexpected screen sequence %q", got)
+	}
+}
+
+func TestCopyText_NoTerminalOverSSH(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	// Over SSH without a display the remote clipboard is skipped, and a file isn't a terminal
+	_, err = copyText("hi", env(map[string]string{"SSH_CONNECTION": "10.0.0.1 5000 10.0.0.2 22"}), out)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+	if info, _ := out.Stat(); info.Size() != 0 {
+		t.Errorf("expected nothing written to a non-terminal, got %d bytes", info.Size())
+	}
+}
+
+func TestRetry_NothingCopied(t *testing.T) {
+	if _, err := Retry(); err == nil {
+		t.Fatal("expected an error before anything was copied")
+	}
+}
+
+func TestWriteOSC52_TooLong(t *testing.T) {
+	var out strings.Builder
+	if err := writeOSC52(&out, strings.Repeat("a", MaxTerminalBytes), env(map[string]string{"STY": "1.pts-0"})); err != nil {
+		t.Fatalf("expected MaxTerminalBytes to fit, got %v", err)
+	}
+	if err := writeOSC52(io.Discard, strings.Repeat("a", 2*MaxTerminalBytes), env(nil)); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("expected ErrTooLong, got %v", err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		max  int
+		want []string
+	}{
+		{"short\n", 10, []string{"short\n"}},
+		{"one\ntwo\nthree\n", 8, []string{"one\ntwo\n", "three\n"}},
+		{"abcdefghij\nk", 4, []string{"abcd", "efgh", "ij\nk"}},
+		{"ééé", 3, []string{"é", "é", "é"}},
+	} {
+		got := Split(tc.text, tc.max)
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+			t.Errorf("Split(%q, %d) = %q, want %q", tc.text, tc.max, got, tc.want)
+		}
+	}
+}
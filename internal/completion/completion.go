@@ -0,0 +1,344 @@
+// Package completion generates shell completion scripts for the llamasidekick command.
+package completion
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+// Flag describes a command-line flag for completion
+type Flag struct {
+	Name       string
+	Usage      string
+	TakesValue bool
+}
+
+// Command is a top-level command with its fixed sub-arguments
+type Command struct {
+	Name  string
+	Usage string
+	Args  []string
+}
+
+// Commands lists the top-level commands llamasidekick accepts
+var Commands = []Command{
+	{Name: "ask", Usage: "Run one prompt in Ask mode"},
+	{Name: "plan", Usage: "Run one prompt in Plan mode"},
+	{Name: "edit", Usage: "Run one prompt in Edit mode"},
+	{Name: "agent", Usage: "Run one prompt in Agent mode"},
+	{Name: "cmd", Usage: "Run one prompt in CMD mode"},
+	{Name: "batch", Usage: "Run the prompts in a batch file"},
+	{Name: "eval", Usage: "Check the configured models against golden prompts"},
+	{Name: "hook", Usage: "Manage the git pre-commit review hook", Args: []string{"install", "uninstall"}},
+	{Name: "index", Usage: "Manage the project's semantic index", Args: []string{"build", "status"}},
+	{Name: "serve", Usage: "Start a local HTTP API"},
+	{Name: "rpc", Usage: "Start a JSON-RPC server for editor plugins"},
+	{Name: "config", Usage: "Manage the config file", Args: []string{"edit"}},
+	{Name: "secret", Usage: "Manage secrets in the OS keyring", Args: []string{"set", "delete"}},
+	{Name: "completion", Usage: "Generate shell completions", Args: Shells},
+}
+
+// Shells lists the shells Script supports
+var Shells = []string{"bash", "zsh", "fish", "powershell"}
+
+// valueCompletions maps flags to the hidden "__complete <kind>" query that lists their values
+var valueCompletions = map[string]string{
+	"model":   "models",
+	"profile": "profiles",
+}
+
+// staticValues maps flags to a fixed set of values
+var staticValues = map[string][]string{
+	"output": {"text", "json"},
+}
+
+// dirFlags lists flags whose value is a directory; other value flags take free text
+var dirFlags = map[string]bool{
+	"project-root": true,
+}
+
+// Script returns the completion script for shell
+func Script(shell string, flags []Flag) (string, error) {
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	switch shell {
+	case "bash":
+		return bashScript(flags), nil
+	case "zsh":
+		return zshScript(flags), nil
+	case "fish":
+		return fishScript(flags), nil
+	case "powershell":
+		return powershellScript(flags), nil
+	}
+	return "", fmt.Errorf("unsupported shell %q (use %s)", shell, strings.Join(Shells, ", "))
+}
+
+func commandNames() []string {
+	names := make([]string, len(Commands))
+	for i, c := range Commands {
+		names[i] = c.Name
+	}
+	return names
+}
+
+func flagNames(flags []Flag) []string {
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = "--" + f.Name
+	}
+	return names
+}
+
+func valueFlags(flags []Flag) []string {
+	var names []string
+	for _, f := range flags {
+		if f.TakesValue {
+			names = append(names, "--"+f.Name, "-"+f.Name)
+		}
+	}
+	return names
+}
+
+func bashScript(flags []Flag) string {
+	var b strings.Builder
+	b.WriteString("# bash completion for llamasidekick\n")
+	b.WriteString("# Load with: source <(llamasidekick completion bash)\n")
+	b.WriteString("_llamasidekick() {\n")
+	b.WriteString("    local cur prev i command=\"\"\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n\n")
+	b.WriteString("    case \"$prev\" in\n")
+	for _, f := range flags {
+		if !f.TakesValue {
+			continue
+		}
+		pattern := fmt.Sprintf("-%s|--%s", f.Name, f.Name)
+		switch {
+		case valueCompletions[f.Name] != "":
+			fmt.Fprintf(&b, "        %s) COMPREPLY=( $(compgen -W \"$(llamasidekick __complete %s 2>/dev/null)\" -- \"$cur\") ); return ;;\n", pattern, valueCompletions[f.Name])
+		case staticValues[f.Name] != nil:
+			fmt.Fprintf(&b, "        %s) COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") ); return ;;\n", pattern, strings.Join(staticValues[f.Name], " "))
+		case dirFlags[f.Name]:
+			fmt.Fprintf(&b, "        %s) COMPREPLY=( $(compgen -d -- \"$cur\") ); return ;;\n", pattern)
+		default:
+			fmt.Fprintf(&b, "        %s) return ;;\n", pattern)
+		}
+	}
+	b.WriteString("    esac\n\n")
+	b.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") )\n", strings.Join(flagNames(flags), " "))
+	b.WriteString("        return\n    fi\n\n")
+	b.WriteString("    # Find the command, skipping flags and their values\n")
+	b.WriteString("    for (( i=1; i<COMP_CWORD; i++ )); do\n")
+	b.WriteString("        case \"${COMP_WORDS[i]}\" in\n")
+	if vf := valueFlags(flags); len(vf) > 0 {
+		fmt.Fprintf(&b, "            %s) (( i++ )) ;;\n", strings.Join(vf, "|"))
+	}
+	b.WriteString("            -*) ;;\n")
+	b.WriteString("            *) command=\"${COMP_WORDS[i]}\"; break ;;\n")
+	b.WriteString("        esac\n    done\n\n")
+	b.WriteString("    case \"$command\" in\n")
+	fmt.Fprintf(&b, "        \"\") COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") ) ;;\n", strings.Join(commandNames(), " "))
+	for _, c := range Commands {
+		if len(c.Args) > 0 {
+			fmt.Fprintf(&b, "        %s) [[ $i -eq $((COMP_CWORD-1)) ]] && COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") ) ;;\n", c.Name, strings.Join(c.Args, " "))
+		}
+	}
+	b.WriteString("    esac\n}\n")
+	b.WriteString("complete -F _llamasidekick llamasidekick\n")
+	return b.String()
+}
+
+// zshQuote makes text safe inside a single-quoted _arguments spec description
+func zshQuote(text string) string {
+	return strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:").Replace(text)
+}
+
+func zshScript(flags []Flag) string {
+	var b strings.Builder
+	b.WriteString("#compdef llamasidekick\n")
+	b.WriteString("# zsh completion for llamasidekick\n")
+	b.WriteString("# Load with: source <(llamasidekick completion zsh)\n")
+	b.WriteString("_llamasidekick() {\n")
+	b.WriteString("    local -a commands\n")
+	b.WriteString("    local state\n")
+	b.WriteString("    commands=(\n")
+	for _, c := range Commands {
+		fmt.Fprintf(&b, "        '%s:%s'\n", c.Name, zshQuote(c.Usage))
+	}
+	b.WriteString("    )\n")
+	b.WriteString("    _arguments -C \\\n")
+	for _, f := range flags {
+		spec := fmt.Sprintf("--%s[%s]", f.Name, zshQuote(f.Usage))
+		if f.TakesValue {
+			switch {
+			case valueCompletions[f.Name] != "":
+				spec += fmt.Sprintf(":%s:->%s", f.Name, valueCompletions[f.Name])
+			case staticValues[f.Name] != nil:
+				spec += fmt.Sprintf(":%s:(%s)", f.Name, strings.Join(staticValues[f.Name], " "))
+			case dirFlags[f.Name]:
+				spec += fmt.Sprintf(":%s:_directories", f.Name)
+			default:
+				spec += fmt.Sprintf(":%s: ", f.Name)
+			}
+		}
+		fmt.Fprintf(&b, "        '%s' \\\n", spec)
+	}
+	b.WriteString("        '1:command:->command' \\\n")
+	b.WriteString("        '*::arg:->args'\n\n")
+	b.WriteString("    case $state in\n")
+	for _, kind := range []string{"models", "profiles"} {
+		fmt.Fprintf(&b, "        %s) compadd -- ${(f)\"$(llamasidekick __complete %s 2>/dev/null)\"} ;;\n", kind, kind)
+	}
+	b.WriteString("        command) _describe 'command' commands ;;\n")
+	b.WriteString("        args)\n")
+	b.WriteString("            case $words[1] in\n")
+	for _, c := range Commands {
+		if len(c.Args) > 0 {
+			fmt.Fprintf(&b, "                %s) (( CURRENT == 2 )) && compadd %s ;;\n", c.Name, strings.Join(c.Args, " "))
+		}
+	}
+	b.WriteString("            esac ;;\n")
+	b.WriteString("    esac\n}\n")
+	b.WriteString("compdef _llamasidekick llamasidekick\n")
+	return b.String()
+}
+
+// fishQuote makes text safe inside a single-quoted fish string
+func fishQuote(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(text)
+}
+
+func fishScript(flags []Flag) string {
+	var b strings.Builder
+	b.WriteString("# fish completion for llamasidekick\n")
+	b.WriteString("# Load with: llamasidekick completion fish | source\n")
+	b.WriteString("complete -c llamasidekick -f\n")
+	for _, c := range Commands {
+		fmt.Fprintf(&b, "complete -c llamasidekick -n __fish_use_subcommand -a %s -d '%s'\n", c.Name, fishQuote(c.Usage))
+		if len(c.Args) > 0 {
+			fmt.Fprintf(&b, "complete -c llamasidekick -n '__fish_seen_subcommand_from %s' -a '%s'\n", c.Name, strings.Join(c.Args, " "))
+		}
+	}
+	for _, f := range flags {
+		line := fmt.Sprintf("complete -c llamasidekick -o %s -l %s -d '%s'", f.Name, f.Name, fishQuote(f.Usage))
+		if f.TakesValue {
+			switch {
+			case valueCompletions[f.Name] != "":
+				line += fmt.Sprintf(" -x -a '(llamasidekick __complete %s 2>/dev/null)'", valueCompletions[f.Name])
+			case staticValues[f.Name] != nil:
+				line += fmt.Sprintf(" -x -a '%s'", strings.Join(staticValues[f.Name], " "))
+			case dirFlags[f.Name]:
+				line += " -x -a '(__fish_complete_directories)'"
+			default:
+				line += " -x"
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// psList renders values as a PowerShell array literal
+func psList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return "@(" + strings.Join(quoted, ", ") + ")"
+}
+
+func powershellScript(flags []Flag) string {
+	var b strings.Builder
+	b.WriteString("# PowerShell completion for llamasidekick\n")
+	b.WriteString("# Load with: llamasidekick completion powershell | Out-String | Invoke-Expression\n")
+	b.WriteString("Register-ArgumentCompleter -Native -CommandName llamasidekick -ScriptBlock {\n")
+	b.WriteString("    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	b.WriteString("    $elements = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })\n")
+	b.WriteString("    if ($wordToComplete -ne '' -and $elements.Count -gt 1) { $elements = $elements[0..($elements.Count - 2)] }\n")
+	b.WriteString("    $prev = $elements[-1]\n")
+	b.WriteString("    $candidates = switch -Regex ($prev) {\n")
+	for _, f := range flags {
+		if !f.TakesValue {
+			continue
+		}
+		switch {
+		case valueCompletions[f.Name] != "":
+			fmt.Fprintf(&b, "        '^--?%s$' { @(& llamasidekick __complete %s 2>$null) }\n", f.Name, valueCompletions[f.Name])
+		case staticValues[f.Name] != nil:
+			fmt.Fprintf(&b, "        '^--?%s$' { %s }\n", f.Name, psList(staticValues[f.Name]))
+		case dirFlags[f.Name]:
+			fmt.Fprintf(&b, "        '^--?%s$' { @(Get-ChildItem -Directory -Name) }\n", f.Name)
+		default:
+			fmt.Fprintf(&b, "        '^--?%s$' { @() }\n", f.Name)
+		}
+	}
+	for _, c := range Commands {
+		if len(c.Args) > 0 {
+			fmt.Fprintf(&b, "        '^%s$' { %s }\n", c.Name, psList(c.Args))
+		}
+	}
+	fmt.Fprintf(&b, "        default { if ($wordToComplete -like '-*') { %s } else { %s } }\n", psList(flagNames(flags)), psList(commandNames()))
+	b.WriteString("    }\n")
+	b.WriteString("    $candidates | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	b.WriteString("        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)\n")
+	b.WriteString("    }\n}\n")
+	return b.String()
+}
+
+// modelCacheMaxAge is how long cached model names are offered before they are considered stale
+const modelCacheMaxAge = 7 * 24 * time.Hour
+
+type modelCache struct {
+	Models    []string  `json:"models"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func modelCachePath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config dir: %w", err)
+	}
+	return filepath.Join(configDir, "models-cache.json"), nil
+}
+
+// SaveModelCache stores the installed model names for completion. It is called whenever
+// LlamaSidekick lists models, so completion never has to wait for Ollama.
+func SaveModelCache(models []string) error {
+	path, err := modelCachePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(modelCache{Models: models, UpdatedAt: time.Now()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal model cache: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write model cache: %w", err)
+	}
+	return nil
+}
+
+// CachedModels returns the cached model names, or nil if the cache is missing or stale
+func CachedModels() []string {
+	path, err := modelCachePath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cache modelCache
+	if err := json.Unmarshal(data, &cache); err != nil || time.Since(cache.UpdatedAt) > modelCacheMaxAge {
+		return nil
+	}
+	return cache.Models
+}
@@ -0,0 +1,45 @@
+package completion
+
+import (
+	"strings"
+	"testing"
+)
+
+var testFlags = []Flag{
+	{Name: "model", Usage: "Model to use", TakesValue: true},
+	{Name: "no-stream", Usage: "Don't stream"},
+	{Name: "project-root", Usage: "Project directory", TakesValue: true},
+}
+
+func TestScript_AllShells(t *testing.T) {
+	for _, shell := range Shells {
+		script, err := Script(shell, testFlags)
+		if err != nil {
+			t.Fatalf("%s: %v", shell, err)
+		}
+		for _, want := range []string{"no-stream", "__complete models", "completion", "secret"} {
+			if !strings.Contains(script, want) {
+				t.Fatalf("%s script missing %q:\n%s", shell, want, script)
+			}
+		}
+	}
+
+	if _, err := Script("tcsh", testFlags); err == nil {
+		t.Fatalf("expected error for unsupported shell")
+	}
+}
+
+func TestModelCache_RoundTrip(t *testing.T) {
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", t.TempDir())
+
+	if models := CachedModels(); models != nil {
+		t.Fatalf("expected no cached models, got %v", models)
+	}
+	if err := SaveModelCache([]string{"llama3:8b", "qwen2.5-coder:7b"}); err != nil {
+		t.Fatal(err)
+	}
+	models := CachedModels()
+	if len(models) != 2 || models[0] != "llama3:8b" {
+		t.Fatalf("unexpected cached models: %v", models)
+	}
+}
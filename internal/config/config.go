@@ -4,15 +4,73 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/spf13/viper"
 )
 
 // Config holds all configuration for LlamaSidekick
 type Config struct {
-	Ollama OllamaConfig `mapstructure:"ollama"`
-	Models ModelsConfig `mapstructure:"models"`
-	UI     UIConfig     `mapstructure:"ui"`
+	Version     int                       `mapstructure:"version"`
+	Ollama      OllamaConfig              `mapstructure:"ollama"`
+	Models      ModelsConfig              `mapstructure:"models"`
+	UI          UIConfig                  `mapstructure:"ui"`
+	Context     ContextConfig             `mapstructure:"context"`
+	Backups     BackupsConfig             `mapstructure:"backups"`
+	Edits       EditsConfig               `mapstructure:"edits"`
+	Templates   map[string]TemplateConfig `mapstructure:"templates"`
+	CustomModes []CustomModeConfig        `mapstructure:"custom_modes"`
+	Profiles    map[string]ProfileConfig  `mapstructure:"profiles"`
+	MCP         MCPConfig                 `mapstructure:"mcp"`
+	Logging     LoggingConfig             `mapstructure:"logging"`
+	PreCommit   PreCommitConfig           `mapstructure:"precommit"`
+	Agent       AgentConfig               `mapstructure:"agent"`
+	Test        TestConfig                `mapstructure:"test"`
+	Build       BuildConfig               `mapstructure:"build"`
+	Format      FormatConfig              `mapstructure:"format"`
+	Index       IndexConfig               `mapstructure:"index"`
+	Hooks       HooksConfig               `mapstructure:"hooks"`
+	Router      RouterConfig              `mapstructure:"router"`
+	Share       ShareConfig               `mapstructure:"share"`
+	Budget      BudgetConfig              `mapstructure:"budget"`
+	Cache       CacheConfig               `mapstructure:"cache"`
+	Cmd         CmdConfig                 `mapstructure:"cmd"`
+
+	overridden    map[string]overriddenValue // Keys changed by ApplyOverrides
+	projectModels map[string]projectModel    // Models set by ApplyProjectModels, by mode
+}
+
+// TemplateConfig holds a reusable prompt template invoked with /tpl
+type TemplateConfig struct {
+	Description string `mapstructure:"description"`
+	Mode        string `mapstructure:"mode"`   // Mode to run the expanded prompt in (defaults to current mode)
+	Prompt      string `mapstructure:"prompt"` // Prompt text with {input}, {file}, {lang}, {selection} placeholders
+}
+
+// CustomModeConfig is a mode defined in config.yaml, run with /<name> and listed in the menu
+type CustomModeConfig struct {
+	Name         string   `mapstructure:"name"`
+	Description  string   `mapstructure:"description"`
+	SystemPrompt string   `mapstructure:"system_prompt"`
+	Model        string   `mapstructure:"model"`       // Empty uses ollama.model
+	Temperature  *float64 `mapstructure:"temperature"` // Unset uses ollama.temperature
+	Output       string   `mapstructure:"output"`      // One of CustomModeOutputs; empty means markdown
+}
+
+// CustomModeOutputs lists what a custom mode does with the response: render it as
+// markdown, copy it to the clipboard as a command, or write the files it contains
+var CustomModeOutputs = []string{"markdown", "command", "files"}
+
+// CustomMode returns the custom mode called name
+func (c *Config) CustomMode(name string) (CustomModeConfig, bool) {
+	for _, m := range c.CustomModes {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return CustomModeConfig{}, false
 }
 
 // OllamaConfig holds Ollama-specific settings
@@ -21,41 +79,393 @@ type OllamaConfig struct {
 	Model       string  `mapstructure:"model"`        // Default model (deprecated, use Models config)
 	Temperature float64 `mapstructure:"temperature"`
 	Debug       bool    `mapstructure:"debug"`
+	APIKey      string  `mapstructure:"api_key"`      // Secret reference, e.g. keyring:ollama or env:OLLAMA_API_KEY
+	Seed        int     `mapstructure:"seed"`         // Fixed sampling seed for reproducible responses (0 = random)
+	NumCtx      int     `mapstructure:"num_ctx"`      // Context window in tokens (0 = the model's default)
+	NumPredict  map[string]int `mapstructure:"num_predict"` // Most tokens an answer may have, per mode (unset = no limit)
 }
 
-// ModelsConfig holds per-mode model settings
+// ModelsConfig holds per-mode model settings. The built-in modes have a field each; any
+// other mode, such as a custom mode, is kept in Other under its name.
 type ModelsConfig struct {
-	Plan  string `mapstructure:"plan"`
-	Edit  string `mapstructure:"edit"`
-	Agent string `mapstructure:"agent"`
-	CMD   string `mapstructure:"cmd"`
+	Plan  string            `mapstructure:"plan" json:"plan,omitempty"`
+	Edit  string            `mapstructure:"edit" json:"edit,omitempty"`
+	Agent string            `mapstructure:"agent" json:"agent,omitempty"`
+	CMD   string            `mapstructure:"cmd" json:"cmd,omitempty"`
+	Ask   string            `mapstructure:"ask" json:"ask,omitempty"`
+	Other map[string]string `mapstructure:",remain" json:"other,omitempty"`
 }
 
-// UIConfig holds UI-specific settings
-type UIConfig struct {
-	Theme string `mapstructure:"theme"`
+// builtinModelModes are the modes with a field in ModelsConfig, in order
+var builtinModelModes = []string{"plan", "edit", "agent", "cmd", "ask"}
+
+// Get returns the model set for mode, or "" if there is none
+func (m ModelsConfig) Get(mode string) string {
+	switch mode {
+	case "plan":
+		return m.Plan
+	case "edit":
+		return m.Edit
+	case "agent":
+		return m.Agent
+	case "cmd":
+		return m.CMD
+	case "ask":
+		return m.Ask
+	}
+	return m.Other[mode]
 }
 
-// GetModelForMode returns the configured model for a specific mode
-func (c *Config) GetModelForMode(mode string) string {
+// Set sets the model for mode; an empty model removes it
+func (m *ModelsConfig) Set(mode, model string) {
 	switch mode {
 	case "plan":
-		if c.Models.Plan != "" {
-			return c.Models.Plan
-		}
+		m.Plan = model
 	case "edit":
-		if c.Models.Edit != "" {
-			return c.Models.Edit
-		}
+		m.Edit = model
 	case "agent":
-		if c.Models.Agent != "" {
-			return c.Models.Agent
-		}
+		m.Agent = model
 	case "cmd":
-		if c.Models.CMD != "" {
-			return c.Models.CMD
+		m.CMD = model
+	case "ask":
+		m.Ask = model
+	default:
+		if model == "" {
+			delete(m.Other, mode)
+			return
+		}
+		if m.Other == nil {
+			m.Other = make(map[string]string)
+		}
+		m.Other[mode] = model
+	}
+}
+
+// Modes returns the built-in modes, then the other modes that have a model, sorted
+func (m ModelsConfig) Modes() []string {
+	other := make([]string, 0, len(m.Other))
+	for mode := range m.Other {
+		other = append(other, mode)
+	}
+	sort.Strings(other)
+	return append(slices.Clone(builtinModelModes), other...)
+}
+
+// ContextConfig limits how much file content is loaded into prompts
+type ContextConfig struct {
+	MaxFileBytes   int64    `mapstructure:"max_file_bytes"`   // Larger files are truncated (0 = unlimited)
+	MaxTotalTokens int      `mapstructure:"max_total_tokens"` // Budget for all loaded files combined (0 = unlimited)
+	Ignore         []string `mapstructure:"ignore"`           // Glob patterns for files that are never loaded
+	LineNumbers    bool     `mapstructure:"line_numbers"`     // Prefix loaded file lines with their line numbers
+	GitHistory     bool     `mapstructure:"git_history"`      // Add blame and recent commits of file regions to Ask prompts
+	RepoMap        bool     `mapstructure:"repo_map"`         // Add the git branch, declared dependencies and project tasks to every prompt
+	Secrets        string   `mapstructure:"secrets"`          // Likely secrets in loaded files: redact, confirm or off (empty = redact)
+	SanitizeTools  bool     `mapstructure:"sanitize_tools"`   // Remove instructions aimed at the model from MCP tool results
+	Stack          string   `mapstructure:"stack"`            // Project language and frameworks for system prompts: auto, off, or a description
+	Infra          bool     `mapstructure:"infra"`            // Add Compose services, running containers and Kubernetes contexts to CMD and Agent prompts
+	Compress       string   `mapstructure:"compress"`         // Shrink files loaded by globs or as active files: off, strip or summarize (empty = off)
+	CompressModel  string   `mapstructure:"compress_model"`   // Model that writes summaries (empty = ollama.model)
+}
+
+// DefaultContextConfig returns the context limits used when none are configured
+func DefaultContextConfig() ContextConfig {
+	return ContextConfig{
+		MaxFileBytes:   256 * 1024,
+		MaxTotalTokens: 32000,
+		Ignore:         []string{".git", "node_modules", ".env", "*.pem", "*.key"},
+		LineNumbers:    true,
+		RepoMap:        true,
+		Secrets:        "redact",
+		SanitizeTools:  true,
+		Stack:          "auto",
+		Infra:          true,
+		Compress:       "off",
+	}
+}
+
+// BackupsConfig controls the copies kept of files before LlamaSidekick overwrites them
+type BackupsConfig struct {
+	Keep  int  `mapstructure:"keep"`  // Versions kept per file
+	Trash bool `mapstructure:"trash"` // Also keep every replaced version in the trash until it is emptied
+}
+
+// EditsConfig controls how Edit and Agent mode apply file changes
+type EditsConfig struct {
+	DryRun            bool     `mapstructure:"dry_run"`             // Show the diff of proposed changes without writing them
+	ReadOnly          bool     `mapstructure:"read_only"`           // Like dry_run, but can't be toggled off and also disables MCP tools and restores
+	AllowSymlinks     bool     `mapstructure:"allow_symlinks"`      // Write through symlinks that stay inside the project
+	AllowSpecialFiles bool     `mapstructure:"allow_special_files"` // Write to device files, FIFOs and sockets
+	AutoCommit        bool     `mapstructure:"auto_commit"`         // Commit every approved change with a generated [llamasidekick] message
+	Hunks             bool     `mapstructure:"hunks"`               // Step through Edit mode changes hunk by hunk (y/n/e) before writing them
+	AutoApprove       bool     `mapstructure:"auto_approve"`        // Apply changes, renames and deletions without asking, for throwaway projects
+	AllowWrite        []string `mapstructure:"allow_write"`         // Patterns of the only project paths that may be written (empty = all)
+	DenyWrite         []string `mapstructure:"deny_write"`          // Patterns of project paths that are never written, whoever asks
+}
+
+// PreCommitConfig controls the git pre-commit hook installed with "llamasidekick hook install"
+type PreCommitConfig struct {
+	Review       bool   `mapstructure:"review"`         // Ask the model to review the staged diff
+	SecretScan   bool   `mapstructure:"secret_scan"`    // Look for credentials in added lines
+	Action       string `mapstructure:"action"`         // "warn" or "block" when problems are found
+	MaxDiffBytes int    `mapstructure:"max_diff_bytes"` // Larger diffs are truncated before review
+}
+
+// LoggingConfig controls the log file used for troubleshooting
+type LoggingConfig struct {
+	Level  string `mapstructure:"level"`  // debug, info, warn, error or off
+	Format string `mapstructure:"format"` // text or json
+	File   string `mapstructure:"file"`   // Empty means llamasidekick.log in the data dir
+}
+
+// AgentConfig controls how Agent mode works with the project's git repository and how
+// large a project /scaffold generates
+type AgentConfig struct {
+	Branch           bool   `mapstructure:"branch"`             // Work on a new branch per task and commit the changes there
+	BranchPrefix     string `mapstructure:"branch_prefix"`      // Prefix of task branch names
+	ScaffoldMaxFiles int    `mapstructure:"scaffold_max_files"` // Files a /scaffold plan may list
+}
+
+// TestConfig controls the test command that /fix-tests runs
+type TestConfig struct {
+	Command       string `mapstructure:"command"`        // Test command; empty to detect it from the project files
+	MaxIterations int    `mapstructure:"max_iterations"` // Fixes tried before /fix-tests gives up
+}
+
+// BuildConfig controls the build command that /build runs
+type BuildConfig struct {
+	Command       string `mapstructure:"command"`        // Build command; empty to detect it from the project files
+	MaxIterations int    `mapstructure:"max_iterations"` // Patch rounds tried before /build gives up
+}
+
+// IndexConfig controls the semantic index built by "llamasidekick index build"
+type IndexConfig struct {
+	Model        string         `mapstructure:"model"`         // Ollama embedding model
+	ChunkLines   int            `mapstructure:"chunk_lines"`   // Lines per embedded chunk
+	ChunkOverlap int            `mapstructure:"chunk_overlap"` // Lines shared by consecutive chunks
+	TopK         int            `mapstructure:"top_k"`         // Chunks retrieved into Ask, Edit and Agent prompts (0 = off)
+	Chunking     []ChunkingRule `mapstructure:"chunking"`      // How matching files are split; others are split by lines
+}
+
+// ChunkingRule picks the chunking strategy for the files matching one of Files
+type ChunkingRule struct {
+	Files    []string `mapstructure:"files"`    // Glob patterns, like context.ignore
+	Strategy string   `mapstructure:"strategy"` // One of ChunkingStrategies
+}
+
+// ChunkingStrategies lists the ways files can be split into index chunks: Go files by
+// declaration, markdown by heading, or anything by a fixed number of lines
+var ChunkingStrategies = []string{"go", "markdown", "lines"}
+
+// DefaultChunking splits Go files by declaration and markdown files by heading
+func DefaultChunking() []ChunkingRule {
+	return []ChunkingRule{
+		{Files: []string{"*.go"}, Strategy: "go"},
+		{Files: []string{"*.md", "*.markdown"}, Strategy: "markdown"},
+	}
+}
+
+// FormatConfig lists the formatters and linters run on files after they are written
+type FormatConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Formatters []ToolCommand `mapstructure:"formatters"` // Rewrite the file in place
+	Linters    []ToolCommand `mapstructure:"linters"`    // Report problems; a non-zero exit status means findings
+}
+
+// ToolCommand is a shell command run on every written file matching one of Files.
+// {file} in the command is replaced with the file's path relative to the project.
+type ToolCommand struct {
+	Files   []string `mapstructure:"files"` // Glob patterns, like context.ignore
+	Command string   `mapstructure:"command"`
+}
+
+// DefaultFormatters format Go, Python and web files with their standard formatters when
+// those are installed
+func DefaultFormatters() []ToolCommand {
+	return []ToolCommand{
+		{Files: []string{"*.go"}, Command: "gofmt -w {file}"},
+		{Files: []string{"*.py"}, Command: "black -q {file}"},
+		{Files: []string{"*.js", "*.jsx", "*.ts", "*.tsx", "*.css", "*.scss", "*.html", "*.vue"}, Command: "prettier --write --log-level warn {file}"},
+	}
+}
+
+// HooksConfig lists shell commands run around what LlamaSidekick does, to plug it into
+// other automation. Placeholders are replaced with shell-quoted values: {file} with the
+// path of the file relative to the project, {command} with the test or build command,
+// {status} with "passed" or "failed", and {tool} with the name of the Agent tool.
+type HooksConfig struct {
+	PreEdit     []string `mapstructure:"pre_edit"`     // Before each file is written ({file}); a failure cancels the write
+	PostEdit    []string `mapstructure:"post_edit"`    // After each file is written and formatted ({file})
+	PreCommand  []string `mapstructure:"pre_command"`  // Before /fix-tests or /build runs its command ({command}); a failure stops it
+	PostCommand []string `mapstructure:"post_command"` // After the command ran ({command}, {status})
+	PreTool     []string `mapstructure:"pre_tool"`     // Before Agent mode calls a tool ({tool}); a failure declines the call
+	PostTool    []string `mapstructure:"post_tool"`    // After the tool call ({tool})
+}
+
+// RouterConfig controls how ui.default_mode: auto picks a mode, and optionally a model,
+// for input typed without a slash command
+type RouterConfig struct {
+	Model      string `mapstructure:"model"`       // Small, fast model that classifies each input (empty = keyword heuristics)
+	SmallModel string `mapstructure:"small_model"` // Model for requests the router rates simple (empty = the mode's model)
+	LargeModel string `mapstructure:"large_model"` // Model for requests the router rates complex (empty = the mode's model)
+	Confirm    bool   `mapstructure:"confirm"`     // Ask before following the router's decision, which can be changed
+}
+
+// ShareConfig controls how /share publishes conversations
+type ShareConfig struct {
+	GitHubToken string `mapstructure:"github_token"` // Secret reference for creating gists; empty uses the gh CLI
+}
+
+// BudgetConfig limits how much LlamaSidekick generates, for shared Ollama servers and
+// metered backends. A limit of 0 means no limit.
+type BudgetConfig struct {
+	SessionTokens  int     `mapstructure:"session_tokens"`  // Prompt plus response tokens per run
+	SessionMinutes float64 `mapstructure:"session_minutes"` // Generation time per run
+	DailyTokens    int     `mapstructure:"daily_tokens"`    // Tokens per day, across all runs
+	DailyMinutes   float64 `mapstructure:"daily_minutes"`   // Generation time per day, across all runs
+	WarnAt         int     `mapstructure:"warn_at"`         // Percentage of a limit at which to warn
+}
+
+// CacheConfig controls the response cache, which answers requests that were made before
+// with deterministic settings (temperature 0 or ollama.seed) without generating
+type CacheConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	MaxEntries int  `mapstructure:"max_entries"` // Responses kept; the least recently used are dropped
+}
+
+// CmdConfig controls CMD mode
+type CmdConfig struct {
+	ShellHistory bool   `mapstructure:"shell_history"` // Learn the user's preferred tools from their shell history (off unless opted in)
+	HistoryFile  string `mapstructure:"history_file"`  // Empty = $HISTFILE or the shell's usual history file
+	HistoryLines int    `mapstructure:"history_lines"` // Most recent commands read from the history
+}
+
+// MCPConfig lists Model Context Protocol servers whose tools Agent mode can call
+type MCPConfig struct {
+	Servers  map[string]MCPServerConfig `mapstructure:"servers"`
+	Confirm  bool                       `mapstructure:"confirm"`   // Ask before every tool call
+	MaxSteps int                        `mapstructure:"max_steps"` // Tool calls allowed per prompt
+}
+
+// MCPServerConfig describes how to launch one MCP server
+type MCPServerConfig struct {
+	Command  string   `mapstructure:"command"`
+	Args     []string `mapstructure:"args"`
+	Env      []string `mapstructure:"env"` // KEY=VALUE entries; values may be secret references
+	Disabled bool     `mapstructure:"disabled"`
+}
+
+// MCPServerNames returns the names of the enabled MCP servers in sorted order
+func (c *Config) MCPServerNames() []string {
+	names := make([]string, 0, len(c.MCP.Servers))
+	for name, server := range c.MCP.Servers {
+		if !server.Disabled {
+			names = append(names, name)
 		}
 	}
+	sort.Strings(names)
+	return names
+}
+
+// MCPServerEnv resolves the secret references in a server's env entries
+func (c *Config) MCPServerEnv(name string) ([]string, error) {
+	var env []string
+	for _, entry := range c.MCP.Servers[name].Env {
+		key, value, _ := strings.Cut(entry, "=")
+		resolved, err := ResolveSecret(value)
+		if err != nil {
+			return nil, fmt.Errorf("mcp.servers.%s.env %s: %w", name, key, err)
+		}
+		env = append(env, key+"="+resolved)
+	}
+	return env, nil
+}
+
+// BackupDir returns the directory backups are stored in, outside any project tree
+func BackupDir() (string, error) {
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "backups"), nil
+}
+
+// TrashDir returns the directory of the trash used when backups.trash is enabled
+func TrashDir() (string, error) {
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "trash"), nil
+}
+
+// UIConfig holds UI-specific settings
+type UIConfig struct {
+	Theme         string            `mapstructure:"theme"`
+	DefaultMode   string            `mapstructure:"default_mode"`     // Mode for input without a slash command: last, auto, plan, edit, agent, cmd or ask
+	MarkdownStyle string            `mapstructure:"markdown_style"`   // Glamour style name or path to a glamour JSON style file
+	CodeTheme     string            `mapstructure:"code_theme"`       // Chroma theme for code blocks (empty = the style's own colors)
+	WordDiff      bool              `mapstructure:"word_diff"`        // Highlight changed words in diffs
+	Stream        bool              `mapstructure:"stream"`           // Render responses while they stream in
+	LineNumbers   bool              `mapstructure:"line_numbers"`     // Show file line numbers in diff previews
+	FollowUps     bool              `mapstructure:"follow_ups"`       // Suggest follow-up commands (/apply, /copy, /run, ...) after each answer
+	CheckRefs     bool              `mapstructure:"check_references"` // Flag files and symbols an answer names that aren't in the project
+	ShowThinking  bool              `mapstructure:"show_thinking"`    // Print a reasoning model's thinking after each answer instead of folding it away
+	Brief         bool              `mapstructure:"brief"`            // Ask for short answers and cap them at BriefTokens, as /brief does
+	BriefTokens   int               `mapstructure:"brief_tokens"`     // Most tokens an answer may have while brief is on
+	Accessible    bool              `mapstructure:"accessible"`       // Screen-reader-friendly output: no spinners, emoji, box drawing or alternate screen
+	Display       string            `mapstructure:"display"`          // How responses appear: one of DisplayStyles
+	ModeDisplay   map[string]string `mapstructure:"mode_display"`     // Display style per mode, overriding Display
+}
+
+// Display styles for responses: a spinner until the first token and then markdown
+// rendered block by block, a spinner until the whole response is there and then rendered
+// at once, or the tokens printed as they arrive without rendering
+const (
+	DisplayLive   = "live"
+	DisplayRender = "render"
+	DisplayRaw    = "raw"
+)
+
+// DisplayStyles lists the values of ui.display and ui.mode_display
+var DisplayStyles = []string{DisplayLive, DisplayRender, DisplayRaw}
+
+// DisplayForMode returns the display style of mode: ui.mode_display if it names the
+// mode, and ui.display otherwise. With ui.stream off (--no-stream) responses are only
+// shown once complete.
+func (c *Config) DisplayForMode(mode string) string {
+	if !c.UI.Stream {
+		return DisplayRender
+	}
+	if style := c.UI.ModeDisplay[mode]; style != "" {
+		return style
+	}
+	if c.UI.Display == "" {
+		return DisplayLive
+	}
+	return c.UI.Display
+}
+
+// MarkdownStylePath returns ui.markdown_style with relative file paths resolved against
+// the config dir. Style names are returned unchanged.
+func (c *Config) MarkdownStylePath() string {
+	style := c.UI.MarkdownStyle
+	if style == "" || filepath.IsAbs(style) || !strings.HasSuffix(strings.ToLower(style), ".json") {
+		return style
+	}
+	if configDir, err := GetConfigDir(); err == nil {
+		return filepath.Join(configDir, style)
+	}
+	return style
+}
+
+// GetModelForMode returns the configured model for a specific mode
+func (c *Config) GetModelForMode(mode string) string {
+	if model := c.Models.Get(mode); model != "" {
+		return model
+	}
+	if m, ok := c.CustomMode(mode); ok && m.Model != "" {
+		return m.Model
+	}
 	// Fallback to default model
 	if c.Ollama.Model != "" {
 		return c.Ollama.Model
@@ -63,6 +473,34 @@ func (c *Config) GetModelForMode(mode string) string {
 	return "codellama:7b"
 }
 
+// SetModel sets a model config key, models.<mode> or ollama.model, to model
+func (c *Config) SetModel(key, model string) error {
+	if key == "ollama.model" {
+		c.Ollama.Model = model
+		return nil
+	}
+	mode, ok := strings.CutPrefix(key, "models.")
+	if !ok || mode == "" {
+		return fmt.Errorf("%s is not a model setting", key)
+	}
+	c.Models.Set(mode, model)
+	return nil
+}
+
+// WithModel returns a copy of the config in which every mode uses model, for a single
+// request; the config itself is left unchanged
+func (c *Config) WithModel(model string) *Config {
+	copied := *c
+	copied.Ollama.Model = model
+	copied.Models = ModelsConfig{}
+	copied.CustomModes = make([]CustomModeConfig, len(c.CustomModes))
+	for i, m := range c.CustomModes {
+		m.Model = ""
+		copied.CustomModes[i] = m
+	}
+	return &copied
+}
+
 // GetConfigDir returns the cross-platform config directory
 func GetConfigDir() (string, error) {
 	if override := os.Getenv("LLAMASIDEKICK_CONFIG_DIR"); override != "" {
@@ -87,6 +525,15 @@ func GetConfigDir() (string, error) {
 	return llamaConfigDir, nil
 }
 
+// ConfigPath returns the path of the config file
+func ConfigPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config.yaml"), nil
+}
+
 // GetDataDir returns the cross-platform data directory
 func GetDataDir() (string, error) {
 	// On Windows, UserConfigDir returns %APPDATA%, which we can use for data too
@@ -125,6 +572,8 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 	
+	// Start from a clean slate so reloads pick up edits made outside the app
+	viper.Reset()
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(configDir)
@@ -137,16 +586,112 @@ func Load() (*Config, error) {
 		isFirstRun = true
 	}
 	
+	// Upgrade older config files before reading them
+	backupPath, err := migrateConfigFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if backupPath != "" {
+		fmt.Fprintf(os.Stderr, "Config upgraded to version %d (previous version saved to %s)\n", CurrentConfigVersion, backupPath)
+	}
+	
 	// Set defaults
+	viper.SetDefault("version", CurrentConfigVersion)
 	viper.SetDefault("ollama.host", "http://localhost:11434")
 	viper.SetDefault("ollama.model", "codellama:7b")
 	viper.SetDefault("ollama.temperature", 0.7)
 	viper.SetDefault("ollama.debug", false)
+	viper.SetDefault("ollama.seed", 0)
+	viper.SetDefault("ollama.num_ctx", 0)
 	viper.SetDefault("models.plan", "")
 	viper.SetDefault("models.edit", "")
 	viper.SetDefault("models.agent", "")
 	viper.SetDefault("models.cmd", "")
+	viper.SetDefault("models.ask", "")
 	viper.SetDefault("ui.theme", "default")
+	viper.SetDefault("ui.default_mode", "last")
+	viper.SetDefault("ui.markdown_style", "dark")
+	viper.SetDefault("ui.code_theme", "")
+	viper.SetDefault("ui.word_diff", true)
+	viper.SetDefault("ui.stream", true)
+	viper.SetDefault("ui.line_numbers", true)
+	viper.SetDefault("ui.follow_ups", true)
+	viper.SetDefault("ui.check_references", true)
+	viper.SetDefault("ui.show_thinking", false)
+	viper.SetDefault("ui.brief", false)
+	viper.SetDefault("ui.brief_tokens", 300)
+	viper.SetDefault("ui.accessible", false)
+	viper.SetDefault("ui.display", DisplayLive)
+	viper.SetDefault("backups.keep", 10)
+	viper.SetDefault("backups.trash", false)
+	viper.SetDefault("edits.dry_run", false)
+	viper.SetDefault("edits.read_only", false)
+	viper.SetDefault("edits.allow_symlinks", false)
+	viper.SetDefault("edits.allow_special_files", false)
+	viper.SetDefault("edits.auto_commit", false)
+	viper.SetDefault("edits.hunks", false)
+	viper.SetDefault("edits.auto_approve", false)
+	viper.SetDefault("edits.allow_write", []string{})
+	viper.SetDefault("edits.deny_write", []string{".git"})
+	viper.SetDefault("precommit.review", true)
+	viper.SetDefault("precommit.secret_scan", true)
+	viper.SetDefault("precommit.action", "warn")
+	viper.SetDefault("precommit.max_diff_bytes", 100000)
+	viper.SetDefault("logging.level", "warn")
+	viper.SetDefault("logging.format", "text")
+	viper.SetDefault("logging.file", "")
+	viper.SetDefault("agent.branch", false)
+	viper.SetDefault("agent.branch_prefix", "llamasidekick/")
+	viper.SetDefault("agent.scaffold_max_files", 60)
+	viper.SetDefault("test.command", "")
+	viper.SetDefault("test.max_iterations", 3)
+	viper.SetDefault("build.command", "")
+	viper.SetDefault("build.max_iterations", 3)
+	viper.SetDefault("index.model", "nomic-embed-text")
+	viper.SetDefault("index.chunk_lines", 60)
+	viper.SetDefault("index.chunk_overlap", 10)
+	viper.SetDefault("index.top_k", 5)
+	viper.SetDefault("index.chunking", DefaultChunking())
+	viper.SetDefault("format.enabled", true)
+	viper.SetDefault("format.formatters", DefaultFormatters())
+	viper.SetDefault("format.linters", []ToolCommand{})
+	viper.SetDefault("custom_modes", []CustomModeConfig{})
+	viper.SetDefault("hooks.pre_edit", []string{})
+	viper.SetDefault("hooks.post_edit", []string{})
+	viper.SetDefault("hooks.pre_command", []string{})
+	viper.SetDefault("hooks.post_command", []string{})
+	viper.SetDefault("hooks.pre_tool", []string{})
+	viper.SetDefault("hooks.post_tool", []string{})
+	viper.SetDefault("router.model", "")
+	viper.SetDefault("router.small_model", "")
+	viper.SetDefault("router.large_model", "")
+	viper.SetDefault("router.confirm", false)
+	viper.SetDefault("share.github_token", "")
+	viper.SetDefault("budget.session_tokens", 0)
+	viper.SetDefault("budget.session_minutes", 0)
+	viper.SetDefault("budget.daily_tokens", 0)
+	viper.SetDefault("budget.daily_minutes", 0)
+	viper.SetDefault("budget.warn_at", 80)
+	viper.SetDefault("cache.enabled", true)
+	viper.SetDefault("cache.max_entries", 1000)
+	viper.SetDefault("cmd.shell_history", false)
+	viper.SetDefault("cmd.history_file", "")
+	viper.SetDefault("cmd.history_lines", 1000)
+	viper.SetDefault("mcp.confirm", true)
+	viper.SetDefault("mcp.max_steps", 8)
+	contextDefaults := DefaultContextConfig()
+	viper.SetDefault("context.max_file_bytes", contextDefaults.MaxFileBytes)
+	viper.SetDefault("context.max_total_tokens", contextDefaults.MaxTotalTokens)
+	viper.SetDefault("context.ignore", contextDefaults.Ignore)
+	viper.SetDefault("context.line_numbers", contextDefaults.LineNumbers)
+	viper.SetDefault("context.git_history", contextDefaults.GitHistory)
+	viper.SetDefault("context.repo_map", contextDefaults.RepoMap)
+	viper.SetDefault("context.secrets", contextDefaults.Secrets)
+	viper.SetDefault("context.sanitize_tools", contextDefaults.SanitizeTools)
+	viper.SetDefault("context.stack", contextDefaults.Stack)
+	viper.SetDefault("context.infra", contextDefaults.Infra)
+	viper.SetDefault("context.compress", contextDefaults.Compress)
+	viper.SetDefault("context.compress_model", contextDefaults.CompressModel)
 	
 	// Try to read config
 	if err := viper.ReadInConfig(); err != nil {
@@ -168,26 +713,11 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
-// Save saves the current config to disk
+// Save saves the current config to disk. Only the settings LlamaSidekick manages are
+// updated; comments, templates and any other keys in the file are preserved, and
+// per-invocation overrides are not written.
 func (c *Config) Save() error {
-	configDir, err := GetConfigDir()
-	if err != nil {
-		return err
-	}
-	
-	// Update viper with current values
-	viper.Set("ollama.host", c.Ollama.Host)
-	viper.Set("ollama.model", c.Ollama.Model)
-	viper.Set("ollama.temperature", c.Ollama.Temperature)
-	viper.Set("ollama.debug", c.Ollama.Debug)
-	viper.Set("models.plan", c.Models.Plan)
-	viper.Set("models.edit", c.Models.Edit)
-	viper.Set("models.agent", c.Models.Agent)
-	viper.Set("models.cmd", c.Models.CMD)
-	viper.Set("ui.theme", c.UI.Theme)
-	
-	configPath := filepath.Join(configDir, "config.yaml")
-	if err := viper.WriteConfigAs(configPath); err != nil {
+	if err := writeConfigValues(c.persistedValues()); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 	
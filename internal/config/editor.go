@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// EditorCommand returns the user's preferred editor command from $VISUAL or $EDITOR,
+// falling back to a platform default
+func EditorCommand() string {
+	if editor := strings.TrimSpace(os.Getenv("VISUAL")); editor != "" {
+		return editor
+	}
+	if editor := strings.TrimSpace(os.Getenv("EDITOR")); editor != "" {
+		return editor
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// OpenInEditor opens path in the user's editor and waits for it to exit
+func OpenInEditor(path string) error {
+	parts := strings.Fields(EditorCommand())
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", parts[0], err)
+	}
+	return nil
+}
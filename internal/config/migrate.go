@@ -0,0 +1,174 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the schema version written by this build. Bump it and add a
+// migration whenever keys are renamed or restructured.
+const CurrentConfigVersion = 1
+
+// migration upgrades a config document from version `from` to `from+1`
+type migration struct {
+	from        int
+	description string
+	apply       func(root *yaml.Node) error
+}
+
+// migrations run in order; files without a version field are treated as version 0
+var migrations = []migration{
+	{
+		from:        0,
+		description: "add schema version field",
+		apply:       func(root *yaml.Node) error { return nil },
+	},
+}
+
+// migrateConfigFile upgrades the config file at path to CurrentConfigVersion, keeping a
+// backup of the original next to it. It returns the backup path, or "" if nothing changed.
+func migrateConfigFile(path string) (backupPath string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read config: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return "", nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("failed to parse config: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return "", fmt.Errorf("config is not a YAML mapping")
+	}
+	root := doc.Content[0]
+
+	version, err := configVersion(root)
+	if err != nil {
+		return "", err
+	}
+	if version > CurrentConfigVersion {
+		return "", fmt.Errorf("config version %d is newer than this build supports (%d); upgrade LlamaSidekick", version, CurrentConfigVersion)
+	}
+	if version == CurrentConfigVersion {
+		return "", nil
+	}
+
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+		if err := m.apply(root); err != nil {
+			return "", fmt.Errorf("config migration from version %d (%s) failed: %w", m.from, m.description, err)
+		}
+	}
+	if err := setYAMLValue(root, []string{"version"}, CurrentConfigVersion); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+
+	backupPath = fmt.Sprintf("%s.v%d-%s.bak", path, version, time.Now().Format("20060102_150405"))
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to back up config before migration: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return backupPath, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+	return backupPath, nil
+}
+
+// configVersion reads the top-level version field, defaulting to 0 when absent
+func configVersion(root *yaml.Node) (int, error) {
+	for i := 0; i < len(root.Content)-1; i += 2 {
+		if root.Content[i].Value != "version" {
+			continue
+		}
+		version, err := strconv.Atoi(root.Content[i+1].Value)
+		if err != nil {
+			return 0, fmt.Errorf("config version %q is not a number", root.Content[i+1].Value)
+		}
+		return version, nil
+	}
+	return 0, nil
+}
+
+// moveYAMLKey moves the value at from to to, for use by migrations that rename keys.
+// It does nothing if from doesn't exist or to is already set.
+func moveYAMLKey(root *yaml.Node, from, to []string) error {
+	value := removeYAMLKey(root, from)
+	if value == nil {
+		return nil
+	}
+	if lookupYAMLKey(root, to) != nil {
+		return nil
+	}
+	parent := root
+	for _, key := range to[:len(to)-1] {
+		child := lookupYAMLKey(parent, []string{key})
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		if child.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a mapping", key)
+		}
+		parent = child
+	}
+	parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: to[len(to)-1]}, value)
+	return nil
+}
+
+// lookupYAMLKey returns the value node at path, or nil if it doesn't exist
+func lookupYAMLKey(mapping *yaml.Node, path []string) *yaml.Node {
+	for i := 0; i < len(mapping.Content)-1; i += 2 {
+		if mapping.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return mapping.Content[i+1]
+		}
+		if mapping.Content[i+1].Kind != yaml.MappingNode {
+			return nil
+		}
+		return lookupYAMLKey(mapping.Content[i+1], path[1:])
+	}
+	return nil
+}
+
+// removeYAMLKey deletes the key at path and returns its value node, or nil if absent
+func removeYAMLKey(mapping *yaml.Node, path []string) *yaml.Node {
+	for i := 0; i < len(mapping.Content)-1; i += 2 {
+		if mapping.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			value := mapping.Content[i+1]
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return value
+		}
+		if mapping.Content[i+1].Kind != yaml.MappingNode {
+			return nil
+		}
+		return removeYAMLKey(mapping.Content[i+1], path[1:])
+	}
+	return nil
+}
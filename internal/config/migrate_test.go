@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrateConfigFile_StampsVersionAndBacksUp(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "config.yaml")
+	original := "# keep me\nollama:\n  host: http://localhost:11434\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	backup, err := migrateConfigFile(path)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if backup == "" {
+		t.Fatalf("expected a backup path")
+	}
+	if data, err := os.ReadFile(backup); err != nil || string(data) != original {
+		t.Fatalf("backup does not hold the original config: %q (%v)", data, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.Contains(string(data), "version: 1") || !strings.Contains(string(data), "# keep me") {
+		t.Fatalf("unexpected migrated config:\n%s", data)
+	}
+
+	// Already current: nothing to do
+	if backup, err := migrateConfigFile(path); err != nil || backup != "" {
+		t.Fatalf("expected no-op migration, got %q (%v)", backup, err)
+	}
+}
+
+func TestMigrateConfigFile_RejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("version: 99\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := migrateConfigFile(path); err == nil {
+		t.Fatalf("expected error for newer config version")
+	}
+}
+
+func TestMoveYAMLKey(t *testing.T) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte("ollama:\n  model: llama3\n  host: h\n"), &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	root := doc.Content[0]
+	if err := moveYAMLKey(root, []string{"ollama", "model"}, []string{"models", "default"}); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if lookupYAMLKey(root, []string{"ollama", "model"}) != nil {
+		t.Fatalf("expected old key to be removed")
+	}
+	if v := lookupYAMLKey(root, []string{"models", "default"}); v == nil || v.Value != "llama3" {
+		t.Fatalf("expected value at new key, got %#v", v)
+	}
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileConfig is a named set of settings selected with --profile
+type ProfileConfig struct {
+	Host        string       `mapstructure:"host"`
+	Model       string       `mapstructure:"model"` // Used for every mode unless models overrides it
+	Temperature *float64     `mapstructure:"temperature"`
+	Models      ModelsConfig `mapstructure:"models"`
+}
+
+// Overrides are per-invocation settings (from flags or a profile). They are applied on top
+// of the loaded config, again whenever it is reloaded, but never written back by Save.
+type Overrides struct {
+	Profile    string
+	Host       string
+	Model      string // Used for every mode
+	ReadOnly   bool   // --read-only
+	NoStream   bool   // --no-stream
+	Accessible bool   // --accessible
+	LogLevel   string // --log-level
+}
+
+// overriddenValue remembers what a key held in the file and what the override set it to,
+// so Save can tell an override apart from a later change the user made in the app
+type overriddenValue struct {
+	persisted interface{}
+	override  interface{}
+}
+
+// ApplyOverrides applies the selected profile and then the individual overrides
+func (c *Config) ApplyOverrides(o Overrides) error {
+	if o.Profile != "" {
+		profile, ok := c.Profiles[o.Profile]
+		if !ok {
+			return fmt.Errorf("unknown profile %q (defined profiles: %s)", o.Profile, strings.Join(c.ProfileNames(), ", "))
+		}
+		if profile.Host != "" {
+			c.override("ollama.host", profile.Host)
+		}
+		if profile.Model != "" {
+			c.overrideAllModels(profile.Model)
+		}
+		if profile.Temperature != nil {
+			c.override("ollama.temperature", *profile.Temperature)
+		}
+		for _, mode := range profile.Models.Modes() {
+			if model := profile.Models.Get(mode); model != "" {
+				c.override("models."+mode, model)
+			}
+		}
+	}
+
+	if o.Host != "" {
+		c.override("ollama.host", o.Host)
+	}
+	if o.Model != "" {
+		c.overrideAllModels(o.Model)
+	}
+	if o.ReadOnly {
+		c.Edits.ReadOnly = true
+	}
+	if o.NoStream {
+		c.UI.Stream = false
+	}
+	if o.Accessible {
+		c.UI.Accessible = true
+	}
+	if o.LogLevel != "" {
+		c.Logging.Level = o.LogLevel
+	}
+	return nil
+}
+
+// ProfileNames returns the sorted names of the configured profiles
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FallBackModels makes the keys that use one of the missing models use installed ones
+// instead, for this run only: modes fall back to the default model, which falls back to
+// the first installed model when it is missing itself, and the router goes back to its
+// heuristics and the mode's model. Save keeps the models in the file. It returns the
+// default model it falls back to.
+func (c *Config) FallBackModels(missing []UninstalledModel, installed []string) (string, error) {
+	if len(missing) == 0 {
+		return c.Ollama.Model, nil
+	}
+	if !ModelInstalled(c.Ollama.Model, installed) {
+		if len(installed) == 0 {
+			return "", fmt.Errorf("no models are installed to fall back to")
+		}
+		c.override("ollama.model", installed[0])
+	}
+	for _, m := range missing {
+		for _, key := range m.Keys {
+			switch key {
+			case "ollama.model":
+			case "router.model":
+				c.Router.Model = ""
+			case "router.small_model":
+				c.Router.SmallModel = ""
+			case "router.large_model":
+				c.Router.LargeModel = ""
+			default:
+				c.override(key, "")
+			}
+		}
+	}
+	return c.Ollama.Model, nil
+}
+
+// ApplyProjectModels makes the modes use the models remembered for a project, for this run:
+// the models a previous call applied go back to what they were before, unless they were
+// changed since, and Save keeps the models in the file
+func (c *Config) ApplyProjectModels(models ModelsConfig) {
+	for mode, pinned := range c.projectModels {
+		if c.Models.Get(mode) == pinned.applied {
+			c.override("models."+mode, pinned.base)
+		}
+	}
+	c.projectModels = nil
+	for _, mode := range models.Modes() {
+		model := models.Get(mode)
+		if model == "" {
+			continue
+		}
+		if c.projectModels == nil {
+			c.projectModels = make(map[string]projectModel)
+		}
+		c.projectModels[mode] = projectModel{base: c.Models.Get(mode), applied: model}
+		c.override("models."+mode, model)
+	}
+}
+
+// projectModel is a model ApplyProjectModels set for a mode, and the one it replaced
+type projectModel struct {
+	base, applied string
+}
+
+func (c *Config) overrideAllModels(model string) {
+	c.override("ollama.model", model)
+	for _, mode := range c.Models.Modes() {
+		c.override("models."+mode, model)
+	}
+}
+
+// override sets a managed key and records its persisted value the first time
+func (c *Config) override(key string, value interface{}) {
+	if c.overridden == nil {
+		c.overridden = make(map[string]overriddenValue)
+	}
+	previous, ok := c.overridden[key]
+	if !ok {
+		previous.persisted = c.managedValue(key)
+	}
+	previous.override = value
+	c.overridden[key] = previous
+
+	switch key {
+	case "ollama.host":
+		c.Ollama.Host = value.(string)
+	case "ollama.model":
+		c.Ollama.Model = value.(string)
+	case "ollama.temperature":
+		c.Ollama.Temperature = value.(float64)
+	default:
+		if mode, ok := strings.CutPrefix(key, "models."); ok {
+			c.Models.Set(mode, value.(string))
+		}
+	}
+}
+
+// managedValue returns the current value of a key Save manages
+func (c *Config) managedValue(key string) interface{} {
+	for _, v := range c.managedValues() {
+		if v.key == key {
+			return v.value
+		}
+	}
+	return nil
+}
+
+// persistedValues returns the managed values with unchanged overrides replaced by the
+// values from the file
+func (c *Config) persistedValues() []managedValue {
+	var values []managedValue
+	for _, v := range c.managedValues() {
+		if o, ok := c.overridden[v.key]; ok && v.value == o.override {
+			if o.persisted == nil {
+				// The override added the key, e.g. a custom mode's model; the file has none
+				continue
+			}
+			v.value = o.persisted
+		}
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyOverrides_ProfileAndFlagsAreNotSaved(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", tmp)
+
+	original := `ollama:
+  host: http://localhost:11434
+  model: llama3
+  temperature: 0.7
+profiles:
+  remote:
+    host: http://gpu-box:11434
+    model: deepseek-coder:33b
+    temperature: 0.2
+    models:
+      cmd: codellama:7b
+`
+	path := filepath.Join(tmp, "config.yaml")
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := cfg.ApplyOverrides(Overrides{Profile: "missing"}); err == nil {
+		t.Fatalf("expected unknown profile to fail")
+	}
+	if err := cfg.ApplyOverrides(Overrides{Profile: "remote", Host: "http://other:11434"}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	if cfg.Ollama.Host != "http://other:11434" {
+		t.Fatalf("expected --host to win over the profile, got %s", cfg.Ollama.Host)
+	}
+	if cfg.GetModelForMode("edit") != "deepseek-coder:33b" || cfg.GetModelForMode("cmd") != "codellama:7b" {
+		t.Fatalf("unexpected models: edit=%s cmd=%s", cfg.GetModelForMode("edit"), cfg.GetModelForMode("cmd"))
+	}
+	if cfg.Ollama.Temperature != 0.2 {
+		t.Fatalf("expected profile temperature, got %v", cfg.Ollama.Temperature)
+	}
+
+	// A change made in the app is saved, the overrides are not
+	cfg.Models.Plan = "llama3:70b"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	saved := string(data)
+	if strings.Contains(saved, "other:11434") || strings.Contains(saved, "temperature: 0.2\n  debug") || strings.Contains(saved, "edit: deepseek") {
+		t.Fatalf("overrides were saved:\n%s", saved)
+	}
+	for _, want := range []string{"host: http://localhost:11434", "model: llama3", "plan: llama3:70b"} {
+		if !strings.Contains(saved, want) {
+			t.Fatalf("expected saved config to contain %q, got:\n%s", want, saved)
+		}
+	}
+}
+
+func TestFallBackModels(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", tmp)
+
+	original := `ollama:
+  model: llama3
+models:
+  edit: deepseek-coder:33b
+router:
+  model: qwen2.5:0.5b
+`
+	path := filepath.Join(tmp, "config.yaml")
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	installed := []string{"mistral:latest"}
+	if _, err := cfg.FallBackModels(cfg.UninstalledModels(nil), nil); err == nil {
+		t.Fatalf("expected falling back without installed models to fail")
+	}
+	model, err := cfg.FallBackModels(cfg.UninstalledModels(installed), installed)
+	if err != nil {
+		t.Fatalf("fall back: %v", err)
+	}
+	if model != "mistral:latest" || cfg.GetModelForMode("edit") != "mistral:latest" || cfg.Router.Model != "" {
+		t.Fatalf("unexpected models: default=%s edit=%s router=%s", model, cfg.GetModelForMode("edit"), cfg.Router.Model)
+	}
+	if missing := cfg.UninstalledModels(installed); len(missing) != 0 {
+		t.Fatalf("expected no missing models after falling back, got %v", missing)
+	}
+
+	// The fallback is for this run; the file keeps the configured models
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	for _, want := range []string{"model: llama3", "edit: deepseek-coder:33b"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected saved config to contain %q, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestApplyOverrides_SurviveReload(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", tmp)
+
+	overrides := Overrides{Model: "qwen2.5-coder:7b", ReadOnly: true, NoStream: true, LogLevel: "debug"}
+
+	// The file as /config reloads it after an edit
+	path := filepath.Join(tmp, "config.yaml")
+	if err := os.WriteFile(path, []byte("ollama:\n  model: mistral\nui:\n  stream: true\nedits:\n  read_only: false\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := cfg.ApplyOverrides(overrides); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if !cfg.Edits.ReadOnly {
+		t.Fatal("expected read-only to survive the reload")
+	}
+	if cfg.UI.Stream || cfg.Logging.Level != "debug" || cfg.GetModelForMode("edit") != "qwen2.5-coder:7b" {
+		t.Fatalf("unexpected settings after reload: stream=%v log=%s model=%s", cfg.UI.Stream, cfg.Logging.Level, cfg.GetModelForMode("edit"))
+	}
+
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if saved := string(data); !strings.Contains(saved, "read_only: false") || !strings.Contains(saved, "model: mistral") {
+		t.Fatalf("overrides were saved:\n%s", saved)
+	}
+}
+
+func TestApplyProjectModels(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", tmp)
+
+	path := filepath.Join(tmp, "config.yaml")
+	if err := os.WriteFile(path, []byte("ollama:\n  model: llama3\nmodels:\n  edit: llama3\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	cfg.ApplyProjectModels(ModelsConfig{Edit: "deepseek-coder:33b", Other: map[string]string{"review": "qwen2.5-coder:7b"}})
+	if cfg.GetModelForMode("edit") != "deepseek-coder:33b" || cfg.Models.Get("review") != "qwen2.5-coder:7b" {
+		t.Fatalf("expected the first project's models, got %#v", cfg.Models)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if saved := string(data); !strings.Contains(saved, "edit: llama3") || strings.Contains(saved, "deepseek") || strings.Contains(saved, "review") {
+		t.Fatalf("the project's models were saved:\n%s", saved)
+	}
+
+	// Switching projects drops the first project's models
+	cfg.ApplyProjectModels(ModelsConfig{Plan: "mistral"})
+	if cfg.Models.Edit != "llama3" || cfg.Models.Get("review") != "" || cfg.Models.Plan != "mistral" {
+		t.Fatalf("expected only the second project's models, got %#v", cfg.Models)
+	}
+	cfg.Models.Plan = "llama3:70b" // Changed in the app
+	cfg.ApplyProjectModels(ModelsConfig{})
+	if cfg.Models.Plan != "llama3:70b" {
+		t.Fatalf("expected a model changed in the app to stay, got %q", cfg.Models.Plan)
+	}
+}
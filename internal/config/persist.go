@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// managedValues returns the config keys owned by Save, in file order. Keys not listed
+// here (templates, unknown keys, comments) are left exactly as the user wrote them.
+func (c *Config) managedValues() []managedValue {
+	values := []managedValue{
+		{"version", CurrentConfigVersion},
+		{"ollama.host", c.Ollama.Host},
+		{"ollama.model", c.Ollama.Model},
+		{"ollama.temperature", c.Ollama.Temperature},
+		{"ollama.debug", c.Ollama.Debug},
+	}
+	for _, mode := range c.Models.Modes() {
+		values = append(values, managedValue{"models." + mode, c.Models.Get(mode)})
+	}
+	return append(values,
+		managedValue{"ui.theme", c.UI.Theme},
+		managedValue{"ui.default_mode", c.UI.DefaultMode},
+	)
+}
+
+type managedValue struct {
+	key   string
+	value interface{}
+}
+
+// updateConfigYAML applies the managed values to existing YAML content, preserving
+// everything else in the document
+func updateConfigYAML(existing []byte, values []managedValue) ([]byte, error) {
+	var doc yaml.Node
+	if len(bytes.TrimSpace(existing)) > 0 {
+		if err := yaml.Unmarshal(existing, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse existing config: %w", err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("existing config is not a YAML mapping")
+	}
+
+	for _, v := range values {
+		if err := setYAMLValue(doc.Content[0], strings.Split(v.key, "."), v.value); err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", v.key, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// setYAMLValue sets a scalar at path inside mapping, creating intermediate mappings as needed
+func setYAMLValue(mapping *yaml.Node, path []string, value interface{}) error {
+	for i := 0; i < len(mapping.Content)-1; i += 2 {
+		keyNode, valNode := mapping.Content[i], mapping.Content[i+1]
+		if keyNode.Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			setScalar(valNode, value)
+			return nil
+		}
+		if valNode.Kind != yaml.MappingNode {
+			if valNode.Kind == yaml.ScalarNode && (valNode.Tag == "!!null" || valNode.Value == "") {
+				*valNode = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			} else {
+				return fmt.Errorf("%s is not a mapping", path[0])
+			}
+		}
+		return setYAMLValue(valNode, path[1:], value)
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}
+	var valNode *yaml.Node
+	if len(path) == 1 {
+		valNode = &yaml.Node{}
+		setScalar(valNode, value)
+	} else {
+		valNode = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if err := setYAMLValue(valNode, path[1:], value); err != nil {
+			return err
+		}
+	}
+	mapping.Content = append(mapping.Content, keyNode, valNode)
+	return nil
+}
+
+// setScalar replaces node's value, keeping comments and string quoting style
+func setScalar(node *yaml.Node, value interface{}) {
+	style := node.Style
+	node.Kind = yaml.ScalarNode
+	node.Content = nil
+	switch v := value.(type) {
+	case string:
+		node.Tag = "!!str"
+		node.Value = v
+		node.Style = style &^ (yaml.LiteralStyle | yaml.FoldedStyle)
+	case bool:
+		node.Tag = "!!bool"
+		node.Value = strconv.FormatBool(v)
+		node.Style = 0
+	case float64:
+		node.Tag = "!!float"
+		node.Value = strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.ContainsAny(node.Value, ".eEn") {
+			// Keep whole numbers recognisable as floats (1.0 rather than !!float 1)
+			node.Value += ".0"
+		}
+		node.Style = 0
+	case int:
+		node.Tag = "!!int"
+		node.Value = strconv.Itoa(v)
+		node.Style = 0
+	default:
+		node.Tag = "!!str"
+		node.Value = fmt.Sprint(v)
+	}
+}
+
+// SetValue updates a single key in the config file, preserving everything else
+func SetValue(key string, value interface{}) error {
+	return writeConfigValues([]managedValue{{key, value}})
+}
+
+// writeConfigValues writes values into the config file, preserving unmanaged content
+func writeConfigValues(values []managedValue) error {
+	configPath, err := ConfigPath()
+	if err != nil {
+		return err
+	}
+	existing, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	updated, err := updateConfigYAML(existing, values)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(configPath, updated, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
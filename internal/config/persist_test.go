@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSave_PreservesUnmanagedContent(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", tmp)
+
+	original := `# my settings
+ollama:
+  host: http://localhost:11434 # local server
+  model: llama3
+  temperature: 0.7
+custom:
+  keep: me
+templates:
+  review:
+    prompt: "Review {file}"
+`
+	path := filepath.Join(tmp, "config.yaml")
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	cfg.Models.Edit = "deepseek-coder:33b"
+	cfg.Ollama.Temperature = 0.2
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	saved := string(data)
+	for _, want := range []string{"# my settings", "# local server", "keep: me", "Review {file}", "temperature: 0.2", "edit: deepseek-coder:33b"} {
+		if !strings.Contains(saved, want) {
+			t.Fatalf("expected saved config to contain %q, got:\n%s", want, saved)
+		}
+	}
+
+	reloaded, err := Load()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if reloaded.Models.Edit != "deepseek-coder:33b" || reloaded.Models.Plan != "" {
+		t.Fatalf("unexpected models after reload: %#v", reloaded.Models)
+	}
+	if reloaded.Templates["review"].Prompt != "Review {file}" {
+		t.Fatalf("template lost after save: %#v", reloaded.Templates)
+	}
+}
+
+func TestSave_KeepsModelsOfAllModes(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", tmp)
+
+	original := `models:
+  ask: llama3
+  review: qwen2.5-coder:7b
+custom_modes:
+  - name: review
+    system_prompt: Review the code.
+`
+	path := filepath.Join(tmp, "config.yaml")
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.GetModelForMode("ask"); got != "llama3" {
+		t.Fatalf("ask uses %q", got)
+	}
+	if got := cfg.GetModelForMode("review"); got != "qwen2.5-coder:7b" {
+		t.Fatalf("review uses %q", got)
+	}
+	if err := cfg.SetModel("models.ask", "mistral"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := cfg.SetModel("models.review", "deepseek-coder:33b"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	reloaded, err := Load()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if reloaded.Models.Ask != "mistral" || reloaded.Models.Get("review") != "deepseek-coder:33b" {
+		t.Fatalf("unexpected models after reload: %#v", reloaded.Models)
+	}
+	if err := reloaded.Validate(); err != nil {
+		t.Fatalf("unexpected problems: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// KeyringService is the service name LlamaSidekick uses for entries in the OS keyring
+const KeyringService = "llamasidekick"
+
+const (
+	keyringPrefix = "keyring:"
+	envPrefix     = "env:"
+)
+
+// ResolveSecret turns a secret reference from the config into its value.
+// "keyring:<name>" is looked up in the OS keyring, "env:<VAR>" is read from the
+// environment, and anything else is treated as a literal value.
+func ResolveSecret(ref string) (string, error) {
+	switch {
+	case ref == "":
+		return "", nil
+	case strings.HasPrefix(ref, keyringPrefix):
+		name := strings.TrimPrefix(ref, keyringPrefix)
+		secret, err := keyring.Get(KeyringService, name)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %q from the OS keyring: %w (store it with: llamasidekick secret set %s)", name, err, name)
+		}
+		return secret, nil
+	case strings.HasPrefix(ref, envPrefix):
+		name := strings.TrimPrefix(ref, envPrefix)
+		secret := os.Getenv(name)
+		if secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+	default:
+		return ref, nil
+	}
+}
+
+// IsSecretReference reports whether value points at a keyring entry or environment
+// variable rather than containing the secret itself
+func IsSecretReference(value string) bool {
+	return strings.HasPrefix(value, keyringPrefix) || strings.HasPrefix(value, envPrefix)
+}
+
+// StoreSecret saves secret in the OS keyring under name and returns the reference to put in the config
+func StoreSecret(name, secret string) (string, error) {
+	if err := keyring.Set(KeyringService, name, secret); err != nil {
+		return "", fmt.Errorf("failed to store secret in the OS keyring: %w", err)
+	}
+	return keyringPrefix + name, nil
+}
+
+// DeleteSecret removes name from the OS keyring
+func DeleteSecret(name string) error {
+	if err := keyring.Delete(KeyringService, name); err != nil {
+		return fmt.Errorf("failed to delete secret from the OS keyring: %w", err)
+	}
+	return nil
+}
+
+// OllamaAPIKey resolves the configured Ollama API key, if any
+func (c *Config) OllamaAPIKey() (string, error) {
+	return ResolveSecret(c.Ollama.APIKey)
+}
+
+// GitHubToken resolves the token /share uses to create gists, if any
+func (c *Config) GitHubToken() (string, error) {
+	return ResolveSecret(c.Share.GitHubToken)
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestResolveSecret(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("LLAMASIDEKICK_TEST_KEY", "from-env")
+
+	ref, err := StoreSecret("ollama", "from-keyring")
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if ref != "keyring:ollama" {
+		t.Fatalf("unexpected reference: %s", ref)
+	}
+
+	cases := map[string]string{
+		"":                           "",
+		"plain":                      "plain",
+		"keyring:ollama":             "from-keyring",
+		"env:LLAMASIDEKICK_TEST_KEY": "from-env",
+	}
+	for in, want := range cases {
+		got, err := ResolveSecret(in)
+		if err != nil {
+			t.Fatalf("resolve %q: %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("resolve %q: expected %q, got %q", in, want, got)
+		}
+	}
+
+	if _, err := ResolveSecret("keyring:missing"); err == nil {
+		t.Fatalf("expected error for missing keyring entry")
+	}
+}
@@ -0,0 +1,545 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// knownKeys lists the config keys LlamaSidekick understands. Entries ending in "."
+// match any key below that prefix (used for user-defined maps like templates).
+var knownKeys = []string{
+	"version",
+	"ollama.host",
+	"ollama.model",
+	"ollama.temperature",
+	"ollama.debug",
+	"ollama.seed",
+	"ollama.num_ctx",
+	"ollama.num_predict.",
+	"ollama.api_key",
+	"models.",
+	"ui.theme",
+	"ui.default_mode",
+	"ui.markdown_style",
+	"ui.code_theme",
+	"ui.word_diff",
+	"ui.stream",
+	"ui.line_numbers",
+	"ui.follow_ups",
+	"ui.check_references",
+	"ui.show_thinking",
+	"ui.brief",
+	"ui.brief_tokens",
+	"ui.accessible",
+	"ui.display",
+	"ui.mode_display.",
+	"context.max_file_bytes",
+	"context.max_total_tokens",
+	"context.ignore",
+	"context.line_numbers",
+	"context.git_history",
+	"context.repo_map",
+	"context.secrets",
+	"context.sanitize_tools",
+	"context.stack",
+	"context.infra",
+	"context.compress",
+	"context.compress_model",
+	"backups.keep",
+	"backups.trash",
+	"edits.dry_run",
+	"edits.read_only",
+	"edits.allow_symlinks",
+	"edits.allow_special_files",
+	"edits.auto_commit",
+	"edits.hunks",
+	"edits.auto_approve",
+	"edits.allow_write",
+	"edits.deny_write",
+	"precommit.review",
+	"precommit.secret_scan",
+	"precommit.action",
+	"precommit.max_diff_bytes",
+	"logging.level",
+	"logging.format",
+	"logging.file",
+	"agent.branch",
+	"agent.branch_prefix",
+	"agent.scaffold_max_files",
+	"test.command",
+	"test.max_iterations",
+	"build.command",
+	"build.max_iterations",
+	"format.enabled",
+	"format.formatters",
+	"format.linters",
+	"index.model",
+	"index.chunk_lines",
+	"index.chunk_overlap",
+	"index.top_k",
+	"index.chunking",
+	"custom_modes",
+	"hooks.pre_edit",
+	"hooks.post_edit",
+	"hooks.pre_command",
+	"hooks.post_command",
+	"hooks.pre_tool",
+	"hooks.post_tool",
+	"router.model",
+	"router.small_model",
+	"router.large_model",
+	"router.confirm",
+	"share.github_token",
+	"budget.session_tokens",
+	"budget.session_minutes",
+	"budget.daily_tokens",
+	"budget.daily_minutes",
+	"budget.warn_at",
+	"cache.enabled",
+	"cache.max_entries",
+	"cmd.shell_history",
+	"cmd.history_file",
+	"cmd.history_lines",
+	"mcp.confirm",
+	"mcp.max_steps",
+	"mcp.servers.",
+	"templates.",
+	"profiles.",
+}
+
+// validModes lists the mode names a template may reference.
+var validModes = []string{"plan", "edit", "agent", "cmd", "ask"}
+
+// PromptCommands are the prompt's built-in slash commands other than the modes', without
+// the slash, in the order they are listed
+var PromptCommands = []string{"tpl", "config", "projects", "sessions", "restore", "trash", "mcp", "fix-tests", "build", "scaffold", "grep", "where", "callers", "compare", "model", "preview", "aside", "share", "why", "budget", "cache", "temp", "ctx", "seed", "apply", "copy", "run", "more", "regen", "e", "private", "dryrun", "hunks", "think", "brief", "yolo", "menu", "compact", "pin", "tasks", "rollback", "clear"}
+
+// reservedCommands are the built-in slash commands a custom mode can't be named after
+var reservedCommands = slices.Concat(validModes, PromptCommands)
+
+// customModeNamePattern matches names usable as a slash command
+var customModeNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidationError collects every problem found in the config so users can fix them in one pass
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid config:")
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p)
+	}
+	if path, err := ConfigPath(); err == nil {
+		b.WriteString("\nEdit ")
+		b.WriteString(path)
+		b.WriteString(" to fix these settings.")
+	}
+	return b.String()
+}
+
+// Validate checks the config for values that would otherwise fail later with cryptic errors
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.Ollama.Host == "" {
+		problems = append(problems, "ollama.host is empty; set it to your Ollama URL, e.g. http://localhost:11434")
+	} else if problem := checkHost("ollama.host", c.Ollama.Host); problem != "" {
+		problems = append(problems, problem)
+	}
+
+	if problem := checkTemperature("ollama.temperature", c.Ollama.Temperature); problem != "" {
+		problems = append(problems, problem)
+	}
+
+	for _, name := range c.ProfileNames() {
+		p := c.Profiles[name]
+		if p.Host != "" {
+			if problem := checkHost("profiles."+name+".host", p.Host); problem != "" {
+				problems = append(problems, problem)
+			}
+		}
+		if p.Temperature != nil {
+			if problem := checkTemperature("profiles."+name+".temperature", *p.Temperature); problem != "" {
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	switch c.UI.DefaultMode {
+	case "", "last", "auto":
+	default:
+		if !c.isModeName(c.UI.DefaultMode) {
+			problems = append(problems, fmt.Sprintf("ui.default_mode %q is unknown; use last, auto or one of %s", c.UI.DefaultMode, strings.Join(validModes, ", ")))
+		}
+	}
+
+	for _, mode := range c.Models.Modes() {
+		if !c.isModeName(mode) {
+			problems = append(problems, fmt.Sprintf("models.%s: %q is not a mode; use one of %s or a custom mode", mode, mode, strings.Join(validModes, ", ")))
+		}
+	}
+
+	if c.UI.Display != "" && !slices.Contains(DisplayStyles, c.UI.Display) {
+		problems = append(problems, fmt.Sprintf("ui.display %q is unknown; use %s", c.UI.Display, strings.Join(DisplayStyles, ", ")))
+	}
+	modeDisplays := make([]string, 0, len(c.UI.ModeDisplay))
+	for mode := range c.UI.ModeDisplay {
+		modeDisplays = append(modeDisplays, mode)
+	}
+	sort.Strings(modeDisplays)
+	for _, mode := range modeDisplays {
+		if !c.isModeName(mode) {
+			problems = append(problems, fmt.Sprintf("ui.mode_display.%s: %q is not a mode; use one of %s or a custom mode", mode, mode, strings.Join(validModes, ", ")))
+		} else if style := c.UI.ModeDisplay[mode]; !slices.Contains(DisplayStyles, style) {
+			problems = append(problems, fmt.Sprintf("ui.mode_display.%s %q is unknown; use %s", mode, style, strings.Join(DisplayStyles, ", ")))
+		}
+	}
+
+	if c.Context.MaxFileBytes < 0 {
+		problems = append(problems, fmt.Sprintf("context.max_file_bytes %d is negative; use 0 for unlimited or a size in bytes such as 262144", c.Context.MaxFileBytes))
+	}
+	if c.Context.MaxTotalTokens < 0 {
+		problems = append(problems, fmt.Sprintf("context.max_total_tokens %d is negative; use 0 for unlimited or a token budget such as 32000", c.Context.MaxTotalTokens))
+	}
+	problems = append(problems, checkPatterns("edits.allow_write", c.Edits.AllowWrite)...)
+	problems = append(problems, checkPatterns("edits.deny_write", c.Edits.DenyWrite)...)
+	switch c.Context.Secrets {
+	case "", "redact", "confirm", "off":
+	default:
+		problems = append(problems, fmt.Sprintf("context.secrets %q is unknown; use redact, confirm or off", c.Context.Secrets))
+	}
+	switch c.Context.Compress {
+	case "", "off", "strip", "summarize":
+	default:
+		problems = append(problems, fmt.Sprintf("context.compress %q is unknown; use off, strip or summarize", c.Context.Compress))
+	}
+
+	if c.Backups.Keep < 1 {
+		problems = append(problems, fmt.Sprintf("backups.keep %d must be at least 1 (10 is the default)", c.Backups.Keep))
+	}
+
+	if c.PreCommit.Action != "" && c.PreCommit.Action != "warn" && c.PreCommit.Action != "block" {
+		problems = append(problems, fmt.Sprintf("precommit.action %q is unknown; use warn or block", c.PreCommit.Action))
+	}
+
+	switch strings.ToLower(c.Logging.Level) {
+	case "", "debug", "info", "warn", "warning", "error", "off":
+	default:
+		problems = append(problems, fmt.Sprintf("logging.level %q is unknown; use debug, info, warn, error or off", c.Logging.Level))
+	}
+	if c.Logging.Format != "" && c.Logging.Format != "text" && c.Logging.Format != "json" {
+		problems = append(problems, fmt.Sprintf("logging.format %q is unknown; use text or json", c.Logging.Format))
+	}
+
+	if p := c.Agent.BranchPrefix; strings.ContainsAny(p, " \t~^:?*[\\") || strings.Contains(p, "..") || strings.HasPrefix(p, "-") {
+		problems = append(problems, fmt.Sprintf("agent.branch_prefix %q is not a valid git branch prefix", p))
+	}
+
+	if c.Agent.ScaffoldMaxFiles < 1 {
+		problems = append(problems, fmt.Sprintf("agent.scaffold_max_files %d must be at least 1 (60 is the default)", c.Agent.ScaffoldMaxFiles))
+	}
+
+	if c.Test.MaxIterations < 1 {
+		problems = append(problems, fmt.Sprintf("test.max_iterations %d must be at least 1 (3 is the default)", c.Test.MaxIterations))
+	}
+	if c.Build.MaxIterations < 1 {
+		problems = append(problems, fmt.Sprintf("build.max_iterations %d must be at least 1 (3 is the default)", c.Build.MaxIterations))
+	}
+
+	if c.Index.ChunkLines < 1 {
+		problems = append(problems, fmt.Sprintf("index.chunk_lines %d must be at least 1 (60 is the default)", c.Index.ChunkLines))
+	} else if c.Index.ChunkOverlap < 0 || c.Index.ChunkOverlap >= c.Index.ChunkLines {
+		problems = append(problems, fmt.Sprintf("index.chunk_overlap %d must be at least 0 and less than index.chunk_lines (%d)", c.Index.ChunkOverlap, c.Index.ChunkLines))
+	}
+
+	if c.Index.TopK < 0 {
+		problems = append(problems, fmt.Sprintf("index.top_k %d must not be negative (0 turns retrieval off)", c.Index.TopK))
+	}
+
+	for i, rule := range c.Index.Chunking {
+		if !slices.Contains(ChunkingStrategies, rule.Strategy) {
+			problems = append(problems, fmt.Sprintf("index.chunking[%d].strategy %q is not one of %s", i, rule.Strategy, strings.Join(ChunkingStrategies, ", ")))
+		}
+		if len(rule.Files) == 0 {
+			problems = append(problems, fmt.Sprintf("index.chunking[%d].files is empty; list the files it applies to, e.g. [\"*.go\"]", i))
+		}
+	}
+
+	for _, tools := range []struct {
+		key      string
+		commands []ToolCommand
+	}{{"format.formatters", c.Format.Formatters}, {"format.linters", c.Format.Linters}} {
+		for i, tool := range tools.commands {
+			if strings.TrimSpace(tool.Command) == "" {
+				problems = append(problems, fmt.Sprintf("%s[%d].command is empty; set the command to run, e.g. gofmt -w {file}", tools.key, i))
+			}
+			if len(tool.Files) == 0 {
+				problems = append(problems, fmt.Sprintf("%s[%d].files is empty; list the files it applies to, e.g. [\"*.go\"]", tools.key, i))
+			}
+		}
+	}
+
+	if c.MCP.MaxSteps < 1 {
+		problems = append(problems, fmt.Sprintf("mcp.max_steps %d must be at least 1 (8 is the default)", c.MCP.MaxSteps))
+	}
+	for _, name := range c.MCPServerNames() {
+		server := c.MCP.Servers[name]
+		if strings.TrimSpace(server.Command) == "" {
+			problems = append(problems, fmt.Sprintf("mcp.servers.%s.command is empty; set the program that starts the server, e.g. npx", name))
+		}
+		for _, entry := range server.Env {
+			if key, _, ok := strings.Cut(entry, "="); !ok || key == "" {
+				problems = append(problems, fmt.Sprintf("mcp.servers.%s.env entry %q must have the form KEY=VALUE", name, entry))
+			}
+		}
+	}
+
+	for _, hooks := range []struct {
+		key      string
+		commands []string
+	}{
+		{"hooks.pre_edit", c.Hooks.PreEdit}, {"hooks.post_edit", c.Hooks.PostEdit},
+		{"hooks.pre_command", c.Hooks.PreCommand}, {"hooks.post_command", c.Hooks.PostCommand},
+		{"hooks.pre_tool", c.Hooks.PreTool}, {"hooks.post_tool", c.Hooks.PostTool},
+	} {
+		for i, command := range hooks.commands {
+			if strings.TrimSpace(command) == "" {
+				problems = append(problems, fmt.Sprintf("%s[%d] is empty; set the command to run, e.g. git add {file}", hooks.key, i))
+			}
+		}
+	}
+
+	if c.Router.Model == "" && (c.Router.SmallModel != "" || c.Router.LargeModel != "") {
+		problems = append(problems, "router.small_model and router.large_model need router.model to rate requests; set router.model to a small, fast model")
+	}
+
+	if c.Budget.SessionTokens < 0 || c.Budget.DailyTokens < 0 || c.Budget.SessionMinutes < 0 || c.Budget.DailyMinutes < 0 {
+		problems = append(problems, "budget limits must be 0 (no limit) or more")
+	}
+	if c.Budget.WarnAt < 1 || c.Budget.WarnAt > 100 {
+		problems = append(problems, fmt.Sprintf("budget.warn_at is %d; must be a percentage between 1 and 100", c.Budget.WarnAt))
+	}
+	if c.Ollama.Seed < 0 {
+		problems = append(problems, fmt.Sprintf("ollama.seed %d must be 0 (random) or more", c.Ollama.Seed))
+	}
+	if c.Ollama.NumCtx < 0 {
+		problems = append(problems, fmt.Sprintf("ollama.num_ctx %d must be 0 (the model's default) or more", c.Ollama.NumCtx))
+	}
+	limitedModes := make([]string, 0, len(c.Ollama.NumPredict))
+	for mode := range c.Ollama.NumPredict {
+		limitedModes = append(limitedModes, mode)
+	}
+	sort.Strings(limitedModes)
+	for _, mode := range limitedModes {
+		if !c.isModeName(mode) {
+			problems = append(problems, fmt.Sprintf("ollama.num_predict.%s: %q is not a mode; use one of %s or a custom mode", mode, mode, strings.Join(validModes, ", ")))
+		} else if n := c.Ollama.NumPredict[mode]; n < 0 {
+			problems = append(problems, fmt.Sprintf("ollama.num_predict.%s %d must be 0 (no limit) or more", mode, n))
+		}
+	}
+	if c.UI.BriefTokens < 1 {
+		problems = append(problems, fmt.Sprintf("ui.brief_tokens %d must be at least 1 (300 is the default)", c.UI.BriefTokens))
+	}
+	if c.Cache.MaxEntries < 1 {
+		problems = append(problems, fmt.Sprintf("cache.max_entries %d must be at least 1 (1000 is the default)", c.Cache.MaxEntries))
+	}
+	if c.Cmd.HistoryLines < 1 {
+		problems = append(problems, fmt.Sprintf("cmd.history_lines %d must be at least 1 (1000 is the default)", c.Cmd.HistoryLines))
+	}
+
+	seen := map[string]bool{}
+	for i, m := range c.CustomModes {
+		key := fmt.Sprintf("custom_modes[%d]", i)
+		switch {
+		case !customModeNamePattern.MatchString(m.Name):
+			problems = append(problems, fmt.Sprintf("%s.name %q must be a lower-case word usable as /<name>, e.g. review", key, m.Name))
+		case slices.Contains(reservedCommands, m.Name):
+			problems = append(problems, fmt.Sprintf("%s.name %q is a built-in command; pick another name", key, m.Name))
+		case seen[m.Name]:
+			problems = append(problems, fmt.Sprintf("%s.name %q is used by another custom mode", key, m.Name))
+		}
+		seen[m.Name] = true
+		if strings.TrimSpace(m.SystemPrompt) == "" {
+			problems = append(problems, fmt.Sprintf("%s.system_prompt is empty; describe what the mode should do", key))
+		}
+		if m.Output != "" && !slices.Contains(CustomModeOutputs, m.Output) {
+			problems = append(problems, fmt.Sprintf("%s.output %q is not one of %s", key, m.Output, strings.Join(CustomModeOutputs, ", ")))
+		}
+		if m.Temperature != nil {
+			if problem := checkTemperature(key+".temperature", *m.Temperature); problem != "" {
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	for _, name := range sortedTemplateNames(c.Templates) {
+		t := c.Templates[name]
+		if strings.TrimSpace(t.Prompt) == "" {
+			problems = append(problems, fmt.Sprintf("templates.%s.prompt is empty; add the prompt text for this template", name))
+		}
+		if t.Mode != "" && !c.isModeName(t.Mode) {
+			problems = append(problems, fmt.Sprintf("templates.%s.mode %q is unknown; use one of %s", name, t.Mode, strings.Join(validModes, ", ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// checkHost returns a problem if host is not a usable Ollama URL
+func checkHost(key, host string) string {
+	u, err := url.Parse(host)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("%s %q is not a valid URL: %v", key, host, err)
+	case u.Scheme != "http" && u.Scheme != "https":
+		return fmt.Sprintf("%s %q must start with http:// or https://, e.g. http://%s", key, host, strings.TrimPrefix(host, u.Scheme+"://"))
+	case u.Host == "":
+		return fmt.Sprintf("%s %q has no host name, e.g. http://localhost:11434", key, host)
+	}
+	return ""
+}
+
+// checkTemperature returns a problem if temperature is outside Ollama's useful range
+func checkTemperature(key string, temperature float64) string {
+	if temperature < 0 || temperature > 2 {
+		return fmt.Sprintf("%s %.2f is out of range; use a value between 0.0 and 2.0 (0.7 is a good default)", key, temperature)
+	}
+	return ""
+}
+
+// UnknownKeys returns keys present in the config file that LlamaSidekick doesn't recognise,
+// which usually indicates a typo
+func UnknownKeys() []string {
+	var unknown []string
+	for _, key := range viper.AllKeys() {
+		if !isKnownKey(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// UninstalledModel is a configured model that isn't installed, with the keys that use it
+type UninstalledModel struct {
+	Model string
+	Keys  []string // e.g. models.edit, in config order
+}
+
+// UninstalledModels returns the configured models that are not in the installed list, in
+// the order they are first used in the config
+func (c *Config) UninstalledModels(installed []string) []UninstalledModel {
+	var missing []UninstalledModel
+	for _, entry := range c.configuredModels() {
+		if entry.model == "" || ModelInstalled(entry.model, installed) {
+			continue
+		}
+		i := slices.IndexFunc(missing, func(m UninstalledModel) bool { return m.Model == entry.model })
+		if i < 0 {
+			missing = append(missing, UninstalledModel{Model: entry.model})
+			i = len(missing) - 1
+		}
+		missing[i].Keys = append(missing[i].Keys, entry.key)
+	}
+	return missing
+}
+
+// MissingModels returns a warning for every configured model that is not in the installed list
+func (c *Config) MissingModels(installed []string) []string {
+	var warnings []string
+	for _, entry := range c.configuredModels() {
+		if entry.model == "" || ModelInstalled(entry.model, installed) {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s uses model %q which is not installed; run 'ollama pull %s' or pick another model via Configure Models", entry.key, entry.model, entry.model))
+	}
+	return warnings
+}
+
+// configuredModel is a model config key and the model it is set to
+type configuredModel struct{ key, model string }
+
+// configuredModels returns every model setting, in config order
+func (c *Config) configuredModels() []configuredModel {
+	configured := []configuredModel{{"ollama.model", c.Ollama.Model}}
+	for _, mode := range c.Models.Modes() {
+		configured = append(configured, configuredModel{"models." + mode, c.Models.Get(mode)})
+	}
+	return append(configured,
+		configuredModel{"router.model", c.Router.Model},
+		configuredModel{"router.small_model", c.Router.SmallModel},
+		configuredModel{"router.large_model", c.Router.LargeModel},
+	)
+}
+
+// ModelInstalled reports whether model is in the installed list, where "llama3" also
+// matches "llama3:latest"
+func ModelInstalled(model string, installed []string) bool {
+	for _, name := range installed {
+		if name == model || strings.TrimSuffix(name, ":latest") == model {
+			return true
+		}
+	}
+	return false
+}
+
+func isKnownKey(key string) bool {
+	for _, known := range knownKeys {
+		if strings.HasSuffix(known, ".") {
+			if strings.HasPrefix(key, known) {
+				return true
+			}
+		} else if key == known {
+			return true
+		}
+	}
+	return false
+}
+
+// isModeName reports whether mode is a built-in or custom mode
+func (c *Config) isModeName(mode string) bool {
+	_, custom := c.CustomMode(mode)
+	return custom || isValidMode(mode)
+}
+
+func isValidMode(mode string) bool {
+	for _, m := range validModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedTemplateNames(templates map[string]TemplateConfig) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkPatterns returns a problem for every pattern of key that is empty or malformed
+func checkPatterns(key string, patterns []string) []string {
+	var problems []string
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+			problems = append(problems, fmt.Sprintf("%s: %q is not a valid pattern; use globs like deploy/** or *.env", key, pattern))
+		}
+	}
+	return problems
+}
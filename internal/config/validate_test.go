@@ -0,0 +1,233 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func validConfig() *Config {
+	return &Config{
+		Ollama:  OllamaConfig{Host: "http://localhost:11434", Model: "codellama:7b", Temperature: 0.7},
+		Backups: BackupsConfig{Keep: 10},
+		MCP:     MCPConfig{MaxSteps: 8},
+		Agent:   AgentConfig{ScaffoldMaxFiles: 60},
+		Test:    TestConfig{MaxIterations: 3},
+		Build:   BuildConfig{MaxIterations: 3},
+		Index:   IndexConfig{Model: "nomic-embed-text", ChunkLines: 60, ChunkOverlap: 10},
+		Budget:  BudgetConfig{WarnAt: 80},
+		Cache:   CacheConfig{Enabled: true, MaxEntries: 1000},
+		Cmd:     CmdConfig{HistoryLines: 1000},
+		UI:      UIConfig{BriefTokens: 300},
+	}
+}
+
+func TestValidate_AcceptsDefaults(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := validConfig()
+	cfg.Ollama.Host = "localhost:11434"
+	cfg.Ollama.Temperature = 3
+	cfg.Templates = map[string]TemplateConfig{"review": {Mode: "review"}}
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(verr.Problems) != 4 {
+		t.Fatalf("expected 4 problems, got %d: %v", len(verr.Problems), verr.Problems)
+	}
+	if !strings.Contains(verr.Problems[0], "http://") {
+		t.Fatalf("expected host hint, got %q", verr.Problems[0])
+	}
+}
+
+func TestValidate_MCPServers(t *testing.T) {
+	cfg := validConfig()
+	cfg.MCP.Servers = map[string]MCPServerConfig{
+		"github": {Command: "npx", Env: []string{"GITHUB_TOKEN=keyring:github"}},
+		"broken": {Env: []string{"NOVALUE"}},
+		"off":    {Disabled: true},
+	}
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(verr.Problems) != 2 || !strings.Contains(verr.Problems[0], "mcp.servers.broken.command") {
+		t.Fatalf("unexpected problems: %v", verr.Problems)
+	}
+	if names := cfg.MCPServerNames(); len(names) != 2 || names[0] != "broken" {
+		t.Fatalf("unexpected enabled servers: %v", names)
+	}
+}
+
+func TestValidate_CustomModes(t *testing.T) {
+	hot := 2.5
+	cfg := validConfig()
+	cfg.CustomModes = []CustomModeConfig{
+		{Name: "review", SystemPrompt: "Review the code.", Output: "markdown"},
+		{Name: "review", SystemPrompt: "Again."},
+		{Name: "edit", SystemPrompt: "Clash."},
+		{Name: "Bad Name", Output: "html", Temperature: &hot},
+	}
+	cfg.Templates = map[string]TemplateConfig{"pr": {Mode: "review", Prompt: "review {input}"}}
+	cfg.UI.DefaultMode = "review"
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	want := []string{"used by another", "built-in command", "lower-case word", "system_prompt is empty", "output \"html\"", "temperature 2.50"}
+	if len(verr.Problems) != len(want) {
+		t.Fatalf("expected %d problems, got %v", len(want), verr.Problems)
+	}
+	for i, w := range want {
+		if !strings.Contains(verr.Problems[i], w) {
+			t.Errorf("problem %d: expected %q in %q", i, w, verr.Problems[i])
+		}
+	}
+
+	if m, ok := cfg.CustomMode("review"); !ok || m.SystemPrompt != "Review the code." {
+		t.Fatalf("expected the first review mode, got %+v", m)
+	}
+	cfg.CustomModes[0].Model = "llama3"
+	if got := cfg.GetModelForMode("review"); got != "llama3" {
+		t.Fatalf("expected the custom mode's model, got %q", got)
+	}
+}
+
+func TestValidate_CustomModesCantShadowCommands(t *testing.T) {
+	for _, name := range PromptCommands {
+		cfg := validConfig()
+		cfg.CustomModes = []CustomModeConfig{{Name: name, SystemPrompt: "Shadow."}}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "built-in command") {
+			t.Errorf("/%s: expected a custom mode named after it to be rejected, got %v", name, err)
+		}
+	}
+}
+
+func TestValidate_RouterModels(t *testing.T) {
+	cfg := validConfig()
+	cfg.Router.LargeModel = "qwen2.5-coder:32b"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "need router.model") {
+		t.Fatalf("expected the missing router.model to be reported, got %v", err)
+	}
+	cfg.Router.Model = "qwen2.5:0.5b"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected a valid router config, got %v", err)
+	}
+}
+
+func TestWithModel(t *testing.T) {
+	cfg := validConfig()
+	cfg.Models.Edit = "deepseek-coder:33b"
+	cfg.CustomModes = []CustomModeConfig{{Name: "review", SystemPrompt: "Review.", Model: "llama3"}}
+
+	routed := cfg.WithModel("qwen2.5-coder:1.5b")
+	for _, mode := range []string{"edit", "ask", "review"} {
+		if got := routed.GetModelForMode(mode); got != "qwen2.5-coder:1.5b" {
+			t.Errorf("%s: expected the routed model, got %q", mode, got)
+		}
+	}
+	if cfg.GetModelForMode("edit") != "deepseek-coder:33b" || cfg.GetModelForMode("review") != "llama3" {
+		t.Fatal("WithModel changed the original config")
+	}
+}
+
+func TestMissingModels(t *testing.T) {
+	cfg := validConfig()
+	cfg.Ollama.Model = "llama3"
+	cfg.Models.Edit = "deepseek-coder:33b"
+
+	warnings := cfg.MissingModels([]string{"llama3:latest"})
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", warnings)
+	}
+	if !strings.Contains(warnings[0], "models.edit") {
+		t.Fatalf("unexpected warning: %q", warnings[0])
+	}
+}
+
+func TestUninstalledModels(t *testing.T) {
+	cfg := validConfig()
+	cfg.Ollama.Model = "llama3"
+	cfg.Models.Edit = "deepseek-coder:33b"
+	cfg.Models.Agent = "deepseek-coder:33b"
+	cfg.Router.Model = "qwen2.5:0.5b"
+
+	missing := cfg.UninstalledModels([]string{"llama3:latest"})
+	if len(missing) != 2 {
+		t.Fatalf("expected 2 missing models, got %v", missing)
+	}
+	if missing[0].Model != "deepseek-coder:33b" || strings.Join(missing[0].Keys, ",") != "models.edit,models.agent" {
+		t.Errorf("unexpected first missing model: %+v", missing[0])
+	}
+	if missing[1].Model != "qwen2.5:0.5b" || strings.Join(missing[1].Keys, ",") != "router.model" {
+		t.Errorf("unexpected second missing model: %+v", missing[1])
+	}
+}
+
+func TestDisplayForMode(t *testing.T) {
+	cfg := validConfig()
+	cfg.UI.Stream = true
+	cfg.UI.ModeDisplay = map[string]string{"cmd": DisplayRaw}
+	if got := cfg.DisplayForMode("ask"); got != DisplayLive {
+		t.Errorf("ask: expected %s, got %s", DisplayLive, got)
+	}
+	if got := cfg.DisplayForMode("cmd"); got != DisplayRaw {
+		t.Errorf("cmd: expected %s, got %s", DisplayRaw, got)
+	}
+	cfg.UI.Stream = false
+	if got := cfg.DisplayForMode("cmd"); got != DisplayRender {
+		t.Errorf("cmd without streaming: expected %s, got %s", DisplayRender, got)
+	}
+
+	cfg.UI.Display = "typewriter"
+	cfg.UI.ModeDisplay = map[string]string{"cmd": "render", "review": "raw", "ask": "fast"}
+	var verr *ValidationError
+	if err := cfg.Validate(); !errors.As(err, &verr) || len(verr.Problems) != 3 {
+		t.Fatalf("expected 3 problems, got %v", err)
+	}
+}
+
+func TestValidate_NumPredict(t *testing.T) {
+	cfg := validConfig()
+	cfg.Ollama.NumPredict = map[string]int{"ask": -1, "review": 100, "cmd": 200}
+	var verr *ValidationError
+	if err := cfg.Validate(); !errors.As(err, &verr) || len(verr.Problems) != 2 {
+		t.Fatalf("expected 2 problems, got %v", err)
+	}
+}
+
+func TestValidate_WritePatterns(t *testing.T) {
+	cfg := validConfig()
+	cfg.Edits.AllowWrite = []string{"internal/**", "[bad"}
+	cfg.Edits.DenyWrite = []string{".git", " "}
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(verr.Problems) != 2 || !strings.Contains(verr.Problems[0], "edits.allow_write") || !strings.Contains(verr.Problems[1], "edits.deny_write") {
+		t.Fatalf("unexpected problems: %v", verr.Problems)
+	}
+}
+
+func TestValidate_CmdHistoryLines(t *testing.T) {
+	cfg := validConfig()
+	cfg.Cmd.HistoryLines = 0
+
+	var verr *ValidationError
+	if err := cfg.Validate(); !errors.As(err, &verr) || len(verr.Problems) != 1 || !strings.Contains(verr.Problems[0], "cmd.history_lines") {
+		t.Fatalf("expected a cmd.history_lines problem, got %v", err)
+	}
+}
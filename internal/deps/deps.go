@@ -0,0 +1,141 @@
+// Package deps reads the dependencies a project declares in go.mod and package.json.
+package deps
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dependency is a declared module or package
+type Dependency struct {
+	Name     string
+	Version  string
+	Indirect bool // go.mod "// indirect" requirements and devDependencies
+}
+
+// Manifest is a parsed dependency file
+type Manifest struct {
+	File   string // Relative to the project root, e.g. go.mod
+	Module string // The module or package name the project itself declares
+	Deps   []Dependency
+}
+
+// Load parses the manifests found in root. Missing files are skipped; unreadable or
+// malformed ones are returned as errors alongside the manifests that could be read.
+func Load(root string) ([]Manifest, error) {
+	var manifests []Manifest
+	var errs []string
+	for _, m := range []struct {
+		file  string
+		parse func([]byte) (Manifest, error)
+	}{{"go.mod", ParseGoMod}, {"package.json", ParsePackageJSON}} {
+		data, err := os.ReadFile(filepath.Join(root, m.file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		manifest, err := m.parse(data)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", m.file, err))
+			continue
+		}
+		manifest.File = m.file
+		manifests = append(manifests, manifest)
+	}
+	if len(errs) > 0 {
+		return manifests, fmt.Errorf("failed to read dependencies: %s", strings.Join(errs, "; "))
+	}
+	return manifests, nil
+}
+
+// ParseGoMod parses the module path and require directives of a go.mod file
+func ParseGoMod(data []byte) (Manifest, error) {
+	var m Manifest
+	inRequire := false
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		indirect := strings.Contains(line, "// indirect")
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case line == "":
+		case inRequire && line == ")":
+			inRequire = false
+		case inRequire:
+			if dep, ok := goRequirement(line, indirect); ok {
+				m.Deps = append(m.Deps, dep)
+			}
+		case strings.HasPrefix(line, "module "):
+			m.Module = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`)
+		case line == "require (":
+			inRequire = true
+		case strings.HasPrefix(line, "require "):
+			if dep, ok := goRequirement(strings.TrimPrefix(line, "require "), indirect); ok {
+				m.Deps = append(m.Deps, dep)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return m, err
+	}
+	if m.Module == "" {
+		return m, fmt.Errorf("no module directive")
+	}
+	return m, nil
+}
+
+func goRequirement(line string, indirect bool) (Dependency, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return Dependency{}, false
+	}
+	return Dependency{Name: strings.Trim(fields[0], `"`), Version: fields[1], Indirect: indirect}, true
+}
+
+// ParsePackageJSON parses the name, dependencies and devDependencies of a package.json
+func ParsePackageJSON(data []byte) (Manifest, error) {
+	var pkg struct {
+		Name            string            `json:"name"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+		PeerDeps        map[string]string `json:"peerDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return Manifest{}, err
+	}
+	m := Manifest{Module: pkg.Name}
+	add := func(deps map[string]string, indirect bool) {
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			m.Deps = append(m.Deps, Dependency{Name: name, Version: deps[name], Indirect: indirect})
+		}
+	}
+	add(pkg.Dependencies, false)
+	add(pkg.PeerDeps, false)
+	add(pkg.DevDependencies, true)
+	return m, nil
+}
+
+// Has reports whether the manifest declares name
+func (m Manifest) Has(name string) bool {
+	for _, d := range m.Deps {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
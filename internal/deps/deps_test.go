@@ -0,0 +1,87 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testGoMod = `module example.com/app
+
+go 1.23.0
+
+require github.com/spf13/viper v1.18.2
+
+require (
+	github.com/charmbracelet/glamour v0.7.0
+	golang.org/x/term v0.20.0 // indirect
+)
+`
+
+func TestParseGoMod(t *testing.T) {
+	m, err := ParseGoMod([]byte(testGoMod))
+	if err != nil {
+		t.Fatalf("ParseGoMod: %v", err)
+	}
+	want := []Dependency{
+		{Name: "github.com/spf13/viper", Version: "v1.18.2"},
+		{Name: "github.com/charmbracelet/glamour", Version: "v0.7.0"},
+		{Name: "golang.org/x/term", Version: "v0.20.0", Indirect: true},
+	}
+	if m.Module != "example.com/app" || !reflect.DeepEqual(m.Deps, want) {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+}
+
+func TestLoadAndUndeclaredImports(t *testing.T) {
+	root := t.TempDir()
+	pkg := `{"name": "web", "dependencies": {"react": "^18.2.0", "@tanstack/query": "5.0.0"}, "devDependencies": {"@types/lodash": "4"}}`
+	for name, content := range map[string]string{"go.mod": testGoMod, "package.json": pkg} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifests, err := Load(root)
+	if err != nil || len(manifests) != 2 {
+		t.Fatalf("Load = %+v, %v", manifests, err)
+	}
+
+	goFile := `package main
+
+import (
+	"fmt"
+	"example.com/app/internal/x"
+	"github.com/spf13/viper"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/sirupsen/logrus"
+)
+`
+	if got := UndeclaredImports(manifests, "main.go", goFile); !reflect.DeepEqual(got, []string{"github.com/sirupsen/logrus"}) {
+		t.Errorf("Go imports: got %v", got)
+	}
+
+	jsFile := `import React from "react";
+import { useQuery } from '@tanstack/query/core';
+import map from "lodash/map";
+import fs from "node:fs";
+import path from "path";
+import "./styles.css";
+const axios = require("axios");
+const lazy = import("dayjs");
+`
+	if got := UndeclaredImports(manifests, "src/app.tsx", jsFile); !reflect.DeepEqual(got, []string{"axios", "dayjs"}) {
+		t.Errorf("JS imports: got %v", got)
+	}
+	if got := UndeclaredImports(manifests, "main.py", "import requests"); got != nil {
+		t.Errorf("Python files aren't checked, got %v", got)
+	}
+}
+
+func TestAdded(t *testing.T) {
+	before := Manifest{Deps: []Dependency{{Name: "a"}}}
+	after := Manifest{Deps: []Dependency{{Name: "a"}, {Name: "b", Version: "v1"}}}
+	if got := Added(before, after); !reflect.DeepEqual(got, []Dependency{{Name: "b", Version: "v1"}}) {
+		t.Fatalf("Added = %v", got)
+	}
+}
@@ -0,0 +1,122 @@
+package deps
+
+import (
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// jsImportPattern matches the module of import ... from "x", import "x", import("x") and
+// require("x")
+var jsImportPattern = regexp.MustCompile(`(?:\bfrom\s*|\bimport\s*\(?\s*|\brequire\s*\(\s*)['"]([^'"\s]+)['"]`)
+
+// nodeBuiltins are the Node.js core modules, which need no dependency
+var nodeBuiltins = map[string]bool{
+	"assert": true, "async_hooks": true, "buffer": true, "child_process": true, "cluster": true,
+	"console": true, "crypto": true, "dgram": true, "dns": true, "events": true, "fs": true,
+	"http": true, "http2": true, "https": true, "module": true, "net": true, "os": true,
+	"path": true, "perf_hooks": true, "process": true, "querystring": true, "readline": true,
+	"stream": true, "string_decoder": true, "timers": true, "tls": true, "tty": true, "url": true,
+	"util": true, "v8": true, "vm": true, "worker_threads": true, "zlib": true,
+}
+
+// UndeclaredImports returns the imports in a Go or JavaScript/TypeScript file that no
+// manifest declares. Files of other languages, and languages without a manifest in the
+// project, are not checked.
+func UndeclaredImports(manifests []Manifest, filename, content string) []string {
+	switch path.Ext(filename) {
+	case ".go":
+		if m, ok := find(manifests, "go.mod"); ok {
+			return undeclaredGoImports(m, filename, content)
+		}
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".vue", ".svelte":
+		if m, ok := find(manifests, "package.json"); ok {
+			return undeclaredJSImports(m, content)
+		}
+	}
+	return nil
+}
+
+// Added returns the dependencies after declares that before doesn't
+func Added(before, after Manifest) []Dependency {
+	var added []Dependency
+	for _, d := range after.Deps {
+		if !before.Has(d.Name) {
+			added = append(added, d)
+		}
+	}
+	return added
+}
+
+func find(manifests []Manifest, file string) (Manifest, bool) {
+	for _, m := range manifests {
+		if m.File == file {
+			return m, true
+		}
+	}
+	return Manifest{}, false
+}
+
+func undeclaredGoImports(m Manifest, filename, content string) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), filename, content, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	var missing []string
+	for _, imp := range f.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || !strings.Contains(strings.SplitN(p, "/", 2)[0], ".") {
+			continue // The standard library has no dot in its first path element
+		}
+		if within(p, m.Module) {
+			continue
+		}
+		declared := false
+		for _, d := range m.Deps {
+			if within(p, d.Name) {
+				declared = true
+				break
+			}
+		}
+		if !declared {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+func undeclaredJSImports(m Manifest, content string) []string {
+	var missing []string
+	seen := map[string]bool{}
+	for _, match := range jsImportPattern.FindAllStringSubmatch(content, -1) {
+		spec := match[1]
+		if strings.HasPrefix(spec, ".") || strings.HasPrefix(spec, "/") || strings.HasPrefix(spec, "node:") || strings.Contains(spec, ":") {
+			continue
+		}
+		name := packageName(spec)
+		if nodeBuiltins[name] || name == m.Module || m.Has(name) || m.Has("@types/"+name) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		missing = append(missing, name)
+	}
+	return missing
+}
+
+// packageName returns the package part of a module specifier: "lodash" for
+// "lodash/map" and "@scope/pkg" for "@scope/pkg/sub"
+func packageName(spec string) string {
+	parts := strings.SplitN(spec, "/", 3)
+	if strings.HasPrefix(spec, "@") && len(parts) >= 2 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+// within reports whether import path p is module or a package inside it
+func within(p, module string) bool {
+	return module != "" && (p == module || strings.HasPrefix(p, module+"/"))
+}
@@ -0,0 +1,386 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Kind identifies what happened to a line between the old and new text
+type Kind int
+
+const (
+	Equal Kind = iota
+	Insert
+	Delete
+)
+
+// Line is a single line of a diff
+type Line struct {
+	Kind Kind
+	Text string
+}
+
+// Hunk is a group of changes with surrounding context, as in a unified diff
+type Hunk struct {
+	OldStart int // 1-based first line in the old text
+	OldLines int
+	NewStart int // 1-based first line in the new text
+	NewLines int
+	Lines    []Line
+}
+
+// SplitLines splits text into lines without their trailing newlines
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Lines computes a minimal line diff between a and b using Myers' algorithm, in its
+// linear-space form: the middle snake of the edit path splits the problem in two, so
+// memory stays proportional to the input even for a full rewrite of a large file.
+// Within a run of changes, deletions come before insertions.
+func Lines(a, b []string) []Line {
+	max := (len(a) + len(b) + 1) / 2
+	d := differ{a: a, b: b, offset: max + 1, forward: make([]int, 2*max+3), backward: make([]int, 2*max+3)}
+	d.compare(0, len(a), 0, len(b))
+	return append(d.lines, d.inserts...)
+}
+
+// differ holds the inputs of Lines, the diagonals reused by every middleSnake and the
+// lines found so far
+type differ struct {
+	a, b              []string
+	offset            int
+	forward, backward []int // Furthest x reached on each diagonal, from each end
+	lines             []Line
+	inserts           []Line // Insertions held back until the run of changes ends
+}
+
+// compare adds the diff of a[aLo:aHi] and b[bLo:bHi] to the lines
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.equal(d.a[aLo])
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-1-suffix] == d.b[bHi-1-suffix] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aHi:
+		for _, text := range d.b[bLo:bHi] {
+			d.inserts = append(d.inserts, Line{Kind: Insert, Text: text})
+		}
+	case bLo == bHi:
+		for _, text := range d.a[aLo:aHi] {
+			d.lines = append(d.lines, Line{Kind: Delete, Text: text})
+		}
+	default:
+		// Both ends differ, so the path has at least two edits and the split leaves two
+		// smaller problems
+		x, y := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aHi, y, bHi)
+	}
+
+	for _, text := range d.a[aHi : aHi+suffix] {
+		d.equal(text)
+	}
+}
+
+// equal ends the current run of changes and adds an unchanged line
+func (d *differ) equal(text string) {
+	d.lines = append(d.lines, d.inserts...)
+	d.inserts = d.inserts[:0]
+	d.lines = append(d.lines, Line{Kind: Equal, Text: text})
+}
+
+// middleSnake searches for the shortest edit path from both ends at once and returns a
+// point on it where the two searches meet
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	forward, backward, offset := d.forward, d.backward, d.offset
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	for step := 0; step <= (n+m+1)/2; step++ {
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			// The backward search is one step behind; its diagonal delta-k
+			if odd && delta-k >= -(step-1) && delta-k <= step-1 && x+backward[offset+delta-k] >= n {
+				return aLo + x, bLo + y
+			}
+		}
+		for k := -step; k <= step; k += 2 {
+			// u and w count from the ends of a and b
+			var u int
+			if k == -step || (k != step && backward[offset+k-1] < backward[offset+k+1]) {
+				u = backward[offset+k+1]
+			} else {
+				u = backward[offset+k-1] + 1
+			}
+			w := u - k
+			for u < n && w < m && d.a[aHi-1-u] == d.b[bHi-1-w] {
+				u++
+				w++
+			}
+			backward[offset+k] = u
+			if !odd && delta-k >= -step && delta-k <= step && forward[offset+delta-k]+u >= n {
+				return aHi - u, bHi - w
+			}
+		}
+	}
+	// Unreachable: the searches meet by the time half the edits are made
+	return aHi, bHi
+}
+
+// Hunks groups a line diff into hunks with up to context unchanged lines around each change
+func Hunks(lines []Line, context int) []Hunk {
+	var hunks []Hunk
+	oldLine, newLine := 1, 1
+	i := 0
+	for i < len(lines) {
+		if lines[i].Kind == Equal {
+			i++
+			oldLine++
+			newLine++
+			continue
+		}
+
+		// Start a hunk with leading context
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		for start < i && lines[start].Kind != Equal {
+			start++
+		}
+		h := Hunk{OldStart: oldLine - (i - start), NewStart: newLine - (i - start)}
+		for j := start; j < i; j++ {
+			h.Lines = append(h.Lines, lines[j])
+			h.OldLines++
+			h.NewLines++
+		}
+
+		// Consume changes, merging runs separated by at most 2*context equal lines
+		for i < len(lines) {
+			if lines[i].Kind == Equal {
+				run := 0
+				for i+run < len(lines) && lines[i+run].Kind == Equal {
+					run++
+				}
+				if i+run >= len(lines) || run > 2*context {
+					trailing := run
+					if trailing > context {
+						trailing = context
+					}
+					for j := 0; j < trailing; j++ {
+						h.Lines = append(h.Lines, lines[i+j])
+						h.OldLines++
+						h.NewLines++
+					}
+					i += run
+					oldLine += run
+					newLine += run
+					break
+				}
+				for j := 0; j < run; j++ {
+					h.Lines = append(h.Lines, lines[i+j])
+				}
+				h.OldLines += run
+				h.NewLines += run
+				i += run
+				oldLine += run
+				newLine += run
+				continue
+			}
+			h.Lines = append(h.Lines, lines[i])
+			if lines[i].Kind == Delete {
+				h.OldLines++
+				oldLine++
+			} else {
+				h.NewLines++
+				newLine++
+			}
+			i++
+		}
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
+
+// Unified returns a unified diff between oldText and newText, or "" if they are equal.
+// An empty oldName or newName is rendered as /dev/null (file created or deleted).
+func Unified(oldName, newName, oldText, newText string, context int) string {
+	if oldText == newText {
+		return ""
+	}
+	hunks := Hunks(Lines(SplitLines(oldText), SplitLines(newText)), context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(Header(oldName, newName))
+	for _, h := range hunks {
+		b.WriteString(h.String())
+	}
+	return b.String()
+}
+
+// Header returns the ---/+++ lines that start a unified diff between oldName and newName
+func Header(oldName, newName string) string {
+	return "--- " + diffName("a/", oldName) + "\n+++ " + diffName("b/", newName) + "\n"
+}
+
+// String returns the hunk in unified diff format, starting with its @@ line
+func (h Hunk) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines)))
+	for _, l := range h.Lines {
+		switch l.Kind {
+		case Equal:
+			b.WriteString(" ")
+		case Insert:
+			b.WriteString("+")
+		case Delete:
+			b.WriteString("-")
+		}
+		b.WriteString(l.Text + "\n")
+	}
+	return b.String()
+}
+
+// ParseHunk reads an edited copy of h in unified diff format, as a user leaves it after
+// changing its lines: "#" comment lines and the @@ line are ignored, and the old lines
+// (context and "-") must still match h's so the hunk applies to the same place. An
+// empty text returns an error, as does an unknown line prefix.
+func ParseHunk(text string, h Hunk) (Hunk, error) {
+	edited := Hunk{OldStart: h.OldStart, NewStart: h.NewStart}
+	for _, line := range SplitLines(text) {
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@@") {
+			continue
+		}
+		if line == "" {
+			// Editors often strip the trailing space of an empty context line
+			line = " "
+		}
+		var kind Kind
+		switch line[0] {
+		case ' ':
+			kind = Equal
+		case '+':
+			kind = Insert
+		case '-':
+			kind = Delete
+		default:
+			return Hunk{}, fmt.Errorf("unexpected line %q: lines must start with ' ', '+' or '-'", line)
+		}
+		edited.Lines = append(edited.Lines, Line{Kind: kind, Text: line[1:]})
+		if kind != Insert {
+			edited.OldLines++
+		}
+		if kind != Delete {
+			edited.NewLines++
+		}
+	}
+	if len(edited.Lines) == 0 {
+		return Hunk{}, fmt.Errorf("the hunk is empty")
+	}
+	if !slices.Equal(oldSide(edited), oldSide(h)) {
+		return Hunk{}, fmt.Errorf("the edited hunk's context and removed lines no longer match the file")
+	}
+	return edited, nil
+}
+
+// oldSide returns the lines of the old text a hunk covers
+func oldSide(h Hunk) []string {
+	var lines []string
+	for _, l := range h.Lines {
+		if l.Kind != Insert {
+			lines = append(lines, l.Text)
+		}
+	}
+	return lines
+}
+
+// Apply returns oldText with hunks applied. The hunks must come from Hunks on a diff of
+// oldText, in order, but can be any of them, so a change can be applied in part. The
+// result ends with a newline when oldText does, or when oldText is empty.
+func Apply(oldText string, hunks []Hunk) string {
+	old := SplitLines(oldText)
+	var lines []string
+	next := 0
+	for _, h := range hunks {
+		start := h.OldStart - 1
+		lines = append(lines, old[next:start]...)
+		for _, l := range h.Lines {
+			if l.Kind != Delete {
+				lines = append(lines, l.Text)
+			}
+		}
+		next = start + h.OldLines
+	}
+	lines = append(lines, old[next:]...)
+	if len(lines) == 0 {
+		return ""
+	}
+	text := strings.Join(lines, "\n")
+	if oldText == "" || strings.HasSuffix(oldText, "\n") {
+		text += "\n"
+	}
+	return text
+}
+
+// Stat returns the number of added and removed lines between oldText and newText
+func Stat(oldText, newText string) (added, removed int) {
+	for _, l := range Lines(SplitLines(oldText), SplitLines(newText)) {
+		switch l.Kind {
+		case Insert:
+			added++
+		case Delete:
+			removed++
+		}
+	}
+	return added, removed
+}
+
+func diffName(prefix, name string) string {
+	if name == "" {
+		return "/dev/null"
+	}
+	return prefix + name
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// Unified diff convention: an empty range points at the line before
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
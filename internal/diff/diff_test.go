@@ -0,0 +1,135 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\n"
+
+	got := Unified("x.txt", "x.txt", oldText, newText, 1)
+	want := "--- a/x.txt\n+++ b/x.txt\n" +
+		"@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n" +
+		"@@ -8 +8,2 @@\n h\n+i\n"
+	if got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_NewFile(t *testing.T) {
+	got := Unified("", "new.txt", "", "hello\n", 3)
+	want := "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+hello\n"
+	if got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestStat(t *testing.T) {
+	added, removed := Stat("a\nb\nc\n", "a\nc\nd\ne\n")
+	if added != 2 || removed != 1 {
+		t.Fatalf("expected +2 -1, got +%d -%d", added, removed)
+	}
+	if Unified("f", "f", "same\n", "same\n", 3) != "" {
+		t.Fatalf("expected empty diff for identical text")
+	}
+}
+
+func TestLines_FullRewriteOfALargeFile(t *testing.T) {
+	var oldText, newText strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&oldText, "old line %d\n", i)
+		fmt.Fprintf(&newText, "new line %d\n", i)
+	}
+	if added, removed := Stat(oldText.String(), newText.String()); added != 5000 || removed != 5000 {
+		t.Fatalf("expected +5000 -5000, got +%d -%d", added, removed)
+	}
+	lines := Lines(SplitLines(oldText.String()), SplitLines(newText.String()))
+	if lines[0].Kind != Delete || lines[4999].Kind != Delete || lines[5000].Kind != Insert {
+		t.Fatal("expected the deletions before the insertions")
+	}
+}
+
+func TestLines_IsMinimal(t *testing.T) {
+	a := SplitLines("a\nb\nc\na\nb\nb\na\n")
+	b := SplitLines("c\nb\na\nb\na\nc\n")
+	edits := 0
+	var oldLines, newLines []string
+	for _, l := range Lines(a, b) {
+		if l.Kind != Equal {
+			edits++
+		}
+		if l.Kind != Insert {
+			oldLines = append(oldLines, l.Text)
+		}
+		if l.Kind != Delete {
+			newLines = append(newLines, l.Text)
+		}
+	}
+	// The example from Myers' paper: the shortest edit script has 5 edits
+	if edits != 5 {
+		t.Fatalf("expected 5 edits, got %d", edits)
+	}
+	if strings.Join(oldLines, "") != strings.Join(a, "") || strings.Join(newLines, "") != strings.Join(b, "") {
+		t.Fatalf("the diff doesn't turn a into b: %v", Lines(a, b))
+	}
+}
+
+func TestApply(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	newText := "x\na\nB\nc\nd\ne\nf\ng\nh\ni\n"
+	for _, context := range []int{0, 1, 3} {
+		hunks := Hunks(Lines(SplitLines(oldText), SplitLines(newText)), context)
+		if got := Apply(oldText, hunks); got != newText {
+			t.Errorf("context %d: applying every hunk gave %q", context, got)
+		}
+		if got := Apply(oldText, nil); got != oldText {
+			t.Errorf("context %d: applying no hunk gave %q", context, got)
+		}
+	}
+
+	hunks := Hunks(Lines(SplitLines(oldText), SplitLines(newText)), 1)
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(hunks))
+	}
+	if got := Apply(oldText, hunks[1:]); got != "a\nb\nc\nd\ne\nf\ng\nh\ni\n" {
+		t.Errorf("applying the last hunk gave %q", got)
+	}
+	if got := Apply("", Hunks(Lines(nil, []string{"new"}), 3)); got != "new\n" {
+		t.Errorf("applying to an empty file gave %q", got)
+	}
+}
+
+func TestParseHunk(t *testing.T) {
+	h := Hunks(Lines(SplitLines("a\nb\nc\n"), SplitLines("a\nB\nc\n")), 1)[0]
+	text := "# Edit the hunk\n" + h.String()
+
+	same, err := ParseHunk(text, h)
+	if err != nil || same.String() != h.String() {
+		t.Fatalf("unchanged hunk parsed as %q (%v)", same.String(), err)
+	}
+
+	// Keep b, and add a line of our own instead of B
+	edited, err := ParseHunk("@@ -1,3 +1,3 @@\n a\n b\n+mine\n\n", Hunk{OldStart: 1, Lines: []Line{{Equal, "a"}, {Delete, "b"}, {Insert, "B"}, {Equal, ""}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.OldLines != 3 || edited.NewLines != 4 {
+		t.Errorf("expected -3 +4 lines, got -%d +%d", edited.OldLines, edited.NewLines)
+	}
+	if got := Apply("a\nb\n\nz\n", []Hunk{edited}); got != "a\nb\nmine\n\nz\n" {
+		t.Errorf("applying the edited hunk gave %q", got)
+	}
+
+	if _, err := ParseHunk(" a\n-x\n c\n", h); err == nil {
+		t.Error("expected an error for a hunk whose old lines changed")
+	}
+	if _, err := ParseHunk("a\n", h); err == nil {
+		t.Error("expected an error for a line without a prefix")
+	}
+	if _, err := ParseHunk("# all gone\n", h); err == nil {
+		t.Error("expected an error for an empty hunk")
+	}
+}
@@ -0,0 +1,128 @@
+package diff
+
+import "strings"
+
+// edit replaces base[start:end] with lines
+type edit struct {
+	start, end int
+	lines      []string
+}
+
+// edits converts a line diff from base to another text into replacements of base ranges
+func edits(base, other []string) []edit {
+	var result []edit
+	pos := 0
+	var current *edit
+	for _, l := range Lines(base, other) {
+		if l.Kind == Equal {
+			if current != nil {
+				result = append(result, *current)
+				current = nil
+			}
+			pos++
+			continue
+		}
+		if current == nil {
+			current = &edit{start: pos, end: pos}
+		}
+		if l.Kind == Delete {
+			pos++
+			current.end = pos
+		} else {
+			current.lines = append(current.lines, l.Text)
+		}
+	}
+	if current != nil {
+		result = append(result, *current)
+	}
+	return result
+}
+
+// apply returns base[start:end] with the given edits (all inside the range) applied
+func apply(base []string, start, end int, es []edit) []string {
+	var out []string
+	pos := start
+	for _, e := range es {
+		out = append(out, base[pos:e.start]...)
+		out = append(out, e.lines...)
+		pos = e.end
+	}
+	return append(out, base[pos:end]...)
+}
+
+// Merge3 merges the changes made from base to ours and from base to theirs. Regions changed
+// differently on both sides are emitted with conflict markers labelled oursLabel and
+// theirsLabel. It returns the merged text and the number of conflicts.
+func Merge3(base, ours, theirs, oursLabel, theirsLabel string) (string, int) {
+	baseLines := SplitLines(base)
+	oursEdits := edits(baseLines, SplitLines(ours))
+	theirsEdits := edits(baseLines, SplitLines(theirs))
+
+	var out []string
+	conflicts := 0
+	pos := 0
+	i, j := 0, 0
+	for i < len(oursEdits) || j < len(theirsEdits) {
+		// Start a region at the earliest pending edit and grow it while edits overlap
+		var start int
+		switch {
+		case j >= len(theirsEdits):
+			start = oursEdits[i].start
+		case i >= len(oursEdits):
+			start = theirsEdits[j].start
+		default:
+			start = min(oursEdits[i].start, theirsEdits[j].start)
+		}
+		end := start
+		oi, tj := i, j
+		for {
+			grew := false
+			if oi < len(oursEdits) && overlaps(oursEdits[oi], start, end) {
+				end = max(end, oursEdits[oi].end)
+				oi++
+				grew = true
+			}
+			if tj < len(theirsEdits) && overlaps(theirsEdits[tj], start, end) {
+				end = max(end, theirsEdits[tj].end)
+				tj++
+				grew = true
+			}
+			if !grew {
+				break
+			}
+		}
+
+		out = append(out, baseLines[pos:start]...)
+		oursRegion := apply(baseLines, start, end, oursEdits[i:oi])
+		theirsRegion := apply(baseLines, start, end, theirsEdits[j:tj])
+		switch {
+		case oi == i:
+			out = append(out, theirsRegion...)
+		case tj == j:
+			out = append(out, oursRegion...)
+		case strings.Join(oursRegion, "\n") == strings.Join(theirsRegion, "\n"):
+			out = append(out, oursRegion...)
+		default:
+			conflicts++
+			out = append(out, "<<<<<<< "+oursLabel)
+			out = append(out, oursRegion...)
+			out = append(out, "=======")
+			out = append(out, theirsRegion...)
+			out = append(out, ">>>>>>> "+theirsLabel)
+		}
+		pos = end
+		i, j = oi, tj
+	}
+	out = append(out, baseLines[pos:]...)
+
+	if len(out) == 0 {
+		return "", conflicts
+	}
+	return strings.Join(out, "\n") + "\n", conflicts
+}
+
+// overlaps reports whether e touches the region [start, end). Insertions at the start of
+// the region count as overlapping so both sides inserting at one spot is a conflict.
+func overlaps(e edit, start, end int) bool {
+	return e.start < end || e.start == start
+}
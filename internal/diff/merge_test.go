@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge3_CombinesIndependentChanges(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+	ours := "A\nb\nc\nd\ne\n"
+	theirs := "a\nb\nc\nd\nE\nf\n"
+
+	merged, conflicts := Merge3(base, ours, theirs, "disk", "model")
+	if conflicts != 0 {
+		t.Fatalf("expected no conflicts, got %d:\n%s", conflicts, merged)
+	}
+	if merged != "A\nb\nc\nd\nE\nf\n" {
+		t.Fatalf("unexpected merge:\n%s", merged)
+	}
+}
+
+func TestMerge3_MarksConflicts(t *testing.T) {
+	base := "a\nb\nc\n"
+	ours := "a\nB1\nc\n"
+	theirs := "a\nB2\nc\n"
+
+	merged, conflicts := Merge3(base, ours, theirs, "disk", "model")
+	if conflicts != 1 {
+		t.Fatalf("expected 1 conflict, got %d", conflicts)
+	}
+	want := "a\n<<<<<<< disk\nB1\n=======\nB2\n>>>>>>> model\nc\n"
+	if merged != want {
+		t.Fatalf("unexpected merge:\n%s", merged)
+	}
+}
+
+func TestMerge3_IdenticalChangesDontConflict(t *testing.T) {
+	base := "a\nb\n"
+	same := "a\nb\nc\n"
+	merged, conflicts := Merge3(base, same, same, "disk", "model")
+	if conflicts != 0 || merged != same {
+		t.Fatalf("expected clean merge, got %d conflicts:\n%s", conflicts, merged)
+	}
+	if strings.Contains(merged, "<<<<<<<") {
+		t.Fatalf("unexpected conflict markers")
+	}
+}
@@ -0,0 +1,136 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkHeader matches the @@ line of a unified diff hunk
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ApplyUnified applies a unified diff for a single file, as a model writes one, to
+// oldText. The ---/+++ header is optional and the line numbers of the @@ lines are only
+// a hint: like patch, each hunk applies where its context and removed lines match,
+// nearest the line it names. It returns an error when a hunk matches nowhere.
+func ApplyUnified(oldText, patch string) (string, error) {
+	hunks, err := parseUnified(patch)
+	if err != nil {
+		return "", err
+	}
+	old := SplitLines(oldText)
+	next := 0
+	for i := range hunks {
+		want := oldSide(hunks[i])
+		hint := hunks[i].OldStart - 1
+		if len(want) == 0 {
+			// -N,0 inserts after line N
+			hint = hunks[i].OldStart
+		}
+		start, ok := locate(old, want, hint, next)
+		if !ok {
+			return "", fmt.Errorf("hunk %d does not apply: its context and removed lines don't match the file", i+1)
+		}
+		hunks[i].OldStart = start + 1
+		hunks[i].OldLines = len(want)
+		next = start + len(want)
+	}
+	return Apply(oldText, hunks), nil
+}
+
+// parseUnified reads the hunks of a unified diff. Lines before the first @@ line, such
+// as the ---/+++ header, are skipped, and a hunk ends at a line that isn't part of one.
+func parseUnified(patch string) ([]Hunk, error) {
+	lines := SplitLines(strings.ReplaceAll(patch, "\r\n", "\n"))
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	var hunks []Hunk
+	inHunk := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "@@") {
+			h := Hunk{}
+			if m := hunkHeader.FindStringSubmatch(line); m != nil {
+				h.OldStart, _ = strconv.Atoi(m[1])
+				h.NewStart, _ = strconv.Atoi(m[3])
+			}
+			hunks = append(hunks, h)
+			inHunk = true
+			continue
+		}
+		if !inHunk {
+			continue
+		}
+		if strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+			return nil, fmt.Errorf("the diff changes more than one file")
+		}
+		if strings.HasPrefix(line, `\`) {
+			// "\ No newline at end of file"
+			continue
+		}
+		if line == "" {
+			// Models and editors often strip the space of an empty context line
+			line = " "
+		}
+		var kind Kind
+		switch line[0] {
+		case ' ':
+			kind = Equal
+		case '+':
+			kind = Insert
+		case '-':
+			kind = Delete
+		default:
+			inHunk = false
+			continue
+		}
+		h := &hunks[len(hunks)-1]
+		h.Lines = append(h.Lines, Line{Kind: kind, Text: line[1:]})
+		if kind != Insert {
+			h.OldLines++
+		}
+		if kind != Delete {
+			h.NewLines++
+		}
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("no @@ hunks found in the diff")
+	}
+	for i, h := range hunks {
+		if len(h.Lines) == 0 {
+			return nil, fmt.Errorf("hunk %d is empty", i+1)
+		}
+	}
+	return hunks, nil
+}
+
+// locate returns where want starts in old, at or after min and as near hint as it can,
+// ignoring trailing whitespace
+func locate(old, want []string, hint, min int) (int, bool) {
+	last := len(old) - len(want)
+	if hint < min {
+		hint = min
+	}
+	if hint > last {
+		hint = last
+	}
+	for d := 0; hint-d >= min || hint+d <= last; d++ {
+		for _, p := range []int{hint - d, hint + d} {
+			if p >= min && p <= last && matchesAt(old, want, p) {
+				return p, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// matchesAt reports whether want is in old at p, ignoring trailing whitespace
+func matchesAt(old, want []string, p int) bool {
+	for i, w := range want {
+		if strings.TrimRight(old[p+i], " \t\r") != strings.TrimRight(w, " \t\r") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyUnified(t *testing.T) {
+	oldText := "package main\n\nfunc add(a, b int) int {\n\treturn a - b\n}\n\nfunc main() {}\n"
+
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{
+			name:  "exact",
+			patch: "--- a/main.go\n+++ b/main.go\n@@ -3,3 +3,3 @@\n func add(a, b int) int {\n-\treturn a - b\n+\treturn a + b\n }\n",
+			want:  "package main\n\nfunc add(a, b int) int {\n\treturn a + b\n}\n\nfunc main() {}\n",
+		},
+		{
+			name:  "wrong line numbers and no header",
+			patch: "@@ -40,2 +40,2 @@\n-\treturn a - b\n+\treturn a + b\n }\n\n",
+			want:  "package main\n\nfunc add(a, b int) int {\n\treturn a + b\n}\n\nfunc main() {}\n",
+		},
+		{
+			name:  "insertion",
+			patch: "@@ -7,0 +8,2 @@\n+\n+func sub(a, b int) int { return a - b }\n",
+			want:  oldText + "\nfunc sub(a, b int) int { return a - b }\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := ApplyUnified(oldText, tt.patch)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyUnified_Errors(t *testing.T) {
+	oldText := "a\nb\nc\n"
+	tests := []struct {
+		patch string
+		want  string
+	}{
+		{"@@ -1,2 +1,2 @@\n a\n-x\n+y\n", "does not apply"},
+		{"just some text\n", "no @@ hunks"},
+		{"--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n+A\n--- a/g\n+++ b/g\n@@ -1 +1 @@\n-a\n+A\n", "more than one file"},
+	}
+	for _, tt := range tests {
+		if _, err := ApplyUnified(oldText, tt.patch); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ApplyUnified(%q) error = %v, want %q", tt.patch, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Package envinfo takes a snapshot of the user's environment for troubleshooting: the
+// operating system, the shell, the versions of common tools on the PATH and the names of
+// the environment variables. Variable values are never included, as they often hold
+// tokens and passwords.
+package envinfo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// versionTimeout bounds how long a tool may take to print its version
+const versionTimeout = 2 * time.Second
+
+// tools are the programs whose versions the snapshot includes when they are on the PATH,
+// with the arguments that print their version
+var tools = []struct {
+	name string
+	args []string
+}{
+	{"go", []string{"version"}},
+	{"git", []string{"--version"}},
+	{"make", []string{"--version"}},
+	{"gcc", []string{"--version"}},
+	{"node", []string{"--version"}},
+	{"npm", []string{"--version"}},
+	{"python3", []string{"--version"}},
+	{"python", []string{"--version"}},
+	{"java", []string{"-version"}},
+	{"rustc", []string{"--version"}},
+	{"cargo", []string{"--version"}},
+	{"docker", []string{"--version"}},
+	{"kubectl", []string{"version", "--client"}},
+	{"ollama", []string{"--version"}},
+}
+
+// Tool is a program found on the PATH and the first line of its version output
+type Tool struct {
+	Name    string
+	Version string
+}
+
+// Snapshot describes the environment
+type Snapshot struct {
+	OS        string // e.g. "linux/amd64 (Ubuntu 24.04 LTS)"
+	Shell     string
+	Tools     []Tool
+	Variables []string // Names of the environment variables, sorted
+}
+
+// Take takes a snapshot of the current environment. Tools print their versions in parallel,
+// and one that takes longer than a couple of seconds is left out.
+func Take() Snapshot {
+	snap := Snapshot{OS: describeOS(), Shell: shell(), Variables: variableNames(os.Environ())}
+
+	found := make([]*Tool, len(tools))
+	var wg sync.WaitGroup
+	for i, t := range tools {
+		path, err := exec.LookPath(t.name)
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, path string, args []string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+			defer cancel()
+			out, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+			if err == nil {
+				found[i] = &Tool{Name: tools[i].name, Version: firstLine(out)}
+			}
+		}(i, path, t.args)
+	}
+	wg.Wait()
+	for _, t := range found {
+		if t != nil {
+			snap.Tools = append(snap.Tools, *t)
+		}
+	}
+	return snap
+}
+
+// Describe returns the snapshot as text for a prompt
+func (s Snapshot) Describe() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "OS: %s\n", s.OS)
+	if s.Shell != "" {
+		fmt.Fprintf(&b, "Shell: %s\n", s.Shell)
+	}
+	if len(s.Tools) > 0 {
+		b.WriteString("Tools on the PATH:\n")
+		for _, t := range s.Tools {
+			fmt.Fprintf(&b, "  %s: %s\n", t.Name, t.Version)
+		}
+	}
+	if len(s.Variables) > 0 {
+		fmt.Fprintf(&b, "Environment variables (values redacted): %s\n", strings.Join(s.Variables, ", "))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// describeOS returns the platform and, on Linux, the distribution
+func describeOS() string {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	if runtime.GOOS != "linux" {
+		return platform
+	}
+	f, err := os.Open("/etc/os-release")
+	if err != nil {
+		return platform
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if name, ok := strings.CutPrefix(scanner.Text(), "PRETTY_NAME="); ok {
+			return fmt.Sprintf("%s (%s)", platform, strings.Trim(name, `"`))
+		}
+	}
+	return platform
+}
+
+// shell returns the user's shell: $SHELL, or PowerShell or cmd on Windows
+func shell() string {
+	if sh := os.Getenv("SHELL"); sh != "" {
+		return sh
+	}
+	if runtime.GOOS == "windows" {
+		if os.Getenv("PSModulePath") != "" {
+			return "PowerShell"
+		}
+		return "cmd"
+	}
+	return ""
+}
+
+// variableNames returns the sorted names of the KEY=VALUE entries, without their values
+func variableNames(environ []string) []string {
+	var names []string
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		// Windows keeps per-drive directories in variables such as "=C:"
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// firstLine returns the first non-empty line of a tool's output
+func firstLine(out []byte) string {
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		if text := strings.TrimSpace(string(line)); text != "" {
+			return text
+		}
+	}
+	return ""
+}
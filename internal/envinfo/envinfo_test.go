@@ -0,0 +1,31 @@
+package envinfo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVariableNames(t *testing.T) {
+	got := variableNames([]string{"TOKEN=abc=def", "HOME=/home/me", "=C:=C:\\work", "EMPTY="})
+	if want := []string{"EMPTY", "HOME", "TOKEN"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFirstLine(t *testing.T) {
+	if got := firstLine([]byte("\n  openjdk version \"21\"\nOpenJDK Runtime\n")); got != `openjdk version "21"` {
+		t.Fatalf("unexpected first line %q", got)
+	}
+}
+
+func TestTake_RedactsValues(t *testing.T) {
+	t.Setenv("LLAMASIDEKICK_TEST_TOKEN", "s3cr3t-value")
+	text := Take().Describe()
+	if !strings.Contains(text, "LLAMASIDEKICK_TEST_TOKEN") || strings.Contains(text, "s3cr3t-value") {
+		t.Fatalf("expected the variable's name but not its value in:\n%s", text)
+	}
+	if !strings.HasPrefix(text, "OS: ") {
+		t.Fatalf("expected the OS first, got:\n%s", text)
+	}
+}
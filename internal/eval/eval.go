@@ -0,0 +1,320 @@
+// Package eval reads evaluation suites, golden prompts with assertions about the answers,
+// and checks a mode's outcome against them, so a local model can be tried with each mode
+// before relying on it.
+package eval
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/diff"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed golden/*.yaml
+var golden embed.FS
+
+// Suite is a set of eval cases
+type Suite struct {
+	Mode  string `yaml:"mode"` // Default mode for cases that don't set one
+	Cases []Case `yaml:"cases"`
+}
+
+// Case is one golden prompt. It runs in a throwaway project holding its files.
+type Case struct {
+	Name   string            `yaml:"name"`
+	Mode   string            `yaml:"mode"`
+	Prompt string            `yaml:"prompt"`
+	Files  map[string]string `yaml:"files"` // Project files by path, e.g. main.go
+	Expect []Assertion       `yaml:"expect"`
+}
+
+// Assertion is one check of a case's outcome. Each sets one of json, contains,
+// not_contains, matches, code_block, command, diff_applies or changed; file makes the
+// first four check a project file after the run instead of the answer.
+type Assertion struct {
+	File        string `yaml:"file"`
+	JSON        bool   `yaml:"json"`         // Parses as JSON, also inside a code fence
+	Contains    string `yaml:"contains"`     // Contains the text, ignoring case
+	NotContains string `yaml:"not_contains"` // Doesn't contain the text, ignoring case
+	Matches     string `yaml:"matches"`      // Matches the regular expression
+	CodeBlock   string `yaml:"code_block"`   // Has a fenced code block in this language, or "any"
+	Command     string `yaml:"command"`      // Suggests a command that matches the regular expression
+	DiffApplies string `yaml:"diff_applies"` // Has a unified diff that applies cleanly to this project file
+	Changed     string `yaml:"changed"`      // The mode wrote this project file
+}
+
+// Outcome is what running a case produced
+type Outcome struct {
+	Response string   // The answer, as added to the conversation
+	Commands []string // Commands suggested in the answer
+	Changed  []string // Project files the mode wrote
+	Root     string   // The case's project directory
+}
+
+// Load reads and validates a suite file
+func Load(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read eval suite: %w", err)
+	}
+	return parse(data, path)
+}
+
+// Builtin returns the golden suite that comes with LlamaSidekick, with cases for the
+// ask, plan, edit and cmd modes
+func Builtin() (*Suite, error) {
+	names, err := golden.ReadDir("golden")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in eval suite: %w", err)
+	}
+	all := &Suite{}
+	for _, entry := range names {
+		data, err := golden.ReadFile(path.Join("golden", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read built-in eval suite: %w", err)
+		}
+		suite, err := parse(data, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		all.Cases = append(all.Cases, suite.Cases...)
+	}
+	return all, nil
+}
+
+// parse reads a suite and gives every case a name and a mode
+func parse(data []byte, name string) (*Suite, error) {
+	var s Suite
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse eval suite %s: %w", name, err)
+	}
+	if len(s.Cases) == 0 {
+		return nil, fmt.Errorf("eval suite %s has no cases", name)
+	}
+	if s.Mode == "" {
+		s.Mode = "ask"
+	}
+	for i := range s.Cases {
+		c := &s.Cases[i]
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case %d", i+1)
+		}
+		if c.Mode == "" {
+			c.Mode = s.Mode
+		}
+		if strings.TrimSpace(c.Prompt) == "" {
+			return nil, fmt.Errorf("%s in %s has an empty prompt", c.Name, name)
+		}
+		if len(c.Expect) == 0 {
+			return nil, fmt.Errorf("%s in %s has no expect list", c.Name, name)
+		}
+		for j, a := range c.Expect {
+			if err := a.validate(); err != nil {
+				return nil, fmt.Errorf("%s in %s, assertion %d: %w", c.Name, name, j+1, err)
+			}
+		}
+	}
+	return &s, nil
+}
+
+// validate checks that the assertion sets exactly one check, and that its patterns compile
+func (a Assertion) validate() error {
+	set := 0
+	for _, on := range []bool{a.JSON, a.Contains != "", a.NotContains != "", a.Matches != "", a.CodeBlock != "", a.Command != "", a.DiffApplies != "", a.Changed != ""} {
+		if on {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("set exactly one of json, contains, not_contains, matches, code_block, command, diff_applies or changed")
+	}
+	if a.File != "" && a.CodeBlock+a.Command+a.DiffApplies+a.Changed != "" {
+		return fmt.Errorf("file only works with json, contains, not_contains and matches")
+	}
+	for _, pattern := range []string{a.Matches, a.Command} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// String describes the assertion, e.g. `contains "func"`
+func (a Assertion) String() string {
+	var s string
+	switch {
+	case a.JSON:
+		s = "is JSON"
+	case a.Contains != "":
+		s = fmt.Sprintf("contains %q", a.Contains)
+	case a.NotContains != "":
+		s = fmt.Sprintf("doesn't contain %q", a.NotContains)
+	case a.Matches != "":
+		s = fmt.Sprintf("matches /%s/", a.Matches)
+	case a.CodeBlock == "any":
+		s = "has a code block"
+	case a.CodeBlock != "":
+		s = fmt.Sprintf("has a %s code block", a.CodeBlock)
+	case a.Command != "":
+		s = fmt.Sprintf("suggests a command matching /%s/", a.Command)
+	case a.DiffApplies != "":
+		return fmt.Sprintf("has a diff that applies to %s", a.DiffApplies)
+	case a.Changed != "":
+		return fmt.Sprintf("changed %s", a.Changed)
+	}
+	if a.File != "" {
+		return a.File + " " + s
+	}
+	return "answer " + s
+}
+
+// Check returns why the outcome fails the assertion, or nil when it passes
+func (a Assertion) Check(c Case, out Outcome) error {
+	text := out.Response
+	if a.File != "" {
+		data, err := os.ReadFile(filepath.Join(out.Root, filepath.FromSlash(a.File)))
+		if err != nil {
+			return fmt.Errorf("%s is missing", a.File)
+		}
+		text = string(data)
+	}
+	lower := strings.ToLower(text)
+
+	switch {
+	case a.JSON:
+		var v interface{}
+		if err := json.Unmarshal([]byte(unfence(text)), &v); err != nil {
+			return fmt.Errorf("not valid JSON: %w", err)
+		}
+	case a.Contains != "":
+		if !strings.Contains(lower, strings.ToLower(a.Contains)) {
+			return fmt.Errorf("%q not found", a.Contains)
+		}
+	case a.NotContains != "":
+		if strings.Contains(lower, strings.ToLower(a.NotContains)) {
+			return fmt.Errorf("%q found", a.NotContains)
+		}
+	case a.Matches != "":
+		if !regexp.MustCompile(a.Matches).MatchString(text) {
+			return fmt.Errorf("no match for /%s/", a.Matches)
+		}
+	case a.CodeBlock != "":
+		for _, b := range codeBlocks(text) {
+			if a.CodeBlock == "any" || strings.EqualFold(b.lang, a.CodeBlock) {
+				return nil
+			}
+		}
+		return fmt.Errorf("no matching code block")
+	case a.Command != "":
+		re := regexp.MustCompile(a.Command)
+		for _, cmd := range out.Commands {
+			if re.MatchString(cmd) {
+				return nil
+			}
+		}
+		if len(out.Commands) == 0 {
+			return fmt.Errorf("no command suggested")
+		}
+		return fmt.Errorf("suggested %s", strings.Join(out.Commands, "; "))
+	case a.DiffApplies != "":
+		original, ok := c.Files[a.DiffApplies]
+		if !ok {
+			return fmt.Errorf("%s is not one of the case's files", a.DiffApplies)
+		}
+		if _, err := diff.ApplyUnified(original, patchIn(text)); err != nil {
+			return err
+		}
+	case a.Changed != "":
+		if !slices.Contains(out.Changed, a.Changed) {
+			if len(out.Changed) == 0 {
+				return fmt.Errorf("no file was written")
+			}
+			return fmt.Errorf("wrote %s instead", strings.Join(out.Changed, ", "))
+		}
+	}
+	return nil
+}
+
+// Failures returns the assertions of c the outcome fails, with why
+func (c Case) Failures(out Outcome) []string {
+	var failures []string
+	for _, a := range c.Expect {
+		if err := a.Check(c, out); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", a, err))
+		}
+	}
+	return failures
+}
+
+// WriteFiles creates the case's project files under root
+func (c Case) WriteFiles(root string) error {
+	for name, content := range c.Files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if rel, err := filepath.Rel(root, p); err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("%s: file %s is outside the project", c.Name, name)
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", name, err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+type codeBlock struct {
+	lang string
+	body string
+}
+
+// codeBlocks returns the fenced code blocks of text
+func codeBlocks(text string) []codeBlock {
+	var blocks []codeBlock
+	var current *codeBlock
+	var body []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "```") {
+			if current != nil {
+				body = append(body, line)
+			}
+			continue
+		}
+		if current == nil {
+			current = &codeBlock{lang: strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))}
+			body = nil
+			continue
+		}
+		current.body = strings.Join(body, "\n") + "\n"
+		blocks = append(blocks, *current)
+		current = nil
+	}
+	return blocks
+}
+
+// unfence returns the body of text's only code block, or text when it has none
+func unfence(text string) string {
+	if blocks := codeBlocks(text); len(blocks) == 1 {
+		return blocks[0].body
+	}
+	return text
+}
+
+// patchIn returns the diff in an answer: its diff or patch code block, or the answer
+func patchIn(text string) string {
+	for _, b := range codeBlocks(text) {
+		if b.lang == "diff" || b.lang == "patch" {
+			return b.body
+		}
+	}
+	return unfence(text)
+}
@@ -0,0 +1,100 @@
+package eval
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltin(t *testing.T) {
+	suite, err := Builtin()
+	if err != nil {
+		t.Fatalf("Builtin: %v", err)
+	}
+	modes := map[string]bool{}
+	for _, c := range suite.Cases {
+		modes[c.Mode] = true
+	}
+	for _, mode := range []string{"ask", "plan", "edit", "cmd"} {
+		if !modes[mode] {
+			t.Errorf("no built-in case for %s", mode)
+		}
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		yaml string
+		want string
+	}{
+		{"cases: []\n", "has no cases"},
+		{"cases:\n  - prompt: hi\n", "has no expect list"},
+		{"cases:\n  - expect: [{json: true}]\n", "empty prompt"},
+		{"cases:\n  - prompt: hi\n    expect: [{json: true, contains: x}]\n", "exactly one"},
+		{"cases:\n  - prompt: hi\n    expect: [{file: a.go, changed: a.go}]\n", "file only works"},
+		{"cases:\n  - prompt: hi\n    expect: [{matches: '('}]\n", "invalid regular expression"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "suite.yaml")
+		if err := os.WriteFile(path, []byte(tt.yaml), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%q) error = %v, want %q", tt.yaml, err, tt.want)
+		}
+	}
+}
+
+func TestAssertionCheck(t *testing.T) {
+	root := t.TempDir()
+	c := Case{Name: "fix", Files: map[string]string{"calc.go": "package calc\n\nfunc Add(a, b int) int {\n\treturn a - b\n}\n"}}
+	if err := c.WriteFiles(root); err != nil {
+		t.Fatalf("WriteFiles: %v", err)
+	}
+	out := Outcome{
+		Response: "Here you go:\n```diff\n--- a/calc.go\n+++ b/calc.go\n@@ -3,3 +3,3 @@\n func Add(a, b int) int {\n-\treturn a - b\n+\treturn a + b\n }\n```\n",
+		Commands: []string{"ls -la"},
+		Changed:  []string{"calc.go"},
+		Root:     root,
+	}
+
+	tests := []struct {
+		a    Assertion
+		pass bool
+	}{
+		{Assertion{DiffApplies: "calc.go"}, true},
+		{Assertion{CodeBlock: "diff"}, true},
+		{Assertion{CodeBlock: "go"}, false},
+		{Assertion{CodeBlock: "any"}, true},
+		{Assertion{Contains: "RETURN A + B"}, true},
+		{Assertion{NotContains: "here you go"}, false},
+		{Assertion{Matches: `@@ -\d+,\d+`}, true},
+		{Assertion{JSON: true}, false},
+		{Assertion{Command: `^ls `}, true},
+		{Assertion{Command: `^find`}, false},
+		{Assertion{Changed: "calc.go"}, true},
+		{Assertion{Changed: "main.go"}, false},
+		{Assertion{File: "calc.go", Contains: "package calc"}, true},
+		{Assertion{File: "missing.go", Contains: "x"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.a.Check(c, out); (err == nil) != tt.pass {
+			t.Errorf("%s: got error %v, want pass %v", tt.a, err, tt.pass)
+		}
+	}
+
+	if err := (Assertion{JSON: true}).Check(c, Outcome{Response: "```json\n{\"a\": 1}\n```"}); err != nil {
+		t.Errorf("fenced JSON: %v", err)
+	}
+	if err := (Assertion{DiffApplies: "calc.go"}).Check(c, Outcome{Response: "@@ -4 +4 @@\n-\treturn a * b\n+\treturn a + b\n"}); err == nil {
+		t.Errorf("expected a diff that doesn't match to fail")
+	}
+}
+
+func TestWriteFiles_StaysInRoot(t *testing.T) {
+	c := Case{Name: "escape", Files: map[string]string{"../outside.txt": "x"}}
+	if err := c.WriteFiles(t.TempDir()); err == nil {
+		t.Fatalf("expected an error for a file outside the project")
+	}
+}
@@ -0,0 +1,54 @@
+// Package exitcode defines the exit codes returned by one-shot commands, so scripts and
+// git hooks can tell why a run failed.
+package exitcode
+
+import (
+	"errors"
+
+	"github.com/yourusername/llamasidekick/internal/budget"
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+// Exit codes
+const (
+	OK           = 0   // Success
+	Error        = 1   // Any failure without a more specific code
+	Usage        = 2   // Unknown command or invalid arguments
+	Validation   = 3   // The config could not be loaded or failed validation
+	Connection   = 4   // The Ollama server could not be reached
+	ModelMissing = 5   // The requested model is not installed
+	Generation   = 6   // Ollama was reached but generating a response failed
+	Budget       = 7   // A token or generation time budget is used up
+	Aborted      = 130 // Interrupted by the user (Ctrl+C)
+)
+
+// ErrUsage marks errors caused by invalid command-line usage
+var ErrUsage = errors.New("invalid usage")
+
+// ErrAborted marks runs the user interrupted
+var ErrAborted = errors.New("aborted")
+
+// For returns the exit code for err
+func For(err error) int {
+	var validation *config.ValidationError
+	switch {
+	case err == nil:
+		return OK
+	case errors.Is(err, ErrAborted):
+		return Aborted
+	case errors.Is(err, ErrUsage):
+		return Usage
+	case errors.As(err, &validation):
+		return Validation
+	case errors.Is(err, ollama.ErrConnection):
+		return Connection
+	case errors.Is(err, ollama.ErrModelNotFound):
+		return ModelMissing
+	case errors.Is(err, ollama.ErrGeneration):
+		return Generation
+	case errors.Is(err, budget.ErrExceeded):
+		return Budget
+	}
+	return Error
+}
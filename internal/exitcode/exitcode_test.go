@@ -0,0 +1,36 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/budget"
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+func TestFor(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{nil, OK},
+		{errors.New("boom"), Error},
+		{fmt.Errorf("%w: unknown command: x", ErrUsage), Usage},
+		{&config.ValidationError{Problems: []string{"ollama.host is empty"}}, Validation},
+		{fmt.Errorf("error generating JSON: %w", fmt.Errorf("failed to send request: %w: %w", ollama.ErrConnection, errors.New("refused"))), Connection},
+		{fmt.Errorf("%w: llama3 is not available", ollama.ErrModelNotFound), ModelMissing},
+		{fmt.Errorf("%w: ollama API error: 500", ollama.ErrGeneration), Generation},
+		{hint.Wrap(fmt.Errorf("%w: llama3 is not available", ollama.ErrModelNotFound), "run `ollama pull llama3`"), ModelMissing},
+		{fmt.Errorf("failed to summarize: %w", hint.Wrap(fmt.Errorf("%w: %w", ollama.ErrGeneration, ollama.ErrContextLength), "use /compact")), Generation},
+		{fmt.Errorf("error generating response: %w", fmt.Errorf("%w: 500 of 500 tokens today", budget.ErrExceeded)), Budget},
+		{fmt.Errorf("%w: interrupted", ErrAborted), Aborted},
+	}
+	for _, c := range cases {
+		if got := For(c.err); got != c.want {
+			t.Errorf("For(%v) = %d, want %d", c.err, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package filelock provides advisory locks between LlamaSidekick instances using lock
+// files created exclusively on disk.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StaleAfter is how old a lock file must be before it is considered abandoned by a
+// crashed instance and taken over. Locks are only held for the duration of a write.
+var StaleAfter = 30 * time.Second
+
+// DefaultTimeout is how long Acquire callers usually wait for another instance
+const DefaultTimeout = 5 * time.Second
+
+// Lock is a held advisory lock
+type Lock struct {
+	path string
+}
+
+// Acquire takes the lock at path, waiting up to timeout for another holder to release it
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock dir: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > StaleAfter {
+			// The holder is gone; remove its lock and try again
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another LlamaSidekick instance (pid %s)", strings.TrimSuffix(filepath.Base(path), ".lock"), holder(path))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Release frees the lock
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// holder returns the pid recorded in the lock file, or "unknown"
+func holder(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil || strings.TrimSpace(string(data)) == "" {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(data))
+}
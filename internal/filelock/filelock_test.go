@@ -0,0 +1,46 @@
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquire_ExcludesSecondHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.lock")
+
+	lock, err := Acquire(path, time.Second)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err := Acquire(path, 100*time.Millisecond); err == nil {
+		t.Fatalf("expected second acquire to time out")
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	again, err := Acquire(path, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected acquire after release to succeed, got %v", err)
+	}
+	again.Release()
+}
+
+func TestAcquire_TakesOverStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.lock")
+	if err := os.WriteFile(path, []byte("12345\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * StaleAfter)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := Acquire(path, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected stale lock to be taken over, got %v", err)
+	}
+	lock.Release()
+}
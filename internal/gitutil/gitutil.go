@@ -0,0 +1,116 @@
+// Package gitutil runs git commands for the features that integrate with a repository.
+package gitutil
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Run runs git with args in dir and returns its stdout
+func Run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return stdout.String(), nil
+}
+
+// RepoRoot returns the top-level directory of the repository containing dir
+func RepoRoot(dir string) (string, error) {
+	out, err := Run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// StagedDiff returns the diff of the changes staged for the next commit
+func StagedDiff(dir string) (string, error) {
+	return Run(dir, "diff", "--cached", "--no-color", "--no-ext-diff")
+}
+
+// HooksDir returns the directory git runs hooks from, honouring core.hooksPath
+func HooksDir(dir string) (string, error) {
+	out, err := Run(dir, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+	hooks := strings.TrimSpace(out)
+	if !filepath.IsAbs(hooks) {
+		hooks = filepath.Join(dir, hooks)
+	}
+	return hooks, nil
+}
+
+// Diff returns the diff of the working tree changes that are not staged yet
+func Diff(dir string) (string, error) {
+	return Run(dir, "diff", "--no-color", "--no-ext-diff")
+}
+
+// Status returns the short status of the working tree, starting with the branch line
+func Status(dir string) (string, error) {
+	return Run(dir, "status", "--short", "--branch")
+}
+
+// Branch returns the current branch (also before its first commit), or the short commit
+// hash when HEAD is detached
+func Branch(dir string) (string, error) {
+	if out, err := Run(dir, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		return strings.TrimSpace(out), nil
+	}
+	out, err := Run(dir, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return "detached at " + strings.TrimSpace(out), nil
+}
+
+// CommitPaths commits only the given paths (relative to dir) with message, leaving any
+// other staged changes staged, and returns the short hash of the new commit
+func CommitPaths(dir, message string, paths []string) (string, error) {
+	if _, err := Run(dir, append([]string{"add", "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	if _, err := Run(dir, append([]string{"commit", "--quiet", "-m", message, "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	out, err := Run(dir, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// BranchExists reports whether a local branch named name exists
+func BranchExists(dir, name string) bool {
+	_, err := Run(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+name)
+	return err == nil
+}
+
+// CreateBranch creates a branch at HEAD and switches to it, keeping uncommitted changes
+func CreateBranch(dir, name string) error {
+	_, err := Run(dir, "switch", "--quiet", "-c", name)
+	return err
+}
+
+// Blame returns who last changed lines start..end of path, with short dates
+func Blame(dir, path string, start, end int) (string, error) {
+	return Run(dir, "blame", "--date=short", "-L", fmt.Sprintf("%d,%d", start, end), "--", path)
+}
+
+// LineLog returns the last n commits that touched lines start..end of path, each with the
+// patch limited to those lines
+func LineLog(dir, path string, start, end, n int) (string, error) {
+	return Run(dir, "log", "--no-color", fmt.Sprintf("-n%d", n), "--date=short", "--format=commit %h %ad %an%n%n    %s%n",
+		fmt.Sprintf("-L%d,%d:%s", start, end, path))
+}
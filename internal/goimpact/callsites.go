@@ -0,0 +1,166 @@
+package goimpact
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/deps"
+)
+
+// CallSite is a use of a changed function or method in another file
+type CallSite struct {
+	File   string // Relative to the project root
+	Line   int
+	Text   string // The trimmed source line
+	Change Change
+}
+
+// FindCallSites scans the Go files under root for calls to the changed declarations of
+// the file at relPath. Functions are matched by package: qualified calls in files that
+// import it and plain calls in its own package. Methods can't be told apart without type
+// information, so any call of a changed method name in those files is reported. skip
+// leaves out project paths, e.g. ignored ones.
+func FindCallSites(root, relPath string, changes []Change, skip func(rel string) bool) ([]CallSite, error) {
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	pkgDir := path.Dir(relPath)
+	pkgName := packageName(filepath.Join(root, relPath), pkgDir)
+	importPath := packageImportPath(root, pkgDir)
+
+	funcs := map[string]Change{}
+	methods := map[string]Change{}
+	for _, c := range changes {
+		if c.Recv == "" {
+			funcs[c.Name] = c
+		} else {
+			methods[c.Name] = c
+		}
+	}
+
+	var sites []CallSite
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" || d.Name() == "vendor" || (skip != nil && skip(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(rel, ".go") || rel == relPath || (skip != nil && skip(rel)) {
+			return nil
+		}
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		sites = append(sites, fileCallSites(rel, src, pkgDir, pkgName, importPath, funcs, methods)...)
+		return nil
+	})
+	return sites, err
+}
+
+// fileCallSites returns the calls of funcs and methods in one file
+func fileCallSites(rel string, src []byte, pkgDir, pkgName, importPath string, funcs, methods map[string]Change) []CallSite {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	samePackage := path.Dir(rel) == pkgDir && file.Name.Name == pkgName
+	qualifier := ""
+	imported := map[string]bool{}
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		name := path.Base(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		imported[name] = true
+		if importPath != "" && p == importPath {
+			if imp.Name == nil {
+				name = pkgName
+			}
+			qualifier = name
+		}
+	}
+	if !samePackage && qualifier == "" {
+		return nil
+	}
+
+	lines := strings.Split(string(src), "\n")
+	var sites []CallSite
+	add := func(pos token.Pos, c Change) {
+		line := fset.Position(pos).Line
+		text := ""
+		if line > 0 && line <= len(lines) {
+			text = strings.TrimSpace(lines[line-1])
+		}
+		sites = append(sites, CallSite{File: rel, Line: line, Text: text, Change: c})
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		switch fun := call.Fun.(type) {
+		case *ast.Ident:
+			if c, ok := funcs[fun.Name]; ok && samePackage {
+				add(fun.Pos(), c)
+			}
+		case *ast.SelectorExpr:
+			x, isIdent := fun.X.(*ast.Ident)
+			if isIdent && x.Name == qualifier && qualifier != "" {
+				if c, ok := funcs[fun.Sel.Name]; ok {
+					add(fun.Pos(), c)
+				}
+			} else if c, ok := methods[fun.Sel.Name]; ok && !(isIdent && imported[x.Name]) {
+				add(fun.Pos(), c)
+			}
+		}
+		return true
+	})
+	return sites
+}
+
+// packageName reads the package clause of the file at abs, falling back to the name of
+// its directory
+func packageName(abs, pkgDir string) string {
+	file, err := parser.ParseFile(token.NewFileSet(), abs, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return path.Base(pkgDir)
+	}
+	return file.Name.Name
+}
+
+// packageImportPath returns the import path of the package in pkgDir from the module path
+// in root/go.mod, or "" without a go.mod
+func packageImportPath(root, pkgDir string) string {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	manifest, err := deps.ParseGoMod(data)
+	if err != nil || manifest.Module == "" {
+		return ""
+	}
+	if pkgDir == "." {
+		return manifest.Module
+	}
+	return manifest.Module + "/" + pkgDir
+}
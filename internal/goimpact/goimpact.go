@@ -0,0 +1,151 @@
+// Package goimpact finds the call sites an edit to a Go file would break by changing the
+// signature of an exported function or method.
+package goimpact
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Change is an exported function or method whose signature an edit changed or removed
+type Change struct {
+	Name string // The function name, or the method name for methods
+	Recv string // The receiver type name for methods, e.g. Server for (*Server).Start
+	Old  string // The signature before the edit, e.g. func(int, string) error
+	New  string // The signature after the edit; empty when the declaration was removed
+}
+
+// String describes the change, e.g. "(Server).Start: func() error -> func(context.Context) error"
+func (c Change) String() string {
+	name := c.Name
+	if c.Recv != "" {
+		name = "(" + c.Recv + ")." + c.Name
+	}
+	if c.New == "" {
+		return name + " was removed (was " + c.Old + ")"
+	}
+	return name + ": " + c.Old + " -> " + c.New
+}
+
+// Compare returns the exported functions and methods whose signature differs between the
+// old and new source of a file. Parameter names are ignored since renaming them doesn't
+// break callers. Sources that don't parse yield no changes.
+func Compare(oldSrc, newSrc []byte) []Change {
+	before, err := signatures(oldSrc)
+	if err != nil {
+		return nil
+	}
+	after, err := signatures(newSrc)
+	if err != nil {
+		return nil
+	}
+	var changes []Change
+	for key, old := range before {
+		if sig, ok := after[key]; !ok || sig.sig != old.sig {
+			changes = append(changes, Change{Name: old.name, Recv: old.recv, Old: old.sig, New: sig.sig})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Recv != changes[j].Recv {
+			return changes[i].Recv < changes[j].Recv
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+type signature struct {
+	name, recv, sig string
+}
+
+// signatures maps "Recv.Name" (or "Name") to the signature of each exported function and
+// method on an exported type in src
+func signatures(src []byte) (map[string]signature, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	sigs := map[string]signature{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || !fn.Name.IsExported() {
+			continue
+		}
+		recv := ""
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			recv = receiverName(fn.Recv.List[0].Type)
+			if !ast.IsExported(recv) {
+				continue
+			}
+		}
+		key := fn.Name.Name
+		if recv != "" {
+			key = recv + "." + key
+		}
+		sigs[key] = signature{name: fn.Name.Name, recv: recv, sig: funcSignature(fset, fn.Type)}
+	}
+	return sigs, nil
+}
+
+// receiverName returns the type name of a method receiver, without pointer or type
+// parameters
+func receiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// funcSignature prints a function type without parameter names, e.g. func(int, int) error
+func funcSignature(fset *token.FileSet, ft *ast.FuncType) string {
+	var b strings.Builder
+	b.WriteString("func")
+	if ft.TypeParams != nil {
+		b.WriteString("[" + strings.Join(fieldTypes(fset, ft.TypeParams), ", ") + "]")
+	}
+	b.WriteString("(" + strings.Join(fieldTypes(fset, ft.Params), ", ") + ")")
+	results := fieldTypes(fset, ft.Results)
+	switch {
+	case len(results) == 1:
+		b.WriteString(" " + results[0])
+	case len(results) > 1:
+		b.WriteString(" (" + strings.Join(results, ", ") + ")")
+	}
+	return b.String()
+}
+
+// fieldTypes returns one printed type per name in fields, so "a, b int" is "int, int"
+func fieldTypes(fset *token.FileSet, fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var types []string
+	for _, field := range fields.List {
+		var buf bytes.Buffer
+		printer.Fprint(&buf, fset, field.Type)
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			types = append(types, buf.String())
+		}
+	}
+	return types
+}
@@ -0,0 +1,98 @@
+package goimpact
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const oldSource = `package store
+
+type Store struct{}
+
+func Open(path string) (*Store, error) { return nil, nil }
+
+func (s *Store) Get(key string) string { return "" }
+
+func (s *Store) Put(key, value string) {}
+
+func Close(s *Store) {}
+
+func helper(n int) {}
+`
+
+const newSource = `package store
+
+type Store struct{}
+
+func Open(name string) (*Store, error) { return nil, nil }
+
+func (s *Store) Get(key string, fallback string) string { return "" }
+
+func (s *Store) Put(key, value string) {}
+
+func helper(n, m int) {}
+`
+
+func TestCompare(t *testing.T) {
+	changes := Compare([]byte(oldSource), []byte(newSource))
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"Close was removed (was func(*Store))",
+		"(Store).Get: func(string) string -> func(string, string) string",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompareUnparseable(t *testing.T) {
+	if changes := Compare([]byte(oldSource), []byte("package store\nfunc (")); changes != nil {
+		t.Fatalf("expected no changes for a broken file, got %v", changes)
+	}
+}
+
+func TestFindCallSites(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":          "module example.com/app\n\ngo 1.23.0\n",
+		"store/store.go":  oldSource,
+		"store/extra.go":  "package store\n\nfunc reset(s *Store) {\n\tClose(s)\n}\n",
+		"cmd/main.go":     "package main\n\nimport db \"example.com/app/store\"\n\nfunc main() {\n\ts, _ := db.Open(\"x\")\n\t_ = s.Get(\"k\")\n\tdb.Close(s)\n}\n",
+		"other/other.go":  "package other\n\nfunc f(m map[string]int) {\n\tClose(nil)\n\tm.Get(\"k\")\n}\n",
+		"vendor/v/v.go":   "package v\n\nimport \"example.com/app/store\"\n\nfunc f() { store.Close(nil) }\n",
+		"ignored/skip.go": "package skip\n\nimport \"example.com/app/store\"\n\nfunc f() { store.Close(nil) }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	changes := Compare([]byte(oldSource), []byte(newSource))
+	sites, err := FindCallSites(root, "store/store.go", changes, func(rel string) bool { return rel == "ignored" })
+	if err != nil {
+		t.Fatalf("FindCallSites: %v", err)
+	}
+	var got []string
+	for _, s := range sites {
+		got = append(got, fmt.Sprintf("%s:%d %s", s.File, s.Line, s.Text))
+	}
+	want := []string{
+		"cmd/main.go:7 _ = s.Get(\"k\")",
+		"cmd/main.go:8 db.Close(s)",
+		"store/extra.go:4 Close(s)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got call sites:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
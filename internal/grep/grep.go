@@ -0,0 +1,172 @@
+// Package grep searches project files for a regular expression, using ripgrep when it is
+// installed and a built-in search otherwise.
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+)
+
+// Match is a matching line with the lines around it
+type Match struct {
+	File   string // Relative to the search root, with forward slashes
+	Line   int
+	Text   string
+	Before []Line // Context lines before the match, in order
+	After  []Line // Context lines after the match, in order
+}
+
+// Line is a numbered line of a file
+type Line struct {
+	Number int
+	Text   string
+}
+
+// Options controls a search
+type Options struct {
+	Context    int      // Lines of context around each match
+	IgnoreCase bool     // Match regardless of case
+	MaxMatches int      // Stop after this many matches (0 = unlimited)
+	Ignore     []string // Glob patterns of paths that are never searched
+}
+
+// Search returns the lines of files under root that match pattern, sorted by file and
+// line. Hidden and binary files are skipped. Like ripgrep, which it uses when
+// it is installed, the pattern is a regular expression.
+func Search(root, pattern string, opts Options) ([]Match, error) {
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	var matches []Match
+	if rg, err := exec.LookPath("rg"); err == nil {
+		matches, err = searchRipgrep(rg, root, re.String(), opts)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		matches = searchFiles(root, re, opts)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].File != matches[j].File {
+			return matches[i].File < matches[j].File
+		}
+		return matches[i].Line < matches[j].Line
+	})
+	if opts.MaxMatches > 0 && len(matches) > opts.MaxMatches {
+		matches = matches[:opts.MaxMatches]
+	}
+	return matches, nil
+}
+
+// searchFiles is the built-in search
+func searchFiles(root string, re *regexp.Regexp, opts Options) []Match {
+	var matches []Match
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		hidden := strings.HasPrefix(d.Name(), ".")
+		if d.IsDir() {
+			if hidden || pathmatch.MatchAny(opts.Ignore, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if opts.MaxMatches > 0 && len(matches) >= opts.MaxMatches {
+			return filepath.SkipAll
+		}
+		if hidden || !d.Type().IsRegular() || pathmatch.MatchAny(opts.Ignore, rel) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+			return nil
+		}
+		matches = append(matches, matchLines(rel, content, re, opts.Context)...)
+		return nil
+	})
+	return matches
+}
+
+// matchLines returns the matches of re in one file's content
+func matchLines(rel string, content []byte, re *regexp.Regexp, context int) []Match {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	var matches []Match
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		m := Match{File: rel, Line: i + 1, Text: line}
+		for j := max(0, i-context); j < i; j++ {
+			m.Before = append(m.Before, Line{Number: j + 1, Text: lines[j]})
+		}
+		for j := i + 1; j < len(lines) && j <= i+context; j++ {
+			m.After = append(m.After, Line{Number: j + 1, Text: lines[j]})
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// Format prints matches grouped by file like grep -n: "12:" marks a matching line and
+// "11-" a context line, and "--" separates regions that aren't adjacent
+func Format(matches []Match) string {
+	matched := map[string]map[int]bool{}
+	for _, m := range matches {
+		if matched[m.File] == nil {
+			matched[m.File] = map[int]bool{}
+		}
+		matched[m.File][m.Line] = true
+	}
+	var b strings.Builder
+	file, last := "", 0
+	for _, m := range matches {
+		if m.File != file {
+			if file != "" {
+				b.WriteString("\n")
+			}
+			file, last = m.File, 0
+			b.WriteString(m.File + "\n")
+		}
+		lines := append(append(append([]Line{}, m.Before...), Line{Number: m.Line, Text: m.Text}), m.After...)
+		for _, l := range lines {
+			if l.Number <= last {
+				continue
+			}
+			if last > 0 && l.Number > last+1 {
+				b.WriteString("--\n")
+			}
+			sep := "-"
+			if matched[m.File][l.Number] {
+				sep = ":"
+			}
+			fmt.Fprintf(&b, "%d%s%s\n", l.Number, sep, l.Text)
+			last = l.Number
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package grep
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestSearchFilesAndFormat(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.go":              "package a\n\nfunc Load() {}\n\nfunc Save() {\n\tLoad()\n}\n",
+		"docs/readme.md":    "Call Load to read\n",
+		"node_modules/x.js": "Load()\n",
+		".hidden/h.go":      "Load()\n",
+		"bin":               "Load\x00",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches := searchFiles(root, regexp.MustCompile(`Load\(`), Options{Context: 1, Ignore: []string{"node_modules"}})
+	if len(matches) != 2 || matches[0].Line != 3 || matches[1].Line != 6 {
+		t.Fatalf("unexpected matches %+v", matches)
+	}
+	want := "a.go\n2-\n3:func Load() {}\n4-\n5-func Save() {\n6:\tLoad()\n7-}\n"
+	if got := Format(matches); got != want {
+		t.Fatalf("Format =\n%q\nwant\n%q", got, want)
+	}
+
+	matches = searchFiles(root, regexp.MustCompile(`(?i)call load`), Options{})
+	if len(matches) != 1 || matches[0].File != "docs/readme.md" {
+		t.Fatalf("unexpected case-insensitive matches %+v", matches)
+	}
+}
+
+func TestParseRipgrepJSON(t *testing.T) {
+	out := []byte(`{"type":"begin","data":{"path":{"text":"./a.go"}}}
+{"type":"context","data":{"path":{"text":"./a.go"},"lines":{"text":"package a\n"},"line_number":1}}
+{"type":"match","data":{"path":{"text":"./a.go"},"lines":{"text":"func Load() {}\n"},"line_number":2,"submatches":[]}}
+{"type":"match","data":{"path":{"text":"./vendor/v.go"},"lines":{"text":"Load()\n"},"line_number":9,"submatches":[]}}
+{"type":"summary","data":{}}
+`)
+	matches := parseRipgrepJSON(out, Options{Context: 2, Ignore: []string{"vendor"}})
+	if len(matches) != 1 {
+		t.Fatalf("expected one match, got %+v", matches)
+	}
+	m := matches[0]
+	if m.File != "a.go" || m.Line != 2 || m.Text != "func Load() {}" || len(m.Before) != 1 || m.Before[0].Text != "package a" || len(m.After) != 0 {
+		t.Fatalf("unexpected match %+v", m)
+	}
+}
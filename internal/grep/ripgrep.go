@@ -0,0 +1,98 @@
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+)
+
+// rgMessage is one line of ripgrep's --json output
+type rgMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Path       rgText `json:"path"`
+		Lines      rgText `json:"lines"`
+		LineNumber int    `json:"line_number"`
+	} `json:"data"`
+}
+
+type rgText struct {
+	Text string `json:"text"`
+}
+
+// searchRipgrep runs rg in root and converts its JSON output to matches. ripgrep also
+// skips files listed in .gitignore.
+func searchRipgrep(rg, root, pattern string, opts Options) ([]Match, error) {
+	args := []string{"--json", "--context", strconv.Itoa(opts.Context), "--regexp", pattern, "."}
+	cmd := exec.Command(rg, args...)
+	cmd.Dir = root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		// Exit status 1 only means nothing matched
+		return nil, fmt.Errorf("rg failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseRipgrepJSON(out, opts), nil
+}
+
+// parseRipgrepJSON converts rg --json output to matches
+func parseRipgrepJSON(out []byte, opts Options) []Match {
+	// Collect the lines rg printed per file, then attach the context to each match
+	type fileLines struct {
+		text    map[int]string
+		matches []int
+	}
+	files := map[string]*fileLines{}
+	var order []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var msg rgMessage
+		if json.Unmarshal(scanner.Bytes(), &msg) != nil || (msg.Type != "match" && msg.Type != "context") {
+			continue
+		}
+		path := strings.TrimPrefix(msg.Data.Path.Text, "./")
+		if path == "" || pathmatch.MatchAny(opts.Ignore, path) {
+			continue
+		}
+		f := files[path]
+		if f == nil {
+			f = &fileLines{text: map[int]string{}}
+			files[path] = f
+			order = append(order, path)
+		}
+		f.text[msg.Data.LineNumber] = strings.TrimRight(msg.Data.Lines.Text, "\r\n")
+		if msg.Type == "match" {
+			f.matches = append(f.matches, msg.Data.LineNumber)
+		}
+	}
+
+	var matches []Match
+	for _, path := range order {
+		f := files[path]
+		for _, n := range f.matches {
+			m := Match{File: path, Line: n, Text: f.text[n]}
+			for i := n - opts.Context; i < n; i++ {
+				if text, ok := f.text[i]; ok {
+					m.Before = append(m.Before, Line{Number: i, Text: text})
+				}
+			}
+			for i := n + 1; i <= n+opts.Context; i++ {
+				if text, ok := f.text[i]; ok {
+					m.After = append(m.After, Line{Number: i, Text: text})
+				}
+			}
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
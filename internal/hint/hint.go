@@ -0,0 +1,66 @@
+// Package hint attaches remediation hints to errors, so the UI can tell the user what to
+// do about a failure next to what went wrong.
+package hint
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Error is a failure with a hint on how the user can fix it
+type Error struct {
+	Err  error
+	Hint string // e.g. "run `ollama pull llama3`"
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap attaches hint to err. It returns nil if err is nil, and err if hint is empty.
+func Wrap(err error, hint string) error {
+	if err == nil || hint == "" {
+		return err
+	}
+	return &Error{Err: err, Hint: hint}
+}
+
+// For returns the hint attached to err or to an error it wraps, or "" if there is none
+func For(err error) string {
+	var h *Error
+	if errors.As(err, &h) {
+		return h.Hint
+	}
+	return ""
+}
+
+// Print prints err as an error for the interactive UI, with its hint below it
+func Print(err error) {
+	fmt.Printf("\033[38;5;9mError: %v\033[0m\n", err)
+	printHint(err)
+}
+
+// Warn prints err as a warning for the interactive UI, with its hint below it
+func Warn(err error) {
+	fmt.Printf("\033[38;5;214mWarning: %v\033[0m\n", err)
+	printHint(err)
+}
+
+// Fprint writes err and its hint to w without colors, e.g. to stderr for scripts
+func Fprint(w io.Writer, err error) {
+	fmt.Fprintf(w, "Error: %v\n", err)
+	if h := For(err); h != "" {
+		fmt.Fprintf(w, "Hint: %s\n", h)
+	}
+}
+
+func printHint(err error) {
+	if h := For(err); h != "" {
+		fmt.Printf("\033[38;5;240mHint: %s\033[0m\n", h)
+	}
+}
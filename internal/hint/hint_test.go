@@ -0,0 +1,32 @@
+package hint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWrapAndFor(t *testing.T) {
+	base := errors.New("model not found")
+	err := fmt.Errorf("error generating JSON: %w", Wrap(base, "run `ollama pull llama3`"))
+
+	if !errors.Is(err, base) {
+		t.Fatalf("expected the hinted error to wrap %v", base)
+	}
+	if got := For(err); got != "run `ollama pull llama3`" {
+		t.Fatalf("For = %q", got)
+	}
+	if err.Error() != "error generating JSON: model not found" {
+		t.Fatalf("expected the hint left out of the message, got %q", err.Error())
+	}
+	if For(base) != "" || Wrap(nil, "x") != nil || Wrap(base, "") != base {
+		t.Fatal("expected errors without a hint to stay as they are")
+	}
+
+	var out bytes.Buffer
+	Fprint(&out, err)
+	if out.String() != "Error: error generating JSON: model not found\nHint: run `ollama pull llama3`\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
@@ -0,0 +1,221 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+)
+
+const (
+	// embedBatchSize is how many chunks are sent per embedding request
+	embedBatchSize = 32
+	// maxEmbedBytes bounds the text embedded per chunk, for files with very long lines
+	maxEmbedBytes = 6 * 1024
+)
+
+// Embedder returns one vector per text, e.g. ollama.Client.Embed bound to a model
+type Embedder func(texts []string) ([][]float32, error)
+
+// Options controls which files are indexed and how they are chunked
+type Options struct {
+	ChunkLines   int      // Lines per chunk
+	ChunkOverlap int      // Lines shared by consecutive chunks
+	MaxFileBytes int64    // Larger files are skipped (0 = unlimited)
+	Ignore       []string // Glob patterns of paths that are never indexed
+	Chunking     []config.ChunkingRule
+}
+
+// Stats describes what an Update did
+type Stats struct {
+	Files    int // Files in the index afterwards
+	Embedded int // Files that were (re)embedded
+	Reused   int // Unchanged files whose chunks were kept
+	Removed  int // Files dropped because they no longer exist
+	Chunks   int // Chunks embedded in this update
+}
+
+// Update brings the index up to date with the project: new and changed files are chunked,
+// embedded and have their declarations and calls recorded, unchanged ones are kept, and
+// deleted ones are dropped. progress, if set, is called before each file is embedded.
+// Files embedded before an error are kept, so the index can be saved and a later Update
+// continues where this one stopped.
+func (idx *Index) Update(opts Options, embed Embedder, progress func(path string, done, total int)) (Stats, error) {
+	var stats Stats
+	files, err := projectFiles(idx.Root, opts)
+	if err != nil {
+		return stats, err
+	}
+	if idx.ChunkingChanged(opts) {
+		// Chunks made with other settings can't be reused
+		idx.Files = map[string]*File{}
+		idx.Chunking = opts.signature()
+	}
+
+	present := map[string]bool{}
+	var pending []string
+	hashes := map[string]string{}
+	contents := map[string][]byte{}
+	for _, rel := range files {
+		content, err := os.ReadFile(filepath.Join(idx.Root, filepath.FromSlash(rel)))
+		if err != nil || !indexable(content) {
+			continue
+		}
+		present[rel] = true
+		hash := safeio.Hash(content)
+		if f, ok := idx.Files[rel]; ok && f.Hash == hash {
+			stats.Reused++
+			continue
+		}
+		pending = append(pending, rel)
+		hashes[rel] = hash
+		contents[rel] = content
+	}
+	for rel := range idx.Files {
+		if !present[rel] {
+			delete(idx.Files, rel)
+			stats.Removed++
+		}
+	}
+
+	for i, rel := range pending {
+		if progress != nil {
+			progress(rel, i, len(pending))
+		}
+		chunks := ChunkFile(rel, string(contents[rel]), opts)
+		if err := embedChunks(rel, chunks, embed); err != nil {
+			stats.Files = len(idx.Files)
+			return stats, err
+		}
+		symbols, calls := ExtractSymbols(rel, contents[rel])
+		idx.Files[rel] = &File{Hash: hashes[rel], Chunks: chunks, Symbols: symbols, Calls: calls}
+		stats.Embedded++
+		stats.Chunks += len(chunks)
+	}
+	idx.Built = time.Now()
+	stats.Files = len(idx.Files)
+	return stats, nil
+}
+
+// ChunkingChanged reports whether opts split files differently from how the indexed
+// files were split, so that an Update re-embeds every file
+func (idx *Index) ChunkingChanged(opts Options) bool {
+	return idx.Chunking != opts.signature()
+}
+
+// signature describes the options that affect chunking
+func (opts Options) signature() string {
+	return fmt.Sprintf("%d/%d/%v", opts.ChunkLines, opts.ChunkOverlap, opts.Chunking)
+}
+
+// Stale counts the files that changed, appeared or disappeared since the index was built
+func (idx *Index) Stale(opts Options) (changed, added, removed int, err error) {
+	files, err := projectFiles(idx.Root, opts)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	present := map[string]bool{}
+	for _, rel := range files {
+		content, err := os.ReadFile(filepath.Join(idx.Root, filepath.FromSlash(rel)))
+		if err != nil || !indexable(content) {
+			continue
+		}
+		present[rel] = true
+		f, ok := idx.Files[rel]
+		switch {
+		case !ok:
+			added++
+		case f.Hash != safeio.Hash(content):
+			changed++
+		}
+	}
+	for rel := range idx.Files {
+		if !present[rel] {
+			removed++
+		}
+	}
+	return changed, added, removed, nil
+}
+
+// embedChunks fills in the vectors of a file's chunks. Each chunk is embedded with its
+// path so that file names take part in the match.
+func embedChunks(rel string, chunks []Chunk, embed Embedder) error {
+	for start := 0; start < len(chunks); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(chunks))
+		texts := make([]string, 0, end-start)
+		for _, c := range chunks[start:end] {
+			text := c.Text
+			if len(text) > maxEmbedBytes {
+				text = strings.ToValidUTF8(text[:maxEmbedBytes], "")
+			}
+			texts = append(texts, rel+"\n"+text)
+		}
+		vectors, err := embed(texts)
+		if err != nil {
+			return err
+		}
+		for i, v := range vectors {
+			chunks[start+i].Vector = v
+		}
+	}
+	return nil
+}
+
+// projectFiles lists the files under root that may be indexed, as project paths
+func projectFiles(root string, opts Options) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" || pathmatch.MatchAny(opts.Ignore, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || pathmatch.MatchAny(opts.Ignore, rel) {
+			return nil
+		}
+		if opts.MaxFileBytes > 0 {
+			if info, err := d.Info(); err != nil || info.Size() > opts.MaxFileBytes {
+				return nil
+			}
+		}
+		files = append(files, rel)
+		return nil
+	})
+	return files, err
+}
+
+// indexable reports whether content is non-empty text
+func indexable(content []byte) bool {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return false
+	}
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) < 0 && utf8.Valid(content)
+}
+
+// OptionsFor returns the options set by the index and context config
+func OptionsFor(cfg *config.Config) Options {
+	return Options{
+		ChunkLines:   cfg.Index.ChunkLines,
+		ChunkOverlap: cfg.Index.ChunkOverlap,
+		MaxFileBytes: cfg.Context.MaxFileBytes,
+		Ignore:       cfg.Context.Ignore,
+		Chunking:     cfg.Index.Chunking,
+	}
+}
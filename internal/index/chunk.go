@@ -0,0 +1,144 @@
+package index
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+)
+
+// ChunkFile splits a file with the strategy of the first index.chunking rule matching
+// its path, or by lines when none does
+func ChunkFile(path, text string, opts Options) []Chunk {
+	strategy := "lines"
+	for _, rule := range opts.Chunking {
+		if pathmatch.MatchAny(rule.Files, path) {
+			strategy = rule.Strategy
+			break
+		}
+	}
+	switch strategy {
+	case "go":
+		return ChunkGo(text, opts.ChunkLines, opts.ChunkOverlap)
+	case "markdown":
+		return ChunkMarkdown(text, opts.ChunkLines, opts.ChunkOverlap)
+	}
+	return ChunkLines(text, opts.ChunkLines, opts.ChunkOverlap)
+}
+
+// ChunkLines splits text into chunks of size lines, each sharing overlap lines with the
+// previous one. Blank chunks are left out.
+func ChunkLines(text string, size, overlap int) []Chunk {
+	return chunkLines(splitLines(text), 0, size, overlap)
+}
+
+// ChunkGo splits Go source by top-level declaration, each with the comments above it.
+// Consecutive declarations are combined while they fit in size lines, and declarations
+// longer than that are split by lines. Source that doesn't parse is split by lines.
+func ChunkGo(text string, size, overlap int) []Chunk {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", text, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil || len(file.Decls) == 0 {
+		return ChunkLines(text, size, overlap)
+	}
+	// Each declaration starts right after the previous one ends; the first one also
+	// covers the package clause
+	starts := []int{0}
+	for _, decl := range file.Decls[:len(file.Decls)-1] {
+		starts = append(starts, fset.Position(decl.End()).Line)
+	}
+	return chunkUnits(splitLines(text), starts, size, overlap)
+}
+
+// ChunkMarkdown splits markdown into sections at headings outside code blocks. Sections
+// are combined and split like Go declarations.
+func ChunkMarkdown(text string, size, overlap int) []Chunk {
+	lines := splitLines(text)
+	starts := []int{0}
+	fenced := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if !fenced && i > 0 && isHeading(line) {
+			starts = append(starts, i)
+		}
+	}
+	return chunkUnits(lines, starts, size, overlap)
+}
+
+// isHeading reports whether line is a markdown ATX heading such as "## Usage"
+func isHeading(line string) bool {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	return level >= 1 && level <= 6 && (len(line) == level || line[level] == ' ' || line[level] == '\t')
+}
+
+// chunkUnits turns units of lines, starting at the 0-based indexes in starts, into
+// chunks: consecutive units are combined while they fit in size lines, and a unit that
+// is longer is split by lines
+func chunkUnits(lines []string, starts []int, size, overlap int) []Chunk {
+	if size < 1 {
+		size = 1
+	}
+	var chunks []Chunk
+	flushFrom := -1
+	flush := func(end int) {
+		if flushFrom >= 0 && flushFrom < end {
+			chunks = append(chunks, chunkLines(lines[flushFrom:end], flushFrom, end-flushFrom, 0)...)
+		}
+		flushFrom = -1
+	}
+	for i, start := range starts {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		if end <= start {
+			continue
+		}
+		if end-start > size {
+			flush(start)
+			chunks = append(chunks, chunkLines(lines[start:end], start, size, overlap)...)
+			continue
+		}
+		if flushFrom >= 0 && end-flushFrom > size {
+			flush(start)
+		}
+		if flushFrom < 0 {
+			flushFrom = start
+		}
+	}
+	flush(len(lines))
+	return chunks
+}
+
+// chunkLines splits lines, the first of which is line offset+1 of the file, into chunks
+// of size lines sharing overlap lines
+func chunkLines(lines []string, offset, size, overlap int) []Chunk {
+	if size < 1 {
+		size = 1
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+	var chunks []Chunk
+	for start := 0; start < len(lines); start += size - overlap {
+		end := min(start+size, len(lines))
+		body := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(body) != "" {
+			chunks = append(chunks, Chunk{StartLine: offset + start + 1, EndLine: offset + end, Text: body})
+		}
+		if end == len(lines) {
+			break
+		}
+	}
+	return chunks
+}
+
+// splitLines splits text into lines without the final newline
+func splitLines(text string) []string {
+	return strings.Split(strings.TrimRight(text, "\n"), "\n")
+}
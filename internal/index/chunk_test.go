@@ -0,0 +1,73 @@
+package index
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+// spans returns the line ranges of chunks, e.g. "1-4 5-9"
+func spans(chunks []Chunk) string {
+	var parts []string
+	for _, c := range chunks {
+		parts = append(parts, fmt.Sprintf("%d-%d", c.StartLine, c.EndLine))
+	}
+	return strings.Join(parts, " ")
+}
+
+const goSource = `package store
+
+import "os"
+
+// Open opens a store
+func Open(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
+
+// Close closes it
+func Close() {}
+
+func Long() {
+	a := 1
+	a++
+	a++
+	a++
+	a++
+	_ = a
+}
+`
+
+func TestChunkGo(t *testing.T) {
+	// Each declaration starts after the previous one, so Open brings its comment; the
+	// header doesn't fit with Open, nor Close with Long, and Long is split by lines
+	got := spans(ChunkGo(goSource, 7, 0))
+	if want := "1-3 4-9 10-12 13-19 20-21"; got != want {
+		t.Fatalf("ChunkGo spans = %s, want %s", got, want)
+	}
+	if got := spans(ChunkGo(goSource, 20, 0)); got != "1-12 13-21" {
+		t.Fatalf("ChunkGo with room for more = %s", got)
+	}
+	if got := spans(ChunkGo("package broken\nfunc (", 5, 0)); got != "1-2" {
+		t.Fatalf("unparseable Go should be split by lines, got %s", got)
+	}
+}
+
+func TestChunkMarkdown(t *testing.T) {
+	text := "# Title\nIntro\n\n## Install\nRun it\n```sh\n# not a heading\n```\n## Usage\nUse it\n"
+	if got := spans(ChunkMarkdown(text, 5, 0)); got != "1-3 4-8 9-10" {
+		t.Fatalf("ChunkMarkdown spans = %s", got)
+	}
+}
+
+func TestChunkFileStrategy(t *testing.T) {
+	opts := Options{ChunkLines: 7, Chunking: config.DefaultChunking()}
+	if got := spans(ChunkFile("pkg/store.go", goSource, opts)); got != "1-3 4-9 10-12 13-19 20-21" {
+		t.Fatalf("Go files should be chunked by declaration, got %s", got)
+	}
+	if got := spans(ChunkFile("store.txt", goSource, opts)); got != "1-7 8-14 15-21" {
+		t.Fatalf("other files should be chunked by lines, got %s", got)
+	}
+}
@@ -0,0 +1,225 @@
+package index
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// fusionK damps the weight of top ranks in reciprocal rank fusion; 60 is the usual value
+const fusionK = 60
+
+// Techniques a chunk can be ranked by
+const (
+	TechniqueSymbol   = "symbol"
+	TechniqueKeyword  = "keyword"
+	TechniqueSemantic = "semantic"
+)
+
+// Hit is a chunk ranked by one technique, with the reason it was picked
+type Hit struct {
+	Path   string
+	Chunk  Chunk
+	Detail string
+}
+
+// Signal records how one technique ranked a fused chunk
+type Signal struct {
+	Technique string
+	Rank      int // 1-based
+	Detail    string
+}
+
+// Ranked is a chunk ranked by combining techniques
+type Ranked struct {
+	Path    string
+	Chunk   Chunk
+	Score   float64
+	Signals []Signal
+}
+
+// Fuse combines per-technique rankings, each best first, with reciprocal rank fusion: a
+// chunk scores the sum of 1/(60+rank) over the techniques that found it, so chunks
+// several techniques agree on come first.
+func Fuse(rankings map[string][]Hit) []Ranked {
+	type key struct {
+		path  string
+		start int
+	}
+	byKey := map[key]*Ranked{}
+	var order []key
+	techniques := make([]string, 0, len(rankings))
+	for t := range rankings {
+		techniques = append(techniques, t)
+	}
+	sort.Strings(techniques)
+	for _, technique := range techniques {
+		for i, hit := range rankings[technique] {
+			k := key{hit.Path, hit.Chunk.StartLine}
+			r := byKey[k]
+			if r == nil {
+				r = &Ranked{Path: hit.Path, Chunk: hit.Chunk}
+				byKey[k] = r
+				order = append(order, k)
+			}
+			r.Score += 1 / float64(fusionK+i+1)
+			r.Signals = append(r.Signals, Signal{Technique: technique, Rank: i + 1, Detail: hit.Detail})
+		}
+	}
+	fused := make([]Ranked, 0, len(order))
+	for _, k := range order {
+		fused = append(fused, *byKey[k])
+	}
+	sort.SliceStable(fused, func(i, j int) bool {
+		if fused[i].Score != fused[j].Score {
+			return fused[i].Score > fused[j].Score
+		}
+		if fused[i].Path != fused[j].Path {
+			return fused[i].Path < fused[j].Path
+		}
+		return fused[i].Chunk.StartLine < fused[j].Chunk.StartLine
+	})
+	return fused
+}
+
+// SemanticHits ranks chunks by similarity to the query vector
+func (idx *Index) SemanticHits(query []float32, n int, keep func(path string) bool) []Hit {
+	var hits []Hit
+	for _, r := range idx.Search(query, n, keep) {
+		hits = append(hits, Hit{Path: r.Path, Chunk: r.Chunk, Detail: fmt.Sprintf("similarity %.2f", r.Score)})
+	}
+	return hits
+}
+
+// SymbolHits ranks the chunks that declare one of terms, e.g. "func Load" or "type Load",
+// by how many of the terms they declare
+func (idx *Index) SymbolHits(terms []string, n int, keep func(path string) bool) []Hit {
+	if len(terms) == 0 {
+		return nil
+	}
+	patterns := make([]*regexp.Regexp, len(terms))
+	for i, term := range terms {
+		patterns[i] = declarationPattern(term)
+	}
+	return idx.rankChunks(n, keep, func(text string) (int, string) {
+		var declared []string
+		for i, re := range patterns {
+			if re.MatchString(text) {
+				declared = append(declared, terms[i])
+			}
+		}
+		return len(declared), "declares " + strings.Join(declared, ", ")
+	})
+}
+
+// KeywordHits ranks the chunks that contain terms as whole words by how often they occur
+func (idx *Index) KeywordHits(terms []string, n int, keep func(path string) bool) []Hit {
+	if len(terms) == 0 {
+		return nil
+	}
+	patterns := make([]*regexp.Regexp, len(terms))
+	for i, term := range terms {
+		patterns[i] = regexp.MustCompile(`\b` + regexp.QuoteMeta(term) + `\b`)
+	}
+	return idx.rankChunks(n, keep, func(text string) (int, string) {
+		count := 0
+		var found []string
+		for i, re := range patterns {
+			if c := len(re.FindAllStringIndex(text, -1)); c > 0 {
+				count += c
+				found = append(found, terms[i])
+			}
+		}
+		return count, fmt.Sprintf("mentions %s (%d×)", strings.Join(found, ", "), count)
+	})
+}
+
+// rankChunks scores every chunk with score and returns the n best with a positive score
+func (idx *Index) rankChunks(n int, keep func(path string) bool, score func(text string) (int, string)) []Hit {
+	type scored struct {
+		hit   Hit
+		score int
+	}
+	var all []scored
+	for path, f := range idx.Files {
+		if keep != nil && !keep(path) {
+			continue
+		}
+		for _, c := range f.Chunks {
+			if s, detail := score(c.Text); s > 0 {
+				all = append(all, scored{Hit{Path: path, Chunk: c, Detail: detail}, s})
+			}
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].score != all[j].score {
+			return all[i].score > all[j].score
+		}
+		if all[i].hit.Path != all[j].hit.Path {
+			return all[i].hit.Path < all[j].hit.Path
+		}
+		return all[i].hit.Chunk.StartLine < all[j].hit.Chunk.StartLine
+	})
+	hits := make([]Hit, 0, min(n, len(all)))
+	for i := 0; i < len(all) && i < n; i++ {
+		hits = append(hits, all[i].hit)
+	}
+	return hits
+}
+
+// declarationPattern matches a declaration of name in common languages: Go func (also
+// methods), type, const and var, and class, def, function, interface, struct and enum
+func declarationPattern(name string) *regexp.Regexp {
+	q := regexp.QuoteMeta(name)
+	return regexp.MustCompile(`(?m)^\s*(?:export\s+|pub\s+|public\s+|private\s+|static\s+|async\s+)*(?:func(?:\s*\([^)]*\))?|type|const|var|let|class|def|function|interface|struct|enum|fn)\s+` + q + `\b`)
+}
+
+var (
+	identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*`)
+	quotedPattern     = regexp.MustCompile("`([^`\\s]+)`")
+)
+
+// Terms picks the identifiers out of a prompt: words written like code (camelCase,
+// PascalCase, snake_case, pkg.Name, or followed by "()") and anything in backticks.
+// Plain English words are left to semantic search.
+func Terms(input string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	add := func(term string) {
+		term = strings.Trim(term, ".()")
+		if len(term) >= 3 && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	for _, m := range quotedPattern.FindAllStringSubmatch(input, -1) {
+		add(m[1])
+	}
+	for _, loc := range identifierPattern.FindAllStringIndex(input, -1) {
+		word := input[loc[0]:loc[1]]
+		call := strings.HasPrefix(input[loc[1]:], "()")
+		if !call && !strings.ContainsAny(word, "_.") && !hasInnerUpper(word) {
+			continue
+		}
+		if strings.Contains(word, ".") {
+			// pkg.Name: the last part is what's declared
+			add(word)
+			add(word[strings.LastIndex(word, ".")+1:])
+			continue
+		}
+		add(word)
+	}
+	return terms
+}
+
+// hasInnerUpper reports whether word has an upper-case letter after its first letter,
+// as in camelCase and PascalCase
+func hasInnerUpper(word string) bool {
+	for _, r := range word[1:] {
+		if r >= 'A' && r <= 'Z' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	got := Terms("Why does loadConfig fail when `viper` reads config.Load and parse_args()? Also Run() and the user")
+	want := []string{"viper", "loadConfig", "config.Load", "Load", "parse_args", "Run"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Terms = %q, want %q", got, want)
+	}
+}
+
+func TestHybridRankings(t *testing.T) {
+	idx := New("/project", "embed-model")
+	idx.Files["config.go"] = &File{Chunks: []Chunk{
+		{StartLine: 1, EndLine: 20, Text: "package config\n\nfunc loadConfig() error {\n\treturn nil\n}", Vector: []float32{0, 1}},
+	}}
+	idx.Files["main.go"] = &File{Chunks: []Chunk{
+		{StartLine: 1, EndLine: 20, Text: "func main() {\n\tloadConfig()\n\tloadConfig()\n}", Vector: []float32{0.6, 0.8}},
+	}}
+	idx.Files["docs.md"] = &File{Chunks: []Chunk{
+		{StartLine: 1, EndLine: 5, Text: "Configuration is read on startup.", Vector: []float32{1, 0}},
+	}}
+
+	terms := []string{"loadConfig"}
+	symbols := idx.SymbolHits(terms, 5, nil)
+	if len(symbols) != 1 || symbols[0].Path != "config.go" || symbols[0].Detail != "declares loadConfig" {
+		t.Fatalf("unexpected symbol hits %+v", symbols)
+	}
+	keywords := idx.KeywordHits(terms, 5, nil)
+	if len(keywords) != 2 || keywords[0].Path != "main.go" || keywords[0].Detail != "mentions loadConfig (2×)" {
+		t.Fatalf("unexpected keyword hits %+v", keywords)
+	}
+	semantic := idx.SemanticHits([]float32{0.6, 0.8}, 5, nil)
+	if len(semantic) != 3 || semantic[0].Path != "main.go" || semantic[0].Detail != "similarity 1.00" {
+		t.Fatalf("unexpected semantic hits %+v", semantic)
+	}
+
+	fused := Fuse(map[string][]Hit{TechniqueSymbol: symbols, TechniqueKeyword: keywords, TechniqueSemantic: semantic})
+	var order []string
+	for _, r := range fused {
+		order = append(order, r.Path)
+	}
+	// config.go is found by all three techniques, main.go by two and docs.md by one
+	if !reflect.DeepEqual(order, []string{"config.go", "main.go", "docs.md"}) {
+		t.Fatalf("unexpected fused order %q", order)
+	}
+	if len(fused[0].Signals) != 3 || fused[0].Signals[0].Technique != TechniqueKeyword || fused[0].Signals[0].Rank != 2 {
+		t.Fatalf("unexpected signals %+v", fused[0].Signals)
+	}
+}
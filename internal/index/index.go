@@ -0,0 +1,143 @@
+// Package index keeps a local semantic index of a project: files are split into chunks of
+// lines, each chunk is embedded with an Ollama embedding model, and the vectors are stored
+// in a file per project in the data dir.
+//
+// The file is a gob rather than a SQLite database. Ranking compares the prompt's vector
+// with every chunk's, so a lookup reads all vectors whichever way they are stored; the
+// SQLite drivers need cgo, which the CGO_ENABLED=0 release builds don't have, or add a
+// large pure-Go dependency. Callers keep the decoded index and load it again only when
+// the file changes.
+package index
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/filelock"
+)
+
+// formatVersion is bumped when the stored layout changes; older files are rebuilt
+const formatVersion = 2
+
+// Chunk is an embedded range of lines of a file
+type Chunk struct {
+	StartLine int // 1-based, inclusive
+	EndLine   int // 1-based, inclusive
+	Text      string
+	Vector    []float32
+}
+
+// File is the indexed state of one project file
+type File struct {
+	Hash    string // Content hash when the file was embedded
+	Chunks  []Chunk
+	Symbols []Symbol // Declarations, for structural queries
+	Calls   []Call
+}
+
+// Index is the semantic index of a project
+type Index struct {
+	Version  int
+	Root     string
+	Model    string // Embedding model the vectors were made with
+	Chunking string // The chunking options the files were split with, see Options.signature
+	Built    time.Time
+	Files    map[string]*File // Keyed by project path with forward slashes
+}
+
+// New returns an empty index of root for model
+func New(root, model string) *Index {
+	return &Index{Version: formatVersion, Root: root, Model: model, Files: map[string]*File{}}
+}
+
+// ChunkCount returns the number of chunks across all files
+func (idx *Index) ChunkCount() int {
+	n := 0
+	for _, f := range idx.Files {
+		n += len(f.Chunks)
+	}
+	return n
+}
+
+// Paths returns the indexed project paths, sorted
+func (idx *Index) Paths() []string {
+	paths := make([]string, 0, len(idx.Files))
+	for p := range idx.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// PathFor returns the index file of a project root in the data dir
+func PathFor(root string) (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(dataDir, "index", hex.EncodeToString(sum[:8])+".gob"), nil
+}
+
+// Load reads an index file. A missing file returns an error wrapping os.ErrNotExist; so
+// does a file of an older format, so that it gets rebuilt.
+func Load(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var idx Index
+	if err := gob.NewDecoder(f).Decode(&idx); err != nil {
+		return nil, fmt.Errorf("failed to read index %s: %w", path, err)
+	}
+	if idx.Version != formatVersion {
+		return nil, fmt.Errorf("index %s has an old format: %w", path, os.ErrNotExist)
+	}
+	if idx.Files == nil {
+		idx.Files = map[string]*File{}
+	}
+	return &idx, nil
+}
+
+// Save writes the index to path, replacing the file atomically
+func (idx *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create index dir: %w", err)
+	}
+	lock, err := filelock.Acquire(path+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	tmpFile := path + ".tmp"
+	f, err := os.Create(tmpFile)
+	if err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := gob.NewEncoder(f).Encode(idx); err != nil {
+		f.Close()
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package index
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChunkLines(t *testing.T) {
+	text := "1\n2\n3\n4\n5\n6\n7\n"
+	chunks := ChunkLines(text, 3, 1)
+	want := [][2]int{{1, 3}, {3, 5}, {5, 7}}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d: %+v", len(chunks), len(want), chunks)
+	}
+	for i, c := range chunks {
+		if c.StartLine != want[i][0] || c.EndLine != want[i][1] {
+			t.Errorf("chunk %d covers %d-%d, want %d-%d", i, c.StartLine, c.EndLine, want[i][0], want[i][1])
+		}
+	}
+	if chunks[1].Text != "3\n4\n5" {
+		t.Errorf("unexpected chunk text %q", chunks[1].Text)
+	}
+}
+
+func TestUpdateIsIncremental(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "package a\n\nfunc A() {}\n")
+	write("b.md", "# B\n")
+	write("node_modules/x.js", "ignored")
+	write("bin.dat", "\x00\x01\x02")
+
+	embedded := 0
+	embed := func(texts []string) ([][]float32, error) {
+		embedded += len(texts)
+		vectors := make([][]float32, len(texts))
+		for i, text := range texts {
+			vectors[i] = []float32{float32(len(text)), 1}
+		}
+		return vectors, nil
+	}
+	opts := Options{ChunkLines: 2, Ignore: []string{"node_modules"}}
+
+	idx := New(root, "embed-model")
+	stats, err := idx.Update(opts, embed, nil)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if stats.Files != 2 || stats.Embedded != 2 || idx.ChunkCount() != 3 || embedded != 3 {
+		t.Fatalf("unexpected first build %+v, %d chunks, %d embedded", stats, idx.ChunkCount(), embedded)
+	}
+	if v := idx.Files["a.go"].Chunks[0].Vector; len(v) != 2 {
+		t.Fatalf("chunk has no vector: %v", v)
+	}
+
+	path := filepath.Join(t.TempDir(), "index.gob")
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	idx, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	write("a.go", "package a\n\nfunc A() { B() }\n")
+	os.Remove(filepath.Join(root, "b.md"))
+	write("c.txt", "new\n")
+	changed, added, removed, err := idx.Stale(opts)
+	if err != nil || changed != 1 || added != 1 || removed != 1 {
+		t.Fatalf("Stale = %d changed, %d added, %d removed, %v", changed, added, removed, err)
+	}
+
+	embedded = 0
+	stats, err = idx.Update(opts, embed, nil)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if stats.Embedded != 2 || stats.Removed != 1 || stats.Reused != 0 || embedded != 3 {
+		t.Fatalf("unexpected update %+v, %d embedded", stats, embedded)
+	}
+	stats, _ = idx.Update(opts, embed, nil)
+	if stats.Reused != 2 || stats.Embedded != 0 {
+		t.Fatalf("unchanged files were embedded again: %+v", stats)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "none.gob")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a not-exist error, got %v", err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	idx := New("/project", "embed-model")
+	idx.Files["a.go"] = &File{Chunks: []Chunk{
+		{StartLine: 1, EndLine: 10, Vector: []float32{1, 0}},
+		{StartLine: 9, EndLine: 20, Vector: []float32{0.6, 0.8}},
+	}}
+	idx.Files["b.go"] = &File{Chunks: []Chunk{
+		{StartLine: 1, EndLine: 5, Vector: []float32{0.8, 0.6}},
+		{StartLine: 5, EndLine: 9, Vector: []float32{0, 1, 0}},
+	}}
+
+	results := idx.Search([]float32{1, 0}, 2, nil)
+	if len(results) != 2 || results[0].Path != "a.go" || results[1].Path != "b.go" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	results = idx.Search([]float32{1, 0}, 5, func(path string) bool { return path != "a.go" })
+	if len(results) != 1 || results[0].Path != "b.go" || results[0].Chunk.StartLine != 1 {
+		t.Fatalf("expected only the matching chunk of b.go, got %+v", results)
+	}
+}
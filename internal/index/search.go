@@ -0,0 +1,59 @@
+package index
+
+import (
+	"math"
+	"sort"
+)
+
+// Result is a chunk matched by Search
+type Result struct {
+	Path  string
+	Chunk Chunk
+	Score float64 // Cosine similarity to the query
+}
+
+// Search returns the k chunks most similar to query, best first. Chunks of files for
+// which keep returns false are left out.
+func (idx *Index) Search(query []float32, k int, keep func(path string) bool) []Result {
+	var results []Result
+	for path, f := range idx.Files {
+		if keep != nil && !keep(path) {
+			continue
+		}
+		for _, c := range f.Chunks {
+			if score := cosine(query, c.Vector); score > 0 {
+				results = append(results, Result{Path: path, Chunk: c, Score: score})
+			}
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Path != results[j].Path {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].Chunk.StartLine < results[j].Chunk.StartLine
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// cosine returns the cosine similarity of a and b, or 0 when their lengths differ
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,370 @@
+package index
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/safeio"
+)
+
+// Symbol is a declaration in a file
+type Symbol struct {
+	Name string
+	Kind string // func, method, type, const, var, or the declaring keyword (class, def, ...)
+	Recv string // Receiver type of Go methods
+	Line int
+}
+
+// Call is a call of a function or method by name
+type Call struct {
+	Name string
+	Line int
+}
+
+// codeExtensions are the non-Go files whose declarations and calls are found by pattern
+var codeExtensions = map[string]bool{
+	".py": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".java": true, ".rs": true,
+	".rb": true, ".php": true, ".cs": true, ".swift": true, ".kt": true, ".c": true, ".cpp": true,
+	".h": true, ".sh": true,
+}
+
+var (
+	declarationLinePattern = regexp.MustCompile(`^\s*(?:export\s+|pub\s+|public\s+|private\s+|protected\s+|static\s+|async\s+|default\s+)*(func|type|const|var|let|class|def|function|interface|struct|enum|fn|trait)\s+([A-Za-z_][A-Za-z0-9_]*)`)
+	callPattern            = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	notCalls               = map[string]bool{"if": true, "for": true, "while": true, "switch": true, "return": true, "catch": true, "elif": true, "function": true, "sizeof": true, "and": true, "or": true, "not": true, "in": true}
+)
+
+// ExtractSymbols returns the declarations and calls in a file. Go files are parsed;
+// other code files are matched line by line, which finds most top-level declarations
+// and calls in common languages. Other files have neither.
+func ExtractSymbols(filename string, content []byte) ([]Symbol, []Call) {
+	ext := strings.ToLower(path.Ext(filename))
+	if ext == ".go" {
+		if symbols, calls, err := goSymbols(content); err == nil {
+			return symbols, calls
+		}
+	}
+	if !codeExtensions[ext] && ext != ".go" {
+		return nil, nil
+	}
+	var symbols []Symbol
+	var calls []Call
+	for i, line := range strings.Split(string(content), "\n") {
+		if m := declarationLinePattern.FindStringSubmatch(line); m != nil {
+			symbols = append(symbols, Symbol{Name: m[2], Kind: m[1], Line: i + 1})
+			continue
+		}
+		for _, m := range callPattern.FindAllStringSubmatch(line, -1) {
+			if !notCalls[m[1]] {
+				calls = append(calls, Call{Name: m[1], Line: i + 1})
+			}
+		}
+	}
+	return symbols, calls
+}
+
+// goSymbols parses Go source for its top-level declarations and calls
+func goSymbols(content []byte) ([]Symbol, []Call, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, nil, err
+	}
+	line := func(pos token.Pos) int { return fset.Position(pos).Line }
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			s := Symbol{Name: d.Name.Name, Kind: "func", Line: line(d.Name.Pos())}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				s.Kind, s.Recv = "method", receiverTypeName(d.Recv.List[0].Type)
+			}
+			symbols = append(symbols, s)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					symbols = append(symbols, Symbol{Name: sp.Name.Name, Kind: "type", Line: line(sp.Name.Pos())})
+				case *ast.ValueSpec:
+					for _, name := range sp.Names {
+						if name.Name != "_" {
+							symbols = append(symbols, Symbol{Name: name.Name, Kind: d.Tok.String(), Line: line(name.Pos())})
+						}
+					}
+				}
+			}
+		}
+	}
+	var calls []Call
+	ast.Inspect(file, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			switch fun := call.Fun.(type) {
+			case *ast.Ident:
+				calls = append(calls, Call{Name: fun.Name, Line: line(fun.Pos())})
+			case *ast.SelectorExpr:
+				calls = append(calls, Call{Name: fun.Sel.Name, Line: line(fun.Sel.Pos())})
+			}
+		}
+		return true
+	})
+	return symbols, calls, nil
+}
+
+// receiverTypeName returns the type name of a method receiver, without pointer or type
+// parameters
+func receiverTypeName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// Location is a line found by a structural query
+type Location struct {
+	Path   string
+	Line   int
+	Text   string // The trimmed source line
+	Symbol Symbol // The declaration, for Where
+	Caller string // The function the call is in, for Callers; empty at top level
+}
+
+// Structure holds the declarations and calls of every project file as they are on disk
+type Structure struct {
+	root  string
+	files map[string]*fileStructure
+	paths []string // Every project file, also those without declarations or calls
+}
+
+type fileStructure struct {
+	symbols []Symbol
+	calls   []Call
+	lines   []string // Read when first needed for files whose symbols were cached
+}
+
+// LoadStructure reads the declarations and calls of the project's files. Those of files
+// unchanged since cache stored them are taken from it without reading the files; of the
+// others, those unchanged since idx was built come from the index and the rest are
+// extracted from the files. cache (which may be nil) is updated but not saved.
+func LoadStructure(root string, idx *Index, opts Options, cache *SymbolCache) (*Structure, error) {
+	files, err := projectFiles(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	s := &Structure{root: root, files: map[string]*fileStructure{}}
+	present := map[string]bool{}
+	for _, rel := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		present[rel] = true
+		s.paths = append(s.paths, rel)
+		fs := &fileStructure{}
+		if cached, ok := cache.lookup(rel, info); ok {
+			fs.symbols, fs.calls = cached.Symbols, cached.Calls
+		} else {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			if indexable(content) {
+				fs.lines = strings.Split(string(content), "\n")
+				if f, ok := idx.file(rel); ok && f.Hash == safeio.Hash(content) {
+					fs.symbols, fs.calls = f.Symbols, f.Calls
+				} else {
+					fs.symbols, fs.calls = ExtractSymbols(rel, content)
+				}
+			}
+			cache.store(rel, info, fs.symbols, fs.calls)
+		}
+		if len(fs.symbols) > 0 || len(fs.calls) > 0 {
+			s.files[rel] = fs
+		}
+	}
+	cache.retain(present)
+	return s, nil
+}
+
+// fileLines returns the lines of a file with declarations or calls
+func (s *Structure) fileLines(rel string) []string {
+	fs := s.files[rel]
+	if fs.lines == nil {
+		content, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil
+		}
+		fs.lines = strings.Split(string(content), "\n")
+	}
+	return fs.lines
+}
+
+// file returns the indexed state of a file; a nil index has none
+func (idx *Index) file(rel string) (*File, bool) {
+	if idx == nil {
+		return nil, false
+	}
+	f, ok := idx.Files[rel]
+	return f, ok
+}
+
+// splitQualified splits "Type.Method" or "pkg.Func" into its qualifier and name
+func splitQualified(name string) (qualifier, base string) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// Where returns the declarations of name. A qualified name matches the receiver type of
+// a method or the directory name of a package, e.g. Config.Save or config.Load.
+func (s *Structure) Where(name string) []Location {
+	qualifier, base := splitQualified(name)
+	var locations []Location
+	for rel, fs := range s.files {
+		for _, sym := range fs.symbols {
+			if sym.Name != base {
+				continue
+			}
+			if qualifier != "" && sym.Recv != qualifier && path.Base(path.Dir(rel)) != qualifier {
+				continue
+			}
+			locations = append(locations, s.location(rel, sym.Line, Location{Symbol: sym}))
+		}
+	}
+	sortLocations(locations)
+	return locations
+}
+
+// Callers returns the calls of the function or method name (a qualifier is ignored, as
+// calls aren't resolved to types), each with the function it is in
+func (s *Structure) Callers(name string) []Location {
+	_, base := splitQualified(name)
+	var locations []Location
+	for rel, fs := range s.files {
+		for _, call := range fs.calls {
+			if call.Name != base {
+				continue
+			}
+			locations = append(locations, s.location(rel, call.Line, Location{Caller: enclosingFunction(fs.symbols, call.Line)}))
+		}
+	}
+	sortLocations(locations)
+	return locations
+}
+
+// location fills in the file, line and source text of loc
+func (s *Structure) location(rel string, line int, loc Location) Location {
+	loc.Path, loc.Line = rel, line
+	if lines := s.fileLines(rel); line > 0 && line <= len(lines) {
+		loc.Text = strings.TrimSpace(lines[line-1])
+	}
+	return loc
+}
+
+// enclosingFunction returns the name of the last function declared at or before line,
+// e.g. "(Config).Save"
+func enclosingFunction(symbols []Symbol, line int) string {
+	name := ""
+	for _, sym := range symbols {
+		if sym.Line > line {
+			break
+		}
+		switch sym.Kind {
+		case "func", "def", "function", "fn":
+			name = sym.Name
+		case "method":
+			name = "(" + sym.Recv + ")." + sym.Name
+		}
+	}
+	return name
+}
+
+func sortLocations(locations []Location) {
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].Path != locations[j].Path {
+			return locations[i].Path < locations[j].Path
+		}
+		return locations[i].Line < locations[j].Line
+	})
+}
+
+// HasCode reports whether any project file has declarations or calls
+func (s *Structure) HasCode() bool {
+	return len(s.files) > 0
+}
+
+// FindFiles returns the project files whose path is name or ends with /name
+func (s *Structure) FindFiles(name string) []string {
+	var found []string
+	for _, rel := range s.paths {
+		if rel == name || strings.HasSuffix(rel, "/"+name) {
+			found = append(found, rel)
+		}
+	}
+	return found
+}
+
+// HasPackage reports whether a directory of the project that has code is named name
+func (s *Structure) HasPackage(name string) bool {
+	for rel := range s.files {
+		if path.Base(path.Dir(rel)) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Mentions reports whether word appears as a whole identifier in a file with code, e.g.
+// a struct field or a local name, which aren't declarations of their own
+func (s *Structure) Mentions(word string) bool {
+	for rel := range s.files {
+		for _, line := range s.fileLines(rel) {
+			for i := strings.Index(line, word); i >= 0; {
+				end := i + len(word)
+				if (i == 0 || !isIdentByte(line[i-1])) && (end == len(line) || !isIdentByte(line[end])) {
+					return true
+				}
+				next := strings.Index(line[i+1:], word)
+				if next < 0 {
+					break
+				}
+				i += next + 1
+			}
+		}
+	}
+	return false
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// Lines returns lines first to last of a file, or as many of them as it has
+func (s *Structure) Lines(rel string, first, last int) string {
+	if _, ok := s.files[rel]; !ok {
+		return ""
+	}
+	lines := s.fileLines(rel)
+	if first < 1 || first > len(lines) {
+		return ""
+	}
+	return strings.Join(lines[first-1:min(last, len(lines))], "\n")
+}
@@ -0,0 +1,157 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/safeio"
+)
+
+func TestExtractSymbols(t *testing.T) {
+	src := "package store\n\ntype Store struct{}\n\nconst limit = 10\n\nfunc New() *Store {\n\treturn &Store{}\n}\n\nfunc (s *Store) Get(key string) string {\n\treturn lookup(key)\n}\n"
+	symbols, calls := ExtractSymbols("store/store.go", []byte(src))
+	want := []Symbol{
+		{Name: "Store", Kind: "type", Line: 3},
+		{Name: "limit", Kind: "const", Line: 5},
+		{Name: "New", Kind: "func", Line: 7},
+		{Name: "Get", Kind: "method", Recv: "Store", Line: 11},
+	}
+	if len(symbols) != len(want) {
+		t.Fatalf("expected %d symbols, got %+v", len(want), symbols)
+	}
+	for i := range want {
+		if symbols[i] != want[i] {
+			t.Errorf("symbol %d: expected %+v, got %+v", i, want[i], symbols[i])
+		}
+	}
+	if len(calls) != 1 || calls[0] != (Call{Name: "lookup", Line: 12}) {
+		t.Errorf("unexpected calls %+v", calls)
+	}
+
+	symbols, calls = ExtractSymbols("app.py", []byte("class App:\n    def run(self):\n        if ready():\n            start()\n"))
+	if len(symbols) != 2 || symbols[1].Name != "run" || symbols[1].Kind != "def" {
+		t.Errorf("unexpected Python symbols %+v", symbols)
+	}
+	if len(calls) != 2 || calls[0].Name != "ready" || calls[1].Name != "start" {
+		t.Errorf("unexpected Python calls %+v", calls)
+	}
+
+	if symbols, calls := ExtractSymbols("README.md", []byte("# Run it (now)\n")); symbols != nil || calls != nil {
+		t.Errorf("expected nothing for markdown, got %+v %+v", symbols, calls)
+	}
+}
+
+func TestStructureQueries(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"config/config.go": "package config\n\ntype Config struct{}\n\nfunc Load() *Config {\n\treturn nil\n}\n\nfunc (c *Config) Save() error {\n\treturn nil\n}\n",
+		"main.go":          "package main\n\nimport \"example.com/app/config\"\n\nfunc main() {\n\tcfg := config.Load()\n\tcfg.Save()\n}\n",
+		"tool/save.go":     "package tool\n\nfunc Save() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := LoadStructure(root, nil, Options{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Where("Save"); len(got) != 2 || got[0].Path != "config/config.go" || got[0].Line != 9 || got[1].Path != "tool/save.go" {
+		t.Fatalf("unexpected declarations of Save %+v", got)
+	}
+	if got := s.Where("Config.Save"); len(got) != 1 || got[0].Symbol.Recv != "Config" {
+		t.Fatalf("expected only the method for Config.Save, got %+v", got)
+	}
+	if got := s.Where("tool.Save"); len(got) != 1 || got[0].Path != "tool/save.go" {
+		t.Fatalf("expected only the function for tool.Save, got %+v", got)
+	}
+
+	got := s.Callers("config.Load")
+	if len(got) != 1 || got[0].Path != "main.go" || got[0].Line != 6 || got[0].Caller != "main" || got[0].Text != "cfg := config.Load()" {
+		t.Fatalf("unexpected callers of Load %+v", got)
+	}
+	if got := s.Lines("config/config.go", 5, 7); got != "func Load() *Config {\n\treturn nil\n}" {
+		t.Fatalf("unexpected lines %q", got)
+	}
+
+	if got := s.FindFiles("save.go"); len(got) != 1 || got[0] != "tool/save.go" {
+		t.Fatalf("unexpected files named save.go %v", got)
+	}
+	if !s.HasPackage("tool") || s.HasPackage("save") {
+		t.Fatal("expected tool, and not save, to be a package")
+	}
+	if !s.Mentions("cfg") || s.Mentions("onfig") || s.Mentions("Missing") {
+		t.Fatal("expected only whole identifiers in the code to be mentioned")
+	}
+
+	// Symbols of unchanged indexed files come from the index
+	idx := New(root, "model")
+	content := []byte(files["tool/save.go"])
+	idx.Files["tool/save.go"] = &File{Hash: safeio.Hash(content), Symbols: []Symbol{{Name: "Indexed", Kind: "func", Line: 3}}}
+	s, err = LoadStructure(root, idx, Options{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Where("Indexed"); len(got) != 1 || got[0].Text != "func Save() {}" {
+		t.Fatalf("expected the indexed symbol, got %+v", got)
+	}
+}
+
+func TestSymbolCache(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "save.go")
+	if err := os.WriteFile(path, []byte("package tool\n\nfunc Save() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cachePath := filepath.Join(t.TempDir(), "symbols.gob")
+	cache := OpenSymbolCache(cachePath)
+	if _, err := LoadStructure(root, nil, Options{}, cache); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new run takes the symbols of the unchanged file from the cache
+	cache = OpenSymbolCache(cachePath)
+	entry := cache.files["save.go"]
+	if len(entry.Symbols) != 1 || entry.Symbols[0].Name != "Save" {
+		t.Fatalf("expected Save in the stored cache, got %+v", entry)
+	}
+	entry.Symbols = []Symbol{{Name: "Cached", Kind: "func", Line: 3}}
+	cache.files["save.go"] = entry
+	s, err := LoadStructure(root, nil, Options{}, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Where("Cached"); len(got) != 1 || got[0].Text != "func Save() {}" {
+		t.Fatalf("expected the cached symbol, got %+v", got)
+	}
+
+	// A changed file is parsed again
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = LoadStructure(root, nil, Options{}, cache); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Where("Save"); len(got) != 1 || len(s.Where("Cached")) != 0 {
+		t.Fatalf("expected the file to be parsed again, got %+v", got)
+	}
+
+	// Deleted files are dropped
+	os.Remove(path)
+	if _, err := LoadStructure(root, nil, Options{}, cache); err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.files) != 0 {
+		t.Fatalf("expected the deleted file to be dropped, got %+v", cache.files)
+	}
+}
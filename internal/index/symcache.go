@@ -0,0 +1,153 @@
+package index
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/filelock"
+)
+
+// symbolCacheVersion is bumped when the cached layout or the symbol extraction changes
+const symbolCacheVersion = 1
+
+// SymbolCache keeps the declarations and calls of a project's files on disk by their
+// modification time and size, so structural queries of a large project only read and
+// parse the files that changed since the last run. It is safe for concurrent use.
+type SymbolCache struct {
+	mu      sync.Mutex
+	path    string
+	files   map[string]cachedSymbols
+	changed bool
+}
+
+// cachedSymbols is what the cache knows about one file. Files without declarations or
+// calls are kept too, so they aren't read again either.
+type cachedSymbols struct {
+	ModTime time.Time
+	Size    int64
+	Symbols []Symbol
+	Calls   []Call
+}
+
+// symbolCacheFile is the stored form of a SymbolCache
+type symbolCacheFile struct {
+	Version int
+	Files   map[string]cachedSymbols
+}
+
+// SymbolCachePathFor returns the symbol cache file of a project root in the data dir
+func SymbolCachePathFor(root string) (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(dataDir, "cache", "symbols", hex.EncodeToString(sum[:8])+".gob"), nil
+}
+
+// OpenSymbolCache reads the symbol cache at path. A missing, unreadable or outdated
+// cache starts out empty.
+func OpenSymbolCache(path string) *SymbolCache {
+	c := &SymbolCache{path: path, files: map[string]cachedSymbols{}}
+	f, err := os.Open(path)
+	if err != nil {
+		return c
+	}
+	defer f.Close()
+	var stored symbolCacheFile
+	if gob.NewDecoder(f).Decode(&stored) == nil && stored.Version == symbolCacheVersion && stored.Files != nil {
+		c.files = stored.Files
+	}
+	return c
+}
+
+// lookup returns the cached symbols of rel if the file is unchanged since they were cached
+func (c *SymbolCache) lookup(rel string, info os.FileInfo) (cachedSymbols, bool) {
+	if c == nil {
+		return cachedSymbols{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.files[rel]
+	if !ok || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		return cachedSymbols{}, false
+	}
+	return entry, true
+}
+
+// store caches the symbols of rel as it is described by info
+func (c *SymbolCache) store(rel string, info os.FileInfo, symbols []Symbol, calls []Call) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files[rel] = cachedSymbols{ModTime: info.ModTime(), Size: info.Size(), Symbols: symbols, Calls: calls}
+	c.changed = true
+}
+
+// retain drops the files that aren't in present, e.g. because they were deleted
+func (c *SymbolCache) retain(present map[string]bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for rel := range c.files {
+		if !present[rel] {
+			delete(c.files, rel)
+			c.changed = true
+		}
+	}
+}
+
+// Save writes the cache if it changed since it was read, replacing the file atomically
+func (c *SymbolCache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.changed {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create symbol cache dir: %w", err)
+	}
+	lock, err := filelock.Acquire(c.path+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	tmpFile := c.path + ".tmp"
+	f, err := os.Create(tmpFile)
+	if err != nil {
+		return fmt.Errorf("failed to write symbol cache: %w", err)
+	}
+	if err := gob.NewEncoder(f).Encode(symbolCacheFile{Version: symbolCacheVersion, Files: c.files}); err != nil {
+		f.Close()
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write symbol cache: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write symbol cache: %w", err)
+	}
+	if err := os.Rename(tmpFile, c.path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write symbol cache: %w", err)
+	}
+	c.changed = false
+	return nil
+}
@@ -0,0 +1,307 @@
+// Package infra takes an inventory of what a project runs on: its Docker Compose
+// services, the containers running on the machine and the Kubernetes contexts of the
+// kubeconfig, so commands that manage them can use the right names.
+package infra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dockerTimeout bounds how long listing the running containers may take
+const dockerTimeout = 2 * time.Second
+
+// Limits on how much of the inventory Describe lists
+const (
+	maxContainers   = 15
+	maxKubeContexts = 10
+)
+
+// composeFiles are the names Docker Compose looks for, in the order it prefers them
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// Inventory is what a project runs on
+type Inventory struct {
+	Compose    *ComposeProject
+	Containers []Container // Running containers: the Compose project's if it has one, all of them otherwise
+	Kube       *KubeConfig
+}
+
+// ComposeProject is the Docker Compose file at a project root
+type ComposeProject struct {
+	File     string // e.g. compose.yaml
+	Name     string // The project name, which prefixes container names
+	Services []ComposeService
+}
+
+// ComposeService is a service of a Compose file
+type ComposeService struct {
+	Name          string
+	Image         string // Empty when the service is built
+	ContainerName string // Set only when the file names the container
+}
+
+// Container is a running container
+type Container struct {
+	Name    string
+	Image   string
+	Status  string // e.g. "Up 3 hours"
+	Project string // Compose project, if it was started by Compose
+	Service string // Compose service, if it was started by Compose
+}
+
+// KubeConfig is the part of the kubeconfig that commands need
+type KubeConfig struct {
+	Current  string // The current context
+	Contexts []KubeContext
+}
+
+// KubeContext is a kubeconfig context
+type KubeContext struct {
+	Name      string
+	Cluster   string
+	Namespace string // "default" when the context sets none
+}
+
+// Detect takes the inventory of the project at root. Parts that aren't there, such as
+// Docker on a machine without it, are left empty.
+func Detect(root string) Inventory {
+	var inv Inventory
+	inv.Compose, _ = ReadCompose(root)
+	if containers, err := runningContainers(); err == nil {
+		inv.Containers = projectContainers(containers, inv.Compose)
+	}
+	inv.Kube, _ = ReadKubeConfig(kubeConfigFiles())
+	return inv
+}
+
+// Empty reports whether nothing was found
+func (inv Inventory) Empty() bool {
+	return inv.Compose == nil && len(inv.Containers) == 0 && (inv.Kube == nil || len(inv.Kube.Contexts) == 0)
+}
+
+// Describe returns the inventory as lines for a system prompt, or "" if it is empty
+func (inv Inventory) Describe() string {
+	var lines []string
+	if c := inv.Compose; c != nil {
+		var services []string
+		for _, s := range c.Services {
+			text := s.Name
+			var details []string
+			if s.Image != "" {
+				details = append(details, "image "+s.Image)
+			}
+			if s.ContainerName != "" {
+				details = append(details, "container "+s.ContainerName)
+			}
+			if len(details) > 0 {
+				text += " (" + strings.Join(details, ", ") + ")"
+			}
+			services = append(services, text)
+		}
+		lines = append(lines, fmt.Sprintf("- Docker Compose file %s, project %q, services: %s", c.File, c.Name, strings.Join(services, ", ")))
+	}
+	if len(inv.Containers) > 0 {
+		var containers []string
+		for i, c := range inv.Containers {
+			if i == maxContainers {
+				containers = append(containers, fmt.Sprintf("and %d more", len(inv.Containers)-maxContainers))
+				break
+			}
+			text := fmt.Sprintf("%s (%s, %s", c.Name, c.Image, c.Status)
+			if c.Service != "" {
+				text += fmt.Sprintf(", compose service %s/%s", c.Project, c.Service)
+			}
+			containers = append(containers, text+")")
+		}
+		lines = append(lines, "- Running containers: "+strings.Join(containers, ", "))
+	}
+	if k := inv.Kube; k != nil && len(k.Contexts) > 0 {
+		var contexts []string
+		for i, c := range k.Contexts {
+			if i == maxKubeContexts {
+				contexts = append(contexts, fmt.Sprintf("and %d more", len(k.Contexts)-maxKubeContexts))
+				break
+			}
+			text := c.Name + " ("
+			if c.Name == k.Current {
+				text += "current, "
+			}
+			if c.Cluster != "" {
+				text += "cluster " + c.Cluster + ", "
+			}
+			contexts = append(contexts, text+"namespace "+c.Namespace+")")
+		}
+		lines = append(lines, "- Kubernetes contexts: "+strings.Join(contexts, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ReadCompose reads the Compose file at root, or returns nil if there is none
+func ReadCompose(root string) (*ComposeProject, error) {
+	for _, name := range composeFiles {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		var file struct {
+			Name     string `yaml:"name"`
+			Services map[string]struct {
+				Image         string `yaml:"image"`
+				ContainerName string `yaml:"container_name"`
+			} `yaml:"services"`
+		}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		project := &ComposeProject{File: name, Name: file.Name}
+		if project.Name == "" {
+			project.Name = composeProjectName(filepath.Base(root))
+		}
+		for service, s := range file.Services {
+			project.Services = append(project.Services, ComposeService{Name: service, Image: s.Image, ContainerName: s.ContainerName})
+		}
+		sort.Slice(project.Services, func(i, j int) bool { return project.Services[i].Name < project.Services[j].Name })
+		return project, nil
+	}
+	return nil, nil
+}
+
+var composeNameInvalid = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// composeProjectName is the project name Compose derives from a directory name
+func composeProjectName(dir string) string {
+	return composeNameInvalid.ReplaceAllString(strings.ToLower(dir), "")
+}
+
+// runningContainers lists the running containers with docker ps
+func runningContainers() ([]Container, error) {
+	docker, err := exec.LookPath("docker")
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, docker, "ps", "--format",
+		`{{.Names}}\t{{.Image}}\t{{.Status}}\t{{.Label "com.docker.compose.project"}}\t{{.Label "com.docker.compose.service"}}`)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("docker ps: %w", err)
+	}
+	return parseContainers(stdout.String()), nil
+}
+
+// parseContainers parses the tab-separated lines runningContainers asks docker ps for
+func parseContainers(out string) []Container {
+	var containers []Container
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		c := Container{Name: fields[0], Image: fields[1], Status: fields[2]}
+		if len(fields) == 5 {
+			c.Project, c.Service = fields[3], fields[4]
+		}
+		containers = append(containers, c)
+	}
+	return containers
+}
+
+// projectContainers returns the containers of the Compose project, or all of them if
+// there is no Compose project or none of its containers are running
+func projectContainers(containers []Container, project *ComposeProject) []Container {
+	if project == nil {
+		return containers
+	}
+	var own []Container
+	for _, c := range containers {
+		if c.Project == project.Name {
+			own = append(own, c)
+		}
+	}
+	if len(own) == 0 {
+		return containers
+	}
+	return own
+}
+
+// kubeConfigFiles returns the kubeconfig files kubectl reads: $KUBECONFIG or ~/.kube/config
+func kubeConfigFiles() []string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return filepath.SplitList(env)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(home, ".kube", "config")}
+}
+
+// ReadKubeConfig reads the contexts of the kubeconfig files, merged the way kubectl
+// merges them: the first file to set the current context or a context name wins. It
+// returns nil if none of the files exist.
+func ReadKubeConfig(files []string) (*KubeConfig, error) {
+	var kube *KubeConfig
+	seen := map[string]bool{}
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+		}
+		var file struct {
+			CurrentContext string `yaml:"current-context"`
+			Contexts       []struct {
+				Name    string `yaml:"name"`
+				Context struct {
+					Cluster   string `yaml:"cluster"`
+					Namespace string `yaml:"namespace"`
+				} `yaml:"context"`
+			} `yaml:"contexts"`
+		}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse kubeconfig %s: %w", name, err)
+		}
+		if kube == nil {
+			kube = &KubeConfig{}
+		}
+		if kube.Current == "" {
+			kube.Current = file.CurrentContext
+		}
+		for _, c := range file.Contexts {
+			if c.Name == "" || seen[c.Name] {
+				continue
+			}
+			seen[c.Name] = true
+			namespace := c.Context.Namespace
+			if namespace == "" {
+				namespace = "default"
+			}
+			kube.Contexts = append(kube.Contexts, KubeContext{Name: c.Name, Cluster: c.Context.Cluster, Namespace: namespace})
+		}
+	}
+	if kube != nil {
+		// The current context first, as it is the one commands act on
+		sort.SliceStable(kube.Contexts, func(i, j int) bool {
+			return kube.Contexts[i].Name == kube.Current && kube.Contexts[j].Name != kube.Current
+		})
+	}
+	return kube, nil
+}
@@ -0,0 +1,94 @@
+package infra
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCompose(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "My App")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if project, err := ReadCompose(root); project != nil || err != nil {
+		t.Fatalf("expected no project without a compose file, got %v, %v", project, err)
+	}
+	compose := "services:\n  web:\n    image: nginx:1.25\n  db:\n    image: postgres:16\n    container_name: shop-db\n  worker:\n    build: .\n"
+	if err := os.WriteFile(filepath.Join(root, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	project, err := ReadCompose(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ComposeProject{File: "docker-compose.yml", Name: "myapp", Services: []ComposeService{
+		{Name: "db", Image: "postgres:16", ContainerName: "shop-db"},
+		{Name: "web", Image: "nginx:1.25"},
+		{Name: "worker"},
+	}}
+	if !reflect.DeepEqual(project, want) {
+		t.Fatalf("expected %+v, got %+v", want, project)
+	}
+}
+
+func TestReadKubeConfig(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	os.WriteFile(first, []byte("current-context: prod\ncontexts:\n- name: staging\n  context:\n    cluster: eu\n- name: prod\n  context:\n    cluster: eu\n    namespace: shop\n"), 0600)
+	os.WriteFile(second, []byte("current-context: dev\ncontexts:\n- name: prod\n  context:\n    cluster: other\n- name: dev\n  context:\n    cluster: kind\n"), 0600)
+
+	kube, err := ReadKubeConfig([]string{first, filepath.Join(dir, "missing"), second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &KubeConfig{Current: "prod", Contexts: []KubeContext{
+		{Name: "prod", Cluster: "eu", Namespace: "shop"},
+		{Name: "staging", Cluster: "eu", Namespace: "default"},
+		{Name: "dev", Cluster: "kind", Namespace: "default"},
+	}}
+	if !reflect.DeepEqual(kube, want) {
+		t.Fatalf("expected %+v, got %+v", want, kube)
+	}
+	if kube, err := ReadKubeConfig([]string{filepath.Join(dir, "missing")}); kube != nil || err != nil {
+		t.Fatalf("expected no kubeconfig, got %v, %v", kube, err)
+	}
+}
+
+func TestProjectContainers(t *testing.T) {
+	containers := parseContainers("myapp-web-1\tnginx:1.25\tUp 3 hours\tmyapp\tweb\nredis\tredis:7\tUp 2 days\t\t\n")
+	if len(containers) != 2 || containers[0].Service != "web" || containers[1].Project != "" {
+		t.Fatalf("unexpected containers %+v", containers)
+	}
+	own := projectContainers(containers, &ComposeProject{Name: "myapp"})
+	if len(own) != 1 || own[0].Name != "myapp-web-1" {
+		t.Fatalf("expected only the project's container, got %+v", own)
+	}
+	if all := projectContainers(containers, &ComposeProject{Name: "other"}); len(all) != 2 {
+		t.Fatalf("expected all containers when none are the project's, got %+v", all)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	if (Inventory{}).Describe() != "" || !(Inventory{}).Empty() {
+		t.Fatal("expected an empty inventory to describe nothing")
+	}
+	inv := Inventory{
+		Compose:    &ComposeProject{File: "compose.yaml", Name: "myapp", Services: []ComposeService{{Name: "web", Image: "nginx"}}},
+		Containers: []Container{{Name: "myapp-web-1", Image: "nginx", Status: "Up 3 hours", Project: "myapp", Service: "web"}},
+		Kube:       &KubeConfig{Current: "prod", Contexts: []KubeContext{{Name: "prod", Cluster: "eu", Namespace: "shop"}}},
+	}
+	got := inv.Describe()
+	for _, want := range []string{
+		`compose.yaml, project "myapp", services: web (image nginx)`,
+		"myapp-web-1 (nginx, Up 3 hours, compose service myapp/web)",
+		"prod (current, cluster eu, namespace shop)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package logging sets up the shared slog logger. Logs go to a file so they never mix
+// with the terminal UI.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+// maxLogBytes is the size at which the log file is rotated to <file>.1 on startup
+const maxLogBytes = 10 * 1024 * 1024
+
+// Levels lists the accepted log levels
+var Levels = []string{"debug", "info", "warn", "error", "off"}
+
+// Options configures the logger
+type Options struct {
+	Level  string // debug, info, warn, error or off
+	Format string // text or json
+	File   string // Empty means llamasidekick.log in the data dir, "stderr" the terminal's stderr
+}
+
+// StderrFile is the File value that logs to stderr instead of a file
+const StderrFile = "stderr"
+
+// ParseLevel converts a level name to a slog level. "off" is reported with ok false.
+func ParseLevel(name string) (level slog.Level, ok bool, err error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, true, nil
+	case "info":
+		return slog.LevelInfo, true, nil
+	case "", "warn", "warning":
+		return slog.LevelWarn, true, nil
+	case "error":
+		return slog.LevelError, true, nil
+	case "off":
+		return 0, false, nil
+	}
+	return 0, false, fmt.Errorf("unknown log level %q (use %s)", name, strings.Join(Levels, ", "))
+}
+
+// DefaultFile returns the log file used when none is configured
+func DefaultFile() (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "llamasidekick.log"), nil
+}
+
+// Setup installs the default slog logger. It returns the path being logged to, or ""
+// when logging is off.
+func Setup(opts Options) (string, error) {
+	level, enabled, err := ParseLevel(opts.Level)
+	if err != nil {
+		return "", err
+	}
+	if !enabled {
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1})))
+		return "", nil
+	}
+
+	newHandler := func(w io.Writer, o *slog.HandlerOptions) slog.Handler { return slog.NewTextHandler(w, o) }
+	switch opts.Format {
+	case "", "text":
+	case "json":
+		newHandler = func(w io.Writer, o *slog.HandlerOptions) slog.Handler { return slog.NewJSONHandler(w, o) }
+	default:
+		return "", fmt.Errorf("unknown log format %q (use text or json)", opts.Format)
+	}
+
+	path := opts.File
+	out := io.Writer(os.Stderr)
+	if path != StderrFile {
+		if path == "" {
+			if path, err = DefaultFile(); err != nil {
+				return "", err
+			}
+		}
+		file, err := openLogFile(path)
+		if err != nil {
+			return "", err
+		}
+		out = file
+	}
+	handler := newHandler(out, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(handler).With("pid", os.Getpid()))
+	return path, nil
+}
+
+// openLogFile opens path for appending, rotating it first when it has grown too large
+func openLogFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log dir: %w", err)
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > maxLogBytes {
+		os.Rename(path, path+".1")
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return file, nil
+}
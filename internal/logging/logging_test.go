@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	if level, ok, err := ParseLevel("debug"); err != nil || !ok || level != slog.LevelDebug {
+		t.Fatalf("unexpected result for debug: %v %v %v", level, ok, err)
+	}
+	if _, ok, err := ParseLevel("off"); err != nil || ok {
+		t.Fatalf("expected off to disable logging, got %v %v", ok, err)
+	}
+	if _, _, err := ParseLevel("verbose"); err == nil {
+		t.Fatalf("expected error for unknown level")
+	}
+}
+
+func TestSetup_WritesJSONToFile(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	path := filepath.Join(t.TempDir(), "logs", "test.log")
+
+	if _, err := Setup(Options{Level: "info", Format: "json", File: path}); err != nil {
+		t.Fatal(err)
+	}
+	slog.Debug("hidden")
+	slog.Info("wrote file", "path", "a.txt")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected only the info line, got %q", data)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("expected JSON log line: %v", err)
+	}
+	if entry["msg"] != "wrote file" || entry["path"] != "a.txt" {
+		t.Fatalf("unexpected entry: %v", entry)
+	}
+}
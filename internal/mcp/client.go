@@ -0,0 +1,274 @@
+// Package mcp is a minimal Model Context Protocol client for tool servers that speak
+// JSON-RPC 2.0 over stdio.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProtocolVersion is the MCP revision the client negotiates
+const ProtocolVersion = "2024-11-05"
+
+// DefaultTimeout bounds how long a single request may take
+const DefaultTimeout = 60 * time.Second
+
+// Tool describes a tool offered by a server
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// Client is a connection to one MCP server process
+type Client struct {
+	Name    string
+	Timeout time.Duration
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	mu      sync.Mutex
+	nextID  int
+	waiting map[int]chan response
+	closed  chan struct{}
+	readErr error
+}
+
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *int        `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id"`
+	Method  string          `json:"method"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Start launches a server and performs the initialize handshake. env entries have the
+// form KEY=VALUE and are added to the current environment.
+func Start(name, command string, args, env []string) (*Client, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = append(os.Environ(), env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start MCP server %s: %w", name, err)
+	}
+
+	c := &Client{
+		Name:    name,
+		Timeout: DefaultTimeout,
+		cmd:     cmd,
+		stdin:   stdin,
+		waiting: map[int]chan response{},
+		closed:  make(chan struct{}),
+	}
+	go c.readLoop(stdout)
+
+	params := map[string]interface{}{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "llamasidekick", "version": "1"},
+	}
+	if _, err := c.call("initialize", params); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to initialize MCP server %s: %w", name, err)
+	}
+	if err := c.notify("notifications/initialized"); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to initialize MCP server %s: %w", name, err)
+	}
+	return c, nil
+}
+
+// ListTools returns the tools the server offers
+func (c *Client) ListTools() ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		result, err := c.call("tools/list", params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tools of %s: %w", c.Name, err)
+		}
+		var page struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(result, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse tools of %s: %w", c.Name, err)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool runs a tool and returns its text output. A tool that reports an error is
+// returned as an error carrying its output.
+func (c *Client) CallTool(name string, arguments map[string]interface{}) (string, error) {
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	result, err := c.call("tools/call", map[string]interface{}{"name": name, "arguments": arguments})
+	if err != nil {
+		return "", fmt.Errorf("failed to call %s.%s: %w", c.Name, name, err)
+	}
+	var out struct {
+		Content []struct {
+			Type     string `json:"type"`
+			Text     string `json:"text"`
+			MimeType string `json:"mimeType"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(result, &out); err != nil {
+		return "", fmt.Errorf("failed to parse result of %s.%s: %w", c.Name, name, err)
+	}
+
+	var parts []string
+	for _, item := range out.Content {
+		if item.Type == "text" {
+			parts = append(parts, item.Text)
+		} else {
+			parts = append(parts, fmt.Sprintf("[%s content %s omitted]", item.Type, item.MimeType))
+		}
+	}
+	text := strings.Join(parts, "\n")
+	if out.IsError {
+		return "", fmt.Errorf("%s.%s failed: %s", c.Name, name, text)
+	}
+	return text, nil
+}
+
+// Close stops the server
+func (c *Client) Close() error {
+	c.stdin.Close()
+	done := make(chan error, 1)
+	go func() { done <- c.cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		c.cmd.Process.Kill()
+		<-done
+	}
+	return nil
+}
+
+func (c *Client) call(method string, params interface{}) (json.RawMessage, error) {
+	c.mu.Lock()
+	if c.readErr != nil {
+		err := c.readErr
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.nextID++
+	id := c.nextID
+	reply := make(chan response, 1)
+	c.waiting[id] = reply
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.waiting, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(request{JSONRPC: "2.0", ID: &id, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-reply:
+		if resp.Error != nil {
+			return nil, fmt.Errorf("server error %d: %s", resp.Error.Code, resp.Error.Message)
+		}
+		return resp.Result, nil
+	case <-c.closed:
+		return nil, c.readErr
+	case <-time.After(c.Timeout):
+		return nil, fmt.Errorf("%s timed out after %s", method, c.Timeout)
+	}
+}
+
+func (c *Client) notify(method string) error {
+	return c.send(request{JSONRPC: "2.0", Method: method})
+}
+
+func (c *Client) send(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to MCP server %s: %w", c.Name, err)
+	}
+	return nil
+}
+
+// readLoop delivers responses to waiting calls and refuses requests from the server,
+// since the client offers no capabilities
+func (c *Client) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var msg response
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue // Servers may log to stdout; ignore anything that isn't JSON-RPC
+		}
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			c.send(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      *msg.ID,
+				"error":   rpcError{Code: -32601, Message: "method not supported by client"},
+			})
+		case msg.Method == "" && msg.ID != nil:
+			c.mu.Lock()
+			reply := c.waiting[*msg.ID]
+			c.mu.Unlock()
+			if reply != nil {
+				reply <- msg
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.readErr = fmt.Errorf("MCP server %s exited", c.Name)
+	if err := scanner.Err(); err != nil {
+		c.readErr = fmt.Errorf("failed to read from MCP server %s: %w", c.Name, err)
+	}
+	c.mu.Unlock()
+	close(c.closed)
+}
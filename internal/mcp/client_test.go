@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestHelperServer is not a real test: it acts as a tiny MCP server when run as a child process
+func TestHelperServer(t *testing.T) {
+	if os.Getenv("LLAMASIDEKICK_FAKE_MCP") != "1" {
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     *int   `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Name      string                 `json:"name"`
+				Arguments map[string]interface{} `json:"arguments"`
+			} `json:"params"`
+		}
+		json.Unmarshal(scanner.Bytes(), &req)
+		if req.ID == nil {
+			continue
+		}
+		var result string
+		switch req.Method {
+		case "initialize":
+			fmt.Println("this line is log noise")
+			result = `{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"fake"}}`
+		case "tools/list":
+			result = `{"tools":[{"name":"echo","description":"Echo the text back","inputSchema":{"type":"object","properties":{"text":{"type":"string"}}}}]}`
+		case "tools/call":
+			if req.Params.Name != "echo" {
+				result = `{"content":[{"type":"text","text":"no such tool"}],"isError":true}`
+				break
+			}
+			text, _ := json.Marshal(fmt.Sprint(req.Params.Arguments["text"]) + " " + os.Getenv("FAKE_MCP_SUFFIX"))
+			result = fmt.Sprintf(`{"content":[{"type":"text","text":%s}]}`, text)
+		}
+		fmt.Printf(`{"jsonrpc":"2.0","id":%d,"result":%s}`+"\n", *req.ID, result)
+	}
+	os.Exit(0)
+}
+
+func startFake(t *testing.T) *Client {
+	t.Helper()
+	c, err := Start("fake", os.Args[0], []string{"-test.run=TestHelperServer"}, []string{"LLAMASIDEKICK_FAKE_MCP=1", "FAKE_MCP_SUFFIX=!"})
+	if err != nil {
+		t.Fatalf("failed to start fake server: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient_ListAndCallTools(t *testing.T) {
+	c := startFake(t)
+
+	tools, err := c.ListTools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 1 || tools[0].Name != "echo" {
+		t.Fatalf("unexpected tools: %+v", tools)
+	}
+
+	out, err := c.CallTool("echo", map[string]interface{}{"text": "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "hello !" {
+		t.Fatalf("unexpected output %q", out)
+	}
+
+	if _, err := c.CallTool("missing", nil); err == nil || !strings.Contains(err.Error(), "no such tool") {
+		t.Fatalf("expected tool error, got %v", err)
+	}
+}
+
+func TestClient_FailsWhenServerExits(t *testing.T) {
+	if _, err := Start("broken", os.Args[0], []string{"-test.run=^$"}, nil); err == nil {
+		t.Fatalf("expected error for server that exits immediately")
+	}
+}
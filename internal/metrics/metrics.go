@@ -0,0 +1,217 @@
+// Package metrics keeps counters, gauges and histograms in memory and writes them in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultDurationBuckets are histogram buckets in seconds suited to model responses
+var DefaultDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Registry holds metric families in registration order
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    string // counter, gauge or histogram
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64   // counters and gauges
+	counts      []float64 // histogram bucket counts (not cumulative)
+	sum         float64
+	count       float64
+}
+
+// Counter is a monotonically increasing value for each combination of labels
+type Counter struct {
+	r *Registry
+	f *family
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	r *Registry
+	f *family
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	r *Registry
+	f *family
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(name, help, kind string, buckets []float64, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := &family{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: map[string]*series{}}
+	r.families = append(r.families, f)
+	return f
+}
+
+// Counter registers a counter with the given label names
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{r: r, f: r.register(name, help, "counter", nil, labels)}
+}
+
+// Gauge registers a gauge with the given label names
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r: r, f: r.register(name, help, "gauge", nil, labels)}
+}
+
+// Histogram registers a histogram with ascending bucket upper bounds and label names
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{r: r, f: r.register(name, help, "histogram", buckets, labels)}
+}
+
+// lookup returns the series for labelValues, creating it on first use. The registry
+// lock must be held.
+func (f *family) lookup(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.kind == "histogram" {
+			s.counts = make([]float64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Add increases the counter by value, which must not be negative
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.f.lookup(labelValues).value += value
+}
+
+// Inc increases the counter by one
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Set sets the gauge
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	g.f.lookup(labelValues).value = value
+}
+
+// Add changes the gauge by delta
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	g.f.lookup(labelValues).value += delta
+}
+
+// Observe records one observation
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.r.mu.Lock()
+	defer h.r.mu.Unlock()
+	s := h.f.lookup(labelValues)
+	for i, upper := range h.f.buckets {
+		if value <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+// Write writes every metric in the Prometheus text format
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+	for _, f := range r.families {
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.kind)
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := f.series[key]
+			if f.kind != "histogram" {
+				fmt.Fprintf(&b, "%s%s %s\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), formatValue(s.value))
+				continue
+			}
+			cumulative := 0.0
+			for i, upper := range f.buckets {
+				cumulative += s.counts[i]
+				fmt.Fprintf(&b, "%s_bucket%s %s\n", f.name, formatLabels(f.labels, s.labelValues, "le", formatValue(upper)), formatValue(cumulative))
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %s\n", f.name, formatLabels(f.labels, s.labelValues, "le", "+Inf"), formatValue(s.count))
+			fmt.Fprintf(&b, "%s_sum%s %s\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), formatValue(s.sum))
+			fmt.Fprintf(&b, "%s_count%s %s\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), formatValue(s.count))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatLabels renders {name="value",...}, with an optional extra label such as le
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	parts := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		parts = append(parts, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		parts = append(parts, extraName+`="`+extraValue+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escapeLabel escapes a label value; the text format only escapes backslash, double quote
+// and newline
+func escapeLabel(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(help)
+}
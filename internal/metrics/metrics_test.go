@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	requests := r.Counter("test_requests_total", "Requests handled.", "route", "status")
+	pending := r.Gauge("test_pending", "Pending things.")
+	latency := r.Histogram("test_duration_seconds", "Latency.", []float64{0.5, 1}, "route")
+
+	requests.Inc("GET /a", "200")
+	requests.Add(2, "GET /a", "200")
+	requests.Inc("POST \"b\"", "500")
+	pending.Set(3)
+	latency.Observe(0.2, "GET /a")
+	latency.Observe(0.7, "GET /a")
+	latency.Observe(9, "GET /a")
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	want := `# HELP test_requests_total Requests handled.
+# TYPE test_requests_total counter
+test_requests_total{route="GET /a",status="200"} 3
+test_requests_total{route="POST \"b\"",status="500"} 1
+# HELP test_pending Pending things.
+# TYPE test_pending gauge
+test_pending 3
+# HELP test_duration_seconds Latency.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="GET /a",le="0.5"} 1
+test_duration_seconds_bucket{route="GET /a",le="1"} 2
+test_duration_seconds_bucket{route="GET /a",le="+Inf"} 3
+test_duration_seconds_sum{route="GET /a"} 9.9
+test_duration_seconds_count{route="GET /a"} 3
+`
+	if got := b.String(); got != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -3,19 +3,39 @@ package modes
 import (
 	"bufio"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
-	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
 	"github.com/yourusername/llamasidekick/internal/ollama"
-	"github.com/yourusername/llamasidekick/internal/renderer"
+	"github.com/yourusername/llamasidekick/internal/progress"
 	"github.com/yourusername/llamasidekick/internal/safeio"
 	"github.com/yourusername/llamasidekick/internal/session"
 )
 
+// generatedFilesPrompt asks for a response that is only a JSON array of files, for
+// ParseGeneratedFilesJSON
+const generatedFilesPrompt = `You MUST respond with ONLY a valid JSON array of file objects. No markdown, no explanations, no extra text.
+
+Each object must have these fields:
+- "filename": string (the file path/name)
+- "content": string (the complete file content)
+
+Example response format:
+[{"filename": "test.txt", "content": "hello world"}]
+
+For multiple files:
+[{"filename": "index.html", "content": "<!DOCTYPE html>..."}, {"filename": "style.css", "content": "body {...}"}]
+
+To delete a file the task requires removing, or one you created earlier that is no longer needed, use an object with "delete": true instead of content:
+[{"filename": "old.js", "delete": true}]
+Only delete files when the task calls for it; the user is asked to approve every deletion.
+
+Output ONLY the JSON array. Any other text will cause failure.`
+
 // AgentMode provides autonomous task execution assistance
 type AgentMode struct{}
 
@@ -67,10 +87,27 @@ func (m *AgentMode) ProcessInput(client *ollama.Client, sess *session.Session, c
 	modelName := cfg.GetModelForMode("agent")
 	var responseText string
 
-	enhancedInput := ReadFilesFromInputWithRoot(input, sess.ProjectRoot)
+	enhancedInput := ReadInputContext(client, input, sess, cfg.Context)
+	enhancedInput += RetrieveContext(client, sess, cfg, input, enhancedInput)
 	sess.AddMessage("user", input)
+	defer LimitLength(client, cfg, ModeAgent)()
 	conversationContext := BuildConversationContext(sess, enhancedInput)
 	
+	// Let the model gather information with the built-in and MCP tools before it answers.
+	// Lines the user types meanwhile steer the rest of the run.
+	steer := newSteering()
+	if tools := append(builtinTools(sess, cfg), ConnectMCPServers(cfg)...); len(tools) > 0 {
+		if steer.enabled {
+			fmt.Println("\033[38;5;240m(Type a message and press Enter to steer the agent while it works, or /stop to have it answer now)\033[0m")
+		}
+		conversationContext += runToolLoop(client, cfg, sess.ProjectRoot, modelName, conversationContext, tools, steer)
+	}
+	steer.poll()
+	if len(steer.notes) > 0 {
+		conversationContext += steer.prompt()
+		sess.AddMessage("user", "While you were working: "+strings.Join(steer.notes, "; "))
+	}
+	
 	// Detect if this is a file creation request
 	lowerInput := strings.ToLower(input)
 	needsFileCreation := strings.Contains(lowerInput, "create") && 
@@ -81,84 +118,37 @@ func (m *AgentMode) ProcessInput(client *ollama.Client, sess *session.Session, c
 		 strings.Contains(lowerInput, "python") ||
 		 strings.Contains(lowerInput, "javascript"))
 	
-	if client.Debug {
-		fmt.Printf("\n[DEBUG] File creation detection: %v (input: %s)\n", needsFileCreation, input)
-	}
+	slog.Debug("file creation detection", "needs_file_creation", needsFileCreation, "input_chars", len(input))
 	
 	if needsFileCreation {
 		// Use JSON mode for guaranteed file creation
 		fmt.Print(lipgloss.NewStyle().Foreground(lipgloss.Color("blue")).Render("\nAgent: "))
 		fmt.Println("Creating files...")
 		
-		jsonSystemPrompt := `You MUST respond with ONLY a valid JSON array of file objects. No markdown, no explanations, no extra text.
-
-Each object must have exactly these fields:
-- "filename": string (the file path/name)
-- "content": string (the complete file content)
-
-Example response format:
-[{"filename": "test.txt", "content": "hello world"}]
-
-For multiple files:
-[{"filename": "index.html", "content": "<!DOCTYPE html>..."}, {"filename": "style.css", "content": "body {...}"}]
-
-Output ONLY the JSON array. Any other text will cause failure.`
-		
-		jsonResponse, err := client.GenerateJSON(modelName, conversationContext, jsonSystemPrompt, 0.3)
+		var err error
+		responseText, err = streamGeneratedFiles(client, cfg, sess, modelName, conversationContext, generatedFilesPrompt, 0.3, input)
 		if err != nil {
-			return fmt.Errorf("error generating JSON: %w", err)
-		}
-
-		files, err := ParseGeneratedFilesJSON(jsonResponse)
-		if err != nil {
-			return fmt.Errorf("error parsing JSON response: %w\nResponse was: %s", err, jsonResponse)
-		}
-		
-		if client.Debug {
-			fmt.Printf("[DEBUG] Parsed %d files from JSON response\n", len(files))
+			return err
 		}
 		
-		// Create files
-		for _, file := range files {
-			absPath, relPath, err := safeio.ResolveWithinRoot(sess.ProjectRoot, file.Filename)
-			if err != nil {
-				fmt.Printf("\033[38;5;9mRefusing to write '%s': %v\033[0m\n", file.Filename, err)
-				continue
-			}
-			backup, err := safeio.WriteFileWithBackup(absPath, []byte(file.Content))
-			if err != nil {
-				fmt.Printf("\033[38;5;9mError writing file %s: %v\033[0m\n", relPath, err)
-				continue
-			}
-			if backup != "" {
-				fmt.Printf("\033[1;32m✓ Wrote: %s\033[0m (%d bytes)\n\033[38;5;240m  Backup saved: %s\033[0m\n", relPath, len(file.Content), backup)
-			} else {
-				fmt.Printf("\033[1;32m✓ Wrote: %s\033[0m (%d bytes)\n", relPath, len(file.Content))
-			}
-		}
-		fmt.Println()
-		
-		responseText = fmt.Sprintf("Created %d file(s) successfully", len(files))
-		
 	} else {
 		// Normal streaming response for non-file-creation tasks
 		// Start spinner
-		s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-		s.Suffix = " Thinking..."
+		s := NewModelStatus(ModeAgent, modelName)
 		s.Start()
 		
-		var fullResponse strings.Builder
+		md := NewResponseDisplay(cfg, ModeAgent, func() {
+			s.Stop()
+			fmt.Print(lipgloss.NewStyle().Foreground(lipgloss.Color("blue")).Render("\nAgent: "))
+		})
 		err := client.GenerateWithModel(
 			modelName,
 			conversationContext,
-			m.GetSystemPrompt(),
+			ProjectSystemPrompt(ModeAgent, m.GetSystemPrompt(), sess.ProjectRoot, cfg.Context)+InfraPrompt(sess.ProjectRoot, cfg.Context),
 			cfg.Ollama.Temperature,
 			func(chunk string) error {
-				if s.Active() {
-					s.Stop()
-					fmt.Print(lipgloss.NewStyle().Foreground(lipgloss.Color("blue")).Render("\nAgent: "))
-				}
-				fullResponse.WriteString(chunk)
+				s.Token()
+				md.Write(chunk)
 				return nil
 			},
 		)
@@ -171,10 +161,9 @@ Output ONLY the JSON array. Any other text will cause failure.`
 			return fmt.Errorf("error generating response: %w", err)
 		}
 		
-		// Render markdown
-		markdown := fullResponse.String()
-		renderedMd := renderer.RenderMarkdown(markdown)
-		fmt.Print(renderedMd)
+		// Render the rest of the markdown
+		markdown := md.String()
+		fmt.Print(md.Finish())
 		fmt.Println()
 		
 		responseText = markdown
@@ -220,9 +209,61 @@ func (m *AgentMode) Run(client *ollama.Client, sess *session.Session, cfg *confi
 		
 		// Process the input (handles file creation and normal responses)
 		if err := m.ProcessInput(client, sess, cfg, input); err != nil {
-			fmt.Printf("\nError: %v\n", err)
+			fmt.Println()
+			hint.Print(err)
+			continue
 		}
+		progress.Announce("Done")
 	}
 	
 	return nil
 }
+
+// writeGeneratedFiles writes files generated for input together, or not at all, and
+// returns a summary of what was written for the session history
+func writeGeneratedFiles(cfg *config.Config, sess *session.Session, files []GeneratedFile, input string) (string, error) {
+	backups, err := OpenBackupStore(cfg)
+	if err != nil {
+		return "", err
+	}
+	
+	// Stage all files so they are written together or not at all
+	tx := backups.Begin(sess.ProjectRoot)
+	for _, file := range files {
+		if err := tx.Stage(file.Filename, []byte(file.Content)); err != nil {
+			fmt.Printf("\033[38;5;9mRefusing to write '%s': %v\033[0m\n", file.Filename, err)
+		}
+	}
+	return applyGeneratedFiles(cfg, sess, tx, input)
+}
+
+// applyGeneratedFiles writes the staged files of tx and returns a summary of what was
+// written for the session history
+func applyGeneratedFiles(cfg *config.Config, sess *session.Session, tx *safeio.Transaction, input string) (string, error) {
+	written, err := applyTransaction(cfg, sess, tx, input)
+	if err != nil {
+		return "", fmt.Errorf("error writing files: %w", err)
+	}
+	fmt.Println()
+	
+	files, removed := 0, 0
+	for _, c := range tx.Changes() {
+		if c.Removed {
+			removed++
+		} else {
+			files++
+		}
+	}
+	switch {
+	case len(tx.Changes()) == 0:
+		return "No files were written", nil
+	case written && removed > 0:
+		return fmt.Sprintf("Created %d file(s) and deleted %d successfully", files, removed), nil
+	case written:
+		return fmt.Sprintf("Created %d file(s) successfully", files), nil
+	case removed > 0:
+		return fmt.Sprintf("Proposed %d file(s) and %d deletion(s) (not written)", files, removed), nil
+	default:
+		return fmt.Sprintf("Proposed %d file(s) (not written)", files), nil
+	}
+}
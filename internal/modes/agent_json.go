@@ -3,23 +3,37 @@ package modes
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/yourusername/llamasidekick/internal/hint"
 )
 
+// invalidJSONHint is the hint for a reply that isn't the JSON the model was asked for
+const invalidJSONHint = "models sometimes break the format; try again, or assign a model that follows instructions more closely with Configure Models"
+
+// invalidJSON returns err, about a reply that isn't the JSON asked for, with a hint
+func invalidJSON(err error) error {
+	return hint.Wrap(err, invalidJSONHint)
+}
+
 type GeneratedFile struct {
 	Filename string `json:"filename"`
 	Content  string `json:"content"`
+	Delete   bool   `json:"delete,omitempty"` // Remove the file instead of writing it
 }
 
 // ParseGeneratedFilesJSON parses either a JSON array of files or a single file object.
+// Line numbers the model copied from the loaded files are removed from the content.
 func ParseGeneratedFilesJSON(jsonResponse string) ([]GeneratedFile, error) {
 	var files []GeneratedFile
-	if err := json.Unmarshal([]byte(jsonResponse), &files); err == nil {
-		return files, nil
+	if err := json.Unmarshal([]byte(jsonResponse), &files); err != nil {
+		var single GeneratedFile
+		if err := json.Unmarshal([]byte(jsonResponse), &single); err != nil {
+			return nil, invalidJSON(fmt.Errorf("invalid JSON for generated files"))
+		}
+		files = []GeneratedFile{single}
 	}
-
-	var single GeneratedFile
-	if err := json.Unmarshal([]byte(jsonResponse), &single); err != nil {
-		return nil, fmt.Errorf("invalid JSON for generated files")
+	for i := range files {
+		files[i].Content = stripLineNumbers(files[i].Content)
 	}
-	return []GeneratedFile{single}, nil
+	return files, nil
 }
@@ -36,3 +36,13 @@ func TestParseGeneratedFilesJSON_Invalid(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+func TestParseGeneratedFilesJSON_Deletion(t *testing.T) {
+	files, err := ParseGeneratedFilesJSON(`[{"filename":"a.txt","content":"hello"},{"filename":"old.txt","delete":true}]`)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(files) != 2 || files[0].Delete || !files[1].Delete || files[1].Filename != "old.txt" {
+		t.Fatalf("unexpected files: %#v", files)
+	}
+}
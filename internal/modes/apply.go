@@ -0,0 +1,124 @@
+package modes
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/diff"
+	"github.com/yourusername/llamasidekick/internal/renderer"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/secrets"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// applyTransaction writes the staged changes in tx, reports each file and returns whether
+// anything was written.
+//
+// Secrets redacted from the prompt are first put back into the model's edits. In dry-run
+// or read-only mode only the combined diff is printed. Otherwise the session's approval
+// hook, if set, must approve the changes, and the pre_edit hooks must succeed. Agent runs
+// and changes to several files are checkpointed for /rollback before they are written.
+// Written files go through the formatters, linters and post_edit hooks. With
+// edits.auto_commit, or on an Agent mode task branch, they are committed with a message
+// generated from summary.
+func applyTransaction(cfg *config.Config, sess *session.Session, tx *safeio.Transaction, summary string) (bool, error) {
+	changes := tx.Changes()
+	if len(changes) == 0 {
+		return false, nil
+	}
+	if restoreRedactedSecrets(cfg, tx) {
+		changes = tx.Changes()
+	}
+
+	if cfg.Edits.DryRun || cfg.Edits.ReadOnly {
+		fmt.Println()
+		fmt.Print(renderer.RenderDiff(tx.Diff(), renderer.DiffOptions{
+			WordLevel:   cfg.UI.WordDiff,
+			LineNumbers: cfg.UI.LineNumbers,
+		}))
+		if cfg.Edits.ReadOnly {
+			fmt.Println("\033[38;5;214mRead-only mode - no files were written\033[0m")
+		} else {
+			fmt.Println("\033[38;5;214mDry run - no files were written (toggle with /dryrun)\033[0m")
+		}
+		return false, nil
+	}
+
+	if sess.ApproveChanges != nil && !sess.ApproveChanges(tx.Diff()) {
+		fmt.Println("\033[38;5;214mChanges rejected - no files were written\033[0m")
+		return false, nil
+	}
+
+	for _, c := range changes {
+		if err := runHooks(cfg, sess.ProjectRoot, "pre_edit", cfg.Hooks.PreEdit, map[string]string{"file": c.RelPath}); err != nil {
+			fmt.Printf("\033[38;5;9m%v - no files were written\033[0m\n", err)
+			return false, nil
+		}
+	}
+
+	onTaskBranch := useTaskBranch(cfg, sess, summary)
+	saveCheckpoint(sess, changes, summary)
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	for _, c := range changes {
+		if c.RenamedTo != "" {
+			// Reported with the new path
+			continue
+		}
+		added, removed := diff.Stat(string(c.Original), string(c.Content))
+		sess.RecordChange(session.FileChange{Path: c.RelPath, Action: c.Action(), Added: added, Removed: removed})
+		switch {
+		case c.RenamedFrom != "":
+			fmt.Printf("\033[1;32m✓ Renamed: %s → %s\033[0m (+%d -%d lines)\n", c.RenamedFrom, c.RelPath, added, removed)
+			fmt.Printf("\033[38;5;240m  Previous version backed up (undo with /restore %s)\033[0m\n", c.RenamedFrom)
+		case c.Removed:
+			fmt.Printf("\033[1;32m✓ Deleted: %s\033[0m (%d lines)\n", c.RelPath, removed)
+			fmt.Printf("\033[38;5;240m  Moved to the trash (undo with /restore %s or /trash restore)\033[0m\n", c.RelPath)
+		case c.Existed:
+			fmt.Printf("\033[1;32m✓ Modified: %s\033[0m (+%d -%d lines)\n", c.RelPath, added, removed)
+			fmt.Printf("\033[38;5;240m  Previous version backed up (undo with /restore %s)\033[0m\n", c.RelPath)
+		default:
+			executable := ""
+			if info, err := os.Stat(c.AbsPath); err == nil && info.Mode().Perm()&0111 != 0 {
+				executable = ", executable"
+			}
+			fmt.Printf("\033[1;32m✓ Created: %s\033[0m (%d bytes%s)\n", c.RelPath, len(c.Content), executable)
+		}
+	}
+	warnNewDependencies(sess.ProjectRoot, changes)
+	postProcess(cfg, sess, changes)
+	for _, c := range changes {
+		if err := runHooks(cfg, sess.ProjectRoot, "post_edit", cfg.Hooks.PostEdit, map[string]string{"file": c.RelPath}); err != nil {
+			fmt.Printf("\033[38;5;214mWarning: %v\033[0m\n", err)
+		}
+	}
+	if onTaskBranch || cfg.Edits.AutoCommit {
+		autoCommit(sess, changes, summary)
+	}
+	return true, nil
+}
+
+// restoreRedactedSecrets puts the secrets redacted from the files loaded into the prompt
+// back into the model's edits of those files, so an edit doesn't replace a real key with
+// its [REDACTED ...] marker. It returns whether any staged content changed.
+func restoreRedactedSecrets(cfg *config.Config, tx *safeio.Transaction) bool {
+	if cfg.Context.Secrets == "off" {
+		return false
+	}
+	restored := false
+	for _, c := range tx.Changes() {
+		if c.Original == nil || c.Removed {
+			continue
+		}
+		if content := secrets.Unredact(string(c.Original), string(c.Content)); content != string(c.Content) {
+			// Staging a path again keeps its original content
+			if err := tx.Stage(c.RelPath, []byte(content)); err == nil {
+				restored = true
+			}
+		}
+	}
+	return restored
+}
@@ -5,12 +5,11 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
 	"github.com/yourusername/llamasidekick/internal/ollama"
-	"github.com/yourusername/llamasidekick/internal/renderer"
+	"github.com/yourusername/llamasidekick/internal/progress"
 	"github.com/yourusername/llamasidekick/internal/session"
 )
 
@@ -60,7 +59,11 @@ func (m *AskMode) ProcessInput(client *ollama.Client, sess *session.Session, cfg
 	modelName := cfg.GetModelForMode("ask")
 
 	// Detect and read files mentioned in the input
-	enhancedInput := ReadFilesFromInputWithRoot(input, sess.ProjectRoot)
+	enhancedInput := ReadInputContext(client, input, sess, cfg.Context)
+	enhancedInput += RetrieveContext(client, sess, cfg, input, enhancedInput)
+	if cfg.Context.GitHistory {
+		enhancedInput += ReadGitHistory(input, sess.ProjectRoot, cfg.Context)
+	}
 
 	// Add user message to history
 	sess.AddMessage("user", input)
@@ -68,23 +71,24 @@ func (m *AskMode) ProcessInput(client *ollama.Client, sess *session.Session, cfg
 	// Build conversation context from session history
 	conversationContext := BuildConversationContext(sess, enhancedInput)
 
+	defer LimitLength(client, cfg, ModeAsk)()
+
 	// Start spinner
-	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-	s.Suffix = " Thinking..."
+	s := NewModelStatus(ModeAsk, modelName)
 	s.Start()
 
-	var fullResponse strings.Builder
+	md := NewResponseDisplay(cfg, ModeAsk, func() {
+		s.Stop()
+		fmt.Println()
+	})
 	err := client.GenerateWithModel(
 		modelName,
 		conversationContext,
-		m.GetSystemPrompt(),
+		ProjectSystemPrompt(ModeAsk, m.GetSystemPrompt(), sess.ProjectRoot, cfg.Context)+LengthPrompt(cfg, ModeAsk),
 		cfg.Ollama.Temperature,
 		func(chunk string) error {
-			if s.Active() {
-				s.Stop()
-				fmt.Println()
-			}
-			fullResponse.WriteString(chunk)
+			s.Token()
+			md.Write(chunk)
 			return nil
 		},
 	)
@@ -97,11 +101,10 @@ func (m *AskMode) ProcessInput(client *ollama.Client, sess *session.Session, cfg
 		return err
 	}
 
-	response := fullResponse.String()
+	response := md.String()
 
-	// Render the markdown response
-	rendered := renderer.RenderMarkdown(response)
-	fmt.Println(rendered)
+	// Render whatever is left of the markdown response
+	fmt.Println(md.Finish())
 
 	sess.AddMessage("assistant", response)
 	if err := sess.Save(); err != nil {
@@ -138,8 +141,10 @@ func (m *AskMode) Run(client *ollama.Client, sess *session.Session, cfg *config.
 		}
 
 		if err := m.ProcessInput(client, sess, cfg, input); err != nil {
-			fmt.Printf("\n\033[38;5;9mError: %v\033[0m\n", err)
+			fmt.Println()
+			hint.Print(err)
 			continue
 		}
+		progress.Announce("Done")
 	}
 }
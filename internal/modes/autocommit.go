@@ -0,0 +1,98 @@
+package modes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/diff"
+	"github.com/yourusername/llamasidekick/internal/gitutil"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// AutoCommitPrefix starts the subject of every commit made by edits.auto_commit
+const AutoCommitPrefix = "[llamasidekick]"
+
+// maxSubjectLength keeps generated commit subjects readable in git log --oneline
+const maxSubjectLength = 72
+
+// autoCommit commits the files written by a change, so every approved change can be
+// reverted on its own. Failures are only reported; the files stay written.
+func autoCommit(sess *session.Session, changes []safeio.Change, summary string) {
+	if _, err := gitutil.RepoRoot(sess.ProjectRoot); err != nil {
+		fmt.Println("\033[38;5;240m(Note: Not committing - the project is not a git repository)\033[0m")
+		return
+	}
+	paths := make([]string, len(changes))
+	for i, c := range changes {
+		paths[i] = c.RelPath
+	}
+	hash, err := gitutil.CommitPaths(sess.ProjectRoot, autoCommitMessage(changes, summary, lastUserMessage(sess)), paths)
+	if err != nil {
+		fmt.Printf("\033[38;5;214mWarning: failed to commit the change: %v\033[0m\n", err)
+		return
+	}
+	fmt.Printf("\033[38;5;240m  Committed as %s (undo with git revert %s)\033[0m\n", hash, hash)
+}
+
+// autoCommitMessage builds a commit message from the change summary, falling back to the
+// changed files when there is none, and lists the files and the request in the body
+func autoCommitMessage(changes []safeio.Change, summary, request string) string {
+	subject := firstLine(summary)
+	if subject == "" {
+		verb := "Update"
+		if len(changes) > 0 && !changes[0].Existed {
+			verb = "Create"
+		}
+		if len(changes) == 1 {
+			subject = fmt.Sprintf("%s %s", verb, changes[0].RelPath)
+		} else {
+			subject = fmt.Sprintf("%s %d files", verb, len(changes))
+		}
+	}
+	subject = AutoCommitPrefix + " " + subject
+	if runes := []rune(subject); len(runes) > maxSubjectLength {
+		subject = string(runes[:maxSubjectLength-3]) + "..."
+	}
+
+	var b strings.Builder
+	b.WriteString(subject + "\n\n")
+	for _, c := range changes {
+		if c.RenamedTo != "" {
+			continue
+		}
+		added, removed := diff.Stat(string(c.Original), string(c.Content))
+		path := c.RelPath
+		if c.RenamedFrom != "" {
+			path = c.RenamedFrom + " -> " + c.RelPath
+		}
+		fmt.Fprintf(&b, "- %s %s (+%d -%d)\n", c.Action(), path, added, removed)
+	}
+	if request = strings.TrimSpace(request); request != "" {
+		fmt.Fprintf(&b, "\nRequest: %s\n", request)
+	}
+	return b.String()
+}
+
+// lastUserMessage returns the most recent prompt in the session, or "" when it is private,
+// since the commit outlives the run
+func lastUserMessage(sess *session.Session) string {
+	for i := len(sess.History) - 1; i >= 0; i-- {
+		if msg := sess.History[i]; msg.Role == "user" {
+			if msg.Private {
+				return ""
+			}
+			return msg.Content
+		}
+	}
+	return ""
+}
+
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return strings.TrimSuffix(line, ".")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestAutoCommitMessage(t *testing.T) {
+	changes := []safeio.Change{
+		{RelPath: "a.go", Original: []byte("x\n"), Content: []byte("y\nz\n"), Existed: true},
+		{RelPath: "b.go", Content: []byte("new\n")},
+	}
+	msg := autoCommitMessage(changes, "Add input validation.\nmore detail", "validate the input")
+	want := "[llamasidekick] Add input validation\n\n- modified a.go (+2 -1)\n- created b.go (+1 -0)\n\nRequest: validate the input\n"
+	if msg != want {
+		t.Fatalf("unexpected message:\n%q\nwant:\n%q", msg, want)
+	}
+
+	if msg := autoCommitMessage(changes[1:], "", ""); !strings.HasPrefix(msg, "[llamasidekick] Create b.go\n") {
+		t.Fatalf("expected subject from the changed file, got %q", msg)
+	}
+	if subject := firstLine(autoCommitMessage(changes, strings.Repeat("long ", 30), "")); len(subject) > maxSubjectLength {
+		t.Fatalf("subject not shortened: %q", subject)
+	}
+}
+
+func TestLastUserMessage_SkipsPrivatePrompts(t *testing.T) {
+	sess := session.New(t.TempDir())
+	sess.AddMessage("user", "validate the input")
+	if got := lastUserMessage(sess); got != "validate the input" {
+		t.Fatalf("expected the last prompt, got %q", got)
+	}
+	sess.Private = true
+	sess.AddMessage("user", "use the token ghp_secret")
+	sess.AddMessage("assistant", "Done.")
+	if got := lastUserMessage(sess); got != "" {
+		t.Fatalf("expected a private prompt to stay out of the commit, got %q", got)
+	}
+}
+
+func TestAutoCommitKeepsOtherStagedChanges(t *testing.T) {
+	root, git := newTestRepo(t, "main")
+	for name, content := range map[string]string{"a.txt": "a\n", "other.txt": "o\n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+
+	if err := os.WriteFile(filepath.Join(root, "other.txt"), []byte("staged\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	git("add", "other.txt")
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("changed\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	sess := session.New(root)
+	sess.AddMessage("user", "change a")
+	autoCommit(sess, []safeio.Change{{RelPath: "a.txt", Original: []byte("a\n"), Content: []byte("changed\n"), Existed: true}}, "Change a")
+
+	if log := git("log", "-1", "--format=%s"); log != "[llamasidekick] Change a\n" {
+		t.Fatalf("unexpected last commit %q", log)
+	}
+	if files := git("show", "--name-only", "--format=", "HEAD"); files != "a.txt\n" {
+		t.Fatalf("commit should only contain a.txt, got %q", files)
+	}
+	if staged := git("diff", "--cached", "--name-only"); staged != "other.txt\n" {
+		t.Fatalf("other staged changes should stay staged, got %q", staged)
+	}
+}
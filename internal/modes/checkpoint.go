@@ -0,0 +1,114 @@
+package modes
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/diff"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// maxCheckpointLabel bounds the summary a checkpoint is labelled with
+const maxCheckpointLabel = 60
+
+// saveCheckpoint takes a checkpoint of the conversation and of the files changes are
+// about to replace, when they are an Agent run's or touch several files, so /rollback
+// can return to it
+func saveCheckpoint(sess *session.Session, changes []safeio.Change, summary string) {
+	if sess.Mode != ModeAgent && len(changes) < 2 {
+		return
+	}
+	var files []session.CheckpointFile
+	for _, c := range changes {
+		if c.Existed && !c.Removed && c.Original == nil {
+			// A special file has no content to go back to
+			continue
+		}
+		files = append(files, session.CheckpointFile{Path: c.RelPath, Existed: c.Existed, Content: c.Original})
+	}
+	if len([]rune(summary)) > maxCheckpointLabel {
+		summary = string([]rune(summary)[:maxCheckpointLabel]) + "..."
+	}
+	cp, err := sess.SaveCheckpoint(fmt.Sprintf("%s: %s", sess.Mode, summary), files)
+	if err != nil {
+		fmt.Printf("\033[38;5;214mWarning: failed to save a checkpoint: %v\033[0m\n", err)
+		return
+	}
+	fmt.Printf("\033[38;5;240mCheckpoint %d saved (go back to it with /rollback)\033[0m\n", cp.ID)
+}
+
+// PrepareRollback stages the writes that return the files of checkpoint id, and of every
+// later checkpoint, to how they were when it was taken. The transaction backs up the
+// current files when it is committed, so a rollback can be undone with /restore.
+func PrepareRollback(cfg *config.Config, sess *session.Session, id int) (session.Checkpoint, *safeio.Transaction, error) {
+	if cfg.Edits.ReadOnly {
+		return session.Checkpoint{}, nil, fmt.Errorf("read-only mode: rolling back is disabled")
+	}
+	checkpoints, err := sess.Checkpoints()
+	if err != nil {
+		return session.Checkpoint{}, nil, err
+	}
+	start := -1
+	for i, cp := range checkpoints {
+		if cp.ID == id {
+			start = i
+		}
+	}
+	if start < 0 {
+		return session.Checkpoint{}, nil, fmt.Errorf("there is no checkpoint %d (list them with /rollback list)", id)
+	}
+
+	backups, err := OpenBackupStore(cfg)
+	if err != nil {
+		return session.Checkpoint{}, nil, err
+	}
+	tx := backups.Begin(sess.ProjectRoot)
+	seen := map[string]bool{}
+	for _, cp := range checkpoints[start:] {
+		for _, f := range cp.Files {
+			// The earliest checkpoint of a file has its state before all of them
+			if seen[f.Path] {
+				continue
+			}
+			seen[f.Path] = true
+			current, err := os.ReadFile(filepath.Join(sess.ProjectRoot, f.Path))
+			if err == nil {
+				// The rollback replaces whatever is there now
+				promptReads.Record(filepath.Join(sess.ProjectRoot, f.Path), current)
+			}
+			switch {
+			case f.Existed && (err != nil || !bytes.Equal(current, f.Content)):
+				err = tx.Stage(f.Path, f.Content)
+			case !f.Existed && err == nil:
+				err = tx.StageRemove(f.Path)
+			default:
+				continue
+			}
+			if err != nil {
+				return session.Checkpoint{}, nil, fmt.Errorf("failed to roll back %s: %w", f.Path, err)
+			}
+		}
+	}
+	return checkpoints[start], tx, nil
+}
+
+// FinishRollback writes the files staged by PrepareRollback, returns the conversation to
+// the checkpoint and drops the checkpoint and the later ones
+func FinishRollback(sess *session.Session, cp session.Checkpoint, tx *safeio.Transaction) error {
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, c := range tx.Changes() {
+		added, removed := diff.Stat(string(c.Original), string(c.Content))
+		sess.RecordChange(session.FileChange{Path: c.RelPath, Action: c.Action(), Added: added, Removed: removed})
+	}
+	sess.History = append([]session.Message(nil), cp.History...)
+	if err := sess.Save(); err != nil {
+		fmt.Printf("Warning: failed to save session: %v\n", err)
+	}
+	return sess.DropCheckpoints(cp.ID)
+}
@@ -0,0 +1,79 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestRollback(t *testing.T) {
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", t.TempDir())
+	root := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			return "<missing>"
+		}
+		return string(data)
+	}
+	write("a.go", "package a\n")
+
+	cfg := &config.Config{Backups: config.BackupsConfig{Keep: 5}}
+	sess := session.New(root)
+	sess.SetMode(ModeAgent)
+	sess.AddMessage("user", "first")
+	sess.AddMessage("assistant", "done")
+	sess.AddMessage("user", "add b")
+
+	store, err := OpenBackupStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := store.Begin(root)
+	tx.Stage("a.go", []byte("package a // changed\n"))
+	tx.Stage("b.go", []byte("package b\n"))
+	if _, err := applyTransaction(cfg, sess, tx, "add b"); err != nil {
+		t.Fatal(err)
+	}
+	// A later checkpoint that changed a.go again
+	tx = store.Begin(root)
+	tx.Stage("a.go", []byte("package a // again\n"))
+	if _, err := applyTransaction(cfg, sess, tx, "change a"); err != nil {
+		t.Fatal(err)
+	}
+	checkpoints, err := sess.Checkpoints()
+	if err != nil || len(checkpoints) != 2 {
+		t.Fatalf("expected 2 checkpoints, got %d (%v)", len(checkpoints), err)
+	}
+
+	if _, _, err := PrepareRollback(&config.Config{Edits: config.EditsConfig{ReadOnly: true}}, sess, 1); err == nil {
+		t.Fatal("expected read-only mode to refuse rolling back")
+	}
+	cp, tx, err := PrepareRollback(cfg, sess, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := FinishRollback(sess, cp, tx); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("a.go"); got != "package a\n" {
+		t.Errorf("a.go = %q, want its content before the first checkpoint", got)
+	}
+	if got := read("b.go"); got != "<missing>" {
+		t.Errorf("b.go should be removed, got %q", got)
+	}
+	if len(sess.History) != 2 || sess.History[1].Content != "done" {
+		t.Errorf("unexpected history after rolling back: %+v", sess.History)
+	}
+	if checkpoints, _ := sess.Checkpoints(); len(checkpoints) != 0 {
+		t.Errorf("expected the checkpoints to be dropped, got %d", len(checkpoints))
+	}
+}
@@ -3,28 +3,25 @@ package modes
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"runtime"
 	"strings"
-	"time"
 
-	"github.com/atotto/clipboard"
-	"github.com/briandowns/spinner"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
 	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/progress"
 	"github.com/yourusername/llamasidekick/internal/session"
+	"golang.org/x/term"
 )
 
 var cmdStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.Color("yellow")).
 	Bold(true)
 
-var copiedStyle = lipgloss.NewStyle().
-	Foreground(lipgloss.Color("green")).
-	Bold(true)
-
 // CmdMode helps generate commands without executing them
 type CmdMode struct{}
 
@@ -37,13 +34,10 @@ func (m *CmdMode) Description() string {
 }
 
 func (m *CmdMode) GetSystemPrompt() string {
-	osType := "Linux/Unix"
-	shellType := "bash"
+	osType, shellType := cmdPlatform()
 	exampleCmd := "df -h"
 	
 	if runtime.GOOS == "windows" {
-		osType = "Windows"
-		shellType = "PowerShell"
 		exampleCmd = "Get-PSDrive -PSProvider FileSystem | Select-Object Name, Used, Free"
 	}
 	
@@ -63,34 +57,54 @@ func (m *CmdMode) GetSystemPrompt() string {
 		"Output the command only.", osType, shellType, osType, osType, exampleCmd)
 }
 
-// ProcessInput handles a single cmd request.
+// ProcessInput handles a single cmd request. Input starting with --with-output reads the
+// output of a command the user ran and asks what it means and what to run next.
 func (m *CmdMode) ProcessInput(client *ollama.Client, sess *session.Session, cfg *config.Config, input string) error {
 	sess.SetMode(ModeCmd)
 	modelName := cfg.GetModelForMode("cmd")
 
-	enhancedInput := ReadFilesFromInputWithRoot(input, sess.ProjectRoot)
+	systemPrompt := m.GetSystemPrompt()
+	question, withOutput := CutWithOutputFlag(input)
+	output := ""
+	if withOutput {
+		var err error
+		if output, err = readCommandOutput(os.Stdin, term.IsTerminal(int(os.Stdin.Fd()))); err != nil {
+			return err
+		}
+		if len(output) > maxCommandOutput {
+			fmt.Printf("\033[38;5;240m(Note: Kept the last %d of %d bytes of the output)\033[0m\n", maxCommandOutput, len(output))
+			output = "...\n" + strings.ToValidUTF8(output[len(output)-maxCommandOutput:], "")
+		}
+		input = question
+		systemPrompt = commandOutputSystemPrompt()
+	}
+
+	enhancedInput := ReadInputContext(client, input, sess, cfg.Context)
+	if withOutput {
+		input, enhancedInput = commandOutputMessage(input, output), commandOutputMessage(enhancedInput, output)
+	}
 	sess.AddMessage("user", input)
 
 	conversationContext := BuildConversationContext(sess, enhancedInput)
 
+	defer LimitLength(client, cfg, ModeCmd)()
+
 	// Start spinner
-	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-	s.Suffix = " Generating command..."
+	s := NewModelStatus(ModeCmd, modelName)
 	s.Start()
 
-	var fullResponse strings.Builder
+	display := newCommandDisplay(cfg, ModeCmd, func() {
+		s.Stop()
+		fmt.Print(lipgloss.NewStyle().Foreground(lipgloss.Color("yellow")).Render("\nCommands:\n"))
+	})
 	err := client.GenerateWithModel(
 		modelName,
 		conversationContext,
-		m.GetSystemPrompt(),
+		ProjectSystemPrompt(ModeCmd, systemPrompt, sess.ProjectRoot, cfg.Context)+LengthPrompt(cfg, ModeCmd)+InfraPrompt(sess.ProjectRoot, cfg.Context)+ShellHistoryPrompt(cfg.Cmd),
 		cfg.Ollama.Temperature,
 		func(chunk string) error {
-			if s.Active() {
-				s.Stop()
-				fmt.Print(lipgloss.NewStyle().Foreground(lipgloss.Color("yellow")).Render("\nCommands:\n"))
-			}
-			fmt.Print(responseStyle.Render(chunk))
-			fullResponse.WriteString(chunk)
+			s.Token()
+			display.Write(chunk)
 			return nil
 		},
 	)
@@ -102,18 +116,11 @@ func (m *CmdMode) ProcessInput(client *ollama.Client, sess *session.Session, cfg
 		return fmt.Errorf("error generating response: %w", err)
 	}
 
+	response := display.String()
+	fmt.Print(display.Finish())
 	fmt.Println()
 
-	response := fullResponse.String()
-	commands := extractCommands(response)
-	if len(commands) > 0 {
-		cmdToCopy := strings.Join(commands, "\n")
-		if err := clipboard.WriteAll(cmdToCopy); err != nil {
-			fmt.Printf("Warning: failed to copy to clipboard: %v\n", err)
-		} else {
-			fmt.Println(copiedStyle.Render("✓ Command(s) copied to clipboard - ready to paste!"))
-		}
-	}
+	CopyCommands(response)
 
 	fmt.Println()
 
@@ -130,6 +137,7 @@ func (m *CmdMode) Run(client *ollama.Client, sess *session.Session, cfg *config.
 	
 	fmt.Println(lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("yellow")).Render("\n=== CMD MODE ==="))
 	fmt.Println("Get command help - commands are copied to clipboard, NEVER executed.")
+	fmt.Println("Start with --with-output to paste the output of a command you ran and get the next step.")
 	fmt.Println("Type 'exit' to return to main menu.")
 	fmt.Println()
 	
@@ -153,16 +161,98 @@ func (m *CmdMode) Run(client *ollama.Client, sess *session.Session, cfg *config.
 		}
 		
 		if err := m.ProcessInput(client, sess, cfg, input); err != nil {
-			fmt.Printf("\nError: %v\n", err)
+			fmt.Println()
+			hint.Print(err)
 			continue
 		}
+		progress.Announce("Done")
 	}
 	
 	return nil
 }
 
-// extractCommands extracts commands from code blocks in the response
-func extractCommands(response string) []string {
+// WithOutputFlag starts a CMD prompt that comes with the output of a command the user ran
+const WithOutputFlag = "--with-output"
+
+// maxCommandOutput is how much of a command's output --with-output sends; the end is kept,
+// since that's where errors usually are
+const maxCommandOutput = 8000
+
+// CutWithOutputFlag returns input without a leading --with-output flag, and whether it had one
+func CutWithOutputFlag(input string) (string, bool) {
+	rest, ok := strings.CutPrefix(input, WithOutputFlag)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\n') {
+		return input, false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// cmdPlatform returns the operating system and shell CMD mode writes commands for
+func cmdPlatform() (string, string) {
+	if runtime.GOOS == "windows" {
+		return "Windows", "PowerShell"
+	}
+	return "Linux/Unix", "bash"
+}
+
+// commandOutputSystemPrompt is the system prompt for interpreting a command's output
+func commandOutputSystemPrompt() string {
+	osType, shellType := cmdPlatform()
+	return fmt.Sprintf("You are a command-line expert assistant helping the user troubleshoot step by step.\n\n"+
+		"USER'S OPERATING SYSTEM: %s\n"+
+		"SHELL: %s\n\n"+
+		"The user ran a command, usually the one you suggested last, and pasted its output. "+
+		"In one to three short sentences, say what the output means, answering the user's question if there is one. "+
+		"Then, if something is left to do, give the single next command to run in a ```%s code block. "+
+		"If nothing is left to do, say so and give no command.", osType, shellType, strings.ToLower(shellType))
+}
+
+// commandOutputMessage is the user message for a command's output and the question about it
+func commandOutputMessage(question, output string) string {
+	if question == "" {
+		question = "What does this mean, and what should I run next?"
+	}
+	return fmt.Sprintf("I ran the command. Its output:\n```\n%s\n```\n%s", strings.TrimRight(output, "\n"), question)
+}
+
+// readCommandOutput reads the output of a command: all of r when it is piped, or what the
+// user pastes into the terminal up to a line with just "." or Ctrl+D
+func readCommandOutput(r io.Reader, terminal bool) (string, error) {
+	if !terminal {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the command output: %w", err)
+		}
+		if strings.TrimSpace(string(data)) == "" {
+			return "", fmt.Errorf("no command output to read: pipe it in, e.g. `kubectl get pods 2>&1 | llamasidekick cmd %s`", WithOutputFlag)
+		}
+		return string(data), nil
+	}
+
+	fmt.Println("\033[38;5;240mPaste the command's output, then type . on a line of its own (or press Ctrl+D):\033[0m")
+	reader := bufio.NewReader(r)
+	var output strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if strings.TrimRight(line, "\r\n") == "." {
+			break
+		}
+		output.WriteString(line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read the command output: %w", err)
+		}
+	}
+	if strings.TrimSpace(output.String()) == "" {
+		return "", fmt.Errorf("no command output was pasted")
+	}
+	return output.String(), nil
+}
+
+// ExtractCommands extracts commands from code blocks in the response
+func ExtractCommands(response string) []string {
 	// Match code blocks with ```bash, ```powershell, ```sh, or just ```
 	re := regexp.MustCompile("```(?:bash|powershell|sh|shell)?\n([^`]+)```")
 	matches := re.FindAllStringSubmatch(response, -1)
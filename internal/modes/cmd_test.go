@@ -0,0 +1,105 @@
+package modes
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestCutWithOutputFlag(t *testing.T) {
+	cases := []struct {
+		input, want string
+		ok          bool
+	}{
+		{"--with-output", "", true},
+		{"--with-output why did it fail?", "why did it fail?", true},
+		{"--with-outputs", "--with-outputs", false},
+		{"list files --with-output", "list files --with-output", false},
+	}
+	for _, c := range cases {
+		if got, ok := CutWithOutputFlag(c.input); got != c.want || ok != c.ok {
+			t.Errorf("CutWithOutputFlag(%q) = %q, %v, want %q, %v", c.input, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestReadCommandOutput(t *testing.T) {
+	got, err := readCommandOutput(strings.NewReader("pod-a Running\npod-b CrashLoopBackOff\n.\nignored\n"), true)
+	if err != nil || got != "pod-a Running\npod-b CrashLoopBackOff\n" {
+		t.Fatalf("expected the pasted lines up to the dot, got %q, %v", got, err)
+	}
+	if got, err := readCommandOutput(strings.NewReader("no trailing dot"), true); err != nil || got != "no trailing dot" {
+		t.Fatalf("expected everything up to EOF, got %q, %v", got, err)
+	}
+	if got, err := readCommandOutput(strings.NewReader("a\n.\nb\n"), false); err != nil || got != "a\n.\nb\n" {
+		t.Fatalf("expected piped output to be read whole, got %q, %v", got, err)
+	}
+	if _, err := readCommandOutput(strings.NewReader(".\n"), true); err == nil {
+		t.Fatal("expected an error for empty output")
+	}
+}
+
+func TestCmdMode_WithOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.txt")
+	if err := os.WriteFile(path, []byte("Error: port 8080 already in use\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin }()
+
+	var req ollama.GenerateRequest
+	stop := errors.New("stop")
+	client := ollama.NewClient("http://127.0.0.1:1", "default")
+	client.Intercept = func(r ollama.GenerateRequest) error {
+		req = r
+		return stop
+	}
+	sess := session.New(t.TempDir())
+	sess.AddMessage("user", "start the server")
+	sess.AddMessage("assistant", "npm start")
+
+	if err := (&CmdMode{}).ProcessInput(client, sess, &config.Config{}, "--with-output what now?"); !errors.Is(err, stop) {
+		t.Fatalf("expected the intercepted request, got %v", err)
+	}
+	if !strings.Contains(req.Prompt, "Assistant: npm start") || !strings.Contains(req.Prompt, "port 8080 already in use") || !strings.HasSuffix(strings.TrimSpace(req.Prompt), "what now?") {
+		t.Fatalf("expected the conversation, the output and the question in the prompt, got %q", req.Prompt)
+	}
+	if !strings.Contains(req.System, "pasted its output") {
+		t.Fatalf("expected the output system prompt, got %q", req.System)
+	}
+	if last := sess.History[len(sess.History)-1]; !strings.Contains(last.Content, "port 8080") {
+		t.Fatalf("expected the output in the conversation, got %q", last.Content)
+	}
+}
+
+func TestShellHistoryPrompt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	history := strings.Repeat("eza -la\nls\neza\nmysql -psecret\n", 3)
+	if err := os.WriteFile(path, []byte(history), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.CmdConfig{HistoryFile: path, HistoryLines: 1000}
+	if got := ShellHistoryPrompt(cfg); got != "" {
+		t.Fatalf("expected nothing without consent, got %q", got)
+	}
+	cfg.ShellHistory = true
+	got := ShellHistoryPrompt(cfg)
+	if !strings.Contains(got, "eza rather than") || !strings.Contains(got, "mysql") {
+		t.Fatalf("expected the preferred and frequent tools, got %q", got)
+	}
+	if strings.Contains(got, "secret") || strings.Contains(got, "-la") {
+		t.Fatalf("expected no arguments from the history, got %q", got)
+	}
+}
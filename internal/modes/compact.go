@@ -0,0 +1,86 @@
+package modes
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// compactSummaryPrefix starts the message a compacted conversation is replaced with
+const compactSummaryPrefix = "Summary of the conversation so far:\n\n"
+
+const compactSystemPrompt = `You condense conversations between a developer and a coding assistant into a brief the assistant can continue the conversation from.
+Summarize the goal, the decisions made and why, the files, functions and commands involved, code that was settled on, and what is still open. Leave out greetings, abandoned approaches and anything repeated.
+Reply with the summary only, as concise markdown bullet points.`
+
+// Compaction describes what CompactConversation did. Tokens are estimates.
+type Compaction struct {
+	Summarized   int // Messages replaced by the summary
+	Kept         int // Pinned and private messages kept as they were
+	TokensBefore int
+	TokensAfter  int
+}
+
+// CompactConversation asks the Ask model to summarize the conversation in sess and
+// replaces the history with the summary, followed by the pinned messages. Private
+// messages are kept as they are too, so their contents never end up in a summary that
+// is saved. onChunk, if set, receives the summary as it streams.
+func CompactConversation(client *ollama.Client, cfg *config.Config, sess *session.Session, onChunk func(string)) (Compaction, error) {
+	var summarized, kept []session.Message
+	for _, msg := range sess.History {
+		if msg.Pinned || msg.Private {
+			kept = append(kept, msg)
+		} else {
+			summarized = append(summarized, msg)
+		}
+	}
+	if len(summarized) < 2 {
+		return Compaction{}, fmt.Errorf("nothing to compact: the conversation has fewer than two messages that aren't pinned")
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Summarize this conversation:\n\n")
+	for _, msg := range summarized {
+		role := "User"
+		if msg.Role == "assistant" {
+			role = "Assistant"
+		}
+		fmt.Fprintf(&prompt, "%s: %s\n\n", role, msg.Content)
+	}
+
+	var response strings.Builder
+	err := client.GenerateWithModel(cfg.GetModelForMode(ModeAsk), prompt.String(), compactSystemPrompt, 0.2, func(chunk string) error {
+		response.WriteString(chunk)
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+		return nil
+	})
+	if err != nil {
+		return Compaction{}, fmt.Errorf("failed to summarize the conversation: %w", err)
+	}
+	summary := strings.TrimSpace(response.String())
+	if summary == "" {
+		return Compaction{}, fmt.Errorf("failed to summarize the conversation: the model's reply was empty")
+	}
+
+	result := Compaction{Summarized: len(summarized), Kept: len(kept), TokensBefore: historyTokens(sess.History)}
+	history := []session.Message{{Role: "assistant", Content: compactSummaryPrefix + summary, Timestamp: summarized[len(summarized)-1].Timestamp}}
+	sess.History = append(history, kept...)
+	sess.UpdatedAt = time.Now()
+	result.TokensAfter = historyTokens(sess.History)
+	return result, nil
+}
+
+// historyTokens estimates the tokens the messages take up in a prompt
+func historyTokens(history []session.Message) int {
+	tokens := 0
+	for _, msg := range history {
+		tokens += EstimateTokens(msg.Content)
+	}
+	return tokens
+}
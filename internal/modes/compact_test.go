@@ -0,0 +1,63 @@
+package modes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestCompactConversation(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollama.GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Prompt
+		w.Write([]byte("{\"response\": \"- Fixing the reload race\"}\n{\"done\": true}\n"))
+	}))
+	defer server.Close()
+
+	sess := session.New(t.TempDir())
+	sess.AddMessage("user", "why does reloading the config race?")
+	sess.AddMessage("assistant", strings.Repeat("Because both goroutines write cfg. ", 20))
+	sess.AddMessage("user", "always use the mutex in config.go")
+	sess.AddMessage("assistant", "Will do.")
+	sess.PinLastExchange()
+	sess.Private = true
+	sess.AddMessage("user", "my token is hunter2")
+	sess.Private = false
+	sess.AddMessage("user", "and the watcher?")
+
+	result, err := CompactConversation(ollama.NewClient(server.URL, "default"), &config.Config{}, sess, nil)
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if strings.Contains(prompt, "hunter2") || strings.Contains(prompt, "mutex") || !strings.Contains(prompt, "User: and the watcher?") {
+		t.Fatalf("expected only the unpinned public messages summarized, got %q", prompt)
+	}
+	if result.Summarized != 3 || result.Kept != 3 || result.TokensAfter >= result.TokensBefore {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(sess.History) != 4 || sess.History[0].Content != compactSummaryPrefix+"- Fixing the reload race" {
+		t.Fatalf("expected the summary followed by the kept messages, got %+v", sess.History)
+	}
+	if !sess.History[1].Pinned || sess.History[2].Content != "Will do." || !sess.History[3].Private {
+		t.Fatalf("expected the pinned and private messages kept in order, got %+v", sess.History)
+	}
+}
+
+func TestCompactConversation_TooShort(t *testing.T) {
+	sess := session.New(t.TempDir())
+	sess.AddMessage("user", "hello")
+	if _, err := CompactConversation(ollama.NewClient("http://127.0.0.1:0", "default"), &config.Config{}, sess, nil); err == nil {
+		t.Fatal("expected an error for a conversation with one message")
+	}
+	if len(sess.History) != 1 {
+		t.Fatalf("expected the history unchanged, got %+v", sess.History)
+	}
+}
@@ -0,0 +1,94 @@
+package modes
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// maxCompareModels bounds how many models one /compare asks at once
+const maxCompareModels = 4
+
+// Comparison is one model's answer to a compared question
+type Comparison struct {
+	Model    string
+	Response string
+	Duration time.Duration
+	Stats    ollama.TokenStats
+	Err      error
+}
+
+// ParseCompareModels splits a comma-separated model list, dropping blanks and duplicates
+func ParseCompareModels(list string) ([]string, error) {
+	seen := map[string]bool{}
+	var models []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			models = append(models, name)
+		}
+	}
+	if len(models) < 2 {
+		return nil, fmt.Errorf("name at least two models to compare, e.g. /compare llama3,qwen2.5-coder <question>")
+	}
+	if len(models) > maxCompareModels {
+		return nil, fmt.Errorf("at most %d models can be compared at once", maxCompareModels)
+	}
+	return models, nil
+}
+
+// CompareModels asks every model the same question concurrently, with the context Ask mode
+// would give it, and returns their answers in the order of models. The question and the
+// labelled answers are added to the conversation so a follow-up can refer to them.
+func CompareModels(client *ollama.Client, sess *session.Session, cfg *config.Config, models []string, question string) []Comparison {
+	enhancedInput := ReadInputContext(client, question, sess, cfg.Context)
+	enhancedInput += RetrieveContext(client, sess, cfg, question, enhancedInput)
+	sess.AddMessage("user", question)
+	prompt := BuildConversationContext(sess, enhancedInput)
+	systemPrompt := ProjectSystemPrompt(ModeAsk, (&AskMode{}).GetSystemPrompt(), sess.ProjectRoot, cfg.Context) + LengthPrompt(cfg, ModeAsk)
+	defer LimitLength(client, cfg, ModeAsk)()
+
+	results := make([]Comparison, len(models))
+	var wg sync.WaitGroup
+	for i, model := range models {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each request gets its own copy of the client, whose token counts aren't safe
+			// to update concurrently
+			c := *client
+			c.Stats, c.OnChunk = ollama.TokenStats{}, nil
+			var response strings.Builder
+			start := time.Now()
+			err := c.GenerateWithModel(model, prompt, systemPrompt, cfg.Ollama.Temperature, func(chunk string) error {
+				response.WriteString(chunk)
+				return nil
+			})
+			results[i] = Comparison{Model: model, Response: response.String(), Duration: time.Since(start), Stats: c.Stats, Err: err}
+		}()
+	}
+	wg.Wait()
+
+	var summary strings.Builder
+	for _, r := range results {
+		client.Stats.Requests += r.Stats.Requests
+		client.Stats.PromptTokens += r.Stats.PromptTokens
+		client.Stats.ResponseTokens += r.Stats.ResponseTokens
+		answer := r.Response
+		if r.Err != nil {
+			answer = "Error: " + r.Err.Error()
+		}
+		fmt.Fprintf(&summary, "Answer from %s:\n%s\n\n", r.Model, strings.TrimSpace(answer))
+	}
+	sess.AddMessage("assistant", strings.TrimSpace(summary.String()))
+	if err := sess.Save(); err != nil {
+		fmt.Printf("Warning: failed to save session: %v\n", err)
+	}
+	return results
+}
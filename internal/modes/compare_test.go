@@ -0,0 +1,60 @@
+package modes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestParseCompareModels(t *testing.T) {
+	models, err := ParseCompareModels(" llama3, qwen ,llama3,")
+	if err != nil || strings.Join(models, "|") != "llama3|qwen" {
+		t.Fatalf("unexpected models %v, %v", models, err)
+	}
+	if _, err := ParseCompareModels("llama3"); err == nil {
+		t.Fatal("expected an error for a single model")
+	}
+	if _, err := ParseCompareModels("a,b,c,d,e"); err == nil {
+		t.Fatal("expected an error for too many models")
+	}
+}
+
+func TestCompareModels(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollama.GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model == "broken" {
+			http.Error(w, `{"error": "model not found"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "{\"response\": \"%s says hi\"}\n{\"done\": true, \"prompt_eval_count\": 10, \"eval_count\": 3}\n", req.Model)
+	}))
+	defer server.Close()
+
+	client := ollama.NewClient(server.URL, "default")
+	sess := session.New(t.TempDir())
+	cfg := &config.Config{Context: config.DefaultContextConfig()}
+	results := CompareModels(client, sess, cfg, []string{"llama3", "broken", "qwen"}, "what is a goroutine?")
+
+	if len(results) != 3 || results[0].Response != "llama3 says hi" || results[2].Response != "qwen says hi" {
+		t.Fatalf("expected the answers in model order, got %+v", results)
+	}
+	if results[1].Err == nil {
+		t.Fatal("expected an error for the broken model")
+	}
+	if client.Stats.Requests != 2 || client.Stats.ResponseTokens != 6 {
+		t.Fatalf("expected the successful requests to be counted, got %+v", client.Stats)
+	}
+	last := sess.History[len(sess.History)-1].Content
+	if !strings.Contains(last, "Answer from llama3:\nllama3 says hi") || !strings.Contains(last, "Answer from broken:\nError:") {
+		t.Fatalf("expected the labelled answers in the history, got %q", last)
+	}
+}
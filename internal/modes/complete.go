@@ -0,0 +1,127 @@
+package modes
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// maxPathCompletions bounds how many paths CompletePath returns
+const maxPathCompletions = 50
+
+// maxRecentFiles bounds how many files RecentFiles returns
+const maxRecentFiles = 10
+
+// referenceNames are the @ references that aren't files
+var referenceNames = []string{"diff", "staged", "status", "env"}
+
+// ReferenceNames returns the @ references that aren't files, e.g. diff for @diff
+func ReferenceNames() []string {
+	return append([]string(nil), referenceNames...)
+}
+
+// CompletePath returns the project paths, relative to root, that complete prefix: the
+// entries of its directory that start with its last part, directories ending in "/", so
+// they can be completed further. Hidden and ignored entries are left out unless prefix
+// names them.
+func CompletePath(root, prefix string, ignore []string) []string {
+	if root == "" {
+		root = "."
+	}
+	dir, part := path.Split(prefix)
+
+	var paths []string
+	entries, _ := os.ReadDir(filepath.Join(root, filepath.FromSlash(dir)))
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, part) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(part, ".")) {
+			continue
+		}
+		rel := dir + name
+		if pathmatch.MatchAny(ignore, rel) {
+			continue
+		}
+		if e.IsDir() {
+			rel += "/"
+		}
+		paths = append(paths, rel)
+		if len(paths) == maxPathCompletions {
+			return paths
+		}
+	}
+	return paths
+}
+
+// RecentFiles returns the project files the session used last, most recent first: files
+// written during this run, the last edited file, the files in context and the files the
+// latest prompts named
+func RecentFiles(sess *session.Session) []string {
+	var files []string
+	seen := map[string]bool{}
+	add := func(name string) bool {
+		name = strings.TrimPrefix(filepath.ToSlash(name), "./")
+		if name == "" || seen[name] {
+			return len(files) < maxRecentFiles
+		}
+		seen[name] = true
+		if _, err := os.Stat(filepath.Join(sess.ProjectRoot, filepath.FromSlash(name))); err == nil {
+			files = append(files, name)
+		}
+		return len(files) < maxRecentFiles
+	}
+
+	for i := len(sess.Changes) - 1; i >= 0; i-- {
+		if !add(sess.Changes[i].Path) {
+			return files
+		}
+	}
+	if !add(sess.LastEditedFile) {
+		return files
+	}
+	for i := len(sess.ActiveFiles) - 1; i >= 0; i-- {
+		if !add(sess.ActiveFiles[i]) {
+			return files
+		}
+	}
+	for i := len(sess.History) - 1; i >= 0; i-- {
+		if sess.History[i].Role != "user" {
+			continue
+		}
+		for _, word := range strings.Fields(sess.History[i].Content) {
+			word = strings.TrimRight(strings.TrimPrefix(word, "@"), `,;:)!?"'`+"`")
+			if filePattern.MatchString(word) && !add(word) {
+				return files
+			}
+		}
+	}
+	return files
+}
+
+// fileMention returns the path an @ mention such as @Makefile or @internal/ui/prompt.go
+// names, if it is a project file; @diff and the other references aren't files
+func fileMention(word, projectRoot string) (string, bool) {
+	name, ok := strings.CutPrefix(word, "@")
+	if !ok || name == "" {
+		return "", false
+	}
+	for _, ref := range referenceNames {
+		if name == ref {
+			return "", false
+		}
+	}
+	if filePattern.MatchString(name) {
+		return name, true
+	}
+	root := projectRoot
+	if root == "" {
+		root = "."
+	}
+	if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(name))); err == nil && info.Mode().IsRegular() {
+		return name, true
+	}
+	return "", false
+}
@@ -0,0 +1,80 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func writeTree(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, f := range files {
+		path := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(f+"\n"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+}
+
+func TestCompletePath(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "internal/ui/prompt.go", "internal/ui/menu.go", "internal/modes/edit.go", "main.go", ".env", "vendor/lib.go")
+	ignore := []string{"vendor/**"}
+
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"", "internal/ main.go"},
+		{"in", "internal/"},
+		{"internal/", "internal/modes/ internal/ui/"},
+		{"internal/ui/p", "internal/ui/prompt.go"},
+		{".", ".env"},
+		{"vendor/", ""},
+		{"missing/", ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(CompletePath(root, tt.prefix, ignore), " "); got != tt.want {
+			t.Errorf("CompletePath(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestRecentFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "main.go", "edited.go", "active.go", "asked.go", "cmd/run.go")
+
+	sess := session.New(root)
+	sess.ActiveFiles = []string{"active.go", "main.go"}
+	sess.SetLastEditedFile("edited.go")
+	sess.AddMessage("user", "why does @cmd/run.go call asked.go, and gone.go?")
+	sess.AddMessage("assistant", "because of reply.go")
+	sess.RecordChange(session.FileChange{Path: "main.go", Action: "modified"})
+
+	got := strings.Join(RecentFiles(sess), " ")
+	if want := "main.go edited.go active.go cmd/run.go asked.go"; got != want {
+		t.Fatalf("RecentFiles = %q, want %q", got, want)
+	}
+}
+
+func TestReadFilesFromInputWithLimits_Mentions(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "Taskfile_fixture", "sub/mentioned_fixture.go")
+
+	out := ReadFilesFromInputWithLimits("what do @Taskfile_fixture and @sub/mentioned_fixture.go do? see @diff and @nothing", root, config.ContextConfig{})
+	for _, want := range []string{"--- End of Taskfile_fixture ---", "--- End of sub/mentioned_fixture.go ---"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "--- nothing") || strings.Contains(out, "--- diff") {
+		t.Errorf("loaded a mention that isn't a file:\n%s", out)
+	}
+}
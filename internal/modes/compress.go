@@ -0,0 +1,146 @@
+package modes
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+// minSummaryBytes is the size below which a file is stripped rather than summarized:
+// the summary would hardly be shorter
+const minSummaryBytes = 2048
+
+const summarySystemPrompt = `You summarize source files for a coding assistant that is working on other files of the same project.
+Reply in plain text with what the file is for, then every exported type, function, method and constant with its exact signature and a few words on what it does. Leave out the bodies. Be brief.`
+
+// Comment syntaxes of the languages whose comments can be stripped
+var (
+	slashCommentLanguages = map[string]bool{".go": true, ".js": true, ".ts": true, ".java": true, ".c": true, ".cpp": true, ".h": true, ".rs": true, ".php": true, ".cs": true, ".swift": true, ".kt": true}
+	hashCommentLanguages  = map[string]bool{".py": true, ".rb": true, ".sh": true, ".bash": true, ".yml": true, ".yaml": true}
+)
+
+// compressor shrinks the files that are loaded for context without being named in the
+// prompt, following context.compress
+type compressor struct {
+	mode   string // strip or summarize
+	model  string
+	client *ollama.Client
+}
+
+// newCompressor returns the compressor limits ask for, or nil when context.compress is off.
+// Without a client, summarize falls back to strip.
+func newCompressor(client *ollama.Client, limits config.ContextConfig) *compressor {
+	switch limits.Compress {
+	case "strip":
+		return &compressor{mode: "strip"}
+	case "summarize":
+		if client == nil {
+			return &compressor{mode: "strip"}
+		}
+		model := limits.CompressModel
+		if model == "" {
+			model = client.Model
+		}
+		return &compressor{mode: "summarize", model: model, client: client}
+	}
+	return nil
+}
+
+// compress returns a shorter text for the file name and what became of it, for the
+// file's header, or ok=false when the text is best left as it is
+func (c *compressor) compress(name, text string) (compressed, how string, ok bool) {
+	if c.mode == "summarize" && len(text) >= minSummaryBytes {
+		summary, err := c.summarize(name, text)
+		if err == nil {
+			return summary, "summary, not the file's content", true
+		}
+		fmt.Printf("\033[38;5;240m(Note: Could not summarize '%s', stripping it instead: %v)\033[0m\n", name, err)
+	}
+	stripped := stripComments(name, text)
+	if len(stripped) >= len(text) {
+		return "", "", false
+	}
+	return stripped, "comments and blank lines removed", true
+}
+
+// summarize asks the compress model for a summary of the file. Summaries are generated
+// at temperature 0, so the response cache keeps them until the file changes.
+func (c *compressor) summarize(name, text string) (string, error) {
+	fmt.Printf("\033[38;5;240m(Summarizing '%s'...)\033[0m\n", name)
+	// A summary isn't part of the answer, so it isn't forwarded to API clients
+	quiet := *c.client
+	quiet.OnChunk = nil
+	// The summary is part of the request a preview shows, so it is generated even then
+	quiet.Intercept = nil
+	var response strings.Builder
+	err := quiet.GenerateWithModel(c.model, fmt.Sprintf("File: %s\n\n%s", name, text), summarySystemPrompt, 0, func(chunk string) error {
+		response.WriteString(chunk)
+		return nil
+	})
+	c.client.Stats = quiet.Stats
+	if err != nil {
+		return "", err
+	}
+	summary := strings.TrimSpace(response.String())
+	if summary == "" {
+		return "", fmt.Errorf("the model's reply was empty")
+	}
+	return summary, nil
+}
+
+// stripComments removes the comments of the file name's language, leaving strings alone,
+// and drops blank lines. Files of other types only lose their blank lines.
+func stripComments(name, text string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	slash, hash := slashCommentLanguages[ext], hashCommentLanguages[ext]
+
+	var out strings.Builder
+	var quote byte // Quote of the string being read, or 0
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		if quote != 0 {
+			out.WriteByte(ch)
+			switch {
+			case ch == '\\' && quote != '`' && i+1 < len(text):
+				i++
+				out.WriteByte(text[i])
+			case ch == quote:
+				quote = 0
+			case ch == '\n' && quote != '`':
+				// Quotes don't span lines, e.g. a Rust lifetime isn't a string
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case ch == '"' || ch == '\'' || (ch == '`' && slash):
+			quote = ch
+		case slash && strings.HasPrefix(text[i:], "//"), hash && ch == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t' || text[i-1] == '\n') && !strings.HasPrefix(text[i:], "#!"):
+			// Skip to the end of the line
+			for i+1 < len(text) && text[i+1] != '\n' {
+				i++
+			}
+			continue
+		case slash && strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				i = len(text)
+			} else {
+				i += end + 3
+			}
+			continue
+		}
+		out.WriteByte(ch)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,60 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "main.go",
+			text: "// Package main runs\npackage main\n\n/* block\n   comment */\nvar url = \"http://example.com\" // the // in the string stays\nvar raw = `/* not a comment */`\n",
+			want: "package main\nvar url = \"http://example.com\"\nvar raw = `/* not a comment */`\n",
+		},
+		{
+			name: "build.sh",
+			text: "#!/bin/sh\n# Build it\n\necho \"#1\" ${#x} # done\n",
+			want: "#!/bin/sh\necho \"#1\" ${#x}\n",
+		},
+		{
+			name: "notes.md",
+			text: "# Title\n\n// kept\n",
+			want: "# Title\n// kept\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := stripComments(tt.name, tt.text); got != tt.want {
+			t.Errorf("stripComments(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadFilesFromInputWithLimits_CompressesGlobMatches(t *testing.T) {
+	root := t.TempDir()
+	src := "// Helper does things\nfunc Helper() {}\n\n"
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	limits := config.ContextConfig{Compress: "strip", LineNumbers: true}
+	out := ReadFilesFromInputWithLimits("change a.go using *.go", root, limits)
+
+	// a.go is named, so it is sent whole; b.go only matches the glob
+	if !strings.Contains(out, "--- a.go (line numbers") || !strings.Contains(out, "1 | // Helper does things") {
+		t.Errorf("expected the named file whole and numbered:\n%s", out)
+	}
+	if !strings.Contains(out, "--- b.go (comments and blank lines removed) ---\nfunc Helper() {}\n") {
+		t.Errorf("expected the glob match stripped:\n%s", out)
+	}
+}
@@ -0,0 +1,39 @@
+package modes
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// externalChangeChoice is how to proceed when a file changed on disk during an edit
+type externalChangeChoice int
+
+const (
+	externalChangeMerge externalChangeChoice = iota
+	externalChangeReread
+	externalChangeOverwrite
+	externalChangeCancel
+)
+
+// askExternalChange warns that relPath changed since it was read into the prompt and asks
+// the user how to proceed. Merging is the default.
+func askExternalChange(relPath string) externalChangeChoice {
+	fmt.Printf("\033[38;5;214m%s changed on disk while the model was working.\033[0m\n", relPath)
+	fmt.Print("[M]erge the edit into the new content, [r]e-read and ask again, [o]verwrite, or [c]ancel? ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return externalChangeCancel
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "r", "reread", "re-read":
+		return externalChangeReread
+	case "o", "overwrite":
+		return externalChangeOverwrite
+	case "c", "cancel":
+		return externalChangeCancel
+	}
+	return externalChangeMerge
+}
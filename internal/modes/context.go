@@ -6,6 +6,14 @@ import (
 	"github.com/yourusername/llamasidekick/internal/session"
 )
 
+// charsPerToken is the rough ratio used to estimate token counts without a tokenizer
+const charsPerToken = 4
+
+// EstimateTokens returns an approximate token count for text
+func EstimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
 // BuildConversationContext formats session history into a single prompt.
 // The last user message is substituted with enhancedLastUserMessage (typically including loaded file contents).
 func BuildConversationContext(sess *session.Session, enhancedLastUserMessage string) string {
@@ -0,0 +1,135 @@
+package modes
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/clip"
+	"github.com/yourusername/llamasidekick/internal/hint"
+	"golang.org/x/term"
+)
+
+// maxBareCommandLines bounds how long an answer without code blocks can be and still be
+// taken as nothing but a command
+const maxBareCommandLines = 10
+
+// proseLine matches markdown structure: headings, list items, emphasis and inline code
+var proseLine = regexp.MustCompile("^(#+ |[-*+] |\\d+[.)] |>)|\\*\\*|`")
+
+// sentenceEnd matches a line ending like a sentence, e.g. "Use df -h." but not "docker build ."
+var sentenceEnd = regexp.MustCompile(`[\p{L})"'][.:?!]$`)
+
+// CommandsToCopy returns the commands of a command answer: its code blocks, or the whole
+// answer when it is nothing but a command. An explanation without code blocks has no
+// commands, so prose is never copied as one.
+func CommandsToCopy(response string) []string {
+	if commands := ExtractCommands(response); len(commands) > 0 {
+		return commands
+	}
+	text := strings.TrimSpace(response)
+	lines := strings.Split(text, "\n")
+	if text == "" || len(lines) > maxBareCommandLines {
+		return nil
+	}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if proseLine.MatchString(line) || (sentenceEnd.MatchString(line) && len(strings.Fields(line)) >= 3) {
+			return nil
+		}
+	}
+	return []string{text}
+}
+
+// CopyCommands copies the commands of a command answer to the clipboard. With several, it
+// lists them and, on a terminal, asks which one to copy; all of them by default.
+func CopyCommands(response string) {
+	commands := CommandsToCopy(response)
+	if len(commands) == 0 {
+		return
+	}
+	chosen, label := commands, "Command(s)"
+	if len(commands) > 1 && term.IsTerminal(int(os.Stdin.Fd())) {
+		var ok bool
+		if chosen, label, ok = pickCommands(commands, bufio.NewReader(os.Stdin)); !ok {
+			fmt.Println("\033[38;5;240mNothing copied (copy one later with /copy <n>)\033[0m")
+			return
+		}
+	}
+	if err := CopyText(strings.Join(chosen, "\n"), label+" copied to clipboard - ready to paste!"); err != nil {
+		hint.Warn(fmt.Errorf("failed to copy to clipboard: %w", err))
+	}
+}
+
+// pickCommands lists commands and reads which to copy: a number, all of them for an
+// empty answer or "a", or none for "n". It returns false when none are to be copied.
+func pickCommands(commands []string, in *bufio.Reader) ([]string, string, bool) {
+	for i, c := range commands {
+		fmt.Printf("  %d. %s\n", i+1, strings.ReplaceAll(c, "\n", "\n     "))
+	}
+	for {
+		fmt.Printf("Copy which command? [1-%d, Enter = all, n = none] ", len(commands))
+		line, err := in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Println()
+			return nil, "", false
+		}
+		switch answer := strings.ToLower(strings.TrimSpace(line)); answer {
+		case "", "a", "all":
+			return commands, "Commands", true
+		case "n", "no", "none", "q":
+			return nil, "", false
+		default:
+			if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(commands) {
+				return commands[n-1 : n], fmt.Sprintf("Command %d", n), true
+			}
+		}
+	}
+}
+
+// CopyText copies text to the clipboard and reports it with copied. Text that is too
+// long for the terminal's clipboard is copied in parts on a terminal, each once the user
+// has pasted the one before.
+func CopyText(text, copied string) error {
+	method, err := clip.Copy(text)
+	if errors.Is(err, clip.ErrTooLong) && term.IsTerminal(int(os.Stdin.Fd())) {
+		parts := clip.Split(text, clip.MaxTerminalBytes)
+		fmt.Printf("\033[38;5;214mThat's too long to copy at once; copying it in %d parts\033[0m\n", len(parts))
+		return copyParts(parts, bufio.NewReader(os.Stdin), clip.Copy)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\033[1;32m✓ %s\033[0m\033[38;5;240m%s\033[0m\n", copied, method.Describe())
+	return nil
+}
+
+// copyParts copies parts one at a time with copy, waiting for Enter between them
+func copyParts(parts []string, in *bufio.Reader, copy func(string) (clip.Method, error)) error {
+	line := 1
+	for i, part := range parts {
+		if i > 0 {
+			fmt.Printf("Press Enter to copy part %d of %d, or q to stop: ", i+1, len(parts))
+			answer, err := in.ReadString('\n')
+			if (err != nil && answer == "") || strings.EqualFold(strings.TrimSpace(answer), "q") {
+				fmt.Println("\033[38;5;240mStopped copying\033[0m")
+				return nil
+			}
+		}
+		method, err := copy(part)
+		if err != nil {
+			return fmt.Errorf("part %d of %d: %w", i+1, len(parts), err)
+		}
+		last := line + strings.Count(strings.TrimSuffix(part, "\n"), "\n")
+		fmt.Printf("\033[1;32m✓ Copied part %d of %d (lines %d-%d) to clipboard\033[0m\033[38;5;240m%s\033[0m\n", i+1, len(parts), line, last, method.Describe())
+		line = last
+		if strings.HasSuffix(part, "\n") {
+			line++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package modes
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/clip"
+)
+
+func TestCommandsToCopy(t *testing.T) {
+	for response, want := range map[string]string{
+		"df -h":                             "df -h",
+		"docker build .\n":                  "docker build .",
+		"git commit -m \"Fix the parser.\"": "git commit -m \"Fix the parser.\"",
+		"Run this:\n```bash\nls -la\n```\nthen\n```sh\npwd\n```": "ls -la|pwd",
+		"Use df -h to see the free space.":                       "",
+		"You can check it with:\n\n- `df -h`":                    "",
+		"**Note**: this deletes files":                           "",
+		"":                                                       "",
+	} {
+		if got := strings.Join(CommandsToCopy(response), "|"); got != want {
+			t.Errorf("CommandsToCopy(%q) = %q, want %q", response, got, want)
+		}
+	}
+}
+
+func TestPickCommands(t *testing.T) {
+	commands := []string{"make build", "make test"}
+	for answer, want := range map[string]string{"\n": "make build|make test", "3\n2\n": "make test", "n\n": "", "": ""} {
+		chosen, _, ok := pickCommands(commands, bufio.NewReader(strings.NewReader(answer)))
+		if got := strings.Join(chosen, "|"); got != want || ok != (want != "") {
+			t.Errorf("answer %q: got %q (%v), want %q", answer, got, ok, want)
+		}
+	}
+}
+
+func TestCopyParts(t *testing.T) {
+	var copied []string
+	copy := func(text string) (clip.Method, error) {
+		copied = append(copied, text)
+		return clip.System, nil
+	}
+	parts := []string{"a\nb\n", "c\n", "d"}
+	if err := copyParts(parts, bufio.NewReader(strings.NewReader("\nq\n")), copy); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(copied, "|") != "a\nb\n|c\n" {
+		t.Fatalf("expected the first two parts before stopping, got %q", copied)
+	}
+}
@@ -0,0 +1,173 @@
+package modes
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// CustomMode is a mode defined under custom_modes in config.yaml: a system prompt, model
+// and temperature, and what to do with the response
+type CustomMode struct {
+	Config config.CustomModeConfig
+}
+
+func (m *CustomMode) Name() string {
+	return m.Config.Name
+}
+
+func (m *CustomMode) Description() string {
+	if m.Config.Description != "" {
+		return m.Config.Description
+	}
+	return "Custom mode from config.yaml"
+}
+
+func (m *CustomMode) GetSystemPrompt() string {
+	return m.Config.SystemPrompt
+}
+
+// temperature returns the mode's temperature, or ollama.temperature if it has none
+func (m *CustomMode) temperature(cfg *config.Config) float64 {
+	if m.Config.Temperature != nil {
+		return *m.Config.Temperature
+	}
+	return cfg.Ollama.Temperature
+}
+
+// ProcessInput handles a single request in the custom mode
+func (m *CustomMode) ProcessInput(client *ollama.Client, sess *session.Session, cfg *config.Config, input string) error {
+	sess.SetMode(m.Config.Name)
+	modelName := cfg.GetModelForMode(m.Config.Name)
+
+	enhancedInput := ReadInputContext(client, input, sess, cfg.Context)
+	enhancedInput += RetrieveContext(client, sess, cfg, input, enhancedInput)
+	sess.AddMessage("user", input)
+	conversationContext := BuildConversationContext(sess, enhancedInput)
+	systemPrompt := ProjectSystemPrompt(m.Config.Name, m.GetSystemPrompt(), sess.ProjectRoot, cfg.Context) + LengthPrompt(cfg, m.Config.Name)
+	defer LimitLength(client, cfg, m.Config.Name)()
+
+	var response string
+	var err error
+	switch m.Config.Output {
+	case "files":
+		response, err = m.generateFiles(client, sess, cfg, modelName, conversationContext, systemPrompt, input)
+	case "command":
+		response, err = m.generateCommand(client, cfg, modelName, conversationContext, systemPrompt)
+	default:
+		response, err = m.generateMarkdown(client, cfg, modelName, conversationContext, systemPrompt)
+	}
+	if err != nil {
+		return err
+	}
+
+	sess.AddMessage("assistant", response)
+	if err := sess.Save(); err != nil {
+		fmt.Printf("Warning: failed to save session: %v\n", err)
+	}
+	return nil
+}
+
+// generateMarkdown streams the response rendered as markdown
+func (m *CustomMode) generateMarkdown(client *ollama.Client, cfg *config.Config, modelName, prompt, systemPrompt string) (string, error) {
+	s := NewModelStatus(m.Config.Name, modelName)
+	s.Start()
+
+	md := NewResponseDisplay(cfg, m.Config.Name, func() {
+		s.Stop()
+		fmt.Println()
+	})
+	err := client.GenerateWithModel(modelName, prompt, systemPrompt, m.temperature(cfg), func(chunk string) error {
+		s.Token()
+		md.Write(chunk)
+		return nil
+	})
+	if s.Active() {
+		s.Stop()
+	}
+	if err != nil {
+		return "", fmt.Errorf("error generating response: %w", err)
+	}
+
+	response := md.String()
+	fmt.Println(md.Finish())
+	return response, nil
+}
+
+// generateCommand prints the response as plain text and copies the commands in it to the
+// clipboard: those in code blocks, or the whole response if it has none
+func (m *CustomMode) generateCommand(client *ollama.Client, cfg *config.Config, modelName, prompt, systemPrompt string) (string, error) {
+	s := NewModelStatus(m.Config.Name, modelName)
+	s.Start()
+
+	display := newCommandDisplay(cfg, m.Config.Name, func() {
+		s.Stop()
+		fmt.Println()
+	})
+	err := client.GenerateWithModel(modelName, prompt, systemPrompt, m.temperature(cfg), func(chunk string) error {
+		s.Token()
+		display.Write(chunk)
+		return nil
+	})
+	if s.Active() {
+		s.Stop()
+	}
+	if err != nil {
+		return "", fmt.Errorf("error generating response: %w", err)
+	}
+
+	response := display.String()
+	fmt.Print(display.Finish())
+	fmt.Println()
+
+	CopyCommands(response)
+	fmt.Println()
+	return response, nil
+}
+
+// generateFiles asks for the response as a JSON array of files and writes them
+func (m *CustomMode) generateFiles(client *ollama.Client, sess *session.Session, cfg *config.Config, modelName, prompt, systemPrompt, input string) (string, error) {
+	return streamGeneratedFiles(client, cfg, sess, modelName, prompt, systemPrompt+"\n\n"+generatedFilesPrompt, m.temperature(cfg), input)
+}
+
+func (m *CustomMode) Run(client *ollama.Client, sess *session.Session, cfg *config.Config) error {
+	fmt.Printf("\n\033[1;38;5;75m=== %s ===\033[0m\n", m.Config.Name)
+	fmt.Printf("\033[38;5;240m%s\033[0m\n", m.Description())
+	fmt.Println("\033[38;5;240mType 'q' to return to menu\033[0m")
+	fmt.Println()
+
+	sess.SetMode(m.Config.Name)
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Printf("\n\033[1;38;5;75m%s>\033[0m ", m.Config.Name)
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			continue
+		}
+
+		if input == "q" || input == "quit" {
+			return nil
+		}
+
+		if err := m.ProcessInput(client, sess, cfg, input); err != nil {
+			fmt.Println()
+			hint.Print(err)
+			continue
+		}
+		progress.Announce("Done")
+	}
+}
@@ -0,0 +1,86 @@
+package modes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestCustomModeFiles(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", t.TempDir())
+	root := t.TempDir()
+
+	var got ollama.GenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"response": "[{\"filename\": \"Dockerfile\", \"content\": \"FROM golang\\n\"}]", "done": true}`))
+	}))
+	defer server.Close()
+
+	cold := 0.1
+	mode := &CustomMode{Config: config.CustomModeConfig{
+		Name:         "dockerize",
+		SystemPrompt: "Write a Dockerfile.",
+		Model:        "coder",
+		Temperature:  &cold,
+		Output:       "files",
+	}}
+	cfg := &config.Config{
+		Context:     config.DefaultContextConfig(),
+		Backups:     config.BackupsConfig{Keep: 10},
+		CustomModes: []config.CustomModeConfig{mode.Config},
+	}
+	sess := session.New(root)
+	if err := mode.ProcessInput(ollama.NewClient(server.URL, "default"), sess, cfg, "containerize the app"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Model != "coder" || got.Temperature != 0.1 || got.Format != "json" {
+		t.Fatalf("expected the mode's model and temperature in JSON format, got %+v", got)
+	}
+	if !strings.HasPrefix(got.System, "Write a Dockerfile.\n\n") || !strings.Contains(got.System, "JSON array") {
+		t.Fatalf("expected the mode's prompt followed by the file format, got %q", got.System)
+	}
+	content, err := os.ReadFile(filepath.Join(root, "Dockerfile"))
+	if err != nil || string(content) != "FROM golang\n" {
+		t.Fatalf("expected the Dockerfile to be written, got %q, %v", content, err)
+	}
+	if sess.LastMode != "dockerize" || sess.History[len(sess.History)-1].Content != "Created 1 file(s) successfully" {
+		t.Fatalf("unexpected session state: mode %q, history %+v", sess.LastMode, sess.History)
+	}
+}
+
+func TestRegistry_ListsCustomModesAfterBuiltIns(t *testing.T) {
+	r := NewRegistry(&config.Config{CustomModes: []config.CustomModeConfig{{Name: "review", Output: "markdown"}}})
+	if got := strings.Join(r.Commands(), " "); got != "/plan /edit /agent /cmd /ask /review" {
+		t.Fatalf("unexpected commands %q", got)
+	}
+	e, ok := r.Lookup("review")
+	if !ok || e.Mode.Name() == "" || e.ModelKey != "models.review" {
+		t.Fatalf("unexpected custom mode entry: %+v %v", e, ok)
+	}
+	if _, ok := r.Lookup("nope"); ok {
+		t.Fatal("unknown mode found")
+	}
+}
+
+func TestRegistry_ModelKeysAreModelSettings(t *testing.T) {
+	cfg := &config.Config{}
+	for _, e := range NewRegistry(cfg).Entries() {
+		if err := cfg.SetModel(e.ModelKey, "m-"+e.Key); err != nil {
+			t.Fatalf("%s: %v", e.Key, err)
+		}
+		if got := cfg.GetModelForMode(e.Key); got != "m-"+e.Key {
+			t.Fatalf("%s uses %q after setting %s", e.Key, got, e.ModelKey)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package modes
+
+import (
+	"fmt"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/renderer"
+)
+
+// ResponseDisplay prints a streamed response in the mode's display style (ui.display).
+// start runs once before anything of the response is printed, to stop the spinner and
+// print a header: at the first token, or when a rendered response is done.
+type ResponseDisplay struct {
+	style   string
+	text    bool // A command response, printed as styled text rather than markdown
+	md      *renderer.StreamingMarkdownBuffer
+	start   func()
+	started bool
+}
+
+// NewResponseDisplay returns the display of a markdown response in mode
+func NewResponseDisplay(cfg *config.Config, mode string, start func()) *ResponseDisplay {
+	return &ResponseDisplay{style: cfg.DisplayForMode(mode), md: renderer.NewStreamingMarkdownBuffer(), start: start}
+}
+
+// newCommandDisplay returns the display of a response with commands in mode, which is
+// printed as text in the response style
+func newCommandDisplay(cfg *config.Config, mode string, start func()) *ResponseDisplay {
+	d := NewResponseDisplay(cfg, mode, start)
+	d.text = true
+	return d
+}
+
+// Write adds a chunk of the response and prints what is ready to be shown
+func (d *ResponseDisplay) Write(chunk string) {
+	d.md.Write(chunk)
+	switch d.style {
+	case config.DisplayRaw:
+		d.begin()
+		fmt.Print(chunk)
+	case config.DisplayLive:
+		d.begin()
+		if d.text {
+			fmt.Print(responseStyle.Render(chunk))
+		} else {
+			fmt.Print(d.md.Flush())
+		}
+	}
+}
+
+// Finish returns the rest of the response to print: all of it when it is rendered at
+// once, and nothing when the tokens were printed as they arrived
+func (d *ResponseDisplay) Finish() string {
+	if d.md.String() != "" {
+		d.begin()
+	}
+	switch {
+	case d.style == config.DisplayRaw:
+		return ""
+	case d.text && d.style == config.DisplayLive:
+		return ""
+	case d.text:
+		return responseStyle.Render(d.md.String())
+	}
+	return d.md.Finish()
+}
+
+// String returns the markdown of the response
+func (d *ResponseDisplay) String() string {
+	return d.md.String()
+}
+
+func (d *ResponseDisplay) begin() {
+	if !d.started {
+		d.started = true
+		if d.start != nil {
+			d.start()
+		}
+	}
+}
@@ -4,16 +4,17 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/diff"
+	"github.com/yourusername/llamasidekick/internal/hint"
 	"github.com/yourusername/llamasidekick/internal/ollama"
-	"github.com/yourusername/llamasidekick/internal/renderer"
+	"github.com/yourusername/llamasidekick/internal/progress"
 	"github.com/yourusername/llamasidekick/internal/safeio"
 	"github.com/yourusername/llamasidekick/internal/session"
 )
@@ -51,8 +52,10 @@ func (m *EditMode) GetSystemPrompt() string {
 // ProcessInput handles a single edit request with automatic file modification
 func (m *EditMode) ProcessInput(client *ollama.Client, sess *session.Session, cfg *config.Config, input string) error {
 	sess.SetMode(ModeEdit)
-	enhancedInput := ReadFilesFromInputWithRoot(input, sess.ProjectRoot)
+	enhancedInput := ReadInputContext(client, input, sess, cfg.Context)
+	enhancedInput += RetrieveContext(client, sess, cfg, input, enhancedInput)
 	sess.AddMessage("user", input)
+	defer LimitLength(client, cfg, ModeEdit)()
 
 	fileToEdit := detectFileInInput(input)
 	if fileToEdit == "" {
@@ -68,6 +71,13 @@ func (m *EditMode) ProcessInput(client *ollama.Client, sess *session.Session, cf
 			return fmt.Errorf("refusing to edit '%s': %w", fileToEdit, err)
 		}
 		fileToEdit = relPath
+		backups, err := OpenBackupStore(cfg)
+		if err != nil {
+			return err
+		}
+		if err := backups.Policy.Check(absPath, relPath); err != nil {
+			return fmt.Errorf("refusing to edit '%s': %w", relPath, err)
+		}
 		if !fileExists(absPath) {
 			// Fall back to suggestion mode if the resolved file doesn't exist.
 			goto suggestionMode
@@ -77,64 +87,115 @@ func (m *EditMode) ProcessInput(client *ollama.Client, sess *session.Session, cf
 		if err != nil {
 			return fmt.Errorf("error reading file %s: %w", relPath, err)
 		}
+		promptReads.Record(absPath, currentContent)
 
-		if client.Debug {
-			fmt.Printf("\n[DEBUG] File editing detected: %s (%d bytes)\n", relPath, len(currentContent))
-		}
+		slog.Debug("file editing detected", "path", relPath, "bytes", len(currentContent))
 
 		fmt.Print(lipgloss.NewStyle().Foreground(lipgloss.Color("green")).Render("\nEdit: "))
 		fmt.Printf("Modifying %s...\n", relPath)
 
-		jsonSystemPrompt := "You MUST respond with ONLY a valid JSON object. No markdown, no explanations, no extra text.\n\n" +
-			"The object must have exactly these fields:\n" +
-			"- filename: string (the file path/name being edited)\n" +
-			"- content: string (the COMPLETE modified file content)\n" +
-			"- summary: string (brief description of changes made)\n\n" +
-			"Example response format:\n" +
-			"{\"filename\": \"index.html\", \"content\": \"full content here\", \"summary\": \"Reduced animation speed\"}\n\n" +
-			"Output ONLY the JSON object. Any other text will cause failure."
-
-		conversationContext := BuildConversationContext(sess, enhancedInput)
-		editPrompt := fmt.Sprintf("File: %s\n\nCurrent content:\n%s\n\nUser request: %s\n\nProvide the COMPLETE modified file content.",
-			relPath, string(currentContent), input)
-		fullPrompt := conversationContext + "\n\n" + editPrompt
-
-		modelName := cfg.GetModelForMode("edit")
-		jsonResponse, err := client.GenerateJSON(modelName, fullPrompt, jsonSystemPrompt, 0.3)
+		result, err := requestFileEdit(client, sess, cfg, enhancedInput, input, relPath, currentContent)
 		if err != nil {
-			return fmt.Errorf("error generating JSON: %w", err)
+			return err
 		}
 
-		type EditResult struct {
-			Filename string `json:"filename"`
-			Content  string `json:"content"`
-			Summary  string `json:"summary"`
+		// The file may have changed on disk (e.g. in an editor) while the model was working
+		baseHash := safeio.Hash(currentContent)
+		for {
+			onDisk, err := os.ReadFile(absPath)
+			if err != nil {
+				return fmt.Errorf("error reading file %s: %w", relPath, err)
+			}
+			if safeio.Hash(onDisk) == baseHash {
+				break
+			}
+			choice := askExternalChange(relPath)
+			if choice == externalChangeReread {
+				currentContent = onDisk
+				promptReads.Record(absPath, currentContent)
+				enhancedInput = ReadInputContext(client, input, sess, cfg.Context)
+				enhancedInput += RetrieveContext(client, sess, cfg, input, enhancedInput)
+				baseHash = safeio.Hash(currentContent)
+				if result, err = requestFileEdit(client, sess, cfg, enhancedInput, input, relPath, currentContent); err != nil {
+					return err
+				}
+				continue
+			}
+			if choice == externalChangeCancel {
+				fmt.Println("\033[38;5;240mEdit cancelled, nothing was written\033[0m")
+				sess.AddMessage("assistant", fmt.Sprintf("Edit of %s cancelled because the file changed on disk", relPath))
+				if err := sess.Save(); err != nil {
+					fmt.Printf("Warning: failed to save session: %v\n", err)
+				}
+				return nil
+			}
+			// Merging and overwriting both replace what is on disk now
+			promptReads.Record(absPath, onDisk)
+			if choice == externalChangeMerge {
+				merged, conflicts := diff.Merge3(string(currentContent), string(onDisk), result.Content, "on disk", "model")
+				result.Content = merged
+				if conflicts > 0 {
+					fmt.Printf("\033[38;5;214m%d conflicting region(s) were marked with <<<<<<< / >>>>>>> in %s\033[0m\n", conflicts, relPath)
+				}
+			}
+			break
 		}
-		var result EditResult
 
-		if err := json.Unmarshal([]byte(jsonResponse), &result); err != nil {
-			return fmt.Errorf("error parsing JSON response: %w\nResponse was: %s", err, jsonResponse)
+		callers, proceed := checkCallSites(client, sess, cfg, relPath, currentContent, result.Content)
+		if !proceed {
+			fmt.Println("\033[38;5;240mEdit cancelled, nothing was written\033[0m")
+			sess.AddMessage("assistant", fmt.Sprintf("Edit of %s cancelled because it breaks call sites", relPath))
+			if err := sess.Save(); err != nil {
+				fmt.Printf("Warning: failed to save session: %v\n", err)
+			}
+			return nil
 		}
 
-		if client.Debug {
-			fmt.Printf("[DEBUG] Parsed edit result: %s - %s\n", result.Filename, result.Summary)
+		hunksNote := ""
+		if reviewHunks(cfg, sess) {
+			proposed := result.Content
+			var applied, total int
+			result.Content, applied, total = selectHunks(cfg, relPath, string(currentContent), proposed, bufio.NewReader(os.Stdin), func(h diff.Hunk) (diff.Hunk, error) {
+				return editHunk(relPath, h)
+			})
+			if applied < total || result.Content != proposed {
+				hunksNote = fmt.Sprintf(" (%d of %d hunks applied)", applied, total)
+			}
+			if applied == 0 {
+				fmt.Printf("\033[38;5;240mNo hunks applied to %s\033[0m\n", relPath)
+			}
 		}
 
-		backupPath, err := safeio.WriteFileWithBackup(absPath, []byte(result.Content))
+		tx := backups.Begin(sess.ProjectRoot)
+		if result.Content != string(currentContent) {
+			if err := tx.Stage(relPath, []byte(result.Content)); err != nil {
+				return fmt.Errorf("error staging file: %w", err)
+			}
+		}
+		for _, file := range callers {
+			if err := tx.Stage(file.Filename, []byte(file.Content)); err != nil {
+				fmt.Printf("\033[38;5;9mRefusing to write '%s': %v\033[0m\n", file.Filename, err)
+			}
+		}
+		stageFileOperations(cfg, sess, tx, result.Operations)
+		newPath := renamedPath(tx, relPath)
+		written, err := applyTransaction(cfg, sess, tx, result.Summary)
 		if err != nil {
 			return fmt.Errorf("error writing file: %w", err)
 		}
+		fmt.Printf("  %s\n\n", result.Summary)
 
-		fmt.Printf("\033[1;32m✓ Modified: %s\033[0m (%d → %d bytes)\n", relPath, len(currentContent), len(result.Content))
-		fmt.Printf("  %s\n", result.Summary)
-		if backupPath != "" {
-			fmt.Printf("\033[38;5;240m  Backup saved: %s\033[0m\n\n", backupPath)
+		target := relPath
+		if newPath != relPath {
+			target = relPath + " (renamed to " + newPath + ")"
+		}
+		responseText := fmt.Sprintf("Modified %s%s: %s", target, hunksNote, result.Summary)
+		if written {
+			sess.SetLastEditedFile(newPath)
 		} else {
-			fmt.Println()
+			sess.SetLastEditedFile(relPath)
+			responseText = fmt.Sprintf("Proposed changes to %s (not written): %s", target, result.Summary)
 		}
-
-		sess.SetLastEditedFile(relPath)
-		responseText := fmt.Sprintf("Modified %s: %s", relPath, result.Summary)
 		sess.AddMessage("assistant", responseText)
 
 		if err := sess.Save(); err != nil {
@@ -146,24 +207,23 @@ func (m *EditMode) ProcessInput(client *ollama.Client, sess *session.Session, cf
 
 suggestionMode:
 	// Suggestion mode (no file editing)
-		s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-		s.Suffix = " Thinking..."
+		modelName := cfg.GetModelForMode("edit")
+		s := NewModelStatus(ModeEdit, modelName)
 		s.Start()
 		
-		var fullResponse strings.Builder
-		modelName := cfg.GetModelForMode("edit")
+		md := NewResponseDisplay(cfg, ModeEdit, func() {
+			s.Stop()
+			fmt.Print(lipgloss.NewStyle().Foreground(lipgloss.Color("green")).Render("\nEdit: "))
+		})
 		conversationContext := BuildConversationContext(sess, enhancedInput)
 		err := client.GenerateWithModel(
 			modelName,
 			conversationContext,
-			m.GetSystemPrompt(),
+			ProjectSystemPrompt(ModeEdit, m.GetSystemPrompt(), sess.ProjectRoot, cfg.Context),
 			cfg.Ollama.Temperature,
 			func(chunk string) error {
-				if s.Active() {
-					s.Stop()
-					fmt.Print(lipgloss.NewStyle().Foreground(lipgloss.Color("green")).Render("\nEdit: "))
-				}
-				fullResponse.WriteString(chunk)
+				s.Token()
+				md.Write(chunk)
 				return nil
 			},
 		)
@@ -176,18 +236,59 @@ suggestionMode:
 			return fmt.Errorf("error generating response: %w", err)
 		}
 		
-		markdown := fullResponse.String()
-		renderedMd := renderer.RenderMarkdown(markdown)
-		fmt.Print(renderedMd)
+		fmt.Print(md.Finish())
 		fmt.Println()
 		
-		sess.AddMessage("assistant", fullResponse.String())
+		sess.AddMessage("assistant", md.String())
 	if err := sess.Save(); err != nil {
 		fmt.Printf("Warning: failed to save session: %v\n", err)
 	}
 	return nil
 }
 
+// fileEditResult is the JSON object Edit mode asks the model for
+type fileEditResult struct {
+	Filename   string          `json:"filename"`
+	Content    string          `json:"content"`
+	Summary    string          `json:"summary"`
+	Operations []FileOperation `json:"operations"` // Renames and deletions that go with the edit
+}
+
+// requestFileEdit asks the model for the complete new content of relPath
+func requestFileEdit(client *ollama.Client, sess *session.Session, cfg *config.Config, enhancedInput, input, relPath string, currentContent []byte) (*fileEditResult, error) {
+	jsonSystemPrompt := "You MUST respond with ONLY a valid JSON object. No markdown, no explanations, no extra text.\n\n" +
+		"The object must have these fields:\n" +
+		"- filename: string (the file path/name being edited)\n" +
+		"- content: string (the COMPLETE modified file content)\n" +
+		"- summary: string (brief description of changes made)\n" +
+		fileOperationsPrompt + "\n" +
+		"Example response format:\n" +
+		"{\"filename\": \"index.html\", \"content\": \"full content here\", \"summary\": \"Reduced animation speed\"}\n\n" +
+		"Output ONLY the JSON object. Any other text will cause failure."
+
+	conversationContext := BuildConversationContext(sess, enhancedInput)
+	editPrompt := fmt.Sprintf("File: %s\n\nCurrent content:\n%s\n\nUser request: %s\n\nProvide the COMPLETE modified file content.",
+		relPath, redactedForPrompt(string(currentContent), cfg.Context), input)
+	fullPrompt := conversationContext + "\n\n" + editPrompt
+
+	modelName := cfg.GetModelForMode("edit")
+	jsonResponse, err := streamFiles(client, modelName, fullPrompt, jsonSystemPrompt, 0.3, "Writing "+relPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error generating JSON: %w", err)
+	}
+
+	var result fileEditResult
+	if err := json.Unmarshal([]byte(jsonResponse), &result); err != nil {
+		return nil, invalidJSON(fmt.Errorf("error parsing JSON response: %w\nResponse was: %s", err, jsonResponse))
+	}
+	// The file is in the conversation with line numbers, which the model may copy
+	result.Content = stripLineNumbers(result.Content)
+
+	slog.Debug("parsed edit result", "path", result.Filename, "summary_chars", len(result.Summary))
+
+	return &result, nil
+}
+
 func (m *EditMode) Run(client *ollama.Client, sess *session.Session, cfg *config.Config) error {
 	sess.SetMode(ModeEdit)
 	
@@ -216,8 +317,11 @@ func (m *EditMode) Run(client *ollama.Client, sess *session.Session, cfg *config
 		}
 		
 		if err := m.ProcessInput(client, sess, cfg, input); err != nil {
-			fmt.Printf("\nError: %v\n", err)
+			fmt.Println()
+			hint.Print(err)
+			continue
 		}
+		progress.Announce("Done")
 	}
 	
 	return nil
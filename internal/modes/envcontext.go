@@ -0,0 +1,20 @@
+package modes
+
+import (
+	"regexp"
+
+	"github.com/yourusername/llamasidekick/internal/envinfo"
+)
+
+// envReferencePattern matches @env written as a separate word
+var envReferencePattern = regexp.MustCompile(`(?:^|\s)@env\b`)
+
+// ReadEnvReference expands @env in input into a snapshot of the user's environment: the
+// OS, the shell, the versions of common tools and the names of the environment variables,
+// without their values. It returns "" when input doesn't mention @env.
+func ReadEnvReference(input string) string {
+	if !envReferencePattern.MatchString(input) {
+		return ""
+	}
+	return "\n\n--- Environment ---\n" + envinfo.Take().Describe() + "\n--- End of Environment ---\n"
+}
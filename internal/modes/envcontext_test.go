@@ -0,0 +1,16 @@
+package modes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadEnvReference(t *testing.T) {
+	if got := ReadEnvReference("mail ops@env.example.com about @environment"); got != "" {
+		t.Fatalf("expected no snapshot without @env, got %q", got)
+	}
+	got := ReadEnvReference("why does the build fail here? @env")
+	if !strings.Contains(got, "--- Environment ---") || !strings.Contains(got, "OS: ") {
+		t.Fatalf("expected an environment snapshot, got %q", got)
+	}
+}
@@ -0,0 +1,107 @@
+package modes
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// FileOperation is a rename or deletion the model proposes along with an edit
+type FileOperation struct {
+	Action string `json:"action"` // "rename" or "delete"
+	From   string `json:"from"`
+	To     string `json:"to,omitempty"`
+}
+
+func (op FileOperation) String() string {
+	if op.Action == "rename" {
+		return fmt.Sprintf("rename %s → %s", op.From, op.To)
+	}
+	return op.Action + " " + op.From
+}
+
+// fileOperationsPrompt describes the operations field of an edit reply
+const fileOperationsPrompt = "- operations: optional array of files to rename or delete, e.g. " +
+	"[{\"action\": \"rename\", \"from\": \"a.go\", \"to\": \"b.go\"}, {\"action\": \"delete\", \"from\": \"old.go\"}]. " +
+	"A renamed file keeps the content given for it. Leave it out unless the request asks to move, rename or delete files.\n"
+
+// stageFileOperations stages the renames and deletions in ops in tx once the user agrees
+// to them. Renames and deletions are declined by default; in dry-run and read-only mode,
+// or when the session approves the whole diff itself, they are staged without asking.
+func stageFileOperations(cfg *config.Config, sess *session.Session, tx *safeio.Transaction, ops []FileOperation) {
+	var valid []FileOperation
+	for _, op := range ops {
+		switch {
+		case op.Action == "rename" && op.From != "" && op.To != "":
+		case op.Action == "delete" && op.From != "":
+		default:
+			fmt.Printf("\033[38;5;214mIgnoring unknown file operation %q\033[0m\n", op.String())
+			continue
+		}
+		valid = append(valid, op)
+	}
+	if len(valid) == 0 {
+		return
+	}
+
+	created := map[string]bool{}
+	for _, c := range sess.Changes {
+		if c.Action == "created" {
+			created[c.Path] = true
+		}
+	}
+	fmt.Println("\033[1mThe model also wants to move or delete files:\033[0m")
+	for _, op := range valid {
+		note := ""
+		if created[op.From] {
+			note = " \033[38;5;240m(created this session)\033[0m"
+		}
+		fmt.Printf("  %s%s\n", op, note)
+	}
+	if cfg.Edits.AutoApprove && !cfg.Edits.DryRun && !cfg.Edits.ReadOnly {
+		fmt.Println("\033[38;5;214mAuto-approved (/yolo)\033[0m")
+	} else if !cfg.Edits.DryRun && !cfg.Edits.ReadOnly && sess.ApproveChanges == nil && !confirmFileOperations() {
+		fmt.Println("\033[38;5;240mKeeping the files where they are\033[0m")
+		return
+	}
+
+	for _, op := range valid {
+		var err error
+		if op.Action == "rename" {
+			err = tx.StageRename(op.From, op.To)
+		} else {
+			err = tx.StageRemove(op.From)
+		}
+		if err != nil {
+			fmt.Printf("\033[38;5;9mRefusing to %s: %v\033[0m\n", op, err)
+		}
+	}
+}
+
+// confirmFileOperations asks whether to apply the renames and deletions just listed.
+// Declining is the default, and without an interactive answer they are declined.
+func confirmFileOperations() bool {
+	fmt.Print("Apply them? [y/N] ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		fmt.Println()
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// renamedPath returns where tx moves relPath, or relPath if it stays
+func renamedPath(tx *safeio.Transaction, relPath string) string {
+	for _, c := range tx.Changes() {
+		if c.RenamedFrom == relPath {
+			return c.RelPath
+		}
+	}
+	return relPath
+}
@@ -0,0 +1,63 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestStageFileOperations_StagesRenamesAndDeletions(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.go", "old.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package a\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tx := safeio.NewBackupStore(t.TempDir(), 5).Begin(root)
+	if err := tx.Stage("a.go", []byte("package b\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dry-run mode stages them without asking, so the diff shows them
+	cfg := &config.Config{Edits: config.EditsConfig{DryRun: true}}
+	stageFileOperations(cfg, &session.Session{}, tx, []FileOperation{
+		{Action: "rename", From: "a.go", To: "b.go"},
+		{Action: "delete", From: "old.go"},
+		{Action: "chmod", From: "a.go"},
+		{Action: "delete", From: "../outside.go"},
+	})
+
+	var actions []string
+	for _, c := range tx.Changes() {
+		actions = append(actions, c.Action()+" "+c.RelPath)
+	}
+	if got := strings.Join(actions, ", "); got != "renamed b.go, renamed a.go, deleted old.go" {
+		t.Fatalf("unexpected changes: %s", got)
+	}
+	if got := renamedPath(tx, "a.go"); got != "b.go" {
+		t.Fatalf("a.go moves to %q", got)
+	}
+}
+
+func TestStageFileOperations_AutoApprove(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "old.go"), []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tx := safeio.NewBackupStore(t.TempDir(), 5).Begin(root)
+
+	// Nothing is read from stdin, so asking would decline the deletion
+	cfg := &config.Config{Edits: config.EditsConfig{AutoApprove: true}}
+	stageFileOperations(cfg, &session.Session{}, tx, []FileOperation{{Action: "delete", From: "old.go"}})
+	if changes := tx.Changes(); len(changes) != 1 || !changes[0].Removed {
+		t.Fatalf("expected the deletion to be staged without asking, got %+v", changes)
+	}
+	if reviewHunks(&config.Config{Edits: config.EditsConfig{Hunks: true, AutoApprove: true}}, &session.Session{}) {
+		t.Fatal("auto-approved changes should not be reviewed hunk by hunk")
+	}
+}
@@ -2,10 +2,24 @@ package modes
 
 import (
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+	"github.com/yourusername/llamasidekick/internal/projects"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/secrets"
+	"github.com/yourusername/llamasidekick/internal/session"
 )
 
 // ReadFilesFromInput detects file references in input and reads their contents.
@@ -17,51 +31,413 @@ func ReadFilesFromInput(input string) string {
 // ReadFilesFromInputWithRoot is like ReadFilesFromInput, but also attempts to resolve
 // file paths relative to projectRoot.
 func ReadFilesFromInputWithRoot(input string, projectRoot string) string {
-	filePattern := regexp.MustCompile(`(?:^|\s)([a-zA-Z0-9_\-./\\]+\.(go|js|ts|py|java|c|cpp|h|rs|rb|php|cs|swift|kt|sh|bash|yml|yaml|json|xml|md|txt))(?:\s|$)`)
-	matches := filePattern.FindAllStringSubmatch(input, -1)
-	
-	if len(matches) == 0 {
-		return input
+	return ReadFilesFromInputWithLimits(input, projectRoot, config.DefaultContextConfig())
+}
+
+// fileReadWorkers bounds how many referenced files are read and scanned for secrets at
+// once, and maxGlobFiles how many files one glob in a prompt loads
+const (
+	fileReadWorkers = 8
+	maxGlobFiles    = 50
+)
+
+const fileExtensions = `go|js|ts|py|java|c|cpp|h|rs|rb|php|cs|swift|kt|sh|bash|yml|yaml|json|xml|md|txt`
+
+// filePattern and globPattern match a word of a prompt that names a file or a glob
+var (
+	filePattern = regexp.MustCompile(`^[a-zA-Z0-9_\-./\\]+\.(` + fileExtensions + `)$`)
+	globPattern = regexp.MustCompile(`^[a-zA-Z0-9_\-./\\*?]*[*?][a-zA-Z0-9_\-./\\*?]*\.(` + fileExtensions + `)$`)
+)
+
+// referencedFile is a file named in a prompt, once it has been read and scanned
+type referencedFile struct {
+	name       string
+	content    []byte
+	err        error
+	redacted   string
+	findings   []secrets.Finding
+	background bool // Loaded by a glob or as an active file rather than named, so it may be compressed
+}
+
+// ReadFilesFromInputWithLimits is like ReadFilesFromInputWithRoot, but applies the configured
+// context limits: ignored files are skipped, large files are truncated and loading stops
+// once the total token budget is used up. Globs like internal/*.go or **/*_test.go load
+// every matching project file. Files are read and scanned concurrently but added in the
+// order the prompt names them, so the budget goes to the first ones.
+func ReadFilesFromInputWithLimits(input string, projectRoot string, limits config.ContextConfig) string {
+	return input + readFileContext(nil, input, nil, projectRoot, limits)
+}
+
+// readFileContext loads the files input names and then the extra ones, and returns their
+// contents as a section to add to the prompt ("" when none was loaded). Files matched by a
+// glob and the extra ones are compressed as context.compress says.
+func readFileContext(client *ollama.Client, input string, extra []string, projectRoot string, limits config.ContextConfig) string {
+	names, background := referencedFileNames(input, extra, projectRoot, limits)
+	if len(names) == 0 {
+		return ""
 	}
+	files := readReferencedFiles(names, projectRoot, limits)
+	comp := newCompressor(client, limits)
 	
 	var fileContents strings.Builder
 	fileContents.WriteString("\n\nFile contents:\n")
 	
-	for _, match := range matches {
-		filename := match[1]
+	usedTokens := 0
+	loaded := 0
+	compressed := 0
+	for _, f := range files {
+		filename := f.name
+		if f.err != nil {
+			fmt.Printf("\033[38;5;240m(Note: Could not read file '%s')\033[0m\n", filename)
+			continue
+		}
 		
-		// Try to read the file from current directory
-		content, err := os.ReadFile(filename)
-		if err != nil {
-			// Try relative to project root
-			if projectRoot != "" {
-				rootPath := filepath.Join(projectRoot, filename)
-				if c2, err2 := os.ReadFile(rootPath); err2 == nil {
-					content = c2
-					err = nil
-				}
+		text, ok := guardFileContent(filename, string(f.content), f.redacted, f.findings, limits)
+		if !ok {
+			continue
+		}
+		how := ""
+		if comp != nil && background[filename] {
+			if short, what, ok := comp.compress(filename, text); ok {
+				text, how = short, what
+				compressed++
 			}
 		}
-		if err != nil {
-			// Try with absolute path
-			absPath, _ := filepath.Abs(filename)
-			content, err = os.ReadFile(absPath)
-			if err != nil {
-				fmt.Printf("\033[38;5;240m(Note: Could not read file '%s')\033[0m\n", filename)
+		truncated := false
+		if limits.MaxFileBytes > 0 && int64(len(text)) > limits.MaxFileBytes {
+			text = strings.ToValidUTF8(text[:limits.MaxFileBytes], "")
+			truncated = true
+		}
+		
+		// Line numbers of a compressed file wouldn't match the file
+		numbered := how == "" && limits.LineNumbers
+		tokens := EstimateTokens(text)
+		if numbered {
+			tokens = EstimateTokens(numberLines(text))
+		}
+		if limits.MaxTotalTokens > 0 && usedTokens+tokens > limits.MaxTotalTokens {
+			remaining := limits.MaxTotalTokens - usedTokens
+			if remaining <= 0 {
+				fmt.Printf("\033[38;5;240m(Note: Skipping '%s' - context.max_total_tokens reached)\033[0m\n", filename)
 				continue
 			}
+			cut := remaining * charsPerToken
+			if numbered {
+				// Leave room for the gutter, in proportion to the whole file's
+				cut = cut * len(text) / len(numberLines(text))
+			}
+			if cut < len(text) {
+				text = strings.ToValidUTF8(text[:cut], "")
+			}
+			tokens = remaining
+			truncated = true
 		}
+		usedTokens += tokens
+		loaded++
 		
-		fileContents.WriteString(fmt.Sprintf("\n--- %s ---\n", filename))
-		fileContents.WriteString(string(content))
+		if truncated {
+			fmt.Printf("\033[38;5;240m(Note: Truncated '%s' to %d of %d bytes to fit context limits)\033[0m\n", filename, len(text), len(f.content))
+		}
+		
+		if how != "" {
+			fileContents.WriteString(fmt.Sprintf("\n--- %s (%s) ---\n", filename, how))
+			fileContents.WriteString(text)
+		} else if numbered {
+			fileContents.WriteString(fmt.Sprintf("\n--- %s (line numbers are for reference and not part of the file) ---\n", filename))
+			fileContents.WriteString(numberLines(text))
+		} else {
+			fileContents.WriteString(fmt.Sprintf("\n--- %s ---\n", filename))
+			fileContents.WriteString(text)
+		}
+		if truncated {
+			fileContents.WriteString("\n... (truncated)")
+		}
 		fileContents.WriteString(fmt.Sprintf("\n--- End of %s ---\n", filename))
 	}
 	
-	if fileContents.Len() > len("\n\nFile contents:\n") {
-		return input + fileContents.String()
+	if loaded == 0 {
+		return ""
+	}
+	if compressed > 0 {
+		fmt.Printf("\033[38;5;240m(Loaded %d file(s), ~%d tokens, %d compressed)\033[0m\n", loaded, usedTokens, compressed)
+	} else {
+		fmt.Printf("\033[38;5;240m(Loaded %d file(s), ~%d tokens)\033[0m\n", loaded, usedTokens)
+	}
+	return fileContents.String()
+}
+
+// referencedFileNames returns the files input names, bare or as @path, and then the extra
+// ones, once each and in order, with globs expanded. Ignored files are left out. background holds the
+// files that weren't named in input itself: glob matches and extra files.
+func referencedFileNames(input string, extra []string, projectRoot string, limits config.ContextConfig) (names []string, background map[string]bool) {
+	seen := map[string]bool{}
+	background = map[string]bool{}
+	words := strings.Fields(input)
+	named := len(words)
+	words = append(words, extra...)
+	for i, word := range words {
+		mention, isMention := fileMention(word, projectRoot)
+		if isMention {
+			word = mention
+		}
+		if isMention || filePattern.MatchString(word) {
+			if seen[word] {
+				continue
+			}
+			seen[word] = true
+			if pathmatch.MatchAny(limits.Ignore, word) {
+				fmt.Printf("\033[38;5;240m(Note: Skipping '%s' - matches context.ignore)\033[0m\n", word)
+				continue
+			}
+			names = append(names, word)
+			background[word] = i >= named
+			continue
+		}
+		if !globPattern.MatchString(word) {
+			continue
+		}
+		
+		matches, more := expandGlob(word, projectRoot, limits.Ignore)
+		if len(matches) == 0 {
+			fmt.Printf("\033[38;5;240m(Note: No files match '%s')\033[0m\n", word)
+		}
+		if more {
+			fmt.Printf("\033[38;5;240m(Note: Only loading the first %d files that match '%s')\033[0m\n", maxGlobFiles, word)
+		}
+		for _, name := range matches {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+				background[name] = true
+			}
+		}
+	}
+	return names, background
+}
+
+// expandGlob returns the project files matching pattern, relative to projectRoot (or the
+// working directory), skipping hidden and ignored directories. As in a shell, a pattern
+// without a "/" only matches files at the top level; "**" matches any number of
+// directories. It reports whether there were more than maxGlobFiles matches.
+func expandGlob(pattern, projectRoot string, ignore []string) ([]string, bool) {
+	root := projectRoot
+	if root == "" {
+		root = "."
 	}
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
 	
-	return input
+	var matches []string
+	more := false
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, p)
+		if relErr != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") || pathmatch.MatchAny(ignore, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if pathmatch.MatchAny(ignore, rel) {
+			return nil
+		}
+		matched := false
+		if strings.Contains(pattern, "/") {
+			matched = pathmatch.Match(pattern, rel)
+		} else if !strings.Contains(rel, "/") {
+			matched, _ = path.Match(pattern, rel)
+		}
+		if !matched {
+			return nil
+		}
+		if len(matches) == maxGlobFiles {
+			more = true
+			return filepath.SkipAll
+		}
+		matches = append(matches, rel)
+		return nil
+	})
+	return matches, more
+}
+
+// readReferencedFiles reads and scans the named files with a bounded pool of workers,
+// and returns them in the order of names
+func readReferencedFiles(names []string, projectRoot string, limits config.ContextConfig) []referencedFile {
+	files := make([]referencedFile, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(fileReadWorkers, len(names)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				f := referencedFile{name: names[i]}
+				f.content, f.err = readReferencedFile(names[i], projectRoot)
+				if f.err == nil && limits.Secrets != "off" {
+					f.redacted, f.findings = secrets.RedactText(string(f.content))
+				}
+				files[i] = f
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return files
+}
+
+// readReferencedFile reads a file named in a prompt: from the working directory, then
+// relative to projectRoot, then as an absolute path
+func readReferencedFile(filename, projectRoot string) ([]byte, error) {
+	paths := []string{filename}
+	if projectRoot != "" {
+		paths = append(paths, filepath.Join(projectRoot, filename))
+	}
+	var firstErr error
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err == nil {
+			promptReads.Record(path, content)
+			return content, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// ReadInputContext is like ReadFilesFromInputWithLimits, but also loads the session's
+// active files (added with "add file to context") that input doesn't already mention,
+// expands the git references @diff, @staged and @status and the environment snapshot
+// @env, and adds the repo map. client
+// summarizes files when context.compress is summarize; without one they are stripped.
+func ReadInputContext(client *ollama.Client, input string, sess *session.Session, limits config.ContextConfig) string {
+	var active []string
+	for _, f := range sess.ActiveFiles {
+		if !strings.Contains(input, f) {
+			active = append(active, f)
+		}
+	}
+	files := readFileContext(client, input, active, sess.ProjectRoot, limits)
+	enhanced := input + files + redactSecrets("the git references", ReadGitReferences(input, sess.ProjectRoot, limits), limits)
+	enhanced += redactSecrets("the environment snapshot", ReadEnvReference(input), limits)
+	if limits.RepoMap {
+		enhanced += RepoMap(sess.ProjectRoot)
+	}
+	return enhanced
+}
+
+// ContextFiles returns the files whose contents prompt includes, in order
+func ContextFiles(prompt string) []string {
+	var files []string
+	for _, line := range strings.Split(prompt, "\n") {
+		if name, ok := strings.CutPrefix(line, "--- End of "); ok && strings.HasSuffix(name, " ---") {
+			files = append(files, strings.TrimSuffix(name, " ---"))
+		}
+	}
+	return files
+}
+
+// numberLines prefixes each line of text with its 1-based line number so the model and
+// the user can refer to exact lines
+func numberLines(text string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	width := len(fmt.Sprint(len(lines)))
+	var b strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%*d | %s", width, i+1, line)
+		if i < len(lines)-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// echoedLineNumber matches the gutter numberLines adds, which a model may copy into the files
+// it writes. A blank line's gutter may have lost its trailing space.
+var echoedLineNumber = regexp.MustCompile(`^\s*(\d+) \|(?: |$)`)
+
+// stripLineNumbers removes the gutter numberLines adds from content a model wrote, when
+// every line has one and the numbers count up by one. Anything else is left as it is.
+func stripLineNumbers(content string) string {
+	lines := strings.Split(content, "\n")
+	last := len(lines)
+	if last > 1 && lines[last-1] == "" {
+		last--
+	}
+	prev := 0
+	for i, line := range lines[:last] {
+		m := echoedLineNumber.FindStringSubmatch(line)
+		if m == nil {
+			return content
+		}
+		n, _ := strconv.Atoi(m[1])
+		if i > 0 && n != prev+1 {
+			return content
+		}
+		prev = n
+		lines[i] = line[len(m[0]):]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// promptReads holds the content of every file read into a prompt, which transactions
+// compare against before writing
+var promptReads = safeio.NewReadLog()
+
+// OpenBackupStore returns the store used to back up files before they are overwritten
+func OpenBackupStore(cfg *config.Config) (*safeio.BackupStore, error) {
+	dir, err := config.BackupDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate backup dir: %w", err)
+	}
+	store := safeio.NewBackupStore(dir, cfg.Backups.Keep)
+	store.Reads = promptReads
+	store.Policy = safeio.WritePolicy{
+		AllowSymlinks:     cfg.Edits.AllowSymlinks,
+		AllowSpecialFiles: cfg.Edits.AllowSpecialFiles,
+		Allow:             cfg.Edits.AllowWrite,
+		Deny:              cfg.Edits.DenyWrite,
+	}
+	// The working directory's project may add patterns of its own
+	reg, err := projects.Load()
+	if err != nil {
+		return nil, err
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if project, ok := reg.Find(cwd); ok {
+			store.Policy.Allow = append(slices.Clone(store.Policy.Allow), project.AllowWrite...)
+			store.Policy.Deny = append(slices.Clone(store.Policy.Deny), project.DenyWrite...)
+		}
+	}
+	trash, err := OpenTrash()
+	if err != nil {
+		return nil, err
+	}
+	// Deleted files always go to the trash, replaced ones only with backups.trash
+	store.RemovedTrash = trash
+	if cfg.Backups.Trash {
+		store.Trash = trash
+	}
+	return store, nil
+}
+
+// OpenTrash returns the trash that keeps deleted files, and replaced file contents when
+// backups.trash is on
+func OpenTrash() (*safeio.Trash, error) {
+	dir, err := config.TrashDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate trash dir: %w", err)
+	}
+	return safeio.NewTrash(dir), nil
 }
 
 // extractAndCreateFiles finds code blocks with FILENAME: prefix and creates the files
@@ -72,7 +448,7 @@ func extractAndCreateFiles(response string) []string {
 	pattern := regexp.MustCompile(`(?i)FILENAME:\s*([^\n]+)\n\s*\x60\x60\x60[^\n]*\n([\s\S]*?)\x60\x60\x60`)
 	matches := pattern.FindAllStringSubmatch(response, -1)
 	
-	fmt.Printf("\n[DEBUG] Checking for files to create... Found %d matches\n", len(matches))
+	slog.Debug("checking for files to create", "matches", len(matches))
 	
 	for _, match := range matches {
 		if len(match) < 3 {
@@ -82,7 +458,7 @@ func extractAndCreateFiles(response string) []string {
 		filename := strings.TrimSpace(match[1])
 		content := match[2]
 		
-		fmt.Printf("[DEBUG] Creating file: %s (%d bytes)\n", filename, len(content))
+		slog.Debug("creating file", "path", filename, "bytes", len(content))
 		
 		// Create directory if needed
 		dir := filepath.Dir(filename)
@@ -0,0 +1,148 @@
+package modes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestReadFilesFromInputWithLimits(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "big_fixture.txt"), []byte(strings.Repeat("a", 100)), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "secret"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secret", "keys_fixture.txt"), []byte("hidden"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	limits := config.ContextConfig{MaxFileBytes: 40, Ignore: []string{"secret/**"}}
+	out := ReadFilesFromInputWithLimits("look at big_fixture.txt and secret/keys_fixture.txt", root, limits)
+
+	if strings.Contains(out, "hidden") {
+		t.Fatalf("ignored file was loaded:\n%s", out)
+	}
+	if !strings.Contains(out, strings.Repeat("a", 40)+"\n... (truncated)") || strings.Contains(out, strings.Repeat("a", 41)) {
+		t.Fatalf("expected file truncated to 40 bytes:\n%s", out)
+	}
+
+	limits = config.ContextConfig{MaxTotalTokens: 5}
+	out = ReadFilesFromInputWithLimits("look at big_fixture.txt", root, limits)
+	if !strings.Contains(out, strings.Repeat("a", 20)+"\n... (truncated)") || strings.Contains(out, strings.Repeat("a", 21)) {
+		t.Fatalf("expected file truncated to the token budget:\n%s", out)
+	}
+}
+
+func TestReadFilesFromInputWithLimits_LineNumbers(t *testing.T) {
+	root := t.TempDir()
+	lines := make([]string, 12)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	if err := os.WriteFile(filepath.Join(root, "numbered_fixture.txt"), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	out := ReadFilesFromInputWithLimits("see numbered_fixture.txt", root, config.ContextConfig{LineNumbers: true})
+	if !strings.Contains(out, " 1 | line\n") || !strings.Contains(out, "12 | line\n--- End of") {
+		t.Fatalf("expected numbered lines:\n%s", out)
+	}
+
+	// The gutter counts against the budget: the file alone is 15 tokens, numbered it is 30
+	out = ReadFilesFromInputWithLimits("see numbered_fixture.txt", root, config.ContextConfig{LineNumbers: true, MaxTotalTokens: 20})
+	if !strings.Contains(out, "... (truncated)") || strings.Contains(out, "12 | line") {
+		t.Fatalf("expected the numbered file truncated to the budget:\n%s", out)
+	}
+}
+
+func TestStripLineNumbers(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"echoed gutter", " 9 | package main\n10 |\n11 | func main() {}\n", "package main\n\nfunc main() {}\n"},
+		{"no gutter", "package main\n", "package main\n"},
+		{"not every line", "1 | a\nb\n", "1 | a\nb\n"},
+		{"numbers don't count up", "1 | a\n3 | b\n", "1 | a\n3 | b\n"},
+		{"table", "| a | b |\n|---|---|\n", "| a | b |\n|---|---|\n"},
+	}
+	for _, tt := range tests {
+		if got := stripLineNumbers(tt.content); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	files, err := ParseGeneratedFilesJSON(`[{"filename": "main.go", "content": "1 | package main\n2 | \n"}]`)
+	if err != nil || files[0].Content != "package main\n\n" {
+		t.Fatalf("expected the gutter removed from generated files, got %+v, %v", files, err)
+	}
+}
+
+func TestReadFilesFromInputWithLimits_Globs(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"main.go", "util.go", "internal/api/server.go", "internal/api/routes.go", "vendor/lib/lib.go", ".git/hooks/x.go"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte("// "+name), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	limits := config.ContextConfig{Ignore: []string{"vendor"}}
+
+	out := ReadFilesFromInputWithLimits("compare util.go main.go with **/*.go", root, limits)
+	var order []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "--- ") && !strings.HasPrefix(line, "--- End") {
+			order = append(order, strings.TrimSuffix(strings.TrimPrefix(line, "--- "), " ---"))
+		}
+	}
+	want := "[util.go main.go internal/api/routes.go internal/api/server.go]"
+	if fmt.Sprint(order) != want {
+		t.Fatalf("loaded %v, want %s:\n%s", order, want, out)
+	}
+
+	// Without a "/" a glob only matches files at the top level, like in a shell
+	out = ReadFilesFromInputWithLimits("explain *.go", root, limits)
+	if !strings.Contains(out, "// main.go") || strings.Contains(out, "server.go") {
+		t.Fatalf("expected only the top-level files:\n%s", out)
+	}
+}
+
+func TestReadInputContextIncludesActiveFiles(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "notes_fixture.md"), []byte("remember this"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	sess := session.New(root)
+	sess.AddFile("notes_fixture.md")
+
+	out := ReadInputContext(nil, "what should I remember?", sess, config.DefaultContextConfig())
+	if !strings.HasPrefix(out, "what should I remember?\n\nFile contents:") || !strings.Contains(out, "remember this") {
+		t.Fatalf("active file not loaded:\n%s", out)
+	}
+}
+
+func TestContextFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a_fixture.go", "b_fixture.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package a\n"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	limits := config.DefaultContextConfig()
+	limits.LineNumbers = true
+
+	out := ReadFilesFromInputWithLimits("compare b_fixture.go with a_fixture.go", root, limits)
+	if got := fmt.Sprint(ContextFiles(out)); got != "[b_fixture.go a_fixture.go]" {
+		t.Fatalf("ContextFiles = %s\n%s", got, out)
+	}
+	if got := ContextFiles("no files here"); got != nil {
+		t.Fatalf("ContextFiles without files = %v", got)
+	}
+}
@@ -0,0 +1,248 @@
+package modes
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/renderer"
+	"github.com/yourusername/llamasidekick/internal/session"
+	"github.com/yourusername/llamasidekick/internal/testrunner"
+)
+
+// buildContextLines is how many lines around each error are shown to the model
+const buildContextLines = 10
+
+const fixBuildSystemPrompt = `You fix build errors with small, targeted patches. You MUST respond with ONLY a valid JSON array of patch objects. No markdown, no explanations, no extra text.
+
+Each object must have exactly these fields:
+- "filename": string (the file path, as shown above the code)
+- "start_line": number (first line to replace, from the line numbers shown)
+- "end_line": number (last line to replace, inclusive)
+- "replacement": string (the new text for those lines, without line numbers; empty to delete them)
+
+Only replace the lines needed to fix the errors. Patches must not overlap.`
+
+// buildPatch replaces lines StartLine..EndLine (1-based, inclusive) of a file
+type buildPatch struct {
+	Filename    string `json:"filename"`
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	Replacement string `json:"replacement"`
+}
+
+// lineRegion is a range of lines (1-based, inclusive) in a project file
+type lineRegion struct {
+	start, end int
+}
+
+// FixBuild runs the build command and, while it fails, shows the model the code around
+// each file:line error and applies the line patches it proposes, printing the diff of
+// every round. It stops when the build succeeds, a patch isn't applied, or after
+// build.max_iterations rounds.
+func FixBuild(client *ollama.Client, sess *session.Session, cfg *config.Config, command string) error {
+	if cfg.Edits.ReadOnly {
+		return fmt.Errorf("read-only mode: running %s is disabled", command)
+	}
+	loop := fixLoop{
+		request:   fmt.Sprintf("Fix the build (%s)", command),
+		command:   command,
+		maxRounds: cfg.Build.MaxIterations,
+		limitKey:  "build.max_iterations",
+		cfg:       cfg,
+		okText:    "Build succeeds",
+		failText:  "Build still fails",
+		parse:     testrunner.ParseBuildErrors,
+		fix: func(output string, failures []testrunner.Failure) (bool, error) {
+			return requestBuildFix(client, sess, cfg, command, output, failures)
+		},
+	}
+	return loop.run(sess)
+}
+
+// requestBuildFix asks the model for patches to the regions around failures and applies
+// them. It returns whether anything was written.
+func requestBuildFix(client *ollama.Client, sess *session.Session, cfg *config.Config, command, output string, failures []testrunner.Failure) (bool, error) {
+	regions, contents := errorRegions(sess.ProjectRoot, failures, cfg.Context.Ignore)
+	if len(regions) == 0 {
+		return false, errUnlocatedFailures
+	}
+	files := make([]string, 0, len(regions))
+	for path := range regions {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	fmt.Printf("\033[38;5;240mAsking for patches to %s\033[0m\n", strings.Join(files, ", "))
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "The build command `%s` fails.\n\nErrors:\n", command)
+	for _, f := range failures {
+		fmt.Fprintf(&prompt, "- %s\n", f)
+	}
+	prompt.WriteString("\nCode around the errors (line numbers are for reference and not part of the file):\n")
+	for _, path := range files {
+		shown := redactSecrets("'"+path+"'", contents[path], cfg.Context)
+		for _, r := range regions[path] {
+			fmt.Fprintf(&prompt, "\n--- %s lines %d-%d ---\n%s\n--- End of %s lines %d-%d ---\n", path, r.start, r.end, numberRegion(shown, r), path, r.start, r.end)
+		}
+	}
+	fmt.Fprintf(&prompt, "\nEnd of the build output:\n```\n%s\n```\n", tail(output, maxFixOutputBytes))
+
+	s := progress.NewStatus("Working on patches")
+	s.Start()
+	jsonResponse, err := client.GenerateJSON(cfg.GetModelForMode(ModeEdit), prompt.String(), fixBuildSystemPrompt, 0.2)
+	s.Stop()
+	if err != nil {
+		return false, fmt.Errorf("error generating patches: %w", err)
+	}
+	patches, err := parseBuildPatches(jsonResponse)
+	if err != nil {
+		return false, fmt.Errorf("error parsing patches: %w\nResponse was: %s", err, jsonResponse)
+	}
+	slog.Debug("parsed build patches", "count", len(patches))
+
+	backups, err := OpenBackupStore(cfg)
+	if err != nil {
+		return false, err
+	}
+	tx := backups.Begin(sess.ProjectRoot)
+	for path, filePatches := range groupPatches(patches) {
+		content, ok := contents[path]
+		if !ok {
+			fmt.Printf("\033[38;5;9mIgnoring patch to '%s' - it has no build errors\033[0m\n", path)
+			continue
+		}
+		patched, err := applyLinePatches(content, filePatches)
+		if err != nil {
+			fmt.Printf("\033[38;5;9mIgnoring patches to '%s': %v\033[0m\n", path, err)
+			continue
+		}
+		if err := tx.Stage(path, []byte(patched)); err != nil {
+			fmt.Printf("\033[38;5;9mRefusing to write '%s': %v\033[0m\n", path, err)
+		}
+	}
+	if len(tx.Changes()) == 0 {
+		fmt.Println("\033[38;5;214mThe model proposed no usable patches\033[0m")
+		return false, nil
+	}
+	if !cfg.Edits.DryRun && !cfg.Edits.ReadOnly && sess.ApproveChanges == nil {
+		// Dry-run, read-only mode and approval hooks show the diff themselves
+		fmt.Print(renderer.RenderDiff(tx.Diff(), renderer.DiffOptions{WordLevel: cfg.UI.WordDiff, LineNumbers: cfg.UI.LineNumbers}))
+	}
+	written, err := applyTransaction(cfg, sess, tx, "Fix build errors")
+	if err != nil {
+		return false, fmt.Errorf("error writing files: %w", err)
+	}
+	return written, nil
+}
+
+// errorRegions returns the merged line regions around the located failures per project
+// file, and the current content of those files
+func errorRegions(root string, failures []testrunner.Failure, ignore []string) (map[string][]lineRegion, map[string]string) {
+	resolver := &fileResolver{root: root, ignore: ignore}
+	regions := map[string][]lineRegion{}
+	contents := map[string]string{}
+	for _, f := range failures {
+		if f.File == "" || f.Line < 1 {
+			continue
+		}
+		for _, path := range resolver.resolve(f.File) {
+			if _, ok := contents[path]; !ok {
+				if len(contents) == maxFixFiles {
+					continue
+				}
+				data, err := os.ReadFile(filepath.Join(root, path))
+				if err != nil {
+					continue
+				}
+				promptReads.Record(filepath.Join(root, path), data)
+				contents[path] = string(data)
+			}
+			lines := strings.Count(strings.TrimSuffix(contents[path], "\n"), "\n") + 1
+			r := lineRegion{start: max(1, f.Line-buildContextLines), end: min(lines, f.Line+buildContextLines)}
+			if r.start <= r.end {
+				regions[path] = append(regions[path], r)
+			}
+		}
+	}
+	for path, rs := range regions {
+		regions[path] = mergeRegions(rs)
+	}
+	return regions, contents
+}
+
+// mergeRegions sorts regions and joins the ones that overlap or touch
+func mergeRegions(regions []lineRegion) []lineRegion {
+	sort.Slice(regions, func(i, j int) bool { return regions[i].start < regions[j].start })
+	var merged []lineRegion
+	for _, r := range regions {
+		if n := len(merged); n > 0 && r.start <= merged[n-1].end+1 {
+			merged[n-1].end = max(merged[n-1].end, r.end)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// numberRegion returns the lines of r in text, prefixed with their line numbers
+func numberRegion(text string, r lineRegion) string {
+	selected, err := SelectLines(text, r.start, r.end)
+	if err != nil {
+		return ""
+	}
+	return numberLinesFrom(selected, r.start, r.end)
+}
+
+// parseBuildPatches parses a JSON array of patches or a single patch object
+func parseBuildPatches(jsonResponse string) ([]buildPatch, error) {
+	var patches []buildPatch
+	if err := json.Unmarshal([]byte(jsonResponse), &patches); err == nil {
+		return patches, nil
+	}
+	var single buildPatch
+	if err := json.Unmarshal([]byte(jsonResponse), &single); err != nil {
+		return nil, invalidJSON(fmt.Errorf("invalid JSON for patches"))
+	}
+	return []buildPatch{single}, nil
+}
+
+// groupPatches groups patches by their cleaned file path
+func groupPatches(patches []buildPatch) map[string][]buildPatch {
+	grouped := map[string][]buildPatch{}
+	for _, p := range patches {
+		path := filepath.ToSlash(filepath.Clean(strings.TrimPrefix(p.Filename, "./")))
+		grouped[path] = append(grouped[path], p)
+	}
+	return grouped
+}
+
+// applyLinePatches applies non-overlapping line patches to text, last one first so the
+// line numbers of the others stay valid
+func applyLinePatches(text string, patches []buildPatch) (string, error) {
+	sort.Slice(patches, func(i, j int) bool { return patches[i].StartLine > patches[j].StartLine })
+	for i := 1; i < len(patches); i++ {
+		if patches[i].EndLine >= patches[i-1].StartLine {
+			return "", fmt.Errorf("patches for lines %d-%d and %d-%d overlap", patches[i].StartLine, patches[i].EndLine, patches[i-1].StartLine, patches[i-1].EndLine)
+		}
+	}
+	for _, p := range patches {
+		before, selected, after, err := splitLineRange(text, p.StartLine, p.EndLine)
+		if err != nil {
+			return "", err
+		}
+		replacement := p.Replacement
+		if strings.HasSuffix(selected, "\n") && replacement != "" && !strings.HasSuffix(replacement, "\n") {
+			replacement += "\n"
+		}
+		text = before + replacement + after
+	}
+	return text, nil
+}
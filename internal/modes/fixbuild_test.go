@@ -0,0 +1,56 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/testrunner"
+)
+
+func TestApplyLinePatches(t *testing.T) {
+	text := "one\ntwo\nthree\nfour\nfive\n"
+	got, err := applyLinePatches(text, []buildPatch{
+		{StartLine: 2, EndLine: 2, Replacement: "TWO"},
+		{StartLine: 4, EndLine: 5, Replacement: "FOUR\n"},
+		{StartLine: 1, EndLine: 1, Replacement: ""},
+	})
+	if err != nil {
+		t.Fatalf("applyLinePatches: %v", err)
+	}
+	if got != "TWO\nthree\nFOUR\n" {
+		t.Fatalf("unexpected result %q", got)
+	}
+
+	if _, err := applyLinePatches(text, []buildPatch{{StartLine: 1, EndLine: 3}, {StartLine: 3, EndLine: 4}}); err == nil {
+		t.Fatal("expected overlapping patches to be refused")
+	}
+	if _, err := applyLinePatches(text, []buildPatch{{StartLine: 5, EndLine: 9}}); err == nil {
+		t.Fatal("expected an out of range patch to be refused")
+	}
+}
+
+func TestErrorRegions(t *testing.T) {
+	root := t.TempDir()
+	lines := make([]string, 40)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	failures := []testrunner.Failure{{File: "main.go", Line: 5}, {File: "main.go", Line: 12}, {File: "main.go", Line: 38}, {File: "gone.go", Line: 1}}
+	regions, contents := errorRegions(root, failures, nil)
+	want := map[string][]lineRegion{"main.go": {{1, 22}, {28, 40}}}
+	if !reflect.DeepEqual(regions, want) {
+		t.Fatalf("errorRegions = %v, want %v", regions, want)
+	}
+	if _, ok := contents["main.go"]; !ok || len(contents) != 1 {
+		t.Fatalf("unexpected contents for %v", contents)
+	}
+	if got := numberRegion("a\nb\nc\n", lineRegion{2, 3}); got != "2 | b\n3 | c" {
+		t.Fatalf("numberRegion = %q", got)
+	}
+}
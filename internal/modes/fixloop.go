@@ -0,0 +1,153 @@
+package modes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/session"
+	"github.com/yourusername/llamasidekick/internal/testrunner"
+)
+
+// maxShownFailures is how many failures are listed after each run
+const maxShownFailures = 10
+
+// errUnlocatedFailures is returned by a fixLoop's fix when the failures don't name files
+// in the project
+var errUnlocatedFailures = errors.New("could not tell which files the failures are in")
+
+// fixLoop runs a command and, while it fails, lets fix change the project and runs the
+// command again. The conversation records the request and how it ended.
+type fixLoop struct {
+	request   string // Added to the conversation as the user's message
+	command   string
+	maxRounds int            // Fixes tried before giving up
+	limitKey  string         // Config key of maxRounds, named when giving up
+	okText    string         // e.g. "Tests pass"
+	failText  string         // e.g. "Tests still fail"
+	cfg       *config.Config // Its pre_command and post_command hooks run around every run of command
+	parse     func(output string) []testrunner.Failure
+	// fix tries to repair the failures and reports whether changes were written
+	fix func(output string, failures []testrunner.Failure) (bool, error)
+}
+
+func (l fixLoop) run(sess *session.Session) error {
+	maxRounds := l.maxRounds
+	if maxRounds < 1 {
+		maxRounds = 1
+	}
+	sess.AddMessage("user", l.request)
+	outcome := ""
+	defer func() {
+		sess.AddMessage("assistant", outcome)
+		if err := sess.Save(); err != nil {
+			fmt.Printf("Warning: failed to save session: %v\n", err)
+		}
+	}()
+
+	for round := 0; ; round++ {
+		result, err := l.runCommand(sess.ProjectRoot)
+		if err != nil {
+			outcome = err.Error()
+			return err
+		}
+		if result.Passed {
+			if round == 0 {
+				outcome = l.okText
+				fmt.Printf("\033[1;32m✓ %s\033[0m \033[38;5;240m(%s)\033[0m\n\n", l.okText, result.Duration.Round(time.Millisecond))
+			} else {
+				outcome = fmt.Sprintf("%s after %d fix(es)", l.okText, round)
+				fmt.Printf("\033[1;32m✓ %s\033[0m\n\n", outcome)
+			}
+			return nil
+		}
+
+		failures := l.parse(result.Output)
+		printFailures(failures, result.Output)
+		if round == maxRounds {
+			outcome = fmt.Sprintf("%s after %d fix(es)", l.failText, round)
+			fmt.Printf("\033[38;5;214m%s - stopping (%s)\033[0m\n\n", outcome, l.limitKey)
+			return nil
+		}
+
+		fmt.Printf("\033[38;5;75mFix %d/%d\033[0m\n", round+1, maxRounds)
+		written, err := l.fix(result.Output, failures)
+		if errors.Is(err, errUnlocatedFailures) {
+			outcome = "Could not tell which files the failures are in"
+			fmt.Println("\033[38;5;214mCould not tell which files the failures are in - fix them by hand or name the files in Edit mode\033[0m")
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			outcome = err.Error()
+			return err
+		}
+		if !written {
+			outcome = "Stopped because the proposed changes were not written"
+			fmt.Println()
+			return nil
+		}
+	}
+}
+
+// runCommand runs the loop's command with the pre_command and post_command hooks
+func (l fixLoop) runCommand(root string) (testrunner.Result, error) {
+	vars := map[string]string{"command": l.command}
+	if err := runHooks(l.cfg, root, "pre_command", l.cfg.Hooks.PreCommand, vars); err != nil {
+		return testrunner.Result{}, err
+	}
+	result, err := runWithSpinner(root, l.command)
+	if err != nil {
+		return result, err
+	}
+	vars["status"] = "failed"
+	if result.Passed {
+		vars["status"] = "passed"
+	}
+	if err := runHooks(l.cfg, root, "post_command", l.cfg.Hooks.PostCommand, vars); err != nil {
+		fmt.Printf("\033[38;5;214mWarning: %v\033[0m\n", err)
+	}
+	return result, nil
+}
+
+func runWithSpinner(root, command string) (testrunner.Result, error) {
+	s := progress.NewSpinner()
+	s.Suffix = " Running " + command + "..."
+	s.Start()
+	result, err := testrunner.Run(root, command)
+	s.Stop()
+	return result, err
+}
+
+// printFailures lists the parsed failures, or the end of the output when none were found
+func printFailures(failures []testrunner.Failure, output string) {
+	if len(failures) == 0 {
+		fmt.Println("\033[38;5;9m✗ Tests failed\033[0m")
+		fmt.Printf("\033[38;5;240m%s\033[0m\n", tail(output, 2000))
+		return
+	}
+	fmt.Printf("\033[38;5;9m✗ %d failure(s)\033[0m\n", len(failures))
+	for i, f := range failures {
+		if i == maxShownFailures {
+			fmt.Printf("\033[38;5;240m  ... and %d more\033[0m\n", len(failures)-i)
+			break
+		}
+		fmt.Printf("\033[38;5;240m  %s\033[0m\n", f)
+	}
+}
+
+// tail returns the last max bytes of text, starting at a line boundary
+func tail(text string, max int) string {
+	text = strings.TrimRight(text, "\n")
+	if len(text) <= max {
+		return text
+	}
+	text = text[len(text)-max:]
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[i+1:]
+	}
+	return "...\n" + strings.ToValidUTF8(text, "")
+}
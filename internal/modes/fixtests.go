@@ -0,0 +1,204 @@
+package modes
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/session"
+	"github.com/yourusername/llamasidekick/internal/testrunner"
+)
+
+const (
+	// maxFixFiles bounds how many implicated files are loaded into a fix prompt
+	maxFixFiles = 8
+	// maxFixOutputBytes is how much of the end of the test output goes into a fix prompt
+	maxFixOutputBytes = 8 * 1024
+)
+
+const fixTestsSystemPrompt = `You fix failing tests. You MUST respond with ONLY a valid JSON array of file objects. No markdown, no explanations, no extra text.
+
+Each object must have exactly these fields:
+- "filename": string (the file path, as shown in the file contents)
+- "content": string (the COMPLETE new content of the file)
+
+Only include files you change. Fix the code under test; change a test only when the test itself is wrong.`
+
+// FixTests runs the test command and, while it fails, loads the files implicated by the
+// failures and asks the model for fixes, which go through the usual approval and backups.
+// It stops when the tests pass, a fix isn't applied, or after test.max_iterations fixes.
+func FixTests(client *ollama.Client, sess *session.Session, cfg *config.Config, command string) error {
+	if cfg.Edits.ReadOnly {
+		return fmt.Errorf("read-only mode: running %s is disabled", command)
+	}
+	loop := fixLoop{
+		request:   fmt.Sprintf("Fix the failing tests (%s)", command),
+		command:   command,
+		maxRounds: cfg.Test.MaxIterations,
+		limitKey:  "test.max_iterations",
+		cfg:       cfg,
+		okText:    "Tests pass",
+		failText:  "Tests still fail",
+		parse:     testrunner.ParseFailures,
+		fix: func(output string, failures []testrunner.Failure) (bool, error) {
+			files := implicatedFiles(sess.ProjectRoot, failures, cfg.Context.Ignore)
+			if len(files) == 0 {
+				return false, errUnlocatedFailures
+			}
+			fmt.Printf("\033[38;5;240mAsking for changes to %s\033[0m\n", strings.Join(files, ", "))
+			return requestTestFix(client, sess, cfg, command, output, failures, files)
+		},
+	}
+	return loop.run(sess)
+}
+
+// requestTestFix asks the model to fix files for the failures and applies its changes.
+// It returns whether anything was written.
+func requestTestFix(client *ollama.Client, sess *session.Session, cfg *config.Config, command, output string, failures []testrunner.Failure, files []string) (bool, error) {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "The test command `%s` fails.\n\nFailures:\n", command)
+	for _, f := range failures {
+		fmt.Fprintf(&prompt, "- %s\n", f)
+	}
+	fmt.Fprintf(&prompt, "\nEnd of the test output:\n```\n%s\n```\n\nRelevant files: %s\n", tail(output, maxFixOutputBytes), strings.Join(files, " "))
+	fullPrompt := ReadFilesFromInputWithLimits(prompt.String(), sess.ProjectRoot, cfg.Context)
+
+	s := progress.NewStatus("Working on a fix")
+	s.Start()
+	jsonResponse, err := client.GenerateJSON(cfg.GetModelForMode(ModeEdit), fullPrompt, fixTestsSystemPrompt, 0.3)
+	s.Stop()
+	if err != nil {
+		return false, fmt.Errorf("error generating fix: %w", err)
+	}
+	generated, err := ParseGeneratedFilesJSON(jsonResponse)
+	if err != nil {
+		return false, fmt.Errorf("error parsing fix: %w\nResponse was: %s", err, jsonResponse)
+	}
+
+	backups, err := OpenBackupStore(cfg)
+	if err != nil {
+		return false, err
+	}
+	tx := backups.Begin(sess.ProjectRoot)
+	for _, file := range generated {
+		if err := tx.Stage(file.Filename, []byte(file.Content)); err != nil {
+			fmt.Printf("\033[38;5;9mRefusing to write '%s': %v\033[0m\n", file.Filename, err)
+		}
+	}
+	if len(tx.Changes()) == 0 {
+		fmt.Println("\033[38;5;214mThe model proposed no changes\033[0m")
+		return false, nil
+	}
+	written, err := applyTransaction(cfg, sess, tx, "Fix failing tests")
+	if err != nil {
+		return false, fmt.Errorf("error writing files: %w", err)
+	}
+	return written, nil
+}
+
+// implicatedFiles resolves the files named in failures to project paths and adds the
+// code under test next to each test file
+func implicatedFiles(root string, failures []testrunner.Failure, ignore []string) []string {
+	resolver := &fileResolver{root: root, ignore: ignore}
+	var files []string
+	seen := map[string]bool{}
+	add := func(paths []string) {
+		for _, p := range paths {
+			if !seen[p] && len(files) < maxFixFiles {
+				seen[p] = true
+				files = append(files, p)
+			}
+		}
+	}
+	for _, f := range failures {
+		if f.File == "" {
+			continue
+		}
+		paths := resolver.resolve(f.File)
+		add(paths)
+		for _, p := range paths {
+			if source := testedSource(p); source != "" {
+				add(resolver.resolve(source))
+			}
+		}
+	}
+	return files
+}
+
+// fileResolver maps file names printed by tools to project paths. Tools often print
+// paths relative to a package directory, so names that don't exist at the root are
+// looked up by base name.
+type fileResolver struct {
+	root   string
+	ignore []string
+	index  map[string][]string // Base name to project paths, built on first use
+}
+
+// resolve returns the project paths name may refer to, leaving out ignored files
+func (r *fileResolver) resolve(name string) []string {
+	var paths []string
+	switch {
+	case filepath.IsAbs(name):
+		if rel, err := filepath.Rel(r.root, name); err == nil && !strings.HasPrefix(rel, "..") && fileExists(name) {
+			paths = []string{filepath.ToSlash(rel)}
+		}
+	case !strings.HasPrefix(filepath.Clean(name), "..") && fileExists(filepath.Join(r.root, name)):
+		paths = []string{filepath.ToSlash(filepath.Clean(name))}
+	default:
+		if r.index == nil {
+			r.index = indexProjectFiles(r.root, r.ignore)
+		}
+		paths = r.index[filepath.Base(name)]
+	}
+	var kept []string
+	for _, p := range paths {
+		if !pathmatch.MatchAny(r.ignore, p) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// testedSource returns the file a test file tests, by naming convention: a_test.go is in
+// the same directory as a.go; a Python module is looked up by name
+func testedSource(testPath string) string {
+	dir, name := filepath.Split(testPath)
+	switch {
+	case strings.HasSuffix(name, "_test.go"):
+		return dir + strings.TrimSuffix(name, "_test.go") + ".go"
+	case strings.HasPrefix(name, "test_") && strings.HasSuffix(name, ".py"):
+		return strings.TrimPrefix(name, "test_")
+	case strings.HasSuffix(name, "_test.py"):
+		return strings.TrimSuffix(name, "_test.py") + ".py"
+	}
+	return ""
+}
+
+// indexProjectFiles maps the base name of every file in root to its project paths
+func indexProjectFiles(root string, ignore []string) map[string][]string {
+	index := map[string][]string{}
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" || pathmatch.MatchAny(ignore, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		index[d.Name()] = append(index[d.Name()], rel)
+		return nil
+	})
+	return index
+}
@@ -0,0 +1,43 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/testrunner"
+)
+
+func TestImplicatedFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"pkg/math.go", "pkg/math_test.go", "src/calc.py", "tests/test_calc.py", "node_modules/x/math_test.go"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	failures := []testrunner.Failure{
+		{Test: "TestAdd", File: "math_test.go", Line: 3}, // go test prints paths relative to the package
+		{Test: "tests/test_calc.py::test_add", File: "tests/test_calc.py"},
+		{Test: "TestOther"},
+		{File: "missing.go", Line: 1},
+	}
+	got := implicatedFiles(root, failures, []string{"node_modules"})
+	want := []string{"pkg/math_test.go", "pkg/math.go", "tests/test_calc.py", "src/calc.py"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("implicatedFiles = %v, want %v", got, want)
+	}
+}
+
+func TestTail(t *testing.T) {
+	if got := tail("a\nb\nc\n", 10); got != "a\nb\nc" {
+		t.Fatalf("tail = %q", got)
+	}
+	if got := tail("first line\nsecond\nthird", 10); got != "...\nthird" {
+		t.Fatalf("tail = %q", got)
+	}
+}
@@ -0,0 +1,103 @@
+package modes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+// codeBlockPattern matches a fenced code block and its language
+var codeBlockPattern = regexp.MustCompile("(?s)```([A-Za-z0-9_+#.-]*)[^\n]*\n(.*?)```")
+
+// shellLanguages are the code block languages whose contents can be run with /run
+var shellLanguages = map[string]bool{"bash": true, "sh": true, "shell": true, "zsh": true, "console": true, "powershell": true, "ps1": true}
+
+// CodeBlock is a fenced code block of a response
+type CodeBlock struct {
+	Lang string
+	Code string
+}
+
+// CodeBlocks returns the fenced code blocks of response in order
+func CodeBlocks(response string) []CodeBlock {
+	var blocks []CodeBlock
+	for _, m := range codeBlockPattern.FindAllStringSubmatch(response, -1) {
+		if code := strings.TrimRight(m[2], "\n"); strings.TrimSpace(code) != "" {
+			blocks = append(blocks, CodeBlock{Lang: strings.ToLower(m[1]), Code: code})
+		}
+	}
+	return blocks
+}
+
+// ShellCommands returns the contents of the shell code blocks of response, with any
+// leading "$ " prompts removed. In Cmd mode, whose answers are commands, a block
+// without a language counts too.
+func ShellCommands(mode, response string) []string {
+	var commands []string
+	for _, b := range CodeBlocks(response) {
+		if !shellLanguages[b.Lang] && !(b.Lang == "" && mode == ModeCmd) {
+			continue
+		}
+		lines := strings.Split(b.Code, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimPrefix(line, "$ ")
+		}
+		commands = append(commands, strings.Join(lines, "\n"))
+	}
+	if len(commands) == 0 && mode == ModeCmd && !strings.Contains(response, "```") {
+		if command := strings.TrimSpace(response); command != "" {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// WritesFiles reports whether running mode can change the project's files, so running its
+// input again isn't a harmless regeneration
+func WritesFiles(cfg *config.Config, mode string) bool {
+	switch mode {
+	case ModeEdit, ModeAgent:
+		return true
+	case ModePlan, ModeAsk, ModeCmd:
+		return false
+	}
+	custom, ok := cfg.CustomMode(mode)
+	return !ok || custom.Output == "files"
+}
+
+// FollowUps returns the follow-up commands that apply to response, an answer given in mode
+func FollowUps(cfg *config.Config, mode, response string) []string {
+	var actions []string
+	blocks := CodeBlocks(response)
+	if len(blocks) > 0 && !WritesFiles(cfg, mode) && mode != ModeCmd {
+		actions = append(actions, "/apply")
+	}
+	if len(blocks) > 0 {
+		actions = append(actions, "/copy")
+	}
+	if len(ShellCommands(mode, response)) > 0 {
+		actions = append(actions, "/run")
+	}
+	actions = append(actions, "/more")
+	if !WritesFiles(cfg, mode) {
+		actions = append(actions, "/regen")
+	}
+	return actions
+}
+
+// ApplyInput returns the Edit mode instruction that applies the changes suggested in
+// response, to file or else to the first file the response names
+func ApplyInput(response, file string) (string, error) {
+	for _, word := range strings.Fields(response) {
+		if file != "" {
+			break
+		}
+		file = detectFileInInput(strings.Trim(word, "`*'\"()[],:;."))
+	}
+	if file == "" {
+		return "", fmt.Errorf("the last answer doesn't name a file; use /apply <file>")
+	}
+	return fmt.Sprintf("Apply the changes suggested in your previous answer to %s", file), nil
+}
@@ -0,0 +1,55 @@
+package modes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+const followUpResponse = "Change `internal/app/main.go` like this:\n\n" +
+	"```go\nfunc main() {}\n```\n\n" +
+	"Then check it:\n\n```bash\n$ go build ./...\n$ go test ./...\n```\n"
+
+func TestCodeBlocksAndShellCommands(t *testing.T) {
+	blocks := CodeBlocks(followUpResponse)
+	if len(blocks) != 2 || blocks[0].Lang != "go" || blocks[0].Code != "func main() {}" {
+		t.Fatalf("unexpected code blocks: %+v", blocks)
+	}
+	commands := ShellCommands(ModeAsk, followUpResponse)
+	if len(commands) != 1 || commands[0] != "go build ./...\ngo test ./..." {
+		t.Fatalf("unexpected commands: %q", commands)
+	}
+	if commands := ShellCommands(ModeCmd, "git status -sb"); len(commands) != 1 || commands[0] != "git status -sb" {
+		t.Fatalf("expected a plain Cmd mode answer to be its command, got %q", commands)
+	}
+	if commands := ShellCommands(ModeAsk, "```\nmake\n```"); len(commands) != 0 {
+		t.Fatalf("expected an unlabelled block outside Cmd mode not to be a command, got %q", commands)
+	}
+}
+
+func TestFollowUps(t *testing.T) {
+	cfg := &config.Config{CustomModes: []config.CustomModeConfig{{Name: "scaffold", Output: "files"}}}
+	if got := strings.Join(FollowUps(cfg, ModeAsk, followUpResponse), " "); got != "/apply /copy /run /more /regen" {
+		t.Fatalf("unexpected follow-ups for an answer: %s", got)
+	}
+	if got := strings.Join(FollowUps(cfg, ModeEdit, "Updated main.go"), " "); got != "/more" {
+		t.Fatalf("unexpected follow-ups for an edit: %s", got)
+	}
+	if got := strings.Join(FollowUps(cfg, "scaffold", followUpResponse), " "); got != "/copy /run /more" {
+		t.Fatalf("unexpected follow-ups for a files mode: %s", got)
+	}
+}
+
+func TestApplyInput(t *testing.T) {
+	input, err := ApplyInput(followUpResponse, "")
+	if err != nil || !strings.HasSuffix(input, " to internal/app/main.go") {
+		t.Fatalf("expected the named file to be edited, got %q, %v", input, err)
+	}
+	if input, _ := ApplyInput(followUpResponse, "cmd/other.go"); !strings.HasSuffix(input, " to cmd/other.go") {
+		t.Fatalf("expected the given file to win, got %q", input)
+	}
+	if _, err := ApplyInput("Use a loop.", ""); err == nil {
+		t.Fatal("expected an error when no file is named")
+	}
+}
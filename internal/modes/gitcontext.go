@@ -0,0 +1,77 @@
+package modes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/gitutil"
+)
+
+// gitReferencePattern matches @diff, @staged and @status written as separate words
+var gitReferencePattern = regexp.MustCompile(`(?:^|\s)@(diff|staged|status)\b`)
+
+// gitReference describes what a git reference in a prompt expands to
+type gitReference struct {
+	title string
+	empty string
+	load  func(dir string) (string, error)
+}
+
+var gitReferences = map[string]gitReference{
+	"diff":   {title: "git diff (unstaged changes)", empty: "(no unstaged changes)", load: gitutil.Diff},
+	"staged": {title: "git diff --cached (staged changes)", empty: "(nothing staged)", load: gitutil.StagedDiff},
+	"status": {title: "git status", empty: "(clean)", load: gitutil.Status},
+}
+
+// ReadGitReferences expands @diff, @staged and @status in input into a block of git
+// output for the prompt, headed by the current branch. It returns "" when input has no
+// git references. Each output is truncated to the context's max_file_bytes.
+func ReadGitReferences(input, projectRoot string, limits config.ContextConfig) string {
+	matches := gitReferencePattern.FindAllStringSubmatch(input, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	branch, err := gitutil.Branch(projectRoot)
+	if err != nil {
+		fmt.Printf("\033[38;5;240m(Note: Skipping git references - %s is not a git repository)\033[0m\n", projectRoot)
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n\nGit context (branch %s):\n", branch)
+	seen := map[string]bool{}
+	for _, match := range matches {
+		name := match[1]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		ref := gitReferences[name]
+
+		out, err := ref.load(projectRoot)
+		if err != nil {
+			fmt.Printf("\033[38;5;240m(Note: Could not read @%s: %v)\033[0m\n", name, err)
+			continue
+		}
+		out = strings.TrimRight(out, "\n")
+		if name == "status" {
+			// The branch line is already in the header
+			if first, rest, ok := strings.Cut(out, "\n"); ok && strings.HasPrefix(first, "## ") {
+				out = rest
+			} else if strings.HasPrefix(out, "## ") {
+				out = ""
+			}
+		}
+		if out == "" {
+			out = ref.empty
+		}
+		if limits.MaxFileBytes > 0 && int64(len(out)) > limits.MaxFileBytes {
+			fmt.Printf("\033[38;5;240m(Note: Truncated @%s to %d of %d bytes to fit context limits)\033[0m\n", name, limits.MaxFileBytes, len(out))
+			out = strings.ToValidUTF8(out[:limits.MaxFileBytes], "") + "\n... (truncated)"
+		}
+		fmt.Fprintf(&b, "\n--- %s ---\n%s\n--- End of %s ---\n", ref.title, out, ref.title)
+	}
+	return b.String()
+}
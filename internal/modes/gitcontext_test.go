@@ -0,0 +1,75 @@
+package modes
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+// newTestRepo creates an empty repository on branch and returns its root and a function
+// that runs git in it and returns the output
+func newTestRepo(t *testing.T, branch string) (string, func(args ...string) string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	// Keep caches such as the repo map's out of the real data dir
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	git("init", "-q", "-b", branch)
+	return root, git
+}
+
+func TestReadGitReferences(t *testing.T) {
+	root, git := newTestRepo(t, "feature")
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	write("a.txt", "one\n")
+	write("b.txt", "two\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	write("a.txt", "one changed\n")
+	write("b.txt", "two staged\n")
+	git("add", "b.txt")
+
+	limits := config.DefaultContextConfig()
+	if out := ReadGitReferences("email me at a@diff.example", root, limits); out != "" {
+		t.Fatalf("expected no expansion, got:\n%s", out)
+	}
+
+	out := ReadGitReferences("review @staged and @diff, see @status", root, limits)
+	for _, want := range []string{"branch feature", "+one changed", "+two staged", "--- git status ---\n M a.txt\nM  b.txt"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "+two staged") > strings.Index(out, "+one changed") {
+		t.Errorf("references should be expanded in prompt order:\n%s", out)
+	}
+
+	limits.MaxFileBytes = 10
+	if out := ReadGitReferences("@diff", root, limits); !strings.Contains(out, "... (truncated)") {
+		t.Errorf("expected truncated diff:\n%s", out)
+	}
+}
@@ -0,0 +1,84 @@
+package modes
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/gitutil"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+)
+
+// historyCommits is how many commits touching a region are included
+const historyCommits = 3
+
+// regionPattern matches file regions written as main.go:10-20 or main.go:10, and the
+// "Selection from main.go (lines 10-20)" prompts sent by editor plugins
+var regionPattern = regexp.MustCompile(`([a-zA-Z0-9_\-./\\]+\.[a-zA-Z0-9]+)(?::(\d+)(?:-(\d+))?| \(lines (\d+)-(\d+)\))`)
+
+// fileRegion is a range of lines (1-based, inclusive) in a project file
+type fileRegion struct {
+	path       string
+	start, end int
+}
+
+// findRegions returns the distinct file regions mentioned in input
+func findRegions(input string) []fileRegion {
+	var regions []fileRegion
+	seen := map[fileRegion]bool{}
+	for _, m := range regionPattern.FindAllStringSubmatch(input, -1) {
+		startText, endText := m[2], m[3]
+		if startText == "" {
+			startText, endText = m[4], m[5]
+		}
+		start, _ := strconv.Atoi(startText)
+		end := start
+		if endText != "" {
+			end, _ = strconv.Atoi(endText)
+		}
+		region := fileRegion{path: m[1], start: start, end: end}
+		if start < 1 || end < start || seen[region] {
+			continue
+		}
+		seen[region] = true
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+// ReadGitHistory returns blame and the recent commits for each file region in input, so
+// answers can explain why the code is the way it is. Regions outside the project or not
+// tracked by git are skipped; each region's history is truncated to max_file_bytes.
+func ReadGitHistory(input, projectRoot string, limits config.ContextConfig) string {
+	regions := findRegions(input)
+	if len(regions) == 0 {
+		return ""
+	}
+	if _, err := gitutil.RepoRoot(projectRoot); err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, r := range regions {
+		_, relPath, err := safeio.ResolveWithinRoot(projectRoot, r.path)
+		if err != nil {
+			continue
+		}
+		blame, err := gitutil.Blame(projectRoot, relPath, r.start, r.end)
+		if err != nil {
+			fmt.Printf("\033[38;5;240m(Note: No git history for %s:%d-%d: %v)\033[0m\n", relPath, r.start, r.end, err)
+			continue
+		}
+		history := "Blame:\n" + strings.TrimRight(blame, "\n")
+		if log, err := gitutil.LineLog(projectRoot, relPath, r.start, r.end, historyCommits); err == nil && strings.TrimSpace(log) != "" {
+			history += fmt.Sprintf("\n\nLast %d commits touching these lines:\n%s", historyCommits, strings.TrimRight(log, "\n"))
+		}
+		if limits.MaxFileBytes > 0 && int64(len(history)) > limits.MaxFileBytes {
+			history = strings.ToValidUTF8(history[:limits.MaxFileBytes], "") + "\n... (truncated)"
+		}
+		fmt.Fprintf(&b, "\n\n--- Git history of %s lines %d-%d ---\n%s\n--- End of git history ---\n", relPath, r.start, r.end, history)
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+func TestFindRegions(t *testing.T) {
+	got := findRegions("why is main.go:10-20 so odd? compare pkg/a.go:7 and main.go:10-20\n\nSelection from ui/x.ts (lines 3-4):")
+	want := []fileRegion{{"main.go", 10, 20}, {"pkg/a.go", 7, 7}, {"ui/x.ts", 3, 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("findRegions = %v, want %v", got, want)
+	}
+	if got := findRegions("main.go:20-10 and main.go:0"); len(got) != 0 {
+		t.Fatalf("expected invalid ranges to be skipped, got %v", got)
+	}
+}
+
+func TestReadGitHistory(t *testing.T) {
+	root, git := newTestRepo(t, "main")
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(root, "a.go"), []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	write("package a\n\nvar x = 1\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "add a")
+	write("package a\n\nvar x = 2 // tuned\n")
+	git("commit", "-q", "-am", "tune x for the benchmark")
+
+	out := ReadGitHistory("why is a.go:3 set to 2?", root, config.DefaultContextConfig())
+	for _, want := range []string{"Git history of a.go lines 3-3", "var x = 2 // tuned", "tune x for the benchmark", "+var x = 2 // tuned"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if out := ReadGitHistory("what about missing.go:1?", root, config.DefaultContextConfig()); out != "" {
+		t.Errorf("expected no history for an untracked file, got:\n%s", out)
+	}
+}
@@ -0,0 +1,62 @@
+package modes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/grep"
+	"github.com/yourusername/llamasidekick/internal/mcp"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+const (
+	// grepContextLines is how many lines around each match a search shows
+	grepContextLines = 2
+	// maxGrepMatches bounds how many matches a search returns
+	maxGrepMatches = 100
+)
+
+// GrepProject searches the project's files for a regular expression, leaving out the
+// files in context.ignore
+func GrepProject(root, pattern string, ignoreCase bool, cfg *config.Config) ([]grep.Match, error) {
+	return grep.Search(root, pattern, grep.Options{
+		Context:    grepContextLines,
+		IgnoreCase: ignoreCase,
+		MaxMatches: maxGrepMatches,
+		Ignore:     cfg.Context.Ignore,
+	})
+}
+
+// builtinServer is the server name of the tools LlamaSidekick provides itself
+const builtinServer = "project"
+
+// builtinTools returns the tools Agent mode can always call. They only read the project,
+// so they are available in read-only mode and run without confirmation.
+func builtinTools(sess *session.Session, cfg *config.Config) []AgentTool {
+	grepTool := AgentTool{
+		Server: builtinServer,
+		Tool: mcp.Tool{
+			Name:        "grep",
+			Description: "Search the project's files for a regular expression and return the matching lines as file:line with surrounding lines. Use it to locate definitions and uses of identifiers.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"pattern":{"type":"string","description":"Regular expression (RE2 syntax)"},"ignore_case":{"type":"boolean"}},"required":["pattern"]}`),
+		},
+		run: func(args map[string]interface{}) (string, error) {
+			pattern, _ := args["pattern"].(string)
+			if strings.TrimSpace(pattern) == "" {
+				return "", fmt.Errorf("pattern is required")
+			}
+			ignoreCase, _ := args["ignore_case"].(bool)
+			matches, err := GrepProject(sess.ProjectRoot, pattern, ignoreCase, cfg)
+			if err != nil {
+				return "", err
+			}
+			if len(matches) == 0 {
+				return "No matches", nil
+			}
+			return grep.Format(matches), nil
+		},
+	}
+	return []AgentTool{grepTool}
+}
@@ -0,0 +1,32 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestBuiltinGrepTool(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {\n\trun()\n}\n"), 0644)
+	cfg := &config.Config{Context: config.DefaultContextConfig()}
+	tools := builtinTools(session.New(root), cfg)
+	if len(tools) != 1 || tools[0].FullName() != "project.grep" {
+		t.Fatalf("unexpected built-in tools %+v", tools)
+	}
+
+	out, err := tools[0].call(map[string]interface{}{"pattern": `run\(`})
+	if err != nil || !strings.Contains(out, "main.go\n") || !strings.Contains(out, "4:\trun()") {
+		t.Fatalf("unexpected grep result %q, %v", out, err)
+	}
+	if out, _ := tools[0].call(map[string]interface{}{"pattern": "nothing here"}); out != "No matches" {
+		t.Fatalf("expected no matches, got %q", out)
+	}
+	if _, err := tools[0].call(map[string]interface{}{}); err == nil {
+		t.Fatal("expected an error without a pattern")
+	}
+}
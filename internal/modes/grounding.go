@@ -0,0 +1,161 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/index"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// maxCheckedReferences bounds how many references of an answer are looked up
+const maxCheckedReferences = 30
+
+var (
+	inlineCode = regexp.MustCompile("`([^`\n]+)`")
+	// symbolReference is an identifier, optionally qualified once and followed by arguments,
+	// e.g. ParseConfig, config.Load or (Config).Save(path)
+	symbolReference = regexp.MustCompile(`^\(?([A-Za-z_][A-Za-z0-9_]*)\)?(?:\.([A-Za-z_][A-Za-z0-9_]*))?(\(.*\))?$`)
+	// fileReference is a path, optionally with a line number, e.g. ./cmd/main.go:42
+	fileReference = regexp.MustCompile(`^(?:\./)?([A-Za-z0-9_.\-]+(?:/[A-Za-z0-9_.\-]+)*/?)(?::\d+(?:[:-]\d+)?)?$`)
+	// newFileLine is a line that proposes creating what it names, which can't exist yet
+	newFileLine = regexp.MustCompile(`(?i)\b(create|creating|add a new|new file|rename[sd]? (?:it )?to|move[sd]? (?:it )?to)\b`)
+)
+
+// reference is a file or symbol an answer names in inline code
+type reference struct {
+	text      string // As the answer wrote it
+	file      string // The path, for a file reference
+	qualifier string // The package or type, for a qualified symbol
+	name      string // The symbol
+	call      bool   // Written with arguments, e.g. Load()
+}
+
+// CheckReferences returns the files and symbols that answer names in inline code but that
+// aren't in the project, as the answer wrote them. Code blocks are left out, as they
+// hold new code, and so are lines that propose creating or renaming files.
+func CheckReferences(sess *session.Session, cfg *config.Config, answer string) []string {
+	refs := answerReferences(answer)
+	if len(refs) == 0 {
+		return nil
+	}
+	s, err := projectStructure(sess, cfg)
+	if err != nil || !s.HasCode() {
+		return nil
+	}
+
+	var missing []string
+	for _, ref := range refs {
+		switch {
+		case ref.file != "":
+			if _, err := os.Stat(filepath.Join(sess.ProjectRoot, filepath.FromSlash(ref.file))); err == nil {
+				continue
+			}
+			dir, _, nested := strings.Cut(ref.file, "/")
+			if !nested && len(s.FindFiles(ref.file)) > 0 {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(sess.ProjectRoot, dir)); nested && !filePattern.MatchString(ref.file) && err != nil {
+				// Without an extension, e.g. and/or, it's only a path if it starts in the project
+				continue
+			}
+		case ref.qualifier != "":
+			// Only packages and types of the project can be checked; fmt.Println or a
+			// variable's method can't
+			if !s.HasPackage(ref.qualifier) && !isProjectType(s.Where(ref.qualifier)) {
+				continue
+			}
+			if len(s.Where(ref.qualifier+"."+ref.name)) > 0 || len(s.Callers(ref.name)) > 0 || s.Mentions(ref.name) {
+				continue
+			}
+		default:
+			if len(s.Where(ref.name)) > 0 || len(s.Callers(ref.name)) > 0 || s.Mentions(ref.name) {
+				continue
+			}
+		}
+		missing = append(missing, ref.text)
+	}
+	return missing
+}
+
+// isProjectType reports whether one of the declarations is a type, class or the like
+func isProjectType(locations []index.Location) bool {
+	for _, loc := range locations {
+		switch loc.Symbol.Kind {
+		case "type", "class", "struct", "interface", "enum", "trait":
+			return true
+		}
+	}
+	return false
+}
+
+// answerReferences returns the distinct file and symbol references in the prose of answer
+func answerReferences(answer string) []reference {
+	var refs []reference
+	seen := map[string]bool{}
+	inBlock := false
+	for _, line := range strings.Split(answer, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inBlock = !inBlock
+			continue
+		}
+		if inBlock || newFileLine.MatchString(line) {
+			continue
+		}
+		for _, m := range inlineCode.FindAllStringSubmatch(line, -1) {
+			text := strings.TrimSpace(m[1])
+			if seen[text] || len(refs) == maxCheckedReferences {
+				continue
+			}
+			seen[text] = true
+			if ref, ok := parseReference(text); ok {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// parseReference tells whether text names a file or a symbol that can be looked up.
+// Plain words such as `nil` or `config`, constants in capitals and anything with spaces,
+// such as a command, are not references.
+func parseReference(text string) (reference, bool) {
+	if strings.Contains(text, "://") || strings.HasPrefix(text, "/") || strings.HasPrefix(text, "~") {
+		return reference{}, false
+	}
+	if m := fileReference.FindStringSubmatch(text); m != nil {
+		if file := strings.TrimSuffix(m[1], "/"); filePattern.MatchString(file) || strings.Contains(file, "/") {
+			return reference{text: text, file: file}, true
+		}
+	}
+	m := symbolReference.FindStringSubmatch(text)
+	if m == nil {
+		return reference{}, false
+	}
+	ref := reference{text: text, name: m[1], call: m[3] != ""}
+	if m[2] != "" {
+		ref.qualifier, ref.name = m[1], m[2]
+		return ref, true
+	}
+	return ref, ref.call || compoundIdentifier(ref.name)
+}
+
+// compoundIdentifier reports whether name is made of several words, e.g. ParseConfig,
+// loadFile or read_config, and isn't a constant in capitals
+func compoundIdentifier(name string) bool {
+	if strings.ToUpper(name) == name {
+		return false
+	}
+	if strings.Contains(strings.Trim(name, "_"), "_") {
+		return true
+	}
+	for i := 1; i < len(name); i++ {
+		if name[i] >= 'A' && name[i] <= 'Z' {
+			return true
+		}
+	}
+	return false
+}
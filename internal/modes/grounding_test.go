@@ -0,0 +1,43 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestCheckReferences(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	files := map[string]string{
+		"config/config.go": "package config\n\ntype Config struct {\n\tAutoApprove bool\n}\n\nfunc Load() *Config {\n\treturn nil\n}\n",
+		"main.go":          "package main\n\nfunc main() {\n\tcfg := config.Load()\n\tfmt.Println(cfg)\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	answer := "Call `config.Load()` from `main()` in `main.go:4`, then set `Config.AutoApprove`.\n" +
+		"`ParseConfig` in `config/parse.go` reads it, and `config.Parse` checks it with `validateConfig(cfg)`.\n" +
+		"Plain words such as `nil`, `config` and `MAX_SIZE`, commands like `go test ./...`, `and/or`,\n" +
+		"other packages' `strings.Cut` and paths outside like `/etc/hosts` are not checked.\n" +
+		"Create `internal/auth/jwt.go` for the new code.\n" +
+		"```go\nfunc NewHelper() {}\n// `InBlock` is code\n```\n"
+	got := CheckReferences(session.New(root), &config.Config{}, answer)
+	want := "ParseConfig, config/parse.go, config.Parse, validateConfig(cfg)"
+	if strings.Join(got, ", ") != want {
+		t.Fatalf("expected %s to be flagged, got %v", want, got)
+	}
+
+	if got := CheckReferences(session.New(t.TempDir()), &config.Config{}, answer); len(got) != 0 {
+		t.Fatalf("a directory without code has nothing to check against, got %v", got)
+	}
+}
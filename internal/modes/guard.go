@@ -0,0 +1,89 @@
+package modes
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/secrets"
+)
+
+// injectionPattern matches text that tries to give the model new instructions, such as
+// "ignore all previous instructions" in a fetched web page
+var injectionPattern = regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override)\b[^.\n]{0,40}\b(?:previous|prior|above|earlier|all|any|your|system)\b[^.\n]{0,20}\b(?:instructions?|prompts?|rules|directions)\b|\byou are now\b|\bnew (?:system )?instructions?\s*:|\bsystem prompt\s*:|<\|?(?:system|im_start)\|?>`)
+
+// guardFileContent applies context.secrets to the content of a file before it goes into
+// a prompt, given the redacted text and findings secrets.RedactText returned for it:
+// likely secrets are redacted, or with "confirm" the user chooses. It returns the content
+// to use, or false if the file should be left out.
+func guardFileContent(name, text, redacted string, findings []secrets.Finding, limits config.ContextConfig) (string, bool) {
+	if limits.Secrets == "off" || len(findings) == 0 {
+		return text, true
+	}
+	if limits.Secrets == "confirm" {
+		fmt.Printf("\033[38;5;214m'%s' looks like it contains secrets (%s).\033[0m\n", name, describeFindings(findings))
+		fmt.Print("[R]edact them, send the file [a]s is, or [s]kip it? ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "a", "as is":
+			return text, true
+		case "s", "skip":
+			return "", false
+		}
+	}
+	fmt.Printf("\033[38;5;240m(Note: Redacted %s in '%s')\033[0m\n", describeFindings(findings), name)
+	return redacted, true
+}
+
+// redactSecrets redacts the likely secrets in text taken from source, unless
+// context.secrets is off
+func redactSecrets(source, text string, limits config.ContextConfig) string {
+	if limits.Secrets == "off" {
+		return text
+	}
+	redacted, findings := secrets.RedactText(text)
+	if len(findings) > 0 {
+		fmt.Printf("\033[38;5;240m(Note: Redacted %s in %s)\033[0m\n", describeFindings(findings), source)
+	}
+	return redacted
+}
+
+// redactedForPrompt is like redactSecrets without the note, for content whose secrets
+// were already reported when it was loaded into the prompt
+func redactedForPrompt(text string, limits config.ContextConfig) string {
+	if limits.Secrets == "off" {
+		return text
+	}
+	redacted, _ := secrets.RedactText(text)
+	return redacted
+}
+
+// sanitizeToolOutput removes the lines of a tool result that look like instructions to
+// the model, so content fetched by a tool can't take over the conversation
+func sanitizeToolOutput(tool, output string) string {
+	lines := strings.Split(output, "\n")
+	removed := 0
+	for i, line := range lines {
+		if injectionPattern.MatchString(line) {
+			lines[i] = "[line removed: it looked like instructions to the model]"
+			removed++
+		}
+	}
+	if removed == 0 {
+		return output
+	}
+	fmt.Printf("\033[38;5;214m  Removed %d line(s) from the %s result that looked like instructions to the model\033[0m\n", removed, tool)
+	return strings.Join(lines, "\n")
+}
+
+// describeFindings summarises findings as e.g. "GitHub token on line 3 and 2 more"
+func describeFindings(findings []secrets.Finding) string {
+	first := fmt.Sprintf("%s on line %d", findings[0].Kind, findings[0].Line)
+	if len(findings) == 1 {
+		return first
+	}
+	return fmt.Sprintf("%s and %d more", first, len(findings)-1)
+}
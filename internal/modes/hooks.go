@@ -0,0 +1,43 @@
+package modes
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/shellcmd"
+)
+
+// runHooks runs the hook commands configured for event in root, one after the other,
+// with each {name} placeholder replaced by the shell-quoted value of vars[name]. It stops
+// at the first command that fails and returns its error; output is printed dimmed.
+// Read-only mode runs no hooks, since they are arbitrary shell commands.
+func runHooks(cfg *config.Config, root, event string, commands []string, vars map[string]string) error {
+	if cfg.Edits.ReadOnly {
+		return nil
+	}
+	pairs := make([]string, 0, 2*len(vars))
+	for name, value := range vars {
+		pairs = append(pairs, "{"+name+"}", shellcmd.Quote(value))
+	}
+	replacer := strings.NewReplacer(pairs...)
+	for _, command := range commands {
+		line := replacer.Replace(command)
+		cmd := shellcmd.Command(line)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		output := strings.TrimSpace(string(out))
+		slog.Info("hook", "event", event, "command", line, "error", err)
+		if output != "" {
+			fmt.Printf("\033[38;5;240m%s\033[0m\n", output)
+		}
+		if err != nil {
+			if output != "" {
+				err = fmt.Errorf("%w: %s", err, firstLine(output))
+			}
+			return fmt.Errorf("%s hook %q failed: %w", event, command, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestRunHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell commands")
+	}
+	root := t.TempDir()
+	commands := []string{"echo edited {file} >> hooks.log", "false", "echo never >> hooks.log"}
+	err := runHooks(&config.Config{}, root, "post_edit", commands, map[string]string{"file": "my file.go"})
+	if err == nil || !strings.Contains(err.Error(), `post_edit hook "false" failed`) {
+		t.Fatalf("expected the failing hook to be reported, got %v", err)
+	}
+	log, _ := os.ReadFile(filepath.Join(root, "hooks.log"))
+	if string(log) != "edited my file.go\n" {
+		t.Fatalf("expected only the hooks before the failure to run, got %q", log)
+	}
+}
+
+func TestReadOnlyRunsNoCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell commands")
+	}
+	root := t.TempDir()
+	cfg := &config.Config{Edits: config.EditsConfig{ReadOnly: true}}
+	if err := runHooks(cfg, root, "pre_command", []string{"echo ran >> hooks.log"}, nil); err != nil {
+		t.Fatalf("expected the hooks to be skipped, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "hooks.log")); !os.IsNotExist(err) {
+		t.Fatal("a hook ran in read-only mode")
+	}
+
+	sess := session.New(root)
+	command := "echo ran >> test.log"
+	if err := FixTests(nil, sess, cfg, command); err == nil || !strings.Contains(err.Error(), "read-only mode") {
+		t.Fatalf("expected FixTests to refuse, got %v", err)
+	}
+	if err := FixBuild(nil, sess, cfg, command); err == nil || !strings.Contains(err.Error(), "read-only mode") {
+		t.Fatalf("expected FixBuild to refuse, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "test.log")); !os.IsNotExist(err) {
+		t.Fatal("the command ran in read-only mode")
+	}
+}
+
+func TestPreEditHookCancelsWrite(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell commands")
+	}
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	cfg := &config.Config{
+		Backups: config.BackupsConfig{Keep: 10},
+		Hooks:   config.HooksConfig{PreEdit: []string{`test {file} != locked.txt`}, PostEdit: []string{"echo {file} >> hooks.log"}},
+	}
+	sess := session.New(root)
+	files := []GeneratedFile{{Filename: "notes.txt", Content: "a\n"}, {Filename: "locked.txt", Content: "b\n"}}
+	if summary, err := writeGeneratedFiles(cfg, sess, files, "write notes"); err != nil || summary != "Proposed 2 file(s) (not written)" {
+		t.Fatalf("expected the write to be cancelled, got %q, %v", summary, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "notes.txt")); !os.IsNotExist(err) {
+		t.Fatal("notes.txt was written although a pre_edit hook failed")
+	}
+
+	cfg.Hooks.PreEdit = []string{"true"}
+	if summary, err := writeGeneratedFiles(cfg, sess, files, "write notes"); err != nil || summary != "Created 2 file(s) successfully" {
+		t.Fatalf("expected the files to be written, got %q, %v", summary, err)
+	}
+	log, _ := os.ReadFile(filepath.Join(root, "hooks.log"))
+	if string(log) != "notes.txt\nlocked.txt\n" {
+		t.Fatalf("expected post_edit to run for each file, got %q", log)
+	}
+}
@@ -0,0 +1,107 @@
+package modes
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/diff"
+	"github.com/yourusername/llamasidekick/internal/renderer"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// hunkContext is how many unchanged lines surround each hunk when stepping through them
+const hunkContext = 3
+
+// hunkHelp explains the answers selectHunks accepts
+const hunkHelp = `y - apply this hunk
+n - skip this hunk
+e - edit this hunk in $EDITOR, then apply it
+a - apply this hunk and all later ones
+d - skip this hunk and all later ones
+? - show this help`
+
+// reviewHunks reports whether an Edit mode change is stepped through hunk by hunk: with
+// edits.hunks, when it is written, there is a user to answer and changes aren't auto-approved
+func reviewHunks(cfg *config.Config, sess *session.Session) bool {
+	return cfg.Edits.Hunks && !cfg.Edits.AutoApprove && !cfg.Edits.DryRun && !cfg.Edits.ReadOnly && sess.ApproveChanges == nil
+}
+
+// selectHunks shows the change from oldText to newText one hunk at a time, like
+// git add -p, and returns oldText with the hunks the user applies, as they were or after
+// changing them with edit, with how many of how many hunks they applied
+func selectHunks(cfg *config.Config, relPath, oldText, newText string, in *bufio.Reader, edit func(diff.Hunk) (diff.Hunk, error)) (string, int, int) {
+	hunks := diff.Hunks(diff.Lines(diff.SplitLines(oldText), diff.SplitLines(newText)), hunkContext)
+	options := renderer.DiffOptions{WordLevel: cfg.UI.WordDiff, LineNumbers: cfg.UI.LineNumbers}
+	var selected []diff.Hunk
+	rest, changed := "", false
+	for i := 0; i < len(hunks); i++ {
+		h := hunks[i]
+		answer := rest
+		if answer == "" {
+			fmt.Println()
+			fmt.Print(renderer.RenderDiff(diff.Header(relPath, relPath)+h.String(), options))
+			fmt.Printf("\033[1;34m(%d/%d) Apply this hunk [y,n,e,a,d,?]?\033[0m ", i+1, len(hunks))
+			line, err := in.ReadString('\n')
+			if err != nil && line == "" {
+				fmt.Println()
+				line = "d"
+			}
+			answer = strings.ToLower(strings.TrimSpace(line))
+		}
+		switch answer {
+		case "y", "yes":
+			selected = append(selected, h)
+		case "n", "no":
+		case "a":
+			selected = append(selected, h)
+			rest = "y"
+		case "d", "q":
+			rest = "n"
+		case "e":
+			edited, err := edit(h)
+			if err != nil {
+				fmt.Printf("\033[38;5;9m%v\033[0m\n", err)
+				i--
+				continue
+			}
+			selected = append(selected, edited)
+			changed = true
+		default:
+			fmt.Println(hunkHelp)
+			i--
+		}
+	}
+	if len(selected) == len(hunks) && !changed {
+		return newText, len(hunks), len(hunks)
+	}
+	return diff.Apply(oldText, selected), len(selected), len(hunks)
+}
+
+// editHunk opens hunk h of relPath in the user's editor and returns it as they saved it
+func editHunk(relPath string, h diff.Hunk) (diff.Hunk, error) {
+	f, err := os.CreateTemp("", "llamasidekick-hunk-*.diff")
+	if err != nil {
+		return diff.Hunk{}, fmt.Errorf("failed to create hunk file: %w", err)
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	text := fmt.Sprintf("# Edit the hunk of %s. Lines starting with ' ' are unchanged, '+' added\n", relPath) +
+		"# and '-' removed. To keep a removed line, turn its '-' into ' '; to leave out an\n" +
+		"# added line, delete it. Lines starting with # are ignored.\n" + h.String()
+	_, err = f.WriteString(text)
+	f.Close()
+	if err != nil {
+		return diff.Hunk{}, fmt.Errorf("failed to write hunk file: %w", err)
+	}
+	if err := config.OpenInEditor(path); err != nil {
+		return diff.Hunk{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return diff.Hunk{}, fmt.Errorf("failed to read hunk file: %w", err)
+	}
+	return diff.ParseHunk(string(data), h)
+}
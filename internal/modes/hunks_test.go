@@ -0,0 +1,47 @@
+package modes
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/diff"
+)
+
+func TestSelectHunks(t *testing.T) {
+	oldText := "one\n" + strings.Repeat("same\n", 10) + "two\n" + strings.Repeat("same\n", 10) + "three\n"
+	newText := "ONE\n" + strings.Repeat("same\n", 10) + "TWO\n" + strings.Repeat("same\n", 10) + "THREE\n"
+	cfg := &config.Config{}
+	noEdit := func(h diff.Hunk) (diff.Hunk, error) { return h, fmt.Errorf("not editing") }
+
+	for _, tc := range []struct {
+		name, answers, want string
+		applied             int
+	}{
+		{"all", "y\ny\ny\n", newText, 3},
+		{"some", "n\ny\nn\n", strings.Replace(oldText, "two", "TWO", 1), 1},
+		{"apply the rest", "n\na\n", strings.Replace(newText, "ONE", "one", 1), 2},
+		{"skip the rest", "y\nd\n", strings.Replace(oldText, "one", "ONE", 1), 1},
+		{"help, then end of input", "?\ny\n", strings.Replace(oldText, "one", "ONE", 1), 1},
+	} {
+		got, applied, total := selectHunks(cfg, "f.txt", oldText, newText, bufio.NewReader(strings.NewReader(tc.answers)), noEdit)
+		if got != tc.want || applied != tc.applied || total != 3 {
+			t.Errorf("%s: applied %d of %d hunks, got:\n%s", tc.name, applied, total, got)
+		}
+	}
+
+	// An edit that fails is asked again; a successful one is applied as edited
+	keepOld := func(h diff.Hunk) (diff.Hunk, error) {
+		return diff.ParseHunk(strings.ReplaceAll(strings.ReplaceAll(h.String(), "\n-", "\n "), "\n+THREE", ""), h)
+	}
+	got, applied, _ := selectHunks(cfg, "f.txt", oldText, newText, bufio.NewReader(strings.NewReader("n\nn\ne\n")), keepOld)
+	if got != oldText || applied != 1 {
+		t.Errorf("edited hunk: applied %d, got:\n%s", applied, got)
+	}
+	got, applied, _ = selectHunks(cfg, "f.txt", oldText, newText, bufio.NewReader(strings.NewReader("e\nn\nn\nn\n")), noEdit)
+	if got != oldText || applied != 0 {
+		t.Errorf("failed edit: applied %d, got:\n%s", applied, got)
+	}
+}
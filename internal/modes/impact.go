@@ -0,0 +1,169 @@
+package modes
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/goimpact"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// maxShownCallSites bounds how many broken call sites are listed in the warning
+const maxShownCallSites = 20
+
+const callSitesSystemPrompt = `You update the callers of changed Go functions and methods. You MUST respond with ONLY a valid JSON array of file objects. No markdown, no explanations, no extra text.
+
+Each object must have exactly these fields:
+- "filename": string (the file path, as shown in the file contents)
+- "content": string (the COMPLETE new content of the file)
+
+Only change what the new signatures require, and only include files you change.`
+
+type callSiteChoice int
+
+const (
+	callSitesUpdate callSiteChoice = iota
+	callSitesKeep
+	callSitesCancel
+)
+
+// checkCallSites looks for call sites in other files that an edit of a Go file breaks by
+// changing or removing exported functions and methods. It lists them and offers to update
+// them together with the edit. It returns the updated callers to write with the edit, and
+// false if the user cancelled the edit.
+func checkCallSites(client *ollama.Client, sess *session.Session, cfg *config.Config, relPath string, before []byte, after string) ([]GeneratedFile, bool) {
+	if !strings.HasSuffix(relPath, ".go") {
+		return nil, true
+	}
+	changes := goimpact.Compare(before, []byte(after))
+	if len(changes) == 0 {
+		return nil, true
+	}
+	sites, err := goimpact.FindCallSites(sess.ProjectRoot, relPath, changes, func(rel string) bool {
+		return pathmatch.MatchAny(cfg.Context.Ignore, rel)
+	})
+	if err != nil {
+		fmt.Printf("\033[38;5;214mWarning: failed to scan for call sites: %v\033[0m\n", err)
+	}
+	if len(sites) == 0 {
+		return nil, true
+	}
+
+	printCallSites(relPath, changes, sites)
+	// Without a terminal to ask on (e.g. when another program approves changes), warn only
+	if sess.ApproveChanges != nil {
+		return nil, true
+	}
+	switch askCallSites() {
+	case callSitesCancel:
+		return nil, false
+	case callSitesKeep:
+		return nil, true
+	}
+
+	updated, err := requestCallSiteUpdates(client, sess, cfg, relPath, after, changes, sites)
+	if err != nil {
+		fmt.Printf("\033[38;5;9mFailed to update call sites: %v\033[0m\n", err)
+		fmt.Println("\033[38;5;240mKeeping the edit as is\033[0m")
+		return nil, true
+	}
+	return updated, true
+}
+
+// printCallSites warns about the changed declarations and lists the calls they break
+func printCallSites(relPath string, changes []goimpact.Change, sites []goimpact.CallSite) {
+	fmt.Printf("\033[38;5;214mThis edit of %s changes exported signatures used elsewhere:\033[0m\n", relPath)
+	for _, c := range changes {
+		fmt.Printf("  %s\n", c)
+	}
+	fmt.Printf("\033[38;5;214m%d call site(s) may break:\033[0m\n", len(sites))
+	for i, s := range sites {
+		if i == maxShownCallSites {
+			fmt.Printf("\033[38;5;240m  ... and %d more\033[0m\n", len(sites)-maxShownCallSites)
+			break
+		}
+		fmt.Printf("  %s:%d\033[38;5;240m  %s\033[0m\n", s.File, s.Line, s.Text)
+	}
+}
+
+// askCallSites asks whether to update the broken call sites along with the edit
+func askCallSites() callSiteChoice {
+	fmt.Print("[U]pdate the call sites too, [k]eep the edit as is, or [c]ancel? ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return callSitesKeep
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "k", "keep":
+		return callSitesKeep
+	case "c", "cancel":
+		return callSitesCancel
+	}
+	return callSitesUpdate
+}
+
+// requestCallSiteUpdates asks the model to adapt the files with broken call sites to the
+// edited file
+func requestCallSiteUpdates(client *ollama.Client, sess *session.Session, cfg *config.Config, relPath, after string, changes []goimpact.Change, sites []goimpact.CallSite) ([]GeneratedFile, error) {
+	files := callSiteFiles(sites)
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "%s is being changed. These exported signatures changed:\n", relPath)
+	for _, c := range changes {
+		fmt.Fprintf(&prompt, "- %s\n", c)
+	}
+	fmt.Fprintf(&prompt, "\nNew content of %s:\n```go\n%s\n```\n\nUpdate these call sites:\n", relPath, after)
+	for _, s := range sites {
+		fmt.Fprintf(&prompt, "- %s:%d: %s\n", s.File, s.Line, s.Text)
+	}
+	fmt.Fprintf(&prompt, "\nFiles to update: %s\n", strings.Join(files, " "))
+	fullPrompt := ReadFilesFromInputWithLimits(prompt.String(), sess.ProjectRoot, cfg.Context)
+
+	s := progress.NewStatus("Updating call sites")
+	s.Start()
+	jsonResponse, err := client.GenerateJSON(cfg.GetModelForMode(ModeEdit), fullPrompt, callSitesSystemPrompt, 0.2)
+	s.Stop()
+	if err != nil {
+		return nil, fmt.Errorf("error generating call site updates: %w", err)
+	}
+	generated, err := ParseGeneratedFilesJSON(jsonResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing call site updates: %w", err)
+	}
+
+	// Only the files with call sites may be changed; the edited file comes from the edit
+	allowed := map[string]bool{}
+	for _, f := range files {
+		allowed[f] = true
+	}
+	var updated []GeneratedFile
+	for _, file := range generated {
+		if !allowed[strings.TrimPrefix(file.Filename, "./")] {
+			fmt.Printf("\033[38;5;240mIgnoring unrequested change to %s\033[0m\n", file.Filename)
+			continue
+		}
+		updated = append(updated, file)
+	}
+	return updated, nil
+}
+
+// callSiteFiles returns the distinct files of sites, bounded by maxFixFiles
+func callSiteFiles(sites []goimpact.CallSite) []string {
+	seen := map[string]bool{}
+	var files []string
+	for _, s := range sites {
+		if !seen[s.File] && len(files) < maxFixFiles {
+			seen[s.File] = true
+			files = append(files, s.File)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
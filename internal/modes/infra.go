@@ -0,0 +1,46 @@
+package modes
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/infra"
+)
+
+// infraCacheTTL is how long an inventory is reused; containers come and go, so it is
+// taken again sooner than the project stack
+const infraCacheTTL = time.Minute
+
+type cachedInfra struct {
+	description string
+	detected    time.Time
+}
+
+var (
+	infraMu    sync.Mutex
+	infraCache = map[string]cachedInfra{}
+)
+
+// InfraPrompt describes the Compose services, running containers and Kubernetes contexts
+// of the project at projectRoot for CMD and Agent mode's system prompts, so commands use
+// their real names. It is empty when context.infra is off or nothing was found.
+func InfraPrompt(projectRoot string, limits config.ContextConfig) string {
+	if !limits.Infra || projectRoot == "" {
+		return ""
+	}
+
+	infraMu.Lock()
+	defer infraMu.Unlock()
+	c, ok := infraCache[projectRoot]
+	if !ok || time.Since(c.detected) >= infraCacheTTL {
+		c = cachedInfra{description: infra.Detect(projectRoot).Describe(), detected: time.Now()}
+		slog.Debug("took infrastructure inventory", "root", projectRoot, "inventory", c.description)
+		infraCache[projectRoot] = c
+	}
+	if c.description == "" {
+		return ""
+	}
+	return "\n\nWhat the project runs on:\n" + c.description + "\nWhen a command manages these, use their exact service, container, context and namespace names."
+}
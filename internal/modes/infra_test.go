@@ -0,0 +1,25 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+func TestInfraPrompt(t *testing.T) {
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "compose.yaml"), []byte("name: shop\nservices:\n  api:\n    build: .\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := InfraPrompt(root, config.ContextConfig{}); got != "" {
+		t.Fatalf("expected nothing with context.infra off, got %q", got)
+	}
+	got := InfraPrompt(root, config.ContextConfig{Infra: true})
+	if !strings.Contains(got, `project "shop", services: api`) {
+		t.Fatalf("expected the Compose services in the prompt, got %q", got)
+	}
+}
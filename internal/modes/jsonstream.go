@@ -0,0 +1,203 @@
+package modes
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/diff"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// streamedFilenamePattern finds the filename of a file object that is still arriving
+var streamedFilenamePattern = regexp.MustCompile(`"filename"\s*:\s*("(?:[^"\\]|\\.)*")`)
+
+// fileObjectScanner follows a JSON reply as it streams in and picks out each file object
+// ({"filename": ..., "content": ...}) as soon as it is complete, whether the reply is an
+// array of them or a single one
+type fileObjectScanner struct {
+	data      []byte
+	pos       int // Next byte to scan
+	depth     int
+	inString  bool
+	escaped   bool
+	fileDepth int // Depth of file objects: 1 for a single object, 2 in an array, 0 until the reply starts
+	start     int // Offset of the file object being received, or -1
+}
+
+func newFileObjectScanner() *fileObjectScanner {
+	return &fileObjectScanner{start: -1}
+}
+
+// Write adds a chunk of the reply and returns the file objects it completed
+func (s *fileObjectScanner) Write(chunk string) []GeneratedFile {
+	s.data = append(s.data, chunk...)
+	var files []GeneratedFile
+	for ; s.pos < len(s.data); s.pos++ {
+		c := s.data[s.pos]
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			s.inString = true
+		case '{', '[':
+			if s.fileDepth == 0 {
+				s.fileDepth = 1
+				if c == '[' {
+					s.fileDepth = 2
+				}
+			}
+			s.depth++
+			if c == '{' && s.depth == s.fileDepth {
+				s.start = s.pos
+			}
+		case '}', ']':
+			if c == '}' && s.depth == s.fileDepth && s.start >= 0 {
+				var f GeneratedFile
+				if json.Unmarshal(s.data[s.start:s.pos+1], &f) == nil && f.Filename != "" {
+					f.Content = stripLineNumbers(f.Content)
+					files = append(files, f)
+				}
+				s.start = -1
+			}
+			s.depth--
+		}
+	}
+	return files
+}
+
+// Len returns how many bytes of the reply have arrived
+func (s *fileObjectScanner) Len() int {
+	return len(s.data)
+}
+
+// Current returns the filename of the file object being received, once it has arrived
+func (s *fileObjectScanner) Current() string {
+	if s.start < 0 {
+		return ""
+	}
+	m := streamedFilenamePattern.FindSubmatch(s.data[s.start:])
+	if m == nil {
+		return ""
+	}
+	name, err := strconv.Unquote(string(m[1]))
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// formatSize describes a number of bytes, e.g. "12.3 KB"
+func formatSize(n int) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
+
+// streamFiles generates a JSON reply with file contents while a spinner shows how much
+// has arrived and which file the model is writing. onFile, if set, is called for every
+// file object as soon as it is complete. It returns the whole reply.
+func streamFiles(client *ollama.Client, modelName, prompt, system string, temperature float64, label string, onFile func(GeneratedFile)) (string, error) {
+	s := progress.NewStatus(label + " with " + modelName)
+	s.Start()
+	defer s.Stop()
+
+	scan := newFileObjectScanner()
+	return client.GenerateJSONStream(modelName, prompt, system, temperature, func(chunk string) error {
+		files := scan.Write(chunk)
+		if len(files) > 0 && onFile != nil {
+			s.Stop()
+			for _, f := range files {
+				onFile(f)
+			}
+			s.Start()
+		}
+		detail := formatSize(scan.Len()) + " received"
+		if current := scan.Current(); current != "" {
+			detail += " · " + current
+		}
+		s.SetDetail(detail)
+		s.Token()
+		return nil
+	})
+}
+
+// streamGeneratedFiles asks for complete files as a JSON array and stages each one as
+// soon as it has arrived, showing how it changes the project, then writes them like
+// writeGeneratedFiles. When the reply breaks off, the files that did arrive complete are
+// still proposed.
+func streamGeneratedFiles(client *ollama.Client, cfg *config.Config, sess *session.Session, modelName, prompt, system string, temperature float64, input string) (string, error) {
+	backups, err := OpenBackupStore(cfg)
+	if err != nil {
+		return "", err
+	}
+	tx := backups.Begin(sess.ProjectRoot)
+
+	seen := map[string]bool{}
+	var deletions []FileOperation
+	stage := func(f GeneratedFile) {
+		if seen[f.Filename] {
+			return
+		}
+		seen[f.Filename] = true
+		if f.Delete {
+			// Staged once they are approved, after the reply
+			deletions = append(deletions, FileOperation{Action: "delete", From: f.Filename})
+			return
+		}
+		before := len(tx.Changes())
+		if err := tx.Stage(f.Filename, []byte(f.Content)); err != nil {
+			fmt.Printf("\033[38;5;9mRefusing to write '%s': %v\033[0m\n", f.Filename, err)
+			return
+		}
+		if changes := tx.Changes(); len(changes) > before {
+			c := changes[len(changes)-1]
+			fmt.Printf("\033[38;5;10m  ✓ %s\033[0m \033[38;5;240m(%s)\033[0m\n", c.RelPath, describeChange(c))
+		}
+	}
+
+	response, err := streamFiles(client, modelName, prompt, system, temperature, "Generating files", stage)
+	if err != nil {
+		return "", fmt.Errorf("error generating JSON: %w", err)
+	}
+	files, err := ParseGeneratedFilesJSON(response)
+	if err != nil {
+		if len(seen) == 0 {
+			return "", fmt.Errorf("error parsing JSON response: %w\nResponse was: %s", err, response)
+		}
+		fmt.Printf("\033[38;5;214mWarning: the reply broke off; only the %d complete file(s) are proposed\033[0m\n", len(seen))
+	}
+	for _, f := range files {
+		stage(f)
+	}
+	stageFileOperations(cfg, sess, tx, deletions)
+	return applyGeneratedFiles(cfg, sess, tx, input)
+}
+
+// describeChange summarizes a staged write, e.g. "new, 40 lines" or "+3 -1 lines"
+func describeChange(c safeio.Change) string {
+	added, removed := diff.Stat(string(c.Original), string(c.Content))
+	if !c.Existed {
+		return fmt.Sprintf("new, %d lines", added)
+	}
+	return fmt.Sprintf("+%d -%d lines", added, removed)
+}
@@ -0,0 +1,43 @@
+package modes
+
+import "testing"
+
+func TestFileObjectScanner_Array(t *testing.T) {
+	reply := `[{"filename": "a.go", "content": "x := \"}{\"\n"}, {"filename": "b/c.txt", "content": "[\\\"]"}]`
+	scan := newFileObjectScanner()
+	var got []GeneratedFile
+	// Feed the reply a few bytes at a time, as it would stream in
+	for i := 0; i < len(reply); i += 3 {
+		end := min(i+3, len(reply))
+		got = append(got, scan.Write(reply[i:end])...)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 files, got %d: %+v", len(got), got)
+	}
+	if got[0].Filename != "a.go" || got[0].Content != "x := \"}{\"\n" {
+		t.Errorf("unexpected first file: %+v", got[0])
+	}
+	if got[1].Filename != "b/c.txt" || got[1].Content != `[\"]` {
+		t.Errorf("unexpected second file: %+v", got[1])
+	}
+	if scan.Len() != len(reply) {
+		t.Errorf("expected %d bytes, got %d", len(reply), scan.Len())
+	}
+}
+
+func TestFileObjectScanner_SingleObject(t *testing.T) {
+	scan := newFileObjectScanner()
+	if files := scan.Write(`{"filename": "main.go", "content": "pack`); len(files) != 0 {
+		t.Fatalf("expected no complete files yet, got %+v", files)
+	}
+	if got := scan.Current(); got != "main.go" {
+		t.Errorf("expected main.go to be arriving, got %q", got)
+	}
+	files := scan.Write(`age main\n", "summary": "done"}`)
+	if len(files) != 1 || files[0].Filename != "main.go" || files[0].Content != "package main\n" {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+	if got := scan.Current(); got != "" {
+		t.Errorf("expected no file to be arriving, got %q", got)
+	}
+}
@@ -0,0 +1,39 @@
+package modes
+
+import (
+	"fmt"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+// ResponseLimit returns the most tokens an answer in mode may have, 0 for no limit:
+// ollama.num_predict's value for the mode, lowered to ui.brief_tokens while /brief is
+// on. Brief leaves the modes that write files alone, since a cap would cut a file short.
+func ResponseLimit(cfg *config.Config, mode string) int {
+	limit := cfg.Ollama.NumPredict[mode]
+	if cfg.UI.Brief && !WritesFiles(cfg, mode) && (limit == 0 || cfg.UI.BriefTokens < limit) {
+		limit = cfg.UI.BriefTokens
+	}
+	return limit
+}
+
+// LimitLength caps the answers client generates at mode's ResponseLimit, until the
+// returned func puts the previous cap back
+func LimitLength(client *ollama.Client, cfg *config.Config, mode string) func() {
+	previous := client.NumPredict
+	client.NumPredict = ResponseLimit(cfg, mode)
+	return func() { client.NumPredict = previous }
+}
+
+// LengthPrompt asks for an answer that fits mode's cap, so the model wraps up before the
+// cap cuts it off. It is empty without a cap, and for modes that answer with files.
+func LengthPrompt(cfg *config.Config, mode string) string {
+	limit := ResponseLimit(cfg, mode)
+	if limit == 0 || WritesFiles(cfg, mode) {
+		return ""
+	}
+	// About three words to four tokens, rounded down to ten
+	words := max(limit*3/4/10*10, 10)
+	return fmt.Sprintf("\n\nKeep the answer short: at most about %d words. Answer directly, without an introduction or a closing summary.", words)
+}
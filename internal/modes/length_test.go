@@ -0,0 +1,46 @@
+package modes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+func TestResponseLimit(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.UI.BriefTokens = 300
+	cfg.CustomModes = []config.CustomModeConfig{{Name: "scaffold", Output: "files"}, {Name: "tldr"}}
+	cfg.Ollama.NumPredict = map[string]int{"ask": 800, "cmd": 100, "edit": 4000}
+
+	tests := []struct {
+		brief bool
+		mode  string
+		want  int
+	}{
+		{false, "ask", 800},
+		{false, "plan", 0},
+		{false, "edit", 4000},
+		{true, "ask", 300},
+		{true, "plan", 300},
+		{true, "cmd", 100},
+		{true, "tldr", 300},
+		{true, "edit", 4000},
+		{true, "agent", 0},
+		{true, "scaffold", 0},
+	}
+	for _, tt := range tests {
+		cfg.UI.Brief = tt.brief
+		if got := ResponseLimit(cfg, tt.mode); got != tt.want {
+			t.Errorf("brief %v, %s: got %d, want %d", tt.brief, tt.mode, got, tt.want)
+		}
+	}
+
+	cfg.UI.Brief = true
+	if got := LengthPrompt(cfg, "ask"); !strings.Contains(got, "at most about 220 words") {
+		t.Errorf("unexpected length prompt %q", got)
+	}
+	if got := LengthPrompt(cfg, "edit"); got != "" {
+		t.Errorf("expected no length prompt for a mode that writes files, got %q", got)
+	}
+}
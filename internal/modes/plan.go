@@ -5,13 +5,12 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
 	"github.com/yourusername/llamasidekick/internal/ollama"
-	"github.com/yourusername/llamasidekick/internal/renderer"
+	"github.com/yourusername/llamasidekick/internal/progress"
 	"github.com/yourusername/llamasidekick/internal/session"
 )
 
@@ -77,29 +76,30 @@ func (m *PlanMode) ProcessInput(client *ollama.Client, sess *session.Session, cf
 	sess.SetMode(ModePlan)
 	modelName := cfg.GetModelForMode("plan")
 
-	enhancedInput := ReadFilesFromInputWithRoot(input, sess.ProjectRoot)
+	enhancedInput := ReadInputContext(client, input, sess, cfg.Context)
 	sess.AddMessage("user", input)
 
 	conversationContext := BuildConversationContext(sess, enhancedInput)
 
+	defer LimitLength(client, cfg, ModePlan)()
+
 	// Start spinner
-	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-	s.Suffix = " Thinking..."
+	s := NewModelStatus(ModePlan, modelName)
 	s.Start()
 
-	var fullResponse strings.Builder
+	md := NewResponseDisplay(cfg, ModePlan, func() {
+		s.Stop()
+		fmt.Print(lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Render("\nAssistant: "))
+		fmt.Println()
+	})
 	err := client.GenerateWithModel(
 		modelName,
 		conversationContext,
-		m.GetSystemPrompt(),
+		ProjectSystemPrompt(ModePlan, m.GetSystemPrompt(), sess.ProjectRoot, cfg.Context)+LengthPrompt(cfg, ModePlan),
 		cfg.Ollama.Temperature,
 		func(chunk string) error {
-			if s.Active() {
-				s.Stop()
-				fmt.Print(lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Render("\nAssistant: "))
-				fmt.Println()
-			}
-			fullResponse.WriteString(chunk)
+			s.Token()
+			md.Write(chunk)
 			return nil
 		},
 	)
@@ -111,9 +111,8 @@ func (m *PlanMode) ProcessInput(client *ollama.Client, sess *session.Session, cf
 		return fmt.Errorf("error generating response: %w", err)
 	}
 
-	markdown := fullResponse.String()
-	renderedMd := renderer.RenderMarkdown(markdown)
-	fmt.Print(renderedMd)
+	markdown := md.String()
+	fmt.Print(md.Finish())
 	fmt.Println()
 
 	sess.AddMessage("assistant", markdown)
@@ -152,9 +151,11 @@ func (m *PlanMode) Run(client *ollama.Client, sess *session.Session, cfg *config
 		}
 		
 		if err := m.ProcessInput(client, sess, cfg, input); err != nil {
-			fmt.Printf("\nError: %v\n", err)
+			fmt.Println()
+			hint.Print(err)
 			continue
 		}
+		progress.Announce("Done")
 	}
 	
 	return nil
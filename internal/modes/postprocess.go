@@ -0,0 +1,89 @@
+package modes
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+	"github.com/yourusername/llamasidekick/internal/shellcmd"
+)
+
+// maxLintOutputBytes bounds the linter output added to the conversation per file
+const maxLintOutputBytes = 4 * 1024
+
+// postProcess runs the configured formatters and then the linters on the files that were
+// just written. Formatting is applied in place; lint findings are printed and added to the
+// conversation so the next prompt can address them.
+func postProcess(cfg *config.Config, sess *session.Session, changes []safeio.Change) {
+	if !cfg.Format.Enabled {
+		return
+	}
+	var findings []string
+	for _, c := range changes {
+		if c.Removed {
+			continue
+		}
+		for _, tool := range matchingTools(cfg.Format.Formatters, c.RelPath) {
+			before, _ := os.ReadFile(c.AbsPath)
+			if _, err := runTool(sess.ProjectRoot, tool, c.RelPath); err != nil {
+				fmt.Printf("\033[38;5;214mWarning: %s failed on %s: %s\033[0m\n", shellcmd.Program(tool.Command), c.RelPath, err)
+				continue
+			}
+			if after, err := os.ReadFile(c.AbsPath); err == nil && !bytes.Equal(before, after) {
+				promptReads.Record(c.AbsPath, after)
+				fmt.Printf("\033[38;5;240m  Formatted %s with %s\033[0m\n", c.RelPath, shellcmd.Program(tool.Command))
+			}
+		}
+		for _, tool := range matchingTools(cfg.Format.Linters, c.RelPath) {
+			output, err := runTool(sess.ProjectRoot, tool, c.RelPath)
+			if err == nil {
+				continue
+			}
+			if output == "" {
+				output = err.Error()
+			}
+			program := shellcmd.Program(tool.Command)
+			fmt.Printf("\033[38;5;214m%s found problems in %s:\033[0m\n\033[38;5;240m%s\033[0m\n", program, c.RelPath, output)
+			findings = append(findings, fmt.Sprintf("%s on %s:\n%s", program, c.RelPath, tail(output, maxLintOutputBytes)))
+		}
+	}
+	if len(findings) > 0 {
+		sess.AddMessage("assistant", "Linter findings in the files just written:\n\n"+strings.Join(findings, "\n\n"))
+	}
+}
+
+// matchingTools returns the tools whose file patterns match relPath and whose program
+// is installed
+func matchingTools(tools []config.ToolCommand, relPath string) []config.ToolCommand {
+	var matched []config.ToolCommand
+	for _, tool := range tools {
+		if !pathmatch.MatchAny(tool.Files, relPath) {
+			continue
+		}
+		if _, err := exec.LookPath(shellcmd.Program(tool.Command)); err != nil {
+			slog.Debug("skipping tool that is not installed", "command", tool.Command)
+			continue
+		}
+		matched = append(matched, tool)
+	}
+	return matched
+}
+
+// runTool runs tool on relPath in root and returns its trimmed output
+func runTool(root string, tool config.ToolCommand, relPath string) (string, error) {
+	cmd := shellcmd.Command(strings.ReplaceAll(tool.Command, "{file}", shellcmd.Quote(relPath)))
+	cmd.Dir = root
+	out, err := cmd.CombinedOutput()
+	output := strings.TrimSpace(string(out))
+	if err != nil && output != "" {
+		return output, fmt.Errorf("%w: %s", err, firstLine(output))
+	}
+	return output, err
+}
@@ -0,0 +1,42 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestPostProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	root := t.TempDir()
+	absPath := filepath.Join(root, "main.go")
+	if err := os.WriteFile(absPath, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Format: config.FormatConfig{
+		Enabled: true,
+		Formatters: []config.ToolCommand{
+			{Files: []string{"*.go"}, Command: "printf 'formatted\\n' > {file}"},
+			{Files: []string{"*.py"}, Command: "printf 'wrong file\\n' > {file}"},
+			{Files: []string{"*.go"}, Command: "llamasidekick-missing-formatter {file}"},
+		},
+		Linters: []config.ToolCommand{{Files: []string{"*.go"}, Command: "echo unused variable in {file}; exit 1"}},
+	}}
+	sess := session.New(root)
+	postProcess(cfg, sess, []safeio.Change{{RelPath: "main.go", AbsPath: absPath}})
+
+	if content, _ := os.ReadFile(absPath); string(content) != "formatted\n" {
+		t.Fatalf("formatter not applied, content is %q", content)
+	}
+	if len(sess.History) != 1 || !strings.Contains(sess.History[0].Content, "unused variable in main.go") {
+		t.Fatalf("expected lint findings in the conversation, got %+v", sess.History)
+	}
+}
@@ -0,0 +1,43 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+// ResolveSystemPrompt returns the system prompt for modeKey, applying user overrides
+// from the config dir. prompts/<mode>.md replaces the built-in prompt entirely and
+// prompts/<mode>.append.md is appended to whichever prompt is in effect.
+func ResolveSystemPrompt(modeKey string, builtin string) string {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return builtin
+	}
+	promptsDir := filepath.Join(configDir, "prompts")
+
+	prompt := builtin
+	if override, ok := readPromptFile(filepath.Join(promptsDir, modeKey+".md")); ok {
+		prompt = override
+	}
+	if extra, ok := readPromptFile(filepath.Join(promptsDir, modeKey+".append.md")); ok {
+		prompt = prompt + "\n\n" + extra
+	}
+
+	return prompt
+}
+
+// readPromptFile reads a prompt file, treating missing or blank files as absent.
+func readPromptFile(path string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return "", false
+	}
+	return content, true
+}
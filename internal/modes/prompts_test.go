@@ -0,0 +1,61 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+func TestResolveSystemPrompt_Overrides(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", tmp)
+
+	if got := ResolveSystemPrompt(ModeEdit, "builtin"); got != "builtin" {
+		t.Fatalf("expected builtin prompt, got %q", got)
+	}
+
+	promptsDir := filepath.Join(tmp, "prompts")
+	if err := os.MkdirAll(promptsDir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(promptsDir, "edit.append.md"), []byte("extra rules\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if got := ResolveSystemPrompt(ModeEdit, "builtin"); got != "builtin\n\nextra rules" {
+		t.Fatalf("expected appended prompt, got %q", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(promptsDir, "edit.md"), []byte("custom"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if got := ResolveSystemPrompt(ModeEdit, "builtin"); got != "custom\n\nextra rules" {
+		t.Fatalf("expected replaced prompt, got %q", got)
+	}
+	if got := ResolveSystemPrompt(ModePlan, "builtin"); got != "builtin" {
+		t.Fatalf("expected other modes unaffected, got %q", got)
+	}
+}
+
+func TestProjectSystemPrompt_Stack(t *testing.T) {
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", t.TempDir())
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	limits := config.DefaultContextConfig()
+	if got := ProjectSystemPrompt(ModeAsk, "builtin", root, limits); !strings.HasPrefix(got, "builtin\n\nThe project is written in Go.") {
+		t.Fatalf("expected the detected stack, got %q", got)
+	}
+	limits.Stack = "Python 3.12 with Django"
+	if got := ProjectSystemPrompt(ModeAsk, "builtin", root, limits); !strings.Contains(got, "written in Python 3.12 with Django.") {
+		t.Fatalf("expected the configured stack, got %q", got)
+	}
+	limits.Stack = "off"
+	if got := ProjectSystemPrompt(ModeAsk, "builtin", root, limits); got != "builtin" {
+		t.Fatalf("expected no stack when off, got %q", got)
+	}
+}
@@ -0,0 +1,140 @@
+package modes
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/secrets"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// RangeEdit is the outcome of editing a line range of a file
+type RangeEdit struct {
+	Path        string `json:"path"`
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+	Summary     string `json:"summary"`
+	Diff        string `json:"diff"`
+	Written     bool   `json:"written"`
+}
+
+// rangeEditResult is the JSON object a range edit asks the model for
+type rangeEditResult struct {
+	Replacement string `json:"replacement"`
+	Summary     string `json:"summary"`
+}
+
+// EditRange asks the model to rewrite lines startLine..endLine (1-based, inclusive) of
+// path and passes the result through the same write pipeline as Edit mode, so dry-run,
+// approval hooks, write policy and backups all apply
+func EditRange(client *ollama.Client, sess *session.Session, cfg *config.Config, path string, startLine, endLine int, instruction string) (*RangeEdit, error) {
+	sess.SetMode(ModeEdit)
+	absPath, relPath, err := safeio.ResolveWithinRoot(sess.ProjectRoot, path)
+	if err != nil {
+		return nil, fmt.Errorf("refusing to edit '%s': %w", path, err)
+	}
+	backups, err := OpenBackupStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := backups.Policy.Check(absPath, relPath); err != nil {
+		return nil, fmt.Errorf("refusing to edit '%s': %w", relPath, err)
+	}
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s: %w", relPath, err)
+	}
+	promptReads.Record(absPath, content)
+	before, selected, after, err := splitLineRange(string(content), startLine, endLine)
+	if err != nil {
+		return nil, err
+	}
+
+	request := fmt.Sprintf("Edit %s lines %d-%d: %s", relPath, startLine, endLine, instruction)
+	enhancedInput := ReadInputContext(client, request, sess, cfg.Context)
+	sess.AddMessage("user", request)
+
+	jsonSystemPrompt := "You MUST respond with ONLY a valid JSON object. No markdown, no explanations, no extra text.\n\n" +
+		"The object must have exactly these fields:\n" +
+		"- replacement: string (the new text for the selected lines only, not the whole file)\n" +
+		"- summary: string (brief description of changes made)\n\n" +
+		"Output ONLY the JSON object. Any other text will cause failure."
+	editPrompt := fmt.Sprintf("File: %s\n\nFull file for reference:\n%s\n\nSelected lines %d-%d:\n%s\n\nUser request: %s\n\nProvide the replacement for the selected lines.",
+		relPath, numberLines(redactedForPrompt(string(content), cfg.Context)), startLine, endLine, redactedForPrompt(selected, cfg.Context), instruction)
+	fullPrompt := BuildConversationContext(sess, enhancedInput) + "\n\n" + editPrompt
+
+	jsonResponse, err := client.GenerateJSON(cfg.GetModelForMode("edit"), fullPrompt, jsonSystemPrompt, 0.3)
+	if err != nil {
+		return nil, fmt.Errorf("error generating JSON: %w", err)
+	}
+	var result rangeEditResult
+	if err := json.Unmarshal([]byte(jsonResponse), &result); err != nil {
+		return nil, invalidJSON(fmt.Errorf("error parsing JSON response: %w\nResponse was: %s", err, jsonResponse))
+	}
+	slog.Debug("parsed range edit", "path", relPath, "start", startLine, "end", endLine, "summary_chars", len(result.Summary))
+
+	// Keep the line structure around the range intact. The file was sent with line
+	// numbers, which the model may have copied.
+	replacement := stripLineNumbers(result.Replacement)
+	if cfg.Context.Secrets != "off" {
+		replacement = secrets.Unredact(selected, replacement)
+	}
+	if strings.HasSuffix(selected, "\n") && replacement != "" && !strings.HasSuffix(replacement, "\n") {
+		replacement += "\n"
+	}
+
+	tx := backups.Begin(sess.ProjectRoot)
+	if err := tx.Stage(relPath, []byte(before+replacement+after)); err != nil {
+		return nil, fmt.Errorf("error staging file: %w", err)
+	}
+	edit := &RangeEdit{
+		Path:        relPath,
+		StartLine:   startLine,
+		EndLine:     endLine,
+		Original:    selected,
+		Replacement: replacement,
+		Summary:     result.Summary,
+		Diff:        tx.Diff(),
+	}
+	if edit.Written, err = applyTransaction(cfg, sess, tx, result.Summary); err != nil {
+		return nil, fmt.Errorf("error writing file: %w", err)
+	}
+
+	sess.SetLastEditedFile(relPath)
+	responseText := fmt.Sprintf("Modified %s lines %d-%d: %s", relPath, startLine, endLine, result.Summary)
+	if !edit.Written {
+		responseText = fmt.Sprintf("Proposed changes to %s lines %d-%d (not written): %s", relPath, startLine, endLine, result.Summary)
+	}
+	sess.AddMessage("assistant", responseText)
+	if err := sess.Save(); err != nil {
+		fmt.Printf("Warning: failed to save session: %v\n", err)
+	}
+	return edit, nil
+}
+
+// splitLineRange splits text around lines start..end (1-based, inclusive). Each part
+// keeps its line endings so the parts concatenate back to text.
+func splitLineRange(text string, start, end int) (before, selected, after string, err error) {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if start < 1 || end < start || end > len(lines) {
+		return "", "", "", fmt.Errorf("invalid line range %d-%d (file has %d lines)", start, end, len(lines))
+	}
+	return strings.Join(lines[:start-1], ""), strings.Join(lines[start-1:end], ""), strings.Join(lines[end:], ""), nil
+}
+
+// SelectLines returns lines start..end (1-based, inclusive) of text
+func SelectLines(text string, start, end int) (string, error) {
+	_, selected, _, err := splitLineRange(text, start, end)
+	return selected, err
+}
@@ -0,0 +1,67 @@
+package modes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestSplitLineRange(t *testing.T) {
+	text := "one\ntwo\nthree\nfour"
+	before, selected, after, err := splitLineRange(text, 2, 3)
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	if before != "one\n" || selected != "two\nthree\n" || after != "four" {
+		t.Fatalf("unexpected split: %q %q %q", before, selected, after)
+	}
+	if before+selected+after != text {
+		t.Fatalf("parts don't rebuild the text")
+	}
+
+	if _, selected, after, err := splitLineRange("a\nb\n", 2, 2); err != nil || selected != "b\n" || after != "" {
+		t.Fatalf("unexpected last line split: %q %q %v", selected, after, err)
+	}
+
+	for _, r := range [][2]int{{0, 1}, {3, 2}, {1, 5}} {
+		if _, _, _, err := splitLineRange(text, r[0], r[1]); err == nil {
+			t.Fatalf("expected error for range %v", r)
+		}
+	}
+}
+
+func TestEditRange_StripsEchoedLineNumbers(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", t.TempDir())
+	root := t.TempDir()
+	path := filepath.Join(root, "list.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\nfour\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The numbers from the prompt's copy of the file came back with the lines
+		w.Write([]byte(`{"response": "{\"replacement\": \"2 | TWO\\n3 | THREE\\n\", \"summary\": \"shout\"}", "done": true}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{Context: config.DefaultContextConfig(), Backups: config.BackupsConfig{Keep: 10}}
+	sess := session.New(root)
+	sess.Ephemeral = true
+	sess.ApproveChanges = func(string) bool { return true }
+	edit, err := EditRange(ollama.NewClient(server.URL, "default"), sess, cfg, "list.txt", 2, 3, "upper-case these")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edit.Replacement != "TWO\nTHREE\n" {
+		t.Fatalf("expected the replacement without line numbers, got %q", edit.Replacement)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "one\nTWO\nTHREE\nfour\n" {
+		t.Fatalf("expected the range to be replaced without line numbers, got %q", content)
+	}
+}
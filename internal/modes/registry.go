@@ -0,0 +1,65 @@
+package modes
+
+import "github.com/yourusername/llamasidekick/internal/config"
+
+// Entry is a mode as it is offered to the user: in the menu, and as a slash command
+type Entry struct {
+	Key      string // The slash command and session mode, e.g. "plan"
+	Mode     Mode
+	ModelKey string // The config key of the mode's model, e.g. "models.plan"
+}
+
+// Registry lists every mode in the order they are offered: the built-in modes, then the
+// custom modes from config.yaml. The slash commands, their completion, the menu and
+// Configure Models are all built from it, so a mode added here appears everywhere.
+type Registry struct {
+	entries []Entry
+}
+
+// NewRegistry returns the modes available with cfg
+func NewRegistry(cfg *config.Config) *Registry {
+	entries := []Entry{
+		{Key: ModePlan, Mode: &PlanMode{}, ModelKey: "models.plan"},
+		{Key: ModeEdit, Mode: &EditMode{}, ModelKey: "models.edit"},
+		{Key: ModeAgent, Mode: &AgentMode{}, ModelKey: "models.agent"},
+		{Key: ModeCmd, Mode: &CmdMode{}, ModelKey: "models.cmd"},
+		{Key: ModeAsk, Mode: &AskMode{}, ModelKey: "models.ask"},
+	}
+	for _, c := range cfg.CustomModes {
+		entries = append(entries, Entry{Key: c.Name, Mode: &CustomMode{Config: c}, ModelKey: "models." + c.Name})
+	}
+	return &Registry{entries: entries}
+}
+
+// Entries returns the modes in order
+func (r *Registry) Entries() []Entry {
+	return r.entries
+}
+
+// Lookup returns the mode whose slash command is key
+func (r *Registry) Lookup(key string) (Entry, bool) {
+	for _, e := range r.entries {
+		if e.Key == key {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Keys returns the key of every mode, e.g. "plan"
+func (r *Registry) Keys() []string {
+	keys := make([]string, 0, len(r.entries))
+	for _, e := range r.entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+// Commands returns the slash command of every mode, e.g. "/plan"
+func (r *Registry) Commands() []string {
+	commands := make([]string, 0, len(r.entries))
+	for _, e := range r.entries {
+		commands = append(commands, "/"+e.Key)
+	}
+	return commands
+}
@@ -0,0 +1,235 @@
+package modes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/deps"
+	"github.com/yourusername/llamasidekick/internal/gitutil"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/tasks"
+)
+
+// maxRepoMapDeps bounds how many dependencies of each manifest the repo map lists
+const maxRepoMapDeps = 40
+
+// maxRepoMapTasks bounds how many tasks of each task file the repo map lists
+const maxRepoMapTasks = 30
+
+// repoMapInputs are the files a repo map is made from; it is reused until one of them changes
+var repoMapInputs = append([]string{"go.mod", "package.json", filepath.Join(".git", "HEAD")}, tasks.FileNames()...)
+
+// cachedRepoMap is a repo map with the state of its inputs when it was made
+type cachedRepoMap struct {
+	Key  string `json:"key"`
+	Text string `json:"text"`
+}
+
+var (
+	repoMapMu    sync.Mutex
+	repoMapCache = map[string]cachedRepoMap{}
+)
+
+// RepoMap describes the project for prompts: the current git branch, the dependencies
+// declared in go.mod and package.json, so suggestions stick to libraries the project has,
+// and the tasks of its Makefile, Taskfile or justfile, so they run those. It returns ""
+// when there is nothing to tell. The map is kept in memory and in the data dir until one
+// of those files or the checked out branch change, so a new run doesn't have to make it
+// again.
+func RepoMap(projectRoot string) string {
+	key, ok := repoMapKey(projectRoot)
+	if !ok {
+		return buildRepoMap(projectRoot)
+	}
+	repoMapMu.Lock()
+	defer repoMapMu.Unlock()
+	if c, ok := repoMapCache[projectRoot]; ok && c.Key == key {
+		return c.Text
+	}
+	path, err := repoMapPath(projectRoot)
+	if err == nil {
+		var c cachedRepoMap
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &c) == nil && c.Key == key {
+			repoMapCache[projectRoot] = c
+			return c.Text
+		}
+	}
+
+	c := cachedRepoMap{Key: key, Text: buildRepoMap(projectRoot)}
+	repoMapCache[projectRoot] = c
+	if err == nil {
+		if err := saveRepoMap(path, c); err != nil {
+			slog.Warn("failed to cache repo map", "root", projectRoot, "error", err)
+		}
+	}
+	return c.Text
+}
+
+// saveRepoMap writes a repo map to its cache file, replacing the file atomically
+func saveRepoMap(path string, c cachedRepoMap) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal repo map: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create repo map cache dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write repo map cache: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write repo map cache: %w", err)
+	}
+	return nil
+}
+
+// repoMapKey describes the state of the repo map's inputs. It reports false unless the
+// project root is a repository with a .git directory, as only then does .git/HEAD tell
+// when the branch changes (in a worktree .git is a file, and a subdirectory has none).
+func repoMapKey(projectRoot string) (string, bool) {
+	if info, err := os.Stat(filepath.Join(projectRoot, ".git")); err != nil || !info.IsDir() {
+		return "", false
+	}
+	var key strings.Builder
+	for _, name := range repoMapInputs {
+		if info, err := os.Stat(filepath.Join(projectRoot, name)); err == nil {
+			fmt.Fprintf(&key, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+		} else {
+			fmt.Fprintf(&key, "%s:-;", name)
+		}
+	}
+	return key.String(), true
+}
+
+// repoMapPath returns the file the repo map of projectRoot is cached in
+func repoMapPath(projectRoot string) (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		projectRoot = abs
+	}
+	sum := sha256.Sum256([]byte(projectRoot))
+	return filepath.Join(dataDir, "cache", "repomap", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// buildRepoMap makes the repo map of projectRoot
+func buildRepoMap(projectRoot string) string {
+	var lines []string
+	if branch, err := gitutil.Branch(projectRoot); err == nil {
+		lines = append(lines, "- Git branch: "+branch)
+	}
+	manifests, _ := deps.Load(projectRoot)
+	for _, m := range manifests {
+		line := fmt.Sprintf("- %s", m.File)
+		if m.Module != "" {
+			line += fmt.Sprintf(" (%s)", m.Module)
+		}
+		var listed []string
+		skipped := 0
+		for _, d := range m.Deps {
+			if d.Indirect && m.File == "go.mod" {
+				continue // Only the project's own requirements are useful to the model
+			}
+			if len(listed) == maxRepoMapDeps {
+				skipped++
+				continue
+			}
+			entry := d.Name + " " + d.Version
+			if d.Indirect {
+				entry += " (dev)"
+			}
+			listed = append(listed, entry)
+		}
+		if len(listed) == 0 {
+			line += ": no dependencies"
+		} else {
+			line += ": " + strings.Join(listed, ", ")
+		}
+		if skipped > 0 {
+			line += fmt.Sprintf(" and %d more", skipped)
+		}
+		lines = append(lines, line)
+	}
+	taskFiles, _ := tasks.Load(projectRoot)
+	for _, f := range taskFiles {
+		lines = append(lines, taskLine(f))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	text := "\n\nProject context:\n" + strings.Join(lines, "\n") + "\n"
+	if len(manifests) > 0 {
+		text += "Use only these dependencies and the standard library unless the user asks for a new one.\n"
+	}
+	if len(taskFiles) > 0 {
+		f := taskFiles[0]
+		text += fmt.Sprintf("When one of these tasks does what is asked, run it (e.g. `%s`) instead of the tools it wraps.\n", f.Command(f.Tasks[0].Name))
+	}
+	return text
+}
+
+// taskLine is the repo map line listing the tasks of a task file
+func taskLine(f tasks.File) string {
+	var listed []string
+	for i, t := range f.Tasks {
+		if i == maxRepoMapTasks {
+			listed = append(listed, fmt.Sprintf("and %d more", len(f.Tasks)-maxRepoMapTasks))
+			break
+		}
+		entry := t.Name
+		if t.Description != "" {
+			entry += " (" + t.Description + ")"
+		}
+		listed = append(listed, entry)
+	}
+	return fmt.Sprintf("- %s tasks, run with `%s`: %s", f.Name, f.Command("<task>"), strings.Join(listed, ", "))
+}
+
+// warnNewDependencies points out dependencies that the written files start to use
+// without them being declared, and dependencies added to go.mod or package.json
+func warnNewDependencies(projectRoot string, changes []safeio.Change) {
+	manifests, _ := deps.Load(projectRoot)
+	for _, c := range changes {
+		switch filepath.Base(c.RelPath) {
+		case "go.mod", "package.json":
+			if c.RelPath != filepath.Base(c.RelPath) {
+				continue // Only the manifests at the project root are tracked
+			}
+			parse := deps.ParseGoMod
+			if c.RelPath == "package.json" {
+				parse = deps.ParsePackageJSON
+			}
+			before, _ := parse(c.Original)
+			after, err := parse(c.Content)
+			if err != nil {
+				continue
+			}
+			for _, d := range deps.Added(before, after) {
+				fmt.Printf("\033[38;5;214m⚠ New dependency in %s: %s %s\033[0m\n", c.RelPath, d.Name, d.Version)
+			}
+			continue
+		}
+
+		existing := map[string]bool{}
+		for _, imp := range deps.UndeclaredImports(manifests, c.RelPath, string(c.Original)) {
+			existing[imp] = true
+		}
+		for _, imp := range deps.UndeclaredImports(manifests, c.RelPath, string(c.Content)) {
+			if !existing[imp] {
+				fmt.Printf("\033[38;5;214m⚠ %s imports %s, which the project doesn't declare as a dependency\033[0m\n", c.RelPath, imp)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoMap(t *testing.T) {
+	if got := RepoMap(t.TempDir()); got != "" {
+		t.Fatalf("expected an empty repo map, got %q", got)
+	}
+
+	root, git := newTestRepo(t, "feature/x")
+	goMod := "module example.com/app\n\ngo 1.23\n\nrequire (\n\tgithub.com/spf13/viper v1.18.2\n\tgolang.org/x/term v0.20.0 // indirect\n)\n"
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got := RepoMap(root)
+	for _, want := range []string{"- Git branch: feature/x", "- go.mod (example.com/app): github.com/spf13/viper v1.18.2\n", "Use only these dependencies"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "golang.org/x/term") {
+		t.Errorf("indirect requirements should be left out:\n%s", got)
+	}
+
+	// A new run reads the map from the data dir until the branch changes
+	repoMapCache = map[string]cachedRepoMap{}
+	if again := RepoMap(root); again != got {
+		t.Errorf("expected the cached repo map, got:\n%s", again)
+	}
+	git("checkout", "-q", "-b", "other")
+	if got := RepoMap(root); !strings.Contains(got, "- Git branch: other") {
+		t.Errorf("expected the new branch after a checkout:\n%s", got)
+	}
+}
+
+func TestRepoMap_Tasks(t *testing.T) {
+	root, _ := newTestRepo(t, "main")
+	if err := os.WriteFile(filepath.Join(root, "Makefile"), []byte("test: ## Run the tests\n\tgo test ./...\nlint:\n\tgolangci-lint run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got := RepoMap(root)
+	for _, want := range []string{"- Makefile tasks, run with `make <task>`: test (Run the tests), lint", "run it (e.g. `make test`)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+
+	// Changing the Makefile makes the map again
+	if err := os.WriteFile(filepath.Join(root, "Makefile"), []byte("build:\n\tgo build .\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := RepoMap(root); !strings.Contains(got, "build") || strings.Contains(got, "lint") {
+		t.Errorf("expected the new tasks after the Makefile changed:\n%s", got)
+	}
+}
@@ -0,0 +1,195 @@
+package modes
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/index"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// indexCache keeps the last index read from disk, so prompts only reload it after
+// "index build" has rewritten it
+var indexCache struct {
+	sync.Mutex
+	path    string
+	modTime time.Time
+	idx     *index.Index
+}
+
+// loadProjectIndex returns the index of root, or nil if it has none
+func loadProjectIndex(root string) *index.Index {
+	path, err := index.PathFor(root)
+	if err != nil {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	indexCache.Lock()
+	defer indexCache.Unlock()
+	if indexCache.path == path && indexCache.modTime.Equal(info.ModTime()) {
+		return indexCache.idx
+	}
+	idx, err := index.Load(path)
+	if err != nil {
+		slog.Warn("failed to load index", "path", path, "error", err)
+		return nil
+	}
+	indexCache.path, indexCache.modTime, indexCache.idx = path, info.ModTime(), idx
+	return idx
+}
+
+// lastRetrieval keeps the chunks picked for the last prompt for /why
+var lastRetrieval struct {
+	sync.Mutex
+	query  string
+	chunks []index.Ranked
+}
+
+// LastRetrieval returns the prompt of the last retrieval and the chunks it added, with the
+// techniques that ranked each one
+func LastRetrieval() (string, []index.Ranked) {
+	lastRetrieval.Lock()
+	defer lastRetrieval.Unlock()
+	return lastRetrieval.query, lastRetrieval.chunks
+}
+
+// RetrieveContext returns the index.top_k chunks of the project index most relevant to
+// input, with their file and lines, for adding to a prompt whose context so far is
+// enhanced. Chunks are ranked by three techniques - declarations of identifiers in the
+// prompt, exact matches of those identifiers, and embedding similarity - combined with
+// reciprocal rank fusion. Files already loaded into enhanced, ignored files and files
+// changed since they were indexed are left out, and the chunks stay within what remains
+// of context.max_total_tokens. Without an index it returns "".
+func RetrieveContext(client *ollama.Client, sess *session.Session, cfg *config.Config, input, enhanced string) string {
+	if cfg.Index.TopK <= 0 || strings.TrimSpace(input) == "" {
+		return ""
+	}
+	idx := loadProjectIndex(sess.ProjectRoot)
+	if idx == nil || len(idx.Files) == 0 {
+		return ""
+	}
+	budget := -1
+	if cfg.Context.MaxTotalTokens > 0 {
+		budget = cfg.Context.MaxTotalTokens - EstimateTokens(strings.TrimPrefix(enhanced, input))
+		if budget <= 0 {
+			return ""
+		}
+	}
+
+	keep := func(path string) bool {
+		return !pathmatch.MatchAny(cfg.Context.Ignore, path) && !strings.Contains(enhanced, "\n--- "+path+" ")
+	}
+	// Fetch extra candidates to make up for chunks of files changed since indexing
+	n := cfg.Index.TopK * 3
+	terms := index.Terms(input)
+	rankings := map[string][]index.Hit{
+		index.TechniqueSymbol:  idx.SymbolHits(terms, n, keep),
+		index.TechniqueKeyword: idx.KeywordHits(terms, n, keep),
+	}
+	if vectors, err := client.Embed(idx.Model, []string{input}); err != nil {
+		// Symbols and keywords still work without the embedding model
+		slog.Warn("index retrieval failed", "model", idx.Model, "error", err)
+	} else {
+		rankings[index.TechniqueSemantic] = idx.SemanticHits(vectors[0], n, keep)
+	}
+
+	current := map[string]bool{}
+	var b strings.Builder
+	var picked []index.Ranked
+	for _, r := range index.Fuse(rankings) {
+		if len(picked) == cfg.Index.TopK {
+			break
+		}
+		fresh, checked := current[r.Path]
+		if !checked {
+			path := filepath.Join(sess.ProjectRoot, filepath.FromSlash(r.Path))
+			content, err := os.ReadFile(path)
+			fresh = err == nil && safeio.Hash(content) == idx.Files[r.Path].Hash
+			current[r.Path] = fresh
+			if fresh {
+				promptReads.Record(path, content)
+			}
+		}
+		if !fresh {
+			continue
+		}
+		tokens := EstimateTokens(r.Chunk.Text)
+		if budget >= 0 && tokens > budget {
+			continue
+		}
+		budget -= tokens
+
+		picked = append(picked, r)
+		source := chunkSource(r)
+		fmt.Fprintf(&b, "\n--- %s ---\n", source)
+		text := redactSecrets(source, r.Chunk.Text, cfg.Context)
+		if cfg.Context.LineNumbers {
+			b.WriteString(numberLinesFrom(text, r.Chunk.StartLine, r.Chunk.EndLine))
+		} else {
+			b.WriteString(text)
+		}
+		fmt.Fprintf(&b, "\n--- End of %s ---\n", source)
+	}
+
+	lastRetrieval.Lock()
+	lastRetrieval.query, lastRetrieval.chunks = input, picked
+	lastRetrieval.Unlock()
+	if len(picked) == 0 {
+		return ""
+	}
+	if cfg.Ollama.Debug {
+		fmt.Print("\033[38;5;240m" + ExplainRetrieval(picked) + "\033[0m")
+	} else {
+		sources := make([]string, len(picked))
+		for i, r := range picked {
+			sources[i] = chunkSource(r)
+		}
+		fmt.Printf("\033[38;5;240m(Note: Added %s from the project index)\033[0m\n", strings.Join(sources, ", "))
+	}
+	return "\n\nRelevant code from the project index (file:lines):\n" + b.String()
+}
+
+// ExplainRetrieval describes why each chunk was retrieved: its fused score and the rank
+// and reason from every technique that found it
+func ExplainRetrieval(chunks []index.Ranked) string {
+	var b strings.Builder
+	for i, r := range chunks {
+		fmt.Fprintf(&b, "%d. %s (score %.4f)\n", i+1, chunkSource(r), r.Score)
+		for _, sig := range r.Signals {
+			fmt.Fprintf(&b, "     %-8s #%d  %s\n", sig.Technique, sig.Rank, sig.Detail)
+		}
+	}
+	return b.String()
+}
+
+// chunkSource returns the file:lines label of a retrieved chunk
+func chunkSource(r index.Ranked) string {
+	return fmt.Sprintf("%s:%d-%d", r.Path, r.Chunk.StartLine, r.Chunk.EndLine)
+}
+
+// numberLinesFrom prefixes the lines of a region with their line numbers in the file,
+// starting at first and padded to the width of last
+func numberLinesFrom(text string, first, last int) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	width := len(fmt.Sprint(last))
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%*d | %s", width, first+i, line)
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package modes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/index"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestRetrieveContext(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	files := map[string]string{
+		"auth.go":  "package app\n\nfunc Login() {}\n",
+		"db.go":    "package app\n\nfunc Query() {}\n",
+		"stale.go": "package app\n",
+	}
+	idx := index.New(root, "embed-model")
+	vectors := map[string][]float32{"auth.go": {1, 0}, "db.go": {0.7, 0.7}, "stale.go": {1, 0.1}}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		idx.Files[name] = &index.File{
+			Hash:   safeio.Hash([]byte(content)),
+			Chunks: []index.Chunk{{StartLine: 1, EndLine: 3, Text: strings.TrimSuffix(content, "\n"), Vector: vectors[name]}},
+		}
+	}
+	path, err := index.PathFor(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	// Changed after indexing, so its chunk no longer matches the file
+	os.WriteFile(filepath.Join(root, "stale.go"), []byte("package app\n\nfunc Changed() {}\n"), 0644)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"embeddings": [[1, 0]]}`))
+	}))
+	defer server.Close()
+	client := ollama.NewClient(server.URL, "model")
+	sess := session.New(root)
+	cfg := &config.Config{Context: config.DefaultContextConfig(), Index: config.IndexConfig{TopK: 2}}
+
+	got := RetrieveContext(client, sess, cfg, "how does login work?", "how does login work?")
+	if !strings.Contains(got, "--- auth.go:1-3 ---\n1 | package app") || !strings.Contains(got, "3 | func Login() {}") {
+		t.Fatalf("expected the auth.go chunk with line numbers, got:\n%s", got)
+	}
+	if !strings.Contains(got, "db.go:1-3") || strings.Contains(got, "stale.go") {
+		t.Fatalf("expected db.go and not the stale file, got:\n%s", got)
+	}
+
+	if _, chunks := LastRetrieval(); len(chunks) != 2 || chunks[0].Signals[0].Technique != index.TechniqueSemantic {
+		t.Fatalf("unexpected last retrieval %+v", chunks)
+	}
+
+	// An identifier in the prompt ranks the chunk declaring it first
+	got = RetrieveContext(client, sess, cfg, "what calls Query()?", "what calls Query()?")
+	if _, chunks := LastRetrieval(); len(chunks) == 0 || chunks[0].Path != "db.go" {
+		t.Fatalf("expected db.go first for Query, got:\n%s", got)
+	}
+
+	// Files already in the prompt aren't retrieved again
+	enhanced := "fix auth.go\n\nFile contents:\n\n--- auth.go (line numbers are for reference and not part of the file) ---\n..."
+	if got := RetrieveContext(client, sess, cfg, "fix auth.go", enhanced); strings.Contains(got, "auth.go:") {
+		t.Fatalf("auth.go was retrieved although it is loaded:\n%s", got)
+	}
+
+	cfg.Context.MaxTotalTokens = 1
+	if got := RetrieveContext(client, sess, cfg, "login", "login"); got != "" {
+		t.Fatalf("expected nothing within a budget of 1 token, got:\n%s", got)
+	}
+}
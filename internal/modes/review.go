@@ -0,0 +1,72 @@
+package modes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+// ModeReview is the prompt key of the diff review used by the pre-commit hook; its system
+// prompt can be customised with prompts/review.md like the other modes
+const ModeReview = "review"
+
+// reviewSystemPrompt asks for a short review ending in a machine-readable verdict
+const reviewSystemPrompt = `You are a senior engineer doing a quick pre-commit review of a staged git diff.
+
+Look only for real problems: bugs, crashes, security issues, leaked credentials, debugging leftovers and obviously unfinished code. Ignore style preferences.
+
+Keep it short: a bullet per problem with the file and what is wrong. If there are no real problems, say so in one sentence.
+
+End with exactly one final line:
+VERDICT: OK        (nothing that should stop the commit)
+VERDICT: PROBLEMS  (something that should be fixed first)`
+
+// ReviewResult is the outcome of a diff review
+type ReviewResult struct {
+	Review    string // The model's review, without the verdict line
+	Problems  bool   // The model found something that should be fixed first
+	Truncated bool   // The diff was cut to fit max_diff_bytes
+}
+
+// ReviewDiff asks the model for a fast review of a diff
+func ReviewDiff(client *ollama.Client, cfg *config.Config, diffText string) (ReviewResult, error) {
+	var result ReviewResult
+	if max := cfg.PreCommit.MaxDiffBytes; max > 0 && len(diffText) > max {
+		diffText = strings.ToValidUTF8(diffText[:max], "") + "\n... (diff truncated)"
+		result.Truncated = true
+	}
+
+	var b strings.Builder
+	err := client.GenerateWithModel(
+		cfg.GetModelForMode("ask"),
+		"Review this staged diff:\n\n```diff\n"+diffText+"\n```",
+		ResolveSystemPrompt(ModeReview, reviewSystemPrompt),
+		0.2,
+		func(chunk string) error {
+			b.WriteString(chunk)
+			return nil
+		},
+	)
+	if err != nil {
+		return result, fmt.Errorf("review failed: %w", err)
+	}
+	result.Review, result.Problems = parseVerdict(b.String())
+	return result, nil
+}
+
+// parseVerdict splits off the final VERDICT line. Reviews without one count as OK so a
+// confused model can't block commits.
+func parseVerdict(review string) (string, bool) {
+	lines := strings.Split(strings.TrimRight(review, "\n "), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.ToUpper(strings.Trim(strings.TrimSpace(lines[i]), "*`_"))
+		if strings.HasPrefix(line, "VERDICT:") {
+			verdict := strings.TrimSpace(strings.TrimPrefix(line, "VERDICT:"))
+			text := strings.TrimSpace(strings.Join(append(lines[:i:i], lines[i+1:]...), "\n"))
+			return text, strings.HasPrefix(verdict, "PROBLEM")
+		}
+	}
+	return strings.TrimSpace(review), false
+}
@@ -0,0 +1,19 @@
+package modes
+
+import "testing"
+
+func TestParseVerdict(t *testing.T) {
+	review, problems := parseVerdict("- main.go: nil map write\n\n**VERDICT: PROBLEMS**\n")
+	if !problems || review != "- main.go: nil map write" {
+		t.Fatalf("unexpected result: %q %v", review, problems)
+	}
+
+	if _, problems := parseVerdict("Looks good.\nVERDICT: OK"); problems {
+		t.Fatalf("expected OK verdict")
+	}
+
+	// No verdict never blocks
+	if review, problems := parseVerdict("Looks fine to me"); problems || review != "Looks fine to me" {
+		t.Fatalf("unexpected result: %q %v", review, problems)
+	}
+}
@@ -0,0 +1,104 @@
+package modes
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+var (
+	routeCmdPattern   = regexp.MustCompile(`(?i)\b(command|terminal|shell|bash|powershell|cli|one-liner)\b|^(how (do|can) i|what'?s the command)\b.*\b(list|find|kill|delete|remove|copy|move|check|show|install|run)\b`)
+	routeEditPattern  = regexp.MustCompile(`(?i)\b(fix|refactor|change|modify|update|rename|edit|rewrite|add .* to|remove .* from)\b`)
+	routeAgentPattern = regexp.MustCompile(`(?i)\b(create|generate|scaffold|build|write) (a |an |the |me )?(new )?(file|script|project|app|program|module|page)\b`)
+	routePlanPattern  = regexp.MustCompile(`(?i)\b(plan|roadmap|design|architect|break down|approach|strategy|steps to)\b`)
+)
+
+// RouteInput picks the mode best suited to a plain input using lightweight heuristics.
+// It is used when ui.default_mode is "auto"; anything that doesn't clearly ask for an
+// action is treated as a question.
+func RouteInput(input string) string {
+	trimmed := strings.TrimSpace(input)
+	switch {
+	case routeAgentPattern.MatchString(trimmed):
+		return ModeAgent
+	case detectFileInInput(trimmed) != "" && routeEditPattern.MatchString(trimmed):
+		return ModeEdit
+	case routeCmdPattern.MatchString(trimmed):
+		return ModeCmd
+	case routePlanPattern.MatchString(trimmed):
+		return ModePlan
+	default:
+		return ModeAsk
+	}
+}
+
+// RouteDecision is where the router sends a plain input
+type RouteDecision struct {
+	Mode   string
+	Model  string // Empty keeps the mode's configured model
+	Reason string
+}
+
+const routerSystemPrompt = `You route requests to a coding assistant. Pick the mode that best handles the request:
+%s
+Also rate the request "small" if a small, fast model can handle it (a short question, a one-line command, a small change to one file) or "large" if it needs a capable model (changes across files, new programs, design or debugging work).
+Answer with JSON only: {"mode": "<mode>", "size": "small" or "large", "reason": "<a few words>"}`
+
+// routerModes describes the built-in modes to the router model
+var routerModes = [][2]string{
+	{ModeAsk, "questions and explanations that don't change anything"},
+	{ModeCmd, "how to do something on the command line"},
+	{ModeEdit, "changes to existing files named in the request"},
+	{ModeAgent, "creating new files, scripts or projects"},
+	{ModePlan, "planning features and breaking down larger work"},
+}
+
+// ClassifyInput routes input with a classification pass on router.model, which also rates
+// how large a model the request needs. Without router.model, or if the model doesn't give
+// a usable answer, it falls back to the RouteInput heuristics.
+func ClassifyInput(client *ollama.Client, cfg *config.Config, input string) RouteDecision {
+	if cfg.Router.Model == "" {
+		return RouteDecision{Mode: RouteInput(input), Reason: "keywords"}
+	}
+
+	var list strings.Builder
+	valid := map[string]bool{}
+	for _, m := range routerModes {
+		fmt.Fprintf(&list, "- %s: %s\n", m[0], m[1])
+		valid[m[0]] = true
+	}
+	for _, m := range cfg.CustomModes {
+		description := m.Description
+		if description == "" {
+			description = "custom mode"
+		}
+		fmt.Fprintf(&list, "- %s: %s\n", m.Name, description)
+		valid[m.Name] = true
+	}
+
+	response, err := client.GenerateJSON(cfg.Router.Model, input, fmt.Sprintf(routerSystemPrompt, list.String()), 0)
+	var answer struct {
+		Mode   string `json:"mode"`
+		Size   string `json:"size"`
+		Reason string `json:"reason"`
+	}
+	if err == nil {
+		err = json.Unmarshal([]byte(response), &answer)
+	}
+	if err != nil || !valid[strings.ToLower(strings.TrimSpace(answer.Mode))] {
+		return RouteDecision{Mode: RouteInput(input), Reason: "keywords; the router model gave no usable answer"}
+	}
+
+	decision := RouteDecision{Mode: strings.ToLower(strings.TrimSpace(answer.Mode)), Reason: strings.TrimSpace(answer.Reason)}
+	switch strings.ToLower(answer.Size) {
+	case "small":
+		decision.Model = cfg.Router.SmallModel
+	case "large":
+		decision.Model = cfg.Router.LargeModel
+	}
+	return decision
+}
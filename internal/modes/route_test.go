@@ -0,0 +1,55 @@
+package modes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+func TestRouteInput(t *testing.T) {
+	cases := map[string]string{
+		"create a new script that backs up my photos": ModeAgent,
+		"fix the nil pointer in main.go":              ModeEdit,
+		"how do I find large files on disk":           ModeCmd,
+		"help me plan the auth rewrite":               ModePlan,
+		"what does a goroutine leak look like?":       ModeAsk,
+	}
+	for input, want := range cases {
+		if got := RouteInput(input); got != want {
+			t.Errorf("RouteInput(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestClassifyInput(t *testing.T) {
+	answer := `{"mode": "review", "size": "large", "reason": "needs a careful read"}`
+	var got ollama.GenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": answer, "done": true})
+	}))
+	defer server.Close()
+
+	client := ollama.NewClient(server.URL, "default")
+	cfg := &config.Config{
+		Router:      config.RouterConfig{Model: "tiny", SmallModel: "fast", LargeModel: "big"},
+		CustomModes: []config.CustomModeConfig{{Name: "review", Description: "review code for bugs"}},
+	}
+	decision := ClassifyInput(client, cfg, "look over the parser")
+	if decision != (RouteDecision{Mode: "review", Model: "big", Reason: "needs a careful read"}) {
+		t.Fatalf("unexpected decision %+v", decision)
+	}
+	if got.Model != "tiny" || !strings.Contains(got.System, "- review: review code for bugs") {
+		t.Fatalf("expected the router model to be asked about every mode, got %+v", got)
+	}
+
+	answer = `{"mode": "poetry", "size": "small"}`
+	if decision := ClassifyInput(client, cfg, "how do I find large files on disk"); decision.Mode != ModeCmd || decision.Model != "" {
+		t.Fatalf("expected an unknown mode to fall back to the heuristics, got %+v", decision)
+	}
+}
@@ -0,0 +1,392 @@
+package modes
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// scaffoldBatchFiles bounds how many files are generated per request, so each JSON reply
+// stays small enough for a local model to get right. scaffoldContextBytes bounds how much
+// already generated code later batches see, to keep imports and names consistent.
+const (
+	scaffoldBatchFiles   = 6
+	scaffoldContextBytes = 24000
+)
+
+const scaffoldPlanPrompt = `You design the file layout of new software projects.
+You MUST respond with ONLY a JSON object, no markdown and no explanations:
+{"files": [{"path": "cmd/app/main.go", "purpose": "Entry point: parses flags and starts the server"}]}
+
+Rules:
+- List files only, as relative paths with forward slashes; directories follow from them.
+- Include every file the project needs to build and run (manifest, configuration, README, tests) but no generated, vendored or binary files.
+- List at most %d files.
+- Give each file a one-line purpose that says what it contains.`
+
+// ScaffoldFile is one file of a scaffold plan
+type ScaffoldFile struct {
+	Path    string `json:"path"`
+	Purpose string `json:"purpose"`
+}
+
+// scaffoldGroup is a batch of files from one directory that are generated together
+type scaffoldGroup struct {
+	Dir   string
+	Files []ScaffoldFile
+}
+
+// parseScaffoldPlan reads the planned files from the model's reply: an object with a
+// "files" array or the bare array. Paths are cleaned, and files outside the project and
+// duplicates are dropped. It reports whether the plan was cut to maxFiles.
+func parseScaffoldPlan(jsonResponse string, maxFiles int) ([]ScaffoldFile, bool, error) {
+	var plan struct {
+		Files []ScaffoldFile `json:"files"`
+	}
+	trimmed := strings.TrimSpace(jsonResponse)
+	if err := json.Unmarshal([]byte(trimmed), &plan); err != nil {
+		if err := json.Unmarshal([]byte(trimmed), &plan.Files); err != nil {
+			return nil, false, invalidJSON(fmt.Errorf("invalid JSON for scaffold plan"))
+		}
+	}
+
+	seen := map[string]bool{}
+	var files []ScaffoldFile
+	for _, f := range plan.Files {
+		p := strings.TrimSpace(strings.ReplaceAll(f.Path, "\\", "/"))
+		if p == "" || strings.HasSuffix(p, "/") {
+			continue
+		}
+		p = path.Clean(p)
+		if path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") || seen[p] {
+			continue
+		}
+		seen[p] = true
+		files = append(files, ScaffoldFile{Path: p, Purpose: strings.Join(strings.Fields(f.Purpose), " ")})
+	}
+	if len(files) == 0 {
+		return nil, false, fmt.Errorf("the scaffold plan lists no files")
+	}
+	if maxFiles > 0 && len(files) > maxFiles {
+		return files[:maxFiles], true, nil
+	}
+	return files, false, nil
+}
+
+// treeNode is a directory or file in a rendered scaffold tree
+type treeNode struct {
+	name     string
+	file     *ScaffoldFile
+	children map[string]*treeNode
+}
+
+// renderScaffoldTree draws the planned files as a directory tree under root, with each
+// file's purpose. Files for which exists returns true are marked as replaced.
+func renderScaffoldTree(root string, files []ScaffoldFile, exists func(string) bool) string {
+	top := &treeNode{children: map[string]*treeNode{}}
+	for i := range files {
+		node := top
+		parts := strings.Split(files[i].Path, "/")
+		for j, part := range parts {
+			child := node.children[part]
+			if child == nil {
+				child = &treeNode{name: part, children: map[string]*treeNode{}}
+				node.children[part] = child
+			}
+			if j == len(parts)-1 {
+				child.file = &files[i]
+			}
+			node = child
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(root + "/\n")
+	var walk func(node *treeNode, indent string)
+	walk = func(node *treeNode, indent string) {
+		children := make([]*treeNode, 0, len(node.children))
+		for _, c := range node.children {
+			children = append(children, c)
+		}
+		// Directories first, then files, each alphabetically
+		sort.Slice(children, func(i, j int) bool {
+			iDir, jDir := len(children[i].children) > 0, len(children[j].children) > 0
+			if iDir != jDir {
+				return iDir
+			}
+			return children[i].name < children[j].name
+		})
+		for i, c := range children {
+			branch, next := "├── ", "│   "
+			if i == len(children)-1 {
+				branch, next = "└── ", "    "
+			}
+			if len(c.children) > 0 {
+				b.WriteString(indent + branch + c.name + "/\n")
+				walk(c, indent+next)
+				continue
+			}
+			line := indent + branch + c.name
+			if c.file.Purpose != "" {
+				line += " - " + c.file.Purpose
+			}
+			if exists != nil && exists(c.file.Path) {
+				line += " (replaces existing file)"
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	walk(top, "")
+	return b.String()
+}
+
+// scaffoldGroups splits the plan into batches of at most scaffoldBatchFiles files from the
+// same directory. The project root comes first, since its manifests tell later batches
+// the module path and dependencies; other directories follow by depth, then name.
+func scaffoldGroups(files []ScaffoldFile) []scaffoldGroup {
+	byDir := map[string][]ScaffoldFile{}
+	var dirs []string
+	for _, f := range files {
+		dir := path.Dir(f.Path)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], f)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		di, dj := scaffoldDepth(dirs[i]), scaffoldDepth(dirs[j])
+		if di != dj {
+			return di < dj
+		}
+		return dirs[i] < dirs[j]
+	})
+
+	var groups []scaffoldGroup
+	for _, dir := range dirs {
+		dirFiles := byDir[dir]
+		for start := 0; start < len(dirFiles); start += scaffoldBatchFiles {
+			end := min(start+scaffoldBatchFiles, len(dirFiles))
+			groups = append(groups, scaffoldGroup{Dir: dir, Files: dirFiles[start:end]})
+		}
+	}
+	return groups
+}
+
+func scaffoldDepth(dir string) int {
+	if dir == "." {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// Scaffold generates a whole project from description in Agent mode: the model designs
+// the file layout first, which is shown as a tree to approve or revise, and then writes
+// the files a directory at a time. All files are written together, or not at all.
+func Scaffold(client *ollama.Client, sess *session.Session, cfg *config.Config, description string) error {
+	if description == "" {
+		return fmt.Errorf("usage: /scaffold <description of the project>")
+	}
+	sess.SetMode(ModeAgent)
+	modelName := cfg.GetModelForMode("agent")
+
+	files, err := planScaffold(client, sess, cfg, modelName, description)
+	if err != nil || files == nil {
+		return err
+	}
+
+	backups, err := OpenBackupStore(cfg)
+	if err != nil {
+		return err
+	}
+	tx := backups.Begin(sess.ProjectRoot)
+
+	groups := scaffoldGroups(files)
+	var generated []GeneratedFile
+	var missing []string
+	for i, group := range groups {
+		dir := group.Dir
+		if dir == "." {
+			dir = "(project root)"
+		}
+		fmt.Printf("\033[38;5;75m[%d/%d]\033[0m %s \033[38;5;240m(%d file(s))\033[0m\n", i+1, len(groups), dir, len(group.Files))
+
+		got := generateScaffoldBatch(client, modelName, description, files, group.Files, generated)
+		// Ask once more for the files the model skipped, on their own
+		var skipped []ScaffoldFile
+		for _, f := range group.Files {
+			if _, ok := got[f.Path]; !ok {
+				skipped = append(skipped, f)
+			}
+		}
+		if len(skipped) > 0 {
+			for p, content := range generateScaffoldBatch(client, modelName, description, files, skipped, generated) {
+				got[p] = content
+			}
+		}
+
+		for _, f := range group.Files {
+			content, ok := got[f.Path]
+			if !ok {
+				fmt.Printf("\033[38;5;9m  ✗ %s was not generated\033[0m\n", f.Path)
+				missing = append(missing, f.Path)
+				continue
+			}
+			if err := tx.Stage(f.Path, []byte(content)); err != nil {
+				fmt.Printf("\033[38;5;9m  Refusing to write '%s': %v\033[0m\n", f.Path, err)
+				missing = append(missing, f.Path)
+				continue
+			}
+			generated = append(generated, GeneratedFile{Filename: f.Path, Content: content})
+			fmt.Printf("\033[38;5;10m  ✓ %s\033[0m \033[38;5;240m(%d lines)\033[0m\n", f.Path, strings.Count(content, "\n")+1)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Printf("\033[38;5;214mWarning: %d of %d file(s) could not be generated: %s\033[0m\n", len(missing), len(files), strings.Join(missing, ", "))
+	}
+
+	summary := "Scaffold: " + description
+	written, err := applyTransaction(cfg, sess, tx, summary)
+	if err != nil {
+		return fmt.Errorf("error writing files: %w", err)
+	}
+	fmt.Println()
+
+	var result strings.Builder
+	switch {
+	case written:
+		fmt.Fprintf(&result, "Scaffolded %d file(s):\n", len(tx.Changes()))
+	case len(tx.Changes()) == 0:
+		result.WriteString("No files were written\n")
+	default:
+		fmt.Fprintf(&result, "Proposed %d file(s) (not written):\n", len(tx.Changes()))
+	}
+	for _, f := range generated {
+		fmt.Fprintf(&result, "- %s\n", f.Filename)
+	}
+	sess.AddMessage("user", "/scaffold "+description)
+	sess.AddMessage("assistant", strings.TrimSpace(result.String()))
+	if err := sess.Save(); err != nil {
+		fmt.Printf("Warning: failed to save session: %v\n", err)
+	}
+	return nil
+}
+
+// planScaffold asks for the file layout and shows it until the user approves it, asks for
+// changes, or cancels, in which case it returns no files
+func planScaffold(client *ollama.Client, sess *session.Session, cfg *config.Config, modelName, description string) ([]ScaffoldFile, error) {
+	maxFiles := cfg.Agent.ScaffoldMaxFiles
+	systemPrompt := fmt.Sprintf(scaffoldPlanPrompt, maxFiles)
+	exists := func(p string) bool {
+		_, err := os.Stat(filepath.Join(sess.ProjectRoot, filepath.FromSlash(p)))
+		return err == nil
+	}
+	reader := bufio.NewReader(os.Stdin)
+
+	var files []ScaffoldFile
+	var changes string
+	for {
+		var prompt strings.Builder
+		fmt.Fprintf(&prompt, "Design the file layout for this project:\n%s\n", description)
+		if changes != "" {
+			prompt.WriteString("\nYour previous layout was:\n")
+			for _, f := range files {
+				fmt.Fprintf(&prompt, "- %s: %s\n", f.Path, f.Purpose)
+			}
+			fmt.Fprintf(&prompt, "\nRevise it as follows: %s\n", changes)
+		}
+
+		s := progress.NewStatus("Designing the project layout")
+		s.Start()
+		response, err := client.GenerateJSON(modelName, prompt.String(), systemPrompt, 0.2)
+		s.Stop()
+		if err != nil {
+			return nil, fmt.Errorf("error generating scaffold plan: %w", err)
+		}
+		planned, truncated, err := parseScaffoldPlan(response, maxFiles)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing scaffold plan: %w\nResponse was: %s", err, response)
+		}
+		files = planned
+
+		fmt.Println()
+		fmt.Print(renderScaffoldTree(filepath.Base(sess.ProjectRoot), files, exists))
+		if truncated {
+			fmt.Printf("\033[38;5;214m(The plan was cut to agent.scaffold_max_files = %d files)\033[0m\n", maxFiles)
+		}
+		fmt.Printf("\nGenerate these %d file(s)? [Y/n, or describe what to change] ", len(files))
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Println()
+			return nil, nil
+		}
+		answer = strings.TrimSpace(answer)
+		switch strings.ToLower(answer) {
+		case "", "y", "yes":
+			return files, nil
+		case "n", "no":
+			fmt.Println("\033[38;5;240mScaffold cancelled\033[0m")
+			return nil, nil
+		}
+		changes = answer
+	}
+}
+
+// generateScaffoldBatch asks for the contents of batch, showing the model the whole plan
+// and the files generated so far. It returns the contents by path; files the model
+// skipped or named differently are left out, and a failed request returns none.
+func generateScaffoldBatch(client *ollama.Client, modelName, description string, plan, batch []ScaffoldFile, generated []GeneratedFile) map[string]string {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "You are generating a new project:\n%s\n\nIts complete file layout:\n", description)
+	for _, f := range plan {
+		fmt.Fprintf(&prompt, "- %s: %s\n", f.Path, f.Purpose)
+	}
+
+	if len(generated) > 0 {
+		prompt.WriteString("\nFiles written so far:\n")
+		budget := scaffoldContextBytes
+		for _, f := range generated {
+			if len(f.Content) > budget {
+				fmt.Fprintf(&prompt, "\n--- %s (content omitted) ---\n", f.Filename)
+				continue
+			}
+			budget -= len(f.Content)
+			fmt.Fprintf(&prompt, "\n--- %s ---\n%s\n--- End of %s ---\n", f.Filename, f.Content, f.Filename)
+		}
+	}
+
+	prompt.WriteString("\nNow write ONLY these files, complete and consistent with the layout and the files above (same module path, package names and identifiers):\n")
+	want := make(map[string]bool, len(batch))
+	for _, f := range batch {
+		fmt.Fprintf(&prompt, "- %s: %s\n", f.Path, f.Purpose)
+		want[f.Path] = true
+	}
+
+	response, err := streamFiles(client, modelName, prompt.String(), generatedFilesPrompt, 0.3, "Writing", nil)
+	got := map[string]string{}
+	if err != nil {
+		fmt.Printf("\033[38;5;9m  Generation failed: %v\033[0m\n", err)
+		return got
+	}
+	files, err := ParseGeneratedFilesJSON(response)
+	if err != nil {
+		fmt.Printf("\033[38;5;9m  Generation failed: %v\033[0m\n", err)
+		return got
+	}
+	for _, f := range files {
+		p := path.Clean(strings.TrimPrefix(strings.ReplaceAll(strings.TrimSpace(f.Filename), "\\", "/"), "./"))
+		if want[p] {
+			got[p] = f.Content
+		}
+	}
+	return got
+}
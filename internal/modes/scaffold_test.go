@@ -0,0 +1,77 @@
+package modes
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseScaffoldPlan(t *testing.T) {
+	reply := `{"files": [
+		{"path": "./go.mod", "purpose": "Module definition"},
+		{"path": "cmd\\app\\main.go", "purpose": "Entry   point"},
+		{"path": "go.mod", "purpose": "Duplicate"},
+		{"path": "../outside.txt", "purpose": "Escapes the project"},
+		{"path": "/etc/passwd", "purpose": "Absolute"},
+		{"path": "internal/", "purpose": "A directory"}
+	]}`
+	files, truncated, err := parseScaffoldPlan(reply, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if truncated || len(files) != 2 {
+		t.Fatalf("expected 2 files, got %+v (truncated %v)", files, truncated)
+	}
+	if files[0].Path != "go.mod" || files[1].Path != "cmd/app/main.go" || files[1].Purpose != "Entry point" {
+		t.Fatalf("unexpected files %+v", files)
+	}
+
+	files, truncated, err = parseScaffoldPlan(`[{"path": "a.txt"}, {"path": "b.txt"}, {"path": "c.txt"}]`, 2)
+	if err != nil || !truncated || len(files) != 2 {
+		t.Fatalf("expected the bare array cut to 2 files, got %+v, %v, %v", files, truncated, err)
+	}
+
+	if _, _, err := parseScaffoldPlan(`{"files": []}`, 10); err == nil {
+		t.Fatal("expected an error for an empty plan")
+	}
+}
+
+func TestRenderScaffoldTree(t *testing.T) {
+	files := []ScaffoldFile{
+		{Path: "go.mod", Purpose: "Module definition"},
+		{Path: "main.go"},
+		{Path: "internal/api/server.go", Purpose: "HTTP server"},
+		{Path: "internal/api/routes.go", Purpose: "Routes"},
+		{Path: "cmd/app/main.go", Purpose: "Entry point"},
+	}
+	got := renderScaffoldTree("shop", files, func(p string) bool { return p == "main.go" })
+	want := `shop/
+├── cmd/
+│   └── app/
+│       └── main.go - Entry point
+├── internal/
+│   └── api/
+│       ├── routes.go - Routes
+│       └── server.go - HTTP server
+├── go.mod - Module definition
+└── main.go (replaces existing file)
+`
+	if got != want {
+		t.Fatalf("unexpected tree:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestScaffoldGroups(t *testing.T) {
+	files := []ScaffoldFile{{Path: "internal/api/server.go"}, {Path: "cmd/app/main.go"}, {Path: "go.mod"}}
+	for i := 0; i < scaffoldBatchFiles+1; i++ {
+		files = append(files, ScaffoldFile{Path: fmt.Sprintf("internal/model%d.go", i)})
+	}
+
+	var got []string
+	for _, g := range scaffoldGroups(files) {
+		got = append(got, fmt.Sprintf("%s:%d", g.Dir, len(g.Files)))
+	}
+	want := fmt.Sprintf("[.:1 internal:%d internal:1 cmd/app:1 internal/api:1]", scaffoldBatchFiles)
+	if fmt.Sprint(got) != want {
+		t.Fatalf("unexpected groups %v, want %s", got, want)
+	}
+}
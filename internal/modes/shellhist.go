@@ -0,0 +1,70 @@
+package modes
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/shellhist"
+)
+
+// shellHistoryTTL is how long the tools learned from the shell history are reused
+const shellHistoryTTL = 5 * time.Minute
+
+type cachedShellHistory struct {
+	prompt string
+	read   time.Time
+}
+
+var (
+	shellHistoryMu    sync.Mutex
+	shellHistoryCache = map[string]cachedShellHistory{}
+)
+
+// ShellHistoryPrompt describes the tools the user prefers, learned from their shell
+// history, for CMD mode's system prompt. It is empty unless cmd.shell_history is on.
+// Only program names go into the prompt, never the commands' arguments.
+func ShellHistoryPrompt(cfg config.CmdConfig) string {
+	if !cfg.ShellHistory {
+		return ""
+	}
+	path := cfg.HistoryFile
+	if path == "" {
+		var err error
+		if path, err = shellhist.DefaultFile(); err != nil {
+			slog.Warn("shell history unavailable", "error", err)
+			return ""
+		}
+	}
+	key := fmt.Sprintf("%s:%d", path, cfg.HistoryLines)
+
+	shellHistoryMu.Lock()
+	defer shellHistoryMu.Unlock()
+	if c, ok := shellHistoryCache[key]; ok && time.Since(c.read) < shellHistoryTTL {
+		return c.prompt
+	}
+	prompt := ""
+	if commands, err := shellhist.Read(path, cfg.HistoryLines); err != nil {
+		slog.Warn("shell history unavailable", "file", path, "error", err)
+	} else {
+		prompt = toolsPrompt(shellhist.Tools(commands))
+		slog.Debug("read shell history", "file", path, "commands", len(commands))
+	}
+	shellHistoryCache[key] = cachedShellHistory{prompt: prompt, read: time.Now()}
+	return prompt
+}
+
+// toolsPrompt tells the model which tools the user prefers and uses often
+func toolsPrompt(tools []shellhist.Tool) string {
+	var b strings.Builder
+	if prefs := shellhist.Preferences(tools); len(prefs) > 0 {
+		fmt.Fprintf(&b, "\n\nFrom the user's shell history, they prefer %s. Use their preferred tools when they fit the task.", strings.Join(prefs, ", "))
+	}
+	if frequent := shellhist.Frequent(tools, 3, 20); len(frequent) > 0 {
+		fmt.Fprintf(&b, "\n\nTools the user runs often: %s.", strings.Join(frequent, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package modes
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/stack"
+)
+
+// stackCacheTTL is how long a detected stack is reused before the project is scanned again
+const stackCacheTTL = 5 * time.Minute
+
+type cachedStack struct {
+	description string
+	detected    time.Time
+}
+
+var (
+	stackMu    sync.Mutex
+	stackCache = map[string]cachedStack{}
+)
+
+// ProjectSystemPrompt is ResolveSystemPrompt followed by the project's language and
+// frameworks (context.stack), so answers default to the project's idioms
+func ProjectSystemPrompt(modeKey, builtin, projectRoot string, limits config.ContextConfig) string {
+	prompt := ResolveSystemPrompt(modeKey, builtin)
+	if description := ProjectStack(projectRoot, limits); description != "" {
+		prompt += fmt.Sprintf("\n\nThe project is written in %s. Unless the user asks for something else, answer for this stack and follow its conventions and idioms.", description)
+	}
+	return prompt
+}
+
+// ProjectStack describes the stack of the project at projectRoot as context.stack sets
+// it: detected ("auto" or empty), "off", or the configured description
+func ProjectStack(projectRoot string, limits config.ContextConfig) string {
+	switch limits.Stack {
+	case "off":
+		return ""
+	case "", "auto":
+	default:
+		return limits.Stack
+	}
+	if projectRoot == "" {
+		return ""
+	}
+
+	stackMu.Lock()
+	defer stackMu.Unlock()
+	if c, ok := stackCache[projectRoot]; ok && time.Since(c.detected) < stackCacheTTL {
+		return c.description
+	}
+	description := stack.Detect(projectRoot, limits.Ignore).Describe()
+	slog.Debug("detected project stack", "root", projectRoot, "stack", description)
+	stackCache[projectRoot] = cachedStack{description: description, detected: time.Now()}
+	return description
+}
@@ -0,0 +1,21 @@
+package modes
+
+import "github.com/yourusername/llamasidekick/internal/progress"
+
+// statusLabels say what each built-in mode is doing while it waits for the model
+var statusLabels = map[string]string{
+	ModePlan:  "Planning",
+	ModeEdit:  "Working out the changes",
+	ModeAgent: "Working on it",
+	ModeCmd:   "Generating command",
+	ModeAsk:   "Thinking",
+}
+
+// NewModelStatus returns the spinner shown while mode waits for model to respond
+func NewModelStatus(mode, model string) *progress.Status {
+	label, ok := statusLabels[mode]
+	if !ok {
+		label = "Running /" + mode
+	}
+	return progress.NewStatus(label + " with " + model)
+}
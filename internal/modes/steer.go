@@ -0,0 +1,66 @@
+package modes
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// steering collects the messages the user types while Agent mode is working, so they
+// can redirect the run ("skip the tests", "use sqlite not postgres") without stopping it
+type steering struct {
+	enabled bool
+	notes   []string
+	stop    bool // The user typed /stop: answer with what has been gathered so far
+}
+
+// newSteering starts collecting steering messages when stdin is a terminal; piped input
+// is never read, so it stays available to whatever reads it next
+func newSteering() *steering {
+	return &steering{enabled: term.IsTerminal(int(os.Stdin.Fd()))}
+}
+
+// poll reads the lines typed since the last poll without waiting for more, and reports
+// whether any arrived
+func (s *steering) poll() bool {
+	if s == nil || !s.enabled || !stdinReady() {
+		return false
+	}
+	buf := make([]byte, 4096)
+	n, err := os.Stdin.Read(buf)
+	if err != nil || n == 0 {
+		return false
+	}
+	added := false
+	for _, line := range strings.Split(string(buf[:n]), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case line == "/stop":
+			s.stop = true
+			added = true
+			fmt.Println("\033[38;5;214m↳ Stopping here, answering with what was gathered so far\033[0m")
+		default:
+			s.notes = append(s.notes, line)
+			added = true
+			fmt.Printf("\033[38;5;214m↳ Steering: %s\033[0m\n", line)
+		}
+	}
+	return added
+}
+
+// stopped reports whether the user asked for an answer now
+func (s *steering) stopped() bool {
+	return s != nil && s.stop
+}
+
+// prompt returns the steering messages for the model, or "" if there are none
+func (s *steering) prompt() string {
+	if s == nil || len(s.notes) == 0 {
+		return ""
+	}
+	return "\n\nWhile you were working, the user added:\n- " + strings.Join(s.notes, "\n- ") +
+		"\nFollow these instructions; where they conflict with the original request, they win.\n"
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package modes
+
+// stdinReady reports whether a line can be read from stdin without blocking. There is no
+// non-blocking check on this platform, so steering is unavailable.
+func stdinReady() bool {
+	return false
+}
@@ -0,0 +1,84 @@
+package modes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/mcp"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+func TestSteeringPrompt(t *testing.T) {
+	var none *steering
+	if none.prompt() != "" || none.stopped() || none.poll() {
+		t.Fatal("expected a nil steering to do nothing")
+	}
+	s := &steering{notes: []string{"skip the tests step", "use sqlite not postgres"}}
+	if got := s.prompt(); !strings.Contains(got, "- skip the tests step\n- use sqlite not postgres\n") {
+		t.Fatalf("expected the notes in the prompt, got %q", got)
+	}
+}
+
+func TestRunToolLoop_StopsWhenSteeredTo(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"response": "{\"tool\": \"\"}", "done": true}` + "\n"))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{MCP: config.MCPConfig{MaxSteps: 3}}
+	tools := []AgentTool{{Server: "project", Tool: mcp.Tool{Name: "grep"}, run: func(map[string]interface{}) (string, error) { return "", nil }}}
+	client := ollama.NewClient(server.URL, "default")
+
+	if got := runToolLoop(client, cfg, t.TempDir(), "default", "task", tools, &steering{stop: true}); got != "" || requests != 0 {
+		t.Fatalf("expected no tool steps after /stop, got %q and %d requests", got, requests)
+	}
+	runToolLoop(client, cfg, t.TempDir(), "default", "task", tools, nil)
+	if requests != 1 {
+		t.Fatalf("expected one tool decision without steering, got %d", requests)
+	}
+}
+
+func TestConfirmToolCall_ReadsQueuedSteeringFirst(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("steering needs a non-blocking stdin check")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = oldStdin }()
+
+	// Typed while the model was choosing the tool
+	w.WriteString("use sqlite not postgres\n")
+	go func() {
+		// Answer once the steering line has been read, like a user at the prompt
+		for deadline := time.Now().Add(5 * time.Second); stdinReady() && time.Now().Before(deadline); {
+			time.Sleep(5 * time.Millisecond)
+		}
+		w.WriteString("y\n")
+	}()
+
+	steer := &steering{enabled: true}
+	if !confirmToolCall(steer) {
+		t.Fatal("expected the tool call to be confirmed")
+	}
+	if len(steer.notes) != 1 || steer.notes[0] != "use sqlite not postgres" {
+		t.Fatalf("expected the queued line to steer the run, got %q", steer.notes)
+	}
+
+	if confirmToolCall(&steering{enabled: true, stop: true}) {
+		t.Fatal("expected no tool call after /stop")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package modes
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// stdinReady reports whether a line can be read from stdin without blocking
+func stdinReady() bool {
+	fds := []unix.PollFd{{Fd: int32(os.Stdin.Fd()), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, 0)
+	return err == nil && n > 0 && fds[0].Revents&unix.POLLIN != 0
+}
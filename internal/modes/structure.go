@@ -0,0 +1,126 @@
+package modes
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/index"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+const (
+	// maxLocations bounds how many declarations or call sites a query lists
+	maxLocations = 50
+	// explainDeclarationLines is how many lines of each declaration are sent for explanation
+	explainDeclarationLines = 40
+)
+
+// FindDeclarations returns where name is declared in the project, answered from the
+// parsed declarations of the project index and of files changed since it was built
+func FindDeclarations(sess *session.Session, cfg *config.Config, name string) (*index.Structure, []index.Location, error) {
+	s, err := projectStructure(sess, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, s.Where(name), nil
+}
+
+// FindCallers returns the project's calls of the function or method name, each with the
+// function it is in
+func FindCallers(sess *session.Session, cfg *config.Config, name string) (*index.Structure, []index.Location, error) {
+	s, err := projectStructure(sess, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, s.Callers(name), nil
+}
+
+// symbolCaches keeps the symbol cache of each project read this run, so it is only read
+// from disk once
+var symbolCaches struct {
+	sync.Mutex
+	byRoot map[string]*index.SymbolCache
+}
+
+// projectSymbolCache returns the symbol cache of root, or nil if the data dir is unavailable
+func projectSymbolCache(root string) *index.SymbolCache {
+	symbolCaches.Lock()
+	defer symbolCaches.Unlock()
+	if c, ok := symbolCaches.byRoot[root]; ok {
+		return c
+	}
+	path, err := index.SymbolCachePathFor(root)
+	if err != nil {
+		return nil
+	}
+	if symbolCaches.byRoot == nil {
+		symbolCaches.byRoot = map[string]*index.SymbolCache{}
+	}
+	c := index.OpenSymbolCache(path)
+	symbolCaches.byRoot[root] = c
+	return c
+}
+
+// projectStructure reads the project's declarations and calls, reusing those cached for
+// files unchanged since the last query and those the index has for unchanged files
+func projectStructure(sess *session.Session, cfg *config.Config) (*index.Structure, error) {
+	cache := projectSymbolCache(sess.ProjectRoot)
+	s, err := index.LoadStructure(sess.ProjectRoot, loadProjectIndex(sess.ProjectRoot), index.OptionsFor(cfg), cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project symbols: %w", err)
+	}
+	if err := cache.Save(); err != nil {
+		slog.Warn("failed to save symbol cache", "root", sess.ProjectRoot, "error", err)
+	}
+	return s, nil
+}
+
+// FormatLocations lists locations as "path:line  text", with the declaration kind or the
+// calling function in front, up to maxLocations of them
+func FormatLocations(locations []index.Location) string {
+	var b strings.Builder
+	for i, loc := range locations {
+		if i == maxLocations {
+			fmt.Fprintf(&b, "... and %d more\n", len(locations)-maxLocations)
+			break
+		}
+		fmt.Fprintf(&b, "%s:%d  %s  %s\n", loc.Path, loc.Line, locationLabel(loc), loc.Text)
+	}
+	return b.String()
+}
+
+// locationLabel describes a location: its declaration kind, e.g. "method (Config).Save",
+// or the function a call is in
+func locationLabel(loc index.Location) string {
+	switch {
+	case loc.Symbol.Kind == "method":
+		return fmt.Sprintf("method (%s).%s", loc.Symbol.Recv, loc.Symbol.Name)
+	case loc.Symbol.Kind != "":
+		return loc.Symbol.Kind + " " + loc.Symbol.Name
+	case loc.Caller != "":
+		return "in " + loc.Caller
+	default:
+		return "at top level"
+	}
+}
+
+// ExplainLocationsPrompt builds the prompt asking the model to explain the result of a
+// /where or /callers query. Declarations are sent with their first lines of source.
+func ExplainLocationsPrompt(s *index.Structure, query string, locations []index.Location) string {
+	var b strings.Builder
+	b.WriteString("Explain the result of this lookup in the project, found by parsing its source:\n\n")
+	b.WriteString(query + "\n\n")
+	b.WriteString(FormatLocations(locations))
+	for i, loc := range locations {
+		if i == maxLocations || loc.Symbol.Kind == "" {
+			break
+		}
+		last := loc.Line + explainDeclarationLines - 1
+		fmt.Fprintf(&b, "\n--- %s:%d ---\n%s\n--- End of %s:%d ---\n", loc.Path, loc.Line,
+			numberLinesFrom(s.Lines(loc.Path, loc.Line, last), loc.Line, last), loc.Path, loc.Line)
+	}
+	return b.String()
+}
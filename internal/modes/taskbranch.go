@@ -0,0 +1,74 @@
+package modes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/gitutil"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// maxBranchWords limits how much of the request ends up in a task branch name
+const maxBranchWords = 5
+
+// branchFillerWords are left out of task branch names
+var branchFillerWords = map[string]bool{"a": true, "an": true, "the": true, "please": true, "can": true, "you": true, "me": true}
+
+// useTaskBranch makes sure Agent mode works on a task branch when agent.branch is set:
+// it creates one named after the request unless the repository is already on a branch
+// with the configured prefix. It reports whether the changes should be committed there.
+func useTaskBranch(cfg *config.Config, sess *session.Session, request string) bool {
+	if !cfg.Agent.Branch || sess.Mode != ModeAgent {
+		return false
+	}
+	current, err := gitutil.Branch(sess.ProjectRoot)
+	if err != nil {
+		fmt.Println("\033[38;5;240m(Note: Not creating a task branch - the project is not a git repository)\033[0m")
+		return false
+	}
+	prefix := taskBranchPrefix(cfg)
+	if strings.HasPrefix(current, prefix) {
+		return true
+	}
+
+	base := prefix + branchSlug(request)
+	name := base
+	for i := 2; gitutil.BranchExists(sess.ProjectRoot, name); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	if err := gitutil.CreateBranch(sess.ProjectRoot, name); err != nil {
+		fmt.Printf("\033[38;5;214mWarning: failed to create task branch %s: %v\033[0m\n", name, err)
+		return false
+	}
+	fmt.Printf("\033[38;5;75mCreated branch %s from %s\033[0m\n", name, current)
+	return true
+}
+
+func taskBranchPrefix(cfg *config.Config) string {
+	if cfg.Agent.BranchPrefix == "" {
+		return "llamasidekick/"
+	}
+	return cfg.Agent.BranchPrefix
+}
+
+// branchSlug turns a request into a short branch name such as "add-auth"
+func branchSlug(request string) string {
+	words := strings.FieldsFunc(strings.ToLower(firstLine(request)), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	var kept []string
+	for _, w := range words {
+		if branchFillerWords[w] {
+			continue
+		}
+		kept = append(kept, w)
+		if len(kept) == maxBranchWords {
+			break
+		}
+	}
+	if len(kept) == 0 {
+		return "task"
+	}
+	return strings.Join(kept, "-")
+}
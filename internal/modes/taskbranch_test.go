@@ -0,0 +1,54 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestBranchSlug(t *testing.T) {
+	for request, want := range map[string]string{
+		"Add auth": "add-auth",
+		"Please create the login page for the admin": "create-login-page-for-admin",
+		"Fix bug #42 in parser.go\nmore":             "fix-bug-42-in-parser",
+		"!!!":                                        "task",
+	} {
+		if got := branchSlug(request); got != want {
+			t.Errorf("branchSlug(%q) = %q, want %q", request, got, want)
+		}
+	}
+}
+
+func TestUseTaskBranch(t *testing.T) {
+	root, git := newTestRepo(t, "main")
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("a\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	git("branch", "llamasidekick/add-auth")
+
+	cfg := &config.Config{Agent: config.AgentConfig{Branch: true, BranchPrefix: "llamasidekick/"}}
+	sess := session.New(root)
+	sess.SetMode(ModeEdit)
+	if useTaskBranch(cfg, sess, "add auth") {
+		t.Fatal("only Agent mode should use task branches")
+	}
+
+	sess.SetMode(ModeAgent)
+	if !useTaskBranch(cfg, sess, "add auth") {
+		t.Fatal("expected a task branch")
+	}
+	if branch := git("rev-parse", "--abbrev-ref", "HEAD"); branch != "llamasidekick/add-auth-2\n" {
+		t.Fatalf("expected a new branch next to the existing one, got %q", branch)
+	}
+	if !useTaskBranch(cfg, sess, "something else") {
+		t.Fatal("expected to keep using the task branch")
+	}
+	if branch := git("rev-parse", "--abbrev-ref", "HEAD"); branch != "llamasidekick/add-auth-2\n" {
+		t.Fatalf("should stay on the task branch, got %q", branch)
+	}
+}
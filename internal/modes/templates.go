@@ -0,0 +1,122 @@
+package modes
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+// PromptTemplate is a reusable prompt invoked with /tpl <name> [args].
+type PromptTemplate struct {
+	Name        string
+	Description string
+	Mode        string
+	Prompt      string
+}
+
+// languageByExt maps file extensions to the language name used for {lang}.
+var languageByExt = map[string]string{
+	".go":    "Go",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".py":    "Python",
+	".java":  "Java",
+	".c":     "C",
+	".h":     "C",
+	".cpp":   "C++",
+	".rs":    "Rust",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".cs":    "C#",
+	".swift": "Swift",
+	".kt":    "Kotlin",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".html":  "HTML",
+	".css":   "CSS",
+	".yml":   "YAML",
+	".yaml":  "YAML",
+	".json":  "JSON",
+	".xml":   "XML",
+	".md":    "Markdown",
+}
+
+// LoadTemplates returns all templates defined in config.yaml under "templates" plus
+// markdown files in the config dir's templates/ folder (templates/<name>.md).
+// Config entries take precedence over files with the same name.
+func LoadTemplates(cfg *config.Config) map[string]PromptTemplate {
+	templates := make(map[string]PromptTemplate)
+
+	if configDir, err := config.GetConfigDir(); err == nil {
+		paths, _ := filepath.Glob(filepath.Join(configDir, "templates", "*.md"))
+		for _, path := range paths {
+			content, ok := readPromptFile(path)
+			if !ok {
+				continue
+			}
+			name := strings.TrimSuffix(filepath.Base(path), ".md")
+			templates[name] = PromptTemplate{Name: name, Prompt: content}
+		}
+	}
+
+	if cfg != nil {
+		for name, t := range cfg.Templates {
+			if strings.TrimSpace(t.Prompt) == "" {
+				continue
+			}
+			templates[name] = PromptTemplate{
+				Name:        name,
+				Description: t.Description,
+				Mode:        t.Mode,
+				Prompt:      t.Prompt,
+			}
+		}
+	}
+
+	return templates
+}
+
+// TemplateNames returns the sorted names of the given templates.
+func TemplateNames(templates map[string]PromptTemplate) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExpandTemplate substitutes placeholders in the template prompt.
+// Supported placeholders:
+//   - {input}: the full argument text passed after the template name
+//   - {file}: the first file referenced in the arguments
+//   - {lang}: the language of {file}, derived from its extension
+//   - {selection}: caller-provided selected text (e.g. clipboard contents)
+//
+// If the template does not reference {input}, non-empty arguments are appended
+// so that nothing the user typed is silently dropped.
+func ExpandTemplate(t PromptTemplate, input string, selection string) string {
+	input = strings.TrimSpace(input)
+	file := detectFileInInput(input)
+	lang := ""
+	if file != "" {
+		lang = languageByExt[strings.ToLower(filepath.Ext(file))]
+	}
+
+	expanded := strings.NewReplacer(
+		"{input}", input,
+		"{file}", file,
+		"{lang}", lang,
+		"{selection}", selection,
+	).Replace(t.Prompt)
+
+	if input != "" && !strings.Contains(t.Prompt, "{input}") {
+		expanded = strings.TrimRight(expanded, "\n") + "\n\n" + input
+	}
+
+	return expanded
+}
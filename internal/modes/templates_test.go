@@ -0,0 +1,56 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+func TestExpandTemplate_Placeholders(t *testing.T) {
+	tpl := PromptTemplate{Name: "review", Prompt: "Review this {lang} file: {file}\nRequest: {input}\n{selection}"}
+	got := ExpandTemplate(tpl, "main.go for bugs", "sel")
+	want := "Review this Go file: main.go\nRequest: main.go for bugs\nsel"
+	if got != want {
+		t.Fatalf("unexpected expansion:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestExpandTemplate_AppendsUnusedInput(t *testing.T) {
+	tpl := PromptTemplate{Name: "explain", Prompt: "Explain the following:\n"}
+	got := ExpandTemplate(tpl, "@diff", "")
+	if got != "Explain the following:\n\n@diff" {
+		t.Fatalf("unexpected expansion: %q", got)
+	}
+}
+
+func TestLoadTemplates_ConfigOverridesFiles(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", tmp)
+
+	dir := filepath.Join(tmp, "templates")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.md"), []byte("from file a"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.md"), []byte("from file b"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cfg := &config.Config{Templates: map[string]config.TemplateConfig{
+		"b": {Prompt: "from config b", Mode: "ask"},
+	}}
+	templates := LoadTemplates(cfg)
+	if len(templates) != 2 {
+		t.Fatalf("expected 2 templates, got %d", len(templates))
+	}
+	if templates["a"].Prompt != "from file a" {
+		t.Fatalf("unexpected template a: %#v", templates["a"])
+	}
+	if templates["b"].Prompt != "from config b" || templates["b"].Mode != "ask" {
+		t.Fatalf("unexpected template b: %#v", templates["b"])
+	}
+}
@@ -0,0 +1,80 @@
+package modes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// titleMessages and titleMessageBytes bound how much of a conversation is sent to have
+// it titled: its first exchanges are enough
+const (
+	titleMessages     = 4
+	titleMessageBytes = 1500
+	maxTitleRunes     = 60
+)
+
+const titleSystemPrompt = `You write titles for conversations between a developer and a coding assistant.
+Reply with a title of at most 8 words that says what the conversation is about, like "Fix race in config reload" or "Explain the backup store". Reply with the title only: no quotes, no trailing period.`
+
+// GenerateTitle asks the Ask model for a short title for the conversation in sess, based
+// on its first exchanges. Private messages are left out, since the title is saved.
+func GenerateTitle(client *ollama.Client, cfg *config.Config, sess *session.Session) (string, error) {
+	var prompt strings.Builder
+	prompt.WriteString("Title this conversation:\n\n")
+	sent := 0
+	for _, msg := range sess.History {
+		if msg.Private {
+			continue
+		}
+		if sent == titleMessages {
+			break
+		}
+		sent++
+		content := msg.Content
+		if len(content) > titleMessageBytes {
+			content = strings.ToValidUTF8(content[:titleMessageBytes], "") + " ..."
+		}
+		role := "User"
+		if msg.Role == "assistant" {
+			role = "Assistant"
+		}
+		fmt.Fprintf(&prompt, "%s: %s\n\n", role, content)
+	}
+
+	var response strings.Builder
+	err := client.GenerateWithModel(cfg.GetModelForMode("ask"), prompt.String(), titleSystemPrompt, 0.2, func(chunk string) error {
+		response.WriteString(chunk)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate title: %w", err)
+	}
+	title := cleanTitle(response.String())
+	if title == "" {
+		return "", fmt.Errorf("failed to generate title: the model's reply was empty")
+	}
+	return title, nil
+}
+
+// cleanTitle keeps the first line of a title reply without quotes, markdown or a
+// trailing period, shortened to maxTitleRunes
+func cleanTitle(reply string) string {
+	title := ""
+	for _, line := range strings.Split(reply, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			title = line
+			break
+		}
+	}
+	title = strings.TrimPrefix(title, "Title:")
+	title = strings.Trim(strings.TrimSpace(title), "\"'`*#")
+	title = strings.TrimSpace(strings.TrimRight(title, ".:"))
+	if runes := []rune(title); len(runes) > maxTitleRunes {
+		title = strings.TrimSpace(string(runes[:maxTitleRunes-1])) + "…"
+	}
+	return title
+}
@@ -0,0 +1,44 @@
+package modes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestGenerateTitle(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollama.GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Prompt
+		w.Write([]byte("{\"response\": \"Title: \\\"Fix the config reload race.\\\"\\nIt is about...\"}\n{\"done\": true}\n"))
+	}))
+	defer server.Close()
+
+	sess := session.New(t.TempDir())
+	sess.AddMessage("user", "why does reloading the config race?")
+	sess.AddMessage("assistant", "Because both goroutines write cfg.")
+	sess.Private = true
+	sess.AddMessage("user", "is ghp_secret still valid?")
+	sess.Private = false
+	for i := 0; i < 4; i++ {
+		sess.AddMessage("user", "later question")
+	}
+	title, err := GenerateTitle(ollama.NewClient(server.URL, "default"), &config.Config{}, sess)
+	if err != nil || title != "Fix the config reload race" {
+		t.Fatalf("unexpected title %q, %v", title, err)
+	}
+	if !strings.Contains(prompt, "User: why does reloading the config race?") || strings.Count(prompt, "later question") != 2 {
+		t.Fatalf("expected only the first exchanges in the prompt, got %q", prompt)
+	}
+	if strings.Contains(prompt, "ghp_secret") {
+		t.Fatalf("a private message was sent to be titled: %q", prompt)
+	}
+}
@@ -0,0 +1,225 @@
+package modes
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/mcp"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+// maxToolResultChars caps how much of one tool result is fed back to the model
+const maxToolResultChars = 8000
+
+// mcpServers keeps MCP server processes running between prompts
+var (
+	mcpMu      sync.Mutex
+	mcpServers = map[string]*mcp.Client{}
+)
+
+// AgentTool is a tool from an MCP server or a built-in one, addressed as "<server>.<tool>"
+type AgentTool struct {
+	Server string
+	Tool   mcp.Tool
+	client *mcp.Client
+	run    func(args map[string]interface{}) (string, error) // Set for built-in tools, which run in-process
+}
+
+// call runs the tool with the model's arguments
+func (t AgentTool) call(args map[string]interface{}) (string, error) {
+	if t.run != nil {
+		return t.run(args)
+	}
+	return t.client.CallTool(t.Tool.Name, args)
+}
+
+// FullName returns the name the model uses to call the tool
+func (t AgentTool) FullName() string {
+	return t.Server + "." + t.Tool.Name
+}
+
+// ConnectMCPServers starts the configured MCP servers that aren't running yet and returns
+// their tools. Servers that fail to start are reported and skipped.
+func ConnectMCPServers(cfg *config.Config) []AgentTool {
+	// Tools can change files or run commands on the other side, so read-only mode has none
+	if cfg.Edits.ReadOnly {
+		return nil
+	}
+
+	mcpMu.Lock()
+	defer mcpMu.Unlock()
+
+	var tools []AgentTool
+	for _, name := range cfg.MCPServerNames() {
+		client := mcpServers[name]
+		if client == nil {
+			server := cfg.MCP.Servers[name]
+			env, err := cfg.MCPServerEnv(name)
+			if err != nil {
+				fmt.Printf("\033[38;5;214mWarning: skipping MCP server %s: %v\033[0m\n", name, err)
+				continue
+			}
+			client, err = mcp.Start(name, server.Command, server.Args, env)
+			if err != nil {
+				slog.Warn("mcp server failed to start", "server", name, "error", err)
+				fmt.Printf("\033[38;5;214mWarning: skipping MCP server %s: %v\033[0m\n", name, err)
+				continue
+			}
+			mcpServers[name] = client
+		}
+		list, err := client.ListTools()
+		if err != nil {
+			fmt.Printf("\033[38;5;214mWarning: skipping MCP server %s: %v\033[0m\n", name, err)
+			client.Close()
+			delete(mcpServers, name)
+			continue
+		}
+		for _, tool := range list {
+			tools = append(tools, AgentTool{Server: name, Tool: tool, client: client})
+		}
+	}
+	return tools
+}
+
+// CloseMCPServers stops every running MCP server
+func CloseMCPServers() {
+	mcpMu.Lock()
+	defer mcpMu.Unlock()
+	for name, client := range mcpServers {
+		client.Close()
+		delete(mcpServers, name)
+	}
+}
+
+// toolCall is the model's decision in one step of the tool loop
+type toolCall struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// parseToolCall reads a tool decision; an empty Tool means the model is ready to answer
+func parseToolCall(jsonResponse string) (toolCall, error) {
+	var call toolCall
+	if err := json.Unmarshal([]byte(strings.TrimSpace(jsonResponse)), &call); err != nil {
+		return toolCall{}, fmt.Errorf("invalid JSON for tool call")
+	}
+	call.Tool = strings.TrimSpace(call.Tool)
+	return call, nil
+}
+
+// toolSystemPrompt describes the available tools and the JSON the model must reply with
+func toolSystemPrompt(tools []AgentTool) string {
+	var b strings.Builder
+	b.WriteString("You are deciding whether a tool is needed before answering the user's task.\n\n")
+	b.WriteString("Available tools:\n")
+	for _, t := range tools {
+		fmt.Fprintf(&b, "- %s: %s\n", t.FullName(), strings.TrimSpace(t.Tool.Description))
+		if len(t.Tool.InputSchema) > 0 {
+			fmt.Fprintf(&b, "  arguments schema: %s\n", t.Tool.InputSchema)
+		}
+	}
+	b.WriteString("\nRespond with ONLY a JSON object, no other text:\n")
+	b.WriteString(`- To call a tool: {"tool": "<server>.<tool>", "arguments": {...}}` + "\n")
+	b.WriteString(`- When the tool results so far are enough to answer (or no tool helps): {"tool": ""}` + "\n")
+	b.WriteString("Call one tool at a time and never repeat a call whose result you already have.")
+	return b.String()
+}
+
+// runToolLoop lets the model call tools until it is ready to answer, and returns the
+// tool results to add to the prompt for the final answer. The pre_tool and post_tool
+// hooks run in root around every call; steering messages are picked up before each step.
+func runToolLoop(client *ollama.Client, cfg *config.Config, root, modelName, conversationContext string, tools []AgentTool, steer *steering) string {
+	byName := make(map[string]AgentTool, len(tools))
+	for _, t := range tools {
+		byName[t.FullName()] = t
+	}
+	systemPrompt := toolSystemPrompt(tools)
+
+	var results strings.Builder
+	for step := 0; step < cfg.MCP.MaxSteps; step++ {
+		if steer.poll(); steer.stopped() {
+			break
+		}
+		prompt := conversationContext + steer.prompt()
+		if results.Len() > 0 {
+			prompt += "\n\nTool results so far:\n" + results.String()
+		}
+		response, err := client.GenerateJSON(modelName, prompt, systemPrompt, 0.2)
+		if err != nil {
+			fmt.Printf("\033[38;5;9mTool selection failed: %v\033[0m\n", err)
+			break
+		}
+		call, err := parseToolCall(response)
+		if err != nil || call.Tool == "" {
+			break
+		}
+
+		tool, ok := byName[call.Tool]
+		if !ok {
+			fmt.Fprintf(&results, "\n--- %s ---\nError: no such tool\n", call.Tool)
+			continue
+		}
+		args, _ := json.Marshal(call.Arguments)
+		fmt.Printf("\033[38;5;75m🔧 %s\033[0m \033[38;5;240m%s\033[0m\n", call.Tool, args)
+		if tool.run == nil && cfg.MCP.Confirm && !confirmToolCall(steer) {
+			fmt.Fprintf(&results, "\n--- %s %s ---\nThe user declined this tool call.\n", call.Tool, args)
+			continue
+		}
+
+		vars := map[string]string{"tool": call.Tool}
+		if err := runHooks(cfg, root, "pre_tool", cfg.Hooks.PreTool, vars); err != nil {
+			fmt.Printf("\033[38;5;9m  %v\033[0m\n", err)
+			fmt.Fprintf(&results, "\n--- %s %s ---\nThe call was declined by a hook: %v\n", call.Tool, args, err)
+			continue
+		}
+
+		output, err := tool.call(call.Arguments)
+		slog.Info("tool call", "tool", call.Tool, "argument_bytes", len(args), "result_chars", len(output), "error", err)
+		if hookErr := runHooks(cfg, root, "post_tool", cfg.Hooks.PostTool, vars); hookErr != nil {
+			fmt.Printf("\033[38;5;214m  Warning: %v\033[0m\n", hookErr)
+		}
+		if err != nil {
+			fmt.Printf("\033[38;5;9m  %v\033[0m\n", err)
+			output = "Error: " + err.Error()
+		} else {
+			fmt.Printf("\033[38;5;240m  %d characters returned\033[0m\n", len(output))
+		}
+		output = redactSecrets("the "+call.Tool+" result", output, cfg.Context)
+		if tool.run == nil && cfg.Context.SanitizeTools {
+			output = sanitizeToolOutput(call.Tool, output)
+		}
+		if len(output) > maxToolResultChars {
+			output = strings.ToValidUTF8(output[:maxToolResultChars], "") + "\n... (truncated)"
+		}
+		fmt.Fprintf(&results, "\n--- %s %s ---\n%s\n", call.Tool, args, output)
+	}
+
+	if results.Len() == 0 {
+		return ""
+	}
+	return "\n\nTool results:\n" + results.String()
+}
+
+// confirmToolCall asks whether to run the tool call just shown. Running is the default;
+// without an interactive answer the call is declined. Steering lines typed while the model
+// was working are picked up first, so they aren't taken for the answer; after /stop the
+// call is declined without asking.
+func confirmToolCall(steer *steering) bool {
+	if steer.poll(); steer.stopped() {
+		return false
+	}
+	fmt.Print("  Run this tool? [Y/n] ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		fmt.Println()
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
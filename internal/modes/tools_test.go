@@ -0,0 +1,39 @@
+package modes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/mcp"
+)
+
+func TestParseToolCall(t *testing.T) {
+	call, err := parseToolCall(`{"tool": "github.search_issues", "arguments": {"query": "crash"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.Tool != "github.search_issues" || call.Arguments["query"] != "crash" {
+		t.Fatalf("unexpected call: %+v", call)
+	}
+
+	done, err := parseToolCall(`{"tool": ""}`)
+	if err != nil || done.Tool != "" {
+		t.Fatalf("expected answer decision, got %+v, %v", done, err)
+	}
+
+	if _, err := parseToolCall("I'll search the issues"); err == nil {
+		t.Fatalf("expected error for non-JSON response")
+	}
+}
+
+func TestToolSystemPrompt_ListsTools(t *testing.T) {
+	tools := []AgentTool{{
+		Server: "fs",
+		Tool:   mcp.Tool{Name: "read_file", Description: "Read a file", InputSchema: json.RawMessage(`{"type":"object"}`)},
+	}}
+	prompt := toolSystemPrompt(tools)
+	if !strings.Contains(prompt, "- fs.read_file: Read a file") || !strings.Contains(prompt, `{"type":"object"}`) {
+		t.Fatalf("tool missing from prompt:\n%s", prompt)
+	}
+}
@@ -0,0 +1,69 @@
+package ollama
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+)
+
+// Cache stores responses to requests that are generated deterministically
+type Cache interface {
+	Get(key string) (string, bool)
+	Put(key, model, response string) error
+}
+
+// cacheKey returns the key of req in the client's cache, or "" when req isn't cached:
+// only requests at temperature 0 or with a fixed seed give the same response again
+func (c *Client) cacheKey(req GenerateRequest) string {
+	if c.Cache == nil || (req.Temperature != 0 && c.Seed == 0) {
+		return ""
+	}
+	// Streaming doesn't change the response, so both kinds of request share entries
+	req.Stream = false
+	data, err := json.Marshal(struct {
+		Host string          `json:"host"`
+		Req  GenerateRequest `json:"request"`
+	}{c.Host, req})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedResponse returns the cached response for key, unless the client is refreshing
+func (c *Client) cachedResponse(key, model string) (string, bool) {
+	if key == "" || c.Refresh {
+		return "", false
+	}
+	response, ok := c.Cache.Get(key)
+	if !ok {
+		return "", false
+	}
+	slog.Info("ollama response from cache", "model", model, "response_chars", len(response))
+	c.logResponse(model, response)
+	return response, true
+}
+
+// storeResponse caches a finished response; failing to is only logged
+func (c *Client) storeResponse(key, model, response string) {
+	if key == "" {
+		return
+	}
+	if err := c.Cache.Put(key, model, response); err != nil {
+		slog.Warn("failed to cache response", "model", model, "error", err)
+	}
+}
+
+// options returns the model parameters of a request at temperature
+func (c *Client) options(temperature float64) *GenerateOptions {
+	return &GenerateOptions{Temperature: temperature, Seed: c.Seed, NumCtx: c.NumCtx, NumPredict: c.NumPredict}
+}
+
+// replay delivers a cached response to the callbacks of a streaming request, as one chunk
+func (c *Client) replay(response string, callback StreamCallback) error {
+	answer, reasoning := StripThinking(response)
+	c.Reasoning = reasoning
+	return c.deliver(answer, callback)
+}
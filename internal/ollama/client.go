@@ -3,11 +3,15 @@ package ollama
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // Client represents an Ollama API client
@@ -16,9 +20,27 @@ type Client struct {
 	Model   string
 	Debug   bool
 	Version string
+	APIKey  string // Sent as a bearer token, for Ollama servers behind an authenticating proxy
+	Stats   TokenStats
+	OnChunk StreamCallback // Also receives every streamed chunk, e.g. to forward it to an API client
+	Budget  Budget         // Checked before and charged after every generation, if set
+	Cache   Cache          // Answers repeated deterministic requests without generating, if set
+	Seed    int            // Fixed sampling seed (0 = random); makes every request deterministic
+	NumCtx  int            // Context window in tokens (0 = the model's default)
+	NumPredict int         // Most tokens a response may have (0 = no limit)
+	Refresh bool           // Generate even when the cache has a response, and replace it
+	Intercept func(req GenerateRequest) error // Receives generate requests instead of the server, if set, e.g. to preview them
+	Reasoning string // What a reasoning model thought before its last answer, which is kept out of the answer
+	CutOff  bool           // The last answer stopped at NumPredict rather than where the model ended it
 	client  *http.Client
 }
 
+// Budget limits how much a client may generate
+type Budget interface {
+	Check() error                            // Returns an error if no more requests may be made
+	Record(tokens int, elapsed time.Duration) // Charges a finished request
+}
+
 // NewClient creates a new Ollama client
 func NewClient(host, model string) *Client {
 	return &Client{
@@ -28,6 +50,21 @@ func NewClient(host, model string) *Client {
 	}
 }
 
+// newRequest builds an HTTP request with the headers every Ollama call needs
+func (c *Client) newRequest(method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	return req, nil
+}
+
 // GenerateRequest represents a request to the Ollama generate API
 type GenerateRequest struct {
 	Model       string  `json:"model"`
@@ -36,6 +73,15 @@ type GenerateRequest struct {
 	Temperature float64 `json:"temperature,omitempty"`
 	Stream      bool    `json:"stream"`
 	Format      string  `json:"format,omitempty"`
+	Options     *GenerateOptions `json:"options,omitempty"`
+}
+
+// GenerateOptions are the model parameters of a request
+type GenerateOptions struct {
+	Temperature float64 `json:"temperature"`
+	Seed        int     `json:"seed,omitempty"`
+	NumCtx      int     `json:"num_ctx,omitempty"`
+	NumPredict  int     `json:"num_predict,omitempty"`
 }
 
 // GenerateResponse represents a response from the Ollama generate API
@@ -44,6 +90,83 @@ type GenerateResponse struct {
 	CreatedAt string `json:"created_at"`
 	Response  string `json:"response"`
 	Done      bool   `json:"done"`
+	DoneReason string `json:"done_reason,omitempty"` // Why generation stopped, e.g. "length" at num_predict
+	
+	PromptEvalCount int `json:"prompt_eval_count,omitempty"` // Prompt tokens, reported on the final chunk
+	EvalCount       int `json:"eval_count,omitempty"`        // Response tokens, reported on the final chunk
+	Error           string `json:"error,omitempty"`           // Set when the model fails part-way through
+	Thinking        string `json:"thinking,omitempty"`        // Reasoning, from servers that send it apart from the answer
+}
+
+// TokenStats accumulates the token counts Ollama reports for a client's requests
+type TokenStats struct {
+	Requests       int `json:"requests"`
+	PromptTokens   int `json:"prompt_tokens"`
+	ResponseTokens int `json:"response_tokens"`
+}
+
+// Total returns prompt plus response tokens
+func (s TokenStats) Total() int {
+	return s.PromptTokens + s.ResponseTokens
+}
+
+// record adds the counts from a final response chunk and logs the finished request
+func (c *Client) record(resp GenerateResponse, model string, start time.Time) {
+	slog.Info("ollama response", "model", model, "duration", time.Since(start).Round(time.Millisecond),
+		"prompt_tokens", resp.PromptEvalCount, "response_tokens", resp.EvalCount)
+	c.CutOff = resp.DoneReason == "length"
+	c.Stats.Requests++
+	c.Stats.PromptTokens += resp.PromptEvalCount
+	c.Stats.ResponseTokens += resp.EvalCount
+	if c.Budget != nil {
+		c.Budget.Record(resp.PromptEvalCount+resp.EvalCount, time.Since(start))
+	}
+}
+
+// checkBudget returns the budget's error if it allows no more requests
+func (c *Client) checkBudget() error {
+	if c.Budget == nil {
+		return nil
+	}
+	return c.Budget.Check()
+}
+
+// logRequest logs a generate request. The prompts are logged by length, and with Debug
+// by hash as well, but never as text: they may come from a private or unsaved session.
+func (c *Client) logRequest(req GenerateRequest) {
+	attrs := []any{"model", req.Model, "stream", req.Stream, "format", req.Format, "temperature", req.Temperature,
+		"prompt_chars", len(req.Prompt), "system_chars", len(req.System)}
+	if c.Debug {
+		attrs = append(attrs, "version", c.Version, "prompt_sha256", fingerprint(req.Prompt), "system_sha256", fingerprint(req.System))
+	}
+	slog.Debug("ollama request", attrs...)
+}
+
+// logResponse logs the length and hash of a complete response with Debug, not its text
+func (c *Client) logResponse(model, response string) {
+	if !c.Debug {
+		return
+	}
+	slog.Debug("ollama response text", "model", model, "response_chars", len(response), "response_sha256", fingerprint(response))
+}
+
+// fingerprint returns a short hash of text, to tell logged prompts and responses apart
+func fingerprint(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:6])
+}
+
+// deliver passes a chunk of the answer to OnChunk and callback
+func (c *Client) deliver(chunk string, callback StreamCallback) error {
+	if chunk == "" {
+		return nil
+	}
+	if c.OnChunk != nil {
+		if err := c.OnChunk(chunk); err != nil {
+			return err
+		}
+	}
+	return callback(chunk)
 }
 
 // StreamCallback is called for each chunk of the response
@@ -58,21 +181,24 @@ func (c *Client) GenerateJSON(model, prompt, system string, temperature float64)
 		Temperature: temperature,
 		Stream:      false,
 		Format:      "json",
+		Options:     c.options(temperature),
+	}
+	if c.Intercept != nil {
+		return "", c.Intercept(reqBody)
 	}
 	
-	if c.Debug {
-		fmt.Println("\n\033[38;5;240m=== DEBUG: JSON Request to Ollama ===")
-		if c.Version != "" {
-			fmt.Printf("LlamaSidekick Version: %s\n", c.Version)
-		}
-		fmt.Printf("Model: %s\n", reqBody.Model)
-		fmt.Printf("Format: json\n")
-		fmt.Printf("Temperature: %.2f\n", reqBody.Temperature)
-		fmt.Printf("System Prompt: %s\n", system)
-		fmt.Printf("User Prompt: %s\n", prompt)
-		fmt.Println("=== END DEBUG ===")
-		fmt.Println("\033[0m")
+	c.Reasoning, c.CutOff = "", false
+	key := c.cacheKey(reqBody)
+	if cached, ok := c.cachedResponse(key, reqBody.Model); ok {
+		answer, reasoning := StripThinking(cached)
+		c.Reasoning = reasoning
+		return answer, nil
 	}
+	if err := c.checkBudget(); err != nil {
+		return "", err
+	}
+	start := time.Now()
+	c.logRequest(reqBody)
 	
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -80,31 +206,149 @@ func (c *Client) GenerateJSON(model, prompt, system string, temperature float64)
 	}
 	
 	url := strings.TrimSuffix(c.Host, "/") + "/api/generate"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := c.newRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
 	
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		slog.Warn("ollama request failed", "model", reqBody.Model, "error", err)
+		return "", c.connectionError("failed to send request", err)
 	}
 	defer resp.Body.Close()
 	
+	if resp.StatusCode != http.StatusOK {
+		return "", apiError(reqBody.Model, resp)
+	}
+	
 	var result GenerateResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return "", fmt.Errorf("%w: failed to decode response: %w", ErrGeneration, err)
+	}
+	if result.Error != "" {
+		return "", generationError(result.Error)
 	}
+	c.record(result, reqBody.Model, start)
+	var think thinkFilter
+	think.addThinking(result.Thinking)
+	c.storeResponse(key, reqBody.Model, think.cached(result.Response))
+	answer := think.answer(result.Response) + think.flush()
+	c.Reasoning = think.collected()
 	
-	if c.Debug {
-		fmt.Println("\n\033[38;5;240m=== DEBUG: JSON Response from Ollama ===")
-		fmt.Printf("Response: %s\n", result.Response)
-		fmt.Println("=== END DEBUG ===")
-		fmt.Println("\033[0m")
+	c.logResponse(reqBody.Model, result.Response)
+	
+	return answer, nil
+}
+
+// GenerateJSONStream is like GenerateJSON, but streams the response: callback receives
+// each chunk as it arrives, and the complete response is returned at the end
+func (c *Client) GenerateJSONStream(model, prompt, system string, temperature float64, callback StreamCallback) (string, error) {
+	reqBody := GenerateRequest{
+		Model:       model,
+		Prompt:      prompt,
+		System:      system,
+		Temperature: temperature,
+		Stream:      true,
+		Format:      "json",
+		Options:     c.options(temperature),
+	}
+	if c.Intercept != nil {
+		return "", c.Intercept(reqBody)
+	}
+	
+	c.Reasoning, c.CutOff = "", false
+	key := c.cacheKey(reqBody)
+	if cached, ok := c.cachedResponse(key, reqBody.Model); ok {
+		answer, reasoning := StripThinking(cached)
+		c.Reasoning = reasoning
+		if answer != "" {
+			if err := callback(answer); err != nil {
+				return "", err
+			}
+		}
+		return answer, nil
+	}
+	if err := c.checkBudget(); err != nil {
+		return "", err
+	}
+	start := time.Now()
+	c.logRequest(reqBody)
+	
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+	
+	url := strings.TrimSuffix(c.Host, "/") + "/api/generate"
+	req, err := c.newRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	
+	resp, err := c.client.Do(req)
+	if err != nil {
+		slog.Warn("ollama request failed", "model", reqBody.Model, "error", err)
+		return "", c.connectionError("failed to send request", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return "", apiError(reqBody.Model, resp)
+	}
+	
+	scanner := bufio.NewScanner(resp.Body)
+	// A chunk holds a few tokens, but allow for long lines from servers that send more
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var full, answer strings.Builder
+	var think thinkFilter
+	deliver := func(chunk string) error {
+		if chunk == "" {
+			return nil
+		}
+		answer.WriteString(chunk)
+		return callback(chunk)
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		
+		var genResp GenerateResponse
+		if err := json.Unmarshal([]byte(line), &genResp); err != nil {
+			return "", fmt.Errorf("%w: failed to parse response: %w", ErrGeneration, err)
+		}
+		if genResp.Error != "" {
+			return "", generationError(genResp.Error)
+		}
+		
+		think.addThinking(genResp.Thinking)
+		if genResp.Response != "" {
+			full.WriteString(genResp.Response)
+			if err := deliver(think.answer(genResp.Response)); err != nil {
+				return "", err
+			}
+		}
+		
+		if genResp.Done {
+			if err := deliver(think.flush()); err != nil {
+				return "", err
+			}
+			c.Reasoning = think.collected()
+			c.record(genResp, reqBody.Model, start)
+			c.storeResponse(key, reqBody.Model, think.cached(full.String()))
+			break
+		}
 	}
 	
-	return result.Response, nil
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("%w: error reading response: %w", ErrGeneration, err)
+	}
+	
+	c.logResponse(reqBody.Model, full.String())
+	
+	return answer.String(), nil
 }
 
 // Generate sends a prompt to Ollama and streams the response
@@ -115,20 +359,22 @@ func (c *Client) Generate(prompt, system string, temperature float64, callback S
 		System:      system,
 		Temperature: temperature,
 		Stream:      true,
+		Options:     c.options(temperature),
+	}
+	if c.Intercept != nil {
+		return c.Intercept(reqBody)
 	}
 	
-	if c.Debug {
-		fmt.Println("\n\033[38;5;240m=== DEBUG: Request to Ollama ===")
-		if c.Version != "" {
-			fmt.Printf("LlamaSidekick Version: %s\n", c.Version)
-		}
-		fmt.Printf("Model: %s\n", reqBody.Model)
-		fmt.Printf("Temperature: %.2f\n", reqBody.Temperature)
-		fmt.Printf("System Prompt: %s\n", system)
-		fmt.Printf("User Prompt: %s\n", prompt)
-		fmt.Println("=== END DEBUG ===")
-		fmt.Println("\033[0m")
+	c.Reasoning, c.CutOff = "", false
+	key := c.cacheKey(reqBody)
+	if cached, ok := c.cachedResponse(key, reqBody.Model); ok {
+		return c.replay(cached, callback)
 	}
+	if err := c.checkBudget(); err != nil {
+		return err
+	}
+	start := time.Now()
+	c.logRequest(reqBody)
 	
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -136,26 +382,26 @@ func (c *Client) Generate(prompt, system string, temperature float64, callback S
 	}
 	
 	url := strings.TrimSuffix(c.Host, "/") + "/api/generate"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := c.newRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	
-	req.Header.Set("Content-Type", "application/json")
-	
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		slog.Warn("ollama request failed", "model", reqBody.Model, "error", err)
+		return c.connectionError("failed to send request", err)
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("ollama API error: %s - %s", resp.Status, string(body))
+		return apiError(reqBody.Model, resp)
 	}
 	
 	// Stream the response
 	scanner := bufio.NewScanner(resp.Body)
+	var full strings.Builder
+	var think thinkFilter
 	for scanner.Scan() {
 		line := scanner.Text()
 		if line == "" {
@@ -164,22 +410,33 @@ func (c *Client) Generate(prompt, system string, temperature float64, callback S
 		
 		var genResp GenerateResponse
 		if err := json.Unmarshal([]byte(line), &genResp); err != nil {
-			return fmt.Errorf("failed to parse response: %w", err)
+			return fmt.Errorf("%w: failed to parse response: %w", ErrGeneration, err)
+		}
+		if genResp.Error != "" {
+			return generationError(genResp.Error)
 		}
 		
+		think.addThinking(genResp.Thinking)
 		if genResp.Response != "" {
-			if err := callback(genResp.Response); err != nil {
+			full.WriteString(genResp.Response)
+			if err := c.deliver(think.answer(genResp.Response), callback); err != nil {
 				return err
 			}
 		}
 		
 		if genResp.Done {
+			if err := c.deliver(think.flush(), callback); err != nil {
+				return err
+			}
+			c.Reasoning = think.collected()
+			c.record(genResp, reqBody.Model, start)
+			c.storeResponse(key, reqBody.Model, think.cached(full.String()))
 			break
 		}
 	}
 	
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading response: %w", err)
+		return fmt.Errorf("%w: error reading response: %w", ErrGeneration, err)
 	}
 	
 	return nil
@@ -200,9 +457,14 @@ type ListModelsResponse struct {
 // ListModels retrieves all available models from Ollama
 func (c *Client) ListModels() ([]Model, error) {
 	url := strings.TrimSuffix(c.Host, "/") + "/api/tags"
-	resp, err := c.client.Get(url)
+	req, err := c.newRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ollama: %w", err)
+		slog.Warn("ollama unreachable", "host", c.Host, "error", err)
+		return nil, c.connectionError("failed to connect to Ollama", err)
 	}
 	defer resp.Body.Close()
 	
@@ -232,20 +494,22 @@ func (c *Client) GenerateWithModel(model, prompt, system string, temperature flo
 		System:      system,
 		Temperature: temperature,
 		Stream:      true,
+		Options:     c.options(temperature),
+	}
+	if c.Intercept != nil {
+		return c.Intercept(reqBody)
 	}
 	
-	if c.Debug {
-		fmt.Println("\n\033[38;5;240m=== DEBUG: Request to Ollama ===")
-		if c.Version != "" {
-			fmt.Printf("LlamaSidekick Version: %s\n", c.Version)
-		}
-		fmt.Printf("Model: %s\n", reqBody.Model)
-		fmt.Printf("Temperature: %.2f\n", reqBody.Temperature)
-		fmt.Printf("System Prompt: %s\n", system)
-		fmt.Printf("User Prompt: %s\n", prompt)
-		fmt.Println("=== END DEBUG ===")
-		fmt.Println("\033[0m")
+	c.Reasoning, c.CutOff = "", false
+	key := c.cacheKey(reqBody)
+	if cached, ok := c.cachedResponse(key, reqBody.Model); ok {
+		return c.replay(cached, callback)
+	}
+	if err := c.checkBudget(); err != nil {
+		return err
 	}
+	start := time.Now()
+	c.logRequest(reqBody)
 	
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -253,27 +517,26 @@ func (c *Client) GenerateWithModel(model, prompt, system string, temperature flo
 	}
 	
 	url := strings.TrimSuffix(c.Host, "/") + "/api/generate"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := c.newRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	
-	req.Header.Set("Content-Type", "application/json")
-	
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		slog.Warn("ollama request failed", "model", reqBody.Model, "error", err)
+		return c.connectionError("failed to send request", err)
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("ollama API error: %s - %s", resp.Status, string(body))
+		return apiError(reqBody.Model, resp)
 	}
 	
 	// Stream the response
 	scanner := bufio.NewScanner(resp.Body)
-	var fullDebugResponse strings.Builder
+	var fullResponse strings.Builder
+	var think thinkFilter
 	for scanner.Scan() {
 		line := scanner.Text()
 		if line == "" {
@@ -282,32 +545,151 @@ func (c *Client) GenerateWithModel(model, prompt, system string, temperature flo
 		
 		var genResp GenerateResponse
 		if err := json.Unmarshal([]byte(line), &genResp); err != nil {
-			return fmt.Errorf("failed to parse response: %w", err)
+			return fmt.Errorf("%w: failed to parse response: %w", ErrGeneration, err)
+		}
+		if genResp.Error != "" {
+			return generationError(genResp.Error)
 		}
 		
+		think.addThinking(genResp.Thinking)
 		if genResp.Response != "" {
-			if c.Debug {
-				fullDebugResponse.WriteString(genResp.Response)
-			}
-			if err := callback(genResp.Response); err != nil {
+			fullResponse.WriteString(genResp.Response)
+			if err := c.deliver(think.answer(genResp.Response), callback); err != nil {
 				return err
 			}
 		}
 		
 		if genResp.Done {
-			if c.Debug {
-				fmt.Println("\n\033[38;5;240m=== DEBUG: Response from Ollama ===")
-				fmt.Printf("Full Response: %s\n", fullDebugResponse.String())
-				fmt.Println("=== END DEBUG ===")
-				fmt.Println("\033[0m")
+			if err := c.deliver(think.flush(), callback); err != nil {
+				return err
 			}
+			c.Reasoning = think.collected()
+			c.record(genResp, reqBody.Model, start)
+			c.storeResponse(key, reqBody.Model, think.cached(fullResponse.String()))
+			c.logResponse(reqBody.Model, fullResponse.String())
 			break
 		}
 	}
 	
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading response: %w", err)
+		return fmt.Errorf("%w: error reading response: %w", ErrGeneration, err)
 	}
 	
 	return nil
 }
+
+// EmbedRequest represents a request to the Ollama embed API
+type EmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbedResponse represents a response from the Ollama embed API
+type EmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Embed returns one embedding vector per input, in order
+func (c *Client) Embed(model string, input []string) ([][]float32, error) {
+	jsonData, err := json.Marshal(EmbedRequest{Model: model, Input: input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	start := time.Now()
+	url := strings.TrimSuffix(c.Host, "/") + "/api/embed"
+	req, err := c.newRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		slog.Warn("ollama request failed", "model", model, "error", err)
+		return nil, c.connectionError("failed to send request", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(model, resp)
+	}
+
+	var result EmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode response: %w", ErrGeneration, err)
+	}
+	if result.Error != "" {
+		return nil, generationError(result.Error)
+	}
+	if len(result.Embeddings) != len(input) {
+		return nil, fmt.Errorf("%w: got %d embeddings for %d inputs", ErrGeneration, len(result.Embeddings), len(input))
+	}
+	slog.Info("ollama embeddings", "model", model, "inputs", len(input), "duration", time.Since(start).Round(time.Millisecond))
+	return result.Embeddings, nil
+}
+
+// PullRequest represents a request to the Ollama pull API
+type PullRequest struct {
+	Model  string `json:"model"`
+	Stream bool   `json:"stream"`
+}
+
+// PullProgress is a status update of a pull, e.g. "pulling manifest" or the download of a layer
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`     // Bytes of the layer being downloaded
+	Completed int64  `json:"completed,omitempty"` // Bytes of it downloaded so far
+	Error     string `json:"error,omitempty"`
+}
+
+// PullModel downloads model onto the Ollama server, passing every status update to progress
+func (c *Client) PullModel(model string, progress func(PullProgress)) error {
+	jsonData, err := json.Marshal(PullRequest{Model: model, Stream: true})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	start := time.Now()
+	url := strings.TrimSuffix(c.Host, "/") + "/api/pull"
+	req, err := c.newRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		slog.Warn("ollama request failed", "model", model, "error", err)
+		return c.connectionError("failed to send request", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		slog.Warn("ollama pull failed", "model", model, "status", resp.Status, "body", string(body))
+		var failed PullProgress
+		if json.Unmarshal(body, &failed) == nil && failed.Error != "" {
+			return fmt.Errorf("failed to pull %s: %s", model, failed.Error)
+		}
+		return fmt.Errorf("failed to pull %s: ollama returned status %s", model, resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var update PullProgress
+		if err := json.Unmarshal(scanner.Bytes(), &update); err != nil {
+			return fmt.Errorf("failed to decode pull status: %w", err)
+		}
+		if update.Error != "" {
+			return fmt.Errorf("failed to pull %s: %s", model, update.Error)
+		}
+		if progress != nil {
+			progress(update)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read pull status: %w", err)
+	}
+	slog.Info("ollama pull", "model", model, "duration", time.Since(start).Round(time.Millisecond))
+	return nil
+}
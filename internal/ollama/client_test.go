@@ -0,0 +1,39 @@
+package ollama
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGenerateJSON_DebugLogsNoContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"response": "{\"answer\": \"the launch code is 1234\"}", "done": true}`)
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	c := NewClient(server.URL, "test")
+	c.Debug = true
+	if _, err := c.GenerateJSON("test", "my private prompt", "secret instructions", 0.2); err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"my private prompt", "secret instructions", "launch code"} {
+		if strings.Contains(logs.String(), text) {
+			t.Fatalf("expected %q to stay out of the log, got %s", text, logs.String())
+		}
+	}
+	if want := "prompt_sha256=" + fingerprint("my private prompt"); !strings.Contains(logs.String(), want) {
+		t.Fatalf("expected the prompt's hash in the log, got %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "response_chars=") {
+		t.Fatalf("expected the response's length in the log, got %s", logs.String())
+	}
+}
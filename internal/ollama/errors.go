@@ -0,0 +1,61 @@
+package ollama
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/hint"
+)
+
+// Errors returned by the client, so callers can tell failures apart with errors.Is
+var (
+	// ErrConnection means the Ollama server could not be reached
+	ErrConnection = errors.New("cannot reach Ollama")
+	// ErrModelNotFound means the requested model is not installed on the server
+	ErrModelNotFound = errors.New("model not found")
+	// ErrGeneration means the server was reached but producing a response failed
+	ErrGeneration = errors.New("generation failed")
+	// ErrContextLength means the prompt doesn't fit the model's context window; it comes
+	// wrapped in ErrGeneration
+	ErrContextLength = errors.New("the prompt is longer than the model's context window")
+)
+
+// contextLengthHint is the hint of ErrContextLength
+const contextLengthHint = "shorten the conversation with /compact, load fewer files (context.max_total_tokens) or raise ollama.num_ctx"
+
+// contextLengthMessages are parts of the errors servers return for a prompt that is too long
+var contextLengthMessages = []string{"context length", "context window", "maximum context", "too many tokens", "prompt is too long"}
+
+// apiError converts a non-200 API response into an error wrapping ErrModelNotFound or
+// ErrGeneration
+func apiError(model string, resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	slog.Warn("ollama request failed", "model", model, "status", resp.Status, "body", string(body))
+	if resp.StatusCode == http.StatusNotFound {
+		return hint.Wrap(fmt.Errorf("%w: %s is not available on the Ollama server", ErrModelNotFound, model),
+			fmt.Sprintf("install it with `ollama pull %s`, or pick an installed model with Configure Models", model))
+	}
+	return generationError(fmt.Sprintf("ollama API error: %s - %s", resp.Status, string(body)))
+}
+
+// generationError returns the error for a failed generation the server described with
+// message, telling prompts that are too long apart
+func generationError(message string) error {
+	lower := strings.ToLower(message)
+	for _, m := range contextLengthMessages {
+		if strings.Contains(lower, m) {
+			return hint.Wrap(fmt.Errorf("%w: %w: %s", ErrGeneration, ErrContextLength, message), contextLengthHint)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrGeneration, message)
+}
+
+// connectionError returns the error for a request that didn't reach the server
+func (c *Client) connectionError(what string, err error) error {
+	return hint.Wrap(fmt.Errorf("%s: %w: %w", what, ErrConnection, err),
+		fmt.Sprintf("start Ollama with `ollama serve`, or check ollama.host (%s)", c.Host))
+}
@@ -0,0 +1,110 @@
+package ollama
+
+import "strings"
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// thinkFilter splits a stream into the answer and the reasoning that models such as
+// deepseek-r1 and qwen3 wrap in <think>...</think>, also when a tag is split across
+// chunks. The reasoning is collected so it can be shown on demand.
+type thinkFilter struct {
+	inside    bool
+	pending   string // The end of the last chunk, when it may be the start of a tag
+	started   bool   // Some of the answer has been passed on; whitespace before it is dropped
+	reasoning strings.Builder
+	separate  strings.Builder // Reasoning the server sent in the thinking field
+}
+
+// answer returns the part of chunk that is the answer and collects the reasoning
+func (f *thinkFilter) answer(chunk string) string {
+	text := f.pending + chunk
+	f.pending = ""
+	var out strings.Builder
+	for text != "" {
+		tag := thinkOpen
+		if f.inside {
+			tag = thinkClose
+		}
+		i := strings.Index(text, tag)
+		if i < 0 {
+			// Hold back what could be the start of the tag until the next chunk
+			keep := partialTag(text, tag)
+			f.emit(&out, text[:len(text)-keep])
+			f.pending = text[len(text)-keep:]
+			break
+		}
+		f.emit(&out, text[:i])
+		text = text[i+len(tag):]
+		if !f.inside && f.started {
+			// A section after the answer started is left in; it is part of the answer
+			f.emit(&out, tag)
+			continue
+		}
+		f.inside = !f.inside
+	}
+	return out.String()
+}
+
+// flush returns what was held back at the end of the stream
+func (f *thinkFilter) flush() string {
+	var out strings.Builder
+	f.emit(&out, f.pending)
+	f.pending = ""
+	return out.String()
+}
+
+// emit adds text to the answer or to the reasoning, depending on where the stream is
+func (f *thinkFilter) emit(out *strings.Builder, text string) {
+	if f.inside {
+		f.reasoning.WriteString(text)
+		return
+	}
+	if !f.started {
+		text = strings.TrimLeft(text, " \t\r\n")
+	}
+	if text != "" {
+		f.started = true
+		out.WriteString(text)
+	}
+}
+
+// addThinking collects reasoning the server sent apart from the answer, in the thinking field
+func (f *thinkFilter) addThinking(text string) {
+	f.reasoning.WriteString(text)
+	f.separate.WriteString(text)
+}
+
+// cached returns the raw response as it is cached, with reasoning that was sent apart put
+// back in tags, so a replay can tell it apart again
+func (f *thinkFilter) cached(raw string) string {
+	if f.separate.Len() == 0 {
+		return raw
+	}
+	return thinkOpen + f.separate.String() + thinkClose + raw
+}
+
+// collected returns the reasoning collected so far, trimmed
+func (f *thinkFilter) collected() string {
+	return strings.TrimSpace(f.reasoning.String())
+}
+
+// partialTag returns the length of the longest end of text that tag starts with
+func partialTag(text, tag string) int {
+	for n := min(len(tag)-1, len(text)); n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// StripThinking returns response without the <think> sections reasoning models open it
+// with, and the reasoning they held
+func StripThinking(response string) (answer, reasoning string) {
+	var f thinkFilter
+	answer = f.answer(response) + f.flush()
+	return answer, f.collected()
+}
@@ -0,0 +1,72 @@
+package ollama
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThinkFilter(t *testing.T) {
+	cases := []struct {
+		name          string
+		chunks        []string
+		thinking      string // Sent in the thinking field, before the chunks
+		wantAnswer    string
+		wantReasoning string
+	}{
+		{"no reasoning", []string{"hello ", "world"}, "", "hello world", ""},
+		{"whole section", []string{"<think>plan</think>\n\nanswer"}, "", "answer", "plan"},
+		{"tags split across chunks", []string{"<thi", "nk>pl", "an</th", "ink>", "\n\nanswer"}, "", "answer", "plan"},
+		{"tag split after whitespace", []string{"\n<", "think>plan<", "/think>answer"}, "", "answer", "plan"},
+		{"section after the answer started", []string{"use <think>", " tags</think> like this"}, "", "use <think> tags</think> like this", ""},
+		{"unterminated section", []string{"<think>still ", "thinking"}, "", "", "still thinking"},
+		{"unterminated with a partial close", []string{"<think>plan</thi"}, "", "", "plan</thi"},
+		{"partial open at the end is answer", []string{"a <thi"}, "", "a <thi", ""},
+		{"leading whitespace dropped", []string{"\n\n", "  answer\n"}, "", "answer\n", ""},
+		{"thinking field", []string{"answer"}, "plan", "answer", "plan"},
+		{"thinking field and tags", []string{"<think>more</think>answer"}, "plan ", "answer", "plan more"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var f thinkFilter
+			f.addThinking(c.thinking)
+			var answer strings.Builder
+			for _, chunk := range c.chunks {
+				answer.WriteString(f.answer(chunk))
+			}
+			answer.WriteString(f.flush())
+			if answer.String() != c.wantAnswer || f.collected() != c.wantReasoning {
+				t.Fatalf("got answer %q, reasoning %q; want %q, %q", answer.String(), f.collected(), c.wantAnswer, c.wantReasoning)
+			}
+
+			// A replay from the cache must split the same way
+			raw := strings.Join(c.chunks, "")
+			replayed, reasoning := StripThinking(f.cached(raw))
+			if replayed != c.wantAnswer || reasoning != c.wantReasoning {
+				t.Fatalf("replay gave answer %q, reasoning %q; want %q, %q", replayed, reasoning, c.wantAnswer, c.wantReasoning)
+			}
+
+			// So must the same text cut into chunks of one byte
+			if c.thinking != "" {
+				return
+			}
+			var bytewise thinkFilter
+			answer.Reset()
+			for i := 0; i < len(raw); i++ {
+				answer.WriteString(bytewise.answer(raw[i : i+1]))
+			}
+			answer.WriteString(bytewise.flush())
+			if answer.String() != c.wantAnswer || bytewise.collected() != c.wantReasoning {
+				t.Fatalf("byte by byte got answer %q, reasoning %q; want %q, %q", answer.String(), bytewise.collected(), c.wantAnswer, c.wantReasoning)
+			}
+		})
+	}
+}
+
+func TestThinkFilter_CachedWithoutThinkingFieldIsRaw(t *testing.T) {
+	var f thinkFilter
+	raw := "<think>plan</think>answer"
+	f.answer(raw)
+	if got := f.cached(raw); got != raw {
+		t.Fatalf("expected the raw response to be cached as is, got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package pathmatch
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Match reports whether the slash-separated relative path p matches the glob pattern.
+// Patterns follow path.Match syntax with two extensions:
+//   - "**" matches any number of path segments (including none)
+//   - a pattern without a "/" matches against every path segment, like .gitignore
+//     (so "*.log" matches "logs/app.log" and "node_modules" matches "a/node_modules/b.js")
+func Match(pattern, p string) bool {
+	pattern = strings.TrimSpace(filepath.ToSlash(pattern))
+	p = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(p)), "./")
+	if pattern == "" || p == "" {
+		return false
+	}
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	if !strings.Contains(pattern, "/") {
+		for _, segment := range strings.Split(p, "/") {
+			if ok, _ := path.Match(pattern, segment); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	pattern = strings.TrimPrefix(pattern, "/")
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+// MatchAny reports whether p matches any of the patterns
+func MatchAny(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(rest, segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	// A directory pattern also matches everything below it
+	return true
+}
@@ -0,0 +1,29 @@
+package pathmatch
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.log", "app.log", true},
+		{"*.log", "logs/app.log", true},
+		{"node_modules", "web/node_modules/x/index.js", true},
+		{".env", ".env", true},
+		{".env", "config/.env.example", false},
+		{"vendor/**", "vendor/a/b.go", true},
+		{"vendor/**", "src/vendor/a.go", false},
+		{"**/testdata/**", "pkg/testdata/in.txt", true},
+		{"internal/*.go", "internal/main.go", true},
+		{"internal/*.go", "internal/sub/main.go", false},
+		{"build/", "build/out.bin", true},
+		{"", "main.go", false},
+	}
+	for _, c := range cases {
+		if got := Match(c.pattern, c.path); got != c.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
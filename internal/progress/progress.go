@@ -0,0 +1,66 @@
+// Package progress shows that LlamaSidekick is busy: a spinner on a terminal, or in
+// accessible mode plain lines that a screen reader reads once.
+package progress
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/yourusername/llamasidekick/internal/renderer"
+)
+
+// Spinner is a spinner whose Suffix says what is happening. In accessible mode it
+// doesn't spin: Start prints the suffix as a line the first time instead.
+type Spinner struct {
+	*spinner.Spinner
+	announced bool
+	active    bool
+}
+
+// NewSpinner returns a stopped spinner; set Suffix before calling Start
+func NewSpinner() *Spinner {
+	return &Spinner{Spinner: spinner.New(spinner.CharSets[11], 100*time.Millisecond)}
+}
+
+// Start starts the spinner, or in accessible mode announces it
+func (s *Spinner) Start() {
+	if !renderer.IsAccessible() {
+		s.Spinner.Start()
+		return
+	}
+	s.Lock()
+	suffix := strings.TrimSpace(s.Suffix)
+	s.Unlock()
+	if !s.announced {
+		Announce(suffix)
+		s.announced = true
+	}
+	s.active = true
+}
+
+// Stop stops the spinner
+func (s *Spinner) Stop() {
+	if !renderer.IsAccessible() {
+		s.Spinner.Stop()
+		return
+	}
+	s.active = false
+}
+
+// Active reports whether the spinner was started and not stopped since
+func (s *Spinner) Active() bool {
+	if !renderer.IsAccessible() {
+		return s.Spinner.Active()
+	}
+	return s.active
+}
+
+// Announce prints a change of state, such as "Done", as a line of its own in accessible
+// mode. Otherwise the spinner and colors already show it, and nothing is printed.
+func Announce(text string) {
+	if renderer.IsAccessible() && text != "" {
+		fmt.Println(text)
+	}
+}
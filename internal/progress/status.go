@@ -0,0 +1,128 @@
+package progress
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/renderer"
+)
+
+// Status is a spinner for a request to a model. Besides what is happening it shows how
+// long the request has been running and how many tokens have arrived, so a slow model
+// can be told apart from a hung one.
+type Status struct {
+	*Spinner
+	label string
+
+	mu      sync.Mutex
+	started time.Time
+	tokens  int
+	detail  string
+	stop    chan struct{}
+}
+
+// NewStatus returns a stopped status spinner showing label, e.g. "Planning with llama3"
+func NewStatus(label string) *Status {
+	s := &Status{Spinner: NewSpinner(), label: label}
+	s.Suffix = formatStatus(label, 0, 0, "")
+	return s
+}
+
+// Start starts the spinner. The elapsed time counts from the first Start.
+func (s *Status) Start() {
+	s.mu.Lock()
+	if s.started.IsZero() {
+		s.started = time.Now()
+	}
+	if s.stop == nil && !renderer.IsAccessible() {
+		s.stop = make(chan struct{})
+		go s.tick(s.stop)
+	}
+	s.mu.Unlock()
+	s.update()
+	s.Spinner.Start()
+}
+
+// Stop stops the spinner
+func (s *Status) Stop() {
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	s.mu.Unlock()
+	s.Spinner.Stop()
+}
+
+// Token counts a token of the response; Ollama streams one per chunk
+func (s *Status) Token() {
+	s.mu.Lock()
+	s.tokens++
+	s.mu.Unlock()
+	s.update()
+}
+
+// SetDetail shows detail after the counters, e.g. the file being written
+func (s *Status) SetDetail(detail string) {
+	s.mu.Lock()
+	s.detail = detail
+	s.mu.Unlock()
+	s.update()
+}
+
+// tick keeps the elapsed time current until stop is closed
+func (s *Status) tick(stop chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.update()
+		}
+	}
+}
+
+func (s *Status) update() {
+	s.mu.Lock()
+	var elapsed time.Duration
+	if !s.started.IsZero() {
+		elapsed = time.Since(s.started)
+	}
+	suffix := formatStatus(s.label, elapsed, s.tokens, s.detail)
+	s.mu.Unlock()
+
+	s.Spinner.Lock()
+	s.Suffix = suffix
+	s.Spinner.Unlock()
+}
+
+// formatStatus returns the spinner suffix, e.g. " Planning... 12s · 48 tokens". The time
+// appears after the first second and the tokens once the first one has arrived.
+func formatStatus(label string, elapsed time.Duration, tokens int, detail string) string {
+	suffix := " " + label + "..."
+	if elapsed >= time.Second {
+		suffix += " " + formatElapsed(elapsed)
+	}
+	switch {
+	case tokens == 1:
+		suffix += " · 1 token"
+	case tokens > 1:
+		suffix += fmt.Sprintf(" · %d tokens", tokens)
+	}
+	if detail != "" {
+		suffix += " · " + detail
+	}
+	return suffix
+}
+
+// formatElapsed returns d in whole seconds, e.g. "42s" or "2m05s"
+func formatElapsed(d time.Duration) string {
+	seconds := int(d / time.Second)
+	if seconds < 60 {
+		return fmt.Sprintf("%ds", seconds)
+	}
+	return fmt.Sprintf("%dm%02ds", seconds/60, seconds%60)
+}
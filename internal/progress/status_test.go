@@ -0,0 +1,26 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatStatus(t *testing.T) {
+	tests := []struct {
+		elapsed time.Duration
+		tokens  int
+		detail  string
+		want    string
+	}{
+		{0, 0, "", " Planning..."},
+		{900 * time.Millisecond, 0, "", " Planning..."},
+		{12 * time.Second, 0, "", " Planning... 12s"},
+		{3 * time.Second, 1, "", " Planning... 3s · 1 token"},
+		{125 * time.Second, 48, "main.go", " Planning... 2m05s · 48 tokens · main.go"},
+	}
+	for _, tt := range tests {
+		if got := formatStatus("Planning", tt.elapsed, tt.tokens, tt.detail); got != tt.want {
+			t.Errorf("formatStatus(%v, %d, %q) = %q, want %q", tt.elapsed, tt.tokens, tt.detail, got, tt.want)
+		}
+	}
+}
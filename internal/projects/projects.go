@@ -0,0 +1,194 @@
+package projects
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+// maxProjects bounds the registry so it doesn't grow forever
+const maxProjects = 50
+
+// Project is a directory LlamaSidekick has been used in
+type Project struct {
+	Root         string              `json:"root"`
+	Name         string              `json:"name"`
+	SessionID    string              `json:"session_id"`
+	Models       config.ModelsConfig `json:"models"`                  // Preferred models, applied when entering the project
+	TestCommand  string              `json:"test_command,omitempty"`  // Used by /fix-tests instead of test.command
+	BuildCommand string              `json:"build_command,omitempty"` // Used by /build instead of build.command
+	AllowWrite   []string            `json:"allow_write,omitempty"`   // Added to edits.allow_write in this project
+	DenyWrite    []string            `json:"deny_write,omitempty"`    // Added to edits.deny_write in this project
+	LastUsed     time.Time           `json:"last_used"`
+}
+
+// Registry tracks known projects, most recently used first
+type Registry struct {
+	Projects []Project `json:"projects"`
+}
+
+func registryPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config dir: %w", err)
+	}
+	return filepath.Join(configDir, "projects.json"), nil
+}
+
+// Load reads the project registry from disk, returning an empty registry if none exists
+func Load() (*Registry, error) {
+	path, err := registryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Registry{}, nil
+		}
+		return nil, fmt.Errorf("failed to read project registry: %w", err)
+	}
+
+	var reg Registry
+	if err := json.Unmarshal(data, &reg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal project registry: %w", err)
+	}
+	return &reg, nil
+}
+
+// Save writes the project registry to disk
+func (r *Registry) Save() error {
+	path, err := registryPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project registry: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write project registry: %w", err)
+	}
+	return nil
+}
+
+// Touch records that root was just used with the given session
+func (r *Registry) Touch(root string, sessionID string) {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+
+	entry := Project{Root: root, Name: filepath.Base(root)}
+	for i, p := range r.Projects {
+		if p.Root == root {
+			entry = p
+			r.Projects = append(r.Projects[:i], r.Projects[i+1:]...)
+			break
+		}
+	}
+	entry.SessionID = sessionID
+	entry.LastUsed = time.Now()
+
+	r.Projects = append([]Project{entry}, r.Projects...)
+	if len(r.Projects) > maxProjects {
+		r.Projects = r.Projects[:maxProjects]
+	}
+}
+
+// PinModels stores models as the preferred models for root
+func (r *Registry) PinModels(root string, models config.ModelsConfig) {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	models.Other = maps.Clone(models.Other)
+	for i, p := range r.Projects {
+		if p.Root == root {
+			r.Projects[i].Models = models
+			return
+		}
+	}
+	r.Projects = append([]Project{{Root: root, Name: filepath.Base(root), Models: models, LastUsed: time.Now()}}, r.Projects...)
+}
+
+// SetTestCommand remembers command as the test command of root
+func (r *Registry) SetTestCommand(root, command string) {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	for i, p := range r.Projects {
+		if p.Root == root {
+			r.Projects[i].TestCommand = command
+			return
+		}
+	}
+	r.Projects = append([]Project{{Root: root, Name: filepath.Base(root), TestCommand: command, LastUsed: time.Now()}}, r.Projects...)
+}
+
+// SetBuildCommand remembers command as the build command of root
+func (r *Registry) SetBuildCommand(root, command string) {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	for i, p := range r.Projects {
+		if p.Root == root {
+			r.Projects[i].BuildCommand = command
+			return
+		}
+	}
+	r.Projects = append([]Project{{Root: root, Name: filepath.Base(root), BuildCommand: command, LastUsed: time.Now()}}, r.Projects...)
+}
+
+// SetWriteRules sets the write allowlist and denylist patterns of root
+func (r *Registry) SetWriteRules(root string, allow, deny []string) {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	for i, p := range r.Projects {
+		if p.Root == root {
+			r.Projects[i].AllowWrite, r.Projects[i].DenyWrite = allow, deny
+			return
+		}
+	}
+	r.Projects = append([]Project{{Root: root, Name: filepath.Base(root), AllowWrite: allow, DenyWrite: deny, LastUsed: time.Now()}}, r.Projects...)
+}
+
+// Find returns the project registered for root, if any
+func (r *Registry) Find(root string) (Project, bool) {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	for _, p := range r.Projects {
+		if p.Root == root {
+			return p, true
+		}
+	}
+	return Project{}, false
+}
+
+// Recent returns known projects whose directories still exist, most recently used first
+func (r *Registry) Recent() []Project {
+	var recent []Project
+	for _, p := range r.Projects {
+		if info, err := os.Stat(p.Root); err == nil && info.IsDir() {
+			recent = append(recent, p)
+		}
+	}
+	sort.SliceStable(recent, func(i, j int) bool {
+		return recent[i].LastUsed.After(recent[j].LastUsed)
+	})
+	return recent
+}
+
+// ApplyModels overrides cfg's per-mode models with the ones remembered for the project,
+// in place of the previous project's, for this run only
+func (p Project) ApplyModels(cfg *config.Config) {
+	cfg.ApplyProjectModels(p.Models)
+}
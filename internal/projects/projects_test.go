@@ -0,0 +1,51 @@
+package projects
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+func TestRegistry_TouchPinAndReload(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", tmp)
+
+	a := filepath.Join(tmp, "a")
+	b := tmp
+
+	reg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	reg.Touch(a, "s1")
+	reg.Touch(b, "s2")
+	reg.Touch(a, "s3")
+	reg.PinModels(b, config.ModelsConfig{Edit: "deepseek-coder:33b"})
+	if err := reg.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(loaded.Projects) != 2 {
+		t.Fatalf("expected 2 projects, got %d", len(loaded.Projects))
+	}
+	if loaded.Projects[0].Root != a || loaded.Projects[0].SessionID != "s3" {
+		t.Fatalf("expected most recent project first, got %#v", loaded.Projects[0])
+	}
+
+	// a doesn't exist on disk, so only b is offered
+	recent := loaded.Recent()
+	if len(recent) != 1 || recent[0].Root != b {
+		t.Fatalf("unexpected recent projects: %#v", recent)
+	}
+
+	cfg := &config.Config{Models: config.ModelsConfig{Plan: "llama3", Edit: "llama3"}}
+	recent[0].ApplyModels(cfg)
+	if cfg.Models.Edit != "deepseek-coder:33b" || cfg.Models.Plan != "llama3" {
+		t.Fatalf("unexpected models after apply: %#v", cfg.Models)
+	}
+}
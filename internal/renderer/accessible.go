@@ -0,0 +1,96 @@
+package renderer
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// accessibleOutput is set for screen-reader-friendly output
+var accessibleOutput bool
+
+// SetAccessible turns accessible mode on (or back off): spinners print a line instead of
+// spinning, and full-screen views are drawn inline
+func SetAccessible(enabled bool) {
+	accessibleOutput = enabled
+}
+
+// IsAccessible reports whether accessible mode is on
+func IsAccessible() bool {
+	return accessibleOutput
+}
+
+// symbolWriter replaces emoji and box-drawing characters before passing text on:
+// status symbols become words, box drawing becomes spaces so columns stay aligned, and
+// other emoji are dropped with the spaces after them
+type symbolWriter struct {
+	w         io.Writer
+	pending   []byte // The start of a character split across writes
+	dropSpace bool   // The last character was dropped, so are the spaces after it
+}
+
+// NewAccessibleWriter returns a writer for screen readers that strips escape sequences,
+// emoji and box-drawing characters and writes to w
+func NewAccessibleWriter(w io.Writer) io.Writer {
+	return NewANSIStripper(&symbolWriter{w: w})
+}
+
+// Write replaces the symbols in p. It reports len(p) on success.
+func (s *symbolWriter) Write(p []byte) (int, error) {
+	data := append(s.pending, p...)
+	s.pending = nil
+	var b strings.Builder
+	for len(data) > 0 {
+		if !utf8.FullRune(data) {
+			s.pending = append([]byte(nil), data...)
+			break
+		}
+		r, size := utf8.DecodeRune(data)
+		s.writeRune(&b, r, data[:size])
+		data = data[size:]
+	}
+	if _, err := io.WriteString(s.w, b.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *symbolWriter) writeRune(b *strings.Builder, r rune, raw []byte) {
+	if s.dropSpace && r == ' ' {
+		return
+	}
+	s.dropSpace = false
+	if word, ok := symbolWords[r]; ok {
+		b.WriteString(word)
+		return
+	}
+	switch {
+	case r >= 0x2500 && r <= 0x257f:
+		// Box drawing
+		b.WriteByte(' ')
+	case r == '✓' || r == '↳' || isEmoji(r):
+		s.dropSpace = true
+	default:
+		b.Write(raw)
+	}
+}
+
+// symbolWords are the symbols that carry meaning, and the words they are replaced with
+var symbolWords = map[rune]string{
+	'✗': "Failed:",
+	'⚠': "Warning:",
+	'🔧': "Tool:",
+	'›': ">",
+}
+
+func isEmoji(r rune) bool {
+	return (r >= 0x1f000 && r <= 0x1faff) || (r >= 0x2600 && r <= 0x27bf) || r == 0xfe0f || r == 0x200d
+}
+
+// ReplaceSymbols replaces emoji and box-drawing characters in text like
+// NewAccessibleWriter, but keeps escape sequences
+func ReplaceSymbols(text string) string {
+	var b strings.Builder
+	(&symbolWriter{w: &b}).Write([]byte(text))
+	return b.String()
+}
@@ -0,0 +1,28 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAccessibleWriter_ReplacesSymbols(t *testing.T) {
+	var b strings.Builder
+	w := NewAccessibleWriter(&b)
+	// The check mark is split across writes in the middle of its UTF-8 encoding
+	check := "\033[1;32m✓ Copied\033[0m\n"
+	for _, chunk := range []string{check[:8], check[8:], "\033[38;5;9m✗ Tests failed\033[0m\n", "├── main.go\n", "🦙 LlamaSidekick\n", "⚙️  Settings\n"} {
+		if n, err := w.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("write %q: %d %v", chunk, n, err)
+		}
+	}
+	want := "Copied\nFailed: Tests failed\n    main.go\nLlamaSidekick\nSettings\n"
+	if got := b.String(); got != want {
+		t.Fatalf("unexpected output %q, want %q", got, want)
+	}
+}
+
+func TestReplaceSymbols_KeepsEscapeSequences(t *testing.T) {
+	if got := ReplaceSymbols("\033[1m⚠ New dependency\033[0m"); got != "\033[1mWarning: New dependency\033[0m" {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,246 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/llamasidekick/internal/diff"
+)
+
+const (
+	diffFileColor    = "\033[1m"
+	diffHunkColor    = "\033[38;5;75m"
+	diffAddColor     = "\033[38;5;10m"
+	diffDelColor     = "\033[38;5;9m"
+	diffAddWordColor = "\033[1;38;5;10;48;5;22m"
+	diffDelWordColor = "\033[1;38;5;9;48;5;52m"
+	diffReset        = "\033[0m"
+)
+
+// LooksLikeDiff reports whether text appears to be a unified diff
+func LooksLikeDiff(text string) bool {
+	hasHeader, hasHunk := false, false
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ "):
+			hasHeader = true
+		case strings.HasPrefix(line, "@@ ") && strings.Contains(line[3:], " @@"):
+			hasHunk = true
+		}
+	}
+	return hasHeader && hasHunk
+}
+
+// DiffOptions controls how RenderDiff presents a diff
+type DiffOptions struct {
+	WordLevel   bool // Highlight the changed words of modified lines
+	LineNumbers bool // Prefix lines with their old and new line numbers from the hunk headers
+}
+
+// answerDiffs are the options for diffs in code blocks of rendered markdown
+var answerDiffs = DiffOptions{WordLevel: true}
+
+// SetDiffOptions sets how diffs in code blocks of rendered markdown are presented
+func SetDiffOptions(opts DiffOptions) {
+	answerDiffs = opts
+}
+
+// renderDiffFences renders the fenced code blocks of markdown whose content LooksLikeDiff
+// with RenderDiff, and everything between them with render
+func renderDiffFences(markdown string, render func(string) string) string {
+	lines := strings.SplitAfter(markdown, "\n")
+	var parts []string
+	start := 0 // First line not rendered yet
+	for i := 0; i < len(lines); i++ {
+		fence := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(fence, "```") && !strings.HasPrefix(fence, "~~~") {
+			continue
+		}
+		marker := fence[:3]
+		end := i + 1
+		for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), marker) {
+			end++
+		}
+		body := strings.Join(lines[i+1:min(end, len(lines))], "")
+		if end == len(lines) || !LooksLikeDiff(body) {
+			i = end
+			continue
+		}
+		if text := strings.Join(lines[start:i], ""); strings.TrimSpace(text) != "" {
+			parts = append(parts, strings.Trim(render(text), "\n"))
+		}
+		// Indented like glamour's code blocks
+		rendered := RenderDiff(body, answerDiffs)
+		parts = append(parts, "  "+strings.ReplaceAll(strings.TrimSuffix(rendered, "\n"), "\n", "\n  "))
+		start, i = end+1, end
+	}
+	if parts == nil {
+		return render(markdown)
+	}
+	if text := strings.Join(lines[start:], ""); strings.TrimSpace(text) != "" {
+		parts = append(parts, strings.Trim(render(text), "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// RenderDiff colors a unified diff for the terminal: file headers in bold, hunk headers in
+// blue, additions green and deletions red. With WordLevel, runs of deleted lines directly
+// followed by the same number of added lines also get the changed words highlighted.
+func RenderDiff(text string, opts DiffOptions) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	var b strings.Builder
+	g := &gutter{enabled: opts.LineNumbers}
+	wordLevel := opts.WordLevel
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") || strings.HasPrefix(line, "diff "):
+			writeColored(&b, diffFileColor, line)
+		case strings.HasPrefix(line, "@@"):
+			g.startHunk(line)
+			writeColored(&b, diffHunkColor, line)
+		case strings.HasPrefix(line, "-"):
+			// Collect the block of deletions and the additions that replace it
+			dels := i
+			for i < len(lines) && isChange(lines[i], '-') {
+				i++
+			}
+			adds := i
+			for i < len(lines) && isChange(lines[i], '+') {
+				i++
+			}
+			removed, added := lines[dels:adds], lines[adds:i]
+			i--
+			if wordLevel && len(removed) == len(added) {
+				newLines := make([]string, len(added))
+				for k := range removed {
+					oldLine, newLine := highlightWords(removed[k][1:], added[k][1:])
+					newLines[k] = newLine
+					b.WriteString(g.next('-'))
+					writeColored(&b, diffDelColor, "-"+oldLine)
+				}
+				for _, newLine := range newLines {
+					b.WriteString(g.next('+'))
+					writeColored(&b, diffAddColor, "+"+newLine)
+				}
+				continue
+			}
+			for _, l := range removed {
+				b.WriteString(g.next('-'))
+				writeColored(&b, diffDelColor, l)
+			}
+			for _, l := range added {
+				b.WriteString(g.next('+'))
+				writeColored(&b, diffAddColor, l)
+			}
+		case strings.HasPrefix(line, "+"):
+			b.WriteString(g.next('+'))
+			writeColored(&b, diffAddColor, line)
+		default:
+			if strings.HasPrefix(line, " ") {
+				b.WriteString(g.next(' '))
+			}
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// gutter tracks old and new line numbers through the hunks of a diff
+type gutter struct {
+	enabled  bool
+	old, new int
+}
+
+// startHunk reads the starting line numbers from a "@@ -a,b +c,d @@" header
+func (g *gutter) startHunk(header string) {
+	var oldStart, newStart int
+	fields := strings.Fields(header)
+	if len(fields) >= 3 {
+		fmt.Sscanf(strings.TrimPrefix(fields[1], "-"), "%d", &oldStart)
+		fmt.Sscanf(strings.TrimPrefix(fields[2], "+"), "%d", &newStart)
+	}
+	g.old, g.new = oldStart, newStart
+}
+
+// next returns the gutter for a line of the given kind and advances the counters
+func (g *gutter) next(kind byte) string {
+	if !g.enabled {
+		return ""
+	}
+	oldNum, newNum := "", ""
+	switch kind {
+	case '-':
+		oldNum = fmt.Sprint(g.old)
+		g.old++
+	case '+':
+		newNum = fmt.Sprint(g.new)
+		g.new++
+	default:
+		oldNum, newNum = fmt.Sprint(g.old), fmt.Sprint(g.new)
+		g.old++
+		g.new++
+	}
+	return fmt.Sprintf("\033[38;5;240m%4s %4s │\033[0m ", oldNum, newNum)
+}
+
+// isChange reports whether line is a diff line of the given kind (and not a file header)
+func isChange(line string, prefix byte) bool {
+	if line == "" || line[0] != prefix {
+		return false
+	}
+	return !strings.HasPrefix(line, "--- ") && !strings.HasPrefix(line, "+++ ")
+}
+
+func writeColored(b *strings.Builder, color, line string) {
+	b.WriteString(color)
+	b.WriteString(line)
+	b.WriteString(diffReset)
+	b.WriteString("\n")
+}
+
+// highlightWords marks the words that differ between oldLine and newLine. The highlight
+// escape sequences switch back to the line color afterwards.
+func highlightWords(oldLine, newLine string) (string, string) {
+	var oldOut, newOut strings.Builder
+	for _, l := range diff.Lines(splitWords(oldLine), splitWords(newLine)) {
+		switch l.Kind {
+		case diff.Equal:
+			oldOut.WriteString(l.Text)
+			newOut.WriteString(l.Text)
+		case diff.Delete:
+			oldOut.WriteString(diffDelWordColor + l.Text + diffReset + diffDelColor)
+		case diff.Insert:
+			newOut.WriteString(diffAddWordColor + l.Text + diffReset + diffAddColor)
+		}
+	}
+	return oldOut.String(), newOut.String()
+}
+
+// splitWords splits s into words, runs of whitespace and single punctuation characters
+func splitWords(s string) []string {
+	var tokens []string
+	var current []rune
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		}
+		return 3
+	}
+	for _, r := range s {
+		if len(current) > 0 && (class(r) != class(current[0]) || class(r) == 3) {
+			tokens = append(tokens, string(current))
+			current = current[:0]
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		tokens = append(tokens, string(current))
+	}
+	return tokens
+}
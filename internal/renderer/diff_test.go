@@ -0,0 +1,94 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var speed = 10
++var speed = 5
+ func main() {}
+`
+
+func TestLooksLikeDiff(t *testing.T) {
+	if !LooksLikeDiff(sampleDiff) {
+		t.Fatalf("expected sample to be detected as a diff")
+	}
+	if LooksLikeDiff("- a list item\n+ not a diff\n") {
+		t.Fatalf("expected markdown list not to be detected as a diff")
+	}
+}
+
+func TestRenderDiff_ColorsLines(t *testing.T) {
+	out := RenderDiff(sampleDiff, DiffOptions{})
+	if !strings.Contains(out, diffDelColor+"-var speed = 10"+diffReset) {
+		t.Fatalf("expected deletion in red:\n%q", out)
+	}
+	if !strings.Contains(out, diffAddColor+"+var speed = 5"+diffReset) {
+		t.Fatalf("expected addition in green:\n%q", out)
+	}
+	if !strings.Contains(out, diffHunkColor+"@@ -1,3 +1,3 @@") {
+		t.Fatalf("expected colored hunk header:\n%q", out)
+	}
+	if plain(out) != sampleDiff {
+		t.Fatalf("expected text to be unchanged apart from colors:\n%s", plain(out))
+	}
+}
+
+func TestRenderDiff_HighlightsChangedWords(t *testing.T) {
+	out := RenderDiff(sampleDiff, DiffOptions{WordLevel: true})
+	if !strings.Contains(out, diffDelWordColor+"10"+diffReset) {
+		t.Fatalf("expected changed word highlighted in deletion:\n%q", out)
+	}
+	if !strings.Contains(out, diffAddWordColor+"5"+diffReset) {
+		t.Fatalf("expected changed word highlighted in addition:\n%q", out)
+	}
+	if strings.Contains(out, diffAddWordColor+"speed") {
+		t.Fatalf("expected unchanged words not to be highlighted:\n%q", out)
+	}
+	if plain(out) != sampleDiff {
+		t.Fatalf("expected text to be unchanged apart from colors:\n%s", plain(out))
+	}
+}
+
+func TestRenderDiff_LineNumbers(t *testing.T) {
+	out := plain(RenderDiff(sampleDiff, DiffOptions{LineNumbers: true}))
+	for _, want := range []string{
+		"   1    1 │  package main",
+		"   2      │ -var speed = 10",
+		"        2 │ +var speed = 5",
+		"   3    3 │  func main() {}",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderDiffFences(t *testing.T) {
+	render := func(md string) string { return "<" + strings.TrimSpace(md) + ">" }
+	markdown := "Here is the fix:\n\n```diff\n" + sampleDiff + "```\n\nand some code:\n\n```go\nx := 1\n```\n"
+	out := renderDiffFences(markdown, render)
+	if !strings.HasPrefix(out, "<Here is the fix:>\n\n") {
+		t.Fatalf("expected the text before the diff to be rendered as markdown:\n%q", out)
+	}
+	if !strings.Contains(out, "  "+diffDelColor+"-var speed = ") || strings.Contains(out, "```diff") {
+		t.Fatalf("expected the fenced diff to be colored:\n%q", out)
+	}
+	if !strings.HasSuffix(out, "<and some code:\n\n```go\nx := 1\n```>") {
+		t.Fatalf("expected other code blocks to be left to markdown:\n%q", out)
+	}
+
+	plainCode := "```\nnot a diff\n```\n"
+	if got := renderDiffFences(plainCode, render); got != render(plainCode) {
+		t.Fatalf("expected markdown without diffs to be rendered whole, got %q", got)
+	}
+	unterminated := "```diff\n" + sampleDiff
+	if got := renderDiffFences(unterminated, render); got != render(unterminated) {
+		t.Fatalf("expected an unterminated fence to be left to markdown, got %q", got)
+	}
+}
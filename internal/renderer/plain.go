@@ -0,0 +1,92 @@
+package renderer
+
+import (
+	"io"
+	"strings"
+)
+
+// plainOutput disables glamour so markdown is printed as written
+var plainOutput bool
+
+// SetPlain turns markdown rendering off (or back on). Plain output is used when stdout
+// is not a terminal, so redirected responses contain the markdown the model wrote.
+func SetPlain(enabled bool) {
+	plainOutput = enabled
+}
+
+// IsPlain reports whether markdown rendering is turned off
+func IsPlain() bool {
+	return plainOutput
+}
+
+// ANSI escape parser states
+const (
+	ansiText = iota
+	ansiEscape
+	ansiCSI
+	ansiOSC
+	ansiOSCEscape
+)
+
+// ANSIStripper is a writer that removes ANSI escape sequences (colors, cursor movement,
+// terminal titles) before passing text on. Sequences may be split across writes.
+type ANSIStripper struct {
+	w     io.Writer
+	state int
+}
+
+// NewANSIStripper returns a writer that strips escape sequences and writes to w
+func NewANSIStripper(w io.Writer) *ANSIStripper {
+	return &ANSIStripper{w: w}
+}
+
+// Write strips escape sequences from p. It reports len(p) on success, since the bytes
+// that were dropped were consumed on purpose.
+func (s *ANSIStripper) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, c := range p {
+		switch s.state {
+		case ansiText:
+			if c == 0x1b {
+				s.state = ansiEscape
+			} else {
+				out = append(out, c)
+			}
+		case ansiEscape:
+			switch c {
+			case '[':
+				s.state = ansiCSI
+			case ']':
+				s.state = ansiOSC
+			default:
+				// Two-byte sequence such as ESC 7
+				s.state = ansiText
+			}
+		case ansiCSI:
+			// Parameters and intermediates run until a final byte in 0x40-0x7e
+			if c >= 0x40 && c <= 0x7e {
+				s.state = ansiText
+			}
+		case ansiOSC:
+			// Terminated by BEL or ESC \
+			if c == 0x07 {
+				s.state = ansiText
+			} else if c == 0x1b {
+				s.state = ansiOSCEscape
+			}
+		case ansiOSCEscape:
+			s.state = ansiText
+		}
+	}
+	if _, err := s.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// StripANSI removes ANSI escape sequences from text
+func StripANSI(text string) string {
+	var b strings.Builder
+	NewANSIStripper(&b).Write([]byte(text))
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestANSIStripper_HandlesSplitSequences(t *testing.T) {
+	var b strings.Builder
+	s := NewANSIStripper(&b)
+	for _, chunk := range []string{"\033[38;5;", "9mError\033[0m ", "\033]0;title\007ok", "\033[?25l!"} {
+		if n, err := s.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("write %q: %d %v", chunk, n, err)
+		}
+	}
+	if got := b.String(); got != "Error ok!" {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
+
+func TestSetPlain_PrintsMarkdownAsWritten(t *testing.T) {
+	SetPlain(true)
+	defer SetPlain(false)
+
+	md := "# Title\n\nSome **bold** text\n"
+	if out := RenderMarkdown(md); out != md {
+		t.Fatalf("expected markdown unchanged, got %q", out)
+	}
+	buf := NewStreamingMarkdownBuffer()
+	buf.Write(md)
+	if out := buf.Flush() + buf.Finish(); out != "# Title\n\nSome **bold** text" {
+		t.Fatalf("unexpected streamed output %q", out)
+	}
+}
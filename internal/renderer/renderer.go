@@ -1,48 +1,108 @@
 package renderer
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
 	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/glamour/styles"
 )
 
 var mdRenderer *glamour.TermRenderer
 
+// DefaultStyle is the glamour style used when none is configured
+const DefaultStyle = "dark"
+
 func init() {
-	var err error
-	// Create a dark-mode terminal renderer with specific style
-	mdRenderer, err = glamour.NewTermRenderer(
-		glamour.WithStandardStyle("dark"),
-		glamour.WithWordWrap(100),
-	)
-	if err != nil {
+	if err := Configure(DefaultStyle, ""); err != nil {
 		// Print error to stderr for debugging
 		fmt.Fprintf(os.Stderr, "Warning: Failed to initialize glamour renderer: %v\n", err)
 		mdRenderer = nil
 	}
 }
 
+// Configure rebuilds the markdown renderer. style is a glamour standard style name (dark,
+// light, dracula, tokyo-night, pink, ascii, notty) or the path of a glamour JSON style file.
+// codeTheme, if set, is the chroma theme used to highlight code blocks (e.g. monokai).
+// On error the current renderer is kept.
+func Configure(style, codeTheme string) error {
+	if style == "" {
+		style = DefaultStyle
+	}
+
+	var sc ansi.StyleConfig
+	if builtin, ok := styles.DefaultStyles[style]; ok {
+		sc = *builtin
+	} else {
+		data, err := os.ReadFile(style)
+		if err != nil {
+			return fmt.Errorf("unknown markdown style %q (use %s, or a JSON style file): %w", style, strings.Join(StandardStyles(), ", "), err)
+		}
+		if err := json.Unmarshal(data, &sc); err != nil {
+			return fmt.Errorf("failed to parse markdown style %s: %w", style, err)
+		}
+	}
+
+	if codeTheme != "" {
+		if _, ok := chromastyles.Registry[codeTheme]; !ok {
+			return fmt.Errorf("unknown code theme %q (see https://xyproto.github.io/splash/docs/ for chroma themes)", codeTheme)
+		}
+		// A custom Chroma palette in the style takes precedence over Theme, so drop it
+		sc.CodeBlock.Theme = codeTheme
+		sc.CodeBlock.Chroma = nil
+	}
+
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStyles(sc),
+		glamour.WithWordWrap(100),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create markdown renderer: %w", err)
+	}
+	mdRenderer = r
+	return nil
+}
+
+// StandardStyles returns the names of glamour's built-in styles
+func StandardStyles() []string {
+	names := make([]string, 0, len(styles.DefaultStyles))
+	for name := range styles.DefaultStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // RenderMarkdown renders markdown text with glamour for terminal display
 func RenderMarkdown(markdown string) string {
-	if mdRenderer == nil {
+	if mdRenderer == nil || plainOutput {
 		return markdown // Fallback to plain text
 	}
+	return strings.TrimSpace(renderDiffFences(markdown, glamourRender))
+}
 
+// glamourRender renders markdown with glamour, or returns it unchanged if that fails
+func glamourRender(markdown string) string {
 	rendered, err := mdRenderer.Render(markdown)
 	if err != nil {
 		// Print error for debugging
 		fmt.Fprintf(os.Stderr, "Warning: Failed to render markdown: %v\n", err)
 		return markdown // Fallback on error
 	}
-
-	return strings.TrimSpace(rendered)
+	return rendered
 }
 
-// StreamingMarkdownBuffer accumulates markdown chunks for rendering
+// StreamingMarkdownBuffer accumulates markdown chunks for rendering. Completed blocks can
+// be rendered as they arrive with Flush; only the unterminated trailing block is held back,
+// so output that has been printed never needs to change.
 type StreamingMarkdownBuffer struct {
-	buffer strings.Builder
+	buffer  strings.Builder
+	flushed int // Bytes of buffer already rendered by Flush
 }
 
 // NewStreamingMarkdownBuffer creates a new buffer
@@ -64,3 +124,67 @@ func (b *StreamingMarkdownBuffer) String() string {
 func (b *StreamingMarkdownBuffer) Render() string {
 	return RenderMarkdown(b.buffer.String())
 }
+
+// Flush returns the rendered output of blocks completed since the last call, or "" if
+// the trailing block is still open. A block ends at a blank line outside a code fence.
+func (b *StreamingMarkdownBuffer) Flush() string {
+	pending := b.buffer.String()[b.flushed:]
+	end := stableEnd(pending)
+	if end == 0 {
+		return ""
+	}
+	b.flushed += end
+	if strings.TrimSpace(pending[:end]) == "" {
+		return ""
+	}
+	return renderBlock(pending[:end]) + "\n\n"
+}
+
+// Finish renders everything Flush has not emitted yet, including an unterminated block
+func (b *StreamingMarkdownBuffer) Finish() string {
+	pending := b.buffer.String()[b.flushed:]
+	b.flushed = b.buffer.Len()
+	if strings.TrimSpace(pending) == "" {
+		return ""
+	}
+	return renderBlock(pending)
+}
+
+// stableEnd returns the length of the prefix of text made of complete blocks
+func stableEnd(text string) int {
+	end := 0
+	inFence := false
+	hasContent := false
+	pos := 0
+	for {
+		nl := strings.IndexByte(text[pos:], '\n')
+		if nl < 0 {
+			// The last line is incomplete
+			return end
+		}
+		line := strings.TrimSpace(text[pos : pos+nl])
+		pos += nl + 1
+
+		switch {
+		case strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~"):
+			inFence = !inFence
+			hasContent = true
+		case line == "" && !inFence:
+			if hasContent {
+				end = pos
+				hasContent = false
+			}
+		default:
+			hasContent = true
+		}
+	}
+}
+
+// renderBlock renders one block of markdown, keeping glamour's margins but not the blank
+// lines around it
+func renderBlock(markdown string) string {
+	if mdRenderer == nil || plainOutput {
+		return strings.Trim(markdown, "\n")
+	}
+	return strings.Trim(renderDiffFences(markdown, glamourRender), "\n")
+}
@@ -0,0 +1,77 @@
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func plain(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+func TestStreamingMarkdownBuffer_FlushesCompletedBlocks(t *testing.T) {
+	b := NewStreamingMarkdownBuffer()
+
+	b.Write("First paragr")
+	if out := b.Flush(); out != "" {
+		t.Fatalf("expected nothing for an open block, got %q", out)
+	}
+	b.Write("aph.\n\nSecond")
+	out := plain(b.Flush())
+	if !strings.Contains(out, "First paragraph.") || strings.Contains(out, "Second") {
+		t.Fatalf("expected only the first block, got %q", out)
+	}
+	if again := b.Flush(); again != "" {
+		t.Fatalf("expected flushed output not to repeat, got %q", again)
+	}
+
+	rest := plain(b.Finish())
+	if !strings.Contains(rest, "Second") {
+		t.Fatalf("expected Finish to render the trailing block, got %q", rest)
+	}
+	if b.String() != "First paragraph.\n\nSecond" {
+		t.Fatalf("unexpected accumulated content %q", b.String())
+	}
+}
+
+func TestStableEnd_KeepsCodeFencesTogether(t *testing.T) {
+	text := "Intro\n\n```go\nfunc a() {}\n\nfunc b() {}\n"
+	if end := stableEnd(text); end != len("Intro\n\n") {
+		t.Fatalf("expected the open fence to be held back, got end %d", end)
+	}
+
+	closed := text + "```\n\n"
+	if end := stableEnd(closed); end != len(closed) {
+		t.Fatalf("expected the closed fence to be stable, got end %d of %d", end, len(closed))
+	}
+}
+
+func TestConfigure_StylesAndCodeThemes(t *testing.T) {
+	defer Configure(DefaultStyle, "")
+
+	if err := Configure("light", "monokai"); err != nil {
+		t.Fatalf("expected builtin style with chroma theme to work, got %v", err)
+	}
+	if err := Configure("no-such-style", ""); err == nil {
+		t.Fatalf("expected unknown style to fail")
+	}
+	if err := Configure("dark", "no-such-theme"); err == nil {
+		t.Fatalf("expected unknown code theme to fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "style.json")
+	if err := os.WriteFile(path, []byte(`{"document": {"margin": 0}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Configure(path, ""); err != nil {
+		t.Fatalf("expected JSON style file to load, got %v", err)
+	}
+	if out := plain(RenderMarkdown("hello")); !strings.Contains(out, "hello") {
+		t.Fatalf("expected rendered text, got %q", out)
+	}
+}
@@ -0,0 +1,178 @@
+// Package respcache keeps model responses on disk by a hash of their request, so a
+// request with deterministic settings that was answered before can be answered again
+// without generating.
+package respcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+// entry is what a cache file holds
+type entry struct {
+	Model     string    `json:"model"`
+	Response  string    `json:"response"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store is a response cache in a directory, one file per response. When it holds more
+// than its maximum, the least recently used responses are dropped. It is safe for
+// concurrent use, so one store can be shared by all clients of a run.
+type Store struct {
+	mu         sync.Mutex
+	dir        string
+	maxEntries int
+	hits       int // Responses served this run
+	stored     int // Responses added this run
+}
+
+// New creates a store that keeps up to maxEntries responses in dir
+func New(dir string, maxEntries int) *Store {
+	return &Store{dir: dir, maxEntries: maxEntries}
+}
+
+// Open creates a store in the data dir
+func Open(maxEntries int) (*Store, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return New(filepath.Join(dataDir, "cache", "responses"), maxEntries), nil
+}
+
+// SetMaxEntries changes how many responses are kept, e.g. after the config was edited
+func (s *Store) SetMaxEntries(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxEntries = n
+}
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+// Get returns the response cached for key
+func (s *Store) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path(key))
+	var e entry
+	if err != nil || json.Unmarshal(data, &e) != nil {
+		return "", false
+	}
+	s.hits++
+	// Mark it as recently used, so pruning keeps it
+	now := time.Now()
+	os.Chtimes(s.path(key), now, now)
+	return e.Response, true
+}
+
+// Put caches the response model gave for key
+func (s *Store) Put(key, model, response string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	data, err := json.Marshal(entry{Model: model, Response: response, CreatedAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cached response: %w", err)
+	}
+	tmp := s.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cached response: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(key)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write cached response: %w", err)
+	}
+	s.stored++
+	return s.prune()
+}
+
+// cacheFile is a cached response on disk
+type cacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (s *Store) files() ([]cacheFile, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache dir: %w", err)
+	}
+	var files []cacheFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cacheFile{path: filepath.Join(s.dir, e.Name()), size: info.Size(), modTime: info.ModTime()})
+	}
+	return files, nil
+}
+
+// prune drops the least recently used responses beyond maxEntries
+func (s *Store) prune() error {
+	if s.maxEntries <= 0 {
+		return nil
+	}
+	files, err := s.files()
+	if err != nil || len(files) <= s.maxEntries {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files[:len(files)-s.maxEntries] {
+		os.Remove(f.path)
+	}
+	return nil
+}
+
+// Clear drops every cached response and returns how many there were
+func (s *Store) Clear() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files, err := s.files()
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range files {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("failed to clear cache: %w", err)
+		}
+	}
+	return len(files), nil
+}
+
+// Summary describes the cache and how it was used this run, one line per fact
+func (s *Store) Summary() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	for _, f := range files {
+		size += f.size
+	}
+	return []string{
+		fmt.Sprintf("%d of at most %d responses cached (%.1f KB) in %s", len(files), s.maxEntries, float64(size)/1024, s.dir),
+		fmt.Sprintf("This run: %d answered from the cache, %d generated and cached", s.hits, s.stored),
+	}, nil
+}
@@ -0,0 +1,54 @@
+package respcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_PutGetClear(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "responses"), 10)
+	if _, ok := s.Get("abc"); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	if err := s.Put("abc", "llama3", "hello"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if got, ok := s.Get("abc"); !ok || got != "hello" {
+		t.Fatalf("expected the cached response, got %q, %v", got, ok)
+	}
+	if s.hits != 1 || s.stored != 1 {
+		t.Fatalf("expected 1 hit and 1 stored response, got %d and %d", s.hits, s.stored)
+	}
+
+	n, err := s.Clear()
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 cleared response, got %d, %v", n, err)
+	}
+	if _, ok := s.Get("abc"); ok {
+		t.Fatal("expected a miss after clearing")
+	}
+}
+
+func TestStore_DropsLeastRecentlyUsed(t *testing.T) {
+	s := New(t.TempDir(), 2)
+	for i, key := range []string{"a", "b"} {
+		if err := s.Put(key, "m", key); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+		old := time.Now().Add(time.Duration(i-10) * time.Minute)
+		os.Chtimes(s.path(key), old, old)
+	}
+	// Using a makes b the least recently used
+	s.Get("a")
+	if err := s.Put("c", "m", "c"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, err := os.Stat(s.path(key)); (err == nil) != want {
+			t.Errorf("%s cached = %v, want %v", key, err == nil, want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package safeio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/filelock"
+)
+
+// backupTimeFormat sorts lexically in chronological order
+const backupTimeFormat = "20060102T150405.000000000"
+
+// BackupStore keeps timestamped copies of files outside the project tree, keyed by
+// project root and relative path, keeping at most Keep versions per file. Writes through
+// the store are checked against Policy. When Trash is set, every replaced content is also
+// kept there regardless of Keep; RemovedTrash does the same for removed files only.
+// Transactions check their files against the hashes in Reads before writing.
+type BackupStore struct {
+	Dir          string
+	Keep         int
+	Policy       WritePolicy
+	Trash        *Trash
+	RemovedTrash *Trash
+	Reads        *ReadLog
+}
+
+// Backup is a stored version of a file
+type Backup struct {
+	Version int // 1 is the most recent
+	Path    string
+	Time    time.Time
+	Size    int64
+}
+
+// NewBackupStore creates a store rooted at dir
+func NewBackupStore(dir string, keep int) *BackupStore {
+	if keep < 1 {
+		keep = 1
+	}
+	return &BackupStore{Dir: dir, Keep: keep}
+}
+
+// fileDir returns the directory holding all backups of relPath in root
+func (b *BackupStore) fileDir(root, relPath string) (string, error) {
+	rootAbs, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project root: %w", err)
+	}
+	sum := sha256.Sum256([]byte(rootAbs))
+	project := filepath.Base(rootAbs) + "-" + hex.EncodeToString(sum[:6])
+	return filepath.Join(b.Dir, project, filepath.Clean(relPath)), nil
+}
+
+// lockPath returns the lock file guarding writes to absPath, kept in the store rather
+// than next to the file so project trees stay clean
+func (b *BackupStore) lockPath(absPath string) string {
+	sum := sha256.Sum256([]byte(absPath))
+	return filepath.Join(b.Dir, "locks", filepath.Base(absPath)+"-"+hex.EncodeToString(sum[:6])+".lock")
+}
+
+// Backup copies the current content of relPath (if it exists) into the store and prunes
+// old versions. It returns the backup path, or "" if there was nothing to back up.
+func (b *BackupStore) Backup(root, relPath string) (string, error) {
+	absPath, relPath, err := ResolveWithinRoot(root, relPath)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil || !info.Mode().IsRegular() {
+		// Only regular files have content worth keeping (reading a FIFO would block)
+		return "", nil
+	}
+
+	existing, err := os.ReadFile(absPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read existing file for backup: %w", err)
+	}
+
+	dir, err := b.fileDir(root, relPath)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup dir: %w", err)
+	}
+
+	backupPath := filepath.Join(dir, time.Now().Format(backupTimeFormat)+".bak")
+	if err := os.WriteFile(backupPath, existing, 0644); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	if b.Trash != nil {
+		if _, err := b.Trash.Put(root, relPath, existing); err != nil {
+			return backupPath, err
+		}
+	}
+
+	if err := b.prune(root, relPath); err != nil {
+		return backupPath, err
+	}
+	return backupPath, nil
+}
+
+// List returns the stored versions of relPath, most recent first
+func (b *BackupStore) List(root, relPath string) ([]Backup, error) {
+	_, relPath, err := ResolveWithinRoot(root, relPath)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := b.fileDir(root, relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".bak") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	backups := make([]Backup, 0, len(names))
+	for i, name := range names {
+		backup := Backup{Version: i + 1, Path: filepath.Join(dir, name)}
+		if t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(name, ".bak"), time.Local); err == nil {
+			backup.Time = t
+		}
+		if info, err := os.Stat(backup.Path); err == nil {
+			backup.Size = info.Size()
+		}
+		backups = append(backups, backup)
+	}
+	return backups, nil
+}
+
+// Restore writes the given backup version (1 = most recent) back to relPath. The current
+// content is backed up first so a restore can itself be undone.
+func (b *BackupStore) Restore(root, relPath string, version int) (Backup, error) {
+	backups, err := b.List(root, relPath)
+	if err != nil {
+		return Backup{}, err
+	}
+	if len(backups) == 0 {
+		return Backup{}, fmt.Errorf("no backups found for %s", relPath)
+	}
+	if version < 1 || version > len(backups) {
+		return Backup{}, fmt.Errorf("version %d not found for %s (have 1-%d)", version, relPath, len(backups))
+	}
+	backup := backups[version-1]
+
+	content, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return Backup{}, fmt.Errorf("failed to read backup: %w", err)
+	}
+	if _, err := b.WriteFile(root, relPath, content); err != nil {
+		return Backup{}, err
+	}
+	return backup, nil
+}
+
+// WriteFile writes content to relPath inside root, first backing up any existing file
+// into the store. It returns the backup path, or "" if the file didn't exist.
+func (b *BackupStore) WriteFile(root, relPath string, content []byte) (backupPath string, err error) {
+	absPath, relPath, err := ResolveWithinRoot(root, relPath)
+	if err != nil {
+		return "", err
+	}
+	if err := b.Policy.Check(absPath, relPath); err != nil {
+		slog.Warn("refused write", "path", absPath, "error", err)
+		return "", err
+	}
+
+	// Keep other instances from writing the same file between backup and write
+	lock, err := filelock.Acquire(b.lockPath(absPath), filelock.DefaultTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer lock.Release()
+
+	backupPath, err = b.Backup(root, relPath)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+		return backupPath, fmt.Errorf("failed to create directories: %w", err)
+	}
+	// Existing files keep their permissions; os.WriteFile only applies mode on create
+	if err := os.WriteFile(absPath, content, newFileMode(relPath, content)); err != nil {
+		slog.Error("write failed", "path", absPath, "error", err)
+		return backupPath, fmt.Errorf("failed to write file: %w", err)
+	}
+	slog.Info("wrote file", "path", absPath, "bytes", len(content), "backup", backupPath)
+	return backupPath, nil
+}
+
+// RemoveFile deletes relPath inside root after backing it up into the store, so the
+// removal can be undone with Restore. It returns the backup path.
+func (b *BackupStore) RemoveFile(root, relPath string) (backupPath string, err error) {
+	absPath, relPath, err := ResolveWithinRoot(root, relPath)
+	if err != nil {
+		return "", err
+	}
+	if err := b.Policy.Check(absPath, relPath); err != nil {
+		slog.Warn("refused removal", "path", absPath, "error", err)
+		return "", err
+	}
+
+	lock, err := filelock.Acquire(b.lockPath(absPath), filelock.DefaultTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer lock.Release()
+
+	backupPath, err = b.Backup(root, relPath)
+	if err != nil {
+		return "", err
+	}
+	if b.Trash == nil && b.RemovedTrash != nil {
+		content, err := os.ReadFile(absPath)
+		if err != nil {
+			return backupPath, fmt.Errorf("failed to read file for the trash: %w", err)
+		}
+		if _, err := b.RemovedTrash.Put(root, relPath, content); err != nil {
+			return backupPath, err
+		}
+	}
+	if err := os.Remove(absPath); err != nil {
+		slog.Error("remove failed", "path", absPath, "error", err)
+		return backupPath, fmt.Errorf("failed to remove file: %w", err)
+	}
+	slog.Info("removed file", "path", absPath, "backup", backupPath)
+	return backupPath, nil
+}
+
+// prune removes all but the newest Keep versions of relPath
+func (b *BackupStore) prune(root, relPath string) error {
+	backups, err := b.List(root, relPath)
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups {
+		if backup.Version <= b.Keep {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+	}
+	return nil
+}
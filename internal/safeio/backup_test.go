@@ -0,0 +1,115 @@
+package safeio
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBackupStore_RotatesAndRestores(t *testing.T) {
+	root := t.TempDir()
+	store := NewBackupStore(t.TempDir(), 2)
+
+	for _, content := range []string{"v1", "v2", "v3", "v4"} {
+		if _, err := store.WriteFile(root, "dir/file.txt", []byte(content)); err != nil {
+			t.Fatalf("write %s: %v", content, err)
+		}
+	}
+
+	backups, err := store.List(root, "dir/file.txt")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups kept, got %d", len(backups))
+	}
+	if strings.HasPrefix(backups[0].Path, root) {
+		t.Fatalf("backup stored inside project tree: %s", backups[0].Path)
+	}
+	if data, _ := os.ReadFile(backups[0].Path); string(data) != "v3" {
+		t.Fatalf("expected most recent backup to be v3, got %q", data)
+	}
+
+	if _, err := store.Restore(root, "dir/file.txt", 2); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "dir", "file.txt")); string(data) != "v2" {
+		t.Fatalf("expected restored content v2, got %q", data)
+	}
+
+	// The pre-restore content is itself backed up
+	backups, _ = store.List(root, "dir/file.txt")
+	if data, _ := os.ReadFile(backups[0].Path); string(data) != "v4" {
+		t.Fatalf("expected v4 to be backed up before restore, got %q", data)
+	}
+
+	if _, err := store.Restore(root, "dir/file.txt", 5); err == nil {
+		t.Fatalf("expected error for missing version")
+	}
+}
+
+func TestBackupStore_WriteFileRespectsPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix permission bits")
+	}
+	root := t.TempDir()
+	store := NewBackupStore(t.TempDir(), 2)
+
+	if err := os.WriteFile(filepath.Join(root, "tool"), []byte("old"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.WriteFile(root, "tool", []byte("new")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if info, _ := os.Stat(filepath.Join(root, "tool")); info.Mode().Perm() != 0750 {
+		t.Fatalf("expected mode 0750 to be preserved, got %v", info.Mode().Perm())
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "locked.txt"), []byte("keep"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.WriteFile(root, "locked.txt", []byte("changed")); err == nil {
+		t.Fatalf("expected read-only file to be refused")
+	}
+
+	if _, err := store.WriteFile(root, "build.sh", []byte("echo hi\n")); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	if info, _ := os.Stat(filepath.Join(root, "build.sh")); info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("expected new shell script to be executable, got %v", info.Mode().Perm())
+	}
+	if _, err := store.WriteFile(root, "notes.txt", []byte("plain")); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	if info, _ := os.Stat(filepath.Join(root, "notes.txt")); info.Mode().Perm()&0111 != 0 {
+		t.Fatalf("expected text file not to be executable, got %v", info.Mode().Perm())
+	}
+}
+
+func TestBackupStore_RemoveFileKeepsItInTheTrash(t *testing.T) {
+	root := t.TempDir()
+	store := NewBackupStore(t.TempDir(), 2)
+	store.RemovedTrash = NewTrash(t.TempDir())
+	if err := os.WriteFile(filepath.Join(root, "old.txt"), []byte("bye"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.RemoveFile(root, "old.txt"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "old.txt")); !os.IsNotExist(err) {
+		t.Fatalf("old.txt should have been removed")
+	}
+	entries, err := store.RemovedTrash.List(root)
+	if err != nil || len(entries) != 1 || entries[0].RelPath != "old.txt" {
+		t.Fatalf("expected old.txt in the trash, got %v %v", entries, err)
+	}
+	if _, err := store.RemovedTrash.Restore(root, entries[0].ID, store); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "old.txt")); string(data) != "bye" {
+		t.Fatalf("old.txt not restored, got %q", data)
+	}
+}
@@ -0,0 +1,107 @@
+package safeio
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+)
+
+// WritePolicy controls which files may be written. Paths matching Deny are never written,
+// and when Allow is set only paths matching it are. Of existing files, writable regular
+// files are allowed, read-only files never are, and symlinks and special files are
+// refused unless explicitly allowed.
+type WritePolicy struct {
+	AllowSymlinks     bool     // Write through symlinks (the target must still be inside the project)
+	AllowSpecialFiles bool     // Write to device files, FIFOs and sockets
+	Allow             []string // Patterns of the project paths that may be written (empty = all)
+	Deny              []string // Patterns of the project paths that are never written
+}
+
+// Check returns an error if the file at absPath, relPath inside the project, must not be
+// written or removed under the policy
+func (p WritePolicy) Check(absPath, relPath string) error {
+	if err := p.CheckPath(relPath); err != nil {
+		return err
+	}
+	return p.CheckWritable(absPath)
+}
+
+// CheckPath returns an error if the project path relPath is denied or, with an allowlist,
+// not allowed. Patterns are matched like context.ignore.
+func (p WritePolicy) CheckPath(relPath string) error {
+	for _, pattern := range p.Deny {
+		if pathmatch.Match(pattern, relPath) {
+			return fmt.Errorf("%s is protected by the write denylist pattern %q", relPath, pattern)
+		}
+	}
+	if len(p.Allow) > 0 && !pathmatch.MatchAny(p.Allow, relPath) {
+		return fmt.Errorf("%s is outside the write allowlist", relPath)
+	}
+	return nil
+}
+
+// CheckWritable returns an error if the file at absPath must not be written given its kind
+// and permissions. A path that doesn't exist yet is always writable.
+func (p WritePolicy) CheckWritable(absPath string) error {
+	info, err := os.Lstat(absPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", absPath, err)
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		if !p.AllowSymlinks {
+			return fmt.Errorf("refusing to write through symlink %s", absPath)
+		}
+		if info, err = os.Stat(absPath); err != nil {
+			return fmt.Errorf("failed to inspect symlink target of %s: %w", absPath, err)
+		}
+	}
+
+	switch mode := info.Mode(); {
+	case mode.IsRegular():
+		if mode.Perm()&0200 == 0 {
+			return fmt.Errorf("%s is read-only; make it writable first if it should be changed", absPath)
+		}
+		return nil
+	case mode.IsDir():
+		return fmt.Errorf("%s is a directory", absPath)
+	case !p.AllowSpecialFiles:
+		return fmt.Errorf("refusing to write to special file %s (%s)", absPath, describeMode(mode))
+	}
+	return nil
+}
+
+// describeMode names the kind of special file for error messages
+func describeMode(mode os.FileMode) string {
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeCharDevice != 0:
+		return "character device"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	}
+	return "irregular file"
+}
+
+// newFileMode returns the permissions for a file created by LlamaSidekick. Shell scripts
+// (by extension or a #! line) are made executable.
+func newFileMode(relPath string, content []byte) os.FileMode {
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ".sh", ".bash", ".zsh":
+		return 0755
+	}
+	if bytes.HasPrefix(content, []byte("#!")) {
+		return 0755
+	}
+	return 0644
+}
@@ -0,0 +1,65 @@
+package safeio
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+)
+
+// ErrChangedOnDisk is returned by Commit when a file no longer has the content it was
+// read with
+var ErrChangedOnDisk = errors.New("changed on disk")
+
+// ReadLog remembers the hash of each file's content as it was read into a prompt, so a
+// write generated from that content can tell whether the file changed in the meantime
+type ReadLog struct {
+	mu     sync.Mutex
+	hashes map[string]string
+}
+
+// NewReadLog returns an empty read log
+func NewReadLog() *ReadLog {
+	return &ReadLog{hashes: map[string]string{}}
+}
+
+// Record notes that path was read with content
+func (l *ReadLog) Record(path string, content []byte) {
+	if l == nil {
+		return
+	}
+	key := logKey(path)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hashes[key] = Hash(content)
+}
+
+// Forget drops what is known about path
+func (l *ReadLog) Forget(path string) {
+	if l == nil {
+		return
+	}
+	key := logKey(path)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.hashes, key)
+}
+
+// lookup returns the hash path was last read with
+func (l *ReadLog) lookup(path string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	key := logKey(path)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	hash, ok := l.hashes[key]
+	return hash, ok
+}
+
+// logKey is the absolute form of path, so relative and absolute reads meet
+func logKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
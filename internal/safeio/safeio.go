@@ -1,6 +1,8 @@
 package safeio
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,7 +10,8 @@ import (
 )
 
 // ResolveWithinRoot resolves a user-provided relative path into an absolute path within root.
-// It rejects absolute paths and any path that escapes the root via .. segments.
+// It rejects absolute paths, any path that escapes the root via .. segments, and paths
+// that leave the root through a symlink.
 func ResolveWithinRoot(root string, userPath string) (absPath string, relPath string, err error) {
 	if root == "" {
 		return "", "", fmt.Errorf("project root is empty")
@@ -48,34 +51,51 @@ func ResolveWithinRoot(root string, userPath string) (absPath string, relPath st
 		return "", "", fmt.Errorf("resolved path is outside project root")
 	}
 
+	if err := checkRealPathWithinRoot(rootAbs, joinedAbs); err != nil {
+		return "", "", fmt.Errorf("%s: %w", userPath, err)
+	}
+
 	return joinedAbs, clean, nil
 }
 
-// WriteFileWithBackup writes content to absPath. If the file exists, it first writes a backup
-// to absPath+".backup".
-func WriteFileWithBackup(absPath string, content []byte) (backupPath string, err error) {
-	if absPath == "" {
-		return "", fmt.Errorf("absPath is empty")
+// checkRealPathWithinRoot evaluates symlinks in the existing part of path and verifies the
+// real location is still inside the (also evaluated) root. Components that don't exist yet
+// can't be symlinks, so they are appended unchanged.
+func checkRealPathWithinRoot(rootAbs, path string) error {
+	realRoot, err := filepath.EvalSymlinks(rootAbs)
+	if err != nil {
+		return fmt.Errorf("failed to resolve project root: %w", err)
 	}
 
-	if info, statErr := os.Stat(absPath); statErr == nil && !info.IsDir() {
-		backupPath = absPath + ".backup"
-		existing, err := os.ReadFile(absPath)
-		if err != nil {
-			return "", fmt.Errorf("failed to read existing file for backup: %w", err)
+	existing := path
+	var rest []string
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
 		}
-		if err := os.WriteFile(backupPath, existing, 0644); err != nil {
-			return "", fmt.Errorf("failed to write backup: %w", err)
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return nil
 		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
 	}
 
-	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
-		return backupPath, fmt.Errorf("failed to create directories: %w", err)
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return fmt.Errorf("cannot resolve symlink: %w", err)
 	}
+	real = filepath.Join(append([]string{real}, rest...)...)
 
-	if err := os.WriteFile(absPath, content, 0644); err != nil {
-		return backupPath, fmt.Errorf("failed to write file: %w", err)
+	rel, err := filepath.Rel(realRoot, real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return fmt.Errorf("symlink points outside project root")
 	}
+	return nil
+}
 
-	return backupPath, nil
+// Hash returns the SHA-256 of content, used to detect files changed on disk
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
 }
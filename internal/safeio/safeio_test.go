@@ -43,3 +43,92 @@ func TestResolveWithinRoot_RejectsEscape(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+func TestResolveWithinRoot_RejectsSymlinkEscape(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if _, _, err := ResolveWithinRoot(root, "link/secrets.txt"); err == nil {
+		t.Fatalf("expected error for path through symlink leaving the root")
+	}
+
+	// Symlinks that stay inside the root are fine
+	if err := os.Mkdir(filepath.Join(root, "real"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "real"), filepath.Join(root, "inner")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ResolveWithinRoot(root, "inner/new/file.txt"); err != nil {
+		t.Fatalf("expected symlink inside root to be allowed, got %v", err)
+	}
+}
+
+func TestWritePolicy_RefusesSymlinksUnlessAllowed(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "target.txt")
+	if err := os.WriteFile(target, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(root, "link.txt")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if err := (WritePolicy{}).CheckWritable(link); err == nil {
+		t.Fatalf("expected symlink to be refused by default")
+	}
+	if err := (WritePolicy{AllowSymlinks: true}).CheckWritable(link); err != nil {
+		t.Fatalf("expected symlink to be allowed, got %v", err)
+	}
+	if err := (WritePolicy{}).CheckWritable(target); err != nil {
+		t.Fatalf("expected regular file to be writable, got %v", err)
+	}
+	if err := (WritePolicy{}).CheckWritable(filepath.Join(root, "missing.txt")); err != nil {
+		t.Fatalf("expected new file to be writable, got %v", err)
+	}
+
+	store := NewBackupStore(t.TempDir(), 2)
+	if _, err := store.WriteFile(root, "link.txt", []byte("y")); err == nil {
+		t.Fatalf("expected backup store to refuse writing through symlink")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "x" {
+		t.Fatalf("symlink target was modified: %q", data)
+	}
+}
+
+func TestWritePolicy_AllowAndDenyPatterns(t *testing.T) {
+	policy := WritePolicy{Allow: []string{"internal/**", "README.md"}, Deny: []string{".git", "*.env", "internal/deploy/**"}}
+	for path, allowed := range map[string]bool{
+		"internal/app/main.go":     true,
+		"README.md":                true,
+		"main.go":                  false,
+		"internal/.env":            false,
+		"internal/deploy/prod.yml": false,
+		".git/config":              false,
+	} {
+		if err := policy.CheckPath(path); (err == nil) != allowed {
+			t.Errorf("CheckPath(%q) = %v, want allowed=%v", path, err, allowed)
+		}
+	}
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "prod.env"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewBackupStore(t.TempDir(), 2)
+	store.Policy = WritePolicy{Deny: []string{"*.env"}}
+	tx := store.Begin(root)
+	if err := tx.Stage("prod.env", []byte("y")); err == nil {
+		t.Fatalf("expected a denied path to be refused when staged")
+	}
+	if err := tx.StageRename("prod.env", "prod.txt"); err == nil {
+		t.Fatalf("expected a denied path to be refused when renamed")
+	}
+	if _, err := store.RemoveFile(root, "prod.env"); err == nil {
+		t.Fatalf("expected a denied path to be refused when removed")
+	}
+}
@@ -0,0 +1,289 @@
+package safeio
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/diff"
+)
+
+// Change is a staged file write or removal. A rename is staged as a write of the new path
+// with RenamedFrom set, followed by a removal of the old path with RenamedTo set.
+type Change struct {
+	RelPath     string
+	AbsPath     string
+	Original    []byte // Content before the write (nil for new files; the old file's for a rename)
+	Content     []byte
+	Existed     bool
+	Removed     bool   // The file is deleted rather than written
+	RenamedFrom string // The path this file is moved from
+	RenamedTo   string // The path a removed file was moved to
+}
+
+// Action describes the change: "created", "modified", "deleted" or "renamed"
+func (c Change) Action() string {
+	switch {
+	case c.RenamedFrom != "" || c.RenamedTo != "":
+		return "renamed"
+	case c.Removed:
+		return "deleted"
+	case c.Existed:
+		return "modified"
+	}
+	return "created"
+}
+
+// Diff returns the unified diff for the change. A rename is shown with the write of the
+// new path, so its removal half has no diff of its own.
+func (c Change) Diff() string {
+	switch {
+	case c.RenamedTo != "":
+		return ""
+	case c.RenamedFrom != "":
+		header := fmt.Sprintf("diff --git a/%s b/%s\nrename from %s\nrename to %s\n", c.RenamedFrom, c.RelPath, c.RenamedFrom, c.RelPath)
+		return header + diff.Unified(c.RenamedFrom, c.RelPath, string(c.Original), string(c.Content), 3)
+	case c.Removed:
+		return diff.Unified(c.RelPath, "", string(c.Original), "", 3)
+	}
+	oldName := c.RelPath
+	if !c.Existed {
+		oldName = ""
+	}
+	return diff.Unified(oldName, c.RelPath, string(c.Original), string(c.Content), 3)
+}
+
+// Transaction stages several file writes so they can be reviewed as one diff and then
+// applied all-or-nothing
+type Transaction struct {
+	root    string
+	store   *BackupStore
+	changes []Change
+}
+
+// Begin starts a transaction for files inside root
+func (b *BackupStore) Begin(root string) *Transaction {
+	return &Transaction{root: root, store: b}
+}
+
+// Stage records a write of content to relPath. Staging the same path twice keeps the last content.
+func (t *Transaction) Stage(relPath string, content []byte) error {
+	absPath, relPath, err := ResolveWithinRoot(t.root, relPath)
+	if err != nil {
+		return err
+	}
+
+	if err := t.store.Policy.Check(absPath, relPath); err != nil {
+		return err
+	}
+
+	change := Change{RelPath: relPath, AbsPath: absPath, Content: content}
+	if info, err := os.Stat(absPath); err == nil {
+		change.Existed = true
+		if info.Mode().IsRegular() {
+			original, err := os.ReadFile(absPath)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", relPath, err)
+			}
+			change.Original = original
+		}
+	}
+
+	if i := t.index(relPath); i >= 0 {
+		c := t.changes[i]
+		change.Original, change.Existed, change.RenamedFrom = c.Original, c.Existed, c.RenamedFrom
+		t.changes[i] = change
+		return nil
+	}
+	t.changes = append(t.changes, change)
+	return nil
+}
+
+// StageRemove records the deletion of relPath, which must be an existing regular file
+func (t *Transaction) StageRemove(relPath string) error {
+	change, err := t.removal(relPath)
+	if err != nil {
+		return err
+	}
+	if i := t.index(change.RelPath); i >= 0 {
+		t.changes = append(t.changes[:i], t.changes[i+1:]...)
+	}
+	t.changes = append(t.changes, change)
+	return nil
+}
+
+// StageRename records a move of from to to, which must not exist yet. The moved file
+// gets the content already staged for from, if any, or else its current content.
+func (t *Transaction) StageRename(from, to string) error {
+	removal, err := t.removal(from)
+	if err != nil {
+		return err
+	}
+	toAbs, toRel, err := ResolveWithinRoot(t.root, to)
+	if err != nil {
+		return err
+	}
+	if toRel == removal.RelPath {
+		return fmt.Errorf("%s is renamed to itself", toRel)
+	}
+	if err := t.store.Policy.Check(toAbs, toRel); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(toAbs); err == nil || t.index(toRel) >= 0 {
+		return fmt.Errorf("can't rename %s to %s: %s already exists", removal.RelPath, toRel, toRel)
+	}
+
+	content := removal.Original
+	if i := t.index(removal.RelPath); i >= 0 {
+		content = t.changes[i].Content
+		t.changes = append(t.changes[:i], t.changes[i+1:]...)
+	}
+	removal.RenamedTo = toRel
+	moved := Change{RelPath: toRel, AbsPath: toAbs, Original: removal.Original, Content: content, RenamedFrom: removal.RelPath}
+	t.changes = append(t.changes, moved, removal)
+	return nil
+}
+
+// removal returns the change that deletes relPath
+func (t *Transaction) removal(relPath string) (Change, error) {
+	absPath, relPath, err := ResolveWithinRoot(t.root, relPath)
+	if err != nil {
+		return Change{}, err
+	}
+	if err := t.store.Policy.Check(absPath, relPath); err != nil {
+		return Change{}, err
+	}
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to find %s: %w", relPath, err)
+	}
+	if !info.Mode().IsRegular() {
+		return Change{}, fmt.Errorf("%s is not a regular file", relPath)
+	}
+	original, err := os.ReadFile(absPath)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to read %s: %w", relPath, err)
+	}
+	return Change{RelPath: relPath, AbsPath: absPath, Original: original, Existed: true, Removed: true}, nil
+}
+
+// index returns the position of the staged change of relPath, or -1
+func (t *Transaction) index(relPath string) int {
+	for i, c := range t.changes {
+		if c.RelPath == relPath {
+			return i
+		}
+	}
+	return -1
+}
+
+// Changes returns the staged writes in staging order
+func (t *Transaction) Changes() []Change {
+	return t.changes
+}
+
+// Diff returns the combined unified diff of all staged writes
+func (t *Transaction) Diff() string {
+	var b strings.Builder
+	for _, c := range t.changes {
+		b.WriteString(c.Diff())
+	}
+	return b.String()
+}
+
+// Commit writes every staged change, backing up existing and removed files first. Nothing
+// is written if a file changed on disk since it was read into the prompt (or, for files
+// that weren't, since it was staged). If any write fails, files already written are
+// restored to their original content (new files are removed) and the error is returned.
+func (t *Transaction) Commit() error {
+	if err := t.checkUnchanged(); err != nil {
+		return err
+	}
+	var applied []Change
+	for _, c := range t.changes {
+		var err error
+		if c.Removed {
+			_, err = t.store.RemoveFile(t.root, c.RelPath)
+		} else {
+			_, err = t.store.WriteFile(t.root, c.RelPath, c.Content)
+		}
+		if err != nil {
+			slog.Warn("rolling back transaction", "failed", c.RelPath, "applied", len(applied), "error", err)
+			if rbErr := rollback(applied); rbErr != nil {
+				slog.Error("rollback failed", "error", rbErr)
+				return fmt.Errorf("failed to write %s: %w (rollback also failed: %v)", c.RelPath, err, rbErr)
+			}
+			return fmt.Errorf("failed to write %s, all changes were rolled back: %w", c.RelPath, err)
+		}
+		applied = append(applied, c)
+	}
+	// Later writes build on what was just written
+	for _, c := range t.changes {
+		if c.Removed {
+			t.store.Reads.Forget(c.AbsPath)
+		} else {
+			t.store.Reads.Record(c.AbsPath, c.Content)
+		}
+	}
+	return nil
+}
+
+// checkUnchanged returns an error wrapping ErrChangedOnDisk for the first staged file
+// whose content on disk isn't the one the change was made from
+func (t *Transaction) checkUnchanged() error {
+	for _, c := range t.changes {
+		info, err := os.Stat(c.AbsPath)
+		switch {
+		case !c.Existed:
+			if err == nil {
+				return fmt.Errorf("%s %w: it was created while the change was made, so nothing was written", c.RelPath, ErrChangedOnDisk)
+			}
+			continue
+		case os.IsNotExist(err):
+			return fmt.Errorf("%s %w: it was deleted while the change was made, so nothing was written", c.RelPath, ErrChangedOnDisk)
+		case err != nil || !info.Mode().IsRegular():
+			// Special files have no content to compare; the write policy decides about them
+			continue
+		}
+		current, err := os.ReadFile(c.AbsPath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", c.RelPath, err)
+		}
+		want, ok := t.store.Reads.lookup(c.AbsPath)
+		if !ok {
+			want = Hash(c.Original)
+		}
+		if Hash(current) != want {
+			// The next request works from the new content
+			t.store.Reads.Forget(c.AbsPath)
+			return fmt.Errorf("%s %w since it was read, so nothing was written (repeat the request to work from the new content)", c.RelPath, ErrChangedOnDisk)
+		}
+	}
+	return nil
+}
+
+// rollback undoes applied changes in reverse order
+func rollback(applied []Change) error {
+	var failed []string
+	for i := len(applied) - 1; i >= 0; i-- {
+		c := applied[i]
+		var err error
+		if info, statErr := os.Stat(c.AbsPath); statErr == nil && !info.Mode().IsRegular() {
+			// Special files have no content to put back
+			continue
+		}
+		if c.Existed {
+			err = os.WriteFile(c.AbsPath, c.Original, 0644)
+		} else {
+			err = os.Remove(c.AbsPath)
+		}
+		if err != nil {
+			failed = append(failed, c.RelPath)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("could not restore %s (originals are in the backup store)", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package safeio
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransaction_CommitWritesAllFiles(t *testing.T) {
+	root := t.TempDir()
+	store := NewBackupStore(t.TempDir(), 5)
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := store.Begin(root)
+	if err := tx.Stage("a.txt", []byte("new\n")); err != nil {
+		t.Fatalf("stage a.txt: %v", err)
+	}
+	if err := tx.Stage("sub/b.txt", []byte("hello\n")); err != nil {
+		t.Fatalf("stage b.txt: %v", err)
+	}
+	if err := tx.Stage("../escape.txt", []byte("x")); err == nil {
+		t.Fatalf("expected staging outside the root to fail")
+	}
+
+	d := tx.Diff()
+	for _, want := range []string{"--- a/a.txt", "-old", "+new", "--- /dev/null", "+++ b/sub/b.txt", "+hello"} {
+		if !strings.Contains(d, want) {
+			t.Fatalf("diff missing %q:\n%s", want, d)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(data) != "new\n" {
+		t.Fatalf("a.txt not written, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "sub", "b.txt")); string(data) != "hello\n" {
+		t.Fatalf("sub/b.txt not written, got %q", data)
+	}
+	if backups, _ := store.List(root, "a.txt"); len(backups) != 1 {
+		t.Fatalf("expected a.txt to be backed up, got %d backups", len(backups))
+	}
+}
+
+func TestTransaction_RollsBackOnFailure(t *testing.T) {
+	root := t.TempDir()
+	store := NewBackupStore(t.TempDir(), 5)
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := store.Begin(root)
+	for _, rel := range []string{"a.txt", "new.txt", "blocker/c.txt"} {
+		if err := tx.Stage(rel, []byte("changed")); err != nil {
+			t.Fatalf("stage %s: %v", rel, err)
+		}
+	}
+
+	// A regular file where a directory is needed makes the last write fail
+	if err := os.WriteFile(filepath.Join(root, "blocker"), []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Commit(); err == nil {
+		t.Fatalf("expected commit to fail")
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(data) != "old" {
+		t.Fatalf("a.txt not rolled back, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new.txt")); !os.IsNotExist(err) {
+		t.Fatalf("new.txt should have been removed on rollback")
+	}
+}
+
+func TestTransaction_RenamesAndRemovesFiles(t *testing.T) {
+	root := t.TempDir()
+	store := NewBackupStore(t.TempDir(), 5)
+	for name, content := range map[string]string{"a.go": "package a\n", "old.go": "package old\n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tx := store.Begin(root)
+	if err := tx.Stage("a.go", []byte("package b\n")); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	if err := tx.StageRename("a.go", "pkg/b.go"); err != nil {
+		t.Fatalf("stage rename: %v", err)
+	}
+	if err := tx.StageRemove("old.go"); err != nil {
+		t.Fatalf("stage remove: %v", err)
+	}
+	if err := tx.StageRename("missing.go", "x.go"); err == nil {
+		t.Fatal("expected renaming a missing file to fail")
+	}
+	if err := tx.StageRename("pkg/b.go", "old.go"); err == nil {
+		t.Fatal("expected renaming onto a staged file to fail")
+	}
+
+	var actions []string
+	for _, c := range tx.Changes() {
+		actions = append(actions, c.Action()+" "+c.RelPath)
+	}
+	if got := strings.Join(actions, ", "); got != "renamed pkg/b.go, renamed a.go, deleted old.go" {
+		t.Fatalf("unexpected changes: %s", got)
+	}
+	if d := tx.Diff(); !strings.Contains(d, "rename from a.go\nrename to pkg/b.go\n") || !strings.Contains(d, "-package old") {
+		t.Fatalf("unexpected diff:\n%s", d)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "pkg", "b.go")); string(data) != "package b\n" {
+		t.Fatalf("pkg/b.go not written, got %q", data)
+	}
+	for _, gone := range []string{"a.go", "old.go"} {
+		if _, err := os.Stat(filepath.Join(root, gone)); !os.IsNotExist(err) {
+			t.Fatalf("%s should have been removed", gone)
+		}
+	}
+	if _, err := store.Restore(root, "old.go", 1); err != nil {
+		t.Fatalf("restore removed file: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "old.go")); string(data) != "package old\n" {
+		t.Fatalf("old.go not restored, got %q", data)
+	}
+}
+
+func TestTransaction_RefusesFilesChangedSinceTheyWereRead(t *testing.T) {
+	root := t.TempDir()
+	store := NewBackupStore(t.TempDir(), 5)
+	store.Reads = NewReadLog()
+	path := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(path, []byte("read\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store.Reads.Record(path, []byte("read\n"))
+
+	// An editor saves the file while the model is working
+	if err := os.WriteFile(path, []byte("saved in the editor\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tx := store.Begin(root)
+	if err := tx.Stage("a.txt", []byte("from the model\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Stage("b.txt", []byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrChangedOnDisk) {
+		t.Fatalf("expected ErrChangedOnDisk, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "saved in the editor\n" {
+		t.Fatalf("expected the editor's content to be kept, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "b.txt")); err == nil {
+		t.Fatal("expected no file to be written")
+	}
+
+	// A change made from the current content goes through, and is what later writes build on
+	store.Reads.Record(path, []byte("saved in the editor\n"))
+	tx = store.Begin(root)
+	if err := tx.Stage("a.txt", []byte("from the model\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	tx = store.Begin(root)
+	if err := tx.Stage("a.txt", []byte("again\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("expected a write after our own write to pass, got %v", err)
+	}
+}
+
+func TestTransaction_RefusesFilesChangedWhileStaged(t *testing.T) {
+	root := t.TempDir()
+	store := NewBackupStore(t.TempDir(), 5)
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tx := store.Begin(root)
+	if err := tx.Stage("a.txt", []byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Stage("b.txt", []byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "b.txt"), []byte("created meanwhile\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrChangedOnDisk) {
+		t.Fatalf("expected ErrChangedOnDisk for a file created after staging, got %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(data) != "old\n" {
+		t.Fatalf("expected a.txt untouched, got %q", data)
+	}
+}
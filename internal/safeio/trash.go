@@ -0,0 +1,191 @@
+package safeio
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/filelock"
+)
+
+// Trash keeps every replaced file content, across all projects, until it is emptied.
+// Entries are recorded in an index so they can be listed and restored by ID.
+type Trash struct {
+	Dir string
+}
+
+// TrashEntry is one trashed file content
+type TrashEntry struct {
+	ID      int       `json:"id"`
+	Root    string    `json:"root"`
+	RelPath string    `json:"rel_path"`
+	File    string    `json:"file"` // Name of the content file inside the trash dir
+	Time    time.Time `json:"time"`
+	Size    int64     `json:"size"`
+}
+
+type trashIndex struct {
+	NextID  int          `json:"next_id"`
+	Entries []TrashEntry `json:"entries"`
+}
+
+// NewTrash creates a trash rooted at dir
+func NewTrash(dir string) *Trash {
+	return &Trash{Dir: dir}
+}
+
+func (t *Trash) indexPath() string {
+	return filepath.Join(t.Dir, "index.json")
+}
+
+// lock serializes index updates between instances
+func (t *Trash) lock() (*filelock.Lock, error) {
+	return filelock.Acquire(t.indexPath()+".lock", filelock.DefaultTimeout)
+}
+
+func (t *Trash) load() (*trashIndex, error) {
+	index := &trashIndex{NextID: 1}
+	data, err := os.ReadFile(t.indexPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, fmt.Errorf("failed to read trash index: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse trash index: %w", err)
+	}
+	return index, nil
+}
+
+func (t *Trash) save(index *trashIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trash index: %w", err)
+	}
+	if err := os.WriteFile(t.indexPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write trash index: %w", err)
+	}
+	return nil
+}
+
+// Put stores content that is about to be replaced at relPath in root
+func (t *Trash) Put(root, relPath string, content []byte) (TrashEntry, error) {
+	rootAbs, err := filepath.Abs(root)
+	if err != nil {
+		return TrashEntry{}, fmt.Errorf("failed to resolve project root: %w", err)
+	}
+	if err := os.MkdirAll(t.Dir, 0755); err != nil {
+		return TrashEntry{}, fmt.Errorf("failed to create trash dir: %w", err)
+	}
+	lock, err := t.lock()
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	defer lock.Release()
+	index, err := t.load()
+	if err != nil {
+		return TrashEntry{}, err
+	}
+
+	entry := TrashEntry{
+		ID:      index.NextID,
+		Root:    rootAbs,
+		RelPath: filepath.Clean(relPath),
+		File:    fmt.Sprintf("%d-%s", index.NextID, filepath.Base(relPath)),
+		Time:    time.Now(),
+		Size:    int64(len(content)),
+	}
+	if err := os.WriteFile(filepath.Join(t.Dir, entry.File), content, 0644); err != nil {
+		return TrashEntry{}, fmt.Errorf("failed to write trash file: %w", err)
+	}
+
+	index.NextID++
+	index.Entries = append(index.Entries, entry)
+	if err := t.save(index); err != nil {
+		return TrashEntry{}, err
+	}
+	return entry, nil
+}
+
+// List returns the entries trashed from root, most recent first
+func (t *Trash) List(root string) ([]TrashEntry, error) {
+	rootAbs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project root: %w", err)
+	}
+	index, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []TrashEntry
+	for _, e := range index.Entries {
+		if e.Root == rootAbs {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID > entries[j].ID })
+	return entries, nil
+}
+
+// Restore writes the content of entry id back to its original path through store, so the
+// content being replaced is backed up (and trashed) as usual
+func (t *Trash) Restore(root string, id int, store *BackupStore) (TrashEntry, error) {
+	entries, err := t.List(root)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	for _, e := range entries {
+		if e.ID != id {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(t.Dir, e.File))
+		if err != nil {
+			return TrashEntry{}, fmt.Errorf("failed to read trash file: %w", err)
+		}
+		if _, err := store.WriteFile(root, e.RelPath, content); err != nil {
+			return TrashEntry{}, err
+		}
+		return e, nil
+	}
+	return TrashEntry{}, fmt.Errorf("no trash entry %d for this project", id)
+}
+
+// Empty permanently deletes the entries trashed from root and returns how many were removed
+func (t *Trash) Empty(root string) (int, error) {
+	rootAbs, err := filepath.Abs(root)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve project root: %w", err)
+	}
+	lock, err := t.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Release()
+	index, err := t.load()
+	if err != nil {
+		return 0, err
+	}
+
+	kept := index.Entries[:0]
+	removed := 0
+	for _, e := range index.Entries {
+		if e.Root != rootAbs {
+			kept = append(kept, e)
+			continue
+		}
+		if err := os.Remove(filepath.Join(t.Dir, e.File)); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove trash file: %w", err)
+		}
+		removed++
+	}
+	index.Entries = kept
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, t.save(index)
+}
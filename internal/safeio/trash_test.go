@@ -0,0 +1,53 @@
+package safeio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrash_KeepsEveryVersionAndRestores(t *testing.T) {
+	root := t.TempDir()
+	store := NewBackupStore(t.TempDir(), 1)
+	store.Trash = NewTrash(t.TempDir())
+
+	for _, content := range []string{"v1", "v2", "v3"} {
+		if _, err := store.WriteFile(root, "file.txt", []byte(content)); err != nil {
+			t.Fatalf("write %s: %v", content, err)
+		}
+	}
+
+	entries, err := store.Trash.List(root)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 trashed versions despite keep=1, got %d", len(entries))
+	}
+	if entries[0].RelPath != "file.txt" || entries[0].Size != 2 {
+		t.Fatalf("unexpected newest entry: %+v", entries[0])
+	}
+
+	oldest := entries[len(entries)-1]
+	if _, err := store.Trash.Restore(root, oldest.ID, store); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "file.txt")); string(data) != "v1" {
+		t.Fatalf("expected v1 restored, got %q", data)
+	}
+
+	if other, _ := store.Trash.List(t.TempDir()); len(other) != 0 {
+		t.Fatalf("expected entries to be scoped to their project, got %d", len(other))
+	}
+
+	removed, err := store.Trash.Empty(root)
+	if err != nil {
+		t.Fatalf("empty: %v", err)
+	}
+	if removed != 3 {
+		t.Fatalf("expected 3 entries removed, got %d", removed)
+	}
+	if entries, _ := store.Trash.List(root); len(entries) != 0 {
+		t.Fatalf("expected empty trash, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,237 @@
+// Package secrets finds likely credentials in text so they aren't committed or sent to a model.
+package secrets
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Finding is a likely secret
+type Finding struct {
+	File  string // Set by ScanDiff
+	Line  int    // 1-based line in the scanned text, or in the new file for ScanDiff
+	Kind  string
+	Match string // Redacted, safe to print
+}
+
+type pattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var patterns = []pattern{
+	{"private key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"OpenAI/Anthropic key", regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Stripe key", regexp.MustCompile(`\b[sr]k_live_[0-9a-zA-Z]{20,}\b`)},
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`)},
+	{"password or secret assignment", regexp.MustCompile(`(?i)\b(?:password|passwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token|client[_-]?secret)\b["']?\s*[:=]\s*["']([^"'\s]{8,})["']`)},
+	{"environment variable secret", regexp.MustCompile(`^\s*(?:export\s+)?[A-Z0-9_]*(?:PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|ACCESS_KEY|PRIVATE_KEY)[A-Z0-9_]*\s*=\s*([^\s"'#]{8,})`)},
+}
+
+// placeholders are values in assignments that are clearly not real secrets
+var placeholders = []string{"example", "changeme", "your_", "your-", "xxxx", "<", "${", "{{", "env:", "keyring:", "redacted", "placeholder", "dummy", "test"}
+
+// Scan returns the likely secrets in text
+func Scan(text string) []Finding {
+	var findings []Finding
+	for i, line := range strings.Split(text, "\n") {
+		findings = append(findings, scanLine(line, i+1)...)
+	}
+	return findings
+}
+
+func scanLine(line string, lineNo int) []Finding {
+	var findings []Finding
+	for _, m := range lineMatches(line) {
+		findings = append(findings, Finding{Line: lineNo, Kind: m.kind, Match: Redact(line[m.start:m.end])})
+	}
+	return findings
+}
+
+// match is where a secret is in a line
+type match struct {
+	kind       string
+	start, end int
+}
+
+// lineMatches returns the secrets in line in the order they appear. A value already matched by an earlier, more
+// specific pattern isn't reported again.
+func lineMatches(line string) []match {
+	var matches []match
+	for _, p := range patterns {
+		loc := p.re.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+		start, end := loc[0], loc[1]
+		if len(loc) > 3 && loc[2] >= 0 && loc[3] > loc[2] {
+			start, end = loc[2], loc[3]
+			if isPlaceholder(line[start:end]) {
+				continue
+			}
+		}
+		overlaps := false
+		for _, m := range matches {
+			overlaps = overlaps || (start < m.end && m.start < end)
+		}
+		if !overlaps {
+			matches = append(matches, match{kind: p.kind, start: start, end: end})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	return matches
+}
+
+// RedactText replaces the likely secrets in text with [REDACTED <kind>] and returns the
+// findings. The lines of a private key are blanked up to its END line, so the lines after
+// it keep their numbers.
+func RedactText(text string) (string, []Finding) {
+	redacted, findings, _ := redact(text)
+	return redacted, findings
+}
+
+// redaction is one secret replaced by RedactText
+type redaction struct {
+	kind     string
+	original string
+	blanks   int // Blank lines left in place of a private key's remaining lines
+}
+
+func redact(text string) (string, []Finding, []redaction) {
+	lines := strings.Split(text, "\n")
+	var findings []Finding
+	var redactions []redaction
+	key := -1 // Index in redactions of the private key being blanked
+	for i, line := range lines {
+		if key >= 0 {
+			redactions[key].original += "\n" + line
+			redactions[key].blanks++
+			if strings.Contains(line, "-----END ") {
+				key = -1
+			}
+			lines[i] = ""
+			continue
+		}
+		var b strings.Builder
+		pos := 0
+		for _, m := range lineMatches(line) {
+			findings = append(findings, Finding{Line: i + 1, Kind: m.kind, Match: Redact(line[m.start:m.end])})
+			b.WriteString(line[pos:m.start])
+			if m.kind == "private key" && !strings.Contains(line[m.end:], "-----END ") {
+				redactions = append(redactions, redaction{kind: m.kind, original: line[m.start:]})
+				key = len(redactions) - 1
+				b.WriteString("[REDACTED private key]")
+				pos = len(line)
+				break
+			}
+			redactions = append(redactions, redaction{kind: m.kind, original: line[m.start:m.end]})
+			b.WriteString("[REDACTED " + m.kind + "]")
+			pos = m.end
+		}
+		b.WriteString(line[pos:])
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n"), findings, redactions
+}
+
+var redactedMarker = regexp.MustCompile(`\[REDACTED ([a-zA-Z/ ]+)\]`)
+
+// Unredact puts the secrets RedactText took out of original back into text, a version of
+// the redacted original edited by the model. Each [REDACTED <kind>] marker gets the next
+// secret of that kind, so markers the model kept in place get their secrets back.
+func Unredact(original, text string) string {
+	_, _, redactions := redact(original)
+	if len(redactions) == 0 || !strings.Contains(text, "[REDACTED ") {
+		return text
+	}
+	byKind := map[string][]redaction{}
+	for _, r := range redactions {
+		byKind[r.kind] = append(byKind[r.kind], r)
+	}
+
+	var b strings.Builder
+	rest := text
+	for {
+		loc := redactedMarker.FindStringSubmatchIndex(rest)
+		if loc == nil {
+			break
+		}
+		kind := rest[loc[2]:loc[3]]
+		queue := byKind[kind]
+		if len(queue) == 0 {
+			b.WriteString(rest[:loc[1]])
+			rest = rest[loc[1]:]
+			continue
+		}
+		r := queue[0]
+		byKind[kind] = queue[1:]
+		b.WriteString(rest[:loc[0]])
+		b.WriteString(r.original)
+		rest = rest[loc[1]:]
+		// Drop the blank lines the key's other lines were replaced with
+		if blanks := strings.Repeat("\n", r.blanks); r.blanks > 0 && strings.HasPrefix(rest, blanks) {
+			rest = rest[len(blanks):]
+		}
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+func isPlaceholder(value string) bool {
+	lower := strings.ToLower(value)
+	for _, p := range placeholders {
+		if strings.Contains(lower, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact keeps the first few characters of a secret so it can be recognised without being revealed
+func Redact(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", 8)
+}
+
+// ScanDiff scans only the lines a unified diff adds, reporting file names and new line numbers
+func ScanDiff(diff string) []Finding {
+	var findings []Finding
+	file := ""
+	newLine := 0
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "@@"):
+			newLine = hunkStart(line)
+		case strings.HasPrefix(line, "+"):
+			for _, f := range scanLine(line[1:], newLine) {
+				f.File = file
+				findings = append(findings, f)
+			}
+			newLine++
+		case strings.HasPrefix(line, " "):
+			newLine++
+		}
+	}
+	return findings
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)`)
+
+func hunkStart(header string) int {
+	m := hunkHeader.FindStringSubmatch(header)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
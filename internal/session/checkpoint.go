@@ -0,0 +1,142 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/filelock"
+)
+
+// maxCheckpoints bounds how many checkpoints a session keeps; the oldest are dropped
+const maxCheckpoints = 10
+
+// Checkpoint is the state of the conversation and of the files a risky operation, such
+// as an Agent run or a multi-file edit, was about to change
+type Checkpoint struct {
+	ID        int              `json:"id"`
+	Label     string           `json:"label"` // What the operation was, e.g. "Agent: add a README"
+	CreatedAt time.Time        `json:"created_at"`
+	History   []Message        `json:"history"` // The conversation before the request that made the changes
+	Files     []CheckpointFile `json:"files"`
+}
+
+// CheckpointFile is a file as it was when the checkpoint was taken
+type CheckpointFile struct {
+	Path    string `json:"path"` // Relative to the project root
+	Existed bool   `json:"existed"`
+	Content []byte `json:"content,omitempty"`
+}
+
+// checkpointsPath returns the file the session's checkpoints are kept in, next to the session
+func (s *Session) checkpointsPath() (string, error) {
+	sessionFile, err := sessionPath(s.ProjectRoot, s.Name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sessionFile, ".json") + ".checkpoints.json", nil
+}
+
+// Checkpoints returns the session's checkpoints, oldest first
+func (s *Session) Checkpoints() ([]Checkpoint, error) {
+	path, err := s.checkpointsPath()
+	if err != nil {
+		return nil, err
+	}
+	return readCheckpoints(path)
+}
+
+func readCheckpoints(path string) ([]Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+	var checkpoints []Checkpoint
+	if err := json.Unmarshal(data, &checkpoints); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
+
+// SaveCheckpoint adds a checkpoint of files, with the conversation as it was before its
+// latest question, and returns it. Private messages are left out, as they are never saved.
+func (s *Session) SaveCheckpoint(label string, files []CheckpointFile) (Checkpoint, error) {
+	history := s.History
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == "user" {
+			history = history[:i]
+			break
+		}
+	}
+	cp := Checkpoint{Label: label, CreatedAt: time.Now(), Files: files}
+	for _, msg := range history {
+		if !msg.Private {
+			cp.History = append(cp.History, msg)
+		}
+	}
+
+	err := s.updateCheckpoints(func(checkpoints []Checkpoint) []Checkpoint {
+		cp.ID = 1
+		if n := len(checkpoints); n > 0 {
+			cp.ID = checkpoints[n-1].ID + 1
+		}
+		checkpoints = append(checkpoints, cp)
+		if len(checkpoints) > maxCheckpoints {
+			checkpoints = checkpoints[len(checkpoints)-maxCheckpoints:]
+		}
+		return checkpoints
+	})
+	return cp, err
+}
+
+// DropCheckpoints removes the checkpoint id and every later one, e.g. once it was rolled back to
+func (s *Session) DropCheckpoints(id int) error {
+	return s.updateCheckpoints(func(checkpoints []Checkpoint) []Checkpoint {
+		return slices.DeleteFunc(checkpoints, func(cp Checkpoint) bool { return cp.ID >= id })
+	})
+}
+
+// updateCheckpoints replaces the session's checkpoints with what update makes of them,
+// holding the lock so another instance in the project doesn't lose a checkpoint
+func (s *Session) updateCheckpoints(update func([]Checkpoint) []Checkpoint) error {
+	path, err := s.checkpointsPath()
+	if err != nil {
+		return err
+	}
+	lock, err := filelock.Acquire(path+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	checkpoints, err := readCheckpoints(path)
+	if err != nil {
+		return err
+	}
+	checkpoints = update(checkpoints)
+	if len(checkpoints) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove checkpoints: %w", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(checkpoints)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoints: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpoints(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", tmp)
+
+	s := New(filepath.Join(tmp, "project"))
+	s.AddMessage("user", "what does main do?")
+	s.AddMessage("assistant", "It starts the server.")
+	s.Private = true
+	s.AddMessage("user", "the key is abc")
+	s.Private = false
+	s.AddMessage("user", "add a README")
+
+	cp, err := s.SaveCheckpoint("Agent: add a README", []CheckpointFile{{Path: "README.md"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.ID != 1 || len(cp.History) != 2 || cp.History[1].Content != "It starts the server." {
+		t.Fatalf("expected the conversation before the request without private messages, got %+v", cp)
+	}
+	for i := 0; i < maxCheckpoints+2; i++ {
+		if _, err := s.SaveCheckpoint("Edit", []CheckpointFile{{Path: "main.go", Existed: true, Content: []byte("package main\n")}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkpoints, err := s.Checkpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoints) != maxCheckpoints || checkpoints[0].ID != 4 || string(checkpoints[0].Files[0].Content) != "package main\n" {
+		t.Fatalf("expected the newest %d checkpoints, got %+v", maxCheckpoints, checkpoints)
+	}
+
+	if err := s.DropCheckpoints(12); err != nil {
+		t.Fatal(err)
+	}
+	if checkpoints, _ := s.Checkpoints(); len(checkpoints) != 8 || checkpoints[7].ID != 11 {
+		t.Fatalf("expected checkpoints up to 11 to be left, got %+v", checkpoints)
+	}
+	if err := s.DropCheckpoints(1); err != nil {
+		t.Fatal(err)
+	}
+	if checkpoints, err := s.Checkpoints(); len(checkpoints) != 0 || err != nil {
+		t.Fatalf("expected no checkpoints, got %+v, %v", checkpoints, err)
+	}
+}
@@ -1,13 +1,19 @@
 package session
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/filelock"
 )
 
 // Message represents a single conversation message
@@ -15,11 +21,15 @@ type Message struct {
 	Role      string    `json:"role"`
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp"`
+	Private   bool      `json:"-"` // Kept for this run only, never saved
+	Pinned    bool      `json:"pinned,omitempty"` // Kept word for word when the conversation is compacted
 }
 
 // Session represents a working session
 type Session struct {
 	ID          string    `json:"id"`
+	Name        string    `json:"name,omitempty"` // Empty for the project's default session
+	Title       string    `json:"title,omitempty"` // Short summary of the conversation, generated from its first exchanges
 	ProjectRoot string    `json:"project_root"`
 	ActiveFiles []string  `json:"active_files"`
 	Mode        string    `json:"mode"`
@@ -28,6 +38,28 @@ type Session struct {
 	History     []Message `json:"history"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	
+	// Changes lists files written during this run; it is not persisted
+	Changes []FileChange `json:"-"`
+	
+	// Private, when set, marks the messages added as private: they stay in the
+	// conversation for this run but are left out when the session is saved
+	Private bool `json:"-"`
+	
+	// ApproveChanges, when set, is asked before proposed file changes are written.
+	// It receives the unified diff and returns whether to write them.
+	ApproveChanges func(diff string) bool `json:"-"`
+	
+	// Ephemeral sessions are never saved, e.g. the ones of a throwaway project
+	Ephemeral bool `json:"-"`
+}
+
+// FileChange records a file LlamaSidekick wrote
+type FileChange struct {
+	Path    string `json:"path"`
+	Action  string `json:"action"` // "created", "modified", "deleted" or "renamed"
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
 }
 
 // New creates a new session
@@ -51,10 +83,33 @@ func (s *Session) AddMessage(role, content string) {
 		Role:      role,
 		Content:   content,
 		Timestamp: time.Now(),
+		Private:   s.Private,
 	})
 	s.UpdatedAt = time.Now()
 }
 
+// PinLastExchange pins the last message and, if it is an answer, the message it answers.
+// It returns how many messages it pinned.
+func (s *Session) PinLastExchange() int {
+	n := len(s.History)
+	if n == 0 {
+		return 0
+	}
+	s.History[n-1].Pinned = true
+	if n > 1 && s.History[n-1].Role == "assistant" && s.History[n-2].Role == "user" {
+		s.History[n-2].Pinned = true
+		return 2
+	}
+	return 1
+}
+
+// Clear drops the conversation history and the title that described it
+func (s *Session) Clear() {
+	s.History = []Message{}
+	s.Title = ""
+	s.UpdatedAt = time.Now()
+}
+
 // AddFile adds a file to the active files list
 func (s *Session) AddFile(filepath string) {
 	// Check if file is already in the list
@@ -85,31 +140,99 @@ func (s *Session) SetMode(mode string) {
 	s.UpdatedAt = time.Now()
 }
 
+// RecordChange notes that a file was written during this run
+func (s *Session) RecordChange(change FileChange) {
+	s.Changes = append(s.Changes, change)
+}
+
 func (s *Session) SetLastEditedFile(path string) {
 	s.LastEditedFile = path
 	s.UpdatedAt = time.Now()
 }
 
+// namePattern restricts session names to characters that are safe in file names
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// ValidateName checks that name can be used with --session
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid session name %q: use up to 64 letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
+
+// sessionPath returns the session file for a project. Each project root gets its own
+// file so switching projects restores the right conversation; named sessions get a file
+// of their own next to it.
+func sessionPath(projectRoot, name string) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config dir: %w", err)
+	}
+	
+	sessionsDir := filepath.Join(configDir, "sessions")
+	if err := os.MkdirAll(sessionsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create sessions dir: %w", err)
+	}
+	
+	root := projectRoot
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		root = abs
+	}
+	sum := sha256.Sum256([]byte(root))
+	if name != "" {
+		return filepath.Join(sessionsDir, hex.EncodeToString(sum[:8])+"-"+name+".json"), nil
+	}
+	return filepath.Join(sessionsDir, hex.EncodeToString(sum[:8])+".json"), nil
+}
+
 // Save saves the session to disk
 func (s *Session) Save() error {
-	configDir, err := config.GetConfigDir()
+	if s.Ephemeral {
+		return nil
+	}
+	sessionFile, err := sessionPath(s.ProjectRoot, s.Name)
 	if err != nil {
-		return fmt.Errorf("failed to get config dir: %w", err)
+		return err
 	}
 	
-	sessionFile := filepath.Join(configDir, "session.json")
-	data, err := json.MarshalIndent(s, "", "  ")
+	data, err := json.MarshalIndent(s.withoutPrivate(), "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal session: %w", err)
 	}
 	
-	if err := os.WriteFile(sessionFile, data, 0644); err != nil {
+	// Another instance in the same project may be saving too; serialize the writes
+	// and replace the file atomically so a reader never sees a partial session
+	lock, err := filelock.Acquire(sessionFile+".lock", filelock.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	
+	tmpFile := sessionFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := os.Rename(tmpFile, sessionFile); err != nil {
+		os.Remove(tmpFile)
 		return fmt.Errorf("failed to write session file: %w", err)
 	}
 	
 	return nil
 }
 
+// withoutPrivate returns a copy of the session to save, without the private messages
+func (s *Session) withoutPrivate() *Session {
+	saved := *s
+	saved.History = make([]Message, 0, len(s.History))
+	for _, msg := range s.History {
+		if !msg.Private {
+			saved.History = append(saved.History, msg)
+		}
+	}
+	return &saved
+}
+
 // SaveDebug saves a debug snapshot of the session with mode-specific filename
 func (s *Session) SaveDebug(mode string) error {
 	configDir, err := config.GetConfigDir()
@@ -119,7 +242,7 @@ func (s *Session) SaveDebug(mode string) error {
 	
 	timestamp := time.Now().Format("20060102_150405")
 	sessionFile := filepath.Join(configDir, fmt.Sprintf("session_%s_%s.json", mode, timestamp))
-	data, err := json.MarshalIndent(s, "", "  ")
+	data, err := json.MarshalIndent(s.withoutPrivate(), "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal session: %w", err)
 	}
@@ -131,20 +254,41 @@ func (s *Session) SaveDebug(mode string) error {
 	return nil
 }
 
-// Load loads a session from disk
+// Load loads the default session for projectRoot from disk
 func Load(projectRoot string) (*Session, error) {
-	configDir, err := config.GetConfigDir()
+	return LoadNamed(projectRoot, "")
+}
+
+// LoadNamed loads the session called name for projectRoot, or the default session when
+// name is empty. A session that doesn't exist yet is created empty.
+func LoadNamed(projectRoot, name string) (*Session, error) {
+	if name != "" {
+		if err := ValidateName(name); err != nil {
+			return nil, err
+		}
+	}
+	sessionFile, err := sessionPath(projectRoot, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get config dir: %w", err)
+		return nil, err
 	}
 	
-	sessionFile := filepath.Join(configDir, "session.json")
-	
 	data, err := os.ReadFile(sessionFile)
+	legacy := false
+	if os.IsNotExist(err) && name == "" {
+		// Fall back to the single session file used by older versions
+		configDir, dirErr := config.GetConfigDir()
+		if dirErr != nil {
+			return nil, fmt.Errorf("failed to get config dir: %w", dirErr)
+		}
+		data, err = os.ReadFile(filepath.Join(configDir, "session.json"))
+		legacy = true
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
 			// No session exists, create a new one
-			return New(projectRoot), nil
+			session := New(projectRoot)
+			session.Name = name
+			return session, nil
 		}
 		return nil, fmt.Errorf("failed to read session file: %w", err)
 	}
@@ -153,9 +297,14 @@ func Load(projectRoot string) (*Session, error) {
 	if err := json.Unmarshal(data, &session); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
 	}
+	if legacy && session.ProjectRoot != projectRoot {
+		// The legacy session belongs to another project
+		return New(projectRoot), nil
+	}
 
-	// Always trust the current project root from the caller.
+	// Always trust the current project root and name from the caller.
 	session.ProjectRoot = projectRoot
+	session.Name = name
 	if session.Mode == "" && session.LastMode != "" {
 		session.Mode = session.LastMode
 	}
@@ -163,6 +312,71 @@ func Load(projectRoot string) (*Session, error) {
 	return &session, nil
 }
 
+// Summary describes a saved session for the session browser
+type Summary struct {
+	Name        string // Empty for the project's default session
+	Title       string // Empty until a title is generated
+	FirstPrompt string // FallbackTitle of the session
+	Messages    int
+	UpdatedAt   time.Time
+}
+
+// List returns the saved sessions of projectRoot, most recently used first
+func List(projectRoot string) ([]Summary, error) {
+	defaultFile, err := sessionPath(projectRoot, "")
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.TrimSuffix(filepath.Base(defaultFile), ".json")
+	entries, err := os.ReadDir(filepath.Dir(defaultFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions dir: %w", err)
+	}
+
+	var summaries []Summary
+	for _, e := range entries {
+		file := e.Name()
+		if !strings.HasPrefix(file, prefix) || !strings.HasSuffix(file, ".json") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(file, prefix), ".json")
+		if name != "" {
+			if name = strings.TrimPrefix(name, "-"); ValidateName(name) != nil {
+				continue
+			}
+		}
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(defaultFile), file))
+		if err != nil {
+			continue
+		}
+		var saved Session
+		if err := json.Unmarshal(data, &saved); err != nil {
+			continue
+		}
+		summaries = append(summaries, Summary{Name: name, Title: saved.Title, FirstPrompt: saved.FallbackTitle(), Messages: len(saved.History), UpdatedAt: saved.UpdatedAt})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt) })
+	return summaries, nil
+}
+
+// FallbackTitle returns the start of the first prompt, for sessions without a generated title
+func (s *Session) FallbackTitle() string {
+	for _, msg := range s.History {
+		if msg.Role != "user" {
+			continue
+		}
+		title := strings.Join(strings.Fields(msg.Content), " ")
+		if runes := []rune(title); len(runes) > maxTitleLength {
+			title = string(runes[:maxTitleLength-1]) + "…"
+		}
+		return title
+	}
+	return ""
+}
+
+// maxTitleLength bounds session titles, in characters
+const maxTitleLength = 60
+
 // generateID generates a simple session ID
 func generateID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
@@ -3,7 +3,9 @@ package session
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSessionSaveLoad_RoundTrip(t *testing.T) {
@@ -42,3 +44,141 @@ func TestSessionSaveLoad_RoundTrip(t *testing.T) {
 		t.Fatalf("expected 2 history messages, got %d", len(loaded.History))
 	}
 }
+
+func TestLoadNamed_KeepsSessionsApart(t *testing.T) {
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", t.TempDir())
+	projectRoot := t.TempDir()
+
+	def := New(projectRoot)
+	def.AddMessage("user", "default")
+	if err := def.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	named, err := LoadNamed(projectRoot, "auth-work")
+	if err != nil {
+		t.Fatalf("load named: %v", err)
+	}
+	if len(named.History) != 0 || named.Name != "auth-work" {
+		t.Fatalf("expected a new named session, got %+v", named)
+	}
+	named.AddMessage("user", "named")
+	if err := named.Save(); err != nil {
+		t.Fatalf("save named: %v", err)
+	}
+
+	if loaded, _ := Load(projectRoot); len(loaded.History) != 1 || loaded.History[0].Content != "default" {
+		t.Fatalf("default session changed: %+v", loaded.History)
+	}
+	if loaded, _ := LoadNamed(projectRoot, "auth-work"); len(loaded.History) != 1 || loaded.History[0].Content != "named" {
+		t.Fatalf("named session not restored: %+v", loaded.History)
+	}
+	if _, err := LoadNamed(projectRoot, "../escape"); err == nil {
+		t.Fatalf("expected invalid name to fail")
+	}
+}
+
+func TestList_NewestFirst(t *testing.T) {
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", t.TempDir())
+	projectRoot := t.TempDir()
+
+	def := New(projectRoot)
+	def.AddMessage("user", "How does the   backup store\nprune old versions?")
+	def.Title = "Backup pruning"
+	def.UpdatedAt = time.Now().Add(-time.Hour)
+	if err := def.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	named := New(projectRoot)
+	named.Name = "auth-work"
+	named.AddMessage("user", strings.Repeat("word ", 20))
+	if err := named.Save(); err != nil {
+		t.Fatalf("save named: %v", err)
+	}
+	other := New(t.TempDir())
+	if err := other.Save(); err != nil {
+		t.Fatalf("save other: %v", err)
+	}
+
+	summaries, err := List(projectRoot)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(summaries) != 2 || summaries[0].Name != "auth-work" || summaries[1].Name != "" {
+		t.Fatalf("expected this project's sessions, newest first, got %+v", summaries)
+	}
+	if summaries[1].Title != "Backup pruning" || summaries[1].FirstPrompt != "How does the backup store prune old versions?" || summaries[1].Messages != 1 {
+		t.Fatalf("unexpected default session summary: %+v", summaries[1])
+	}
+	if first := []rune(summaries[0].FirstPrompt); len(first) != maxTitleLength || first[len(first)-1] != '…' {
+		t.Fatalf("expected the first prompt to be shortened, got %q", summaries[0].FirstPrompt)
+	}
+}
+
+func TestSave_LeavesOutPrivateMessages(t *testing.T) {
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", t.TempDir())
+	projectRoot := t.TempDir()
+
+	s := New(projectRoot)
+	s.AddMessage("user", "hello")
+	s.Private = true
+	s.AddMessage("user", "my token is hunter2")
+	s.AddMessage("assistant", "noted")
+	s.Private = false
+	s.AddMessage("user", "thanks")
+	if err := s.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if len(s.History) != 4 {
+		t.Fatalf("expected the private messages to stay in the conversation, got %d", len(s.History))
+	}
+
+	loaded, err := Load(projectRoot)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(loaded.History) != 2 || loaded.History[1].Content != "thanks" {
+		t.Fatalf("expected only the public messages saved, got %+v", loaded.History)
+	}
+}
+
+func TestPinLastExchange_IsSaved(t *testing.T) {
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", t.TempDir())
+	projectRoot := t.TempDir()
+
+	s := New(projectRoot)
+	if s.PinLastExchange() != 0 {
+		t.Fatal("expected nothing to pin in an empty conversation")
+	}
+	s.AddMessage("user", "hello")
+	s.AddMessage("user", "use tabs")
+	s.AddMessage("assistant", "ok")
+	if n := s.PinLastExchange(); n != 2 {
+		t.Fatalf("expected the question and its answer pinned, got %d", n)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	loaded, err := Load(projectRoot)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.History[0].Pinned || !loaded.History[1].Pinned || !loaded.History[2].Pinned {
+		t.Fatalf("expected the last exchange pinned after loading, got %+v", loaded.History)
+	}
+}
+
+func TestSave_SkipsEphemeralSessions(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("LLAMASIDEKICK_CONFIG_DIR", tmp)
+
+	s := New(filepath.Join(tmp, "project"))
+	s.Ephemeral = true
+	s.AddMessage("user", "hello")
+	if err := s.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(tmp, "sessions")); len(entries) != 0 {
+		t.Fatalf("expected no session files, got %d", len(entries))
+	}
+}
@@ -0,0 +1,61 @@
+package share
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/diff"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// ChangeDiffs returns the unified diff of every file changed this run, from its content
+// before the first change (taken from the backups) to its current content. A file whose
+// earlier backups were pruned is diffed against the oldest one left, and one without
+// backups is left out.
+func ChangeDiffs(store *safeio.BackupStore, root string, changes []session.FileChange) string {
+	writes := map[string]int{}
+	created := map[string]bool{}
+	deleted := map[string]bool{}
+	var paths []string
+	for _, c := range changes {
+		if writes[c.Path] == 0 {
+			paths = append(paths, c.Path)
+			// A renamed file's backups are kept under its old path
+			created[c.Path] = c.Action == "created" || c.Action == "renamed"
+		}
+		writes[c.Path]++
+		deleted[c.Path] = c.Action == "deleted"
+	}
+
+	var b strings.Builder
+	for _, path := range paths {
+		current, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil && !(deleted[path] && os.IsNotExist(err)) {
+			continue
+		}
+		if created[path] {
+			b.WriteString(diff.Unified("", path, "", string(current), 3))
+			continue
+		}
+		backups, err := store.List(root, path)
+		if err != nil || len(backups) == 0 {
+			continue
+		}
+		backup := backups[len(backups)-1]
+		if writes[path] <= len(backups) {
+			backup = backups[writes[path]-1]
+		}
+		before, err := os.ReadFile(backup.Path)
+		if err != nil {
+			continue
+		}
+		newName := path
+		if deleted[path] {
+			newName = ""
+		}
+		b.WriteString(diff.Unified(path, newName, string(before), string(current), 3))
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package share
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// gistAPIURL is where gists are created with a token
+var gistAPIURL = "https://api.github.com/gists"
+
+// CreateGist uploads files (name to content) as a secret gist and returns its URL. With a
+// token it uses the GitHub API; without one it uses the gh CLI, which must be logged in.
+func CreateGist(description string, files map[string]string, token string) (string, error) {
+	if token != "" {
+		return createGistWithToken(description, files, token)
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		return "", fmt.Errorf("creating a gist needs the gh CLI or share.github_token")
+	}
+
+	dir, err := os.MkdirTemp("", "llamasidekick-share-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"gist", "create", "--desc", description}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(files[name]), 0600); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		args = append(args, path)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("gh", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("gh gist create failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func createGistWithToken(description string, files map[string]string, token string) (string, error) {
+	type gistFile struct {
+		Content string `json:"content"`
+	}
+	request := struct {
+		Description string              `json:"description"`
+		Public      bool                `json:"public"`
+		Files       map[string]gistFile `json:"files"`
+	}{Description: description, Files: map[string]gistFile{}}
+	for name, content := range files {
+		request.Files[name] = gistFile{Content: content}
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode gist: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, gistAPIURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create gist: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		HTMLURL string `json:"html_url"`
+		Message string `json:"message"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("failed to create gist: GitHub answered %s: %s", resp.Status, result.Message)
+	}
+	return result.HTMLURL, nil
+}
@@ -0,0 +1,86 @@
+// Package share exports a conversation for sharing: as markdown, as a standalone HTML
+// page, or as a secret GitHub Gist.
+package share
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/session"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Markdown renders the conversation without its private messages, followed by diffs if
+// it isn't empty
+func Markdown(sess *session.Session, diffs string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", Title(sess))
+	for _, m := range sess.History {
+		if m.Private {
+			continue
+		}
+		speaker := "LlamaSidekick"
+		if m.Role == "user" {
+			speaker = "You"
+		}
+		fmt.Fprintf(&b, "## %s", speaker)
+		if !m.Timestamp.IsZero() {
+			fmt.Fprintf(&b, " (%s)", m.Timestamp.Format("2006-01-02 15:04"))
+		}
+		fmt.Fprintf(&b, "\n\n%s\n\n", strings.TrimSpace(m.Content))
+	}
+	if diffs != "" {
+		fmt.Fprintf(&b, "## Changes\n\n```diff\n%s\n```\n", strings.TrimRight(diffs, "\n"))
+	}
+	return b.String()
+}
+
+// Title is the conversation's generated title, or else names it after its project and session
+func Title(sess *session.Session) string {
+	if sess.Title != "" {
+		return sess.Title
+	}
+	title := "LlamaSidekick conversation"
+	if sess.ProjectRoot != "" {
+		title += " in " + filepath.Base(sess.ProjectRoot)
+	}
+	if sess.Name != "" {
+		title += " (" + sess.Name + ")"
+	}
+	return title
+}
+
+// HTML renders the conversation as a standalone page with inline styles. Raw HTML in the
+// messages is escaped rather than rendered.
+func HTML(sess *session.Session, diffs string) (string, error) {
+	var body bytes.Buffer
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	if err := md.Convert([]byte(Markdown(sess, diffs)), &body); err != nil {
+		return "", fmt.Errorf("failed to render conversation: %w", err)
+	}
+	return fmt.Sprintf(htmlPage, html.EscapeString(Title(sess)), body.String()), nil
+}
+
+const htmlPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<style>
+body { max-width: 860px; margin: 2rem auto; padding: 0 1rem; font: 15px/1.6 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; }
+h1 { font-size: 1.5rem; border-bottom: 1px solid #d0d7de; padding-bottom: .4rem; }
+h2 { font-size: 1.1rem; margin-top: 2rem; color: #0969da; }
+pre { background: #f6f8fa; padding: .8rem; overflow-x: auto; border-radius: 6px; }
+code { font: 13px ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+table { border-collapse: collapse; } td, th { border: 1px solid #d0d7de; padding: .3rem .6rem; }
+</style>
+</head>
+<body>
+%s</body>
+</html>
+`
@@ -0,0 +1,98 @@
+package share
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/safeio"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestHTML(t *testing.T) {
+	sess := session.New("/home/me/shop")
+	sess.AddMessage("user", "why does `Load` fail?")
+	sess.AddMessage("assistant", "It returns early:\n\n```go\nreturn nil\n```\n\n<script>alert(1)</script>")
+
+	page, err := HTML(sess, "--- a/x.go\n+++ b/x.go\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<title>LlamaSidekick conversation in shop</title>", "<h2>You (", "<code>Load</code>", `<code class="language-go">return nil`, "<h2>Changes</h2>", "+++ b/x.go"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected %q in the page", want)
+		}
+	}
+	if strings.Contains(page, "<script>") {
+		t.Fatal("raw HTML from a message was rendered")
+	}
+}
+
+func TestMarkdown_LeavesOutPrivateMessages(t *testing.T) {
+	sess := session.New("/home/me/shop")
+	sess.AddMessage("user", "how do I list orders?")
+	sess.AddMessage("assistant", "Call ListOrders.")
+	sess.Private = true
+	sess.AddMessage("user", "why does this token fail: ghp_secret")
+	sess.AddMessage("assistant", "The token expired.")
+	sess.Private = false
+
+	md := Markdown(sess, "")
+	if strings.Contains(md, "ghp_secret") || strings.Contains(md, "expired") {
+		t.Fatalf("a private message was shared:\n%s", md)
+	}
+	if !strings.Contains(md, "Call ListOrders.") {
+		t.Fatalf("expected the other messages to be shared:\n%s", md)
+	}
+}
+
+func TestChangeDiffs(t *testing.T) {
+	root := t.TempDir()
+	store := safeio.NewBackupStore(t.TempDir(), 10)
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("one\n"), 0644)
+	for _, content := range []string{"two\n", "three\n"} {
+		if _, err := store.WriteFile(root, "main.go", []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(root, "new.txt"), []byte("hello\n"), 0644)
+
+	diffs := ChangeDiffs(store, root, []session.FileChange{
+		{Path: "main.go", Action: "modified"},
+		{Path: "new.txt", Action: "created"},
+		{Path: "main.go", Action: "modified"},
+	})
+	want := "--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-one\n+three\n--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+hello\n"
+	if diffs != want {
+		t.Fatalf("unexpected diffs:\n%s", diffs)
+	}
+}
+
+func TestCreateGistWithToken(t *testing.T) {
+	var got struct {
+		Public bool                         `json:"public"`
+		Files  map[string]map[string]string `json:"files"`
+	}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"html_url": "https://gist.github.com/me/abc"}`))
+	}))
+	defer server.Close()
+	defer func(url string) { gistAPIURL = url }(gistAPIURL)
+	gistAPIURL = server.URL
+
+	url, err := CreateGist("debugging", map[string]string{"conversation.md": "# hi"}, "ghp_token")
+	if err != nil || url != "https://gist.github.com/me/abc" {
+		t.Fatalf("unexpected result %q, %v", url, err)
+	}
+	if auth != "Bearer ghp_token" || got.Public || got.Files["conversation.md"]["content"] != "# hi" {
+		t.Fatalf("unexpected request: auth %q, body %+v", auth, got)
+	}
+}
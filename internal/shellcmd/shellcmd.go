@@ -0,0 +1,45 @@
+// Package shellcmd runs user-configured command lines through the platform's shell.
+package shellcmd
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Command returns a command that runs line with sh -c, or cmd /C on Windows
+func Command(line string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", line)
+	}
+	return exec.Command("sh", "-c", line)
+}
+
+// Suggested returns a command that runs a command line written by the model. On
+// Windows it is written for PowerShell, so it runs there rather than in cmd.
+func Suggested(line string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", line)
+	}
+	return exec.Command("sh", "-c", line)
+}
+
+// Quote quotes arg so the shell passes it to the command as a single argument
+func Quote(arg string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(arg, `"`, `""`) + `"`
+	}
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./=:@+,") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// Program returns the first word of line, the program it runs
+func Program(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.Trim(fields[0], `"'`)
+}
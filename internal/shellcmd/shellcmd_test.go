@@ -0,0 +1,27 @@
+package shellcmd
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX quoting")
+	}
+	for arg, want := range map[string]string{
+		"pkg/main.go":   "pkg/main.go",
+		"my file.go":    "'my file.go'",
+		"it's.py":       `'it'\''s.py'`,
+		"$(rm -rf x).c": "'$(rm -rf x).c'",
+		"":              "''",
+	} {
+		if got := Quote(arg); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", arg, got, want)
+		}
+	}
+	out, err := Command("printf %s " + Quote("a b'c")).Output()
+	if err != nil || string(out) != "a b'c" {
+		t.Fatalf("quoted argument came out as %q (%v)", out, err)
+	}
+}
@@ -0,0 +1,219 @@
+// Package shellhist learns which command-line tools the user prefers from their shell
+// history. Only program names are kept, never arguments, so nothing like a password
+// typed on the command line leaves the history file.
+package shellhist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Tool is a program the user ran and how often it appears in the history read
+type Tool struct {
+	Name  string
+	Count int
+}
+
+// alternatives are groups of tools that do the same job, with the classic tool that every
+// system has last
+var alternatives = [][]string{
+	{"eza", "exa", "lsd", "ls"},
+	{"bat", "batcat", "cat"},
+	{"rg", "ag", "ack", "grep"},
+	{"fd", "fdfind", "find"},
+	{"docker compose", "podman-compose", "docker-compose"},
+	{"podman", "docker"},
+	{"nvim", "hx", "emacs", "micro", "nano", "vim", "vi"},
+	{"btop", "htop", "top"},
+	{"dust", "du"},
+	{"duf", "df"},
+	{"doas", "sudo"},
+	{"pnpm", "yarn", "bun", "npm"},
+	{"uv", "pipx", "pip3", "pip"},
+	{"procs", "ps"},
+	{"delta", "diff"},
+	{"xh", "curlie", "http", "wget", "curl"},
+	{"sd", "sed"},
+	{"doggo", "dog", "nslookup", "dig"},
+}
+
+// prefixes run the command after them, so the program is the next word. The ones that
+// are true are tools worth counting themselves.
+var prefixes = map[string]bool{"sudo": true, "doas": true, "time": false, "nohup": false, "exec": false, "command": false, "builtin": false, "env": false, "nice": false}
+
+// prefixOptionValues are options of prefixes that take a value, such as sudo -u root and
+// nice -n 10
+var prefixOptionValues = map[string]bool{"-u": true, "-g": true, "-C": true, "-D": true, "-h": true, "-p": true, "-r": true, "-t": true, "-U": true, "-n": true}
+
+var programName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._+-]*$`)
+
+// DefaultFile returns the history file of the user's shell: $HISTFILE if set, or the
+// usual file of bash, zsh, fish or PowerShell
+func DefaultFile() (string, error) {
+	if f := os.Getenv("HISTFILE"); f != "" {
+		return f, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the home directory: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "Microsoft", "Windows", "PowerShell", "PSReadLine", "ConsoleHost_history.txt"), nil
+	}
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case "zsh":
+		return filepath.Join(home, ".zsh_history"), nil
+	case "fish":
+		return filepath.Join(home, ".local", "share", "fish", "fish_history"), nil
+	}
+	return filepath.Join(home, ".bash_history"), nil
+}
+
+// Read returns the last maxLines commands in the history file at path. It understands
+// plain bash and PowerShell history, zsh's extended history and fish's history format.
+func Read(path string, maxLines int) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shell history: %w", err)
+	}
+	var commands []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "- cmd: "):
+			// fish
+			line = strings.TrimPrefix(line, "- cmd: ")
+		case strings.HasPrefix(line, "when: ") || strings.HasPrefix(line, "paths:") || strings.HasPrefix(line, "- "):
+			continue
+		case strings.HasPrefix(line, ": "):
+			// zsh extended history, ": <start>:<duration>;<command>"
+			if _, cmd, ok := strings.Cut(line, ";"); ok {
+				line = cmd
+			}
+		case strings.HasPrefix(line, "#"):
+			// bash timestamps
+			continue
+		}
+		if line != "" {
+			commands = append(commands, line)
+		}
+	}
+	if maxLines > 0 && len(commands) > maxLines {
+		commands = commands[len(commands)-maxLines:]
+	}
+	return commands, nil
+}
+
+// Frequent returns the names of the tools used at least minCount times, at most max of
+// them. A minimum of a few keeps out words typed once by mistake, such as a password.
+func Frequent(tools []Tool, minCount, max int) []string {
+	var names []string
+	for _, t := range tools {
+		if t.Count >= minCount && len(names) < max {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// Tools counts the programs the commands run, most used first. Pipelines and command
+// lists count each program in them; "docker compose" counts as one tool.
+func Tools(commands []string) []Tool {
+	counts := map[string]int{}
+	for _, command := range commands {
+		for _, part := range splitCommands(command) {
+			for _, name := range programs(part) {
+				counts[name]++
+			}
+		}
+	}
+	tools := make([]Tool, 0, len(counts))
+	for name, count := range counts {
+		tools = append(tools, Tool{Name: name, Count: count})
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		if tools[i].Count != tools[j].Count {
+			return tools[i].Count > tools[j].Count
+		}
+		return tools[i].Name < tools[j].Name
+	})
+	return tools
+}
+
+// Preferences describes the tools the user picks over their alternatives, e.g.
+// "eza rather than ls", for each group of alternatives the history uses
+func Preferences(tools []Tool) []string {
+	counts := map[string]int{}
+	for _, t := range tools {
+		counts[t.Name] = t.Count
+	}
+	var prefs []string
+	for _, group := range alternatives {
+		best := ""
+		for _, name := range group {
+			if counts[name] > counts[best] {
+				best = name
+			}
+		}
+		// Preferring the classic tool everyone has tells the model nothing
+		if best == "" || best == group[len(group)-1] {
+			continue
+		}
+		var others []string
+		for _, name := range group {
+			if name != best {
+				others = append(others, name)
+			}
+		}
+		prefs = append(prefs, fmt.Sprintf("%s rather than %s", best, strings.Join(others, " or ")))
+	}
+	return prefs
+}
+
+// splitCommands splits a command line at pipes and command separators
+func splitCommands(line string) []string {
+	return strings.FieldsFunc(line, func(r rune) bool {
+		return r == '|' || r == ';' || r == '&'
+	})
+}
+
+// programs returns the tool a simple command runs, after the prefixes worth counting
+// such as sudo. Variable assignments are skipped, and a first word that doesn't look like
+// a program name gives no tool.
+func programs(command string) []string {
+	var names []string
+	fields := strings.Fields(command)
+	for len(fields) > 0 {
+		counted, isPrefix := prefixes[fields[0]]
+		if !isPrefix && !strings.Contains(fields[0], "=") {
+			break
+		}
+		if counted {
+			names = append(names, fields[0])
+		}
+		fields = fields[1:]
+		// Options of a prefix, e.g. sudo -u root
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			if prefixOptionValues[fields[0]] && len(fields) > 1 {
+				fields = fields[1:]
+			}
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return names
+	}
+	name := filepath.Base(fields[0])
+	if !programName.MatchString(name) {
+		return names
+	}
+	if name == "docker" && len(fields) > 1 && fields[1] == "compose" {
+		name = "docker compose"
+	}
+	return append(names, name)
+}
@@ -0,0 +1,72 @@
+package shellhist
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	for name, history := range map[string]string{
+		"bash": "#1700000000\nls -la\ngit status\n\ndocker compose up -d\n",
+		"zsh":  ": 1700000000:0;ls -la\n: 1700000001:0;git status\n: 1700000002:3;docker compose up -d\n",
+		"fish": "- cmd: ls -la\n  when: 1700000000\n- cmd: git status\n  when: 1700000001\n  paths:\n    - .\n- cmd: docker compose up -d\n  when: 1700000002\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(history), 0600); err != nil {
+			t.Fatal(err)
+		}
+		commands, err := Read(path, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"git status", "docker compose up -d"}; !reflect.DeepEqual(commands, want) {
+			t.Errorf("%s: expected the last two commands %q, got %q", name, want, commands)
+		}
+	}
+}
+
+func TestTools(t *testing.T) {
+	tools := Tools([]string{
+		"eza -la",
+		"sudo -u root eza /root",
+		"FOO=bar rg TODO | head -n 5 && eza",
+		"docker compose up 2>&1 | rg error",
+		"./build.sh; /usr/bin/rg x",
+		"$(secret)",
+	})
+	counts := map[string]int{}
+	for _, tool := range tools {
+		counts[tool.Name] = tool.Count
+	}
+	want := map[string]int{"eza": 3, "rg": 3, "sudo": 1, "head": 1, "docker compose": 1, "build.sh": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("expected %v, got %v", want, counts)
+	}
+	if tools[0].Name != "eza" || tools[1].Name != "rg" {
+		t.Fatalf("expected the most used tools first, got %v", tools)
+	}
+}
+
+func TestPreferences(t *testing.T) {
+	tools := []Tool{{"eza", 5}, {"ls", 2}, {"grep", 4}, {"rg", 1}, {"docker compose", 3}, {"docker-compose", 1}}
+	want := []string{
+		"eza rather than exa or lsd or ls",
+		"docker compose rather than podman-compose or docker-compose",
+	}
+	if got := Preferences(tools); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFrequent(t *testing.T) {
+	tools := []Tool{{"git", 9}, {"go", 4}, {"make", 3}, {"hunter2", 1}}
+	if got := Frequent(tools, 3, 2); !reflect.DeepEqual(got, []string{"git", "go"}) {
+		t.Fatalf("expected the two most used tools, got %q", got)
+	}
+	if got := Frequent(tools, 3, 10); len(got) != 3 {
+		t.Fatalf("expected tools used once to be left out, got %q", got)
+	}
+}
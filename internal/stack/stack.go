@@ -0,0 +1,254 @@
+// Package stack detects a project's main language and the frameworks it uses.
+package stack
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/deps"
+	"github.com/yourusername/llamasidekick/internal/pathmatch"
+)
+
+// maxScannedFiles bounds how many files Detect counts in large projects
+const maxScannedFiles = 20000
+
+// Stack is what a project is written in
+type Stack struct {
+	Language   string   // The main language, e.g. "Go"; empty if none was found
+	Others     []string // Other languages with a notable share of the source files
+	Frameworks []string // Frameworks and major libraries, e.g. "Cobra"
+}
+
+// Describe returns the stack as a phrase such as "Go using Cobra and Bubble Tea, with
+// some Shell", or "" for an unknown stack
+func (s Stack) Describe() string {
+	if s.Language == "" {
+		return ""
+	}
+	text := s.Language
+	if len(s.Frameworks) > 0 {
+		text += " using " + joinAnd(s.Frameworks)
+	}
+	if len(s.Others) > 0 {
+		text += ", with some " + joinAnd(s.Others)
+	}
+	return text
+}
+
+// extensionLanguages maps source file extensions to their language
+var extensionLanguages = map[string]string{
+	".go": "Go", ".rs": "Rust", ".py": "Python", ".rb": "Ruby", ".php": "PHP",
+	".js": "JavaScript", ".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript", ".java": "Java", ".kt": "Kotlin", ".kts": "Kotlin",
+	".cs": "C#", ".c": "C", ".h": "C", ".cpp": "C++", ".cc": "C++", ".hpp": "C++",
+	".swift": "Swift", ".dart": "Dart", ".ex": "Elixir", ".exs": "Elixir", ".scala": "Scala",
+	".lua": "Lua", ".sh": "Shell", ".bash": "Shell",
+}
+
+// manifestLanguages maps the manifests found at a project root to the language they declare
+var manifestLanguages = map[string]string{
+	"go.mod": "Go", "Cargo.toml": "Rust", "package.json": "JavaScript",
+	"pyproject.toml": "Python", "requirements.txt": "Python", "setup.py": "Python", "Pipfile": "Python",
+	"Gemfile": "Ruby", "composer.json": "PHP", "pom.xml": "Java", "build.gradle": "Java",
+	"build.gradle.kts": "Kotlin", "Package.swift": "Swift", "pubspec.yaml": "Dart", "mix.exs": "Elixir",
+}
+
+// skippedDirs are directories of dependencies and build output, which say nothing about
+// the project's own code
+var skippedDirs = map[string]bool{"node_modules": true, "vendor": true, "target": true, "dist": true, "build": true, "__pycache__": true}
+
+// framework is a dependency that names a framework
+type framework struct {
+	dep  string
+	name string
+}
+
+// goFrameworks and jsFrameworks are matched against the dependencies of go.mod and
+// package.json, in the order they are listed
+var goFrameworks = []framework{
+	{"github.com/gin-gonic/gin", "Gin"}, {"github.com/labstack/echo", "Echo"}, {"github.com/gofiber/fiber", "Fiber"},
+	{"github.com/go-chi/chi", "chi"}, {"github.com/gorilla/mux", "gorilla/mux"}, {"google.golang.org/grpc", "gRPC"},
+	{"github.com/spf13/cobra", "Cobra"}, {"github.com/charmbracelet/bubbletea", "Bubble Tea"},
+	{"gorm.io/gorm", "GORM"}, {"github.com/stretchr/testify", "testify"},
+}
+
+var jsFrameworks = []framework{
+	{"next", "Next.js"}, {"nuxt", "Nuxt"}, {"@sveltejs/kit", "SvelteKit"}, {"react-native", "React Native"},
+	{"react", "React"}, {"vue", "Vue"}, {"svelte", "Svelte"}, {"@angular/core", "Angular"},
+	{"@nestjs/core", "NestJS"}, {"express", "Express"}, {"fastify", "Fastify"}, {"electron", "Electron"},
+	{"tailwindcss", "Tailwind CSS"}, {"jest", "Jest"}, {"vitest", "Vitest"},
+}
+
+// textFrameworks are looked for by name in manifests without a parser
+var textFrameworks = map[string][]framework{
+	"Cargo.toml": {{"tokio", "Tokio"}, {"axum", "Axum"}, {"actix-web", "Actix Web"}, {"rocket", "Rocket"},
+		{"tauri", "Tauri"}, {"bevy", "Bevy"}, {"clap", "clap"}},
+	"pyproject.toml":   pythonFrameworks,
+	"requirements.txt": pythonFrameworks,
+	"setup.py":         pythonFrameworks,
+	"Pipfile":          pythonFrameworks,
+	"Gemfile":          {{"rails", "Rails"}, {"sinatra", "Sinatra"}, {"rspec", "RSpec"}},
+	"composer.json":    {{"laravel/framework", "Laravel"}, {"symfony/framework-bundle", "Symfony"}, {"phpunit/phpunit", "PHPUnit"}},
+	"pom.xml":          {{"spring-boot", "Spring Boot"}, {"junit-jupiter", "JUnit 5"}},
+	"build.gradle":     {{"spring-boot", "Spring Boot"}, {"junit-jupiter", "JUnit 5"}},
+	"build.gradle.kts": {{"spring-boot", "Spring Boot"}, {"ktor", "Ktor"}, {"junit-jupiter", "JUnit 5"}},
+}
+
+var pythonFrameworks = []framework{
+	{"django", "Django"}, {"flask", "Flask"}, {"fastapi", "FastAPI"}, {"pydantic", "Pydantic"},
+	{"torch", "PyTorch"}, {"pandas", "pandas"}, {"pytest", "pytest"},
+}
+
+// Detect works out the stack of the project at root from its manifests and the
+// extensions of its files. Files matching ignore are not counted.
+func Detect(root string, ignore []string) Stack {
+	counts := countLanguages(root, ignore)
+	var s Stack
+
+	declared := map[string]bool{}
+	for file, language := range manifestLanguages {
+		if _, err := os.Stat(filepath.Join(root, file)); err == nil {
+			if language == "JavaScript" && counts["TypeScript"] > 0 {
+				language = "TypeScript"
+			}
+			declared[language] = true
+		}
+	}
+
+	// The main language is the declared one with the most files, or else the one with
+	// the most files
+	best := -1
+	for language, n := range counts {
+		if declared[language] && (n > best || n == best && language < s.Language) {
+			s.Language, best = language, n
+		}
+	}
+	if s.Language == "" {
+		for language := range declared {
+			if s.Language == "" || language < s.Language {
+				s.Language = language
+			}
+		}
+	}
+	if s.Language == "" {
+		for language, n := range counts {
+			if n > best || n == best && language < s.Language {
+				s.Language, best = language, n
+			}
+		}
+	}
+
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	for language, n := range counts {
+		// JavaScript build configs are common in TypeScript projects and say little
+		if language != s.Language && n*10 >= total && !(s.Language == "TypeScript" && language == "JavaScript") {
+			s.Others = append(s.Others, language)
+		}
+	}
+	sort.Slice(s.Others, func(i, j int) bool {
+		if counts[s.Others[i]] != counts[s.Others[j]] {
+			return counts[s.Others[i]] > counts[s.Others[j]]
+		}
+		return s.Others[i] < s.Others[j]
+	})
+
+	s.Frameworks = detectFrameworks(root)
+	return s
+}
+
+// countLanguages counts the source files of each language under root
+func countLanguages(root string, ignore []string) map[string]int {
+	counts := map[string]int{}
+	scanned := 0
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") || skippedDirs[d.Name()] || pathmatch.MatchAny(ignore, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if scanned++; scanned > maxScannedFiles {
+			return filepath.SkipAll
+		}
+		if language, ok := extensionLanguages[strings.ToLower(filepath.Ext(d.Name()))]; ok && !pathmatch.MatchAny(ignore, rel) {
+			counts[language]++
+		}
+		return nil
+	})
+	return counts
+}
+
+// detectFrameworks returns the frameworks the manifests at root depend on
+func detectFrameworks(root string) []string {
+	var names []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	manifests, _ := deps.Load(root)
+	for _, m := range manifests {
+		rules := goFrameworks
+		if m.File == "package.json" {
+			rules = jsFrameworks
+		}
+		for _, rule := range rules {
+			for _, d := range m.Deps {
+				if d.Name == rule.dep || m.File == "go.mod" && strings.HasPrefix(d.Name, rule.dep+"/") {
+					add(rule.name)
+					break
+				}
+			}
+		}
+	}
+
+	files := make([]string, 0, len(textFrameworks))
+	for file := range textFrameworks {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil {
+			continue
+		}
+		text := strings.ToLower(string(data))
+		for _, rule := range textFrameworks[file] {
+			if mentionsDependency(text, rule.dep) {
+				add(rule.name)
+			}
+		}
+	}
+	return names
+}
+
+// mentionsDependency reports whether text names dep as a whole word
+func mentionsDependency(text, dep string) bool {
+	return regexp.MustCompile(`(?:^|[^a-z0-9_.-])` + regexp.QuoteMeta(dep) + `(?:$|[^a-z0-9_-])`).MatchString(text)
+}
+
+// joinAnd joins items as "a, b and c"
+func joinAnd(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
@@ -0,0 +1,68 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+}
+
+func TestDetect_GoProject(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod": "module example.com/tool\n\ngo 1.23\n\nrequire (\n\tgithub.com/spf13/cobra v1.8.0\n" +
+			"\tgithub.com/labstack/echo/v4 v4.11.0\n\tgolang.org/x/sys v0.20.0 // indirect\n)\n",
+		"main.go":             "package main\n",
+		"cmd/root.go":         "package cmd\n",
+		"internal/server.go":  "package internal\n",
+		"scripts/release.sh":  "#!/bin/sh\n",
+		"node_modules/x/a.js": "",
+		"node_modules/x/b.js": "",
+		"node_modules/x/c.js": "",
+	})
+
+	got := Detect(root, nil)
+	if got.Describe() != "Go using Echo and Cobra, with some Shell" {
+		t.Fatalf("unexpected stack %q (%+v)", got.Describe(), got)
+	}
+}
+
+func TestDetect_TypeScriptAndPython(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"package.json":   `{"dependencies": {"next": "14.0.0", "react": "18.2.0"}, "devDependencies": {"vitest": "1.0.0"}}`,
+		"tsconfig.json":  "{}",
+		"app/page.tsx":   "",
+		"app/layout.tsx": "",
+		"next.config.js": "",
+	})
+	if got := Detect(root, nil).Describe(); got != "TypeScript using Next.js, React and Vitest" {
+		t.Fatalf("unexpected stack %q", got)
+	}
+
+	root = t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"requirements.txt": "Django==5.0\ndjangorestframework==3.14\npytest\n",
+		"manage.py":        "",
+		"legacy/old.py":    "",
+	})
+	if got := Detect(root, []string{"legacy/**"}).Describe(); got != "Python using Django and pytest" {
+		t.Fatalf("unexpected stack %q", got)
+	}
+
+	if got := Detect(t.TempDir(), nil).Describe(); got != "" {
+		t.Fatalf("expected no stack for an empty project, got %q", got)
+	}
+}
@@ -0,0 +1,200 @@
+// Package tasks reads the tasks a project defines in its Makefile, Taskfile or justfile,
+// so suggestions can run "make test" instead of the tools it wraps.
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Task is a target or recipe a project defines
+type Task struct {
+	Name        string
+	Description string // From a comment next to or above the task, or the Taskfile's desc
+}
+
+// File is a task file at the project root and the tasks it defines
+type File struct {
+	Name   string // e.g. Makefile
+	Runner string // The program that runs its tasks, e.g. make
+	Tasks  []Task
+}
+
+// Command returns the command line that runs task
+func (f File) Command(task string) string {
+	return f.Runner + " " + task
+}
+
+// taskFiles are the file names each runner looks for, in the order it prefers them
+var taskFiles = []struct {
+	runner string
+	names  []string
+	parse  func([]byte) ([]Task, error)
+}{
+	{"make", []string{"GNUmakefile", "makefile", "Makefile"}, ParseMakefile},
+	{"task", []string{"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml", "Taskfile.dist.yml", "Taskfile.dist.yaml"}, ParseTaskfile},
+	{"just", []string{"justfile", "Justfile", ".justfile"}, ParseJustfile},
+}
+
+// FileNames returns the names of all task files Load looks for
+func FileNames() []string {
+	var names []string
+	for _, f := range taskFiles {
+		names = append(names, f.names...)
+	}
+	return names
+}
+
+// Load reads the task files at root, one per runner. Missing files are skipped;
+// unreadable or malformed ones are returned as errors alongside the files that could be read.
+func Load(root string) ([]File, error) {
+	var files []File
+	var errs []string
+	for _, tf := range taskFiles {
+		for _, name := range tf.names {
+			data, err := os.ReadFile(filepath.Join(root, name))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, err.Error())
+				break
+			}
+			tasks, err := tf.parse(data)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+				break
+			}
+			if len(tasks) > 0 {
+				files = append(files, File{Name: name, Runner: tf.runner, Tasks: tasks})
+			}
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return files, fmt.Errorf("failed to read task files: %s", strings.Join(errs, "; "))
+	}
+	return files, nil
+}
+
+// makeRule matches a rule line "targets: prerequisites", but not a ":=" or "::="
+// variable assignment
+var makeRule = regexp.MustCompile(`^([^\s:#=][^:#=]*?)\s*::?(?:[^=]|$)`)
+
+// makeTarget is a target name worth suggesting: not a special target such as .PHONY,
+// a pattern rule or a file path
+var makeTarget = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ParseMakefile returns the targets of a Makefile in the order they are defined. A
+// target's description is a "## ..." comment after its prerequisites or the comment
+// lines right above it.
+func ParseMakefile(data []byte) ([]Task, error) {
+	var tasks []Task
+	seen := map[string]bool{}
+	var comment []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if text, ok := strings.CutPrefix(line, "#"); ok {
+			comment = append(comment, strings.TrimSpace(strings.TrimLeft(text, "#")))
+			continue
+		}
+		above := strings.Join(comment, " ")
+		comment = nil
+		m := makeRule.FindStringSubmatch(line)
+		if m == nil || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		description := above
+		if _, after, ok := strings.Cut(line, "##"); ok {
+			description = strings.TrimSpace(after)
+		}
+		for _, name := range strings.Fields(m[1]) {
+			if makeTarget.MatchString(name) && !seen[name] {
+				seen[name] = true
+				tasks = append(tasks, Task{Name: name, Description: description})
+			}
+		}
+	}
+	return tasks, scanner.Err()
+}
+
+// ParseTaskfile returns the tasks of a Taskfile (taskfile.dev), sorted by name. Internal
+// tasks are left out.
+func ParseTaskfile(data []byte) ([]Task, error) {
+	var file struct {
+		Tasks map[string]yaml.Node `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	var tasks []Task
+	for name, node := range file.Tasks {
+		var task struct {
+			Desc     string `yaml:"desc"`
+			Summary  string `yaml:"summary"`
+			Internal bool   `yaml:"internal"`
+		}
+		// A task can also be a single command or a list of them
+		if node.Kind == yaml.MappingNode {
+			if err := node.Decode(&task); err != nil {
+				return nil, fmt.Errorf("task %s: %w", name, err)
+			}
+		}
+		if task.Internal {
+			continue
+		}
+		description := task.Desc
+		if description == "" {
+			description, _, _ = strings.Cut(strings.TrimSpace(task.Summary), "\n")
+		}
+		tasks = append(tasks, Task{Name: name, Description: description})
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	return tasks, nil
+}
+
+// justRecipe matches the first line of a justfile recipe, "name params: dependencies",
+// with an optional @ that silences it
+var justRecipe = regexp.MustCompile(`^@?([A-Za-z_][A-Za-z0-9_-]*)(?:\s+[^:]*)?:(?:[^=]|$)`)
+
+// ParseJustfile returns the recipes of a justfile in the order they are defined, with
+// the comment above each as its description. Private recipes, whose names start with an
+// underscore or that have the [private] attribute, are left out.
+func ParseJustfile(data []byte) ([]Task, error) {
+	var tasks []Task
+	var comment []string
+	private := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if text, ok := strings.CutPrefix(line, "#"); ok {
+			comment = append(comment, strings.TrimSpace(text))
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			// Attributes such as [private] apply to the recipe below them
+			private = private || strings.Contains(line, "private")
+			continue
+		}
+		above, hidden := strings.Join(comment, " "), private
+		comment, private = nil, false
+		m := justRecipe.FindStringSubmatch(line)
+		if m == nil || strings.HasPrefix(line, "set ") || strings.HasPrefix(line, "alias ") || strings.HasPrefix(line, "export ") {
+			continue
+		}
+		if hidden || strings.HasPrefix(m[1], "_") {
+			continue
+		}
+		tasks = append(tasks, Task{Name: m[1], Description: above})
+	}
+	return tasks, scanner.Err()
+}
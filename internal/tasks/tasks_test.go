@@ -0,0 +1,112 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMakefile(t *testing.T) {
+	makefile := `BIN := bin/app
+VERSION ?= dev
+.PHONY: build test
+
+# Build the binary
+build: $(BIN) ## Compile the app
+$(BIN): main.go
+	go build -o $@ .
+
+# Run the tests
+test lint:
+	go test ./...
+
+%.o: %.c
+	cc -c $<
+clean:
+	rm -rf bin
+`
+	tasks, err := ParseMakefile([]byte(makefile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Task{
+		{Name: "build", Description: "Compile the app"},
+		{Name: "test", Description: "Run the tests"},
+		{Name: "lint", Description: "Run the tests"},
+		{Name: "clean"},
+	}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Fatalf("expected %+v, got %+v", want, tasks)
+	}
+}
+
+func TestParseTaskfile(t *testing.T) {
+	taskfile := `version: '3'
+tasks:
+  test:
+    desc: Run the tests
+    cmds: [go test ./...]
+  fmt: gofmt -w .
+  setup:
+    internal: true
+    cmds: [go mod download]
+  release:
+    summary: |
+      Tag and publish a release.
+
+      Needs a clean tree.
+`
+	tasks, err := ParseTaskfile([]byte(taskfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Task{{Name: "fmt"}, {Name: "release", Description: "Tag and publish a release."}, {Name: "test", Description: "Run the tests"}}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Fatalf("expected %+v, got %+v", want, tasks)
+	}
+}
+
+func TestParseJustfile(t *testing.T) {
+	justfile := `set shell := ["bash", "-c"]
+version := "1.0"
+alias t := test
+
+# Run the tests
+test *args: build
+    go test {{args}} ./...
+
+@build:
+    go build .
+
+_helper:
+    echo hi
+
+[private]
+secret:
+    echo shh
+`
+	tasks, err := ParseJustfile([]byte(justfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Task{{Name: "test", Description: "Run the tests"}, {Name: "build"}}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Fatalf("expected %+v, got %+v", want, tasks)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "Makefile"), []byte("test:\n\tgo test ./...\n"), 0644)
+	os.WriteFile(filepath.Join(root, "justfile"), []byte("deploy:\n    ./deploy.sh\n"), 0644)
+	os.WriteFile(filepath.Join(root, "Taskfile.yml"), []byte("tasks: [broken"), 0644)
+
+	files, err := Load(root)
+	if err == nil {
+		t.Fatal("expected an error for the malformed Taskfile")
+	}
+	if len(files) != 2 || files[0].Command("test") != "make test" || files[1].Runner != "just" || files[1].Tasks[0].Name != "deploy" {
+		t.Fatalf("unexpected task files %+v", files)
+	}
+}
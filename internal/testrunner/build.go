@@ -0,0 +1,56 @@
+package testrunner
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// file:line[:col]: message, as printed by go, gcc, clang, javac, eslint and most others
+	buildLocationPattern = regexp.MustCompile(`^\s*(?:\./)?((?:[a-zA-Z]:)?[^\s:()"']+\.[a-zA-Z0-9]+):(\d+)(?::\d+)?:\s*(.*)$`)
+	// file(line,col): message, as printed by tsc and MSBuild
+	buildParenPattern = regexp.MustCompile(`^\s*([^\s(]+\.[a-zA-Z0-9]+)\((\d+),\d+\):\s*(.*)$`)
+	// --> file:line:col under a rustc "error[...]: message" line
+	rustLocationPattern = regexp.MustCompile(`^\s*--> ([^\s:]+):(\d+):\d+`)
+	rustErrorPattern    = regexp.MustCompile(`^error(?:\[\w+\])?: (.*)$`)
+)
+
+// ParseBuildErrors extracts file:line errors from compiler output, one per location
+func ParseBuildErrors(output string) []Failure {
+	var failures []Failure
+	seen := map[string]bool{}
+	add := func(file, lineText, message string) {
+		line, _ := strconv.Atoi(lineText)
+		key := file + ":" + lineText
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		failures = append(failures, Failure{File: file, Line: line, Message: strings.TrimSpace(message)})
+	}
+
+	rustMessage := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := rustErrorPattern.FindStringSubmatch(line); m != nil {
+			rustMessage = m[1]
+			continue
+		}
+		if m := rustLocationPattern.FindStringSubmatch(line); m != nil {
+			if rustMessage != "" {
+				add(m[1], m[2], rustMessage)
+				rustMessage = ""
+			}
+			continue
+		}
+		if m := buildParenPattern.FindStringSubmatch(line); m != nil {
+			add(m[1], m[2], m[3])
+			continue
+		}
+		if m := buildLocationPattern.FindStringSubmatch(line); m != nil {
+			add(m[1], m[2], m[3])
+		}
+	}
+	return failures
+}
@@ -0,0 +1,29 @@
+package testrunner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBuildErrors(t *testing.T) {
+	output := `# example.com/app
+./main.go:12:2: undefined: helper
+./main.go:12:9: too many errors
+src/util.c:3:10: error: 'x' undeclared (first use in this function)
+src/app.ts(7,5): error TS2322: Type 'string' is not assignable to type 'number'.
+error[E0425]: cannot find value ` + "`y`" + ` in this scope
+ --> src/main.rs:4:13
+  |
+make: *** [Makefile:3: build] Error 1
+`
+	got := ParseBuildErrors(output)
+	want := []Failure{
+		{File: "main.go", Line: 12, Message: "undefined: helper"},
+		{File: "src/util.c", Line: 3, Message: "error: 'x' undeclared (first use in this function)"},
+		{File: "src/app.ts", Line: 7, Message: "error TS2322: Type 'string' is not assignable to type 'number'."},
+		{File: "src/main.rs", Line: 4, Message: "cannot find value `y` in this scope"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseBuildErrors =\n%+v\nwant\n%+v", got, want)
+	}
+}
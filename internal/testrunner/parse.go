@@ -0,0 +1,131 @@
+package testrunner
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Failure is one problem reported by a test run. Any field but Message may be empty when
+// the output doesn't say.
+type Failure struct {
+	Test    string // Test name, e.g. TestParse or tests/test_a.py::test_parse
+	File    string // File as printed by the tool, often relative to the package directory
+	Line    int
+	Message string
+}
+
+var (
+	goRunPattern      = regexp.MustCompile(`^=== (?:RUN|CONT)\s+(\S+)`)
+	goFailPattern     = regexp.MustCompile(`^\s*--- FAIL: (\S+)`)
+	goLocationPattern = regexp.MustCompile(`^(\s*)((?:[a-zA-Z]:)?[a-zA-Z0-9_\-./\\]+\.go):(\d+)(?::\d+)?: ?(.*)$`)
+	goEndPattern      = regexp.MustCompile(`^(--- PASS|--- SKIP|ok\s|FAIL\s|PASS$|FAIL$|panic:)`)
+
+	pytestSummaryPattern  = regexp.MustCompile(`^(?:FAILED|ERROR) ([^\s:]+\.py)(?:::(\S+))?(?: - (.*))?$`)
+	pytestLocationPattern = regexp.MustCompile(`^([^\s:]+\.py):(\d+): (.*)$`)
+)
+
+// ParseFailures extracts failures from the output of go test or pytest
+func ParseFailures(output string) []Failure {
+	failures := ParseGo(output)
+	return append(failures, ParsePytest(output)...)
+}
+
+// ParseGo extracts failing tests and compile errors from go test (or go build and go vet)
+// output. Locations logged by a test are attributed to it, whether they come before its
+// --- FAIL line (go test -v) or after it.
+func ParseGo(output string) []Failure {
+	var failures []Failure
+	located := map[string]bool{} // Tests that already have a failure with a location
+	current := ""                // Test whose log lines follow
+	failed := ""                 // Failed test still waiting for a location
+	flush := func() {
+		if failed != "" && !located[failed] {
+			failures = append(failures, Failure{Test: failed, Message: "test failed"})
+		}
+		failed = ""
+	}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := goRunPattern.FindStringSubmatch(line); m != nil {
+			flush()
+			current = m[1]
+			continue
+		}
+		if m := goFailPattern.FindStringSubmatch(line); m != nil {
+			flush()
+			current, failed = m[1], m[1]
+			continue
+		}
+		if m := goLocationPattern.FindStringSubmatch(line); m != nil {
+			lineNo, _ := strconv.Atoi(m[3])
+			f := Failure{File: strings.TrimPrefix(m[2], "./"), Line: lineNo, Message: strings.TrimSpace(m[4])}
+			if m[1] != "" && current != "" {
+				f.Test = current
+				located[current] = true
+			}
+			failures = append(failures, f)
+			continue
+		}
+		if goEndPattern.MatchString(line) {
+			flush()
+			current = ""
+		}
+	}
+	flush()
+	return failures
+}
+
+// ParsePytest extracts failures from the short test summary of pytest and fills in the
+// line numbers from the tracebacks
+func ParsePytest(output string) []Failure {
+	var failures []Failure
+	lines := map[string]int{} // First traceback line seen per file
+	messages := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := pytestLocationPattern.FindStringSubmatch(line); m != nil {
+			if _, ok := lines[m[1]]; !ok {
+				lines[m[1]], _ = strconv.Atoi(m[2])
+				messages[m[1]] = m[3]
+			}
+			continue
+		}
+		if m := pytestSummaryPattern.FindStringSubmatch(line); m != nil {
+			f := Failure{File: m[1], Message: m[3]}
+			if m[2] != "" {
+				f.Test = m[1] + "::" + m[2]
+			}
+			failures = append(failures, f)
+		}
+	}
+	for i, f := range failures {
+		failures[i].Line = lines[f.File]
+		if failures[i].Message == "" {
+			failures[i].Message = messages[f.File]
+		}
+	}
+	return failures
+}
+
+// String formats the failure like "TestParse at parse_test.go:12: got 1, want 2"
+func (f Failure) String() string {
+	var b strings.Builder
+	b.WriteString(f.Test)
+	if f.File != "" {
+		if b.Len() > 0 {
+			b.WriteString(" at ")
+		}
+		b.WriteString(f.File)
+		if f.Line > 0 {
+			b.WriteString(":" + strconv.Itoa(f.Line))
+		}
+	}
+	if f.Message != "" {
+		if b.Len() > 0 {
+			b.WriteString(": ")
+		}
+		b.WriteString(f.Message)
+	}
+	return b.String()
+}
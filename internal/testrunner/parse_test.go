@@ -0,0 +1,69 @@
+package testrunner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGo(t *testing.T) {
+	output := `=== RUN   TestAdd
+    math_test.go:12: Add(1, 2) = 4, want 3
+--- FAIL: TestAdd (0.00s)
+=== RUN   TestSub
+--- PASS: TestSub (0.00s)
+=== RUN   TestPanics
+--- FAIL: TestPanics (0.00s)
+panic: boom [recovered]
+	/src/math.go:20 +0x1d
+FAIL
+FAIL	example.com/math	0.01s
+# example.com/other
+./other.go:3:2: undefined: missing
+`
+	got := ParseGo(output)
+	want := []Failure{
+		{Test: "TestAdd", File: "math_test.go", Line: 12, Message: "Add(1, 2) = 4, want 3"},
+		{Test: "TestPanics", Message: "test failed"},
+		{File: "other.go", Line: 3, Message: "undefined: missing"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseGo =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseGo_LogAfterFailLine(t *testing.T) {
+	output := "--- FAIL: TestAdd (0.00s)\n    math_test.go:12: wrong\nFAIL\n"
+	want := []Failure{{Test: "TestAdd", File: "math_test.go", Line: 12, Message: "wrong"}}
+	if got := ParseGo(output); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseGo = %+v, want %+v", got, want)
+	}
+}
+
+func TestParsePytest(t *testing.T) {
+	output := `____________________________ test_add ____________________________
+
+    def test_add():
+>       assert add(1, 2) == 4
+E       assert 3 == 4
+
+tests/test_math.py:5: AssertionError
+=========================== short test summary info ===========================
+FAILED tests/test_math.py::test_add - assert 3 == 4
+ERROR tests/test_io.py - ModuleNotFoundError: No module named 'iox'
+`
+	got := ParsePytest(output)
+	want := []Failure{
+		{Test: "tests/test_math.py::test_add", File: "tests/test_math.py", Line: 5, Message: "assert 3 == 4"},
+		{File: "tests/test_io.py", Message: "ModuleNotFoundError: No module named 'iox'"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParsePytest =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestFailureString(t *testing.T) {
+	f := Failure{Test: "TestAdd", File: "math_test.go", Line: 12, Message: "wrong"}
+	if got := f.String(); got != "TestAdd at math_test.go:12: wrong" {
+		t.Fatalf("String = %q", got)
+	}
+}
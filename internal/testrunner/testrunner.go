@@ -0,0 +1,80 @@
+// Package testrunner runs a project's test and build commands and extracts the failing
+// tests and error locations from their output.
+package testrunner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/shellcmd"
+)
+
+// Result is the outcome of one run of a test command
+type Result struct {
+	Command  string
+	Output   string // stdout and stderr, interleaved
+	Passed   bool
+	Duration time.Duration
+}
+
+// Run runs command through the shell in dir. A failing command is a Result with Passed
+// false; an error is only returned when the command could not be started.
+func Run(dir, command string) (Result, error) {
+	cmd := shellcmd.Command(command)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	err := cmd.Run()
+	result := Result{Command: command, Output: output.String(), Passed: err == nil, Duration: time.Since(start)}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return result, fmt.Errorf("failed to run %q: %w", command, err)
+	}
+	return result, nil
+}
+
+// DetectBuild guesses the build command from the files in root, or returns "" when it can't
+func DetectBuild(root string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+	switch {
+	case exists("go.mod"):
+		return "go build ./..."
+	case exists("Cargo.toml"):
+		return "cargo build"
+	case exists("package.json"):
+		return "npm run build"
+	case exists("Makefile"):
+		return "make"
+	}
+	return ""
+}
+
+// Detect guesses the test command from the files in root, or returns "" when it can't
+func Detect(root string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+	switch {
+	case exists("go.mod"):
+		return "go test ./..."
+	case exists("pytest.ini"), exists("conftest.py"), exists("pyproject.toml"), exists("setup.cfg"), exists("tox.ini"):
+		return "python -m pytest -q"
+	case exists("Cargo.toml"):
+		return "cargo test"
+	case exists("package.json"):
+		return "npm test"
+	}
+	return ""
+}
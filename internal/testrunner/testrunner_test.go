@@ -0,0 +1,39 @@
+package testrunner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	result, err := Run(dir, "echo out; echo err >&2; exit 3")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Passed || !strings.Contains(result.Output, "out") || !strings.Contains(result.Output, "err") {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result, err := Run(dir, "true"); err != nil || !result.Passed {
+		t.Fatalf("expected a passing run, got %+v, %v", result, err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	if got := Detect(dir); got != "" {
+		t.Fatalf("expected no command, got %q", got)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Detect(dir); got != "go test ./..." {
+		t.Fatalf("Detect = %q", got)
+	}
+}
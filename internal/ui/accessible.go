@@ -0,0 +1,34 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/llamasidekick/internal/renderer"
+)
+
+// runProgram runs a menu or picker on the alternate screen. In accessible mode it is
+// drawn inline on the terminal instead, without emoji or box drawing.
+func runProgram(m tea.Model) (tea.Model, error) {
+	flushOutput()
+	if !renderer.IsAccessible() {
+		return tea.NewProgram(m, tea.WithAltScreen()).Run()
+	}
+	final, err := tea.NewProgram(accessibleModel{m}, tea.WithOutput(terminal)).Run()
+	if a, ok := final.(accessibleModel); ok {
+		final = a.Model
+	}
+	return final, err
+}
+
+// accessibleModel replaces the symbols in the view of the model it wraps
+type accessibleModel struct {
+	tea.Model
+}
+
+func (a accessibleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := a.Model.Update(msg)
+	return accessibleModel{m}, cmd
+}
+
+func (a accessibleModel) View() string {
+	return renderer.ReplaceSymbols(a.Model.View())
+}
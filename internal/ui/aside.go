@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// runAsideCommand handles /aside <question>: Ask mode answers it with the conversation
+// and files so far, on a copy of the session, so neither the question nor the answer is
+// added to the conversation or saved
+func runAsideCommand(cfg *config.Config, client *ollama.Client, sess *session.Session, question string) error {
+	if question == "" {
+		return fmt.Errorf("usage: /aside <question>")
+	}
+	aside := *sess
+	aside.History = slices.Clone(sess.History)
+	aside.ActiveFiles = slices.Clone(sess.ActiveFiles)
+	aside.Changes = slices.Clone(sess.Changes)
+	aside.Ephemeral = true
+
+	if err := (&modes.AskMode{}).ProcessInput(client, &aside, cfg, question); err != nil {
+		return err
+	}
+	progress.Announce("Done")
+	if client.Reasoning != "" && cfg.UI.ShowThinking {
+		printReasoning(client.Reasoning)
+	}
+	fmt.Println("\033[38;5;240m(Aside: this question and its answer are not part of the conversation)\033[0m")
+	return nil
+}
@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/batch"
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// batchSessionName is the session that checkpoints of batch edits are kept in
+const batchSessionName = "batch"
+
+// BatchOptions controls a batch run
+type BatchOptions struct {
+	Restart bool   // Run every item again instead of skipping the ones that completed
+	Delay   string // Overrides the batch file's delay between items
+	Output  string // "text" or "json"
+}
+
+// BatchItemResult is the outcome of one batch item
+type BatchItemResult struct {
+	batch.Item
+	Status string         `json:"status"` // "succeeded", "failed" or "skipped"
+	Result *OneShotResult `json:"result,omitempty"`
+}
+
+// BatchResult is the summary printed by a batch run with --output json
+type BatchResult struct {
+	Items     []BatchItemResult `json:"items"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Skipped   int               `json:"skipped"`
+}
+
+// RunBatch runs the prompts of a batch file one after another. Completed items are
+// remembered, so running the same file again resumes after the last success.
+func RunBatch(cfg *config.Config, path string, opts BatchOptions) error {
+	if opts.Output == "" {
+		opts.Output = "text"
+	}
+	if opts.Output != "text" && opts.Output != "json" {
+		return fmt.Errorf("unknown output format %q (use text or json)", opts.Output)
+	}
+	file, err := batch.Load(path)
+	if err != nil {
+		return err
+	}
+	if opts.Delay != "" {
+		file.Delay = opts.Delay
+	}
+	delay, err := file.DelayDuration()
+	if err != nil {
+		return err
+	}
+	items := file.Items()
+	for _, item := range items {
+		if _, ok := modeForCommand(cfg, item.Mode).(processInputMode); !ok {
+			return fmt.Errorf("%s: unknown mode %q", item.Task, item.Mode)
+		}
+	}
+
+	progress, err := batch.LoadProgress(path)
+	if err != nil {
+		return err
+	}
+	if opts.Restart {
+		if err := progress.Reset(); err != nil {
+			return err
+		}
+	}
+
+	applyRenderStyle(cfg)
+	defer modes.CloseMCPServers()
+	stdout := os.Stdout
+	if opts.Output == "json" {
+		// Keep stdout clean for the JSON summary
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = stdout }()
+	}
+
+	working := loadWorkingSession()
+
+	var summary BatchResult
+	ran := 0
+	for i, item := range items {
+		label := fmt.Sprintf("[%d/%d] %s: %s", i+1, len(items), item.Mode, item.Prompt)
+		if progress.IsDone(item) {
+			fmt.Printf("\033[38;5;240m%s (already done, skipped)\033[0m\n", label)
+			summary.Items = append(summary.Items, BatchItemResult{Item: item, Status: "skipped"})
+			summary.Skipped++
+			continue
+		}
+		if ran > 0 && delay > 0 {
+			time.Sleep(delay)
+		}
+		ran++
+
+		fmt.Printf("\033[1m%s\033[0m\n", label)
+		result, runErr := runBatchItem(cfg, working, item)
+		if runErr != nil {
+			fmt.Printf("\033[38;5;9m✗ %s failed: %v\033[0m\n\n", item.Task, runErr)
+			summary.Items = append(summary.Items, BatchItemResult{Item: item, Status: "failed", Result: result})
+			summary.Failed++
+			if file.StopOnError {
+				break
+			}
+			continue
+		}
+		fmt.Printf("\033[1;32m✓ %s done\033[0m\n\n", item.Task)
+		summary.Items = append(summary.Items, BatchItemResult{Item: item, Status: "succeeded", Result: result})
+		summary.Succeeded++
+		if err := progress.MarkDone(item); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	fmt.Printf("Batch finished: \033[1;32m%d succeeded\033[0m, \033[38;5;9m%d failed\033[0m, %d skipped\n", summary.Succeeded, summary.Failed, summary.Skipped)
+	if summary.Failed > 0 {
+		fmt.Println("\033[38;5;240mRun the same batch again to retry the failed items\033[0m")
+	}
+
+	if opts.Output == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			return fmt.Errorf("failed to write JSON result: %w", err)
+		}
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d batch item(s) failed", summary.Failed, len(items))
+	}
+	return nil
+}
+
+// runBatchItem runs one item with a fresh client on a session of its own
+func runBatchItem(cfg *config.Config, working *session.Session, item batch.Item) (*OneShotResult, error) {
+	client, err := newModeClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sess := batchSession(working)
+	pim := modeForCommand(cfg, item.Mode).(processInputMode)
+	runErr := pim.ProcessInput(client, sess, cfg, item.Prompt)
+	result := buildOneShotResult(cfg, client, sess, item.Mode, runErr)
+	return &result, runErr
+}
+
+// batchSession returns the session a batch item runs on: the working session's project
+// and files without its history. It is never saved, so the project's conversation is left
+// alone even when the batch is interrupted, and its checkpoints are kept apart under the
+// name "batch".
+func batchSession(working *session.Session) *session.Session {
+	sess := session.New(working.ProjectRoot)
+	sess.Name = batchSessionName
+	sess.ActiveFiles = slices.Clone(working.ActiveFiles)
+	sess.Ephemeral = true
+	return sess
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+func TestBatchSession_LeavesTheConversationAlone(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	working := session.New(root)
+	working.AddFile("main.go")
+	working.AddMessage("user", "the conversation so far")
+	if err := working.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	sess := batchSession(working)
+	if len(sess.History) != 0 || len(sess.ActiveFiles) != 1 || sess.ProjectRoot != root {
+		t.Fatalf("expected the project and files without history, got %+v", sess)
+	}
+	sess.AddMessage("user", "batch item")
+	sess.AddFile("other.go")
+	if err := sess.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := session.Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.History) != 1 || saved.History[0].Content != "the conversation so far" {
+		t.Fatalf("expected the saved conversation to be unchanged, got %+v", saved.History)
+	}
+	if len(working.ActiveFiles) != 1 {
+		t.Fatalf("expected the working session's files to be unchanged, got %v", working.ActiveFiles)
+	}
+}
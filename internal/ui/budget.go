@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/yourusername/llamasidekick/internal/budget"
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+// runBudget tracks the usage of this run against budget.*; all clients share it, so the
+// run's totals cover every mode and the menu
+var (
+	runBudget   *budget.Tracker
+	runBudgetMu sync.Mutex
+)
+
+// attachBudget makes client check and charge the run's budget
+func attachBudget(cfg *config.Config, client *ollama.Client) {
+	runBudgetMu.Lock()
+	defer runBudgetMu.Unlock()
+	if runBudget == nil {
+		tracker, err := budget.Open(cfg.Budget)
+		if err != nil {
+			slog.Warn("budget tracking disabled", "error", err)
+			return
+		}
+		tracker.Warn = func(message string) {
+			fmt.Fprintf(os.Stderr, "\033[38;5;214m%s\033[0m\n", message)
+		}
+		runBudget = tracker
+	}
+	runBudget.SetLimits(cfg.Budget)
+	client.Budget = runBudget
+}
+
+// runBudgetCommand handles /budget, which shows the usage so far, and /budget override,
+// which lets requests continue past the limits for the rest of the run
+func runBudgetCommand(args string) error {
+	if runBudget == nil {
+		return fmt.Errorf("budget tracking is not available")
+	}
+	switch args {
+	case "":
+		for _, line := range runBudget.Summary() {
+			fmt.Printf("\033[38;5;240m%s\033[0m\n", line)
+		}
+	case "override":
+		runBudget.Override()
+		fmt.Println("\033[38;5;214mBudget limits lifted for the rest of this run\033[0m")
+	default:
+		return fmt.Errorf("usage: /budget [override]")
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/respcache"
+)
+
+// runCache is the response cache; all clients share it, so /cache reports on the whole run
+var (
+	runCache   *respcache.Store
+	runCacheMu sync.Mutex
+)
+
+// attachCache gives client the configured seed and context window, which are part of
+// what a response is cached by, and, unless cache.enabled is off, the response cache
+func attachCache(cfg *config.Config, client *ollama.Client) {
+	client.Seed = cfg.Ollama.Seed
+	client.NumCtx = cfg.Ollama.NumCtx
+	runCacheMu.Lock()
+	defer runCacheMu.Unlock()
+	if runCache == nil {
+		store, err := respcache.Open(cfg.Cache.MaxEntries)
+		if err != nil {
+			slog.Warn("response cache disabled", "error", err)
+			return
+		}
+		runCache = store
+	}
+	runCache.SetMaxEntries(cfg.Cache.MaxEntries)
+	if cfg.Cache.Enabled {
+		client.Cache = runCache
+	} else {
+		client.Cache = nil
+	}
+}
+
+// runCacheCommand handles /cache, which describes the response cache, and /cache clear,
+// which empties it
+func runCacheCommand(cfg *config.Config, args string) error {
+	if runCache == nil {
+		return fmt.Errorf("the response cache is not available")
+	}
+	switch args {
+	case "":
+		lines, err := runCache.Summary()
+		if err != nil {
+			return err
+		}
+		if !cfg.Cache.Enabled {
+			lines = append(lines, "The cache is off (cache.enabled: false)")
+		}
+		for _, line := range lines {
+			fmt.Printf("\033[38;5;240m%s\033[0m\n", line)
+		}
+	case "clear":
+		n, err := runCache.Clear()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\033[38;5;10mCleared %d cached response(s)\033[0m\n", n)
+	default:
+		return fmt.Errorf("usage: /cache [clear]")
+	}
+	return nil
+}
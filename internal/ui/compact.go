@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// runCompactCommand handles /compact: it replaces the conversation with a summary from
+// the Ask model plus the pinned messages, and reports how much smaller it got
+func runCompactCommand(cfg *config.Config, client *ollama.Client, sess *session.Session) error {
+	s := progress.NewStatus("Summarizing the conversation with " + cfg.GetModelForMode(modes.ModeAsk))
+	s.Start()
+	result, err := modes.CompactConversation(client, cfg, sess, func(string) { s.Token() })
+	if s.Active() {
+		s.Stop()
+	}
+	if err != nil {
+		return err
+	}
+	if err := sess.Save(); err != nil {
+		fmt.Printf("Warning: failed to save session: %v\n", err)
+	}
+
+	fmt.Printf("\033[38;5;240m%s\033[0m\n", sess.History[0].Content)
+	saved := result.TokensBefore - result.TokensAfter
+	fmt.Printf("\033[38;5;10m✓ Compacted %d message(s) into a summary", result.Summarized)
+	if result.Kept > 0 {
+		fmt.Printf(", kept %d pinned or private", result.Kept)
+	}
+	fmt.Printf(": ~%d → ~%d tokens", result.TokensBefore, result.TokensAfter)
+	if saved > 0 {
+		fmt.Printf(" (saved ~%d, %d%%)", saved, saved*100/result.TokensBefore)
+	}
+	fmt.Println("\033[0m")
+	return nil
+}
+
+// runPinCommand handles /pin: it pins the last exchange, so /compact keeps it word for word
+func runPinCommand(sess *session.Session) error {
+	n := sess.PinLastExchange()
+	if n == 0 {
+		return fmt.Errorf("there is nothing to pin yet")
+	}
+	if err := sess.Save(); err != nil {
+		fmt.Printf("Warning: failed to save session: %v\n", err)
+	}
+	fmt.Printf("\033[38;5;10m✓ Pinned the last %d message(s); /compact keeps them as they are\033[0m\n", n)
+	return nil
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/renderer"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// runCompareCommand handles /compare model1,model2 <question>: it asks every model the
+// question at once and prints the answers one after the other, each labelled with its
+// model, time and token count
+func runCompareCommand(cfg *config.Config, client *ollama.Client, sess *session.Session, args string) error {
+	list, question, _ := strings.Cut(args, " ")
+	question = strings.TrimSpace(question)
+	if list == "" || question == "" {
+		return fmt.Errorf("usage: /compare model1,model2 <question>")
+	}
+	models, err := modes.ParseCompareModels(list)
+	if err != nil {
+		return err
+	}
+
+	s := progress.NewStatus("Asking " + strings.Join(models, ", "))
+	s.Start()
+	results := modes.CompareModels(client, sess, cfg, models, question)
+	s.Stop()
+
+	for _, r := range results {
+		fmt.Printf("\n\033[1;38;5;75m── %s\033[0m \033[38;5;240m(%s", r.Model, r.Duration.Round(100*time.Millisecond))
+		if r.Stats.ResponseTokens > 0 {
+			fmt.Printf(", %d tokens", r.Stats.ResponseTokens)
+		}
+		fmt.Println(")\033[0m")
+		if r.Err != nil {
+			hint.Print(r.Err)
+			continue
+		}
+		fmt.Println(renderer.RenderMarkdown(r.Response))
+	}
+	fmt.Println()
+	return nil
+}
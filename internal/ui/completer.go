@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/completion"
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// autoCompleter provides tab completion: slash commands at the start of the line, model
+// names after /model and /compare, session names after /sessions, and elsewhere project
+// paths, bare or after @, with the files the session used recently first
+type autoCompleter struct {
+	cfg  *config.Config
+	sess *session.Session
+}
+
+func (a *autoCompleter) Do(line []rune, pos int) (newLine [][]rune, length int) {
+	before := string(line[:pos])
+	start := strings.LastIndexAny(before, " \t") + 1
+	word := before[start:]
+	if start == 0 {
+		if strings.HasPrefix(word, "/") {
+			return completions(slashCommands(a.cfg), word)
+		}
+		if !strings.HasPrefix(word, "@") {
+			return nil, 0
+		}
+	}
+
+	command, _, _ := strings.Cut(before, " ")
+	firstArgument := start > 0 && strings.TrimSpace(before[:start]) == command
+	switch {
+	case strings.HasPrefix(word, "@"):
+		var mentions []string
+		for _, name := range modes.ReferenceNames() {
+			mentions = append(mentions, "@"+name)
+		}
+		for _, p := range a.paths(word[1:]) {
+			mentions = append(mentions, "@"+p)
+		}
+		return completions(mentions, word)
+	case firstArgument && command == "/model":
+		return completions(append(completion.CachedModels(), "reset"), word)
+	case firstArgument && command == "/compare":
+		// A comma-separated list; only its last model is being typed
+		return completions(completion.CachedModels(), word[strings.LastIndex(word, ",")+1:])
+	case firstArgument && command == "/sessions":
+		return completions(a.sessionNames(), word)
+	case strings.HasPrefix(before, "/") && modeForCommand(a.cfg, command) == nil:
+		// Other commands take patterns, names or numbers rather than paths
+		return nil, 0
+	}
+	return completions(a.paths(word), word)
+}
+
+// paths returns the project paths that complete prefix, the recently used files first
+func (a *autoCompleter) paths(prefix string) []string {
+	var paths []string
+	for _, f := range modes.RecentFiles(a.sess) {
+		if strings.HasPrefix(f, prefix) {
+			paths = append(paths, f)
+		}
+	}
+	for _, p := range modes.CompletePath(a.sess.ProjectRoot, prefix, a.cfg.Context.Ignore) {
+		if !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// sessionNames returns the names of the project's saved sessions, most recently used first
+func (a *autoCompleter) sessionNames() []string {
+	summaries, err := session.List(a.sess.ProjectRoot)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, s := range summaries {
+		if s.Name != "" {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// completions returns the rest of every candidate that starts with word, as readline
+// expects them
+func completions(candidates []string, word string) ([][]rune, int) {
+	var suggestions [][]rune
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) && c != word {
+			suggestions = append(suggestions, []rune(c[len(word):]))
+		}
+	}
+	return suggestions, len([]rune(word))
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+// runOverrides are the overrides from this run's flags, set with UseOverrides
+var runOverrides config.Overrides
+
+// UseOverrides makes every reload of the config apply the flags' overrides again, so e.g.
+// --read-only lasts for the whole run
+func UseOverrides(o config.Overrides) {
+	runOverrides = o
+}
+
+// reloadConfig loads the config from disk again, with this run's overrides applied
+func reloadConfig() (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ApplyOverrides(runOverrides); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// RunConfigEdit opens config.yaml in the user's editor, validates the result and returns
+// the reloaded config, with this run's overrides applied. If the edited file is invalid
+// the user can re-open the editor or discard the changes, which restores the previous
+// file. cfg is nil when the current file doesn't load, so that it can be fixed.
+func RunConfigEdit(cfg *config.Config) (*config.Config, error) {
+	configPath, err := config.ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure there is a file to edit
+	if _, err := os.Stat(configPath); os.IsNotExist(err) && cfg != nil {
+		if err := cfg.Save(); err != nil {
+			return nil, fmt.Errorf("failed to create config file: %w", err)
+		}
+	}
+
+	original, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		if err := config.OpenInEditor(configPath); err != nil {
+			return nil, err
+		}
+
+		newCfg, err := reloadConfig()
+		if err == nil {
+			err = newCfg.Validate()
+		}
+		if err == nil {
+			for _, key := range config.UnknownKeys() {
+				fmt.Printf("\033[38;5;214mWarning: unknown config key %q (check for typos)\033[0m\n", key)
+			}
+			return newCfg, nil
+		}
+
+		fmt.Printf("\033[38;5;9m%v\033[0m\n", err)
+		fmt.Print("Re-open the editor to fix it? [Y/n] ")
+		answer, readErr := reader.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if readErr != nil || answer == "n" || answer == "no" {
+			if err := os.WriteFile(configPath, original, 0644); err != nil {
+				return nil, fmt.Errorf("failed to restore previous config: %w", err)
+			}
+			if cfg == nil {
+				return nil, fmt.Errorf("config changes discarded, the previous config still doesn't load")
+			}
+			if _, err := config.Load(); err != nil {
+				return nil, fmt.Errorf("failed to reload previous config: %w", err)
+			}
+			return nil, fmt.Errorf("config changes discarded")
+		}
+	}
+}
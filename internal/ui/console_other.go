@@ -0,0 +1,9 @@
+//go:build !windows
+
+package ui
+
+// EnableVirtualTerminal reports whether the terminal can show colors. Terminals outside
+// Windows process ANSI escape sequences already.
+func EnableVirtualTerminal() bool {
+	return true
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package ui
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// EnableVirtualTerminal turns on ANSI escape sequence processing in the Windows console
+// for stdout and stderr, which older consoles leave off. It reports whether the console
+// can show colors; if not, output should be plain.
+func EnableVirtualTerminal() bool {
+	ok := true
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		handle := windows.Handle(f.Fd())
+		var mode uint32
+		if err := windows.GetConsoleMode(handle, &mode); err != nil {
+			continue // Not a console, e.g. redirected
+		}
+		if err := windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+			ok = false
+		}
+	}
+	return ok
+}
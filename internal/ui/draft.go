@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+// draftCutLine separates the prompt from the help text in the /e scratch file
+const draftCutLine = "# ------------------------ >8 ------------------------"
+
+const draftHelp = `# Write your prompt above the line; everything from the line down is ignored.
+# Start with a command such as /edit or /ask to pick the mode, or leave it out
+# for the default mode. Save and quit to send the prompt; an empty one cancels.
+`
+
+// editDraft opens draft in the user's editor, like git commit does, and returns the
+// prompt they saved. An empty result means the prompt was cancelled.
+func editDraft(draft string) (string, error) {
+	f, err := os.CreateTemp("", "llamasidekick-prompt-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create the prompt file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(draft + "\n\n" + draftCutLine + "\n" + draftHelp)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write the prompt file: %w", err)
+	}
+
+	if err := config.OpenInEditor(f.Name()); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read the prompt file: %w", err)
+	}
+	text := string(data)
+	if i := strings.Index(text, draftCutLine); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// draftInput turns a prompt written with /e into prompt input: a slash command on the
+// first line is joined with the rest, so "/edit main.go" above a multi-line request
+// runs that request in Edit mode
+func draftInput(draft string) string {
+	first, rest, found := strings.Cut(draft, "\n")
+	if !found || !strings.HasPrefix(first, "/") {
+		return draft
+	}
+	return strings.TrimSpace(first) + " " + strings.TrimSpace(rest)
+}
@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/eval"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// EvalOptions controls an eval run
+type EvalOptions struct {
+	Mode   string // Run only the cases of this mode
+	Output string // "text" or "json"
+}
+
+// EvalCaseResult is the outcome of one eval case
+type EvalCaseResult struct {
+	Name     string   `json:"name"`
+	Mode     string   `json:"mode"`
+	Model    string   `json:"model"`
+	Passed   bool     `json:"passed"`
+	Failures []string `json:"failures"`
+	Response string   `json:"response"`
+	Seconds  float64  `json:"seconds"`
+}
+
+// EvalResult is the summary printed by an eval run with --output json
+type EvalResult struct {
+	Cases  []EvalCaseResult `json:"cases"`
+	Passed int              `json:"passed"`
+	Failed int              `json:"failed"`
+}
+
+// RunEval runs the cases of an eval suite, or of the built-in one when path is empty,
+// and reports which of their assertions the configured models fail. Every case runs in
+// a throwaway project, so nothing is written to the current one.
+func RunEval(cfg *config.Config, path string, opts EvalOptions) error {
+	if opts.Output == "" {
+		opts.Output = "text"
+	}
+	if opts.Output != "text" && opts.Output != "json" {
+		return fmt.Errorf("unknown output format %q (use text or json)", opts.Output)
+	}
+	if cfg.Edits.ReadOnly {
+		return fmt.Errorf("eval writes the model's edits to throwaway projects, which read-only mode doesn't allow (run it without --read-only and edits.read_only)")
+	}
+	var suite *eval.Suite
+	var err error
+	if path == "" {
+		suite, err = eval.Builtin()
+	} else {
+		suite, err = eval.Load(path)
+	}
+	if err != nil {
+		return err
+	}
+	var cases []eval.Case
+	for _, c := range suite.Cases {
+		if opts.Mode != "" && c.Mode != opts.Mode {
+			continue
+		}
+		if _, ok := modeForCommand(cfg, c.Mode).(processInputMode); !ok {
+			return fmt.Errorf("%s: unknown mode %q", c.Name, c.Mode)
+		}
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		return fmt.Errorf("the eval suite has no cases for mode %q", opts.Mode)
+	}
+
+	applyRenderStyle(cfg)
+	defer modes.CloseMCPServers()
+	stdout := os.Stdout
+	if opts.Output == "json" {
+		// Keep stdout clean for the JSON summary
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = stdout }()
+	}
+	// Cases run unattended: nothing is asked, and edits are written to the throwaway project
+	if devNull, err := os.Open(os.DevNull); err == nil {
+		stdin := os.Stdin
+		os.Stdin = devNull
+		defer func() {
+			os.Stdin = stdin
+			devNull.Close()
+		}()
+	}
+	cfg = evalConfig(cfg)
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	defer os.Chdir(cwd)
+
+	var summary EvalResult
+	perMode := map[string][2]int{}
+	var modeOrder []string
+	for i, c := range cases {
+		fmt.Printf("\033[1m[%d/%d] %s: %s\033[0m\n", i+1, len(cases), c.Mode, c.Name)
+		result := runEvalCase(cfg, c)
+		if result.Passed {
+			fmt.Printf("\033[1;32m✓ %s passed\033[0m \033[38;5;240m(%.1fs)\033[0m\n\n", c.Name, result.Seconds)
+			summary.Passed++
+		} else {
+			fmt.Printf("\033[38;5;9m✗ %s failed\033[0m \033[38;5;240m(%.1fs)\033[0m\n", c.Name, result.Seconds)
+			for _, f := range result.Failures {
+				fmt.Printf("  \033[38;5;9m- %s\033[0m\n", f)
+			}
+			fmt.Println()
+			summary.Failed++
+		}
+		summary.Cases = append(summary.Cases, result)
+
+		counts, seen := perMode[c.Mode]
+		if !seen {
+			modeOrder = append(modeOrder, c.Mode)
+		}
+		counts[1]++
+		if result.Passed {
+			counts[0]++
+		}
+		perMode[c.Mode] = counts
+	}
+
+	fmt.Println("\033[1mEval results\033[0m")
+	for _, mode := range modeOrder {
+		counts := perMode[mode]
+		color := "\033[1;32m"
+		if counts[0] < counts[1] {
+			color = "\033[38;5;9m"
+		}
+		fmt.Printf("  %-8s %s%d/%d passed\033[0m \033[38;5;240m%s\033[0m\n", mode, color, counts[0], counts[1], cfg.GetModelForMode(mode))
+	}
+
+	if opts.Output == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			return fmt.Errorf("failed to write JSON result: %w", err)
+		}
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d eval case(s) failed", summary.Failed, len(cases))
+	}
+	return nil
+}
+
+// evalConfig returns a copy of cfg for running cases: edits are written to the throwaway
+// project, but nothing configured for real projects runs, so no hooks, MCP servers,
+// formatters, linters or git commits
+func evalConfig(cfg *config.Config) *config.Config {
+	copied := *cfg
+	copied.Edits.DryRun = false
+	copied.Edits.AutoCommit = false
+	copied.Hooks = config.HooksConfig{}
+	copied.MCP.Servers = nil
+	copied.Format.Enabled = false
+	return &copied
+}
+
+// runEvalCase runs one case in a new project holding its files, with a fresh session and
+// without the response cache, and checks the outcome
+func runEvalCase(cfg *config.Config, c eval.Case) EvalCaseResult {
+	result := EvalCaseResult{Name: c.Name, Mode: c.Mode, Model: cfg.GetModelForMode(c.Mode), Failures: []string{}}
+	fail := func(err error) EvalCaseResult {
+		result.Failures = append(result.Failures, err.Error())
+		return result
+	}
+
+	root, err := os.MkdirTemp("", "llamasidekick-eval-")
+	if err != nil {
+		return fail(fmt.Errorf("failed to create project directory: %w", err))
+	}
+	defer os.RemoveAll(root)
+	if err := c.WriteFiles(root); err != nil {
+		return fail(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		return fail(fmt.Errorf("failed to enter project directory: %w", err))
+	}
+
+	client, err := newModeClient(cfg)
+	if err != nil {
+		return fail(err)
+	}
+	// A cached answer would say nothing about the model
+	client.Cache = nil
+	sess := session.New(root)
+	sess.Ephemeral = true
+	sess.ApproveChanges = func(string) bool { return true }
+
+	start := time.Now()
+	pim := modeForCommand(cfg, c.Mode).(processInputMode)
+	runErr := pim.ProcessInput(client, sess, cfg, c.Prompt)
+	result.Seconds = time.Since(start).Seconds()
+
+	oneShot := buildOneShotResult(cfg, client, sess, c.Mode, runErr)
+	result.Response = oneShot.Response
+	if runErr != nil {
+		// The first line is enough; edit mode adds the whole response after it
+		message, _, _ := strings.Cut(runErr.Error(), "\n")
+		return fail(fmt.Errorf("the mode failed: %s", message))
+	}
+	out := eval.Outcome{Response: oneShot.Response, Commands: oneShot.Commands, Root: root}
+	for _, change := range sess.Changes {
+		out.Changed = append(out.Changed, change.Path)
+	}
+	result.Failures = append(result.Failures, c.Failures(out)...)
+	result.Passed = len(result.Failures) == 0
+	return result
+}
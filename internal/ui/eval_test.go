@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+func TestRunEval_RefusesReadOnly(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Edits.ReadOnly = true
+	if err := RunEval(cfg, "", EvalOptions{}); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Fatalf("expected eval to refuse read-only mode, got %v", err)
+	}
+	if !cfg.Edits.ReadOnly {
+		t.Fatal("expected read-only mode to stay on")
+	}
+}
+
+func TestEvalConfig_TurnsOffWhatRunsOnRealProjects(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Edits.DryRun = true
+	cfg.Edits.AutoCommit = true
+	cfg.Hooks.PreEdit = []string{"make lint"}
+	cfg.MCP.Servers = map[string]config.MCPServerConfig{"fs": {Command: "mcp-fs"}}
+	cfg.Format.Enabled = true
+
+	got := evalConfig(cfg)
+	if got.Edits.DryRun || got.Edits.AutoCommit || len(got.Hooks.PreEdit) > 0 || len(got.MCPServerNames()) > 0 || got.Format.Enabled {
+		t.Fatalf("expected edits to be written with hooks, MCP, formatters and commits off, got %+v", got)
+	}
+	if !cfg.Edits.DryRun || len(cfg.Hooks.PreEdit) != 1 || len(cfg.MCP.Servers) != 1 || !cfg.Format.Enabled {
+		t.Fatal("expected the user's config to be left unchanged")
+	}
+}
@@ -135,8 +135,7 @@ func (m firstRunModel) View() string {
 
 // RunFirstRun shows the first-run model selection and returns the selected model
 func RunFirstRun(client *ollama.Client, cfg *config.Config) (string, error) {
-	p := tea.NewProgram(newFirstRunModel(client, cfg), tea.WithAltScreen())
-	m, err := p.Run()
+	m, err := runProgram(newFirstRunModel(client, cfg))
 	if err != nil {
 		return "", err
 	}
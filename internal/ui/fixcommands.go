@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/projects"
+	"github.com/yourusername/llamasidekick/internal/session"
+	"github.com/yourusername/llamasidekick/internal/testrunner"
+)
+
+// projectCommand describes how a slash command finds the project command it runs
+type projectCommand struct {
+	kind       string // "test" or "build", the config section
+	slash      string // The slash command, for hints
+	configured string // The <kind>.command setting
+	remembered func(projects.Project) string
+	remember   func(reg *projects.Registry, root, command string)
+	detect     func(root string) string
+}
+
+// resolve picks the command to run for root: args (which is then remembered for the
+// project), the one remembered earlier, the configured one, or one detected from the files
+func (pc projectCommand) resolve(root, args string) (string, error) {
+	reg, err := projects.Load()
+	if err != nil {
+		return "", err
+	}
+	if args != "" {
+		pc.remember(reg, root, args)
+		if err := reg.Save(); err != nil {
+			fmt.Printf("\033[38;5;214mWarning: failed to remember the %s command: %v\033[0m\n", pc.kind, err)
+		}
+		return args, nil
+	}
+	if p, ok := reg.Find(root); ok && pc.remembered(p) != "" {
+		return pc.remembered(p), nil
+	}
+	if pc.configured != "" {
+		return pc.configured, nil
+	}
+	if command := pc.detect(root); command != "" {
+		return command, nil
+	}
+	return "", fmt.Errorf("no %s command for this project; set %s.command or run %s <command>", pc.kind, pc.kind, pc.slash)
+}
+
+// runFixTestsCommand handles /fix-tests [command]. A command given here is remembered as
+// the project's test command; otherwise the remembered one, test.command or a command
+// detected from the project files is used.
+func runFixTestsCommand(cfg *config.Config, client *ollama.Client, sess *session.Session, args string) error {
+	command, err := projectCommand{
+		kind:       "test",
+		slash:      "/fix-tests",
+		configured: cfg.Test.Command,
+		remembered: func(p projects.Project) string { return p.TestCommand },
+		remember:   (*projects.Registry).SetTestCommand,
+		detect:     testrunner.Detect,
+	}.resolve(sess.ProjectRoot, args)
+	if err != nil {
+		return err
+	}
+	return modes.FixTests(client, sess, cfg, command)
+}
+
+// runBuildCommand handles /build [command], choosing the command like /fix-tests does
+func runBuildCommand(cfg *config.Config, client *ollama.Client, sess *session.Session, args string) error {
+	command, err := projectCommand{
+		kind:       "build",
+		slash:      "/build",
+		configured: cfg.Build.Command,
+		remembered: func(p projects.Project) string { return p.BuildCommand },
+		remember:   (*projects.Registry).SetBuildCommand,
+		detect:     testrunner.DetectBuild,
+	}.resolve(sess.ProjectRoot, args)
+	if err != nil {
+		return err
+	}
+	return modes.FixBuild(client, sess, cfg, command)
+}
@@ -0,0 +1,285 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/clip"
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/session"
+	"github.com/yourusername/llamasidekick/internal/shellcmd"
+)
+
+// maxRunOutput bounds how much of a /run command's output is added to the conversation
+const maxRunOutput = 8000
+
+// followUpCommands are the commands that act on the last answer
+var followUpCommands = []string{"/apply", "/copy", "/run", "/more", "/regen"}
+
+// followUpLabels describe the follow-up commands in the hint shown after an answer
+var followUpLabels = map[string]string{
+	"/apply": "/apply as edit",
+	"/copy":  "/copy code",
+	"/run":   "/run command",
+	"/more":  "/more detail",
+	"/regen": "/regen",
+}
+
+// lastTurn is the most recent answer, which the follow-up commands act on
+type lastTurn struct {
+	mode      string
+	cfg       *config.Config
+	input     string
+	response  string
+	reasoning string // What a reasoning model thought before the answer, shown with /think
+}
+
+// runModeInput runs input in mode and returns the answer it gave, or nil if it failed
+// or gave none. With ui.follow_ups on the commands that apply to the answer are listed.
+func runModeInput(mode modes.Mode, key string, client *ollama.Client, sess *session.Session, cfg *config.Config, input string) *lastTurn {
+	before := len(sess.History)
+	var err error
+	if pim, ok := mode.(processInputMode); ok {
+		err = pim.ProcessInput(client, sess, cfg, input)
+	} else {
+		err = executeQuickCommand(mode, key, client, sess, cfg, input)
+	}
+	if err != nil {
+		hint.Print(err)
+		return nil
+	}
+	progress.Announce("Done")
+	if len(sess.History) <= before || sess.History[len(sess.History)-1].Role != "assistant" {
+		return nil
+	}
+	turn := &lastTurn{mode: key, cfg: cfg, input: input, response: sess.History[len(sess.History)-1].Content, reasoning: client.Reasoning}
+	if turn.reasoning != "" {
+		if cfg.UI.ShowThinking {
+			printReasoning(turn.reasoning)
+		} else {
+			fmt.Printf("\033[38;5;240m▸ Thought for %d words (/think to show)\033[0m\n", len(strings.Fields(turn.reasoning)))
+		}
+	}
+	if limit := modes.ResponseLimit(cfg, key); client.CutOff && limit > 0 {
+		longer := fmt.Sprintf("raise ollama.num_predict.%s for longer answers", key)
+		if cfg.UI.Brief && !modes.WritesFiles(cfg, key) {
+			longer = "/brief off for longer answers"
+		}
+		fmt.Printf("\033[38;5;214m▸ Cut off at %d tokens (/more for detail, %s)\033[0m\n", limit, longer)
+	}
+	if cfg.UI.CheckRefs && key != modes.ModeCmd {
+		for _, ref := range modes.CheckReferences(sess, cfg, turn.response) {
+			fmt.Printf("\033[38;5;214m⚠ `%s` not found in the project\033[0m\n", ref)
+		}
+	}
+	if cfg.UI.FollowUps {
+		var labels []string
+		for _, action := range modes.FollowUps(cfg, key, turn.response) {
+			labels = append(labels, followUpLabels[action])
+		}
+		fmt.Println("\033[38;5;240mNext: " + strings.Join(labels, " · ") + "\033[0m")
+	}
+	return turn
+}
+
+// printReasoning prints what a reasoning model thought before its answer, set apart from it
+func printReasoning(reasoning string) {
+	fmt.Println("\033[38;5;240m▾ Reasoning\033[0m")
+	for _, line := range strings.Split(reasoning, "\n") {
+		fmt.Printf("\033[38;5;240m│ %s\033[0m\n", line)
+	}
+}
+
+// runThinkCommand handles /think [on|off]: without an argument it shows what the model
+// thought before the last answer; on and off set whether that is shown after every answer
+func runThinkCommand(cfg *config.Config, last *lastTurn, args string) error {
+	switch args {
+	case "":
+		if last == nil || last.reasoning == "" {
+			fmt.Println("\033[38;5;240mThe last answer came without reasoning (only reasoning models such as deepseek-r1 think first)\033[0m")
+			return nil
+		}
+		printReasoning(last.reasoning)
+	case "on":
+		cfg.UI.ShowThinking = true
+		fmt.Println("\033[38;5;10mReasoning ON - shown after every answer\033[0m")
+	case "off":
+		cfg.UI.ShowThinking = false
+		fmt.Println("\033[38;5;10mReasoning OFF - folded away; /think shows it\033[0m")
+	default:
+		return fmt.Errorf("usage: /think [on|off]")
+	}
+	return nil
+}
+
+// isFollowUpCommand reports whether input is one of the follow-up commands
+func isFollowUpCommand(input string) bool {
+	command, _, _ := strings.Cut(input, " ")
+	for _, c := range followUpCommands {
+		if command == c {
+			return true
+		}
+	}
+	return false
+}
+
+// runFollowUp runs a follow-up command on the last answer and returns the new answer if
+// the command asked the model for one
+func runFollowUp(cfg *config.Config, client *ollama.Client, sess *session.Session, last *lastTurn, input string) (*lastTurn, error) {
+	command, args, _ := strings.Cut(input, " ")
+	args = strings.TrimSpace(args)
+	if command == "/copy" && args == "retry" {
+		// Copying again doesn't need an answer: it may be a command Cmd mode failed to copy
+		method, err := clip.Retry()
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy to clipboard: %w", err)
+		}
+		fmt.Printf("\033[1;32m✓ Copied to clipboard\033[0m\033[38;5;240m%s\033[0m\n", method.Describe())
+		return nil, nil
+	}
+	if last == nil {
+		return nil, fmt.Errorf("there is no answer to follow up on yet")
+	}
+	// A follow-up is a side step: input without a slash command keeps going to the mode it went to before
+	mode, lastMode := sess.Mode, sess.LastMode
+	defer func() { sess.Mode, sess.LastMode = mode, lastMode }()
+
+	switch command {
+	case "/apply":
+		instruction, err := modes.ApplyInput(last.response, args)
+		if err != nil {
+			return nil, err
+		}
+		return runModeInput(&modes.EditMode{}, modes.ModeEdit, client, sess, cfg, instruction), nil
+	case "/copy":
+		var blocks []string
+		for _, b := range modes.CodeBlocks(last.response) {
+			blocks = append(blocks, b.Code)
+		}
+		if len(blocks) == 0 {
+			return nil, fmt.Errorf("the last answer has no code blocks")
+		}
+		chosen, label, err := pickFollowUp(blocks, args, "code block")
+		if err != nil {
+			return nil, err
+		}
+		if err := modes.CopyText(chosen, fmt.Sprintf("Copied %s to clipboard", label)); err != nil {
+			return nil, fmt.Errorf("failed to copy to clipboard: %w", err)
+		}
+		return nil, nil
+	case "/run":
+		return nil, runFollowUpCommand(cfg, sess, last, args)
+	case "/more":
+		question := args
+		if question == "" {
+			question = "Explain your previous answer in more detail."
+		}
+		// Asking for more is asking for a longer answer than /brief allows
+		brief := cfg.UI.Brief
+		cfg.UI.Brief = false
+		defer func() { cfg.UI.Brief = brief }()
+		return runModeInput(&modes.AskMode{}, modes.ModeAsk, client, sess, cfg, question), nil
+	case "/regen":
+		if modes.WritesFiles(last.cfg, last.mode) {
+			return nil, fmt.Errorf("/regen only repeats answers that didn't change files; use /restore to undo an edit")
+		}
+		n := len(sess.History)
+		if n < 2 || sess.History[n-1].Content != last.response || sess.History[n-2].Content != last.input {
+			return nil, fmt.Errorf("the conversation has moved on since the last answer")
+		}
+		mode := modeForCommand(last.cfg, last.mode)
+		if mode == nil {
+			return nil, fmt.Errorf("mode %q is no longer configured", last.mode)
+		}
+		// Replace the answer rather than adding a second one to the conversation, and
+		// the cached response along with it
+		sess.History = sess.History[:n-2]
+		client.Refresh = true
+		defer func() { client.Refresh = false }()
+		return runModeInput(mode, last.mode, client, sess, last.cfg, last.input), nil
+	}
+	return nil, fmt.Errorf("unknown follow-up command %s", command)
+}
+
+// runFollowUpCommand runs a shell command from the last answer once the user accepts it,
+// and adds its output to the conversation so the next question can refer to it
+func runFollowUpCommand(cfg *config.Config, sess *session.Session, last *lastTurn, args string) error {
+	if cfg.Edits.ReadOnly {
+		return fmt.Errorf("read-only mode is on, so commands are not run")
+	}
+	commands := modes.ShellCommands(last.mode, last.response)
+	if len(commands) == 0 {
+		return fmt.Errorf("the last answer has no shell commands")
+	}
+	if len(commands) > 1 && args == "" {
+		fmt.Println("\033[38;5;240mThe last answer has several commands:\033[0m")
+		for i, c := range commands {
+			fmt.Printf("  %d. %s\n", i+1, strings.ReplaceAll(c, "\n", "\n     "))
+		}
+		fmt.Println("\033[38;5;240mRun one with /run <number>\033[0m")
+		return nil
+	}
+	command, _, err := pickFollowUp(commands, args, "command")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n\033[1;33m%s\033[0m\n", command)
+	fmt.Printf("Run this in %s? [y/N] ", sess.ProjectRoot)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		fmt.Println("\033[38;5;240mNot run\033[0m")
+		return nil
+	}
+
+	var output bytes.Buffer
+	cmd := shellcmd.Suggested(command)
+	cmd.Dir = sess.ProjectRoot
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &output)
+	runErr := cmd.Run()
+	status := "succeeded"
+	if runErr != nil {
+		status = fmt.Sprintf("failed (%v)", runErr)
+		fmt.Printf("\033[38;5;9mCommand %s\033[0m\n", status)
+	} else {
+		fmt.Println("\033[1;32m✓ Command succeeded\033[0m")
+	}
+
+	text := output.String()
+	if len(text) > maxRunOutput {
+		text = "...\n" + text[len(text)-maxRunOutput:]
+	}
+	sess.AddMessage("user", fmt.Sprintf("I ran:\n```\n%s\n```\nIt %s with this output:\n```\n%s\n```", command, status, strings.TrimRight(text, "\n")))
+	if err := sess.Save(); err != nil {
+		fmt.Printf("\033[38;5;240mWarning: failed to save session: %v\033[0m\n", err)
+	}
+	fmt.Println("\033[38;5;240m(The output was added to the conversation, ask about it next)\033[0m")
+	return nil
+}
+
+// pickFollowUp returns the item numbered by args (from 1), or all of items joined when
+// args is empty, with a label describing the choice
+func pickFollowUp(items []string, args, noun string) (string, string, error) {
+	if args == "" {
+		if len(items) == 1 {
+			return items[0], "the " + noun, nil
+		}
+		return strings.Join(items, "\n\n"), fmt.Sprintf("%d %ss", len(items), noun), nil
+	}
+	n, err := strconv.Atoi(args)
+	if err != nil || n < 1 || n > len(items) {
+		return "", "", fmt.Errorf("choose a %s from 1 to %d", noun, len(items))
+	}
+	return items[n-1], fmt.Sprintf("%s %d", noun, n), nil
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/grep"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// runGrepCommand handles /grep [-i] <pattern>: it prints the project lines matching the
+// regular expression with the lines around them
+func runGrepCommand(cfg *config.Config, sess *session.Session, args string) error {
+	ignoreCase := false
+	if rest, ok := strings.CutPrefix(args, "-i "); ok {
+		ignoreCase, args = true, strings.TrimSpace(rest)
+	}
+	if args == "" {
+		return fmt.Errorf("usage: /grep [-i] <pattern>")
+	}
+	matches, err := modes.GrepProject(sess.ProjectRoot, args, ignoreCase, cfg)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Println("\033[38;5;240mNo matches\033[0m")
+		return nil
+	}
+	printGrepMatches(matches)
+	return nil
+}
+
+// matchLinePattern matches the matching lines of grep.Format output, e.g. "12:"
+var matchLinePattern = regexp.MustCompile(`^\d+:`)
+
+// printGrepMatches prints grep.Format output with file names highlighted and context
+// lines dimmed
+func printGrepMatches(matches []grep.Match) {
+	files := map[string]bool{}
+	for _, m := range matches {
+		files[m.File] = true
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(grep.Format(matches), "\n"), "\n") {
+		switch {
+		case files[line]:
+			fmt.Printf("\033[1;38;5;75m%s\033[0m\n", line)
+		case matchLinePattern.MatchString(line):
+			fmt.Println(line)
+		default:
+			fmt.Printf("\033[38;5;240m%s\033[0m\n", line)
+		}
+	}
+	fmt.Printf("\033[38;5;240m%d match(es) in %d file(s)\033[0m\n", len(matches), len(files))
+}
+
+// printRetrieval handles /why: it shows which index chunks were added to the last prompt
+// and which techniques ranked them
+func printRetrieval() {
+	query, chunks := modes.LastRetrieval()
+	if len(chunks) == 0 {
+		fmt.Println("\033[38;5;240mNo index chunks were added to a prompt yet\033[0m")
+		return
+	}
+	fmt.Printf("\033[1;38;5;75mChunks retrieved for:\033[0m %s\n", recapLine(query))
+	fmt.Print(modes.ExplainRetrieval(chunks))
+}
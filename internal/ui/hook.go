@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/gitutil"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/renderer"
+	"github.com/yourusername/llamasidekick/internal/secrets"
+)
+
+// hookMarker identifies pre-commit hooks written by LlamaSidekick
+const hookMarker = "# Installed by llamasidekick"
+
+// preCommitScript is the hook installed into the repository. It prefers the binary that
+// installed it and falls back to the one on PATH.
+const preCommitScript = `#!/bin/sh
+` + hookMarker + ` (remove with: llamasidekick hook uninstall)
+# Skip once with: git commit --no-verify
+LLAMASIDEKICK=%s
+[ -x "$LLAMASIDEKICK" ] || LLAMASIDEKICK=llamasidekick
+exec "$LLAMASIDEKICK" hook run pre-commit
+`
+
+// InstallHook installs the pre-commit hook into the repository containing the working
+// directory. An existing hook from another tool is only replaced with force, and is
+// kept as pre-commit.bak.
+func InstallHook(force bool) error {
+	path, err := preCommitPath()
+	if err != nil {
+		return err
+	}
+	if existing, err := os.ReadFile(path); err == nil && !strings.Contains(string(existing), hookMarker) {
+		if !force {
+			return fmt.Errorf("%s already exists and was not installed by llamasidekick; rerun with --force to replace it (it will be kept as pre-commit.bak)", path)
+		}
+		if err := os.WriteFile(path+".bak", existing, 0755); err != nil {
+			return fmt.Errorf("failed to back up existing hook: %w", err)
+		}
+		fmt.Printf("\033[38;5;240mExisting hook saved to %s.bak\033[0m\n", path)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "llamasidekick"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create hooks dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(preCommitScript, shQuote(exe))), 0755); err != nil {
+		return fmt.Errorf("failed to write hook: %w", err)
+	}
+	fmt.Printf("\033[38;5;10m✓ Installed pre-commit hook at %s\033[0m\n", path)
+	return nil
+}
+
+// UninstallHook removes the pre-commit hook if LlamaSidekick installed it, restoring a
+// hook it replaced
+func UninstallHook() error {
+	path, err := preCommitPath()
+	if err != nil {
+		return err
+	}
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		fmt.Println("\033[38;5;240mNo pre-commit hook installed\033[0m")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read hook: %w", err)
+	}
+	if !strings.Contains(string(existing), hookMarker) {
+		return fmt.Errorf("%s was not installed by llamasidekick; leaving it alone", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove hook: %w", err)
+	}
+	if _, err := os.Stat(path + ".bak"); err == nil {
+		if err := os.Rename(path+".bak", path); err != nil {
+			return fmt.Errorf("failed to restore previous hook: %w", err)
+		}
+		fmt.Println("\033[38;5;10m✓ Removed pre-commit hook and restored the previous one\033[0m")
+		return nil
+	}
+	fmt.Println("\033[38;5;10m✓ Removed pre-commit hook\033[0m")
+	return nil
+}
+
+// shQuote quotes s for /bin/sh, which runs the hook on every platform
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func preCommitPath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	hooksDir, err := gitutil.HooksDir(cwd)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(hooksDir, "pre-commit"), nil
+}
+
+// RunPreCommitHook checks the staged diff for secrets and asks the model to review it.
+// It returns an error, which makes git abort the commit, only when problems are found and
+// precommit.action is "block". An unreachable Ollama never blocks a commit.
+func RunPreCommitHook(cfg *config.Config) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	diffText, err := gitutil.StagedDiff(cwd)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(diffText) == "" {
+		return nil
+	}
+
+	problems := false
+	if cfg.PreCommit.SecretScan {
+		findings := secrets.ScanDiff(diffText)
+		for _, f := range findings {
+			// Not even the redacted match is shown: its start is part of the secret
+			fmt.Printf("\033[38;5;9m✗ Possible %s in %s:%d\033[0m\n", f.Kind, f.File, f.Line)
+		}
+		if len(findings) > 0 {
+			problems = true
+		}
+	}
+
+	if cfg.PreCommit.Review && reviewStagedDiff(cfg, diffText) {
+		problems = true
+	}
+
+	if !problems {
+		fmt.Println("\033[38;5;10m✓ llamasidekick: no problems found\033[0m")
+		return nil
+	}
+	if cfg.PreCommit.Action == "block" {
+		return fmt.Errorf("commit blocked by llamasidekick pre-commit review (fix the problems, or skip once with git commit --no-verify)")
+	}
+	fmt.Println("\033[38;5;214mllamasidekick: problems found, committing anyway (set precommit.action: block to stop such commits)\033[0m")
+	return nil
+}
+
+// reviewStagedDiff asks the model to review the staged diff and reports whether it found
+// problems. A review that can't run is skipped with a warning.
+func reviewStagedDiff(cfg *config.Config, diffText string) bool {
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("\033[38;5;214mWarning: skipping AI review: %v\033[0m\n", err)
+		return false
+	}
+	client, err := newModeClient(cfg)
+	if err != nil {
+		fmt.Printf("\033[38;5;214mWarning: skipping AI review: %v\033[0m\n", err)
+		return false
+	}
+	applyRenderStyle(cfg)
+	fmt.Println("\033[38;5;240mllamasidekick: reviewing staged changes...\033[0m")
+	result, err := modes.ReviewDiff(client, cfg, diffText)
+	if err != nil {
+		fmt.Printf("\033[38;5;214mWarning: skipping AI review: %v\033[0m\n", err)
+		return false
+	}
+	if result.Truncated {
+		fmt.Printf("\033[38;5;214mOnly the first %d bytes of the diff were reviewed (precommit.max_diff_bytes)\033[0m\n", cfg.PreCommit.MaxDiffBytes)
+	}
+	fmt.Print(renderer.RenderMarkdown(result.Review))
+	return result.Problems
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+)
+
+func TestPreCommitScript_QuotesTheBinaryPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs /bin/sh")
+	}
+	dir := filepath.Join(t.TempDir(), "it's $HOME `id` \\n")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(dir, "llamasidekick")
+	if err := os.WriteFile(exe, []byte("#!/bin/sh\necho \"ran $*\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	hook := filepath.Join(t.TempDir(), "pre-commit")
+	if err := os.WriteFile(hook, []byte(fmt.Sprintf(preCommitScript, shQuote(exe))), 0755); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("/bin/sh", hook).CombinedOutput()
+	if err != nil || string(out) != "ran hook run pre-commit\n" {
+		t.Fatalf("expected the hook to run the binary at %q, got %q, %v", exe, out, err)
+	}
+}
+
+func TestRunPreCommitHook_SkipsAReviewThatCantRun(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "a.txt")
+	wd, _ := os.Getwd()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// An empty ollama.host fails validation
+	cfg := &config.Config{PreCommit: config.PreCommitConfig{Review: true, Action: "block"}}
+	if err := RunPreCommitHook(cfg); err != nil {
+		t.Fatalf("expected the commit to go ahead without a review, got %v", err)
+	}
+}
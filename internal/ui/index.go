@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/index"
+	"github.com/yourusername/llamasidekick/internal/renderer"
+)
+
+// RunIndexBuild builds or updates the semantic index of the working directory. Only new
+// and changed files are embedded; progress made before a failure is saved.
+func RunIndexBuild(cfg *config.Config) error {
+	if cfg.Index.Model == "" {
+		return fmt.Errorf("index.model is empty; set an Ollama embedding model, e.g. nomic-embed-text")
+	}
+	root, path, err := indexLocation()
+	if err != nil {
+		return err
+	}
+	client, err := newModeClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	idx, err := index.Load(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		idx = index.New(root, cfg.Index.Model)
+	case err != nil:
+		return err
+	case idx.Model != cfg.Index.Model:
+		fmt.Printf("\033[38;5;214mThe index was built with %s; rebuilding it with %s\033[0m\n", idx.Model, cfg.Index.Model)
+		idx = index.New(root, cfg.Index.Model)
+	}
+
+	opts := index.OptionsFor(cfg)
+	if len(idx.Files) > 0 && idx.ChunkingChanged(opts) {
+		fmt.Println("\033[38;5;214mThe chunking settings changed; re-embedding every file\033[0m")
+	}
+	fmt.Printf("Indexing %s with %s...\n", root, cfg.Index.Model)
+	start := time.Now()
+	embed := func(texts []string) ([][]float32, error) {
+		return client.Embed(cfg.Index.Model, texts)
+	}
+	var progress func(string, int, int)
+	if !renderer.IsPlain() {
+		progress = func(file string, done, total int) {
+			fmt.Printf("\r\033[K\033[38;5;240m[%d/%d] %s\033[0m", done+1, total, file)
+		}
+	}
+	stats, buildErr := idx.Update(opts, embed, progress)
+	if progress != nil {
+		fmt.Print("\r\033[K")
+	}
+	if stats.Embedded > 0 || stats.Removed > 0 || buildErr == nil {
+		if err := idx.Save(path); err != nil {
+			return err
+		}
+	}
+	if buildErr != nil {
+		return fmt.Errorf("failed to build index: %w", buildErr)
+	}
+
+	fmt.Printf("\033[1;32m✓ Indexed %d files (%d chunks)\033[0m \033[38;5;240min %s\033[0m\n",
+		stats.Files, idx.ChunkCount(), time.Since(start).Round(time.Millisecond))
+	fmt.Printf("\033[38;5;240m  %d embedded (%d chunks), %d unchanged, %d removed\033[0m\n",
+		stats.Embedded, stats.Chunks, stats.Reused, stats.Removed)
+	return nil
+}
+
+// RunIndexStatus reports whether the working directory has an index and how far it is
+// behind the files on disk
+func RunIndexStatus(cfg *config.Config) error {
+	root, path, err := indexLocation()
+	if err != nil {
+		return err
+	}
+	idx, err := index.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("No index for %s yet. Build one with: llamasidekick index build\n", root)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\033[1;38;5;75mIndex of %s\033[0m\n", root)
+	fmt.Printf("  Model:   %s\n", idx.Model)
+	fmt.Printf("  Files:   %d (%d chunks)\n", len(idx.Files), idx.ChunkCount())
+	fmt.Printf("  Built:   %s\n", idx.Built.Format("2006-01-02 15:04"))
+	if info, err := os.Stat(path); err == nil {
+		fmt.Printf("  Size:    %.1f MB\n", float64(info.Size())/(1024*1024))
+	}
+
+	changed, added, removed, err := idx.Stale(index.OptionsFor(cfg))
+	if err != nil {
+		return err
+	}
+	if changed+added+removed == 0 {
+		fmt.Println("\033[38;5;10m  Up to date\033[0m")
+	} else {
+		fmt.Printf("\033[38;5;214m  Out of date: %d changed, %d new, %d deleted files (run: llamasidekick index build)\033[0m\n", changed, added, removed)
+	}
+	if idx.ChunkingChanged(index.OptionsFor(cfg)) {
+		fmt.Println("\033[38;5;214m  The chunking settings changed; the next build re-embeds every file\033[0m")
+	}
+	if idx.Model != cfg.Index.Model {
+		fmt.Printf("\033[38;5;214m  index.model is now %s; the next build re-embeds every file\033[0m\n", cfg.Index.Model)
+	}
+	return nil
+}
+
+// indexLocation returns the project root of the working directory and its index file
+func indexLocation() (root, path string, err error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	if abs, err := filepath.Abs(cwd); err == nil {
+		cwd = abs
+	}
+	path, err = index.PathFor(cwd)
+	if err != nil {
+		return "", "", err
+	}
+	return cwd, path, nil
+}
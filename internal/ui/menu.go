@@ -2,21 +2,39 @@ package ui
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/llamasidekick/internal/completion"
 	"github.com/yourusername/llamasidekick/internal/config"
 	"github.com/yourusername/llamasidekick/internal/modes"
 	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/renderer"
 	"github.com/yourusername/llamasidekick/internal/session"
 )
 
+// menuItem is a mode, or an action that changes the config
 type menuItem struct {
 	name        string
 	description string
-	isMode      bool
 	mode        modes.Mode
+	action      *menuAction
+}
+
+// menuAction is a menu entry that isn't a mode. It changes the config file, which is
+// reloaded once it returns.
+type menuAction struct {
+	name        string
+	description string
+	run         func(client *ollama.Client, cfg *config.Config) error
+}
+
+// menuActions are listed in the menu after the modes
+var menuActions = []menuAction{
+	{name: "Configure Models", description: "Assign different models to different modes", run: RunModelConfig},
+	{name: "Settings", description: "Toggle debug mode and other settings", run: func(_ *ollama.Client, cfg *config.Config) error { return RunSettings(cfg) }},
 }
 
 type menuModel struct {
@@ -28,39 +46,17 @@ type menuModel struct {
 	session  *session.Session
 }
 
-func initialModel(cfg *config.Config, version string) menuModel {
-	// Get current working directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		cwd = "."
+// menuItems returns the menu entries: every available mode, then the actions
+func menuItems(cfg *config.Config) []menuItem {
+	var items []menuItem
+	for _, e := range modes.NewRegistry(cfg).Entries() {
+		items = append(items, menuItem{name: e.Mode.Name(), description: e.Mode.Description(), mode: e.Mode})
 	}
-
-	// Load or create session
-	sess, err := session.Load(cwd)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to load session: %v\n", err)
-		sess = session.New(cwd)
-	}
-
-	// Create Ollama client
-	client := ollama.NewClient(cfg.Ollama.Host, cfg.Ollama.Model)
-	client.Debug = cfg.Ollama.Debug
-
-	return menuModel{
-		choices: []menuItem{
-			{name: "Plan", description: "Create development plans and break down tasks", isMode: true, mode: &modes.PlanMode{}},
-			{name: "Edit", description: "Get help editing code with suggestions and diffs", isMode: true, mode: &modes.EditMode{}},
-			{name: "Agent", description: "Autonomous multi-step task execution and problem solving", isMode: true, mode: &modes.AgentMode{}},
-			{name: "CMD", description: "Get help with commands - generates but never executes", isMode: true, mode: &modes.CmdMode{}},
-			{name: "Ask", description: "Get information and answers without any changes or plans", isMode: true, mode: &modes.AskMode{}},
-			{name: "Configure Models", description: "Assign different models to different modes", isMode: false},
-		},
-		cursor:   0,
-		selected: false,
-		cfg:      cfg,
-		client:   client,
-		session:  sess,
+	for i := range menuActions {
+		a := &menuActions[i]
+		items = append(items, menuItem{name: a.name, description: a.description, action: a})
 	}
+	return items
 }
 
 func (m menuModel) Init() tea.Cmd {
@@ -118,14 +114,44 @@ func (m menuModel) View() string {
 	return s.String()
 }
 
+// RunOptions controls optional startup behavior
+type RunOptions struct {
+	PickProject bool // Show the recent projects picker before the prompt
+	Resume      bool // Go straight back into the session's last mode
+}
+
 // Run starts the UI
-func Run(cfg *config.Config, version string) error {
+func Run(cfg *config.Config, version string, opts RunOptions) error {
+	applyRenderStyle(cfg)
+	defer modes.CloseMCPServers()
+	
 	// Check Ollama connection first
 	client := ollama.NewClient(cfg.Ollama.Host, cfg.Ollama.Model)
 	client.Debug = cfg.Ollama.Debug
-	if err := client.CheckConnection(); err != nil {
+	apiKey, err := cfg.OllamaAPIKey()
+	if err != nil {
+		return fmt.Errorf("failed to load Ollama API key: %w", err)
+	}
+	client.APIKey = apiKey
+	attachBudget(cfg, client)
+	attachCache(cfg, client)
+	installed, err := client.ListModels()
+	if err != nil {
 		return fmt.Errorf("failed to connect to Ollama at %s: %w\nMake sure Ollama is running with: ollama serve", cfg.Ollama.Host, err)
 	}
+	
+	names := make([]string, 0, len(installed))
+	for _, m := range installed {
+		names = append(names, m.Name)
+	}
+	// Offer to pull or replace configured models that aren't installed (skipped on first run)
+	if cfg.Ollama.Model != "" {
+		names = checkModels(cfg, client, names)
+	}
+	// Remember the installed models so shell completion can offer them without calling Ollama
+	if err := completion.SaveModelCache(names); err != nil {
+		slog.Debug("failed to save model cache", "error", err)
+	}
 
 	// Get current working directory
 	cwd, err := os.Getwd()
@@ -134,11 +160,7 @@ func Run(cfg *config.Config, version string) error {
 	}
 
 	// Load or create session
-	sess, err := session.Load(cwd)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to load session: %v\n", err)
-		sess = session.New(cwd)
-	}
+	sess := loadProjectSession(cwd)
 
 	// Handle first run - if no model is configured, prompt user to select one
 	if cfg.Ollama.Model == "" {
@@ -162,11 +184,37 @@ func Run(cfg *config.Config, version string) error {
 		fmt.Printf("\n✓ Configuration saved! Using %s as default model.\n\n", selectedModel)
 	}
 
+	if opts.PickProject {
+		project, ok, err := RunProjectPicker(sess.ProjectRoot)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := switchProject(cfg, sess, project); err != nil {
+				return err
+			}
+		} else {
+			enterProject(cfg, sess)
+		}
+	} else {
+		enterProject(cfg, sess)
+	}
+
 	// Show welcome message and start prompt
 	fmt.Println("\n\033[1;38;5;205m🦙 LlamaSidekick\033[0m")
-	fmt.Println("\033[38;5;240mQuick commands: /plan, /edit, /agent, /cmd, /ask | Press 'm' for menu | 'q' to quit\033[0m")
+	fmt.Println("\033[38;5;240mQuick commands: " + strings.Join(modes.NewRegistry(cfg).Commands(), ", ") + " | Press 'm' for menu | 'q' to quit\033[0m")
+	if cfg.Edits.ReadOnly {
+		fmt.Println("\033[38;5;214mRead-only mode: changes are shown as diffs and never written\033[0m")
+	} else if cfg.Edits.AutoApprove {
+		fmt.Println("\033[1;38;5;214mAuto-approve is on: changes, renames and deletions are written without asking (/yolo to turn it off)\033[0m")
+	}
 	fmt.Println()
 
+	if opts.Resume {
+		if err := resumeSession(cfg, client, sess); err != nil {
+			return err
+		}
+	}
 	return RunPrompt(cfg, client, sess, version)
 }
 
@@ -174,8 +222,7 @@ func Run(cfg *config.Config, version string) error {
 func ShowMenu(cfg *config.Config, client *ollama.Client, sess *session.Session, version string) error {
 	for {
 		// Run the menu
-		p := tea.NewProgram(initialModelWithSession(cfg, sess, version), tea.WithAltScreen())
-		m, err := p.Run()
+		m, err := runProgram(initialModelWithSession(cfg, sess, version, client.APIKey))
 		if err != nil {
 			return fmt.Errorf("error running menu: %w", err)
 		}
@@ -188,82 +235,50 @@ func ShowMenu(cfg *config.Config, client *ollama.Client, sess *session.Session,
 		}
 
 		selectedItem := model.choices[model.cursor]
-		
-		if selectedItem.isMode {
-			// Run the mode
+		if selectedItem.mode != nil {
+			// Run the mode, then loop back to the menu once it exits
 			if err := selectedItem.mode.Run(model.client, model.session, model.cfg); err != nil {
 				return err
 			}
-			// After mode exits, loop back to main menu
-		} else if selectedItem.name == "Configure Models" {
-			// Configure Models option
-			if err := RunModelConfig(model.client, model.cfg); err != nil {
-				return err
-			}
-			// Reload config after changes
-			newCfg, err := config.Load()
-			if err != nil {
-				return fmt.Errorf("error reloading config: %w", err)
-			}
-			cfg = newCfg
-			// Update client debug flag
-			client.Debug = cfg.Ollama.Debug
-		} else if selectedItem.name == "Settings" {
-			// Settings option
-			if err := RunSettings(model.cfg); err != nil {
-				return err
-			}
-			// Reload config after changes
-			newCfg, err := config.Load()
-			if err != nil {
-				return fmt.Errorf("error reloading config: %w", err)
-			}
-			cfg = newCfg
-			// Update client debug flag
-			client.Debug = cfg.Ollama.Debug
-		} else if selectedItem.name == "Toggle Debug Mode" {
-			// Toggle debug mode
-			cfg.Ollama.Debug = !cfg.Ollama.Debug
-			if err := cfg.Save(); err != nil {
-				return fmt.Errorf("failed to save config: %w", err)
-			}
-			// Update client debug flag
-			client.Debug = cfg.Ollama.Debug
-			// Show confirmation
-			status := "OFF"
-			if cfg.Ollama.Debug {
-				status = "ON"
-			}
-			fmt.Printf("\n\033[1;32m✓ Debug mode is now %s\033[0m\n\n", status)
-			// Reload config to refresh menu
-			newCfg, err := config.Load()
-			if err != nil {
-				return fmt.Errorf("error reloading config: %w", err)
-			}
-			cfg = newCfg
+			continue
+		}
+		if err := selectedItem.action.run(model.client, model.cfg); err != nil {
+			return err
+		}
+		// Reload config after changes
+		newCfg, err := reloadConfig()
+		if err != nil {
+			return fmt.Errorf("error reloading config: %w", err)
 		}
+		cfg = newCfg
+		// Update client debug flag
+		client.Debug = cfg.Ollama.Debug
 	}
 }
 
-func initialModelWithSession(cfg *config.Config, sess *session.Session, version string) menuModel {
+func initialModelWithSession(cfg *config.Config, sess *session.Session, version string, apiKey string) menuModel {
 	// Create Ollama client
 	client := ollama.NewClient(cfg.Ollama.Host, cfg.Ollama.Model)
 	client.Debug = cfg.Ollama.Debug
 	client.Version = version
+	client.APIKey = apiKey
+	attachBudget(cfg, client)
+	attachCache(cfg, client)
 
 	return menuModel{
-		choices: []menuItem{
-			{name: "Plan", description: "Create development plans and break down tasks", isMode: true, mode: &modes.PlanMode{}},
-			{name: "Edit", description: "Get help editing code with suggestions and diffs", isMode: true, mode: &modes.EditMode{}},
-			{name: "Agent", description: "Autonomous multi-step task execution and problem solving", isMode: true, mode: &modes.AgentMode{}},
-			{name: "CMD", description: "Get help with commands - generates but never executes", isMode: true, mode: &modes.CmdMode{}},
-			{name: "Configure Models", description: "Assign different models to different modes", isMode: false},
-			{name: "Settings", description: "Toggle debug mode and other settings", isMode: false},
-		},
-		cursor:   0,
-		selected: false,
-		cfg:      cfg,
-		client:   client,
-		session:  sess,
+		choices: menuItems(cfg),
+		cfg:     cfg,
+		client:  client,
+		session: sess,
+	}
+}
+
+// applyRenderStyle configures markdown rendering from ui.markdown_style and ui.code_theme,
+// keeping the default style if they are invalid, and diffs in answers from ui.word_diff
+// and ui.line_numbers
+func applyRenderStyle(cfg *config.Config) {
+	if err := renderer.Configure(cfg.MarkdownStylePath(), cfg.UI.CodeTheme); err != nil {
+		fmt.Fprintf(os.Stderr, "\033[38;5;214mWarning: %v\033[0m\n", err)
 	}
+	renderer.SetDiffOptions(renderer.DiffOptions{WordLevel: cfg.UI.WordDiff, LineNumbers: cfg.UI.LineNumbers})
 }
@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"golang.org/x/term"
+)
+
+// checkModels lists the configured models that aren't installed and offers to pull them
+// or to fall back to installed ones for this session, so a mode doesn't fail the first
+// time it is used. It returns the installed models afterwards.
+func checkModels(cfg *config.Config, client *ollama.Client, installed []string) []string {
+	missing := cfg.UninstalledModels(installed)
+	if len(missing) == 0 {
+		return installed
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		for _, warning := range cfg.MissingModels(installed) {
+			fmt.Fprintf(os.Stderr, "\033[38;5;214mWarning: %s\033[0m\n", warning)
+		}
+		return installed
+	}
+	fmt.Println("\033[38;5;214mThese configured models are not installed:\033[0m")
+	for _, m := range missing {
+		fmt.Printf("  \033[1m%s\033[0m \033[38;5;240m(%s)\033[0m\n", m.Model, strings.Join(m.Keys, ", "))
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	fmt.Print("Pull them [p], fall back to installed models for this session [f], or continue [Enter]? ")
+	answer, _ := in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "p", "pull":
+		for _, m := range missing {
+			if err := pullModel(client, m.Model); err != nil {
+				fmt.Printf("\033[38;5;9m%v\033[0m\n", err)
+			}
+		}
+		if models, err := client.ListModels(); err == nil {
+			installed = installed[:0]
+			for _, m := range models {
+				installed = append(installed, m.Name)
+			}
+		}
+		if missing = cfg.UninstalledModels(installed); len(missing) == 0 {
+			return installed
+		}
+		fmt.Print("Some models are still missing. Fall back to installed models for this session? [y/N] ")
+		answer, _ = in.ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return installed
+		}
+		fallBack(cfg, missing, installed)
+	case "f", "fall back":
+		fallBack(cfg, missing, installed)
+	}
+	return installed
+}
+
+// fallBack makes the settings that use a missing model use installed ones until LlamaSidekick exits
+func fallBack(cfg *config.Config, missing []config.UninstalledModel, installed []string) {
+	model, err := cfg.FallBackModels(missing, installed)
+	if err != nil {
+		fmt.Printf("\033[38;5;9mCan't fall back: %v\033[0m\n", err)
+		return
+	}
+	fmt.Printf("\033[38;5;240mUsing %s in their place for this session; the config file is unchanged\033[0m\n", model)
+}
+
+// pullModel downloads model, showing its progress on one line
+func pullModel(client *ollama.Client, model string) error {
+	err := client.PullModel(model, func(p ollama.PullProgress) {
+		status := p.Status
+		if p.Total > 0 {
+			status = fmt.Sprintf("%s %d%% (%s of %s)", status, p.Completed*100/p.Total, formatBytes(p.Completed), formatBytes(p.Total))
+		}
+		fmt.Printf("\r\033[KPulling %s: %s", model, status)
+	})
+	fmt.Print("\r\033[K")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\033[1;32m✓ Pulled %s\033[0m\n", model)
+	return nil
+}
+
+// formatBytes describes a number of bytes, e.g. "4.1 GB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KB"
+	for _, s := range []string{"MB", "GB", "TB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, s
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
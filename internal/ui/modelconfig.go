@@ -6,6 +6,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
 	"github.com/yourusername/llamasidekick/internal/ollama"
 )
 
@@ -13,8 +14,8 @@ type modelConfigModel struct {
 	client        *ollama.Client
 	cfg           *config.Config
 	availableModels []ollama.Model
-	currentMode   string
-	modes         []string
+	currentMode   modes.Entry
+	modes         []modes.Entry
 	cursor        int
 	modelCursor   int
 	state         string // "select_mode" or "select_model"
@@ -25,12 +26,23 @@ func newModelConfigModel(client *ollama.Client, cfg *config.Config) modelConfigM
 	return modelConfigModel{
 		client: client,
 		cfg:    cfg,
-		modes:  []string{"plan", "edit", "agent", "cmd", "ask"},
+		modes:  modelModes(cfg),
 		cursor: 0,
 		state:  "select_mode",
 	}
 }
 
+// modelModes returns the modes that have a model setting of their own
+func modelModes(cfg *config.Config) []modes.Entry {
+	var entries []modes.Entry
+	for _, e := range modes.NewRegistry(cfg).Entries() {
+		if e.ModelKey != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
 func (m modelConfigModel) Init() tea.Cmd {
 	return func() tea.Msg {
 		models, err := m.client.ListModels()
@@ -102,19 +114,9 @@ func (m modelConfigModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else {
 				// Save selected model for current mode
 				selectedModel := m.availableModels[m.modelCursor].Name
-				switch m.currentMode {
-				case "plan":
-					m.cfg.Models.Plan = selectedModel
-				case "edit":
-					m.cfg.Models.Edit = selectedModel
-				case "agent":
-					m.cfg.Models.Agent = selectedModel
-				case "cmd":
-					m.cfg.Models.CMD = selectedModel
-				}
-				
-				// Save config
-				if err := m.cfg.Save(); err != nil {
+				if err := m.cfg.SetModel(m.currentMode.ModelKey, selectedModel); err != nil {
+					m.err = err
+				} else if err := m.cfg.Save(); err != nil {
 					m.err = err
 				}
 				
@@ -151,19 +153,19 @@ func (m modelConfigModel) View() string {
 				cursor = "> "
 			}
 
-			currentModel := m.cfg.GetModelForMode(mode)
+			currentModel := m.cfg.GetModelForMode(mode.Key)
 			if m.cursor == i {
-				s.WriteString(cursor + "\033[1;38;5;170m" + strings.ToUpper(mode) + "\033[0m\n")
+				s.WriteString(cursor + "\033[1;38;5;170m" + strings.ToUpper(mode.Key) + "\033[0m\n")
 			} else {
-				s.WriteString(cursor + strings.ToUpper(mode) + "\n")
+				s.WriteString(cursor + strings.ToUpper(mode.Key) + "\n")
 			}
-			s.WriteString("  \033[38;5;240mCurrent: " + currentModel + "\033[0m\n")
+			s.WriteString("  \033[38;5;240mCurrent: " + currentModel + " (" + mode.ModelKey + ")\033[0m\n")
 		}
 
 		s.WriteString("\n")
 		s.WriteString("\033[38;5;240mPress Enter to change, left/h to go back, q to quit\033[0m\n")
 	} else {
-		s.WriteString(fmt.Sprintf("\033[38;5;240mSelect model for \033[1;38;5;205m%s\033[0;38;5;240m mode:\033[0m\n\n", strings.ToUpper(m.currentMode)))
+		s.WriteString(fmt.Sprintf("\033[38;5;240mSelect model for \033[1;38;5;205m%s\033[0;38;5;240m mode:\033[0m\n\n", strings.ToUpper(m.currentMode.Key)))
 
 		for i, model := range m.availableModels {
 			cursor := "  "
@@ -192,7 +194,6 @@ func (m modelConfigModel) View() string {
 
 // RunModelConfig starts the model configuration UI
 func RunModelConfig(client *ollama.Client, cfg *config.Config) error {
-	p := tea.NewProgram(newModelConfigModel(client, cfg), tea.WithAltScreen())
-	_, err := p.Run()
+	_, err := runProgram(newModelConfigModel(client, cfg))
 	return err
 }
@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// OneShotResult is the structured result printed by --output json
+type OneShotResult struct {
+	Mode         string               `json:"mode"`
+	Model        string               `json:"model"`
+	Response     string               `json:"response"`
+	Commands     []string             `json:"commands"`
+	FilesChanged []session.FileChange `json:"files_changed"`
+	Tokens       oneShotTokens        `json:"tokens"`
+	Error        string               `json:"error,omitempty"`
+	Hint         string               `json:"hint,omitempty"` // How to fix the error, if known
+}
+
+type oneShotTokens struct {
+	ollama.TokenStats
+	Total int `json:"total"`
+}
+
+// RunOneShot runs a single prompt in the given mode without starting the interactive UI.
+// With output "json", the usual terminal output goes to stderr and a OneShotResult is
+// written to stdout.
+func RunOneShot(cfg *config.Config, modeKey, prompt, output string) error {
+	mode := modeForCommand(cfg, modeKey)
+	pim, ok := mode.(processInputMode)
+	if !ok {
+		return fmt.Errorf("unknown mode %q", modeKey)
+	}
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unknown output format %q (use text or json)", output)
+	}
+
+	client, err := newModeClient(cfg)
+	if err != nil {
+		return err
+	}
+	applyRenderStyle(cfg)
+	defer modes.CloseMCPServers()
+	sess := loadWorkingSession()
+
+	if output == "text" {
+		return pim.ProcessInput(client, sess, cfg, prompt)
+	}
+
+	// Keep stdout clean for the JSON result
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	runErr := pim.ProcessInput(client, sess, cfg, prompt)
+	os.Stdout = stdout
+
+	result := buildOneShotResult(cfg, client, sess, modeKey, runErr)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return fmt.Errorf("failed to write JSON result: %w", err)
+	}
+	return runErr
+}
+
+// buildOneShotResult collects the outcome of a finished prompt from the session and client
+func buildOneShotResult(cfg *config.Config, client *ollama.Client, sess *session.Session, modeKey string, runErr error) OneShotResult {
+	result := OneShotResult{
+		Mode:         modeKey,
+		Model:        cfg.GetModelForMode(modeKey),
+		Commands:     []string{},
+		FilesChanged: sess.Changes,
+		Tokens:       oneShotTokens{TokenStats: client.Stats, Total: client.Stats.Total()},
+	}
+	if n := len(sess.History); n > 0 && sess.History[n-1].Role == "assistant" {
+		result.Response = sess.History[n-1].Content
+	}
+	if commands := modes.ExtractCommands(result.Response); len(commands) > 0 {
+		result.Commands = commands
+	} else if modeKey == modes.ModeCmd && strings.TrimSpace(result.Response) != "" {
+		// CMD mode is asked for bare commands without code fences
+		result.Commands = []string{strings.TrimSpace(result.Response)}
+	}
+	if result.FilesChanged == nil {
+		result.FilesChanged = []session.FileChange{}
+	}
+	if runErr != nil {
+		result.Error = runErr.Error()
+		result.Hint = hint.For(runErr)
+	}
+	return result
+}
+
+// newModeClient creates an Ollama client configured for running modes
+func newModeClient(cfg *config.Config) (*ollama.Client, error) {
+	client := ollama.NewClient(cfg.Ollama.Host, cfg.Ollama.Model)
+	client.Debug = cfg.Ollama.Debug
+	apiKey, err := cfg.OllamaAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Ollama API key: %w", err)
+	}
+	client.APIKey = apiKey
+	attachBudget(cfg, client)
+	attachCache(cfg, client)
+	return client, nil
+}
+
+// sessionName is the session chosen with --session; empty for the project's default one
+var sessionName string
+
+// UseSession makes every command work with the named session instead of the project's
+// default session
+func UseSession(name string) {
+	sessionName = name
+}
+
+// loadWorkingSession loads the session of the current working directory, starting a new
+// one if it can't be read
+func loadWorkingSession() *session.Session {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = "."
+	}
+	return loadProjectSession(cwd)
+}
+
+// loadProjectSession loads the active session of projectRoot, starting a new one if it
+// can't be read
+func loadProjectSession(projectRoot string) *session.Session {
+	sess, err := session.LoadNamed(projectRoot, sessionName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load session: %v\n", err)
+		sess = session.New(projectRoot)
+		sess.Name = sessionName
+	}
+	return sess
+}
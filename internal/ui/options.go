@@ -0,0 +1,357 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+// sessionOptions are the generation options changed with /temp, /ctx and /seed, the
+// model picked with /model, auto-approval toggled with /yolo and short answers toggled
+// with /brief. They last for the rest of the run, also across /config reloads.
+type sessionOptions struct {
+	base        config.OllamaConfig // The values the options had before this run changed them
+	baseModels  config.ModelsConfig // The models modes had before /model
+	temperature *float64
+	numCtx      *int
+	seed        *int
+	autoApprove *bool
+	brief       *bool
+	briefTokens *int
+	model       string // Used for every mode; empty for the configured models
+}
+
+func newSessionOptions(cfg *config.Config) *sessionOptions {
+	o := &sessionOptions{}
+	o.rebase(cfg)
+	return o
+}
+
+// rebase takes the values options go back to from cfg, e.g. once it is reloaded
+func (o *sessionOptions) rebase(cfg *config.Config) {
+	o.base = cfg.Ollama
+	o.baseModels = cfg.Models
+	o.baseModels.Other = maps.Clone(cfg.Models.Other)
+}
+
+// apply sets the options changed this run on cfg and client
+func (o *sessionOptions) apply(cfg *config.Config, client *ollama.Client) {
+	if o.temperature != nil {
+		cfg.Ollama.Temperature = *o.temperature
+	}
+	if o.numCtx != nil {
+		cfg.Ollama.NumCtx = *o.numCtx
+	}
+	if o.seed != nil {
+		cfg.Ollama.Seed = *o.seed
+	}
+	if o.autoApprove != nil {
+		cfg.Edits.AutoApprove = *o.autoApprove
+	}
+	if o.brief != nil {
+		cfg.UI.Brief = *o.brief
+	}
+	if o.briefTokens != nil {
+		cfg.UI.BriefTokens = *o.briefTokens
+	}
+	if o.model != "" {
+		// Like --model, so Save keeps the configured models
+		cfg.ApplyOverrides(config.Overrides{Model: o.model})
+	}
+	client.Seed = cfg.Ollama.Seed
+	client.NumCtx = cfg.Ollama.NumCtx
+}
+
+// prompt returns the input prompt, which shows the options changed this run, e.g.
+// "[temp 0.2 · seed 42] > ", and a warning while changes are auto-approved
+func (o *sessionOptions) prompt(cfg *config.Config) string {
+	prefix := ""
+	if cfg.Edits.AutoApprove && !cfg.Edits.ReadOnly {
+		prefix = "\033[1;38;5;214m[yolo]\033[0m "
+	}
+	var changed []string
+	if o.model != "" {
+		changed = append(changed, o.model)
+	}
+	if o.temperature != nil {
+		changed = append(changed, "temp "+formatTemperature(*o.temperature))
+	}
+	if o.numCtx != nil {
+		changed = append(changed, fmt.Sprintf("ctx %d", *o.numCtx))
+	}
+	if o.seed != nil {
+		changed = append(changed, fmt.Sprintf("seed %d", *o.seed))
+	}
+	if cfg.UI.Brief {
+		changed = append(changed, "brief")
+	}
+	if len(changed) == 0 {
+		return prefix + "> "
+	}
+	return prefix + "\033[38;5;240m[" + strings.Join(changed, " · ") + "]\033[0m > "
+}
+
+// runYoloCommand handles /yolo [on|off]: it toggles, or sets, auto-approval of changes
+// for the rest of the run
+func runYoloCommand(cfg *config.Config, opts *sessionOptions, args string) error {
+	if cfg.Edits.ReadOnly {
+		return fmt.Errorf("read-only mode is on, so changes are never written")
+	}
+	on := !cfg.Edits.AutoApprove
+	switch args {
+	case "":
+	case "on":
+		on = true
+	case "off":
+		on = false
+	default:
+		return fmt.Errorf("usage: /yolo [on|off]")
+	}
+	opts.autoApprove = &on
+	cfg.Edits.AutoApprove = on
+	if on {
+		fmt.Println("\033[1;38;5;214mAuto-approve ON - changes, renames and deletions are written without asking (/yolo to turn it off)\033[0m")
+	} else {
+		fmt.Println("\033[38;5;10mAuto-approve OFF - renames, deletions and hunks are confirmed again\033[0m")
+	}
+	return nil
+}
+
+// runBriefCommand handles /brief [on|off|tokens]: it toggles, or sets, short answers for
+// the rest of the run. A number turns them on with that many tokens as the cap.
+func runBriefCommand(cfg *config.Config, opts *sessionOptions, args string) error {
+	on := !cfg.UI.Brief
+	switch args {
+	case "":
+	case "on":
+		on = true
+	case "off":
+		on = false
+	default:
+		tokens, err := strconv.Atoi(args)
+		if err != nil || tokens < 1 {
+			return fmt.Errorf("usage: /brief [on|off|tokens]")
+		}
+		on = true
+		opts.briefTokens = &tokens
+		cfg.UI.BriefTokens = tokens
+	}
+	opts.brief = &on
+	cfg.UI.Brief = on
+	if on {
+		fmt.Printf("\033[38;5;10mBrief ON - answers are kept short and cut off at %d tokens (/more for detail, /brief off to turn it off)\033[0m\n", cfg.UI.BriefTokens)
+	} else {
+		fmt.Println("\033[38;5;10mBrief OFF - answers are as long as the model makes them\033[0m")
+	}
+	return nil
+}
+
+// runModelCommand handles /model [name|reset]: without a name it shows each mode's model;
+// with one every mode uses that model for the rest of the run, and reset goes back to the
+// configured models
+func runModelCommand(cfg *config.Config, client *ollama.Client, opts *sessionOptions, args string) error {
+	switch args {
+	case "":
+		for _, mode := range cfg.Models.Modes() {
+			fmt.Printf("\033[38;5;240m%-6s\033[0m %s\n", mode, cfg.GetModelForMode(mode))
+		}
+		if opts.model != "" {
+			fmt.Println("\033[38;5;240m(set with /model for this run; /model reset goes back to the configured models)\033[0m")
+		}
+		return nil
+	case "reset":
+		if opts.model == "" {
+			fmt.Println("\033[38;5;240mAlready using the configured models\033[0m")
+			return nil
+		}
+		opts.model = ""
+		cfg.Ollama.Model = opts.base.Model
+		cfg.Models = opts.baseModels
+		cfg.Models.Other = maps.Clone(opts.baseModels.Other)
+		fmt.Println("\033[38;5;10mBack to the configured models\033[0m")
+		return nil
+	}
+	if strings.ContainsAny(args, " \t") {
+		return fmt.Errorf("usage: /model [name|reset]")
+	}
+	if installed, err := client.ListModels(); err == nil {
+		names := make([]string, 0, len(installed))
+		for _, m := range installed {
+			names = append(names, m.Name)
+		}
+		if !config.ModelInstalled(args, names) {
+			return hint.Wrap(fmt.Errorf("model %q is not installed", args), fmt.Sprintf("install it with `ollama pull %s`", args))
+		}
+	}
+	opts.model = args
+	opts.apply(cfg, client)
+	fmt.Printf("\033[38;5;10mUsing %s for every mode for this run\033[0m \033[38;5;240m(/model reset to go back)\033[0m\n", args)
+	return nil
+}
+
+// forget drops the run's change of key
+func (o *sessionOptions) forget(key string) {
+	switch key {
+	case "ollama.temperature":
+		o.temperature = nil
+	case "ollama.num_ctx":
+		o.numCtx = nil
+	case "ollama.seed":
+		o.seed = nil
+	}
+}
+
+func formatTemperature(t float64) string {
+	return strconv.FormatFloat(t, 'f', -1, 64)
+}
+
+// runOptionCommand handles /temp, /ctx and /seed. Without a value it shows the option;
+// with one it changes the option for the rest of the run, and with --save also in the
+// config file. "reset" goes back to the value the run started with.
+func runOptionCommand(cfg *config.Config, client *ollama.Client, opts *sessionOptions, command, args string) error {
+	var value string
+	save := false
+	for _, field := range strings.Fields(args) {
+		switch {
+		case field == "--save":
+			save = true
+		case value == "":
+			value = field
+		default:
+			return fmt.Errorf("usage: %s [value|reset] [--save]", command)
+		}
+	}
+	if value == "reset" && save {
+		return fmt.Errorf("nothing to save: %s reset goes back to the saved value", command)
+	}
+
+	var key, label string
+	switch command {
+	case "/temp":
+		key, label = "ollama.temperature", "Temperature"
+		switch value {
+		case "":
+		case "reset":
+			opts.temperature = nil
+			cfg.Ollama.Temperature = opts.base.Temperature
+		default:
+			t, err := strconv.ParseFloat(value, 64)
+			if err != nil || t < 0 || t > 2 {
+				return fmt.Errorf("temperature %q must be a number between 0.0 and 2.0", value)
+			}
+			opts.temperature = &t
+		}
+	case "/ctx":
+		key, label = "ollama.num_ctx", "Context window"
+		switch value {
+		case "":
+		case "reset":
+			opts.numCtx = nil
+			cfg.Ollama.NumCtx = opts.base.NumCtx
+		default:
+			n, err := parseTokens(value)
+			if err != nil {
+				return err
+			}
+			opts.numCtx = &n
+		}
+	case "/seed":
+		key, label = "ollama.seed", "Seed"
+		switch value {
+		case "":
+		case "reset":
+			opts.seed = nil
+			cfg.Ollama.Seed = opts.base.Seed
+		default:
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("seed %q must be 0 (random) or a positive number", value)
+			}
+			opts.seed = &n
+		}
+	default:
+		return fmt.Errorf("unknown option command %s", command)
+	}
+	opts.apply(cfg, client)
+	current := describeOption(key, cfg.Ollama)
+	changed := current != describeOption(key, opts.base)
+	if !changed {
+		// Set back to where the run started, so it isn't shown as a change
+		opts.forget(key)
+	}
+
+	if save {
+		if err := saveSessionOption(opts, cfg, key); err != nil {
+			return err
+		}
+		fmt.Printf("\033[38;5;10m%s set to %s and saved as %s in the config file\033[0m\n", label, current, key)
+		return nil
+	}
+	switch {
+	case value == "" && changed:
+		fmt.Printf("\033[38;5;240m%s: %s for this run (%s is %s)\033[0m\n", label, current, key, describeOption(key, opts.base))
+	case value == "":
+		fmt.Printf("\033[38;5;240m%s: %s (%s)\033[0m\n", label, current, key)
+	case changed:
+		fmt.Printf("\033[38;5;10m%s set to %s for this run\033[0m \033[38;5;240m(add --save to keep it)\033[0m\n", label, current)
+	default:
+		fmt.Printf("\033[38;5;10m%s back to %s\033[0m\n", label, current)
+	}
+	return nil
+}
+
+// saveSessionOption writes the run's value of key to the config file, after which it is
+// no longer a change of this run
+func saveSessionOption(opts *sessionOptions, cfg *config.Config, key string) error {
+	var value interface{}
+	switch key {
+	case "ollama.temperature":
+		value, opts.base.Temperature = cfg.Ollama.Temperature, cfg.Ollama.Temperature
+	case "ollama.num_ctx":
+		value, opts.base.NumCtx = cfg.Ollama.NumCtx, cfg.Ollama.NumCtx
+	case "ollama.seed":
+		value, opts.base.Seed = cfg.Ollama.Seed, cfg.Ollama.Seed
+	}
+	opts.forget(key)
+	if err := config.SetValue(key, value); err != nil {
+		return fmt.Errorf("failed to save %s: %w", key, err)
+	}
+	return nil
+}
+
+// parseTokens reads a context window size such as 16384 or 16k
+func parseTokens(value string) (int, error) {
+	multiplier := 1
+	digits := strings.ToLower(value)
+	if strings.HasSuffix(digits, "k") {
+		multiplier, digits = 1024, strings.TrimSuffix(digits, "k")
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("context window %q must be a number of tokens such as 16384 or 16k, or 0 for the model's default", value)
+	}
+	return n * multiplier, nil
+}
+
+// describeOption returns the value of key in o for messages, e.g. "16384 tokens"
+func describeOption(key string, o config.OllamaConfig) string {
+	switch key {
+	case "ollama.temperature":
+		return formatTemperature(o.Temperature)
+	case "ollama.num_ctx":
+		if o.NumCtx == 0 {
+			return "the model's default"
+		}
+		return fmt.Sprintf("%d tokens", o.NumCtx)
+	default:
+		if o.Seed == 0 {
+			return "random"
+		}
+		return strconv.Itoa(o.Seed)
+	}
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/renderer"
+)
+
+// terminal is where stdout went before it was filtered, for the full-screen views
+var terminal = os.Stdout
+
+// flushOutput waits until what was written to stdout has reached the terminal, so the
+// readline prompt and the menus, which write to the terminal directly, come after it
+var flushOutput = func() {}
+
+// flushMarker is written to the filtered stdout by flushOutput. Output never contains
+// a NUL byte, and a single byte can't be split between reads.
+const flushMarker = 0
+
+// StartPlainOutput switches to plain output for when stdout is redirected or piped:
+// markdown is printed as written and ANSI colors are stripped from everything written
+// to stdout. Spinners already stay silent when stdout is not a terminal. The returned
+// function restores stdout and must be called before exiting so no output is lost.
+func StartPlainOutput() func() {
+	renderer.SetPlain(true)
+	return filterStdout(func(w io.Writer) io.Writer { return renderer.NewANSIStripper(w) })
+}
+
+// StartAccessibleOutput switches to output for screen readers: like plain output, and
+// without emoji or box drawing. Spinners announce what is happening as a line instead,
+// and the menus are drawn inline rather than on the alternate screen. The returned
+// function restores stdout like StartPlainOutput's.
+func StartAccessibleOutput() func() {
+	renderer.SetPlain(true)
+	renderer.SetAccessible(true)
+	return filterStdout(renderer.NewAccessibleWriter)
+}
+
+// filterStdout passes everything written to stdout through filter until the returned
+// function is called
+func filterStdout(filter func(io.Writer) io.Writer) func() {
+	r, w, err := os.Pipe()
+	if err != nil {
+		// Colors stay in, but the markdown is still plain
+		return func() {}
+	}
+	stdout := os.Stdout
+	terminal = stdout
+	os.Stdout = w
+	done := make(chan struct{})
+	flushed := make(chan struct{}, 1)
+	go func() {
+		out := filter(stdout)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(buf)
+			data := buf[:n]
+			for len(data) > 0 {
+				i := bytes.IndexByte(data, flushMarker)
+				if i < 0 {
+					out.Write(data)
+					break
+				}
+				out.Write(data[:i])
+				data = data[i+1:]
+				select {
+				case flushed <- struct{}{}:
+				default:
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+		close(done)
+	}()
+	flushOutput = func() {
+		w.Write([]byte{flushMarker})
+		select {
+		case <-flushed:
+		case <-time.After(time.Second):
+		}
+	}
+
+	return func() {
+		flushOutput = func() {}
+		os.Stdout = stdout
+		w.Close()
+		<-done
+		r.Close()
+	}
+}
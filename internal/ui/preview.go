@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// errPreviewed stops a request once /preview has captured it
+var errPreviewed = errors.New("request previewed, not sent")
+
+// runPreviewCommand handles /preview [/mode] <prompt>: it runs the prompt in the mode, or
+// in the one a prompt without a slash command would go to, up to the point the request
+// would be sent, and prints that request instead. The mode works on a copy of the
+// session, so the preview leaves nothing behind in the conversation.
+func runPreviewCommand(cfg *config.Config, client *ollama.Client, sess *session.Session, args string) error {
+	if args == "" {
+		return fmt.Errorf("usage: /preview [/mode] <prompt>")
+	}
+	modeKey, modeCfg := "", cfg
+	if command, rest, _ := strings.Cut(args, " "); strings.HasPrefix(command, "/") {
+		modeKey, args = strings.TrimPrefix(command, "/"), strings.TrimSpace(rest)
+		if modeForCommand(cfg, modeKey) == nil {
+			return fmt.Errorf("unknown mode %q", modeKey)
+		}
+		if args == "" {
+			return fmt.Errorf("usage: /preview [/mode] <prompt>")
+		}
+	} else if modeKey, modeCfg = defaultModeForInput(cfg, client, sess, args); modeKey == "" {
+		return nil
+	}
+	mode := modeForCommand(modeCfg, modeKey)
+	if mode == nil {
+		mode, modeKey = &modes.PlanMode{}, modes.ModePlan
+	}
+
+	var req *ollama.GenerateRequest
+	capture := *client
+	capture.OnChunk = nil
+	capture.Intercept = func(r ollama.GenerateRequest) error {
+		req = &r
+		return errPreviewed
+	}
+	draft := *sess
+	draft.History = slices.Clone(sess.History)
+	draft.ActiveFiles = slices.Clone(sess.ActiveFiles)
+	draft.Changes = slices.Clone(sess.Changes)
+
+	var err error
+	if pim, ok := mode.(processInputMode); ok {
+		err = pim.ProcessInput(&capture, &draft, modeCfg, args)
+	} else {
+		err = executeQuickCommand(mode, modeKey, &capture, &draft, modeCfg, args)
+	}
+	client.Stats = capture.Stats
+	if req == nil {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%s mode finished without asking the model", mode.Name())
+	}
+	printPreview(modeCfg, mode, *req, len(sess.History))
+	return nil
+}
+
+// printPreview prints the request a prompt would send: the system prompt, the prompt
+// with the conversation and the loaded files, and their estimated size
+func printPreview(cfg *config.Config, mode modes.Mode, req ollama.GenerateRequest, history int) {
+	systemTokens, promptTokens := modes.EstimateTokens(req.System), modes.EstimateTokens(req.Prompt)
+
+	fmt.Printf("\n\033[1;38;5;75m=== Preview: %s mode, %s ===\033[0m\n", mode.Name(), req.Model)
+	fmt.Printf("\n\033[1mSystem prompt\033[0m \033[38;5;240m(~%d tokens)\033[0m\n%s\n", systemTokens, req.System)
+	fmt.Printf("\n\033[1mPrompt\033[0m \033[38;5;240m(~%d tokens)\033[0m\n%s\n", promptTokens, req.Prompt)
+
+	fmt.Println()
+	fmt.Printf("\033[38;5;240mHistory: %d earlier message(s)\033[0m\n", history)
+	if files := modes.ContextFiles(req.Prompt); len(files) > 0 {
+		fmt.Printf("\033[38;5;240mFiles: %s\033[0m\n", strings.Join(files, ", "))
+	} else {
+		fmt.Println("\033[38;5;240mFiles: none\033[0m")
+	}
+	if req.Format != "" {
+		fmt.Printf("\033[38;5;240mFormat: %s\033[0m\n", req.Format)
+	}
+	total := fmt.Sprintf("~%d tokens", systemTokens+promptTokens)
+	if cfg.Ollama.NumCtx > 0 {
+		total += fmt.Sprintf(" of a %d token context window", cfg.Ollama.NumCtx)
+	}
+	fmt.Printf("\033[1mEstimated size: %s\033[0m \033[38;5;240m(nothing was sent)\033[0m\n", total)
+}
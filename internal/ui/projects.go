@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/projects"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+type projectPickerModel struct {
+	projects []projects.Project
+	current  string
+	cursor   int
+	selected bool
+}
+
+func (m projectPickerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m projectPickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "down", "j":
+			if m.cursor < len(m.projects)-1 {
+				m.cursor++
+			}
+
+		case "enter":
+			if len(m.projects) > 0 {
+				m.selected = true
+				return m, tea.Quit
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m projectPickerModel) View() string {
+	var s strings.Builder
+
+	s.WriteString("\n\033[1;38;5;205m📁 Recent Projects\033[0m\n\n")
+
+	if len(m.projects) == 0 {
+		s.WriteString("\033[38;5;240mNo projects yet. Run LlamaSidekick inside a project directory to add it.\033[0m\n\n")
+		s.WriteString("\033[38;5;240mPress q to go back\033[0m\n")
+		return s.String()
+	}
+
+	for i, p := range m.projects {
+		cursor := "  "
+		name := p.Name
+		if p.Root == m.current {
+			name += " (current)"
+		}
+		if m.cursor == i {
+			cursor = "> "
+			s.WriteString(cursor + "\033[1;38;5;170m" + name + "\033[0m\n")
+		} else {
+			s.WriteString(cursor + name + "\n")
+		}
+		s.WriteString(fmt.Sprintf("  \033[38;5;240m%s · last used %s\033[0m\n", p.Root, p.LastUsed.Format("2006-01-02 15:04")))
+	}
+
+	s.WriteString("\n\033[38;5;240mPress Enter to switch, q to go back\033[0m\n")
+
+	return s.String()
+}
+
+// RunProjectPicker shows recently used projects and returns the selected one
+func RunProjectPicker(current string) (projects.Project, bool, error) {
+	reg, err := projects.Load()
+	if err != nil {
+		return projects.Project{}, false, err
+	}
+
+	m, err := runProgram(projectPickerModel{projects: reg.Recent(), current: current})
+	if err != nil {
+		return projects.Project{}, false, fmt.Errorf("error running project picker: %w", err)
+	}
+
+	model := m.(projectPickerModel)
+	if !model.selected {
+		return projects.Project{}, false, nil
+	}
+	return model.projects[model.cursor], true, nil
+}
+
+// switchProject makes project the working directory, loads its session into sess and
+// applies the models remembered for it
+func switchProject(cfg *config.Config, sess *session.Session, project projects.Project) error {
+	if err := os.Chdir(project.Root); err != nil {
+		return fmt.Errorf("failed to switch to %s: %w", project.Root, err)
+	}
+
+	*sess = *loadProjectSession(project.Root)
+
+	enterProject(cfg, sess)
+	return nil
+}
+
+// enterProject marks the session's project as recently used and applies its preferred models
+func enterProject(cfg *config.Config, sess *session.Session) {
+	reg, err := projects.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load project registry: %v\n", err)
+		projects.Project{}.ApplyModels(cfg)
+		return
+	}
+	// A project without remembered models still drops the previous project's
+	project, _ := reg.Find(sess.ProjectRoot)
+	project.ApplyModels(cfg)
+	reg.Touch(sess.ProjectRoot, sess.ID)
+	if err := reg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save project registry: %v\n", err)
+	}
+}
+
+// runProjectsCommand handles /projects (picker), /projects pin (remember current models)
+// and the project's write rules: /projects allow|deny <pattern> and /projects rules [clear]
+func runProjectsCommand(cfg *config.Config, sess *session.Session, args string) error {
+	command, pattern, _ := strings.Cut(args, " ")
+	pattern = strings.TrimSpace(pattern)
+	switch command {
+	case "":
+		project, ok, err := RunProjectPicker(sess.ProjectRoot)
+		if err != nil || !ok {
+			return err
+		}
+		if err := switchProject(cfg, sess, project); err != nil {
+			return err
+		}
+		fmt.Printf("\033[38;5;10mSwitched to %s (%d messages in session)\033[0m\n", project.Root, len(sess.History))
+		return nil
+	case "pin":
+		reg, err := projects.Load()
+		if err != nil {
+			return err
+		}
+		reg.PinModels(sess.ProjectRoot, cfg.Models)
+		if err := reg.Save(); err != nil {
+			return err
+		}
+		fmt.Println("\033[38;5;10mCurrent model assignments pinned to this project\033[0m")
+		return nil
+	case "allow", "deny":
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("usage: /projects %s <pattern>, e.g. deploy/** or *.env", command)
+		}
+		return updateWriteRules(cfg, sess, func(p *projects.Project) {
+			if command == "allow" {
+				p.AllowWrite = append(p.AllowWrite, pattern)
+			} else {
+				p.DenyWrite = append(p.DenyWrite, pattern)
+			}
+		})
+	case "rules":
+		switch pattern {
+		case "":
+			return printWriteRules(cfg, sess)
+		case "clear":
+			return updateWriteRules(cfg, sess, func(p *projects.Project) {
+				p.AllowWrite, p.DenyWrite = nil, nil
+			})
+		}
+	}
+	return fmt.Errorf("usage: /projects [pin | allow <pattern> | deny <pattern> | rules [clear]]")
+}
+
+// updateWriteRules changes the write rules remembered for the session's project and
+// prints the rules in effect
+func updateWriteRules(cfg *config.Config, sess *session.Session, change func(p *projects.Project)) error {
+	reg, err := projects.Load()
+	if err != nil {
+		return err
+	}
+	project, _ := reg.Find(sess.ProjectRoot)
+	change(&project)
+	reg.SetWriteRules(sess.ProjectRoot, project.AllowWrite, project.DenyWrite)
+	if err := reg.Save(); err != nil {
+		return err
+	}
+	fmt.Println("\033[38;5;10m✓ Write rules of this project updated\033[0m")
+	return printWriteRules(cfg, sess)
+}
+
+// printWriteRules lists the patterns of the paths that may and may not be written in the
+// session's project: edits.allow_write and edits.deny_write, then the project's own
+func printWriteRules(cfg *config.Config, sess *session.Session) error {
+	reg, err := projects.Load()
+	if err != nil {
+		return err
+	}
+	project, _ := reg.Find(sess.ProjectRoot)
+	allowed := describePatterns(cfg.Edits.AllowWrite, project.AllowWrite)
+	if allowed == "" {
+		allowed = "every path"
+	}
+	denied := describePatterns(cfg.Edits.DenyWrite, project.DenyWrite)
+	if denied == "" {
+		denied = "nothing"
+	}
+	fmt.Printf("\033[38;5;240mMay be written:\033[0m %s\n", allowed)
+	fmt.Printf("\033[38;5;240mNever written:\033[0m  %s\n", denied)
+	return nil
+}
+
+// describePatterns lists the patterns from the config and the project, marking the latter
+func describePatterns(fromConfig, fromProject []string) string {
+	list := slices.Clone(fromConfig)
+	for _, pattern := range fromProject {
+		list = append(list, pattern+" (project)")
+	}
+	return strings.Join(list, ", ")
+}
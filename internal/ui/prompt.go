@@ -3,78 +3,84 @@ package ui
 import (
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/atotto/clipboard"
-	"github.com/briandowns/spinner"
 	"github.com/chzyer/readline"
 	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
 	"github.com/yourusername/llamasidekick/internal/modes"
 	"github.com/yourusername/llamasidekick/internal/ollama"
-	"github.com/yourusername/llamasidekick/internal/renderer"
 	"github.com/yourusername/llamasidekick/internal/session"
 )
 
-// autoCompleter provides tab completion for commands
-type autoCompleter struct{}
-
-func modeForCommand(command string) modes.Mode {
-	switch command {
-	case "plan":
-		return &modes.PlanMode{}
-	case "edit":
-		return &modes.EditMode{}
-	case "agent":
-		return &modes.AgentMode{}
-	case "cmd":
-		return &modes.CmdMode{}
-	case "ask":
-		return &modes.AskMode{}
-	default:
+// modeForCommand returns the built-in or custom mode called command, or nil
+func modeForCommand(cfg *config.Config, command string) modes.Mode {
+	e, ok := modes.NewRegistry(cfg).Lookup(command)
+	if !ok {
 		return nil
 	}
+	return e.Mode
+}
+
+// slashCommands returns every slash command: the modes', then the others. Custom modes
+// can't be named after the others, since those are handled first.
+func slashCommands(cfg *config.Config) []string {
+	commands := modes.NewRegistry(cfg).Commands()
+	for _, name := range config.PromptCommands {
+		commands = append(commands, "/"+name)
+	}
+	return commands
 }
 
 type processInputMode interface {
 	ProcessInput(client *ollama.Client, sess *session.Session, cfg *config.Config, input string) error
 }
 
-func (a *autoCompleter) Do(line []rune, pos int) (newLine [][]rune, length int) {
-	lineStr := string(line)
-	
-	// Only autocomplete at the beginning of the line
-	if !strings.HasPrefix(lineStr, "/") {
-		return nil, 0
-	}
-	
-	commands := []string{"/plan", "/edit", "/agent", "/cmd", "/ask", "/menu", "/clear"}
-	
-	var suggestions [][]rune
-	for _, cmd := range commands {
-		if strings.HasPrefix(cmd, lineStr) {
-			suggestions = append(suggestions, []rune(cmd[len(lineStr):]))
-		}
+// historyPath returns the file the prompt history is kept in: in the data dir, or the
+// temp dir (%TEMP% on Windows) if there is none
+func historyPath() string {
+	if dataDir, err := config.GetDataDir(); err == nil {
+		return filepath.Join(dataDir, "history")
 	}
-	
-	return suggestions, len(lineStr)
+	return filepath.Join(os.TempDir(), "llamasidekick_history")
 }
 
 // RunPrompt shows a command prompt that accepts /mode commands or 'm' for menu
 func RunPrompt(cfg *config.Config, client *ollama.Client, sess *session.Session, version string) error {
 	rl, err := readline.NewEx(&readline.Config{
 		Prompt:          "> ",
-		HistoryFile:     "/tmp/llamasidekick_history",
-		AutoComplete:    &autoCompleter{},
+		HistoryFile:     historyPath(),
+		AutoComplete:    &autoCompleter{cfg: cfg, sess: sess},
 		InterruptPrompt: "^C",
 		EOFPrompt:       "exit",
+		// Lines are added to the history below, unless they are private
+		DisableAutoSaveHistory: true,
 	})
 	if err != nil {
 		return err
 	}
 	defer rl.Close()
 	
+	// The last answer, which /apply, /copy, /run, /more and /regen act on
+	var last *lastTurn
+	// The last prompt written with /e, which a bare /e reopens
+	var draft string
+	// Generation options changed with /temp, /ctx and /seed, shown in the prompt
+	opts := newSessionOptions(cfg)
+	rl.SetPrompt(opts.prompt(cfg))
+	// The response cache, set aside while a private prompt runs
+	var privateCache ollama.Cache
+	
 	for {
+		flushOutput()
+		sess.Private = false
+		if privateCache != nil {
+			client.Cache, privateCache = privateCache, nil
+		}
 		line, err := rl.Readline()
 		if err == readline.ErrInterrupt {
 			if len(line) == 0 {
@@ -92,6 +98,44 @@ func RunPrompt(cfg *config.Config, client *ollama.Client, sess *session.Session,
 			continue
 		}
 		
+		// A leading space or /private keeps the input out of the history file, the saved
+		// session and the response cache, e.g. for prompts with credentials in them
+		private := strings.HasPrefix(line, " ")
+		if input == "/private" || strings.HasPrefix(input, "/private ") {
+			private = true
+			input = strings.TrimSpace(strings.TrimPrefix(input, "/private"))
+			if input == "" {
+				fmt.Println("\033[38;5;240mUsage: /private <prompt or command>, or start the line with a space\033[0m")
+				continue
+			}
+		}
+		if private {
+			sess.Private = true
+			privateCache, client.Cache = client.Cache, nil
+			fmt.Println("\033[38;5;240m(Private: this prompt and its answer are not saved)\033[0m")
+		} else if err := rl.SaveHistory(line); err != nil {
+			fmt.Printf("\033[38;5;240mWarning: failed to save history: %v\033[0m\n", err)
+		}
+		
+		// Compose the prompt in $EDITOR
+		if input == "/e" || strings.HasPrefix(input, "/e ") {
+			if text := strings.TrimSpace(strings.TrimPrefix(input, "/e")); text != "" {
+				draft = text
+			}
+			edited, err := editDraft(draft)
+			if err != nil {
+				hint.Print(err)
+				continue
+			}
+			if edited == "" {
+				fmt.Println("\033[38;5;240mEmpty prompt, nothing sent\033[0m")
+				continue
+			}
+			draft = edited
+			fmt.Println("\033[38;5;240m" + edited + "\033[0m")
+			input = draftInput(edited)
+		}
+		
 		// Check for quit
 		if input == "q" || input == "quit" || input == "exit" {
 			return nil
@@ -109,7 +153,8 @@ func RunPrompt(cfg *config.Config, client *ollama.Client, sess *session.Session,
 		// Check for clear command
 		if input == "/clear" || input == "clear" {
 			// Clear the conversation history
-			sess.History = []session.Message{}
+			sess.Clear()
+			last = nil
 			if err := sess.Save(); err != nil {
 				fmt.Printf("\033[38;5;9mError saving session: %v\033[0m\n", err)
 			} else {
@@ -118,6 +163,276 @@ func RunPrompt(cfg *config.Config, client *ollama.Client, sess *session.Session,
 			continue
 		}
 		
+		if input == "/compact" {
+			if err := runCompactCommand(cfg, client, sess); err != nil {
+				hint.Print(err)
+			} else {
+				last = nil
+			}
+			continue
+		}
+		
+		if input == "/pin" {
+			if err := runPinCommand(sess); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		if input == "/rollback" || strings.HasPrefix(input, "/rollback ") {
+			if rolledBack, err := runRollbackCommand(cfg, sess, strings.Fields(strings.TrimPrefix(input, "/rollback"))); err != nil {
+				hint.Print(err)
+			} else if rolledBack {
+				last = nil
+			}
+			continue
+		}
+		
+		if input == "/tasks" {
+			if err := runTasksCommand(sess); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		// Check for config edit command
+		if input == "/config" {
+			newCfg, err := RunConfigEdit(cfg)
+			if err != nil {
+				hint.Print(err)
+				continue
+			}
+			apiKey, err := newCfg.OllamaAPIKey()
+			if err != nil {
+				hint.Print(err)
+				continue
+			}
+			// Apply in place so everything holding cfg sees the new settings
+			*cfg = *newCfg
+			client.Host = cfg.Ollama.Host
+			client.Debug = cfg.Ollama.Debug
+			client.APIKey = apiKey
+			attachBudget(cfg, client)
+			attachCache(cfg, client)
+			opts.rebase(cfg)
+			opts.apply(cfg, client)
+			rl.SetPrompt(opts.prompt(cfg))
+			applyRenderStyle(cfg)
+			fmt.Println("\033[38;5;10mConfig reloaded!\033[0m")
+			continue
+		}
+		
+		// Check for projects command
+		if input == "/projects" || strings.HasPrefix(input, "/projects ") {
+			if err := runProjectsCommand(cfg, sess, strings.TrimSpace(strings.TrimPrefix(input, "/projects"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		if input == "/sessions" || strings.HasPrefix(input, "/sessions ") {
+			switched, err := runSessionsCommand(cfg, client, sess, strings.TrimSpace(strings.TrimPrefix(input, "/sessions")))
+			if err != nil {
+				hint.Print(err)
+			}
+			if switched {
+				last = nil
+			}
+			continue
+		}
+		
+		// Check for dry-run toggle (applies to this run only)
+		if input == "/dryrun" {
+			if cfg.Edits.ReadOnly {
+				fmt.Println("\033[38;5;214mRead-only mode is on - changes are only shown as diffs\033[0m")
+				continue
+			}
+			cfg.Edits.DryRun = !cfg.Edits.DryRun
+			if cfg.Edits.DryRun {
+				fmt.Println("\033[38;5;214mDry run ON - Edit and Agent will show diffs without writing files\033[0m")
+			} else {
+				fmt.Println("\033[38;5;10mDry run OFF - changes will be written\033[0m")
+			}
+			continue
+		}
+		
+		if input == "/yolo" || strings.HasPrefix(input, "/yolo ") {
+			if err := runYoloCommand(cfg, opts, strings.TrimSpace(strings.TrimPrefix(input, "/yolo"))); err != nil {
+				hint.Print(err)
+			}
+			rl.SetPrompt(opts.prompt(cfg))
+			continue
+		}
+		
+		if input == "/brief" || strings.HasPrefix(input, "/brief ") {
+			if err := runBriefCommand(cfg, opts, strings.TrimSpace(strings.TrimPrefix(input, "/brief"))); err != nil {
+				hint.Print(err)
+			}
+			rl.SetPrompt(opts.prompt(cfg))
+			continue
+		}
+		
+		if input == "/think" || strings.HasPrefix(input, "/think ") {
+			if err := runThinkCommand(cfg, last, strings.TrimSpace(strings.TrimPrefix(input, "/think"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		// Check for hunk-by-hunk toggle (applies to this run only)
+		if input == "/hunks" {
+			cfg.Edits.Hunks = !cfg.Edits.Hunks
+			if cfg.Edits.Hunks {
+				fmt.Println("\033[38;5;10mHunk review ON - Edit mode asks before applying each hunk\033[0m")
+			} else {
+				fmt.Println("\033[38;5;10mHunk review OFF - Edit mode writes the whole change\033[0m")
+			}
+			continue
+		}
+		
+		// Check for restore command
+		if input == "/restore" || strings.HasPrefix(input, "/restore ") {
+			if err := runRestoreCommand(cfg, sess, strings.Fields(strings.TrimPrefix(input, "/restore"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		// Check for trash command
+		if input == "/trash" || strings.HasPrefix(input, "/trash ") {
+			if err := runTrashCommand(cfg, sess, strings.Fields(strings.TrimPrefix(input, "/trash"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		// Check for MCP tools command
+		if input == "/mcp" {
+			runMCPCommand(cfg)
+			continue
+		}
+		
+		// Check for test fixing command
+		if input == "/fix-tests" || strings.HasPrefix(input, "/fix-tests ") {
+			if err := runFixTestsCommand(cfg, client, sess, strings.TrimSpace(strings.TrimPrefix(input, "/fix-tests"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		// Check for build fixing command
+		if input == "/build" || strings.HasPrefix(input, "/build ") {
+			if err := runBuildCommand(cfg, client, sess, strings.TrimSpace(strings.TrimPrefix(input, "/build"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		if input == "/scaffold" || strings.HasPrefix(input, "/scaffold ") {
+			if err := modes.Scaffold(client, sess, cfg, strings.TrimSpace(strings.TrimPrefix(input, "/scaffold"))); err != nil {
+				hint.Print(err)
+			}
+			last = nil
+			continue
+		}
+		
+		if input == "/why" {
+			printRetrieval()
+			continue
+		}
+		
+		if input == "/grep" || strings.HasPrefix(input, "/grep ") {
+			if err := runGrepCommand(cfg, sess, strings.TrimSpace(strings.TrimPrefix(input, "/grep"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		if input == "/where" || strings.HasPrefix(input, "/where ") || input == "/callers" || strings.HasPrefix(input, "/callers ") {
+			command, args, _ := strings.Cut(input, " ")
+			if err := runStructureCommand(cfg, client, sess, command, strings.TrimSpace(args)); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		if input == "/compare" || strings.HasPrefix(input, "/compare ") {
+			if err := runCompareCommand(cfg, client, sess, strings.TrimSpace(strings.TrimPrefix(input, "/compare"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		if input == "/preview" || strings.HasPrefix(input, "/preview ") {
+			if err := runPreviewCommand(cfg, client, sess, strings.TrimSpace(strings.TrimPrefix(input, "/preview"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		if input == "/aside" || strings.HasPrefix(input, "/aside ") {
+			if err := runAsideCommand(cfg, client, sess, strings.TrimSpace(strings.TrimPrefix(input, "/aside"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		if input == "/budget" || strings.HasPrefix(input, "/budget ") {
+			if err := runBudgetCommand(strings.TrimSpace(strings.TrimPrefix(input, "/budget"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		if input == "/cache" || strings.HasPrefix(input, "/cache ") {
+			if err := runCacheCommand(cfg, strings.TrimSpace(strings.TrimPrefix(input, "/cache"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		if input == "/model" || strings.HasPrefix(input, "/model ") {
+			if err := runModelCommand(cfg, client, opts, strings.TrimSpace(strings.TrimPrefix(input, "/model"))); err != nil {
+				hint.Print(err)
+			}
+			rl.SetPrompt(opts.prompt(cfg))
+			continue
+		}
+		
+		if command, args, _ := strings.Cut(input, " "); command == "/temp" || command == "/ctx" || command == "/seed" {
+			if err := runOptionCommand(cfg, client, opts, command, args); err != nil {
+				hint.Print(err)
+			}
+			rl.SetPrompt(opts.prompt(cfg))
+			continue
+		}
+		
+		if input == "/share" || strings.HasPrefix(input, "/share ") {
+			if err := runShareCommand(cfg, sess, strings.TrimSpace(strings.TrimPrefix(input, "/share"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
+		if isFollowUpCommand(input) {
+			turn, err := runFollowUp(cfg, client, sess, last, input)
+			if err != nil {
+				hint.Print(err)
+			}
+			if turn != nil {
+				last = turn
+			}
+			continue
+		}
+		
+		// Check for template command
+		if input == "/tpl" || strings.HasPrefix(input, "/tpl ") {
+			if err := runTemplateCommand(cfg, client, sess, strings.TrimSpace(strings.TrimPrefix(input, "/tpl"))); err != nil {
+				hint.Print(err)
+			}
+			continue
+		}
+		
 		// Parse slash commands
 		if strings.HasPrefix(input, "/") {
 			parts := strings.SplitN(input, " ", 2)
@@ -127,10 +442,10 @@ func RunPrompt(cfg *config.Config, client *ollama.Client, sess *session.Session,
 				prompt = parts[1]
 			}
 			
-			mode := modeForCommand(command)
+			mode := modeForCommand(cfg, command)
 			if mode == nil {
 				fmt.Printf("\033[38;5;9mUnknown command: /%s\033[0m\n", command)
-				fmt.Println("\033[38;5;240mAvailable commands: /plan, /edit, /agent, /cmd, /ask, /clear, or 'm' for menu\033[0m")
+				fmt.Println("\033[38;5;240mAvailable commands: " + strings.Join(slashCommands(cfg), ", ") + ", or 'm' for menu\033[0m")
 				continue
 			}
 			
@@ -143,14 +458,8 @@ func RunPrompt(cfg *config.Config, client *ollama.Client, sess *session.Session,
 			
 			// If there's a prompt, run single-shot
 			if prompt != "" {
-				if pim, ok := mode.(processInputMode); ok {
-					if err := pim.ProcessInput(client, sess, cfg, prompt); err != nil {
-						fmt.Printf("\033[38;5;9mError: %v\033[0m\n", err)
-					}
-				} else {
-					if err := executeQuickCommand(mode, client, sess, cfg, prompt); err != nil {
-						fmt.Printf("\033[38;5;9mError: %v\033[0m\n", err)
-					}
+				if turn := runModeInput(mode, command, client, sess, cfg, prompt); turn != nil {
+					last = turn
 				}
 			} else {
 				// No prompt, enter interactive mode
@@ -161,58 +470,59 @@ func RunPrompt(cfg *config.Config, client *ollama.Client, sess *session.Session,
 			continue
 		}
 		
-		// Default: continue the last-used mode (fallback to plan)
-		modeKey := sess.Mode
-		if modeKey == "" {
-			modeKey = sess.LastMode
-		}
+		// Default: use the configured default mode (last-used mode unless configured otherwise)
+		modeKey, modeCfg := defaultModeForInput(cfg, client, sess, input)
 		if modeKey == "" {
-			modeKey = modes.ModePlan
+			continue
 		}
-		mode := modeForCommand(modeKey)
+		mode := modeForCommand(cfg, modeKey)
 		if mode == nil {
-			mode = &modes.PlanMode{}
+			mode, modeKey = &modes.PlanMode{}, modes.ModePlan
 		}
 
-		if pim, ok := mode.(processInputMode); ok {
-			if err := pim.ProcessInput(client, sess, cfg, input); err != nil {
-				fmt.Printf("\033[38;5;9mError: %v\033[0m\n", err)
-			}
-		} else {
-			if err := executeQuickCommand(mode, client, sess, cfg, input); err != nil {
-				fmt.Printf("\033[38;5;9mError: %v\033[0m\n", err)
-			}
+		if turn := runModeInput(mode, modeKey, client, sess, modeCfg, input); turn != nil {
+			last = turn
 		}
 	}
 	
 	return nil
 }
 
+// defaultModeForInput returns the mode key for input typed without a slash command,
+// based on ui.default_mode, and the config to run it with. An empty key means the
+// routed input was cancelled.
+func defaultModeForInput(cfg *config.Config, client *ollama.Client, sess *session.Session, input string) (string, *config.Config) {
+	switch cfg.UI.DefaultMode {
+	case "auto":
+		return routeInput(cfg, client, input)
+	case "", "last":
+		// Continue the last-used mode (fallback to plan)
+		modeKey := sess.Mode
+		if modeKey == "" {
+			modeKey = sess.LastMode
+		}
+		if modeKey == "" {
+			modeKey = modes.ModePlan
+		}
+		return modeKey, cfg
+	default:
+		return cfg.UI.DefaultMode, cfg
+	}
+}
+
 // executeQuickCommand executes a single command and returns to prompt
-func executeQuickCommand(mode modes.Mode, client *ollama.Client, sess *session.Session, cfg *config.Config, prompt string) error {
+func executeQuickCommand(mode modes.Mode, modeStr string, client *ollama.Client, sess *session.Session, cfg *config.Config, prompt string) error {
 	// Detect and read files from the prompt
-	enhancedPrompt := modes.ReadFilesFromInput(prompt)
+	enhancedPrompt := modes.ReadInputContext(client, prompt, sess, cfg.Context)
 	
 	sess.AddMessage("user", prompt)
 	
 	fmt.Print("\n\033[1;38;5;170m" + mode.Name() + ":\033[0m ")
 	
 	var fullResponse strings.Builder
-	var modeStr string
-	switch mode.(type) {
-	case *modes.PlanMode:
-		modeStr = "plan"
-	case *modes.EditMode:
-		modeStr = "edit"
-	case *modes.AgentMode:
-		modeStr = "agent"
-	case *modes.CmdMode:
-		modeStr = "cmd"
-	case *modes.AskMode:
-		modeStr = "ask"
-	}
 	
 	modelName := cfg.GetModelForMode(modeStr)
+	defer modes.LimitLength(client, cfg, modeStr)()
 	
 	// Print mode header for CMD mode
 	if modeStr == "cmd" {
@@ -239,21 +549,28 @@ func executeQuickCommand(mode modes.Mode, client *ollama.Client, sess *session.S
 	}
 	
 	// Start spinner
-	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-	s.Suffix = " Thinking..."
+	s := modes.NewModelStatus(modeStr, modelName)
 	s.Start()
 	
+	md := modes.NewResponseDisplay(cfg, modeStr, func() {
+		s.Stop()
+		fmt.Println() // Add newline after spinner
+	})
 	err := client.GenerateWithModel(
 		modelName,
 		conversationContext.String(),
-		mode.GetSystemPrompt(),
+		modes.ProjectSystemPrompt(modeStr, mode.GetSystemPrompt(), sess.ProjectRoot, cfg.Context)+modes.LengthPrompt(cfg, modeStr),
 		cfg.Ollama.Temperature,
 		func(chunk string) error {
-			if s.Active() {
+			s.Token()
+			if modeStr == "cmd" && s.Active() {
 				s.Stop()
 				fmt.Println() // Add newline after spinner
 			}
 			fullResponse.WriteString(chunk)
+			if modeStr != "cmd" {
+				md.Write(chunk)
+			}
 			return nil
 		},
 	)
@@ -270,23 +587,16 @@ func executeQuickCommand(mode modes.Mode, client *ollama.Client, sess *session.S
 	
 	// Render markdown for non-CMD modes
 	if modeStr != "cmd" {
-		renderedMd := renderer.RenderMarkdown(response)
-		fmt.Println(renderedMd)
+		fmt.Println(md.Finish())
 	} else {
 		// CMD mode: just print plain text
 		fmt.Println(response)
 	}
 	
-	// Handle CMD mode clipboard copying
+	// Handle CMD mode clipboard copying: the commands, never an explanation around them
 	if modeStr == "cmd" {
-		// Copy the raw response (clean command) to clipboard
-		cleanResponse := strings.TrimSpace(response)
-		if cleanResponse != "" {
-			if err := clipboard.WriteAll(cleanResponse); err == nil {
-				fmt.Println()
-				fmt.Println("\033[1;32m✓ Copied to clipboard\033[0m")
-			}
-		}
+		fmt.Println()
+		modes.CopyCommands(response)
 	}
 	
 	fmt.Println()
@@ -300,3 +610,187 @@ func executeQuickCommand(mode modes.Mode, client *ollama.Client, sess *session.S
 	
 	return nil
 }
+
+// runTemplateCommand expands a prompt template and runs it in the template's mode.
+// With no arguments it lists the available templates.
+func runTemplateCommand(cfg *config.Config, client *ollama.Client, sess *session.Session, args string) error {
+	templates := modes.LoadTemplates(cfg)
+	
+	if args == "" {
+		if len(templates) == 0 {
+			fmt.Println("\033[38;5;240mNo templates defined. Add them under 'templates:' in config.yaml or as templates/<name>.md in the config dir.\033[0m")
+			return nil
+		}
+		fmt.Println("\033[1mTemplates:\033[0m")
+		for _, name := range modes.TemplateNames(templates) {
+			t := templates[name]
+			line := "  " + name
+			if t.Description != "" {
+				line += " \033[38;5;240m- " + t.Description + "\033[0m"
+			}
+			fmt.Println(line)
+		}
+		fmt.Println("\033[38;5;240mUsage: /tpl <name> [args]\033[0m")
+		return nil
+	}
+	
+	parts := strings.SplitN(args, " ", 2)
+	name := parts[0]
+	rest := ""
+	if len(parts) > 1 {
+		rest = parts[1]
+	}
+	
+	t, ok := templates[name]
+	if !ok {
+		return fmt.Errorf("unknown template: %s (run /tpl to list templates)", name)
+	}
+	
+	selection := ""
+	if strings.Contains(t.Prompt, "{selection}") {
+		if pasted, err := clipboard.ReadAll(); err == nil {
+			selection = pasted
+		}
+	}
+	prompt := modes.ExpandTemplate(t, rest, selection)
+	
+	modeKey := t.Mode
+	if modeKey == "" {
+		modeKey = sess.Mode
+	}
+	if modeKey == "" {
+		modeKey = modes.ModePlan
+	}
+	mode := modeForCommand(cfg, modeKey)
+	if mode == nil {
+		return fmt.Errorf("template %s references unknown mode: %s", name, modeKey)
+	}
+	
+	if pim, ok := mode.(processInputMode); ok {
+		return pim.ProcessInput(client, sess, cfg, prompt)
+	}
+	return executeQuickCommand(mode, modeKey, client, sess, cfg, prompt)
+}
+
+// runRestoreCommand handles /restore <file> [version|list]
+func runRestoreCommand(cfg *config.Config, sess *session.Session, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("usage: /restore <file> [version|list]")
+	}
+	store, err := modes.OpenBackupStore(cfg)
+	if err != nil {
+		return err
+	}
+	file := args[0]
+	
+	if len(args) == 2 && args[1] == "list" {
+		backups, err := store.List(sess.ProjectRoot, file)
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			fmt.Printf("\033[38;5;240mNo backups for %s\033[0m\n", file)
+			return nil
+		}
+		fmt.Printf("\033[1mBackups of %s:\033[0m\n", file)
+		for _, b := range backups {
+			fmt.Printf("  %d  %s  \033[38;5;240m%d bytes\033[0m\n", b.Version, b.Time.Format("2006-01-02 15:04:05"), b.Size)
+		}
+		return nil
+	}
+	
+	if cfg.Edits.ReadOnly {
+		return fmt.Errorf("read-only mode: restoring files is disabled")
+	}
+	version := 1
+	if len(args) == 2 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q: use a number from /restore %s list", args[1], file)
+		}
+		version = v
+	}
+	
+	restored, err := store.Restore(sess.ProjectRoot, file, version)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\033[38;5;10m✓ Restored %s to the version from %s\033[0m\n", file, restored.Time.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// runTrashCommand handles /trash [list|restore <id>|empty]
+func runTrashCommand(cfg *config.Config, sess *session.Session, args []string) error {
+	trash, err := modes.OpenTrash()
+	if err != nil {
+		return err
+	}
+	if !cfg.Backups.Trash {
+		fmt.Println("\033[38;5;240mOnly deleted files are kept in the trash; set backups.trash: true in the config to keep every replaced version\033[0m")
+	}
+	
+	if len(args) == 0 || args[0] == "list" {
+		entries, err := trash.List(sess.ProjectRoot)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("\033[38;5;240mTrash is empty\033[0m")
+			return nil
+		}
+		fmt.Println("\033[1mTrash:\033[0m")
+		for _, e := range entries {
+			fmt.Printf("  %d  %s  %s  \033[38;5;240m%d bytes\033[0m\n", e.ID, e.Time.Format("2006-01-02 15:04:05"), e.RelPath, e.Size)
+		}
+		fmt.Println("\033[38;5;240mRestore with /trash restore <id>\033[0m")
+		return nil
+	}
+	
+	switch args[0] {
+	case "restore":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: /trash restore <id>")
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid id %q: use a number from /trash list", args[1])
+		}
+		if cfg.Edits.ReadOnly {
+			return fmt.Errorf("read-only mode: restoring files is disabled")
+		}
+		store, err := modes.OpenBackupStore(cfg)
+		if err != nil {
+			return err
+		}
+		entry, err := trash.Restore(sess.ProjectRoot, id, store)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\033[38;5;10m✓ Restored %s to the version from %s\033[0m\n", entry.RelPath, entry.Time.Format("2006-01-02 15:04:05"))
+	case "empty":
+		removed, err := trash.Empty(sess.ProjectRoot)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\033[38;5;10m✓ Removed %d trashed version(s)\033[0m\n", removed)
+	default:
+		return fmt.Errorf("usage: /trash [list|restore <id>|empty]")
+	}
+	return nil
+}
+
+// runMCPCommand lists the configured MCP servers and the tools Agent mode can call
+func runMCPCommand(cfg *config.Config) {
+	if len(cfg.MCPServerNames()) == 0 {
+		fmt.Println("\033[38;5;240mNo MCP servers configured; add them under mcp.servers in the config\033[0m")
+		return
+	}
+	tools := modes.ConnectMCPServers(cfg)
+	fmt.Println("\033[1mMCP tools available in Agent mode:\033[0m")
+	for _, t := range tools {
+		fmt.Printf("  %s  \033[38;5;240m%s\033[0m\n", t.FullName(), strings.TrimSpace(t.Tool.Description))
+	}
+	if len(tools) == 0 {
+		fmt.Println("\033[38;5;240m  (none)\033[0m")
+	}
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// recapWidth is how much of the last prompt and reply the resume recap shows
+const recapWidth = 100
+
+// resumeSession prints where the session left off and enters its last mode. It returns
+// once the user leaves the mode; a session without history is only reported.
+func resumeSession(cfg *config.Config, client *ollama.Client, sess *session.Session) error {
+	if len(sess.History) == 0 {
+		fmt.Println("\033[38;5;240mNothing to resume yet - this session has no conversation\033[0m")
+		fmt.Println()
+		return nil
+	}
+	mode := modeForCommand(cfg, sess.LastMode)
+	if mode == nil {
+		mode = &modes.PlanMode{}
+	}
+	printRecap(sess, mode.Name())
+	return mode.Run(client, sess, cfg)
+}
+
+// printRecap summarizes the session: its mode, size, and the last exchange
+func printRecap(sess *session.Session, modeName string) {
+	title := fmt.Sprintf("Resuming %s mode", modeName)
+	if sess.Name != "" {
+		title += fmt.Sprintf(" in session %q", sess.Name)
+	}
+	fmt.Printf("\033[1;38;5;75m%s\033[0m \033[38;5;240m(%d messages, last active %s)\033[0m\n",
+		title, len(sess.History), sess.UpdatedAt.Format("2006-01-02 15:04"))
+
+	var lastUser, lastReply string
+	for i := len(sess.History) - 1; i >= 0 && (lastUser == "" || lastReply == ""); i-- {
+		msg := sess.History[i]
+		switch {
+		case msg.Role == "user" && lastUser == "":
+			lastUser = msg.Content
+		case msg.Role == "assistant" && lastReply == "" && lastUser == "":
+			lastReply = msg.Content
+		}
+	}
+	if sess.Title != "" {
+		fmt.Printf("\033[38;5;240m  Title:\033[0m %s\n", sess.Title)
+	}
+	if lastUser != "" {
+		fmt.Printf("\033[38;5;240m  You:\033[0m %s\n", recapLine(lastUser))
+	}
+	if lastReply != "" {
+		fmt.Printf("\033[38;5;240m  Reply:\033[0m %s\n", recapLine(lastReply))
+	}
+	if sess.LastEditedFile != "" {
+		fmt.Printf("\033[38;5;240m  Last edited: %s\033[0m\n", sess.LastEditedFile)
+	}
+	if len(sess.ActiveFiles) > 0 {
+		fmt.Printf("\033[38;5;240m  Context files: %s\033[0m\n", strings.Join(sess.ActiveFiles, ", "))
+	}
+}
+
+// recapLine returns the first non-empty line of text, shortened to recapWidth
+func recapLine(text string) string {
+	line := ""
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			line = l
+			break
+		}
+	}
+	if runes := []rune(line); len(runes) > recapWidth {
+		line = string(runes[:recapWidth-1]) + "…"
+	}
+	return line
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// runRollbackCommand handles /rollback [list|<id>]: it returns the conversation and the
+// files to a checkpoint, the latest one by default. It reports whether it rolled back.
+func runRollbackCommand(cfg *config.Config, sess *session.Session, args []string) (bool, error) {
+	checkpoints, err := sess.Checkpoints()
+	if err != nil {
+		return false, err
+	}
+	if len(checkpoints) == 0 {
+		fmt.Println("\033[38;5;240mNo checkpoints yet; one is taken before each Agent run and multi-file edit\033[0m")
+		return false, nil
+	}
+
+	if len(args) == 1 && args[0] == "list" {
+		fmt.Println("\033[1mCheckpoints:\033[0m")
+		for i := len(checkpoints) - 1; i >= 0; i-- {
+			cp := checkpoints[i]
+			fmt.Printf("  %d  %s  \033[38;5;240m%d file(s) · %s\033[0m\n", cp.ID, cp.Label, len(cp.Files), formatAge(cp.CreatedAt))
+		}
+		fmt.Println("\033[38;5;240mRoll back with /rollback <id>\033[0m")
+		return false, nil
+	}
+	if len(args) > 1 {
+		return false, fmt.Errorf("usage: /rollback [list|<id>]")
+	}
+	id := checkpoints[len(checkpoints)-1].ID
+	if len(args) == 1 {
+		if id, err = strconv.Atoi(args[0]); err != nil {
+			return false, fmt.Errorf("invalid checkpoint %q: use a number from /rollback list", args[0])
+		}
+	}
+
+	cp, tx, err := modes.PrepareRollback(cfg, sess, id)
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("\033[1mRoll back to checkpoint %d\033[0m \033[38;5;240m(%s, %s)\033[0m\n", cp.ID, cp.Label, formatAge(cp.CreatedAt))
+	changes := tx.Changes()
+	if len(changes) == 0 {
+		fmt.Println("  \033[38;5;240mThe files are already as they were; only the conversation goes back\033[0m")
+	}
+	for _, c := range changes {
+		action := "restore"
+		if c.Removed {
+			action = "delete"
+		}
+		fmt.Printf("  %s %s\n", action, c.RelPath)
+	}
+	if later := len(checkpoints) - indexOfCheckpoint(checkpoints, cp.ID) - 1; later > 0 {
+		fmt.Printf("  \033[38;5;240mThis also undoes the %d later checkpoint(s)\033[0m\n", later)
+	}
+	fmt.Print("Roll back? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		fmt.Println("\033[38;5;240mNot rolled back\033[0m")
+		return false, nil
+	}
+
+	if err := modes.FinishRollback(sess, cp, tx); err != nil {
+		return false, err
+	}
+	fmt.Printf("\033[38;5;10m✓ Rolled back to checkpoint %d: %d file(s) restored, conversation back to %d message(s)\033[0m\n", cp.ID, len(changes), len(sess.History))
+	if len(changes) > 0 {
+		fmt.Println("\033[38;5;240m  The replaced versions are backed up (undo with /restore <file>)\033[0m")
+	}
+	return true, nil
+}
+
+// indexOfCheckpoint returns the position of checkpoint id in checkpoints
+func indexOfCheckpoint(checkpoints []session.Checkpoint, id int) int {
+	for i, cp := range checkpoints {
+		if cp.ID == id {
+			return i
+		}
+	}
+	return -1
+}
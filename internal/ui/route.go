@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+// routeInput picks the mode, and the config to run it with, for input typed without a
+// slash command when ui.default_mode is auto. The decision is shown, and with
+// router.confirm it can be accepted, changed to another mode and model, or cancelled,
+// in which case the mode is empty.
+func routeInput(cfg *config.Config, client *ollama.Client, input string) (string, *config.Config) {
+	decision := modes.ClassifyInput(client, cfg, input)
+	fmt.Printf("\033[38;5;240m(routing to /%s%s: %s)\033[0m\n", decision.Mode, routedModelLabel(decision.Model), decision.Reason)
+	if !cfg.Router.Confirm {
+		return decision.Mode, routedConfig(cfg, decision.Model)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("Enter to accept, n to cancel, or another mode and model (e.g. ask llama3): ")
+		answer, err := reader.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if err != nil && answer == "" {
+			return "", cfg
+		}
+		switch strings.ToLower(answer) {
+		case "", "y", "yes":
+			return decision.Mode, routedConfig(cfg, decision.Model)
+		case "n", "no", "q":
+			return "", cfg
+		}
+
+		fields := strings.Fields(answer)
+		mode := strings.TrimPrefix(fields[0], "/")
+		if modeForCommand(cfg, mode) == nil {
+			fmt.Printf("\033[38;5;9mUnknown mode %q\033[0m\n", mode)
+			continue
+		}
+		model := decision.Model
+		if len(fields) > 1 {
+			model = fields[1]
+		}
+		return mode, routedConfig(cfg, model)
+	}
+}
+
+// routedConfig returns cfg with every mode using model, or cfg itself if model is empty
+func routedConfig(cfg *config.Config, model string) *config.Config {
+	if model == "" {
+		return cfg
+	}
+	return cfg.WithModel(model)
+}
+
+func routedModelLabel(model string) string {
+	if model == "" {
+		return ""
+	}
+	return " with " + model
+}
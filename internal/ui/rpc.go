@@ -0,0 +1,447 @@
+package ui
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/safeio"
+)
+
+// DefaultRPCAddr is the address "llamasidekick rpc" listens on when none is given
+const DefaultRPCAddr = "127.0.0.1:7879"
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+	rpcUnauthorized   = -32001
+)
+
+// httpRequestLine matches the first line of an HTTP request, which a web page can send
+// to the port with a cross-origin POST
+var httpRequestLine = regexp.MustCompile(`^[A-Z]+ \S+ HTTP/1\.[01]$`)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcErrorBody   `json:"error,omitempty"`
+}
+
+type rpcErrorBody struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcSelection identifies a line range of a project file (1-based, inclusive)
+type rpcSelection struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+type rpcServer struct {
+	cfg     *config.Config
+	version string
+	token   string // Clients must send it with initialize before anything else
+
+	runMu sync.Mutex // Requests share the working directory's session, so they run one at a time
+}
+
+// rpcConn is one editor connection; responses and notifications may be written from
+// several request goroutines
+type rpcConn struct {
+	writeMu       sync.Mutex
+	w             io.Writer
+	authenticated bool // Set once initialize sent the token; only serveConn uses it
+}
+
+// RunRPC starts a newline-delimited JSON-RPC 2.0 server for editor plugins. addr is a
+// loopback host:port or "unix:<path>" for a Unix domain socket. A token for this run is
+// written to a file only the user can read; clients must send it with initialize.
+func RunRPC(cfg *config.Config, version, addr string) error {
+	if addr == "" {
+		addr = DefaultRPCAddr
+	}
+	network, address := "tcp", addr
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, address = "unix", path
+		// A socket left behind by a previous run would make Listen fail
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+	} else {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+		if !isLoopbackHost(host) {
+			return fmt.Errorf("refusing to listen on %s: editor requests can read and write project files, so only loopback addresses are allowed", addr)
+		}
+	}
+
+	// Nobody is at the terminal to answer interactive questions; they get their defaults
+	if devNull, err := os.Open(os.DevNull); err == nil {
+		os.Stdin = devNull
+	}
+	applyRenderStyle(cfg)
+	defer modes.CloseMCPServers()
+
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	defer listener.Close()
+	if network == "unix" {
+		if err := os.Chmod(address, 0600); err != nil {
+			return fmt.Errorf("failed to restrict socket permissions: %w", err)
+		}
+	}
+	token, tokenPath, err := writeRPCToken()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tokenPath)
+	fmt.Printf("\033[38;5;10mLlamaSidekick editor RPC listening on %s\033[0m\n", addr)
+	fmt.Printf("\033[38;5;240mToken for initialize: %s\033[0m\n", tokenPath)
+	fmt.Printf("\033[38;5;240mProject: %s (Ctrl+C to stop)\033[0m\n", loadWorkingSession().ProjectRoot)
+
+	s := &rpcServer{cfg: cfg, version: version, token: token}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// writeRPCToken makes a random token for this run and writes it to rpc.token in the data
+// dir, readable only by the user
+func writeRPCToken() (token, path string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate RPC token: %w", err)
+	}
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", "", err
+	}
+	path = filepath.Join(dataDir, "rpc.token")
+	// Remove a token left by an earlier run, so the new file gets the new permissions
+	os.Remove(path)
+	token = hex.EncodeToString(buf)
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", "", fmt.Errorf("failed to write RPC token: %w", err)
+	}
+	return token, path, nil
+}
+
+// serveConn reads one request per line until the editor disconnects. Only initialize
+// with the right token is answered until it has been sent, and connections that start
+// like an HTTP request are closed.
+func (s *rpcServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	slog.Info("rpc client connected", "remote", conn.RemoteAddr().String())
+	c := &rpcConn{w: conn}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var wg sync.WaitGroup
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if first && httpRequestLine.MatchString(line) {
+			slog.Warn("rpc connection closed: it sent an HTTP request", "remote", conn.RemoteAddr().String())
+			return
+		}
+		first = false
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			c.reply(nil, nil, &rpcErrorBody{Code: rpcParseError, Message: fmt.Sprintf("invalid JSON: %v", err)})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			c.reply(req.ID, nil, &rpcErrorBody{Code: rpcInvalidRequest, Message: "expected a JSON-RPC 2.0 request with a method"})
+			continue
+		}
+		if !c.authenticated {
+			if req.Method != "initialize" {
+				c.reply(req.ID, nil, &rpcErrorBody{Code: rpcUnauthorized, Message: "call initialize with the token first"})
+				continue
+			}
+			if !s.validToken(req.Params) {
+				slog.Warn("rpc connection closed: wrong token", "remote", conn.RemoteAddr().String())
+				c.reply(req.ID, nil, &rpcErrorBody{Code: rpcUnauthorized, Message: "invalid token"})
+				return
+			}
+			c.authenticated = true
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slog.Info("rpc request", "method", req.Method)
+			result, rpcErr := s.handle(c, req)
+			if req.ID != nil {
+				c.reply(req.ID, result, rpcErr)
+			}
+		}()
+	}
+	wg.Wait()
+	slog.Info("rpc client disconnected", "remote", conn.RemoteAddr().String())
+}
+
+// validToken reports whether the initialize params carry the server's token
+func (s *rpcServer) validToken(raw json.RawMessage) bool {
+	var params struct {
+		Token string `json:"token"`
+	}
+	if decodeParams(raw, &params) != nil || s.token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(params.Token), []byte(s.token)) == 1
+}
+
+func (s *rpcServer) handle(c *rpcConn, req rpcRequest) (any, *rpcErrorBody) {
+	switch req.Method {
+	case "initialize":
+		return map[string]any{"name": "llamasidekick", "version": s.version, "project_root": loadWorkingSession().ProjectRoot}, nil
+	case "context/add":
+		var params struct {
+			Path string `json:"path"`
+		}
+		if err := decodeParams(req.Params, &params); err != nil || params.Path == "" {
+			return nil, invalidParams("context/add needs a \"path\"")
+		}
+		return s.updateActiveFiles(params.Path, true)
+	case "context/remove":
+		var params struct {
+			Path string `json:"path"`
+		}
+		if err := decodeParams(req.Params, &params); err != nil || params.Path == "" {
+			return nil, invalidParams("context/remove needs a \"path\"")
+		}
+		return s.updateActiveFiles(params.Path, false)
+	case "context/list":
+		s.runMu.Lock()
+		defer s.runMu.Unlock()
+		return map[string]any{"active_files": loadWorkingSession().ActiveFiles}, nil
+	case "explain":
+		var params struct {
+			rpcSelection
+			Text     string `json:"text"`
+			Question string `json:"question"`
+		}
+		if err := decodeParams(req.Params, &params); err != nil || params.Path == "" {
+			return nil, invalidParams("explain needs a \"path\" and a line range or \"text\"")
+		}
+		return s.explain(c, req.ID, params.rpcSelection, params.Text, params.Question)
+	case "edit/range":
+		var params struct {
+			rpcSelection
+			Instruction string `json:"instruction"`
+			Apply       bool   `json:"apply"`
+		}
+		if err := decodeParams(req.Params, &params); err != nil || params.Path == "" || params.Instruction == "" {
+			return nil, invalidParams("edit/range needs a \"path\", \"start_line\", \"end_line\" and an \"instruction\"")
+		}
+		return s.editRange(params.rpcSelection, params.Instruction, params.Apply)
+	case "prompt":
+		var params struct {
+			Mode   string `json:"mode"`
+			Prompt string `json:"prompt"`
+			Apply  bool   `json:"apply"`
+		}
+		if err := decodeParams(req.Params, &params); err != nil || params.Prompt == "" {
+			return nil, invalidParams("prompt needs a \"prompt\"")
+		}
+		if params.Mode == "" {
+			params.Mode = modes.ModeAsk
+		}
+		return s.runPrompt(c, req.ID, params.Mode, params.Prompt, params.Apply)
+	case "session/clear":
+		s.runMu.Lock()
+		defer s.runMu.Unlock()
+		sess := loadWorkingSession()
+		sess.Clear()
+		if err := sess.Save(); err != nil {
+			return nil, serverError(fmt.Errorf("failed to save session: %w", err))
+		}
+		return map[string]any{"cleared": true}, nil
+	}
+	return nil, &rpcErrorBody{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}
+}
+
+// updateActiveFiles adds or removes a file from the session's context files
+func (s *rpcServer) updateActiveFiles(path string, add bool) (any, *rpcErrorBody) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	sess := loadWorkingSession()
+	absPath, relPath, err := safeio.ResolveWithinRoot(sess.ProjectRoot, path)
+	if err != nil {
+		return nil, invalidParams(fmt.Sprintf("refusing to use '%s': %v", path, err))
+	}
+	if add {
+		if info, err := os.Stat(absPath); err != nil || info.IsDir() {
+			return nil, invalidParams(fmt.Sprintf("%s is not a file", relPath))
+		}
+		sess.AddFile(relPath)
+	} else {
+		sess.RemoveFile(relPath)
+	}
+	if err := sess.Save(); err != nil {
+		return nil, serverError(fmt.Errorf("failed to save session: %w", err))
+	}
+	return map[string]any{"active_files": sess.ActiveFiles}, nil
+}
+
+// explain runs the selection through Ask mode, streaming the answer as "token" notifications
+func (s *rpcServer) explain(c *rpcConn, id json.RawMessage, sel rpcSelection, text, question string) (any, *rpcErrorBody) {
+	if text == "" {
+		sess := loadWorkingSession()
+		absPath, _, err := safeio.ResolveWithinRoot(sess.ProjectRoot, sel.Path)
+		if err != nil {
+			return nil, invalidParams(fmt.Sprintf("refusing to read '%s': %v", sel.Path, err))
+		}
+		content, err := os.ReadFile(absPath)
+		if err != nil {
+			return nil, invalidParams(fmt.Sprintf("failed to read %s: %v", sel.Path, err))
+		}
+		if text, err = modes.SelectLines(string(content), sel.StartLine, sel.EndLine); err != nil {
+			return nil, invalidParams(err.Error())
+		}
+	}
+	if question == "" {
+		question = "Explain what this code does."
+	}
+	lang := strings.TrimPrefix(filepath.Ext(sel.Path), ".")
+	prompt := fmt.Sprintf("%s\n\nSelection from %s", question, sel.Path)
+	if sel.StartLine > 0 {
+		prompt += fmt.Sprintf(" (lines %d-%d)", sel.StartLine, sel.EndLine)
+	}
+	prompt += fmt.Sprintf(":\n\n```%s\n%s\n```", lang, strings.TrimSuffix(text, "\n"))
+	return s.runPrompt(c, id, modes.ModeAsk, prompt, false)
+}
+
+// runPrompt runs a prompt through a mode with the working directory's session. File
+// changes are only written when apply is set.
+func (s *rpcServer) runPrompt(c *rpcConn, id json.RawMessage, modeKey, prompt string, apply bool) (any, *rpcErrorBody) {
+	pim, ok := modeForCommand(s.cfg, modeKey).(processInputMode)
+	if !ok {
+		return nil, invalidParams(fmt.Sprintf("unknown mode %q", modeKey))
+	}
+
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	client, err := newModeClient(s.cfg)
+	if err != nil {
+		return nil, serverError(err)
+	}
+	client.OnChunk = func(chunk string) error {
+		return c.notify("token", map[string]any{"request_id": id, "text": chunk})
+	}
+	sess := loadWorkingSession()
+	sess.ApproveChanges = func(string) bool { return apply }
+
+	runErr := pim.ProcessInput(client, sess, s.cfg, prompt)
+	result := buildOneShotResult(s.cfg, client, sess, modeKey, runErr)
+	if runErr != nil {
+		return nil, serverError(runErr)
+	}
+	return result, nil
+}
+
+// editRange proposes a replacement for a line range, writing it only when apply is set.
+// Editors that prefer to apply the change to their buffer leave apply off and use the
+// returned replacement.
+func (s *rpcServer) editRange(sel rpcSelection, instruction string, apply bool) (any, *rpcErrorBody) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	client, err := newModeClient(s.cfg)
+	if err != nil {
+		return nil, serverError(err)
+	}
+	sess := loadWorkingSession()
+	sess.ApproveChanges = func(string) bool { return apply }
+
+	edit, err := modes.EditRange(client, sess, s.cfg, sel.Path, sel.StartLine, sel.EndLine, instruction)
+	if err != nil {
+		return nil, serverError(err)
+	}
+	return edit, nil
+}
+
+func (c *rpcConn) reply(id json.RawMessage, result any, rpcErr *rpcErrorBody) error {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	msg := rpcMessage{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr}
+	if rpcErr == nil && result == nil {
+		msg.Result = struct{}{}
+	}
+	return c.write(msg)
+}
+
+func (c *rpcConn) notify(method string, params any) error {
+	return c.write(rpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (c *rpcConn) write(msg rpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return errors.New("missing params")
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func invalidParams(message string) *rpcErrorBody {
+	return &rpcErrorBody{Code: rpcInvalidParams, Message: message}
+}
+
+func serverError(err error) *rpcErrorBody {
+	return &rpcErrorBody{Code: rpcServerError, Message: err.Error()}
+}
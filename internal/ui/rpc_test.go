@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rpcSession runs serveConn on one end of a pipe and returns the other end
+func rpcSession(t *testing.T, s *rpcServer) (net.Conn, *bufio.Reader, <-chan struct{}) {
+	t.Helper()
+	server, client := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		s.serveConn(server)
+		close(done)
+	}()
+	t.Cleanup(func() { client.Close() })
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	return client, bufio.NewReader(client), done
+}
+
+func readReply(t *testing.T, r *bufio.Reader) rpcMessage {
+	t.Helper()
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatalf("expected a reply, got %v", err)
+	}
+	var msg rpcMessage
+	if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		t.Fatalf("invalid reply %q: %v", line, err)
+	}
+	return msg
+}
+
+func TestServeConn_RequiresTheToken(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	s := &rpcServer{version: "test", token: "secret"}
+
+	conn, r, _ := rpcSession(t, s)
+	io.WriteString(conn, `{"jsonrpc":"2.0","id":1,"method":"session/clear"}`+"\n")
+	if msg := readReply(t, r); msg.Error == nil || msg.Error.Code != rpcUnauthorized {
+		t.Fatalf("expected a request before initialize to be refused, got %+v", msg)
+	}
+	io.WriteString(conn, `{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"token":"secret"}}`+"\n")
+	if msg := readReply(t, r); msg.Error != nil {
+		t.Fatalf("expected initialize with the token to succeed, got %+v", msg.Error)
+	}
+
+	conn, r, done := rpcSession(t, s)
+	io.WriteString(conn, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"token":"guess"}}`+"\n")
+	if msg := readReply(t, r); msg.Error == nil || msg.Error.Code != rpcUnauthorized {
+		t.Fatalf("expected a wrong token to be refused, got %+v", msg)
+	}
+	<-done
+}
+
+func TestServeConn_ClosesHTTPRequests(t *testing.T) {
+	s := &rpcServer{version: "test", token: "secret"}
+	conn, r, done := rpcSession(t, s)
+	// A cross-origin text/plain POST from a web page
+	go io.WriteString(conn, strings.Join([]string{
+		"POST / HTTP/1.1",
+		"Host: 127.0.0.1:7879",
+		"Content-Type: text/plain",
+		"",
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"token":"secret"}}`,
+	}, "\r\n")+"\n")
+	<-done
+	if line, err := r.ReadString('\n'); err == nil {
+		t.Fatalf("expected the connection to be closed without a reply, got %q", line)
+	}
+}
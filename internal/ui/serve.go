@@ -0,0 +1,318 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/hint"
+)
+
+// DefaultServeAddr is the address "llamasidekick serve" listens on when none is given
+const DefaultServeAddr = "127.0.0.1:7878"
+
+// approvalTimeout is how long a proposed change waits for a decision before it is rejected
+const approvalTimeout = 10 * time.Minute
+
+// serveRequest is the body of a mode request
+type serveRequest struct {
+	Prompt  string `json:"prompt"`
+	Approve string `json:"approve"` // "ask" (default), "auto" or "never"
+}
+
+// pendingChange is a set of proposed file changes waiting for approval
+type pendingChange struct {
+	ID       string    `json:"id"`
+	Mode     string    `json:"mode"`
+	Diff     string    `json:"diff"`
+	Created  time.Time `json:"created"`
+	decision chan bool
+}
+
+type apiServer struct {
+	cfg     *config.Config
+	version string
+	metrics *serveMetrics
+
+	runMu sync.Mutex // Modes share the working directory's session and the terminal, so prompts run one at a time
+
+	mu      sync.Mutex
+	pending map[string]*pendingChange
+	nextID  int
+}
+
+// RunServe starts a local HTTP API that runs prompts through the same modes as the
+// interactive UI, streaming responses as server-sent events
+func RunServe(cfg *config.Config, version, addr string) error {
+	if addr == "" {
+		addr = DefaultServeAddr
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if !isLoopbackHost(host) {
+		return fmt.Errorf("refusing to listen on %s: the API can read and write project files, so only loopback addresses are allowed", addr)
+	}
+
+	// Nobody is at the terminal to answer interactive questions; they get their defaults
+	if devNull, err := os.Open(os.DevNull); err == nil {
+		os.Stdin = devNull
+	}
+	applyRenderStyle(cfg)
+
+	s := &apiServer{cfg: cfg, version: version, metrics: newServeMetrics(version), pending: map[string]*pendingChange{}}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	fmt.Printf("\033[38;5;10mLlamaSidekick API listening on http://%s\033[0m\n", listener.Addr())
+	fmt.Printf("\033[38;5;240mProject: %s (Ctrl+C to stop)\033[0m\n", loadWorkingSession().ProjectRoot)
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return http.Serve(listener, s.routes(port))
+}
+
+// routes returns the API's handler for a server listening on port
+func (s *apiServer) routes(port string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/health", s.handleHealth)
+	mux.HandleFunc("POST /api/modes/{mode}", s.handleMode)
+	mux.HandleFunc("GET /api/session", s.handleGetSession)
+	mux.HandleFunc("DELETE /api/session", s.handleClearSession)
+	mux.HandleFunc("GET /api/changes", s.handleListChanges)
+	mux.HandleFunc("POST /api/changes/{id}", s.handleDecideChange)
+	mux.HandleFunc("GET /metrics", s.metrics.handleMetrics)
+	return localOnly(port, s.metrics.instrument(mux))
+}
+
+// localOnly rejects requests from web pages on other origins, which browsers would
+// otherwise happily send to a localhost server, and requests for any host but this
+// server's loopback address, which is what a page gets after rebinding its own domain
+// name to 127.0.0.1 (its requests are same-origin, so they carry no Origin)
+func localOnly(port string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if host, hostPort, err := net.SplitHostPort(r.Host); err != nil || !isLoopbackHost(host) || hostPort != port {
+			slog.Warn("rejected API request for another host", "host", r.Host, "path", r.URL.Path)
+			writeJSONError(w, http.StatusForbidden, "requests must be for a loopback address of this server")
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !isLoopbackHost(u.Hostname()) {
+				slog.Warn("rejected cross-origin API request", "origin", origin, "path", r.URL.Path)
+				writeJSONError(w, http.StatusForbidden, "cross-origin requests are not allowed")
+				return
+			}
+		}
+		slog.Info("api request", "method", r.Method, "path", r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *apiServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": s.version})
+}
+
+// handleMode runs a prompt and streams "token", "approval" and finally "done" events
+func (s *apiServer) handleMode(w http.ResponseWriter, r *http.Request) {
+	modeKey := r.PathValue("mode")
+	pim, ok := modeForCommand(s.cfg, modeKey).(processInputMode)
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("unknown mode %q", modeKey))
+		return
+	}
+	var req serveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Prompt == "" {
+		writeJSONError(w, http.StatusBadRequest, "request body must be JSON with a non-empty \"prompt\"")
+		return
+	}
+	if req.Approve == "" {
+		req.Approve = "ask"
+	}
+	if req.Approve != "ask" && req.Approve != "auto" && req.Approve != "never" {
+		writeJSONError(w, http.StatusBadRequest, "approve must be ask, auto or never")
+		return
+	}
+	events, err := newEventStream(w)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	client, err := newModeClient(s.cfg)
+	if err != nil {
+		s.metrics.modeRuns.Inc(modeKey, "error")
+		events.send("done", OneShotResult{Mode: modeKey, Error: err.Error(), Hint: hint.For(err)})
+		return
+	}
+	client.OnChunk = func(chunk string) error {
+		if err := r.Context().Err(); err != nil {
+			return fmt.Errorf("client disconnected: %w", err)
+		}
+		return events.send("token", map[string]string{"text": chunk})
+	}
+
+	sess := loadWorkingSession()
+	sess.ApproveChanges = func(diff string) bool {
+		switch req.Approve {
+		case "auto":
+			return true
+		case "never":
+			return false
+		}
+		return s.awaitApproval(r, events, modeKey, diff)
+	}
+
+	start := time.Now()
+	runErr := pim.ProcessInput(client, sess, s.cfg, req.Prompt)
+	s.metrics.observeRun(modeKey, s.cfg.GetModelForMode(modeKey), client.Stats, time.Since(start), runErr)
+	events.send("done", buildOneShotResult(s.cfg, client, sess, modeKey, runErr))
+}
+
+// awaitApproval publishes a pending change and blocks until it is approved or rejected,
+// the client disconnects or the approval times out
+func (s *apiServer) awaitApproval(r *http.Request, events *eventStream, modeKey, diff string) bool {
+	s.mu.Lock()
+	s.nextID++
+	change := &pendingChange{
+		ID:       strconv.Itoa(s.nextID),
+		Mode:     modeKey,
+		Diff:     diff,
+		Created:  time.Now(),
+		decision: make(chan bool, 1),
+	}
+	s.pending[change.ID] = change
+	s.mu.Unlock()
+	s.metrics.pendingChanges.Add(1)
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, change.ID)
+		s.mu.Unlock()
+		s.metrics.pendingChanges.Add(-1)
+	}()
+
+	if err := events.send("approval", change); err != nil {
+		return false
+	}
+	select {
+	case approved := <-change.decision:
+		return approved
+	case <-r.Context().Done():
+		return false
+	case <-time.After(approvalTimeout):
+		return false
+	}
+}
+
+func (s *apiServer) handleListChanges(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	changes := make([]*pendingChange, 0, len(s.pending))
+	for _, c := range s.pending {
+		changes = append(changes, c)
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, changes)
+}
+
+func (s *apiServer) handleDecideChange(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Approve *bool `json:"approve"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Approve == nil {
+		writeJSONError(w, http.StatusBadRequest, "request body must be JSON with \"approve\": true or false")
+		return
+	}
+
+	s.mu.Lock()
+	change, ok := s.pending[r.PathValue("id")]
+	if ok {
+		delete(s.pending, change.ID)
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "no pending change with that id")
+		return
+	}
+	change.decision <- *body.Approve
+	writeJSON(w, http.StatusOK, map[string]any{"id": change.ID, "approved": *body.Approve})
+}
+
+func (s *apiServer) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	writeJSON(w, http.StatusOK, loadWorkingSession())
+}
+
+func (s *apiServer) handleClearSession(w http.ResponseWriter, r *http.Request) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	sess := loadWorkingSession()
+	sess.Clear()
+	if err := sess.Save(); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save session: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, sess)
+}
+
+// eventStream writes server-sent events
+type eventStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newEventStream(w http.ResponseWriter) (*eventStream, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming is not supported by this connection")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &eventStream{w: w, flusher: flusher}, nil
+}
+
+// send writes one event with data encoded as JSON
+func (e *eventStream) send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", event, err)
+	}
+	if _, err := fmt.Fprintf(e.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", event, err)
+	}
+	e.flusher.Flush()
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
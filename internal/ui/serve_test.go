@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocalOnly(t *testing.T) {
+	s := &apiServer{version: "test", metrics: newServeMetrics("test"), pending: map[string]*pendingChange{}}
+	handler := s.routes("7878")
+
+	cases := []struct {
+		name, method, path, host, origin string
+		want                             int
+	}{
+		{"loopback host", "GET", "/api/health", "127.0.0.1:7878", "", http.StatusOK},
+		{"localhost", "GET", "/api/health", "localhost:7878", "", http.StatusOK},
+		{"ipv6 loopback", "GET", "/api/health", "[::1]:7878", "", http.StatusOK},
+		{"rebound domain", "GET", "/api/session", "evil.example:7878", "", http.StatusForbidden},
+		{"loopback on another port", "GET", "/api/health", "127.0.0.1:8080", "", http.StatusForbidden},
+		{"host without port", "GET", "/api/health", "127.0.0.1", "", http.StatusForbidden},
+		{"foreign origin on a prompt", "POST", "/api/modes/agent", "127.0.0.1:7878", "https://evil.example", http.StatusForbidden},
+		{"foreign origin on an approval", "POST", "/api/changes/1", "127.0.0.1:7878", "https://evil.example", http.StatusForbidden},
+		{"loopback origin", "GET", "/api/health", "127.0.0.1:7878", "http://localhost:5173", http.StatusOK},
+		// Reaching the handler, which knows no change of that id
+		{"loopback origin on an approval", "POST", "/api/changes/1", "127.0.0.1:7878", "http://127.0.0.1:7878", http.StatusNotFound},
+		{"no origin on an approval", "POST", "/api/changes/1", "127.0.0.1:7878", "", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, c.path, strings.NewReader(`{"approve": true}`))
+			r.Host = c.host
+			if c.origin != "" {
+				r.Header.Set("Origin", c.origin)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != c.want {
+				t.Fatalf("expected %d, got %d: %s", c.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
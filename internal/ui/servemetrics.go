@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/metrics"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+)
+
+// serveMetrics are the metrics "llamasidekick serve" exposes at /metrics
+type serveMetrics struct {
+	registry        *metrics.Registry
+	httpRequests    *metrics.Counter
+	httpDuration    *metrics.Histogram
+	modeRuns        *metrics.Counter
+	modeDuration    *metrics.Histogram
+	tokens          *metrics.Counter
+	tokensPerSecond *metrics.Histogram
+	ollamaRequests  *metrics.Counter
+	pendingChanges  *metrics.Gauge
+}
+
+func newServeMetrics(version string) *serveMetrics {
+	r := metrics.NewRegistry()
+	m := &serveMetrics{
+		registry:        r,
+		httpRequests:    r.Counter("llamasidekick_http_requests_total", "API requests by route and status code.", "route", "status"),
+		httpDuration:    r.Histogram("llamasidekick_http_request_duration_seconds", "API request latency, including streamed responses.", metrics.DefaultDurationBuckets, "route"),
+		modeRuns:        r.Counter("llamasidekick_mode_runs_total", "Prompts run by mode and result (ok or error).", "mode", "result"),
+		modeDuration:    r.Histogram("llamasidekick_mode_run_duration_seconds", "Time to run a prompt, including tool calls and approvals.", metrics.DefaultDurationBuckets, "mode"),
+		tokens:          r.Counter("llamasidekick_tokens_total", "Tokens processed by mode and kind (prompt or response).", "mode", "kind"),
+		tokensPerSecond: r.Histogram("llamasidekick_response_tokens_per_second", "Response tokens per second of prompt run time.", []float64{1, 2, 5, 10, 20, 50, 100, 200}, "mode"),
+		ollamaRequests:  r.Counter("llamasidekick_ollama_requests_total", "Requests sent to Ollama by model.", "model"),
+		pendingChanges:  r.Gauge("llamasidekick_pending_changes", "Proposed file changes waiting for approval."),
+	}
+	m.pendingChanges.Set(0)
+	r.Gauge("llamasidekick_build_info", "Build information; always 1.", "version").Set(1, version)
+	r.Gauge("llamasidekick_start_time_seconds", "Unix time the server started.").Set(float64(time.Now().Unix()))
+	return m
+}
+
+// observeRun records a finished prompt
+func (m *serveMetrics) observeRun(modeKey, model string, stats ollama.TokenStats, elapsed time.Duration, runErr error) {
+	result := "ok"
+	if runErr != nil {
+		result = "error"
+	}
+	m.modeRuns.Inc(modeKey, result)
+	m.modeDuration.Observe(elapsed.Seconds(), modeKey)
+	m.tokens.Add(float64(stats.PromptTokens), modeKey, "prompt")
+	m.tokens.Add(float64(stats.ResponseTokens), modeKey, "response")
+	if stats.Requests > 0 {
+		m.ollamaRequests.Add(float64(stats.Requests), model)
+	}
+	if stats.ResponseTokens > 0 && elapsed > 0 {
+		m.tokensPerSecond.Observe(float64(stats.ResponseTokens)/elapsed.Seconds(), modeKey)
+	}
+}
+
+// instrument counts requests and their latency by route pattern
+func (m *serveMetrics) instrument(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		mux.ServeHTTP(rec, r)
+
+		// The mux records the pattern it matched on the request
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		m.httpRequests.Inc(route, strconv.Itoa(rec.status))
+		m.httpDuration.Observe(time.Since(start).Seconds(), route)
+	})
+}
+
+func (m *serveMetrics) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.registry.Write(w)
+}
+
+// statusRecorder remembers the status code of a response while still supporting the
+// flushing that server-sent events need
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/progress"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// maxTitlesPerBrowse bounds how many untitled sessions get a title each time the session
+// browser opens, so a project with many old sessions doesn't keep it waiting
+const maxTitlesPerBrowse = 5
+
+type sessionPickerModel struct {
+	project  string
+	sessions []session.Summary
+	current  string
+	cursor   int
+	selected bool
+}
+
+func (m sessionPickerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m sessionPickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "down", "j":
+			if m.cursor < len(m.sessions)-1 {
+				m.cursor++
+			}
+
+		case "enter":
+			if len(m.sessions) > 0 {
+				m.selected = true
+				return m, tea.Quit
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m sessionPickerModel) View() string {
+	var s strings.Builder
+
+	s.WriteString(fmt.Sprintf("\n\033[1;38;5;205m💬 Sessions in %s\033[0m\n\n", m.project))
+
+	if len(m.sessions) == 0 {
+		s.WriteString("\033[38;5;240mNo saved sessions yet. Start one with --session <name> or /sessions <name>.\033[0m\n\n")
+		s.WriteString("\033[38;5;240mPress q to go back\033[0m\n")
+		return s.String()
+	}
+
+	for i, summary := range m.sessions {
+		title := sessionTitle(summary)
+		if summary.Name == m.current {
+			title += " (current)"
+		}
+		if m.cursor == i {
+			s.WriteString("> \033[1;38;5;170m" + title + "\033[0m\n")
+		} else {
+			s.WriteString("  " + title + "\n")
+		}
+		name := summary.Name
+		if name == "" {
+			name = "default"
+		}
+		s.WriteString(fmt.Sprintf("  \033[38;5;240m%s · %d messages · %s\033[0m\n", name, summary.Messages, formatAge(summary.UpdatedAt)))
+	}
+
+	s.WriteString("\n\033[38;5;240mPress Enter to switch, q to go back\033[0m\n")
+
+	return s.String()
+}
+
+// sessionTitle is the title shown for a session in the browser
+func sessionTitle(summary session.Summary) string {
+	switch {
+	case summary.Title != "":
+		return summary.Title
+	case summary.FirstPrompt != "":
+		return summary.FirstPrompt
+	default:
+		return "(empty)"
+	}
+}
+
+// formatAge describes how long ago t was, e.g. "5m ago"
+func formatAge(t time.Time) string {
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	case age < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
+// runSessionsCommand handles /sessions, which browses the project's sessions, and
+// /sessions <name>, which switches to the named session, starting it if it is new. It
+// returns whether sess now holds another session.
+func runSessionsCommand(cfg *config.Config, client *ollama.Client, sess *session.Session, args string) (bool, error) {
+	if args != "" {
+		return switchSession(sess, args)
+	}
+
+	if err := sess.Save(); err != nil {
+		return false, fmt.Errorf("failed to save session: %w", err)
+	}
+	summaries, err := session.List(sess.ProjectRoot)
+	if err != nil {
+		return false, err
+	}
+	titleSessions(cfg, client, sess, summaries)
+
+	m, err := runProgram(sessionPickerModel{project: filepath.Base(sess.ProjectRoot), sessions: summaries, current: sess.Name})
+	if err != nil {
+		return false, fmt.Errorf("error running session browser: %w", err)
+	}
+	model := m.(sessionPickerModel)
+	if !model.selected {
+		return false, nil
+	}
+	return switchSession(sess, model.sessions[model.cursor].Name)
+}
+
+// titleSessions generates titles for the listed sessions that have a conversation but no
+// title yet, and saves them. It stops at the first failure, e.g. when Ollama is down;
+// those sessions are shown with the start of their first prompt instead.
+func titleSessions(cfg *config.Config, client *ollama.Client, sess *session.Session, summaries []session.Summary) {
+	var pending []int
+	for i, summary := range summaries {
+		if summary.Title == "" && summary.Messages >= 2 && len(pending) < maxTitlesPerBrowse {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	s := progress.NewSpinner()
+	s.Suffix = fmt.Sprintf(" Titling %d session(s)...", len(pending))
+	s.Start()
+	defer s.Stop()
+	for _, i := range pending {
+		target := sess
+		if summaries[i].Name != sess.Name {
+			loaded, err := session.LoadNamed(sess.ProjectRoot, summaries[i].Name)
+			if err != nil {
+				continue
+			}
+			target = loaded
+		}
+		title, err := modes.GenerateTitle(client, cfg, target)
+		if err != nil {
+			return
+		}
+		target.Title = title
+		if err := target.Save(); err != nil {
+			continue
+		}
+		summaries[i].Title = title
+	}
+}
+
+// switchSession saves sess and replaces it with the session called name, which is the
+// project's default session when name is empty
+func switchSession(sess *session.Session, name string) (bool, error) {
+	if name == sess.Name {
+		fmt.Println("\033[38;5;240mAlready in this session\033[0m")
+		return false, nil
+	}
+	if name != "" {
+		if err := session.ValidateName(name); err != nil {
+			return false, err
+		}
+	}
+	if err := sess.Save(); err != nil {
+		return false, fmt.Errorf("failed to save session: %w", err)
+	}
+	loaded, err := session.LoadNamed(sess.ProjectRoot, name)
+	if err != nil {
+		return false, err
+	}
+	*sess = *loaded
+	// Later project switches open the same session, as with --session
+	sessionName = name
+
+	label := "the default session"
+	if name != "" {
+		label = fmt.Sprintf("session %q", name)
+	}
+	if len(sess.History) == 0 {
+		fmt.Printf("\033[38;5;10mStarted %s\033[0m\n", label)
+	} else {
+		fmt.Printf("\033[38;5;10mSwitched to %s: %s (%d messages)\033[0m\n", label, sessionTitle(session.Summary{Title: sess.Title, FirstPrompt: sess.FallbackTitle()}), len(sess.History))
+	}
+	return true, nil
+}
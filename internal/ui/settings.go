@@ -5,6 +5,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
 )
 
 type settingsModel struct {
@@ -81,7 +82,7 @@ func RunSettings(cfg *config.Config) error {
 	settings := []settingItem{
 		{
 			name:        "Debug Mode",
-			description: "Show detailed request/response logs from Ollama",
+			description: "Log request details, without prompt text, at --log-level debug",
 			getValue: func(c *config.Config) string {
 				if c.Ollama.Debug {
 					return "\033[1;32mEnabled\033[0m"
@@ -92,6 +93,19 @@ func RunSettings(cfg *config.Config) error {
 				c.Ollama.Debug = !c.Ollama.Debug
 			},
 		},
+		{
+			name:        "Default Mode",
+			description: "Mode used for input typed without a /command (last = keep the last-used mode, auto = pick per input)",
+			getValue: func(c *config.Config) string {
+				if c.UI.DefaultMode == "" {
+					return "last"
+				}
+				return c.UI.DefaultMode
+			},
+			toggle: func(c *config.Config) {
+				c.UI.DefaultMode = nextOption(defaultModeOptions(c), c.UI.DefaultMode)
+			},
+		},
 	}
 
 	m := settingsModel{
@@ -100,7 +114,21 @@ func RunSettings(cfg *config.Config) error {
 		settings: settings,
 	}
 
-	p := tea.NewProgram(m, tea.WithAltScreen())
-	_, err := p.Run()
+	_, err := runProgram(m)
 	return err
 }
+
+// defaultModeOptions returns the choices for ui.default_mode: last, auto or any mode
+func defaultModeOptions(cfg *config.Config) []string {
+	return append([]string{"last", "auto"}, modes.NewRegistry(cfg).Keys()...)
+}
+
+// nextOption returns the option after current, wrapping around (unknown values restart at the first option)
+func nextOption(options []string, current string) string {
+	for i, option := range options {
+		if option == current {
+			return options[(i+1)%len(options)]
+		}
+	}
+	return options[0]
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/secrets"
+	"github.com/yourusername/llamasidekick/internal/session"
+	"github.com/yourusername/llamasidekick/internal/share"
+)
+
+// runShareCommand handles /share [--gist] [--diffs] [file.html]: it exports the
+// conversation, optionally with the diffs of the files changed this run, as a standalone
+// HTML file or a secret gist
+func runShareCommand(cfg *config.Config, sess *session.Session, args string) error {
+	gist, withDiffs, path := false, false, ""
+	for _, arg := range strings.Fields(args) {
+		switch arg {
+		case "--gist":
+			gist = true
+		case "--diffs":
+			withDiffs = true
+		default:
+			if strings.HasPrefix(arg, "-") || path != "" {
+				return fmt.Errorf("usage: /share [--gist] [--diffs] [file.html]")
+			}
+			path = arg
+		}
+	}
+	if gist && path != "" {
+		return fmt.Errorf("a gist has no file name; use /share --gist or /share <file.html>")
+	}
+	if len(sess.History) == 0 {
+		return fmt.Errorf("nothing to share yet")
+	}
+
+	diffs := ""
+	if withDiffs {
+		store, err := modes.OpenBackupStore(cfg)
+		if err != nil {
+			return err
+		}
+		diffs = share.ChangeDiffs(store, sess.ProjectRoot, sess.Changes)
+		if diffs == "" {
+			fmt.Println("\033[38;5;240mNo files were changed this run; sharing the conversation only\033[0m")
+		}
+	}
+
+	markdown := share.Markdown(sess, diffs)
+	if findings := secrets.Scan(markdown); len(findings) > 0 {
+		fmt.Println("\033[38;5;214mThe conversation looks like it contains secrets:\033[0m")
+		for _, f := range findings {
+			fmt.Printf("  %s: %s\n", f.Kind, f.Match)
+		}
+		fmt.Print("Share it anyway? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Println("Not shared")
+			return nil
+		}
+	}
+
+	if gist {
+		token, err := cfg.GitHubToken()
+		if err != nil {
+			return err
+		}
+		url, err := share.CreateGist(share.Title(sess), map[string]string{"conversation.md": markdown}, token)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\033[1;32m✓ Shared as a secret gist: %s\033[0m\n", url)
+		return nil
+	}
+
+	page, err := share.HTML(sess, diffs)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		path = "llamasidekick-" + time.Now().Format("20060102-150405") + ".html"
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(sess.ProjectRoot, path)
+	}
+	if err := os.WriteFile(path, []byte(page), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("\033[1;32m✓ Wrote %s\033[0m\n", path)
+	return nil
+}
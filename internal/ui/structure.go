@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/index"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/ollama"
+	"github.com/yourusername/llamasidekick/internal/session"
+)
+
+// runStructureCommand handles /where <symbol> and /callers <function> [--explain]: it
+// lists declarations or call sites found by parsing the project, and with --explain
+// passes them to Ask mode for an explanation
+func runStructureCommand(cfg *config.Config, client *ollama.Client, sess *session.Session, command, args string) error {
+	explain := false
+	if rest, ok := strings.CutSuffix(args, "--explain"); ok {
+		explain, args = true, strings.TrimSpace(rest)
+	}
+	if args == "" || strings.ContainsAny(args, " \t") {
+		if command == "/where" {
+			return fmt.Errorf("usage: /where <symbol> [--explain]")
+		}
+		return fmt.Errorf("usage: /callers <function> [--explain]")
+	}
+
+	var s *index.Structure
+	var locations []index.Location
+	var err error
+	if command == "/where" {
+		s, locations, err = modes.FindDeclarations(sess, cfg, args)
+	} else {
+		s, locations, err = modes.FindCallers(sess, cfg, args)
+	}
+	if err != nil {
+		return err
+	}
+	if len(locations) == 0 {
+		if command == "/where" {
+			fmt.Printf("\033[38;5;240mNo declaration of %s found\033[0m\n", args)
+		} else {
+			fmt.Printf("\033[38;5;240mNo calls of %s found\033[0m\n", args)
+		}
+		return nil
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(modes.FormatLocations(locations), "\n"), "\n") {
+		if location, rest, ok := strings.Cut(line, "  "); ok {
+			fmt.Printf("\033[1;38;5;75m%s\033[0m  %s\n", location, rest)
+		} else {
+			fmt.Printf("\033[38;5;240m%s\033[0m\n", line)
+		}
+	}
+	fmt.Printf("\033[38;5;240m%d result(s)\033[0m\n", len(locations))
+
+	if !explain {
+		return nil
+	}
+	fmt.Println()
+	return (&modes.AskMode{}).ProcessInput(client, sess, cfg, modes.ExplainLocationsPrompt(s, command+" "+args, locations))
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/yourusername/llamasidekick/internal/hint"
+	"github.com/yourusername/llamasidekick/internal/session"
+	"github.com/yourusername/llamasidekick/internal/tasks"
+)
+
+// runTasksCommand handles /tasks: it lists the tasks of the project's Makefile, Taskfile
+// and justfile with the commands that run them
+func runTasksCommand(sess *session.Session) error {
+	files, err := tasks.Load(sess.ProjectRoot)
+	if err != nil {
+		hint.Warn(err)
+	}
+	if len(files) == 0 {
+		if err == nil {
+			fmt.Println("\033[38;5;240mNo tasks found; the project has no Makefile, Taskfile or justfile with targets.\033[0m")
+		}
+		return nil
+	}
+	for _, f := range files {
+		fmt.Printf("\033[1m%s\033[0m\n", f.Name)
+		for _, t := range f.Tasks {
+			line := "  " + f.Command(t.Name)
+			if t.Description != "" {
+				line += " \033[38;5;240m- " + t.Description + "\033[0m"
+			}
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
@@ -1,12 +1,23 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
 
+	"github.com/yourusername/llamasidekick/internal/completion"
 	"github.com/yourusername/llamasidekick/internal/config"
+	"github.com/yourusername/llamasidekick/internal/exitcode"
+	"github.com/yourusername/llamasidekick/internal/hint"
+	"github.com/yourusername/llamasidekick/internal/logging"
+	"github.com/yourusername/llamasidekick/internal/modes"
+	"github.com/yourusername/llamasidekick/internal/session"
 	"github.com/yourusername/llamasidekick/internal/ui"
+	"golang.org/x/term"
 )
 
 var (
@@ -15,28 +26,388 @@ var (
 	date    = "unknown"
 )
 
+// usageCommands lists the commands shown by --help
+var usageCommands = [][2]string{
+	{"<mode> <prompt>", "Run one prompt in ask, plan, edit, agent, cmd or a custom mode and exit"},
+	{"config edit", "Open config.yaml in $EDITOR and validate it"},
+	{"secret set <name>", "Store a secret in the OS keyring (\"ollama\" sets ollama.api_key, \"github\" share.github_token)"},
+	{"secret delete <name>", "Remove a secret from the OS keyring"},
+	{"batch <file>", "Run the prompts listed in a YAML batch file (-restart, -delay 2s)"},
+	{"eval [file]", "Check how the configured models do with each mode on golden prompts (-mode edit, -model name)"},
+	{"hook install [--force]", "Install a git pre-commit hook that reviews staged changes"},
+	{"hook uninstall", "Remove the pre-commit hook"},
+	{"index build", "Embed the project's files into a local semantic index (updates only changed files)"},
+	{"index status", "Show the size and freshness of the project's index"},
+	{"serve [address]", "Start a local HTTP API (default 127.0.0.1:7878)"},
+	{"rpc [address]", "Start a JSON-RPC server for editor plugins (default 127.0.0.1:7879, or unix:<path>)"},
+	{"completion <shell>", "Print a completion script for bash, zsh, fish or powershell"},
+}
+
 func main() {
+	os.Exit(run())
+}
+
+// run executes the program and returns its exit code, so deferred cleanup runs first
+func run() int {
 	versionFlag := flag.Bool("version", false, "Print version information")
 	vFlag := flag.Bool("v", false, "Print version information (short)")
+	projectsFlag := flag.Bool("projects", false, "Pick a recent project to open on startup")
+	outputFlag := flag.String("output", "text", "Output format for one-shot prompts: text or json")
+	modelFlag := flag.String("model", "", "Model to use for every mode (overrides the config for this run)")
+	hostFlag := flag.String("host", "", "Ollama URL (overrides ollama.host for this run)")
+	profileFlag := flag.String("profile", "", "Apply a named profile from the config's profiles section")
+	projectRootFlag := flag.String("project-root", "", "Project directory to work in instead of the current directory")
+	noStreamFlag := flag.Bool("no-stream", false, "Show responses only once they are complete")
+	logLevelFlag := flag.String("log-level", "", "Log level for this run: debug, info, warn, error or off")
+	readOnlyFlag := flag.Bool("read-only", false, "Never write files or call MCP tools; show proposed changes as diffs")
+	resumeFlag := flag.Bool("resume", false, "Go straight back into the last mode and conversation of this project")
+	sessionFlag := flag.String("session", "", "Use a named session of this project instead of the default one")
+	accessibleFlag := flag.Bool("accessible", false, "Screen-reader-friendly output: no spinners, emoji, box drawing or full-screen menus")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: llamasidekick [flags] [command]\n\nCommands:\n")
+		for _, c := range usageCommands {
+			fmt.Fprintf(os.Stderr, "  %-24s%s\n", c[0], c[1])
+		}
+		fmt.Fprintf(os.Stderr, "\nFlags:\n")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	// Redirected output, and Windows consoles that can't show ANSI colors, get plain
+	// markdown without colors or spinners
+	finishOutput := func() {}
+	if !term.IsTerminal(int(os.Stdout.Fd())) || !ui.EnableVirtualTerminal() {
+		finishOutput = ui.StartPlainOutput()
+	}
+	defer func() { finishOutput() }()
+
 	if *versionFlag || *vFlag {
 		fmt.Printf("LlamaSidekick %s\n", version)
 		fmt.Printf("Commit: %s\n", commit)
 		fmt.Printf("Built: %s\n", date)
-		os.Exit(0)
+		return exitcode.OK
 	}
 
 	// Initialize config
 	cfg, err := config.Load()
 	if err != nil {
+		if args := flag.Args(); len(args) == 2 && args[0] == "config" && args[1] == "edit" {
+			// The command for fixing the config has to open one that doesn't load
+			fmt.Fprintf(os.Stderr, "\033[38;5;214mThe config doesn't load: %v\033[0m\n", err)
+			if err := runConfigEdit(nil); err != nil {
+				hint.Fprint(os.Stderr, err)
+				return exitcode.For(err)
+			}
+			return exitcode.OK
+		}
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-		os.Exit(1)
+		return exitcode.Validation
 	}
 
-	// Start the UI
-	if err := ui.Run(cfg, version); err != nil {
+	// Per-invocation overrides; these are never saved to the config file, and the UI
+	// applies them again whenever it reloads the config
+	overrides := config.Overrides{
+		Profile:    *profileFlag,
+		Host:       *hostFlag,
+		Model:      *modelFlag,
+		ReadOnly:   *readOnlyFlag,
+		NoStream:   *noStreamFlag,
+		Accessible: *accessibleFlag,
+		LogLevel:   *logLevelFlag,
+	}
+	if err := cfg.ApplyOverrides(overrides); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitcode.Validation
+	}
+	ui.UseOverrides(overrides)
+	if cfg.UI.Accessible {
+		finishOutput()
+		finishOutput = ui.StartAccessibleOutput()
+	}
+	if _, err := logging.Setup(logging.Options{Level: cfg.Logging.Level, Format: cfg.Logging.Format, File: cfg.Logging.File}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: logging disabled: %v\n", err)
+	}
+	slog.Info("starting", "version", version, "flags", setFlags(), "args", len(flag.Args()))
+	if *projectRootFlag != "" {
+		// Everything (file loading, safe writes, the session) is anchored at the working directory
+		if info, err := os.Stat(*projectRootFlag); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Error: --project-root %s is not a directory\n", *projectRootFlag)
+			return exitcode.Usage
+		}
+		if err := os.Chdir(*projectRootFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to enter project root: %v\n", err)
+			return exitcode.Error
+		}
+	}
+
+	if *sessionFlag != "" {
+		if err := session.ValidateName(*sessionFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitcode.Usage
+		}
+		ui.UseSession(*sessionFlag)
+	}
+
+	if args := flag.Args(); len(args) > 0 {
+		if *resumeFlag {
+			fmt.Fprintf(os.Stderr, "Error: --resume starts the interactive UI and can't be combined with a command\n")
+			return exitcode.Usage
+		}
+		// Ctrl+C ends a command with its own exit code instead of a bare signal death
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt)
+		go func() {
+			<-interrupts
+			fmt.Fprintln(os.Stderr, "\nAborted")
+			finishOutput()
+			os.Exit(exitcode.Aborted)
+		}()
+
+		if err := runCommand(cfg, args, *outputFlag); err != nil {
+			hint.Fprint(os.Stderr, err)
+			return exitcode.For(err)
+		}
+		return exitcode.OK
+	}
+
+	// Validate config before anything talks to Ollama
+	if err := cfg.Validate(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return exitcode.Validation
+	}
+	for _, key := range config.UnknownKeys() {
+		fmt.Fprintf(os.Stderr, "Warning: unknown config key %q (check for typos)\n", key)
+	}
+	if cfg.Ollama.APIKey != "" && !config.IsSecretReference(cfg.Ollama.APIKey) {
+		fmt.Fprintf(os.Stderr, "Warning: ollama.api_key is stored in plain text; move it to the OS keyring with: llamasidekick secret set ollama\n")
+	}
+	if cfg.Share.GitHubToken != "" && !config.IsSecretReference(cfg.Share.GitHubToken) {
+		fmt.Fprintf(os.Stderr, "Warning: share.github_token is stored in plain text; move it to the OS keyring with: llamasidekick secret set github\n")
+	}
+
+	// Start the UI
+	if err := ui.Run(cfg, version, ui.RunOptions{PickProject: *projectsFlag, Resume: *resumeFlag}); err != nil {
+		hint.Fprint(os.Stderr, err)
+		return exitcode.For(err)
+	}
+	return exitcode.OK
+}
+
+// runCommand handles non-interactive subcommands such as "config edit"
+func runCommand(cfg *config.Config, args []string, output string) error {
+	switch {
+	case len(args) >= 2 && isModeName(cfg, args[0]):
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		return ui.RunOneShot(cfg, args[0], strings.Join(args[1:], " "), output)
+	case len(args) == 2 && args[0] == "config" && args[1] == "edit":
+		return runConfigEdit(cfg)
+	case len(args) == 3 && args[0] == "secret" && args[1] == "set":
+		return runSecretSet(args[2])
+	case len(args) == 3 && args[0] == "secret" && args[1] == "delete":
+		if err := config.DeleteSecret(args[2]); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Removed %s from the OS keyring\n", args[2])
+		return nil
+	case args[0] == "batch":
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		return runBatch(cfg, args[1:], output)
+	case args[0] == "eval":
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		return runEval(cfg, args[1:], output)
+	case len(args) >= 2 && len(args) <= 3 && args[0] == "hook" && args[1] == "install":
+		force := len(args) == 3 && (args[2] == "--force" || args[2] == "-force")
+		if len(args) == 3 && !force {
+			return fmt.Errorf("%w: llamasidekick hook install [--force]", exitcode.ErrUsage)
+		}
+		return ui.InstallHook(force)
+	case len(args) == 2 && args[0] == "hook" && args[1] == "uninstall":
+		return ui.UninstallHook()
+	case len(args) == 3 && args[0] == "hook" && args[1] == "run" && args[2] == "pre-commit":
+		return ui.RunPreCommitHook(cfg)
+	case len(args) == 2 && args[0] == "index" && args[1] == "build":
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		return ui.RunIndexBuild(cfg)
+	case len(args) == 2 && args[0] == "index" && args[1] == "status":
+		return ui.RunIndexStatus(cfg)
+	case len(args) <= 2 && args[0] == "serve":
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		addr := ""
+		if len(args) == 2 {
+			addr = args[1]
+		}
+		return ui.RunServe(cfg, version, addr)
+	case len(args) <= 2 && args[0] == "rpc":
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		addr := ""
+		if len(args) == 2 {
+			addr = args[1]
+		}
+		return ui.RunRPC(cfg, version, addr)
+	case len(args) == 2 && args[0] == "completion":
+		script, err := completion.Script(args[1], completionFlags())
+		if err != nil {
+			return err
+		}
+		fmt.Print(script)
+		return nil
+	case len(args) == 2 && args[0] == "__complete":
+		// Hidden helper the completion scripts call for dynamic values
+		for _, value := range completeValues(cfg, args[1]) {
+			fmt.Println(value)
+		}
+		return nil
+	default:
+		flag.Usage()
+		return fmt.Errorf("%w: unknown command: %s", exitcode.ErrUsage, strings.Join(args, " "))
+	}
+}
+
+// runConfigEdit runs "config edit"; cfg is nil when the config file doesn't load
+func runConfigEdit(cfg *config.Config) error {
+	if _, err := ui.RunConfigEdit(cfg); err != nil {
+		return err
+	}
+	fmt.Println("✓ Config saved and validated")
+	return nil
+}
+
+// runBatch parses the batch command's own flags and runs the batch file
+func runBatch(cfg *config.Config, args []string, output string) error {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	restart := fs.Bool("restart", false, "Run every item again instead of resuming")
+	delay := fs.String("delay", "", "Pause between items, e.g. 2s (overrides the batch file)")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", exitcode.ErrUsage, err)
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: llamasidekick batch [-restart] [-delay 2s] <file>", exitcode.ErrUsage)
+	}
+	return ui.RunBatch(cfg, fs.Arg(0), ui.BatchOptions{Restart: *restart, Delay: *delay, Output: output})
+}
+
+// runEval parses the eval command's own flags and runs the suite, or the built-in one
+func runEval(cfg *config.Config, args []string, output string) error {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	mode := fs.String("mode", "", "Run only the cases of this mode")
+	model := fs.String("model", "", "Model to evaluate for every mode (same as the global --model)")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", exitcode.ErrUsage, err)
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("%w: llamasidekick eval [-mode name] [-model name] [file]", exitcode.ErrUsage)
+	}
+	if *model != "" {
+		if err := cfg.ApplyOverrides(config.Overrides{Model: *model}); err != nil {
+			return err
+		}
+	}
+	return ui.RunEval(cfg, fs.Arg(0), ui.EvalOptions{Mode: *mode, Output: output})
+}
+
+// secretConfigKeys are the config keys set by secret set for well-known secret names
+var secretConfigKeys = map[string]string{
+	"ollama": "ollama.api_key",
+	"github": "share.github_token",
+}
+
+// runSecretSet reads a secret from the terminal without echoing it and stores it in the OS keyring
+func runSecretSet(name string) error {
+	fmt.Printf("Enter value for %s: ", name)
+	var secret string
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		data, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			return fmt.Errorf("failed to read secret: %w", err)
+		}
+		secret = string(data)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read secret: %w", err)
+		}
+		secret = line
+	}
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return fmt.Errorf("secret is empty")
+	}
+
+	ref, err := config.StoreSecret(name, secret)
+	if err != nil {
+		return err
+	}
+	if key, ok := secretConfigKeys[name]; ok {
+		if err := config.SetValue(key, ref); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Stored in the OS keyring and set %s to %s\n", key, ref)
+		return nil
+	}
+	fmt.Printf("✓ Stored in the OS keyring. Reference it in config.yaml as: %s\n", ref)
+	return nil
+}
+
+// isModeName reports whether name is a mode that can run a one-shot prompt, built in or
+// from custom_modes
+func isModeName(cfg *config.Config, name string) bool {
+	_, ok := modes.NewRegistry(cfg).Lookup(name)
+	return ok
+}
+
+// setFlags returns the names of the flags given on the command line, without their
+// values, which may hold a prompt
+func setFlags() []string {
+	var names []string
+	flag.Visit(func(f *flag.Flag) { names = append(names, f.Name) })
+	return names
+}
+
+// completionFlags describes the registered command-line flags for completion scripts
+func completionFlags() []completion.Flag {
+	var flags []completion.Flag
+	flag.VisitAll(func(f *flag.Flag) {
+		if f.Name == "v" {
+			return
+		}
+		_, isBool := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completion.Flag{Name: f.Name, Usage: f.Usage, TakesValue: !isBool})
+	})
+	return flags
+}
+
+// completeValues lists the values offered for a "__complete" query. It never contacts
+// Ollama: models come from the cache written on startup, falling back to the config.
+func completeValues(cfg *config.Config, kind string) []string {
+	switch kind {
+	case "models":
+		if models := completion.CachedModels(); len(models) > 0 {
+			return models
+		}
+		seen := map[string]bool{}
+		var models []string
+		for _, e := range modes.NewRegistry(cfg).Entries() {
+			if name := cfg.GetModelForMode(e.Key); !seen[name] {
+				seen[name] = true
+				models = append(models, name)
+			}
+		}
+		return models
+	case "profiles":
+		return cfg.ProfileNames()
 	}
+	return nil
 }